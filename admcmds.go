@@ -25,8 +25,19 @@ func AdmNewDB(w http.ResponseWriter, r *http.Request, xbiz *rlib.XBusiness, ui *
 
 // CreateDBBackupFileList returns a string table of backup files and timestamps
 func CreateDBBackupFileList() string {
+	t, err := DBBackupFileListTable()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "no backup files"
+		}
+		return "Error reading Database Backup directory: " + err.Error() + "\n"
+	}
+	return t.String()
+}
+
+// DBBackupFileListTable returns a table of backup files and timestamps
+func DBBackupFileListTable() (gotable.Table, error) {
 	var t gotable.Table
-	errmsg := ""
 	t.Init()
 	t.AddColumn("Filename", 30, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
 	t.AddColumn("Modified", 23, gotable.CELLDATETIME, gotable.COLJUSTIFYLEFT)
@@ -34,12 +45,7 @@ func CreateDBBackupFileList() string {
 	t.SetTitle("Database Backup Files\n\n")
 	files, err := ioutil.ReadDir("./bkup")
 	if err != nil {
-		if os.IsNotExist(err) {
-			errmsg += "no backup files"
-			return errmsg
-		}
-		errmsg += "Error reading Database Backup directory: " + err.Error() + "\n"
-		return errmsg
+		return t, err
 	}
 	for _, file := range files {
 		t.AddRow()
@@ -47,7 +53,7 @@ func CreateDBBackupFileList() string {
 		t.Putdt(-1, 1, file.ModTime())
 		t.Puti(-1, 2, file.Size())
 	}
-	return t.String()
+	return t, nil
 }
 
 // AdmBkup is the HTTP handler for Backing up a database
@@ -0,0 +1,227 @@
+// rrarchive moves a business from one RentRoll database to another. It
+// exports everything that belongs to a business into an archive file, and
+// imports an archive as a new business. The ids of the imported records are
+// assigned by the target database, all references between them are updated
+// to match.  It can also make a new business with just the configuration of
+// an existing one.
+//
+// Examples:
+// 		rrarchive -x REX -f rex.jsonl
+// 		rrarchive -i rex.jsonl -G REX2
+// 		rrarchive -c REX -G REX3 -n "Rexford West" -s 365
+package main
+
+import (
+	"database/sql"
+	"extres"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"rentroll/rlib"
+	"sort"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// App is the global application structure
+var App struct {
+	dbdir  *sql.DB // phonebook db
+	dbrr   *sql.DB //rentroll db
+	DBDir  string  // phonebook database
+	DBRR   string  //rentroll database
+	DBUser string  // user for all databases
+	Export string  // BUD of the business to export
+	Import string  // archive to import
+	File   string  // archive written by an export, "" means stdout
+	Clone  string  // BUD of the template business to clone
+	BUD    string  // business unit designator for an imported or cloned business
+	Name   string  // name of a cloned business
+	Shift  int     // days to move the market rates of a cloned business by
+}
+
+func readCommandLineArgs() {
+	dbuPtr := flag.String("B", "ec2-user", "database user name")
+	clonePtr := flag.String("c", "", "clone the configuration of the business with this BUD, -G is the new BUD")
+	filePtr := flag.String("f", "", "write the export to this file instead of stdout")
+	pBUD := flag.String("G", "", "BUD for the imported business, default is the BUD in the archive")
+	impPtr := flag.String("i", "", "import the business in this archive file")
+	dbrrPtr := flag.String("M", "rentroll", "database name (rentroll)")
+	dbnmPtr := flag.String("N", "accord", "directory database (accord)")
+	namePtr := flag.String("n", "", "name of the cloned business, default is the template's name")
+	shiftPtr := flag.Int("s", 0, "number of days to move the market rates of a cloned business by")
+	verPtr := flag.Bool("v", false, "prints the version to stdout")
+	expPtr := flag.String("x", "", "export the business with this BUD")
+	noconPtr := flag.Bool("nocon", false, "if specified, inhibit Console output")
+
+	flag.Parse()
+	if *verPtr {
+		fmt.Printf("Version:    %s\nBuild Time: %s\n", GetVersionNo(), GetBuildTime())
+		os.Exit(0)
+	}
+	if *noconPtr {
+		rlib.DisableConsole()
+	} else {
+		rlib.EnableConsole()
+	}
+
+	App.DBDir = *dbnmPtr
+	App.DBRR = *dbrrPtr
+	App.DBUser = *dbuPtr
+	App.Export = strings.TrimSpace(*expPtr)
+	App.Import = strings.TrimSpace(*impPtr)
+	App.File = strings.TrimSpace(*filePtr)
+	App.Clone = strings.TrimSpace(*clonePtr)
+	App.BUD = strings.TrimSpace(*pBUD)
+	App.Name = *namePtr
+	App.Shift = *shiftPtr
+
+	n := 0
+	for _, s := range []string{App.Export, App.Import, App.Clone} {
+		if len(s) > 0 {
+			n++
+		}
+	}
+	if n != 1 {
+		fmt.Printf("Please specify one of -x BUD to export a business, -i filename to import one, or -c BUD to clone one\n")
+		os.Exit(1)
+	}
+	if len(App.Clone) > 0 && len(App.BUD) == 0 {
+		fmt.Printf("Please specify the BUD of the cloned business with -G\n")
+		os.Exit(1)
+	}
+}
+
+// doExport writes the archive of the business App.Export
+func doExport() {
+	b := rlib.GetBusinessByDesignation(App.Export)
+	if b.BID == 0 {
+		fmt.Printf("Could not find Business Unit named %s\n", App.Export)
+		os.Exit(1)
+	}
+	var w io.Writer = os.Stdout
+	if len(App.File) > 0 {
+		f, err := os.Create(App.File)
+		if err != nil {
+			fmt.Printf("Could not create %s: %s\n", App.File, err.Error())
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	h, err := rlib.ExportBusiness(b.BID, w)
+	if err != nil {
+		fmt.Printf("%s\n", err.Error())
+		os.Exit(1)
+	}
+	if len(App.File) > 0 {
+		fmt.Printf("Exported business %s to %s\n", h.BUD, App.File)
+		printCounts(h.Rows)
+	}
+}
+
+// doImport loads the archive App.Import as a new business
+func doImport() {
+	f, err := os.Open(App.Import)
+	if err != nil {
+		fmt.Printf("Could not open %s: %s\n", App.Import, err.Error())
+		os.Exit(1)
+	}
+	defer f.Close()
+	r, err := rlib.ImportBusiness(f, App.BUD)
+	if err != nil {
+		fmt.Printf("%s\n", err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("Imported business %s, BID = %d\n", r.BUD, r.BID)
+	printCounts(r.Rows)
+	if r.Unresolved > 0 {
+		fmt.Printf("%d references to records that were not in the archive were set to 0\n", r.Unresolved)
+	}
+}
+
+// doClone makes business App.BUD with the configuration of business App.Clone
+func doClone() {
+	b := rlib.GetBusinessByDesignation(App.Clone)
+	if b.BID == 0 {
+		fmt.Printf("Could not find Business Unit named %s\n", App.Clone)
+		os.Exit(1)
+	}
+	o := rlib.CloneOptions{BUD: App.BUD, Name: App.Name, ShiftDays: App.Shift}
+	r, err := rlib.CloneBusiness(b.BID, &o)
+	if err != nil {
+		fmt.Printf("%s\n", err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("Cloned business %s to %s, BID = %d\n", App.Clone, r.BUD, r.BID)
+	printCounts(r.Rows)
+}
+
+// printCounts lists the number of records of each table
+func printCounts(m map[string]int) {
+	var tables []string
+	for k, n := range m {
+		if n > 0 {
+			tables = append(tables, k)
+		}
+	}
+	sort.Strings(tables)
+	for i := 0; i < len(tables); i++ {
+		fmt.Printf("    %-25s %8d\n", tables[i], m[tables[i]])
+	}
+}
+
+func main() {
+	readCommandLineArgs()
+
+	var err error
+
+	//----------------------------
+	// Open RentRoll database
+	//----------------------------
+	if err = rlib.RRReadConfig(); err != nil {
+		fmt.Printf("sql.Open for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
+		os.Exit(1)
+	}
+
+	s := extres.GetSQLOpenString(rlib.AppConfig.RRDbname, &rlib.AppConfig)
+	App.dbrr, err = sql.Open("mysql", s)
+	if nil != err {
+		fmt.Printf("sql.Open for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
+		os.Exit(1)
+	}
+	defer App.dbrr.Close()
+	err = App.dbrr.Ping()
+	if nil != err {
+		fmt.Printf("DBRR.Ping for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
+		os.Exit(1)
+	}
+
+	//----------------------------
+	// Open Phonebook database
+	//----------------------------
+	s = extres.GetSQLOpenString(rlib.AppConfig.Dbname, &rlib.AppConfig)
+	App.dbdir, err = sql.Open("mysql", s)
+	if nil != err {
+		fmt.Printf("sql.Open: Error = %v\n", err)
+		os.Exit(1)
+	}
+	err = App.dbdir.Ping()
+	if nil != err {
+		fmt.Printf("dbdir.Ping: Error = %v\n", err)
+		os.Exit(1)
+	}
+
+	rlib.RpnInit()
+	rlib.InitDBHelpers(App.dbrr, App.dbdir)
+
+	switch {
+	case len(App.Export) > 0:
+		doExport()
+	case len(App.Import) > 0:
+		doImport()
+	default:
+		doClone()
+	}
+}
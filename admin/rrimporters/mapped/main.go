@@ -0,0 +1,281 @@
+/*
+
+===============
+MAPPED IMPORTER
+===============
+This is main program which is entry point for the mapping driven importer.
+It imports the rent roll csv export of any property management system that
+has a mapping file, see core.Mapping. The mappings shipped with rentroll are
+in the mappings folder next to this program.
+
+This program performs following things
+================================
+> Parse command line arguments
+> Setup log file
+> Check to create csv store
+> Load the mapping
+> Database initiliazation
+> Validate supplied values
+> Call mapped csv handler with required args
+> Print the report, output
+
+Command Line Arguments
+=====================
+1. bud (required) (business unit designation)
+2. csv (required) (rent roll csv)
+3. map (required) (mapping name, such as appfolio, or path of a mapping json file)
+4. lookup (optional) (the second file of mappings that have one, such as a guest export)
+5. testmode (optional) (testmode doesn't clear temp files, right now!)
+6. debug (optional) (debug used for to debug the records, been inspected from rcsv reports)
+7. dryrun (optional) (run the whole import and report it, but save nothing)
+8. frequency (optional) (rent cycle frequency)
+(
+    0: one time only | 1: secondly | 2: minutely | 3: hourly |
+    4: daily | 5: weekly | 6: monthly | 7: quarterly | 8: yearly |
+)
+9. proration (optional) (proration cycle)
+10. gsrpc (optional) (GSRPC)
+
+*/
+
+package main
+
+import (
+	"database/sql"
+	"extres"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"phonebook/lib"
+	"rentroll/importers/core"
+	"rentroll/rlib"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/kardianos/osext"
+)
+
+// tempCSVStoreName holds the name of csvstore folder
+const tempCSVStoreName = "temp_CSVs"
+
+// mappingsFolder holds the name of the folder with the shipped mappings
+const mappingsFolder = "mappings"
+
+// App is the global application structure used for the mapped csv importer
+var App struct {
+	dbdir    *sql.DB  // phonebook db
+	dbrr     *sql.DB  // rentroll db
+	DBDir    string   // phonebook database
+	DBRR     string   // rentroll database
+	DBUser   string   // user for all databases
+	LogFile  *os.File // where to log messages
+	TestMode int      // used for test purpose?
+	CSV      string   // csv filename that needs to be load
+	Map      string   // mapping name or file
+	Lookup   string   // lookup file of the mapping
+	debug    int      // debug records
+	DryRun   int      // validate and report only, save nothing
+}
+
+// userRRValues holds the values passed by user for rentroll attributes,
+// core.MappedDefaultValues supplies the ones that are blank
+var userRRValues = make(map[string]string)
+
+func readCommandLineArgs() []string {
+	inputErrors := []string{}
+	// a csv file must be passed
+	fp := flag.String("csv", "", "the name of the rent roll CSV file to import")
+	// a bud must be passed
+	bud := flag.String("bud", "", "A business unit designation")
+	// a mapping must be passed
+	mp := flag.String("map", "", "mapping name (appfolio) or mapping json file")
+	// the lookup file is optional
+	lookup := flag.String("lookup", "", "the lookup file of the mapping, such as a guest export")
+	// frequency should default to monthly
+	frequency := flag.String("frequency", "", "Rent Cycle")
+	// proration should default to daily
+	proration := flag.String("proration", "", "Proration Cycle")
+	// gsrpc should default to daily
+	gsrpc := flag.String("gsrpc", "", "GSRPC")
+	// is it for testing purpose
+	testmode := flag.Int("testmode", 0, "testing")
+	// is it for debug purpose
+	debug := flag.Int("debug", 0, "debug Records")
+	// dry run validates and reports without saving anything
+	dryrun := flag.Int("dryrun", 0, "validate and report, but do not save anything")
+	// parse db options
+	dbuPtr := flag.String("B", "ec2-user", "database user name")
+	dbrrPtr := flag.String("M", "rentroll", "database name (rentroll)")
+	dbnmPtr := flag.String("N", "accord", "directory database (accord)")
+
+	flag.Parse()
+
+	if *fp == "" {
+		inputErrors = append(inputErrors, "Please, pass rent roll csv input file")
+	}
+	if *bud == "" {
+		inputErrors = append(inputErrors, "Please, pass business unit designation")
+	}
+	if *mp == "" {
+		inputErrors = append(inputErrors, "Please, pass the mapping of the csv file")
+	}
+	if len(inputErrors) > 0 {
+		return inputErrors
+	}
+
+	// App structure values
+	App.DBDir = *dbnmPtr
+	App.DBRR = *dbrrPtr
+	App.DBUser = *dbuPtr
+	App.TestMode = *testmode
+	App.CSV = *fp
+	App.Map = *mp
+	App.Lookup = *lookup
+	App.debug = *debug
+	App.DryRun = *dryrun
+
+	// get user values
+	userRRValues["RentCycle"] = *frequency
+	userRRValues["Proration"] = *proration
+	userRRValues["GSRPC"] = *gsrpc
+	userRRValues["BUD"] = *bud
+
+	return inputErrors
+}
+
+// mappingFile returns the path of the mapping named by the -map option. A
+// name without the .json extension refers to one of the shipped mappings.
+func mappingFile(folderPath, name string) string {
+	if strings.HasSuffix(name, ".json") {
+		return name
+	}
+	return path.Join(folderPath, mappingsFolder, name+".json")
+}
+
+func main() {
+
+	// ================================
+	// COMMAND LINE OPTIONS VALIDATION
+	// ================================
+	inputErrors := readCommandLineArgs()
+	if len(inputErrors) > 0 {
+		for _, errText := range inputErrors {
+			fmt.Println(errText)
+		}
+		os.Exit(1)
+	}
+
+	// ==========================================================
+	// INITIAL SETUP: CSV TEMP STORAGE, MAPPING, DATABASE CONNECTION, LOG FILE
+	// ==========================================================
+
+	var err error
+
+	// LOGFILE SETUP
+	App.LogFile, err = os.OpenFile("mapped.log", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	lib.Errcheck(err)
+	defer App.LogFile.Close()
+	log.SetOutput(App.LogFile)
+	rlib.Ulog("*********** MAPPED IMPORTER HAS BEEN STARTED *********** \n")
+
+	// CSV STORE CHECK
+	folderPath, err := osext.ExecutableFolder()
+	if err != nil {
+		rlib.Ulog("INTERNAL ERROR <INITIALIZATION>: %s", err.Error())
+		os.Exit(1)
+	}
+	tempCSVStore := path.Join(folderPath, tempCSVStoreName)
+	if _, err := os.Stat(tempCSVStore); os.IsNotExist(err) {
+		os.MkdirAll(tempCSVStore, 0700)
+	}
+
+	// MAPPING
+	m, err := core.LoadMapping(mappingFile(folderPath, App.Map))
+	if err != nil {
+		fmt.Printf("Could not load mapping %s: %s\n", App.Map, err.Error())
+		os.Exit(1)
+	}
+
+	//----------------------------
+	// Open RentRoll database
+	//----------------------------
+	if err = rlib.RRReadConfig(); err != nil {
+		fmt.Printf("sql.Open for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
+		os.Exit(1)
+	}
+
+	s := extres.GetSQLOpenString(rlib.AppConfig.RRDbname, &rlib.AppConfig)
+	App.dbrr, err = sql.Open("mysql", s)
+	if nil != err {
+		fmt.Printf("sql.Open for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
+		os.Exit(1)
+	}
+	defer App.dbrr.Close()
+	err = App.dbrr.Ping()
+	if nil != err {
+		fmt.Printf("DBRR.Ping for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
+		os.Exit(1)
+	}
+
+	//----------------------------
+	// Open Phonebook database
+	//----------------------------
+	s = extres.GetSQLOpenString(rlib.AppConfig.Dbname, &rlib.AppConfig)
+	App.dbdir, err = sql.Open("mysql", s)
+	if nil != err {
+		fmt.Printf("sql.Open: Error = %v\n", err)
+		os.Exit(1)
+	}
+	err = App.dbdir.Ping()
+	if nil != err {
+		fmt.Printf("dbdir.Ping: Error = %v\n", err)
+		os.Exit(1)
+	}
+
+	rlib.RpnInit()
+	rlib.InitDBHelpers(App.dbrr, App.dbdir)
+
+	// ==================================
+	// AFTER DB SETUP DO VALIDATION OVER
+	// USER SUPPLIED VALUES WITH DB VALUES
+	// ==================================
+	for k, v := range core.MappedDefaultValues {
+		if userRRValues[k] == "" {
+			userRRValues[k] = v
+		}
+	}
+	validateErrs, business := core.ValidateUserSuppliedValues(userRRValues)
+	if len(validateErrs) > 0 {
+		for _, err := range validateErrs {
+			fmt.Println(err.Error())
+		}
+		os.Exit(1)
+	}
+
+	// =======================
+	// CALL MAPPED CSV HANDLER
+	// =======================
+	report, internalErr, done := core.MappedCSVHandler(
+		m,
+		App.CSV,
+		App.Lookup,
+		tempCSVStore,
+		App.TestMode,
+		userRRValues,
+		business,
+		App.debug,
+		App.DryRun,
+	)
+
+	if internalErr {
+		fmt.Println(core.ErrInternal.Error())
+		os.Exit(1)
+	}
+
+	if !done {
+		fmt.Printf("%s CSV did not import properly. Please look out at the report.\n\n", m.Name)
+	}
+	fmt.Println(report)
+}
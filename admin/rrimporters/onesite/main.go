@@ -22,13 +22,14 @@ Command Line Arguments
 2. csv (required) (onesite csv)
 3. testmode (optional) (testmode doesn't clear temp files, right now!)
 4. debug (optional) (debug used for to debug the records, been inspected from rcsv reports)
-5. frequency (optional) (rent cycle frequency)
+5. dryrun (optional) (run the whole import and report it, but save nothing)
+6. frequency (optional) (rent cycle frequency)
 (
     0: one time only | 1: secondly | 2: minutely | 3: hourly |
     4: daily | 5: weekly | 6: monthly | 7: quarterly | 8: yearly |
 )
-6. proration (optional) (proration cycle)
-7. gsrpc (optional) (GSRPC)
+7. proration (optional) (proration cycle)
+8. gsrpc (optional) (GSRPC)
 
 */
 
@@ -62,6 +63,7 @@ var App struct {
 	TestMode int      // used for test purpose?
 	CSV      string   // csv filename that needs to be load
 	debug    int      // debug records
+	DryRun   int      // validate and report only, save nothing
 }
 
 // userRRValues holds the values passed by user for rentroll attributes
@@ -107,6 +109,8 @@ func readCommandLineArgs() []string {
 	testmode := flag.Int("testmode", 0, "testing")
 	// is it for debug purpose
 	debug := flag.Int("debug", 0, "debug Records")
+	// dry run validates and reports without saving anything
+	dryrun := flag.Int("dryrun", 0, "validate and report, but do not save anything")
 	// parse db options
 	dbuPtr := flag.String("B", "ec2-user", "database user name")
 	dbrrPtr := flag.String("M", "rentroll", "database name (rentroll)")
@@ -140,6 +144,7 @@ func readCommandLineArgs() []string {
 	App.TestMode = *testmode
 	App.CSV = *fp
 	App.debug = *debug
+	App.DryRun = *dryrun
 
 	// get user values
 	userRRValues["RentCycle"] = *frequency
@@ -299,6 +304,7 @@ func main() {
 		userRRValues,
 		business,
 		App.debug,
+		App.DryRun,
 	)
 
 	if internalErr {
@@ -29,6 +29,7 @@ var App struct {
 	CSV          string   // csv filename that needs to be load
 	GuestInfoCSV string   // csv filename containing guest info
 	debug        int      // debug records
+	DryRun       int      // validate and report only, save nothing
 }
 
 // userRRValues holds the values passed by user for rentroll attributes
@@ -76,6 +77,8 @@ func readCommandLineArgs() []string {
 	testmode := flag.Int("testmode", 0, "testing")
 	// is it for debug purpose
 	debug := flag.Int("debug", 0, "debug Records")
+	// dry run validates and reports without saving anything
+	dryrun := flag.Int("dryrun", 0, "validate and report, but do not save anything")
 	// parse db options
 	dbuPtr := flag.String("B", "ec2-user", "database user name")
 	dbrrPtr := flag.String("M", "rentroll", "database name (rentroll)")
@@ -110,6 +113,7 @@ func readCommandLineArgs() []string {
 	App.CSV = *fp
 	App.GuestInfoCSV = *guestInfoFp
 	App.debug = *debug
+	App.DryRun = *dryrun
 
 	// get user values
 	userRRValues["RentCycle"] = *frequency
@@ -236,6 +240,7 @@ func main() {
 		userRRValues,
 		business,
 		App.debug,
+		App.DryRun,
 	)
 
 	if internalErr {
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"gotable"
 	"os"
+	"path"
 	"rentroll/rcsv"
 	"rentroll/rlib"
 	"rentroll/rrpt"
@@ -26,6 +27,7 @@ import (
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/kardianos/osext"
 )
 
 // App is the global application structure
@@ -45,6 +47,7 @@ var App struct {
 	DBRR           string                     //rentroll database
 	DBUser         string                     // user for all databases
 	DepositFile    string                     // Deposits
+	DirFile        string                     // employees of the local directory
 	DepositoryFile string                     // Depository
 	DMFile         string                     // Deposit Methods
 	InvoiceFile    string                     // Invoice
@@ -66,6 +69,7 @@ var App struct {
 	RspRefsFile    string                     // assign specialties to rentables
 	RTFile         string                     // Rentable types csv file
 	SLFile         string                     // StringLists
+	SQLite         string                     // SQLite database file to use instead of MySQL
 	SrcFile        string                     // Sources
 	VehicleFile    string                     // vehicles that belong to people
 	DtStart        time.Time                  // range start time
@@ -111,7 +115,9 @@ func readCommandLineArgs() {
 	vehiclePtr := flag.String("V", "", "add people vehicles via csv file")
 	verPtr := flag.Bool("v", false, "prints the version to stdout")
 	depositPtr := flag.String("y", "", "add Deposits via csv file")
+	dirPtr := flag.String("dir", "", "add employees to the local directory via csv file")
 	noconPtr := flag.Bool("nocon", false, "if specified, inhibit Console output")
+	sqlitePtr := flag.String("sqlite", "", "use this SQLite database file instead of MySQL")
 
 	flag.Parse()
 	if *verPtr {
@@ -135,6 +141,8 @@ func readCommandLineArgs() {
 	App.DBDir = *dbnmPtr
 	App.DBRR = *dbrrPtr
 	App.DBUser = *dbuPtr
+	App.SQLite = *sqlitePtr
+	App.DirFile = *dirPtr
 	App.DepositFile = *depositPtr
 	App.DepositoryFile = *depositoryPtr
 	App.DMFile = *dmPtr
@@ -214,40 +222,62 @@ func main() {
 
 	var err error
 
-	//----------------------------
-	// Open RentRoll database
-	//----------------------------
-	if err = rlib.RRReadConfig(); err != nil {
-		fmt.Printf("sql.Open for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
-		os.Exit(1)
-	}
+	if len(App.SQLite) > 0 {
+		folder, err := osext.ExecutableFolder()
+		if err != nil {
+			fmt.Printf("Cannot find the executable folder: %s\n", err.Error())
+			os.Exit(1)
+		}
+		App.dbrr, err = rlib.OpenSQLiteDB(App.SQLite, path.Join(folder, "schema.sql"))
+		if err != nil {
+			fmt.Printf("SQLite database %s: Error = %v\n", App.SQLite, err)
+			os.Exit(1)
+		}
+		defer App.dbrr.Close()
+		App.dbdir = App.dbrr
+	} else {
+		//----------------------------
+		// Open RentRoll database
+		//----------------------------
+		if err = rlib.RRReadConfig(); err != nil {
+			fmt.Printf("sql.Open for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
+			os.Exit(1)
+		}
 
-	s := extres.GetSQLOpenString(rlib.AppConfig.RRDbname, &rlib.AppConfig)
-	App.dbrr, err = sql.Open("mysql", s)
-	if nil != err {
-		fmt.Printf("sql.Open for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
-		os.Exit(1)
-	}
-	defer App.dbrr.Close()
-	err = App.dbrr.Ping()
-	if nil != err {
-		fmt.Printf("DBRR.Ping for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
-		os.Exit(1)
-	}
+		s := extres.GetSQLOpenString(rlib.AppConfig.RRDbname, &rlib.AppConfig)
+		App.dbrr, err = sql.Open("mysql", s)
+		if nil != err {
+			fmt.Printf("sql.Open for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
+			os.Exit(1)
+		}
+		defer App.dbrr.Close()
+		err = App.dbrr.Ping()
+		if nil != err {
+			fmt.Printf("DBRR.Ping for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
+			os.Exit(1)
+		}
 
-	//----------------------------
-	// Open Phonebook database
-	//----------------------------
-	s = extres.GetSQLOpenString(rlib.AppConfig.Dbname, &rlib.AppConfig)
-	App.dbdir, err = sql.Open("mysql", s)
-	if nil != err {
-		fmt.Printf("sql.Open: Error = %v\n", err)
-		os.Exit(1)
-	}
-	err = App.dbdir.Ping()
-	if nil != err {
-		fmt.Printf("dbdir.Ping: Error = %v\n", err)
-		os.Exit(1)
+		//----------------------------
+		// Open Phonebook database, unless employees are in the local directory
+		//----------------------------
+		var d rlib.Directory
+		if d, err = rlib.NewDirectory(rlib.RRConfig.Directory); err != nil {
+			fmt.Printf("Directory configuration: Error = %v\n", err)
+			os.Exit(1)
+		}
+		if d.Name() == rlib.DirectoryPhonebook {
+			s = extres.GetSQLOpenString(rlib.AppConfig.Dbname, &rlib.AppConfig)
+			App.dbdir, err = sql.Open("mysql", s)
+			if nil != err {
+				fmt.Printf("sql.Open: Error = %v\n", err)
+				os.Exit(1)
+			}
+			err = App.dbdir.Ping()
+			if nil != err {
+				fmt.Printf("dbdir.Ping: Error = %v\n", err)
+				os.Exit(1)
+			}
+		}
 	}
 
 	rlib.RpnInit()
@@ -280,6 +310,7 @@ func main() {
 	// Do all the file loading
 	//----------------------------------------------------
 	var h = []rcsv.CSVLoadHandler{
+		{Fname: App.DirFile, Handler: rcsv.LoadDirectoryCSV},
 		{Fname: App.BizFile, Handler: rcsv.LoadBusinessCSV},
 		{Fname: App.SLFile, Handler: rcsv.LoadStringTablesCSV},
 		{Fname: App.PmtTypeFile, Handler: rcsv.LoadPaymentTypesCSV},
@@ -0,0 +1,394 @@
+package bizlogic
+
+import (
+	"fmt"
+	"rentroll/rlib"
+	"time"
+)
+
+// bizErrf returns a BizError for errno with its message formatted using
+// the supplied arguments
+func bizErrf(errno int, args ...interface{}) BizError {
+	if errno < 0 || errno+1 > len(BizErrors) {
+		return BizError{Errno: errno, Message: fmt.Sprintf("error %d", errno)}
+	}
+	return BizError{Errno: errno, Message: fmt.Sprintf(BizErrors[errno].Message, args...)}
+}
+
+// SaveVendor validates the supplied vendor and writes it to the database.
+// If a.VENDID is 0 a new vendor is created, otherwise the existing vendor
+// is updated.
+//
+// INPUTS
+//    a = the vendor to save
+//
+// RETURNS
+//    a slice of BizErrors
+//-----------------------------------------------------------------------------
+func SaveVendor(a *rlib.Vendor) []BizError {
+	var e []BizError
+	if len(a.Name) == 0 {
+		return AddBizErrToList(e, MissingName)
+	}
+	v, err := rlib.GetVendorByName(a.BID, a.Name)
+	if err == nil && v.VENDID > 0 && v.VENDID != a.VENDID {
+		return AddBizErrToList(e, DuplicateName)
+	}
+	if a.DefaultLID > 0 {
+		l := rlib.GetLedger(a.DefaultLID)
+		if l.LID == 0 || l.BID != a.BID {
+			return append(e, bizErrf(BadExpenseAccount, a.DefaultLID))
+		}
+	}
+	if a.VENDID == 0 {
+		_, err = rlib.InsertVendor(a)
+	} else {
+		err = rlib.UpdateVendor(a)
+	}
+	if err != nil {
+		return AddErrToBizErrlist(err, e)
+	}
+	return nil
+}
+
+// ValidateBill checks the supplied bill and fills in defaults. If the bill
+// has no APLID, the business' Accounts Payable account is used. Bill items
+// with no LID are posted to the vendor's default expense account. If the
+// bill Amount is 0 it is set to the total of its items.
+//
+// INPUTS
+//    a = the bill to validate
+//
+// RETURNS
+//    a slice of BizErrors
+//-----------------------------------------------------------------------------
+func ValidateBill(a *rlib.Bill) []BizError {
+	var e []BizError
+	v, err := rlib.GetVendor(a.VENDID)
+	if err != nil || v.VENDID == 0 || v.BID != a.BID {
+		return append(e, bizErrf(VendorNotFound, a.VENDID, a.BID))
+	}
+	if a.APLID == 0 {
+		m := rlib.GetPayableAccounts(a.BID)
+		if len(m) == 0 {
+			return append(e, bizErrf(NoPayablesAccount, a.BID))
+		}
+		a.APLID = m[0]
+	}
+	if a.DtDue.Before(a.Dt) {
+		e = AddBizErrToList(e, StartDateAfterStopDate)
+	}
+	tot := float64(0)
+	for i := 0; i < len(a.BI); i++ {
+		a.BI[i].BID = a.BID
+		if a.BI[i].LID == 0 {
+			a.BI[i].LID = v.DefaultLID
+		}
+		l := rlib.GetLedger(a.BI[i].LID)
+		if l.LID == 0 || l.BID != a.BID {
+			e = append(e, bizErrf(BadExpenseAccount, a.BI[i].LID))
+		}
+		tot += a.BI[i].Amount
+	}
+	if a.Amount == 0 {
+		a.Amount = tot
+	}
+	if rlib.RoundToCent(tot) != rlib.RoundToCent(a.Amount) {
+		e = append(e, bizErrf(BillItemsTotalMismatch, tot, a.Amount))
+	}
+	return e
+}
+
+// SaveBill validates and saves a vendor bill along with its items and
+// creates its journal entries. If the bill already exists and any of its
+// Dt, APLID, Amount, or items have changed, the existing bill is reversed
+// and a new bill is created. Otherwise only the descriptive fields are
+// updated.
+//
+// INPUTS
+//    a = the bill to save
+//
+// RETURNS
+//    a slice of BizErrors
+//-----------------------------------------------------------------------------
+func SaveBill(a *rlib.Bill) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return SaveBillTx(tx, a) })
+}
+
+// SaveBillTx is SaveBill performed within transaction tx. If tx is nil the
+// database is used directly.
+func SaveBillTx(tx *rlib.RRTx, a *rlib.Bill) []BizError {
+	if a.FLAGS&rlib.BILLREVERSED != 0 {
+		return AddBizErrToList(nil, EditReversal)
+	}
+	e := ValidateBill(a)
+	if len(e) > 0 {
+		return e
+	}
+	if a.BILLID > 0 {
+		aold, err := rlib.GetBillTx(tx, a.BILLID)
+		if err != nil {
+			return bizErrSys(&err)
+		}
+		if !billNeedsReversal(&aold, a) {
+			aold.DtDue = a.DtDue
+			aold.DocNo = a.DocNo
+			aold.Comment = a.Comment
+			aold.LastModBy = a.LastModBy
+			if err = rlib.UpdateBillTx(tx, &aold); err != nil {
+				return bizErrSys(&err)
+			}
+			return nil
+		}
+		now := time.Now()
+		if e = ReverseBillTx(tx, &aold, &now); len(e) > 0 {
+			return e
+		}
+		a.BILLID = 0
+		a.FLAGS = rlib.BILLUNPAID
+	}
+	return insertBill(tx, a)
+}
+
+// billNeedsReversal returns true if the financial content of the bill has changed
+func billNeedsReversal(aold, anew *rlib.Bill) bool {
+	if aold.APLID != anew.APLID || aold.Amount != anew.Amount || !aold.Dt.Equal(anew.Dt) || len(aold.BI) != len(anew.BI) {
+		return true
+	}
+	for i := 0; i < len(aold.BI); i++ {
+		if aold.BI[i].LID != anew.BI[i].LID || aold.BI[i].Amount != anew.BI[i].Amount || aold.BI[i].RID != anew.BI[i].RID {
+			return true
+		}
+	}
+	return false
+}
+
+// insertBill writes a new bill and its items, then journals it
+func insertBill(tx *rlib.RRTx, a *rlib.Bill) []BizError {
+	_, err := rlib.InsertBillTx(tx, a)
+	if err != nil {
+		return bizErrSys(&err)
+	}
+	for i := 0; i < len(a.BI); i++ {
+		a.BI[i].BIID = 0
+		a.BI[i].BILLID = a.BILLID
+		a.BI[i].CreateBy = a.CreateBy
+		a.BI[i].LastModBy = a.LastModBy
+		if _, err = rlib.InsertBillItemTx(tx, &a.BI[i]); err != nil {
+			return bizErrSys(&err)
+		}
+	}
+	var xbiz rlib.XBusiness
+	if err = rlib.ProcessNewBillTx(tx, a, &xbiz); err != nil {
+		return bizErrSys(&err)
+	}
+	return nil
+}
+
+// ReverseBill reverses a bill and all the payments that have been made
+// against it. If the bill has already been reversed it returns immediately.
+//
+// INPUTS
+//    aold = the bill to reverse
+//    dt   = date of the reversal
+//
+// RETURNS
+//    a slice of BizErrors
+//-----------------------------------------------------------------------------
+func ReverseBill(aold *rlib.Bill, dt *time.Time) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return ReverseBillTx(tx, aold, dt) })
+}
+
+// ReverseBillTx is ReverseBill performed within transaction tx. If tx is nil
+// the database is used directly.
+func ReverseBillTx(tx *rlib.RRTx, aold *rlib.Bill, dt *time.Time) []BizError {
+	if aold.FLAGS&rlib.BILLREVERSED != 0 {
+		return nil // it's already reversed
+	}
+	m, err := rlib.GetBillPaymentsThroughDateTx(tx, aold.BILLID, &rlib.ENDOFTIME)
+	if err != nil {
+		return bizErrSys(&err)
+	}
+	for i := 0; i < len(m); i++ {
+		if e := ReverseBillPaymentTx(tx, &m[i], dt); len(e) > 0 {
+			return e
+		}
+	}
+	if len(aold.BI) == 0 {
+		if aold.BI, err = rlib.GetBillItemsTx(tx, aold.BILLID); err != nil {
+			return bizErrSys(&err)
+		}
+	}
+
+	anew := *aold
+	anew.BILLID = 0
+	anew.Amount = -anew.Amount
+	anew.RPBILLID = aold.BILLID
+	anew.FLAGS = rlib.BILLREVERSED
+	anew.Comment = fmt.Sprintf("Reversal of %s", aold.IDtoShortString())
+	anew.BI = make([]rlib.BillItem, len(aold.BI))
+	for i := 0; i < len(aold.BI); i++ {
+		anew.BI[i] = aold.BI[i]
+		anew.BI[i].Amount = -aold.BI[i].Amount
+	}
+	if e := insertBill(tx, &anew); len(e) > 0 {
+		return e
+	}
+
+	// re-read the flags, reversing the payments changed them
+	b, err := rlib.GetBillTx(tx, aold.BILLID)
+	if err != nil {
+		return bizErrSys(&err)
+	}
+	aold.FLAGS = b.FLAGS | rlib.BILLREVERSED
+	aold.Comment = fmt.Sprintf("Reversed by %s", anew.IDtoShortString())
+	if err = rlib.UpdateBillTx(tx, aold); err != nil {
+		return bizErrSys(&err)
+	}
+	return nil
+}
+
+// PayBill records a payment against a vendor bill, journals it, and updates
+// the paid status of the bill.
+//
+// INPUTS
+//    a = the payment. BILLID, DEPID, Dt and Amount must be set, Amount must
+//        be greater than 0. Payments are undone with ReverseBillPayment.
+//
+// RETURNS
+//    a slice of BizErrors
+//-----------------------------------------------------------------------------
+func PayBill(a *rlib.BillPayment) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return PayBillTx(tx, a) })
+}
+
+// PayBillTx is PayBill performed within transaction tx. If tx is nil the
+// database is used directly.
+func PayBillTx(tx *rlib.RRTx, a *rlib.BillPayment) []BizError {
+	var e []BizError
+	if rlib.RoundToCent(a.Amount) <= 0 {
+		return append(e, bizErrf(InvalidPaymentAmount, a.Amount))
+	}
+	b, err := rlib.GetBillTx(tx, a.BILLID)
+	if err != nil || b.BILLID == 0 || b.BID != a.BID {
+		return append(e, bizErrf(BillNotFound, a.BILLID, a.BID))
+	}
+	if b.FLAGS&rlib.BILLREVERSED != 0 {
+		return append(e, bizErrf(BillReversed, b.IDtoShortString()))
+	}
+	dep, err := rlib.GetDepository(a.DEPID)
+	if err != nil || dep.DEPID == 0 || dep.BID != a.BID {
+		return append(e, bizErrf(InvalidDepository, a.DEPID, a.BID))
+	}
+	bal, err := BillBalanceTx(tx, &b, &rlib.ENDOFTIME)
+	if err != nil {
+		return bizErrSys(&err)
+	}
+	if rlib.RoundToCent(a.Amount) > rlib.RoundToCent(bal) {
+		return append(e, bizErrf(BillOverpayment, a.Amount, bal, b.IDtoShortString()))
+	}
+	a.VENDID = b.VENDID
+	if _, err = rlib.InsertBillPaymentTx(tx, a); err != nil {
+		return bizErrSys(&err)
+	}
+	var xbiz rlib.XBusiness
+	if err = rlib.ProcessNewBillPaymentTx(tx, a, &xbiz); err != nil {
+		return bizErrSys(&err)
+	}
+	if err = updateBillPaidStatus(tx, &b); err != nil {
+		return bizErrSys(&err)
+	}
+	return nil
+}
+
+// ReverseBillPayment reverses a bill payment and updates the paid status
+// of the bill. If the payment has already been reversed it returns immediately.
+//
+// INPUTS
+//    aold = the payment to reverse
+//    dt   = date of the reversal
+//
+// RETURNS
+//    a slice of BizErrors
+//-----------------------------------------------------------------------------
+func ReverseBillPayment(aold *rlib.BillPayment, dt *time.Time) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return ReverseBillPaymentTx(tx, aold, dt) })
+}
+
+// ReverseBillPaymentTx is ReverseBillPayment performed within transaction tx.
+// If tx is nil the database is used directly.
+func ReverseBillPaymentTx(tx *rlib.RRTx, aold *rlib.BillPayment, dt *time.Time) []BizError {
+	if aold.FLAGS&rlib.BILLREVERSED != 0 {
+		return nil // it's already reversed
+	}
+	anew := *aold
+	anew.BPID = 0
+	anew.Amount = -anew.Amount
+	anew.RPBPID = aold.BPID
+	anew.FLAGS |= rlib.BILLREVERSED
+	anew.Comment = fmt.Sprintf("Reversal of %s", aold.IDtoShortString())
+	_, err := rlib.InsertBillPaymentTx(tx, &anew)
+	if err != nil {
+		return bizErrSys(&err)
+	}
+	var xbiz rlib.XBusiness
+	if err = rlib.ProcessNewBillPaymentTx(tx, &anew, &xbiz); err != nil {
+		return bizErrSys(&err)
+	}
+
+	aold.Comment = fmt.Sprintf("Reversed by %s", anew.IDtoShortString())
+	aold.FLAGS |= rlib.BILLREVERSED
+	if err = rlib.UpdateBillPaymentTx(tx, aold); err != nil {
+		return bizErrSys(&err)
+	}
+	b, err := rlib.GetBillTx(tx, aold.BILLID)
+	if err != nil {
+		return bizErrSys(&err)
+	}
+	if err = updateBillPaidStatus(tx, &b); err != nil {
+		return bizErrSys(&err)
+	}
+	return nil
+}
+
+// BillBalance returns the amount still owed on the supplied bill as of dt
+func BillBalance(b *rlib.Bill, dt *time.Time) (float64, error) {
+	return BillBalanceTx(nil, b, dt)
+}
+
+// BillBalanceTx is BillBalance performed within transaction tx. If tx is nil
+// the database is used directly.
+func BillBalanceTx(tx *rlib.RRTx, b *rlib.Bill, dt *time.Time) (float64, error) {
+	m, err := rlib.GetBillPaymentsThroughDateTx(tx, b.BILLID, dt)
+	if err != nil {
+		return 0, err
+	}
+	bal := b.Amount
+	for i := 0; i < len(m); i++ {
+		bal -= m[i].Amount
+	}
+	return rlib.RoundToCent(bal), nil
+}
+
+// updateBillPaidStatus sets the unpaid / partially paid / fully paid bits
+// of the bill's FLAGS based on the payments made against it
+func updateBillPaidStatus(tx *rlib.RRTx, b *rlib.Bill) error {
+	bal, err := BillBalanceTx(tx, b, &rlib.ENDOFTIME)
+	if err != nil {
+		return err
+	}
+	flags := b.FLAGS &^ 3
+	switch {
+	case bal <= 0:
+		flags |= rlib.BILLFULLYPAID
+	case bal < b.Amount:
+		flags |= rlib.BILLPARTIALPAID
+	default:
+		flags |= rlib.BILLUNPAID
+	}
+	if flags == b.FLAGS {
+		return nil
+	}
+	b.FLAGS = flags
+	return rlib.UpdateBillTx(tx, b)
+}
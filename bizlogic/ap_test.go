@@ -0,0 +1,175 @@
+// +build sqlite
+
+package bizlogic
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+)
+
+// newTestVendor saves a 1099 vendor whose bills post to Repairs
+func newTestVendor(t *testing.T, b *rrtest.Biz, name string) rlib.Vendor {
+	v := rlib.Vendor{BID: b.BID, Name: name, DefaultLID: b.LID["50001"], FLAGS: rlib.VENDOR1099}
+	if e := SaveVendor(&v); len(e) > 0 {
+		t.Fatalf("SaveVendor %s: %s", name, bizErrString(e))
+	}
+	return v
+}
+
+// newTestBill saves a bill from vendor v for amount, with one item
+func newTestBill(t *testing.T, v *rlib.Vendor, amount float64) rlib.Bill {
+	a := rlib.Bill{
+		BID:    v.BID,
+		VENDID: v.VENDID,
+		Dt:     rrtest.Dt(2017, 3, 1),
+		DtDue:  rrtest.Dt(2017, 3, 31),
+		DocNo:  "INV-1",
+		BI:     []rlib.BillItem{{Amount: amount, Description: "plumbing"}},
+	}
+	if e := SaveBill(&a); len(e) > 0 {
+		t.Fatalf("SaveBill: %s", bizErrString(e))
+	}
+	return a
+}
+
+func TestSaveVendor(t *testing.T) {
+	b := newTestBiz(t)
+	newTestVendor(t, b, "Acme Plumbing")
+
+	m := []struct {
+		v      rlib.Vendor
+		expect int
+	}{
+		{rlib.Vendor{BID: b.BID}, MissingName},
+		{rlib.Vendor{BID: b.BID, Name: "Acme Plumbing"}, DuplicateName},
+		{rlib.Vendor{BID: b.BID, Name: "Bob's Paint", DefaultLID: 9999}, BadExpenseAccount},
+	}
+	for i := 0; i < len(m); i++ {
+		if e := SaveVendor(&m[i].v); !hasBizErr(e, m[i].expect) {
+			t.Errorf("%d: expect error %d, got %q", i, m[i].expect, bizErrString(e))
+		}
+		if m[i].v.VENDID != 0 {
+			t.Errorf("%d: vendor was saved", i)
+		}
+	}
+}
+
+func TestSaveBill(t *testing.T) {
+	b := newTestBiz(t)
+	v := newTestVendor(t, b, "Acme Plumbing")
+
+	bad := rlib.Bill{BID: b.BID, VENDID: v.VENDID + 100, Dt: rrtest.Dt(2017, 3, 1), DtDue: rrtest.Dt(2017, 3, 31)}
+	if e := SaveBill(&bad); !hasBizErr(e, VendorNotFound) {
+		t.Errorf("unknown vendor: expect VendorNotFound, got %q", bizErrString(e))
+	}
+	bad = rlib.Bill{BID: b.BID, VENDID: v.VENDID, Dt: rrtest.Dt(2017, 3, 1), DtDue: rrtest.Dt(2017, 3, 31), Amount: 100,
+		BI: []rlib.BillItem{{Amount: 60}, {Amount: 30}}}
+	if e := SaveBill(&bad); !hasBizErr(e, BillItemsTotalMismatch) {
+		t.Errorf("items total 90 for a 100 bill: expect BillItemsTotalMismatch, got %q", bizErrString(e))
+	}
+
+	a := newTestBill(t, &v, 250)
+	if a.Amount != 250 {
+		t.Errorf("bill amount is the total of its items: expect 250.00, got %.2f", a.Amount)
+	}
+	if a.APLID != b.LID["20001"] {
+		t.Errorf("bill posts to the business' Accounts Payable account %d, got %d", b.LID["20001"], a.APLID)
+	}
+	if a.BI[0].LID != b.LID["50001"] {
+		t.Errorf("bill item posts to the vendor's default account %d, got %d", b.LID["50001"], a.BI[0].LID)
+	}
+	dt := rrtest.Dt(2017, 4, 1)
+	if bal := rlib.GetAccountBalance(b.BID, b.LID["20001"], &dt); bal != -250 {
+		t.Errorf("Accounts Payable balance: expect -250.00, got %.2f", bal)
+	}
+	if bal := rlib.GetAccountBalance(b.BID, b.LID["50001"], &dt); bal != 250 {
+		t.Errorf("Repairs balance: expect 250.00, got %.2f", bal)
+	}
+}
+
+// A bill whose ledger entries cannot be written is not saved at all
+func TestSaveBillRollback(t *testing.T) {
+	b := newTestBiz(t)
+	v := newTestVendor(t, b, "Acme Plumbing")
+	before := bookCounts(t, b.BID)
+	a := rlib.Bill{BID: b.BID, VENDID: v.VENDID, Dt: rrtest.Dt(2017, 3, 1), DtDue: rrtest.Dt(2017, 3, 31), DocNo: "INV-1",
+		BI: []rlib.BillItem{{Amount: 250, Description: "plumbing"}}}
+	done := failWhen(t, "INSERT ON LedgerEntry")
+	e := SaveBill(&a)
+	done()
+	if len(e) == 0 {
+		t.Fatalf("SaveBill succeeded, the ledger entries were made to fail")
+	}
+	sameCounts(t, "after the failed save", before, bookCounts(t, b.BID))
+	for _, table := range []string{"Bill", "BillItem"} {
+		if n := countRows(t, table, b.BID); n != 0 {
+			t.Errorf("after the failed save: expect no %s rows, got %d", table, n)
+		}
+	}
+}
+
+func TestPayBill(t *testing.T) {
+	b := newTestBiz(t)
+	v := newTestVendor(t, b, "Acme Plumbing")
+	a := newTestBill(t, &v, 250)
+
+	pay := func(amount float64) []BizError {
+		p := rlib.BillPayment{BID: b.BID, BILLID: a.BILLID, DEPID: b.DEPID, Dt: rrtest.Dt(2017, 3, 15), Amount: amount, DocNo: "1001"}
+		return PayBill(&p)
+	}
+	flags := func() uint64 {
+		x, err := rlib.GetBillTx(nil, a.BILLID)
+		if err != nil {
+			t.Fatalf("GetBill: %s", err.Error())
+		}
+		return x.FLAGS & 3
+	}
+
+	for _, amt := range []float64{0, -50, 0.001} {
+		if e := pay(amt); !hasBizErr(e, InvalidPaymentAmount) {
+			t.Errorf("payment of %.3f: expect InvalidPaymentAmount, got %q", amt, bizErrString(e))
+		}
+	}
+	if e := pay(300); !hasBizErr(e, BillOverpayment) {
+		t.Errorf("payment of 300 on a 250 bill: expect BillOverpayment, got %q", bizErrString(e))
+	}
+	if flags() != rlib.BILLUNPAID {
+		t.Errorf("rejected payments changed the bill to %d", flags())
+	}
+
+	if e := pay(100); len(e) > 0 {
+		t.Fatalf("payment of 100: %s", bizErrString(e))
+	}
+	if flags() != rlib.BILLPARTIALPAID {
+		t.Errorf("after paying 100 of 250: expect partially paid, got %d", flags())
+	}
+	if bal, _ := BillBalance(&a, &rlib.ENDOFTIME); bal != 150 {
+		t.Errorf("balance after paying 100 of 250: expect 150.00, got %.2f", bal)
+	}
+	if e := pay(150); len(e) > 0 {
+		t.Fatalf("payment of 150: %s", bizErrString(e))
+	}
+	if flags() != rlib.BILLFULLYPAID {
+		t.Errorf("after paying 250 of 250: expect fully paid, got %d", flags())
+	}
+	dt := rrtest.Dt(2017, 4, 1)
+	if bal := rlib.GetAccountBalance(b.BID, b.LID["20001"], &dt); bal != 0 {
+		t.Errorf("Accounts Payable balance after paying the bill: expect 0.00, got %.2f", bal)
+	}
+	if bal := rlib.GetAccountBalance(b.BID, b.LID["10001"], &dt); bal != -250 {
+		t.Errorf("bank balance after paying the bill: expect -250.00, got %.2f", bal)
+	}
+
+	x, _ := rlib.GetBillTx(nil, a.BILLID)
+	now := rrtest.Dt(2017, 3, 20)
+	if e := ReverseBill(&x, &now); len(e) > 0 {
+		t.Fatalf("ReverseBill: %s", bizErrString(e))
+	}
+	if bal := rlib.GetAccountBalance(b.BID, b.LID["10001"], &dt); bal != 0 {
+		t.Errorf("bank balance after reversing the bill and its payments: expect 0.00, got %.2f", bal)
+	}
+	if e := pay(10); !hasBizErr(e, BillReversed) {
+		t.Errorf("payment on a reversed bill: expect BillReversed, got %q", bizErrString(e))
+	}
+}
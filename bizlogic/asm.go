@@ -18,6 +18,12 @@ import (
 //    a slice of BizErrors
 //-------------------------------------------------------------------------------------
 func UpdateAssessment(anew *rlib.Assessment, mode int, dt *time.Time, exp int) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return UpdateAssessmentTx(tx, anew, mode, dt, exp) })
+}
+
+// UpdateAssessmentTx is UpdateAssessment performed within transaction tx. If
+// tx is nil the database is used directly.
+func UpdateAssessmentTx(tx *rlib.RRTx, anew *rlib.Assessment, mode int, dt *time.Time, exp int) []BizError {
 	var err error
 	var errlist []BizError
 
@@ -36,7 +42,7 @@ func UpdateAssessment(anew *rlib.Assessment, mode int, dt *time.Time, exp int) [
 	//-------------------------------
 	// Load existing assessment...
 	//-------------------------------
-	aold, err := rlib.GetAssessment(anew.ASMID)
+	aold, err := rlib.GetAssessmentTx(tx, anew.ASMID)
 	if err != nil {
 		return bizErrSys(&err)
 
@@ -66,17 +72,17 @@ func UpdateAssessment(anew *rlib.Assessment, mode int, dt *time.Time, exp int) [
 		(!aold.Start.Equal(anew.Start)) ||
 		(!aold.Stop.Equal(anew.Stop))
 	if reverse {
-		errlist = ReverseAssessment(&aold, mode, dt) // reverse the assessment itself
-		if errlist != nil {
+		errlist = ReverseAssessmentTx(tx, &aold, mode, dt) // reverse the assessment itself
+		if len(errlist) > 0 {
 			return errlist
 		}
-		errlist = InsertAssessment(anew, exp) // Finally, insert the new assessment...
-		if err != nil {
+		errlist = InsertAssessmentTx(tx, anew, exp) // Finally, insert the new assessment...
+		if len(errlist) > 0 {
 			return errlist
 		}
 	}
 
-	err = rlib.UpdateAssessment(anew) // reversal not needed, just update the assessment
+	err = rlib.UpdateAssessmentTx(tx, anew) // reversal not needed, just update the assessment
 	if err != nil {
 		return bizErrSys(&err)
 	}
@@ -96,6 +102,12 @@ func UpdateAssessment(anew *rlib.Assessment, mode int, dt *time.Time, exp int) [
 //    a slice of BizErrors
 //-------------------------------------------------------------------------------------
 func ReverseAssessment(aold *rlib.Assessment, mode int, dt *time.Time) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return ReverseAssessmentTx(tx, aold, mode, dt) })
+}
+
+// ReverseAssessmentTx is ReverseAssessment performed within transaction tx. If
+// tx is nil the database is used directly.
+func ReverseAssessmentTx(tx *rlib.RRTx, aold *rlib.Assessment, mode int, dt *time.Time) []BizError {
 	funcname := "bizlogic.ReverseAssessment"
 	var errlist []BizError
 	rlib.Console("Entered ReverseAssessment.  mode = %d,  dt = %s\n", mode, dt.Format(rlib.RRDATEFMTSQL))
@@ -105,9 +117,9 @@ func ReverseAssessment(aold *rlib.Assessment, mode int, dt *time.Time) []BizErro
 	rlib.Console("ReverseAssessment: processing forward with mode = %d,  dt = %s\n", mode, dt.Format(rlib.RRDATEFMTSQL))
 	switch mode {
 	case 0:
-		errlist = ReverseAssessmentInstance(aold, dt)
+		errlist = ReverseAssessmentInstanceTx(tx, aold, dt)
 	case 1:
-		errlist = ReverseAssessmentsGoingForward(aold, &aold.Start, dt)
+		errlist = ReverseAssessmentsGoingForwardTx(tx, aold, &aold.Start, dt)
 	case 2:
 		var epoch, inst rlib.Assessment
 		var err error
@@ -116,7 +128,7 @@ func ReverseAssessment(aold *rlib.Assessment, mode int, dt *time.Time) []BizErro
 		// set the epoch
 		//---------------------------------------------------------
 		if aold.PASMID != 0 {
-			epoch, err = rlib.GetAssessment(aold.PASMID)
+			epoch, err = rlib.GetAssessmentTx(tx, aold.PASMID)
 			if err != nil {
 				rlib.Console("EXITING ReverseAssessment.  PT 1\n")
 				return bizErrSys(&err)
@@ -130,24 +142,24 @@ func ReverseAssessment(aold *rlib.Assessment, mode int, dt *time.Time) []BizErro
 		//---------------------------------------------------------
 		if epoch.RentCycle == rlib.RECURNONE {
 			rlib.Console("EXITING ReverseAssessment.  PT 2\n")
-			return ReverseAssessmentInstance(&epoch, dt)
+			return ReverseAssessmentInstanceTx(tx, &epoch, dt)
 		}
 
 		//---------------------------------------------------------
 		// Get the first instance and modify forward...
 		//---------------------------------------------------------
-		inst, err = rlib.GetAssessmentFirstInstance(epoch.ASMID)
+		inst, err = rlib.GetAssessmentFirstInstanceTx(tx, epoch.ASMID)
 		if err != nil {
 			rlib.Console("EXITING ReverseAssessment.  PT 3\n")
 			return bizErrSys(&err)
 		}
-		errlist = ReverseAssessmentsGoingForward(&inst, &inst.Start, dt) // reverse from start of recurring instances forward
+		errlist = ReverseAssessmentsGoingForwardTx(tx, &inst, &inst.Start, dt) // reverse from start of recurring instances forward
 		if len(errlist) > 0 {
 			rlib.Console("EXITING ReverseAssessment.  PT 4\n")
 			return errlist
 		}
 		epoch.FLAGS |= 0x4 // mark that this is void
-		err = rlib.UpdateAssessment(&epoch)
+		err = rlib.UpdateAssessmentTx(tx, &epoch)
 		if err != nil {
 			rlib.Console("EXITING ReverseAssessment.  PT 5\n")
 			return bizErrSys(&err)
@@ -172,6 +184,12 @@ func ReverseAssessment(aold *rlib.Assessment, mode int, dt *time.Time) []BizErro
 //    a slice of BizErrors
 //-------------------------------------------------------------------------------------
 func ReverseAssessmentsGoingForward(aold *rlib.Assessment, dtStart, dt *time.Time) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return ReverseAssessmentsGoingForwardTx(tx, aold, dtStart, dt) })
+}
+
+// ReverseAssessmentsGoingForwardTx is ReverseAssessmentsGoingForward performed
+// within transaction tx. If tx is nil the database is used directly.
+func ReverseAssessmentsGoingForwardTx(tx *rlib.RRTx, aold *rlib.Assessment, dtStart, dt *time.Time) []BizError {
 	var errlist []BizError
 
 	rlib.Console("ENTERED: ReverseAssessmentsGoingForward\n")
@@ -179,10 +197,10 @@ func ReverseAssessmentsGoingForward(aold *rlib.Assessment, dtStart, dt *time.Tim
 	d2 := time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
 	rlib.Console("aold.PASMID = %d, dtStart = %s, dt = %s\n", aold.PASMID, dtStart.Format(rlib.RRDATEREPORTFMT), dt.Format(rlib.RRDATEREPORTFMT))
 
-	m := rlib.GetAssessmentInstancesByParent(aold.PASMID, dtStart, &d2)
+	m := rlib.GetAssessmentInstancesByParentTx(tx, aold.PASMID, dtStart, &d2)
 	rlib.Console("Number of instances to reverse: %d\n", len(m))
 	for i := 0; i < len(m); i++ {
-		errlist = ReverseAssessmentInstance(&m[i], dt)
+		errlist = ReverseAssessmentInstanceTx(tx, &m[i], dt)
 		if len(errlist) > 0 {
 			return errlist
 		}
@@ -201,6 +219,12 @@ func ReverseAssessmentsGoingForward(aold *rlib.Assessment, dtStart, dt *time.Tim
 //    a slice of BizErrors
 //-------------------------------------------------------------------------------------
 func ReverseAssessmentInstance(aold *rlib.Assessment, dt *time.Time) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return ReverseAssessmentInstanceTx(tx, aold, dt) })
+}
+
+// ReverseAssessmentInstanceTx is ReverseAssessmentInstance performed within
+// transaction tx. If tx is nil the database is used directly.
+func ReverseAssessmentInstanceTx(tx *rlib.RRTx, aold *rlib.Assessment, dt *time.Time) []BizError {
 	// funcname := "ReverseAssessmentInstance"
 	if aold.FLAGS&0x4 != 0 {
 		return nil // it's already reversed
@@ -213,20 +237,20 @@ func ReverseAssessmentInstance(aold *rlib.Assessment, dt *time.Time) []BizError
 	anew.FLAGS |= 0x4 // set bit 2 to mark that this assessment is void
 	anew.Comment = fmt.Sprintf("Reversal of %s", aold.IDtoString())
 
-	errlist := InsertAssessment(&anew, 1)
+	errlist := InsertAssessmentTx(tx, &anew, 1)
 	if len(errlist) > 0 {
 		return errlist
 	}
 
 	aold.Comment = fmt.Sprintf("Reversed by %s", anew.IDtoString())
 	aold.FLAGS |= 0x4 // set bit 2 to mark that this assessment is void
-	err := rlib.UpdateAssessment(aold)
+	err := rlib.UpdateAssessmentTx(tx, aold)
 	if err != nil {
 		return bizErrSys(&err)
 	}
 
 	if aold.AGRCPTID == 0 {
-		err = DeallocateAppliedFunds(aold, anew.ASMID, dt)
+		err = DeallocateAppliedFundsTx(tx, aold, anew.ASMID, dt)
 		if err != nil {
 			return bizErrSys(&err)
 		}
@@ -235,7 +259,7 @@ func ReverseAssessmentInstance(aold *rlib.Assessment, dt *time.Time) []BizError
 		// handle auto-generated assessments a little different...
 		// See if there was a funds transfer to a bank account...
 		//---------------------------------------------------------
-		return ReverseAutoGenAsmt(aold)
+		return ReverseAutoGenAsmtTx(tx, aold)
 	}
 	return nil
 }
@@ -251,21 +275,27 @@ func ReverseAssessmentInstance(aold *rlib.Assessment, dt *time.Time) []BizError
 //    a slice of BizErrors
 //-------------------------------------------------------------------------------------
 func ReverseAutoGenAsmt(aold *rlib.Assessment) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return ReverseAutoGenAsmtTx(tx, aold) })
+}
+
+// ReverseAutoGenAsmtTx is ReverseAutoGenAsmt performed within transaction tx.
+// If tx is nil the database is used directly.
+func ReverseAutoGenAsmtTx(tx *rlib.RRTx, aold *rlib.Assessment) []BizError {
 	funcname := "ReverseAutoGenAsmt"
 	var err error
-	jx := rlib.GetJournalByTypeAndID(rlib.JNLTYPEXFER, aold.AGRCPTID)
+	jx := rlib.GetJournalByTypeAndIDTx(tx, rlib.JNLTYPEXFER, aold.AGRCPTID)
 	if jx.JID > 0 {
-		rlib.GetJournalAllocations(&jx)
+		rlib.GetJournalAllocationsTx(tx, &jx)
 		if len(jx.JA) > 0 {
 			m := rlib.ParseSimpleAcctRule(jx.JA[0].AcctRule)
 			//--------------------
 			// journal
 			//--------------------
-			jnl := rlib.GetJournal(jx.JA[0].JID)
+			jnl := rlib.GetJournalTx(tx, jx.JA[0].JID)
 			jnl.Comment = fmt.Sprintf("Reversal of J-%d", jnl.JID)
 			jnl.JID = 0
 			jnl.Amount = -jnl.Amount
-			_, err = rlib.InsertJournal(&jnl) // this will update jnl.JID
+			_, err = rlib.InsertJournalTx(tx, &jnl) // this will update jnl.JID
 			if err != nil {
 				rlib.LogAndPrintError(funcname, err)
 				return bizErrSys(&err)
@@ -280,7 +310,7 @@ func ReverseAutoGenAsmt(aold *rlib.Assessment) []BizError {
 				m[0].Action, m[0].Account, -m[0].Amount,
 				m[1].Action, m[1].Account, -m[1].Amount)
 			ja.Amount = -ja.Amount
-			err = rlib.InsertJournalAllocationEntry(&ja)
+			err = rlib.InsertJournalAllocationEntryTx(tx, &ja)
 			if err != nil {
 				rlib.LogAndPrintError(funcname, err)
 				return bizErrSys(&err)
@@ -289,7 +319,7 @@ func ReverseAutoGenAsmt(aold *rlib.Assessment) []BizError {
 			//-------------
 			// ledgers
 			//-------------
-			n := rlib.GetLedgerEntriesByJAID(aold.BID, jx.JA[0].JAID)
+			n := rlib.GetLedgerEntriesByJAIDTx(tx, aold.BID, jx.JA[0].JAID)
 			for i := 0; i < len(n); i++ {
 				le := n[i]
 				le.LEID = 0
@@ -297,7 +327,7 @@ func ReverseAutoGenAsmt(aold *rlib.Assessment) []BizError {
 				le.Amount = -le.Amount
 				le.JAID = ja.JAID
 				le.JID = jx.JA[0].JID
-				_, err = rlib.InsertLedgerEntry(&le)
+				_, err = rlib.InsertLedgerEntryTx(tx, &le)
 				if err != nil {
 					rlib.LogAndPrintError(funcname, err)
 					return bizErrSys(&err)
@@ -318,18 +348,24 @@ func ReverseAutoGenAsmt(aold *rlib.Assessment) []BizError {
 //    any error that occurred, or nil if no error
 //-------------------------------------------------------------------------------
 func DeallocateAppliedFunds(a *rlib.Assessment, asmtRevID int64, dt *time.Time) error {
+	return rlib.RunInTx(func(tx *rlib.RRTx) error { return DeallocateAppliedFundsTx(tx, a, asmtRevID, dt) })
+}
+
+// DeallocateAppliedFundsTx is DeallocateAppliedFunds performed within
+// transaction tx. If tx is nil the database is used directly.
+func DeallocateAppliedFundsTx(tx *rlib.RRTx, a *rlib.Assessment, asmtRevID int64, dt *time.Time) error {
 	funcname := "bizlogic.DeallocateAppliedFunds"
 	//--------------------------------------------------------------
 	// Find all JournalAllocations that reference Assessment a that
 	// also have a ReceiptID.
 	//--------------------------------------------------------------
-	JA := rlib.GetJournalAllocationByASMID(a.ASMID)
+	JA := rlib.GetJournalAllocationByASMIDTx(tx, a.ASMID)
 	for i := 0; i < len(JA); i++ {
 		if JA[i].RCPTID == 0 {
 			continue
 		}
 
-		rcpt := rlib.GetReceipt(JA[i].RCPTID)
+		rcpt := rlib.GetReceiptTx(tx, JA[i].RCPTID)
 
 		//--------------------------------
 		// Reverse the Journal Entry...
@@ -341,7 +377,7 @@ func DeallocateAppliedFunds(a *rlib.Assessment, asmtRevID int64, dt *time.Time)
 			ID:     asmtRevID, // this is the rcptid of the reversal receipt
 			Dt:     *dt,       // reversal date
 		}
-		_, err := rlib.InsertJournal(&jnl)
+		_, err := rlib.InsertJournalTx(tx, &jnl)
 		if err != nil {
 			rlib.LogAndPrintError(funcname, err)
 			return err
@@ -373,19 +409,23 @@ func DeallocateAppliedFunds(a *rlib.Assessment, asmtRevID int64, dt *time.Time)
 			TCID:     rcpt.TCID,
 			RCPTID:   rcpt.RCPTID,
 		}
-		rlib.InsertJournalAllocationEntry(&ja)
+		err = rlib.InsertJournalAllocationEntryTx(tx, &ja)
+		if err != nil {
+			rlib.LogAndPrintError(funcname, err)
+			return err
+		}
 		jnl.JA = append(jnl.JA, ja)
 
 		//-------------------------------------------------------------------------
 		// Next, reverse the ledger entries...
 		//-------------------------------------------------------------------------
-		le := rlib.GetLedgerEntriesByJAID(rcpt.BID, JA[i].JAID)
+		le := rlib.GetLedgerEntriesByJAIDTx(tx, rcpt.BID, JA[i].JAID)
 		for k := 0; k < len(le); k++ {
 			nle := le[k]
 			nle.JAID = ja.JAID       // our newly created reversing Journal Allocation
 			nle.JID = ja.JID         // which is tied to the reversing Journal entry
 			nle.Amount = -nle.Amount // this reverses the amount
-			_, err = rlib.InsertLedgerEntry(&nle)
+			_, err = rlib.InsertLedgerEntryTx(tx, &nle)
 			if err != nil {
 				rlib.LogAndPrintError(funcname, err)
 				return err
@@ -395,7 +435,7 @@ func DeallocateAppliedFunds(a *rlib.Assessment, asmtRevID int64, dt *time.Time)
 		//-------------------------------------------------------------------------
 		// Next, reverse the receiptAllocation for this assessment...
 		//-------------------------------------------------------------------------
-		m := rlib.GetReceiptAllocationsByASMID(rcpt.BID, a.ASMID)
+		m := rlib.GetReceiptAllocationsByASMIDTx(tx, rcpt.BID, a.ASMID)
 		for k := 0; k < len(m); k++ {
 			m[k].FLAGS |= 0x4 // set bit 2 to indicate that this is a voided entry
 			vra := m[k]
@@ -403,11 +443,11 @@ func DeallocateAppliedFunds(a *rlib.Assessment, asmtRevID int64, dt *time.Time)
 			vra.AcctRule = acctrule
 			vra.Dt = *dt
 			vra.RAID = ja.RAID
-			_, err = rlib.InsertReceiptAllocation(&vra)
+			_, err = rlib.InsertReceiptAllocationTx(tx, &vra)
 			if err != nil {
 				return err
 			}
-			err := rlib.UpdateReceiptAllocation(&m[k]) // update its flags to indicate it is voided
+			err := rlib.UpdateReceiptAllocationTx(tx, &m[k]) // update its flags to indicate it is voided
 			if err != nil {
 				return err
 			}
@@ -418,7 +458,7 @@ func DeallocateAppliedFunds(a *rlib.Assessment, asmtRevID int64, dt *time.Time)
 		// are now available. This journal allocation (JA[i]) is being deallocated
 		// so those funds are now available from the receipt...
 		//-------------------------------------------------------------------------
-		rlib.GetReceiptAllocations(rcpt.RCPTID, &rcpt)
+		rlib.GetReceiptAllocationsTx(tx, rcpt.RCPTID, &rcpt)
 		rar := ""
 		for k := 0; k < len(rcpt.RA); k++ {
 			if rcpt.RA[k].ASMID == 0 {
@@ -444,14 +484,17 @@ func DeallocateAppliedFunds(a *rlib.Assessment, asmtRevID int64, dt *time.Time)
 		rcpt.FLAGS &= ^(uint64(0x3)) // remove whatever status was there before
 		rcpt.FLAGS |= f              // 0 = the entire amount is available, 1 = some is still available
 		rcpt.AcctRuleApply = rar
-		rlib.UpdateReceipt(&rcpt)
+		err = rlib.UpdateReceiptTx(tx, &rcpt)
+		if err != nil {
+			return err
+		}
 
 		//-------------------------------------------------------------------------
 		// Finally, update the assessment that was allocated payment from this receipt...
 		//-------------------------------------------------------------------------
-		unpaid := AssessmentUnpaidPortion(a) // how much of this assessment is still unpaid?
-		paid := a.Amount - unpaid            // how much remains to be paid
-		remaining := paid - JA[i].Amount     // how much remains after removing this allocation
+		unpaid := AssessmentUnpaidPortionTx(tx, a) // how much of this assessment is still unpaid?
+		paid := a.Amount - unpaid                  // how much remains to be paid
+		remaining := paid - JA[i].Amount           // how much remains after removing this allocation
 
 		newflags := uint64(0) // assume nothing has been paid on the assessment after this reversal
 		if remaining > 0 {    // if any portion has still been paid...
@@ -459,7 +502,7 @@ func DeallocateAppliedFunds(a *rlib.Assessment, asmtRevID int64, dt *time.Time)
 		}
 		a.FLAGS &= ^(uint64(0x3)) // clear the bits of interest
 		a.FLAGS |= newflags | 0x4 // set new status and mark as voided
-		err = rlib.UpdateAssessment(a)
+		err = rlib.UpdateAssessmentTx(tx, a)
 		if err != nil {
 			return err
 		}
@@ -479,6 +522,12 @@ func DeallocateAppliedFunds(a *rlib.Assessment, asmtRevID int64, dt *time.Time)
 //    a slice of BizErrors
 //-------------------------------------------------------------------------------------
 func InsertAssessment(a *rlib.Assessment, exp int) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return InsertAssessmentTx(tx, a, exp) })
+}
+
+// InsertAssessmentTx is InsertAssessment performed within transaction tx. If
+// tx is nil the database is used directly.
+func InsertAssessmentTx(tx *rlib.RRTx, a *rlib.Assessment, exp int) []BizError {
 	// funcname := "bizlogic.InsertAssessment"
 	// rlib.Console("Entered %s\n", funcname)
 	var errlist []BizError
@@ -506,7 +555,7 @@ func InsertAssessment(a *rlib.Assessment, exp int) []BizError {
 	}
 
 	// rlib.Console("B:   a = %#v\n", a)
-	_, err := rlib.InsertAssessment(a) // No bizlogic errors, save it
+	_, err := rlib.InsertAssessmentTx(tx, a) // No bizlogic errors, save it
 	if err != nil {
 		return bizErrSys(&err)
 	}
@@ -519,14 +568,18 @@ func InsertAssessment(a *rlib.Assessment, exp int) []BizError {
 	d1, d2 := rlib.GetMonthPeriodForDate(&a.Start) // TODO: probably needs to be more generalized
 	rlib.InitLedgerCache()
 	if a.RentCycle == rlib.RECURNONE { // for nonrecurring, use existng struct: a
-		rlib.ProcessJournalEntry(a, &xbiz, &d1, &d2, true)
+		if err = rlib.ProcessJournalEntryTx(tx, a, &xbiz, &d1, &d2, true); err != nil {
+			return bizErrSys(&err)
+		}
 	} else if exp != 0 && a.PASMID == 0 { // only expand if we're asked and if we're not an instance
 		// rlib.Console("C1\n")
 		now := rlib.DateAtTimeZero(time.Now())
 		dt := rlib.DateAtTimeZero(a.Start)
 		if !dt.After(now) {
 			// rlib.Console("C2\n")
-			createInstancesToDate(a, &xbiz)
+			if err = createInstancesToDate(tx, a, &xbiz); err != nil {
+				return bizErrSys(&err)
+			}
 		}
 	}
 	// rlib.Console("D\n")
@@ -596,14 +649,17 @@ func ValidateAssessment(a *rlib.Assessment) []BizError {
 // xbiz = Business information
 //
 // RETURNS
-//
+//    the first error encountered creating an instance
 //-------------------------------------------------------------------------------------
-func createInstancesToDate(a *rlib.Assessment, xbiz *rlib.XBusiness) {
+func createInstancesToDate(tx *rlib.RRTx, a *rlib.Assessment, xbiz *rlib.XBusiness) error {
 	now := time.Now()
 	as := time.Date(a.Start.Year(), a.Start.Month(), a.Start.Day(), 0, 0, 0, 0, time.UTC)
 	m := rlib.GetRecurrences(&a.Start, &a.Stop, &as, &now, a.RentCycle) // get all from the beginning up to now
 	for i := 0; i < len(m); i++ {
 		dt1, dt2 := rlib.GetMonthPeriodForDate(&m[i])
-		rlib.ProcessJournalEntry(a, xbiz, &dt1, &dt2, true) // this generates the assessment instances
+		if err := rlib.ProcessJournalEntryTx(tx, a, xbiz, &dt1, &dt2, true); err != nil { // this generates the assessment instances
+			return err
+		}
 	}
+	return nil
 }
@@ -0,0 +1,92 @@
+// +build sqlite
+
+package bizlogic
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+)
+
+func TestUpdateAssessmentRollback(t *testing.T) {
+	b := newTestBiz(t)
+	a := rlib.Assessment{BID: b.BID, RID: b.RID[0], RAID: b.RAID, ARID: b.ARID["Late Fee"], Amount: 50,
+		Start: rrtest.Dt(2017, 3, 5), Stop: rrtest.Dt(2017, 3, 5), RentCycle: rlib.RECURNONE}
+	if e := InsertAssessment(&a, 0); len(e) > 0 {
+		t.Fatalf("InsertAssessment: %s", bizErrString(e))
+	}
+	dt := rrtest.Dt(2017, 4, 1)
+	lateFees := func() float64 { return rlib.GetAccountBalance(b.BID, b.LID["42003"], &dt) }
+	before := bookCounts(t, b.BID)
+
+	//--------------------------------------------------------------------
+	// Changing the amount reverses the assessment and inserts a new one.
+	// Fail the insert of the new one, after the reversal has been made.
+	//--------------------------------------------------------------------
+	anew := a
+	anew.Amount = 75
+	done := failWhen(t, "INSERT ON Assessments WHEN NEW.Amount=75")
+	if e := UpdateAssessment(&anew, 0, &dt, 0); len(e) == 0 {
+		t.Fatalf("UpdateAssessment succeeded, the insert of the new assessment was made to fail")
+	}
+	done()
+	sameCounts(t, "after the failed update", before, bookCounts(t, b.BID))
+	if x, _ := rlib.GetAssessment(a.ASMID); x.FLAGS&0x4 != 0 || x.Amount != 50 {
+		t.Errorf("after the failed update: expect assessment %d unchanged, got amount %.2f flags %x", a.ASMID, x.Amount, x.FLAGS)
+	}
+	if bal := lateFees(); bal != -50 {
+		t.Errorf("Late Fees balance after the failed update: expect -50.00, got %.2f", bal)
+	}
+
+	anew = a
+	anew.Amount = 75
+	if e := UpdateAssessment(&anew, 0, &dt, 0); len(e) > 0 {
+		t.Fatalf("UpdateAssessment: %s", bizErrString(e))
+	}
+	if n := countRows(t, "Assessments", b.BID); n != before["Assessments"]+2 {
+		t.Errorf("expect the reversal and the new assessment, got %d assessments", n)
+	}
+	if bal := lateFees(); bal != -75 {
+		t.Errorf("Late Fees balance after the update: expect -75.00, got %.2f", bal)
+	}
+}
+
+// A failure to write any of the journal or ledger records of a new
+// assessment rolls back the whole insert, the assessment included
+func TestInsertAssessmentRollback(t *testing.T) {
+	b := newTestBiz(t)
+	lateFee := rlib.Assessment{BID: b.BID, RID: b.RID[0], RAID: b.RAID, ARID: b.ARID["Late Fee"], Amount: 50,
+		Start: rrtest.Dt(2017, 3, 5), Stop: rrtest.Dt(2017, 3, 5), RentCycle: rlib.RECURNONE}
+	rent := rlib.Assessment{BID: b.BID, RID: b.RID[0], RAID: b.RAID, ARID: b.ARID["Rent"], Amount: rrtest.MarketRate,
+		Start: rrtest.BizStart, Stop: rrtest.RAStop, RentCycle: rlib.RECURMONTHLY, ProrationCycle: rlib.RECURDAILY}
+	m := []struct {
+		what  string
+		a     rlib.Assessment
+		event string
+	}{
+		{"late fee, allocation fails", lateFee, "INSERT ON JournalAllocation"},
+		{"late fee, ledger entry fails", lateFee, "INSERT ON LedgerEntry"},
+		{"rent instances, allocation fails", rent, "INSERT ON JournalAllocation"},
+		{"rent instances, ledger entry fails", rent, "INSERT ON LedgerEntry"},
+	}
+	before := bookCounts(t, b.BID)
+	for i := 0; i < len(m); i++ {
+		a := m[i].a
+		done := failWhen(t, m[i].event)
+		e := InsertAssessment(&a, 1)
+		done()
+		if len(e) == 0 {
+			t.Fatalf("%s: InsertAssessment succeeded", m[i].what)
+		}
+		sameCounts(t, m[i].what, before, bookCounts(t, b.BID))
+	}
+
+	a := lateFee
+	if e := InsertAssessment(&a, 0); len(e) > 0 {
+		t.Fatalf("InsertAssessment: %s", bizErrString(e))
+	}
+	after := bookCounts(t, b.BID)
+	if after["Assessments"] != before["Assessments"]+1 || after["JournalAllocation"] != before["JournalAllocation"]+1 || after["LedgerEntry"] != before["LedgerEntry"]+2 {
+		t.Errorf("expect an assessment, its allocation and 2 ledger entries, got %v", after)
+	}
+}
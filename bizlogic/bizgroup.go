@@ -0,0 +1,48 @@
+package bizlogic
+
+import "rentroll/rlib"
+
+// SaveBusinessGroup validates and saves a business group and replaces its
+// list of member businesses with bids.
+//
+// INPUTS
+//    a    = the group to save. If a.BGID is 0 a new group is created.
+//    bids = the businesses in the group
+//
+// RETURNS
+//    a slice of BizErrors
+//-----------------------------------------------------------------------------
+func SaveBusinessGroup(a *rlib.BusinessGroup, bids []int64) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return SaveBusinessGroupTx(tx, a, bids) })
+}
+
+// SaveBusinessGroupTx is SaveBusinessGroup performed within transaction tx. If
+// tx is nil the database is used directly.
+func SaveBusinessGroupTx(tx *rlib.RRTx, a *rlib.BusinessGroup, bids []int64) []BizError {
+	var e []BizError
+	if len(a.Name) == 0 {
+		return AddBizErrToList(e, MissingName)
+	}
+	g, err := rlib.GetBusinessGroupByName(a.Name)
+	if err == nil && g.BGID > 0 && g.BGID != a.BGID {
+		return AddBizErrToList(e, DuplicateName)
+	}
+	if a.BGID == 0 {
+		_, err = rlib.InsertBusinessGroupTx(tx, a)
+	} else {
+		err = rlib.UpdateBusinessGroupTx(tx, a)
+	}
+	if err != nil {
+		return AddErrToBizErrlist(err, e)
+	}
+	if err = rlib.DeleteBusinessGroupMembersTx(tx, a.BGID); err != nil {
+		return AddErrToBizErrlist(err, e)
+	}
+	for i := 0; i < len(bids); i++ {
+		m := rlib.BusinessGroupMember{BGID: a.BGID, BID: bids[i], CreateBy: a.LastModBy}
+		if err = rlib.InsertBusinessGroupMemberTx(tx, &m); err != nil {
+			return AddErrToBizErrlist(err, e)
+		}
+	}
+	return nil
+}
@@ -17,6 +17,13 @@ import (
 //  d - the Depository where the funds will be deposited.
 //--------------------------------------------------------------
 func EnsureReceiptFundsToDepositoryAccount(r *rlib.Receipt, asmid int64, d *rlib.Depository, deposit *rlib.Deposit) error {
+	return rlib.RunInTx(func(tx *rlib.RRTx) error { return EnsureReceiptFundsToDepositoryAccountTx(tx, r, asmid, d, deposit) })
+}
+
+// EnsureReceiptFundsToDepositoryAccountTx is
+// EnsureReceiptFundsToDepositoryAccount performed within transaction tx. If tx
+// is nil the database is used directly.
+func EnsureReceiptFundsToDepositoryAccountTx(tx *rlib.RRTx, r *rlib.Receipt, asmid int64, d *rlib.Depository, deposit *rlib.Deposit) error {
 	var xbiz rlib.XBusiness
 	var err error
 	funcname := "EnsureReceiptFundsToDepositoryAccount"
@@ -44,7 +51,7 @@ func EnsureReceiptFundsToDepositoryAccount(r *rlib.Receipt, asmid int64, d *rlib
 			ID:      r.RCPTID,
 			Comment: fmt.Sprintf("auto-transfer for deposit %s", deposit.IDtoShortString()),
 		}
-		_, err = rlib.InsertJournal(&jnl)
+		_, err = rlib.InsertJournalTx(tx, &jnl)
 		if err != nil {
 			rlib.LogAndPrintError(funcname, err)
 			return err
@@ -62,7 +69,7 @@ func EnsureReceiptFundsToDepositoryAccount(r *rlib.Receipt, asmid int64, d *rlib
 			TCID:   r.TCID,
 			RCPTID: r.RCPTID,
 		}
-		err = rlib.InsertJournalAllocationEntry(&ja)
+		err = rlib.InsertJournalAllocationEntryTx(tx, &ja)
 		if err != nil {
 			rlib.LogAndPrintError(funcname, err)
 			return err
@@ -83,7 +90,7 @@ func EnsureReceiptFundsToDepositoryAccount(r *rlib.Receipt, asmid int64, d *rlib
 			LID:    d.LID,
 			Amount: r.Amount,
 		}
-		_, err = rlib.InsertLedgerEntry(&l)
+		_, err = rlib.InsertLedgerEntryTx(tx, &l)
 		if err != nil {
 			rlib.LogAndPrintError(funcname, err)
 			return err
@@ -91,7 +98,7 @@ func EnsureReceiptFundsToDepositoryAccount(r *rlib.Receipt, asmid int64, d *rlib
 
 		l.LID = ar.DebitLID
 		l.Amount = -r.Amount
-		_, err = rlib.InsertLedgerEntry(&l)
+		_, err = rlib.InsertLedgerEntryTx(tx, &l)
 		if err != nil {
 			rlib.LogAndPrintError(funcname, err)
 			return err
@@ -107,7 +114,7 @@ func EnsureReceiptFundsToDepositoryAccount(r *rlib.Receipt, asmid int64, d *rlib
 		ra.BID = r.BID
 		ra.Dt = r.Dt
 		ra.RAID = r.RAID
-		_, err = rlib.InsertReceiptAllocation(&ra)
+		_, err = rlib.InsertReceiptAllocationTx(tx, &ra)
 		if err != nil {
 			return err
 		}
@@ -128,6 +135,12 @@ func EnsureReceiptFundsToDepositoryAccount(r *rlib.Receipt, asmid int64, d *rlib
 //	errlist - an array of errors
 //-----------------------------------------------------------------------
 func SaveDeposit(a *rlib.Deposit, newRcpts []int64) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return SaveDepositTx(tx, a, newRcpts) })
+}
+
+// SaveDepositTx is SaveDeposit performed within transaction tx. If tx is nil
+// the database is used directly.
+func SaveDepositTx(tx *rlib.RRTx, a *rlib.Deposit, newRcpts []int64) []BizError {
 	rlib.Console("SaveDeposit: 0\n")
 	var e []BizError
 	var rlist []rlib.Receipt
@@ -137,7 +150,7 @@ func SaveDeposit(a *rlib.Deposit, newRcpts []int64) []BizError {
 	// in this receipt
 	//------------------------------------------------------------
 	for i := 0; i < len(newRcpts); i++ {
-		r := rlib.GetReceipt(newRcpts[i])
+		r := rlib.GetReceiptTx(tx, newRcpts[i])
 		tot += r.Amount
 		if r.DID != 0 && r.DID != a.DID {
 			s := fmt.Sprintf(BizErrors[ReceiptAlreadyDeposited].Message, rlib.IDtoShortString("RCPT", r.RCPTID), rlib.IDtoShortString("D", r.DID))
@@ -171,7 +184,7 @@ func SaveDeposit(a *rlib.Deposit, newRcpts []int64) []BizError {
 	// Save the deposit
 	//------------------------------------------------------------
 	if a.DID == 0 {
-		_, err := rlib.InsertDeposit(a)
+		_, err := rlib.InsertDepositTx(tx, a)
 		if err != nil {
 			e = AddErrToBizErrlist(err, e)
 		}
@@ -181,14 +194,14 @@ func SaveDeposit(a *rlib.Deposit, newRcpts []int64) []BizError {
 				BID:    a.BID,
 				RCPTID: newRcpts[i],
 			}
-			err = rlib.InsertDepositPart(&dp)
+			err = rlib.InsertDepositPartTx(tx, &dp)
 			if err != nil {
 				e = AddErrToBizErrlist(err, e)
 				continue
 			}
 			if rlist[i].DID == 0 {
 				rlist[i].DID = a.DID
-				err = rlib.UpdateReceipt(&rlist[i])
+				err = rlib.UpdateReceiptTx(tx, &rlist[i])
 				if err != nil {
 					e = AddErrToBizErrlist(err, e)
 					continue
@@ -211,22 +224,22 @@ func SaveDeposit(a *rlib.Deposit, newRcpts []int64) []BizError {
 			// compare to LID for the Depository
 			if debitLID != dep.LID { // if they're not the same, transfer to the appropriate account
 				asmid := int64(0) // assume no auto-gen Assessment is associated with this receipt
-				ja := rlib.GetJournalAllocationByASMandRCPTID(newRcpts[i])
+				ja := rlib.GetJournalAllocationByASMandRCPTIDTx(tx, newRcpts[i])
 				if len(ja) == 1 { // if there is an associated auto-gen'd assessment
-					asmt, err := rlib.GetAssessment(ja[0].ASMID)
+					asmt, err := rlib.GetAssessmentTx(tx, ja[0].ASMID)
 					if err != nil {
 						return AddErrToBizErrlist(err, e)
 					}
 					asmid = asmt.ASMID // use the correct ASMID
 				}
-				err = EnsureReceiptFundsToDepositoryAccount(&rlist[i], asmid, &dep, a)
+				err = EnsureReceiptFundsToDepositoryAccountTx(tx, &rlist[i], asmid, &dep, a)
 				if err != nil {
 					return AddErrToBizErrlist(err, e)
 				}
 			}
 		}
 	} else {
-		err := rlib.UpdateDeposit(a)
+		err := rlib.UpdateDepositTx(tx, a)
 		if err != nil {
 			e = AddErrToBizErrlist(err, e)
 		}
@@ -238,7 +251,7 @@ func SaveDeposit(a *rlib.Deposit, newRcpts []int64) []BizError {
 		// link the addlist, and unlink the removelist.  The new Receipts are
 		// already provided in newRcpts.
 		//---------------------------------------------------------------------------
-		curDepParts, err := rlib.GetDepositParts(a.DID)
+		curDepParts, err := rlib.GetDepositPartsTx(tx, a.DID)
 		if err != nil {
 			e = AddErrToBizErrlist(err, e)
 			return e
@@ -274,13 +287,13 @@ func SaveDeposit(a *rlib.Deposit, newRcpts []int64) []BizError {
 		// Remove the deposit link in the removelist receipts...
 		//--------------------------------------------------------
 		for i := 0; i < len(removelist); i++ {
-			r := rlib.GetReceipt(removelist[i])
+			r := rlib.GetReceiptTx(tx, removelist[i])
 			if r.RCPTID == 0 {
 				err := fmt.Errorf("could not load receipt %d", removelist[i])
 				e = AddErrToBizErrlist(err, e)
 			}
 			r.DID = 0
-			err := rlib.UpdateReceipt(&r)
+			err := rlib.UpdateReceiptTx(tx, &r)
 			if err != nil {
 				e = AddErrToBizErrlist(err, e)
 			}
@@ -289,7 +302,7 @@ func SaveDeposit(a *rlib.Deposit, newRcpts []int64) []BizError {
 			//---------------------------------------
 			for j := 0; j < len(curDepParts); j++ {
 				if curDepParts[j].RCPTID == removelist[i] {
-					err = rlib.DeleteDepositPart(curDepParts[j].DPID)
+					err = rlib.DeleteDepositPartTx(tx, curDepParts[j].DPID)
 					if err != nil {
 						e = AddErrToBizErrlist(err, e)
 					}
@@ -302,13 +315,13 @@ func SaveDeposit(a *rlib.Deposit, newRcpts []int64) []BizError {
 		// Add the deposit link in the addlist receipts...
 		//--------------------------------------------------------
 		for i := 0; i < len(addlist); i++ {
-			r := rlib.GetReceipt(addlist[i])
+			r := rlib.GetReceiptTx(tx, addlist[i])
 			if r.RCPTID == 0 {
 				err := fmt.Errorf("could not load receipt %d", addlist[i])
 				e = AddErrToBizErrlist(err, e)
 			}
 			r.DID = a.DID
-			err := rlib.UpdateReceipt(&r)
+			err := rlib.UpdateReceiptTx(tx, &r)
 			if err != nil {
 				e = AddErrToBizErrlist(err, e)
 			}
@@ -320,7 +333,7 @@ func SaveDeposit(a *rlib.Deposit, newRcpts []int64) []BizError {
 				BID:    a.BID,
 				RCPTID: r.RCPTID,
 			}
-			err = rlib.InsertDepositPart(&dp)
+			err = rlib.InsertDepositPartTx(tx, &dp)
 			if err != nil {
 				e = AddErrToBizErrlist(err, e)
 			}
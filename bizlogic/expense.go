@@ -10,6 +10,12 @@ import (
 // it returns immediately.
 //-----------------------------------------------------------------------------
 func ReverseExpense(aold *rlib.Expense, dt *time.Time) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return ReverseExpenseTx(tx, aold, dt) })
+}
+
+// ReverseExpenseTx is ReverseExpense performed within transaction tx. If tx is
+// nil the database is used directly.
+func ReverseExpenseTx(tx *rlib.RRTx, aold *rlib.Expense, dt *time.Time) []BizError {
 	var errlist []BizError
 	if aold.FLAGS&0x4 != 0 {
 		return nil // it's already reversed
@@ -22,16 +28,18 @@ func ReverseExpense(aold *rlib.Expense, dt *time.Time) []BizError {
 	anew.FLAGS |= 0x4 // set bit 2 to mark that this expense is void
 	anew.Comment = fmt.Sprintf("Reversal of %s", aold.IDtoShortString())
 
-	err := rlib.InsertExpense(&anew)
+	err := rlib.InsertExpenseTx(tx, &anew)
 	if err != nil {
 		return bizErrSys(&err)
 	}
 	var xbiz rlib.XBusiness
-	rlib.ProcessNewExpense(&anew, &xbiz)
+	if err = rlib.ProcessNewExpenseTx(tx, &anew, &xbiz); err != nil {
+		return bizErrSys(&err)
+	}
 
 	aold.Comment = fmt.Sprintf("Reversed by %s", anew.IDtoShortString())
 	aold.FLAGS |= 0x4 // set bit 2 to mark that this expense is void
-	err = rlib.UpdateExpense(aold)
+	err = rlib.UpdateExpenseTx(tx, aold)
 	if err != nil {
 		return bizErrSys(&err)
 	}
@@ -51,6 +59,12 @@ func ReverseExpense(aold *rlib.Expense, dt *time.Time) []BizError {
 //    a slice of BizErrors
 //-------------------------------------------------------------------------------------
 func UpdateExpense(anew *rlib.Expense, dt *time.Time) []BizError {
+	return runInTx(func(tx *rlib.RRTx) []BizError { return UpdateExpenseTx(tx, anew, dt) })
+}
+
+// UpdateExpenseTx is UpdateExpense performed within transaction tx. If tx is
+// nil the database is used directly.
+func UpdateExpenseTx(tx *rlib.RRTx, anew *rlib.Expense, dt *time.Time) []BizError {
 	var err error
 	var errlist []BizError
 
@@ -64,7 +78,7 @@ func UpdateExpense(anew *rlib.Expense, dt *time.Time) []BizError {
 	//-------------------------------
 	// Load existing expense...
 	//-------------------------------
-	aold, err := rlib.GetExpense(anew.EXPID)
+	aold, err := rlib.GetExpenseTx(tx, anew.EXPID)
 	if err != nil {
 		return bizErrSys(&err)
 	}
@@ -80,17 +94,17 @@ func UpdateExpense(anew *rlib.Expense, dt *time.Time) []BizError {
 	//   Dt
 	//---------------------------------------------------------------------------------
 	if aold.ARID != anew.ARID || aold.Amount != anew.Amount || (!aold.Dt.Equal(anew.Dt)) {
-		errlist = ReverseExpense(&aold, dt) // reverse the expense itself
+		errlist = ReverseExpenseTx(tx, &aold, dt) // reverse the expense itself
 		if errlist != nil {
 			return errlist
 		}
 		anew.EXPID = 0 // need to insert a new record with the updated info
-		err := rlib.InsertExpense(anew)
+		err := rlib.InsertExpenseTx(tx, anew)
 		if err != nil {
 			return bizErrSys(&err)
 		}
 	} else {
-		err = rlib.UpdateExpense(anew) // reversal not needed, just update the expense
+		err = rlib.UpdateExpenseTx(tx, anew) // reversal not needed, just update the expense
 		if err != nil {
 			return bizErrSys(&err)
 		}
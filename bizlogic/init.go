@@ -44,6 +44,22 @@ const (
 	RentableNameExists              = 20 // A rentable with that name already exists
 	AsmDateRangeNotAllowed          = 21 // Non recur asmts must have equivalent start/stop dates
 	StartDateAfterStopDate          = 22 // Stop date occurs before start date
+	VendorNotFound                  = 23 // the vendor does not exist in this business
+	BillItemsTotalMismatch          = 24 // the sum of the bill items does not match the bill amount
+	NoPayablesAccount               = 25 // the business has no Accounts Payable account
+	BadExpenseAccount               = 26 // a bill item's expense account is not valid
+	BillNotFound                    = 27 // the bill does not exist in this business
+	BillOverpayment                 = 28 // the payment is more than the unpaid balance of the bill
+	BillReversed                    = 29 // the bill has been reversed
+	InvalidDepository               = 30 // the depository does not exist in this business
+	InvalidWebhookURL               = 31 // the webhook url is not an absolute http or https url
+	UnknownReport                   = 32 // no report has the supplied name
+	InvalidReportDateRule           = 33 // the report schedule date rule is not one of the RSDATE values
+	InvalidReportOutputFormat       = 34 // the report output format is not supported
+	InvalidReportCycle              = 35 // the report schedule cycle is not supported
+	InvalidEmailAddress             = 36 // an email address could not be parsed
+	InvalidReportDirectory          = 37 // the report directory is absolute or leaves the report archive
+	InvalidPaymentAmount            = 38 // a payment amount is zero or negative
 )
 
 // InitBizLogic loads the error messages needed for validation errors
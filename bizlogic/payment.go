@@ -37,11 +37,17 @@ const ROUNDINGERR = float64(0.000999)
 // of all unpaid assessments associated with these Rental Agreements.
 //-----------------------------------------------------------------------------
 func GetAllUnpaidAssessmentsForPayor(bid, tcid int64, dt *time.Time) []rlib.Assessment {
+	return GetAllUnpaidAssessmentsForPayorTx(nil, bid, tcid, dt)
+}
+
+// GetAllUnpaidAssessmentsForPayorTx is GetAllUnpaidAssessmentsForPayor
+// performed within transaction tx. If tx is nil the database is used directly.
+func GetAllUnpaidAssessmentsForPayorTx(tx *rlib.RRTx, bid, tcid int64, dt *time.Time) []rlib.Assessment {
 	var a []rlib.Assessment
 	m := rlib.GetRentalAgreementsByPayor(bid, tcid, dt) // Determine which Rental Agreements the Payor is responsible for...
 	// rlib.Console("GetAllUnpaidAssessmentsForPayor: date = %s, len(m) = %d\n", dt.Format(rlib.RRDATEFMTSQL), len(m))
 	for i := 0; i < len(m); i++ { // build the list of unpaid assessments
-		n := rlib.GetUnpaidAssessmentsByRAID(m[i].RAID) // the list is presorted by Start date ascending
+		n := rlib.GetUnpaidAssessmentsByRAIDTx(tx, m[i].RAID) // the list is presorted by Start date ascending
 		// rlib.Console("Unpaid assessment count for RA-%d: %d\n", m[i].RAID, len(n))
 		a = append(a, n...)
 	}
@@ -82,7 +88,13 @@ func RemainingReceiptFunds(r *rlib.Receipt) float64 {
 // receipt on the supplied date
 //--------------------------------------------------------------------------
 func RemainingReceiptFundsOnDate(a *rlib.Receipt, dt *time.Time) float64 {
-	m := rlib.GetReceiptAllocationsThroughDate(a.RCPTID, dt)
+	return RemainingReceiptFundsOnDateTx(nil, a, dt)
+}
+
+// RemainingReceiptFundsOnDateTx is RemainingReceiptFundsOnDate performed
+// within transaction tx. If tx is nil the database is used directly.
+func RemainingReceiptFundsOnDateTx(tx *rlib.RRTx, a *rlib.Receipt, dt *time.Time) float64 {
+	m := rlib.GetReceiptAllocationsThroughDateTx(tx, a.RCPTID, dt)
 	amt := a.Amount
 	for i := 0; i < len(m); i++ {
 		amt -= m[i].Amount
@@ -94,12 +106,18 @@ func RemainingReceiptFundsOnDate(a *rlib.Receipt, dt *time.Time) float64 {
 // assessment.
 //--------------------------------------------------------------------------
 func AssessmentUnpaidPortion(a *rlib.Assessment) float64 {
+	return AssessmentUnpaidPortionTx(nil, a)
+}
+
+// AssessmentUnpaidPortionTx is AssessmentUnpaidPortion performed within
+// transaction tx. If tx is nil the database is used directly.
+func AssessmentUnpaidPortionTx(tx *rlib.RRTx, a *rlib.Assessment) float64 {
 	funcname := "AssessmentUnpaidPortion"
 	switch a.FLAGS & 3 {
 	case 0:
 		return a.Amount
 	case 1:
-		ra := rlib.GetReceiptAllocationsByASMID(a.BID, a.ASMID)
+		ra := rlib.GetReceiptAllocationsByASMIDTx(tx, a.BID, a.ASMID)
 		bal := a.Amount
 		for i := 0; i < len(ra); i++ {
 			bal -= ra[i].Amount
@@ -134,6 +152,12 @@ func AssessmentUnpaidPortion(a *rlib.Assessment) float64 {
 //  dt     - timestamp to mark on the allocation for this payment
 //--------------------------------------------------------------------------
 func PayAssessment(a *rlib.Assessment, rcpt *rlib.Receipt, needed *float64, amt *float64, dt *time.Time) error {
+	return rlib.RunInTx(func(tx *rlib.RRTx) error { return PayAssessmentTx(tx, a, rcpt, needed, amt, dt) })
+}
+
+// PayAssessmentTx is PayAssessment performed within transaction tx. If tx is
+// nil the database is used directly.
+func PayAssessmentTx(tx *rlib.RRTx, a *rlib.Assessment, rcpt *rlib.Receipt, needed *float64, amt *float64, dt *time.Time) error {
 	funcname := "PayAssessment"
 
 	amtToUse := *amt
@@ -174,7 +198,7 @@ func PayAssessment(a *rlib.Assessment, rcpt *rlib.Receipt, needed *float64, amt
 	cacct := rlib.RRdb.BizTypes[a.BID].GLAccounts[car.DebitLID]  // we credit what was debited in the Assessments ARID
 
 	ra.AcctRule = fmt.Sprintf("ASM(%d) d %s %.2f,c %s %.2f", a.ASMID, dacct.GLNumber, amtToUse, cacct.GLNumber, amtToUse)
-	_, err := rlib.InsertReceiptAllocation(&ra)
+	_, err := rlib.InsertReceiptAllocationTx(tx, &ra)
 	if err != nil {
 		rlib.LogAndPrintError(funcname, err)
 		return err
@@ -195,7 +219,7 @@ func PayAssessment(a *rlib.Assessment, rcpt *rlib.Receipt, needed *float64, amt
 		a.FLAGS |= 1 // 1 = partially paid
 		// rlib.Console("Partially paid assessment %d\n", a.ASMID)
 	}
-	err = rlib.UpdateAssessment(a)
+	err = rlib.UpdateAssessmentTx(tx, a)
 	if err != nil {
 		rlib.LogAndPrintError(funcname, err)
 		return err
@@ -222,7 +246,7 @@ func PayAssessment(a *rlib.Assessment, rcpt *rlib.Receipt, needed *float64, amt
 	} else {
 		rcpt.AcctRuleApply = ra.AcctRule
 	}
-	err = rlib.UpdateReceipt(rcpt)
+	err = rlib.UpdateReceiptTx(tx, rcpt)
 	if err != nil {
 		rlib.LogAndPrintError(funcname, err)
 		return err
@@ -242,7 +266,7 @@ func PayAssessment(a *rlib.Assessment, rcpt *rlib.Receipt, needed *float64, amt
 		Type:   rlib.JNLTYPERCPT,
 		ID:     rcpt.RCPTID,
 	}
-	_, err = rlib.InsertJournal(&jnl)
+	_, err = rlib.InsertJournalTx(tx, &jnl)
 	if err != nil {
 		rlib.LogAndPrintError(funcname, err)
 		return err
@@ -259,7 +283,11 @@ func PayAssessment(a *rlib.Assessment, rcpt *rlib.Receipt, needed *float64, amt
 		TCID:     rcpt.TCID,
 		RCPTID:   rcpt.RCPTID,
 	}
-	rlib.InsertJournalAllocationEntry(&ja)
+	err = rlib.InsertJournalAllocationEntryTx(tx, &ja)
+	if err != nil {
+		rlib.LogAndPrintError(funcname, err)
+		return err
+	}
 	jnl.JA = append(jnl.JA, ja)
 
 	//-------------------------------------------------------------------------
@@ -276,7 +304,7 @@ func PayAssessment(a *rlib.Assessment, rcpt *rlib.Receipt, needed *float64, amt
 		LID:    dacct.LID,
 		Amount: amtToUse,
 	}
-	_, err = rlib.InsertLedgerEntry(&l)
+	_, err = rlib.InsertLedgerEntryTx(tx, &l)
 	if err != nil {
 		rlib.LogAndPrintError(funcname, err)
 		return err
@@ -284,7 +312,7 @@ func PayAssessment(a *rlib.Assessment, rcpt *rlib.Receipt, needed *float64, amt
 
 	l.LID = cacct.LID
 	l.Amount = -amtToUse
-	_, err = rlib.InsertLedgerEntry(&l)
+	_, err = rlib.InsertLedgerEntryTx(tx, &l)
 	if err != nil {
 		rlib.LogAndPrintError(funcname, err)
 		return err
@@ -305,6 +333,12 @@ func PayAssessment(a *rlib.Assessment, rcpt *rlib.Receipt, needed *float64, amt
 //  any error encountered
 //--------------------------------------------------------------------------
 func AutoAllocatePayorReceipts(tcid int64, dt *time.Time) error {
+	return rlib.RunInTx(func(tx *rlib.RRTx) error { return AutoAllocatePayorReceiptsTx(tx, tcid, dt) })
+}
+
+// AutoAllocatePayorReceiptsTx is AutoAllocatePayorReceipts performed within
+// transaction tx. If tx is nil the database is used directly.
+func AutoAllocatePayorReceiptsTx(tx *rlib.RRTx, tcid int64, dt *time.Time) error {
 	// funcname := "AutoAllocatePayorReceipts"
 	// rlib.Console("Entered %s\n", funcname)
 	var t rlib.Transactant
@@ -312,8 +346,8 @@ func AutoAllocatePayorReceipts(tcid int64, dt *time.Time) error {
 		rlib.Console("error getting GetTransactant(%d): %s\n", tcid, err.Error())
 		return err
 	}
-	m := GetAllUnpaidAssessmentsForPayor(t.BID, tcid, dt)
-	n := rlib.GetUnallocatedReceiptsByPayor(t.BID, tcid)
+	m := GetAllUnpaidAssessmentsForPayorTx(tx, t.BID, tcid, dt)
+	n := rlib.GetUnallocatedReceiptsByPayorTx(tx, t.BID, tcid)
 
 	// rlib.Console("Unpaid assessments for payor = %s %s: %d\n", t.FirstName, t.LastName, len(m))
 	// rlib.Console("The receipts to be allocated to pay the assessments: %d\n\n", len(n))
@@ -329,16 +363,16 @@ func AutoAllocatePayorReceipts(tcid int64, dt *time.Time) error {
 				continue // move on to the next receipt
 			}
 			// First, determine the amount needed for payment...
-			needed := AssessmentUnpaidPortion(&m[i])
+			needed := AssessmentUnpaidPortionTx(tx, &m[i])
 			amt := RemainingReceiptFunds(&n[j])
 			// rlib.Console("Needed for ASMID %d :  %.2f\n", m[i].ASMID, needed)
 			// rlib.Console("Funds remaining in receipt %d:  %.2f\n", n[j].RCPTID, amt)
-			a, err := rlib.GetAssessment(m[i].ASMID)
+			a, err := rlib.GetAssessmentTx(tx, m[i].ASMID)
 			if err != nil {
 				return err
 			}
 			paymentDate := a.Start
-			err = PayAssessment(&m[i], &n[j], &needed, &amt, &paymentDate)
+			err = PayAssessmentTx(tx, &m[i], &n[j], &needed, &amt, &paymentDate)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,48 @@
+// +build sqlite
+
+package bizlogic
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+)
+
+// A failure to write the journal allocation of a payment rolls back the
+// payment, the receipt allocation and journal entry included
+func TestPayAssessmentRollback(t *testing.T) {
+	b := newTestBiz(t)
+	a := rlib.Assessment{BID: b.BID, RID: b.RID[0], RAID: b.RAID, ARID: b.ARID["Late Fee"], Amount: 50,
+		Start: rrtest.Dt(2017, 3, 5), Stop: rrtest.Dt(2017, 3, 5), RentCycle: rlib.RECURNONE}
+	if e := InsertAssessment(&a, 0); len(e) > 0 {
+		t.Fatalf("InsertAssessment: %s", bizErrString(e))
+	}
+	r := rlib.Receipt{BID: b.BID, TCID: b.TCID, PMTID: b.PMTID, RAID: b.RAID, ARID: b.ARID["Receive Payment"],
+		Dt: rrtest.Dt(2017, 3, 6), DocNo: "1234", Amount: 500}
+	if err := InsertReceipt(&r); err != nil {
+		t.Fatalf("InsertReceipt: %s", err.Error())
+	}
+	dt := rrtest.Dt(2017, 3, 6)
+	before := bookCounts(t, b.BID)
+
+	done := failWhen(t, "INSERT ON JournalAllocation")
+	needed, amt := a.Amount, a.Amount
+	x := rlib.GetReceipt(r.RCPTID)
+	err := PayAssessment(&a, &x, &needed, &amt, &dt)
+	done()
+	if err == nil {
+		t.Fatalf("PayAssessment succeeded, the journal allocation insert was made to fail")
+	}
+	sameCounts(t, "after the failed payment", before, bookCounts(t, b.BID))
+
+	needed, amt = a.Amount, a.Amount
+	x = rlib.GetReceipt(r.RCPTID)
+	if err = PayAssessment(&a, &x, &needed, &amt, &dt); err != nil {
+		t.Fatalf("PayAssessment: %s", err.Error())
+	}
+	after := bookCounts(t, b.BID)
+	if after["Journal"] != before["Journal"]+1 || after["JournalAllocation"] != before["JournalAllocation"]+1 || after["LedgerEntry"] != before["LedgerEntry"]+2 {
+		t.Errorf("expect 1 journal entry, 1 allocation and 2 ledger entries for the payment, got %d, %d and %d",
+			after["Journal"]-before["Journal"], after["JournalAllocation"]-before["JournalAllocation"], after["LedgerEntry"]-before["LedgerEntry"])
+	}
+}
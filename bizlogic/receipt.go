@@ -30,6 +30,12 @@ import (
 //    err = any error that was encountered.
 //-------------------------------------------------------------------------------
 func UpdateReceipt(rnew *rlib.Receipt, dt *time.Time) error {
+	return rlib.RunInTx(func(tx *rlib.RRTx) error { return UpdateReceiptTx(tx, rnew, dt) })
+}
+
+// UpdateReceiptTx is UpdateReceipt performed within transaction tx. If tx is
+// nil the database is used directly.
+func UpdateReceiptTx(tx *rlib.RRTx, rnew *rlib.Receipt, dt *time.Time) error {
 	// funcname := "bizlogic.UpdateReceipt"
 
 	if rnew.FLAGS&0x4 != 0 {
@@ -44,7 +50,7 @@ func UpdateReceipt(rnew *rlib.Receipt, dt *time.Time) error {
 	//-------------------------------
 	// Load existing receipt...
 	//-------------------------------
-	rold := rlib.GetReceipt(rnew.RCPTID)
+	rold := rlib.GetReceiptTx(tx, rnew.RCPTID)
 	if rold.RCPTID == 0 {
 		return fmt.Errorf("Receipt %d not found", rnew.RCPTID)
 	}
@@ -58,11 +64,11 @@ func UpdateReceipt(rnew *rlib.Receipt, dt *time.Time) error {
 	//---------------------------------------------------------------------------------
 	reverse := (!rold.Dt.Equal(rnew.Dt)) || rold.Amount != rnew.Amount || rold.ARID != rnew.ARID || rold.RAID != rnew.RAID
 	if reverse {
-		err := ReverseReceipt(&rold, dt) // reverse the receipt itself
+		err := ReverseReceiptTx(tx, &rold, dt) // reverse the receipt itself
 		if err != nil {
 			return err
 		}
-		err = InsertReceipt(rnew) // Insert the new receipt...
+		err = InsertReceiptTx(tx, rnew) // Insert the new receipt...
 		if err != nil {
 			return err
 		}
@@ -72,23 +78,23 @@ func UpdateReceipt(rnew *rlib.Receipt, dt *time.Time) error {
 				BID:    rnew.BID,
 				RCPTID: rnew.RCPTID,
 			}
-			if err = rlib.InsertDepositPart(&dp); err != nil {
+			if err = rlib.InsertDepositPartTx(tx, &dp); err != nil {
 				return err
 			}
 		}
 		// the deposit total may have changed...
 		if rold.Amount != rnew.Amount && rnew.DID > 0 {
-			dep, err := rlib.GetDeposit(rnew.DID)
+			dep, err := rlib.GetDepositTx(tx, rnew.DID)
 			if err != nil {
 				return err
 			}
 			dep.Amount = dep.Amount - rold.Amount + rnew.Amount
-			return rlib.UpdateDeposit(&dep)
+			return rlib.UpdateDepositTx(tx, &dep)
 		}
 		return nil
 	}
 
-	return rlib.UpdateReceipt(rnew) // reversal not needed, just update the receipt
+	return rlib.UpdateReceiptTx(tx, rnew) // reversal not needed, just update the receipt
 }
 
 // ReverseReceipt reverses the supplied receipt. It links the
@@ -97,6 +103,12 @@ func UpdateReceipt(rnew *rlib.Receipt, dt *time.Time) error {
 //    any error that occurred, or nil if no error
 //-------------------------------------------------------------------------------
 func ReverseReceipt(r *rlib.Receipt, dt *time.Time) error {
+	return rlib.RunInTx(func(tx *rlib.RRTx) error { return ReverseReceiptTx(tx, r, dt) })
+}
+
+// ReverseReceiptTx is ReverseReceipt performed within transaction tx. If tx is
+// nil the database is used directly.
+func ReverseReceiptTx(tx *rlib.RRTx, r *rlib.Receipt, dt *time.Time) error {
 	var err error
 
 	if r.FLAGS&0x04 != 0 {
@@ -108,7 +120,7 @@ func ReverseReceipt(r *rlib.Receipt, dt *time.Time) error {
 	// and reverse any allocation that was applied towards an Assessment
 	//----------------------------------------------------------------------
 	if len(r.RA) == 0 { // if RA slice is empty, it could be because they were not loaded
-		rlib.GetReceiptAllocations(r.RCPTID, r) // try to load them just to make sure
+		rlib.GetReceiptAllocationsTx(tx, r.RCPTID, r) // try to load them just to make sure
 	}
 
 	//------------------------------------------------------
@@ -121,7 +133,7 @@ func ReverseReceipt(r *rlib.Receipt, dt *time.Time) error {
 	rr.PRCPTID = r.RCPTID     // link to parent
 	rr.FLAGS |= rlib.RCPTvoid // mark that it is voided
 	rr.RA = []rlib.ReceiptAllocation{}
-	if err = insertReceiptInternal(&rr, r, dt); err != nil {
+	if err = insertReceiptInternal(tx, &rr, r, dt); err != nil {
 		return err
 	}
 	//-----------------------------------------------------------
@@ -132,7 +144,7 @@ func ReverseReceipt(r *rlib.Receipt, dt *time.Time) error {
 	for i := 0; i < len(rr.RA); i++ {
 		rlib.Console("Before FLAGS update, rr.RA[i].FLAGS = %d\n", rr.RA[i].FLAGS)
 		rr.RA[i].FLAGS |= rlib.RCPTvoid
-		if err = rlib.UpdateReceiptAllocation(&rr.RA[i]); err != nil {
+		if err = rlib.UpdateReceiptAllocationTx(tx, &rr.RA[i]); err != nil {
 			return err
 		}
 		rlib.Console("Reverse Receipt loc 1: updated ReceiptAllocation: RCPAID = %d, FLAGS = %d\n", rr.RA[i].RCPAID, rr.RA[i].FLAGS)
@@ -147,7 +159,7 @@ func ReverseReceipt(r *rlib.Receipt, dt *time.Time) error {
 			BID:    rr.BID,
 			RCPTID: rr.RCPTID,
 		}
-		err := rlib.InsertDepositPart(&dp)
+		err := rlib.InsertDepositPartTx(tx, &dp)
 		if err != nil {
 			return err
 		}
@@ -159,7 +171,7 @@ func ReverseReceipt(r *rlib.Receipt, dt *time.Time) error {
 	for i := 0; i < len(r.RA); i++ {
 		rlib.Console("Second Try: Before FLAGS update, rr.RA[i].FLAGS = %d\n", r.RA[i].FLAGS)
 		r.RA[i].FLAGS |= rlib.RCPTvoid
-		if err := rlib.UpdateReceiptAllocation(&r.RA[i]); err != nil {
+		if err := rlib.UpdateReceiptAllocationTx(tx, &r.RA[i]); err != nil {
 			return err
 		}
 		rlib.Console("Reverse Receipt loc 2: updated ReceiptAllocation: RCPAID = %d, FLAGS = %d\n", r.RA[i].RCPAID, r.RA[i].FLAGS)
@@ -189,7 +201,7 @@ func ReverseReceipt(r *rlib.Receipt, dt *time.Time) error {
 		}
 		ra.AcctRule = acctrule
 		rlib.Console("Reverse Receipt loc 3: updated ReceiptAllocation: RCPAID = %d, FLAGS = %d\n", ra.RCPAID, ra.FLAGS)
-		_, err := rlib.InsertReceiptAllocation(&ra)
+		_, err := rlib.InsertReceiptAllocationTx(tx, &ra)
 		if err != nil {
 			return err
 		}
@@ -204,7 +216,7 @@ func ReverseReceipt(r *rlib.Receipt, dt *time.Time) error {
 		r.Comment += ", "
 	}
 	r.Comment += fmt.Sprintf("Reversed by receipt %s", rr.IDtoString())
-	err = rlib.UpdateReceipt(r)
+	err = rlib.UpdateReceiptTx(tx, r)
 	if err != nil {
 		return err
 	}
@@ -213,7 +225,7 @@ func ReverseReceipt(r *rlib.Receipt, dt *time.Time) error {
 	// reverse any payments allocated from this receipt...
 	//------------------------------------------------------
 	if (r.FLAGS & 0x3) > 0 {
-		ReverseAllocation(r, rr.RCPTID, dt)
+		err = ReverseAllocationTx(tx, r, rr.RCPTID, dt)
 	}
 
 	return err
@@ -228,6 +240,12 @@ func ReverseReceipt(r *rlib.Receipt, dt *time.Time) error {
 //    any error that occurred, or nil if no error
 //-------------------------------------------------------------------------------
 func ReverseAllocation(r *rlib.Receipt, revRCPTID int64, dt *time.Time) error {
+	return rlib.RunInTx(func(tx *rlib.RRTx) error { return ReverseAllocationTx(tx, r, revRCPTID, dt) })
+}
+
+// ReverseAllocationTx is ReverseAllocation performed within transaction tx. If
+// tx is nil the database is used directly.
+func ReverseAllocationTx(tx *rlib.RRTx, r *rlib.Receipt, revRCPTID int64, dt *time.Time) error {
 	funcname := "bizlogic.ReverseAllocation"
 	var err error
 
@@ -236,13 +254,13 @@ func ReverseAllocation(r *rlib.Receipt, revRCPTID int64, dt *time.Time) error {
 	// r.RCPTID. If it represents a payment allocation then
 	// reverse it.
 	//------------------------------------------------------
-	m := rlib.GetJournalsByReceiptID(r.RCPTID)
+	m := rlib.GetJournalsByReceiptIDTx(tx, r.RCPTID)
 	for i := 0; i < len(m); i++ {
 		//-----------------------------------------------------------
 		// Reverse all the JournalAllocation entries in which
 		// the funds of r have been applied to a receipt.
 		//-----------------------------------------------------------
-		rlib.GetJournalAllocations(&m[i]) // load all its allocations
+		rlib.GetJournalAllocationsTx(tx, &m[i]) // load all its allocations
 		if len(m[i].JA) == 0 {
 			continue
 		}
@@ -261,7 +279,7 @@ func ReverseAllocation(r *rlib.Receipt, revRCPTID int64, dt *time.Time) error {
 				Dt:     *dt,          // reversal date
 				Type:   rlib.JNLTYPERCPT,
 			}
-			_, err = rlib.InsertJournal(&jnl)
+			_, err = rlib.InsertJournalTx(tx, &jnl)
 			if err != nil {
 				rlib.LogAndPrintError(funcname, err)
 				return err
@@ -290,19 +308,23 @@ func ReverseAllocation(r *rlib.Receipt, revRCPTID int64, dt *time.Time) error {
 				TCID:     r.TCID,
 				RCPTID:   revRCPTID,
 			}
-			rlib.InsertJournalAllocationEntry(&ja)
+			err = rlib.InsertJournalAllocationEntryTx(tx, &ja)
+			if err != nil {
+				rlib.LogAndPrintError(funcname, err)
+				return err
+			}
 			jnl.JA = append(jnl.JA, ja)
 
 			//-------------------------------------------------------------------------
 			// Next, reverse the ledger entries...
 			//-------------------------------------------------------------------------
-			le := rlib.GetLedgerEntriesByJAID(r.BID, m[i].JA[j].JAID)
+			le := rlib.GetLedgerEntriesByJAIDTx(tx, r.BID, m[i].JA[j].JAID)
 			for k := 0; k < len(le); k++ {
 				nle := le[k]
 				nle.JAID = ja.JAID       // our newly created reversing Journal Allocation
 				nle.JID = ja.JID         // which is tied to the reversing Journal entry
 				nle.Amount = -nle.Amount // this reverses the amount
-				_, err = rlib.InsertLedgerEntry(&nle)
+				_, err = rlib.InsertLedgerEntryTx(tx, &nle)
 				if err != nil {
 					rlib.LogAndPrintError(funcname, err)
 					return err
@@ -312,13 +334,13 @@ func ReverseAllocation(r *rlib.Receipt, revRCPTID int64, dt *time.Time) error {
 			//-------------------------------------------------------------------------
 			// Finally, update the assessment that was allocated payment from this receipt...
 			//-------------------------------------------------------------------------
-			a, err := rlib.GetAssessment(m[i].JA[j].ASMID)
+			a, err := rlib.GetAssessmentTx(tx, m[i].JA[j].ASMID)
 			if err != nil {
 				return err
 			}
-			unpaid := AssessmentUnpaidPortion(&a) // how much of this assessment is still unpaid?
-			paid := a.Amount - unpaid             // how much remains to be paid
-			remaining := paid - m[i].Amount       // how much remains after removing this allocation
+			unpaid := AssessmentUnpaidPortionTx(tx, &a) // how much of this assessment is still unpaid?
+			paid := a.Amount - unpaid                   // how much remains to be paid
+			remaining := paid - m[i].Amount             // how much remains after removing this allocation
 
 			newflags := uint64(0) // assume nothing has been paid on the assessment after this reversal
 			if remaining > 0 {    // if any portion has still been paid...
@@ -328,7 +350,7 @@ func ReverseAllocation(r *rlib.Receipt, revRCPTID int64, dt *time.Time) error {
 			b = ^b              // flip the bits
 			a.FLAGS &= b        // clear those bits in FLAGS
 			a.FLAGS |= newflags // set new status
-			err = rlib.UpdateAssessment(&a)
+			err = rlib.UpdateAssessmentTx(tx, &a)
 			if err != nil {
 				return err
 			}
@@ -342,13 +364,19 @@ func ReverseAllocation(r *rlib.Receipt, revRCPTID int64, dt *time.Time) error {
 	b = ^b
 	r.FLAGS &= b   // remove any payment related flags that might confuse anyone
 	r.FLAGS |= 0x4 // set bit 2 to indicate that it has been voided
-	return rlib.UpdateReceipt(r)
+	return rlib.UpdateReceiptTx(tx, r)
 }
 
 // InsertReceipt adds a new receipt and updates the journal and ledgers
 //-------------------------------------------------------------------------------
 func InsertReceipt(a *rlib.Receipt) error {
-	return insertReceiptInternal(a, nil, nil)
+	return rlib.RunInTx(func(tx *rlib.RRTx) error { return InsertReceiptTx(tx, a) })
+}
+
+// InsertReceiptTx is InsertReceipt performed within transaction tx. If tx is
+// nil the database is used directly.
+func InsertReceiptTx(tx *rlib.RRTx, a *rlib.Receipt) error {
+	return insertReceiptInternal(tx, a, nil, nil)
 }
 
 // insertReceiptInternal adds a new receipt and updates the journal and ledgers,
@@ -361,7 +389,7 @@ func InsertReceipt(a *rlib.Receipt) error {
 //           one being reversed)
 //  dt     - if a reversal is made, use this date for the reversal
 //-------------------------------------------------------------------------------
-func insertReceiptInternal(a, origin *rlib.Receipt, dt *time.Time) error {
+func insertReceiptInternal(tx *rlib.RRTx, a, origin *rlib.Receipt, dt *time.Time) error {
 
 	funcname := "bizlogic.InsertReceipt"
 
@@ -388,7 +416,7 @@ func insertReceiptInternal(a, origin *rlib.Receipt, dt *time.Time) error {
 	if errlist != nil {
 		return BizErrorListToError(errlist)
 	}
-	_, err := rlib.InsertReceipt(a)
+	_, err := rlib.InsertReceiptTx(tx, a)
 	if err != nil {
 		return err
 	}
@@ -410,16 +438,16 @@ func insertReceiptInternal(a, origin *rlib.Receipt, dt *time.Time) error {
 				if a.FLAGS&(1<<2) != 0 { // this receipt is a reversal. We need to reverse the associated assessment
 					var agasmt rlib.Assessment
 					q := fmt.Sprintf("SELECT %s FROM Assessments WHERE AGRCPTID=%d", rlib.RRdb.DBFields["Assessments"], origin.RCPTID)
-					row := rlib.RRdb.Dbrr.QueryRow(q)
+					row := tx.QueryRow(q)
 					rlib.ReadAssessment(row, &agasmt)
 					if agasmt.ASMID > 0 {
-						be := ReverseAssessment(&agasmt, 0, dt)
+						be := ReverseAssessmentTx(tx, &agasmt, 0, dt)
 						if len(be) > 0 {
 							return BizErrorListToError(be)
 						}
 					}
 				} else {
-					asmt, be := CreateSubAssessment(&sub, a) // create a new assessment that this receipt pays for
+					asmt, be := CreateSubAssessmentTx(tx, &sub, a) // create a new assessment that this receipt pays for
 					if len(be) > 0 {
 						return BizErrorListToError(be)
 					}
@@ -434,7 +462,7 @@ func insertReceiptInternal(a, origin *rlib.Receipt, dt *time.Time) error {
 					ra.BID = a.BID
 					ra.Dt = a.Dt
 					ra.RAID = a.RAID
-					_, err = rlib.InsertReceiptAllocation(&ra)
+					_, err = rlib.InsertReceiptAllocationTx(tx, &ra)
 					if err != nil {
 						return err
 					}
@@ -454,7 +482,7 @@ func insertReceiptInternal(a, origin *rlib.Receipt, dt *time.Time) error {
 	ra.BID = a.BID
 	ra.Dt = a.Dt
 	ra.RAID = a.RAID
-	_, err = rlib.InsertReceiptAllocation(&ra)
+	_, err = rlib.InsertReceiptAllocationTx(tx, &ra)
 	if err != nil {
 		return err
 	}
@@ -466,7 +494,7 @@ func insertReceiptInternal(a, origin *rlib.Receipt, dt *time.Time) error {
 	d1 := time.Date(a.Dt.Year(), a.Dt.Month(), 1, 0, 0, 0, 0, rlib.RRdb.Zone)
 	mon, year := rlib.IncMonths(a.Dt.Month(), int64(a.Dt.Year()))
 	d2 := time.Date(int(year), mon, 1, 0, 0, 0, 0, rlib.RRdb.Zone)
-	jnl, err := rlib.ProcessNewReceipt(&xbiz, &d1, &d2, a)
+	jnl, err := rlib.ProcessNewReceiptTx(tx, &xbiz, &d1, &d2, a)
 	if err != nil {
 		e := fmt.Errorf("%s:  Error in rlib.ProcessNewReceipt: %s", funcname, err.Error())
 		rlib.Ulog("%s", e.Error())
@@ -476,9 +504,13 @@ func insertReceiptInternal(a, origin *rlib.Receipt, dt *time.Time) error {
 	//------------------------------------------------
 	// Add it to the Ledgers
 	//------------------------------------------------
-	rlib.GetJournalAllocations(&jnl)
+	rlib.GetJournalAllocationsTx(tx, &jnl)
 	rlib.InitLedgerCache()
-	rlib.GenerateLedgerEntriesFromJournal(&xbiz, &jnl, &d1, &d2)
+	if _, err = rlib.GenerateLedgerEntriesFromJournalTx(tx, &xbiz, &jnl, &d1, &d2); err != nil {
+		e := fmt.Errorf("%s:  Error in rlib.GenerateLedgerEntriesFromJournal: %s", funcname, err.Error())
+		rlib.Ulog("%s", e.Error())
+		return e
+	}
 
 	return nil
 }
@@ -495,6 +527,18 @@ func insertReceiptInternal(a, origin *rlib.Receipt, dt *time.Time) error {
 //
 //-----------------------------------------------------------------------------
 func CreateSubAssessment(sub *rlib.AR, a *rlib.Receipt) (rlib.Assessment, []BizError) {
+	var b rlib.Assessment
+	errlist := runInTx(func(tx *rlib.RRTx) []BizError {
+		var be []BizError
+		b, be = CreateSubAssessmentTx(tx, sub, a)
+		return be
+	})
+	return b, errlist
+}
+
+// CreateSubAssessmentTx is CreateSubAssessment performed within transaction
+// tx. If tx is nil the database is used directly.
+func CreateSubAssessmentTx(tx *rlib.RRTx, sub *rlib.AR, a *rlib.Receipt) (rlib.Assessment, []BizError) {
 	var b rlib.Assessment
 	// for any value not set below, the default value is correct
 	b.BID = a.BID
@@ -506,16 +550,16 @@ func CreateSubAssessment(sub *rlib.AR, a *rlib.Receipt) (rlib.Assessment, []BizE
 	b.Stop = a.Dt
 	b.Comment = "Auto-generated by Account Rule (" + sub.Name + ")"
 	b.FLAGS = a.FLAGS
-	be := InsertAssessment(&b, 0)
+	be := InsertAssessmentTx(tx, &b, 0)
 
 	//--------------------------------------------------------------------
 	// The JournalAllocation record associated with this assessment must
 	// now be updated with a.RCPTID to bind the two together
 	//--------------------------------------------------------------------
-	m := rlib.GetJournalAllocationByASMID(b.ASMID)
+	m := rlib.GetJournalAllocationByASMIDTx(tx, b.ASMID)
 	for i := 0; i < len(m); i++ {
 		m[i].RCPTID = a.RCPTID
-		err := rlib.UpdateJournalAllocation(&m[i])
+		err := rlib.UpdateJournalAllocationTx(tx, &m[i])
 		if err != nil {
 			be = AddErrToBizErrlist(err, be)
 		}
@@ -0,0 +1,52 @@
+// +build sqlite
+
+package bizlogic
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+)
+
+func TestReverseReceiptRollback(t *testing.T) {
+	b := newTestBiz(t)
+	r := rlib.Receipt{BID: b.BID, TCID: b.TCID, PMTID: b.PMTID, RAID: b.RAID, ARID: b.ARID["Receive Payment"],
+		Dt: rrtest.Dt(2017, 3, 5), DocNo: "1234", Amount: 500}
+	if err := InsertReceipt(&r); err != nil {
+		t.Fatalf("InsertReceipt: %s", err.Error())
+	}
+	dt := rrtest.Dt(2017, 4, 1)
+	undeposited := func() float64 { return rlib.GetAccountBalance(b.BID, b.LID["10999"], &dt) }
+	before := bookCounts(t, b.BID)
+
+	//--------------------------------------------------------------------
+	// The reversal receipt, its allocation, journal and ledger entries
+	// are inserted before the allocations are marked void. Fail there.
+	//--------------------------------------------------------------------
+	done := failWhen(t, "UPDATE ON ReceiptAllocation")
+	x := rlib.GetReceipt(r.RCPTID)
+	if err := ReverseReceipt(&x, &dt); err == nil {
+		t.Fatalf("ReverseReceipt succeeded, the receipt allocation updates were made to fail")
+	}
+	done()
+	sameCounts(t, "after the failed reversal", before, bookCounts(t, b.BID))
+	if x = rlib.GetReceipt(r.RCPTID); x.FLAGS&0x4 != 0 {
+		t.Errorf("after the failed reversal: expect receipt %d not reversed, got flags %x", r.RCPTID, x.FLAGS)
+	}
+	if bal := undeposited(); bal != 500 {
+		t.Errorf("Undeposited Funds balance after the failed reversal: expect 500.00, got %.2f", bal)
+	}
+
+	if err := ReverseReceipt(&x, &dt); err != nil {
+		t.Fatalf("ReverseReceipt: %s", err.Error())
+	}
+	if n := countRows(t, "Receipt", b.BID); n != before["Receipt"]+1 {
+		t.Errorf("expect the reversal receipt, got %d receipts", n)
+	}
+	if x = rlib.GetReceipt(r.RCPTID); x.FLAGS&0x4 == 0 {
+		t.Errorf("expect receipt %d reversed, got flags %x", r.RCPTID, x.FLAGS)
+	}
+	if bal := undeposited(); bal != 0 {
+		t.Errorf("Undeposited Funds balance after the reversal: expect 0.00, got %.2f", bal)
+	}
+}
@@ -0,0 +1,67 @@
+package bizlogic
+
+import (
+	"net/mail"
+	"path/filepath"
+	"rentroll/rlib"
+	"rentroll/rrpt"
+	"strings"
+	"time"
+)
+
+// SaveReportSchedule validates the supplied report schedule and writes it
+// to the database. If a.RSID is 0 a new schedule is created, otherwise the
+// existing schedule is updated. A schedule with no NextRun runs the next
+// time the ReportSchedules worker checks.
+//
+// INPUTS
+//    a = the report schedule to save
+//
+// RETURNS
+//    a slice of BizErrors
+//-----------------------------------------------------------------------------
+func SaveReportSchedule(a *rlib.ReportSchedule) []BizError {
+	var e []BizError
+	if len(a.Name) == 0 {
+		return AddBizErrToList(e, MissingName)
+	}
+	tsh, tmh := rrpt.FindReportHandler(a.Report)
+	if !tsh.Found && !tmh.Found {
+		e = append(e, bizErrf(UnknownReport, a.Report))
+	}
+	if a.DateRule < 0 || a.DateRule > rlib.RSDATELAST {
+		e = append(e, bizErrf(InvalidReportDateRule, a.DateRule))
+	}
+	if _, ok := rrpt.ReportOutputExt[int(a.OutputFormat)]; !ok {
+		e = append(e, bizErrf(InvalidReportOutputFormat, a.OutputFormat))
+	}
+	if a.Cycle != rlib.CYCLENORECUR && (a.Cycle < rlib.CYCLEDAILY || a.Cycle > rlib.CYCLEYEARLY) {
+		e = append(e, bizErrf(InvalidReportCycle, a.Cycle))
+	}
+	for _, s := range rlib.ReportScheduleRecipients(a.Recipients) {
+		if _, err := mail.ParseAddress(s); err != nil {
+			e = append(e, bizErrf(InvalidEmailAddress, s))
+		}
+	}
+	d := filepath.Clean(a.Directory)
+	if filepath.IsAbs(d) || d == ".." || strings.HasPrefix(d, ".."+string(filepath.Separator)) {
+		e = append(e, bizErrf(InvalidReportDirectory, a.Directory))
+	}
+	if len(e) > 0 {
+		return e
+	}
+	if a.NextRun.IsZero() {
+		a.NextRun = time.Now()
+	}
+
+	var err error
+	if a.RSID == 0 {
+		_, err = rlib.InsertReportSchedule(a)
+	} else {
+		err = rlib.UpdateReportSchedule(a)
+	}
+	if err != nil {
+		return AddErrToBizErrlist(err, e)
+	}
+	return nil
+}
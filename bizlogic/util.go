@@ -1,6 +1,39 @@
 package bizlogic
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"rentroll/rlib"
+)
+
+// errRollback is returned to rlib.RunInTx by runInTx to roll back a unit of
+// work that failed with BizErrors
+var errRollback = errors.New("bizlogic: unit of work failed")
+
+// runInTx runs f as a single database transaction. Every exported bizlogic
+// operation that updates more than one record runs this way so that a
+// failure part way through leaves the books untouched.
+//
+// INPUTS
+//  f = the unit of work, it must do all of its database updates through tx
+//
+// RETURNS
+//  the errors returned by f, in which case the transaction was rolled back,
+//  or the error beginning or committing the transaction
+//-------------------------------------------------------------------------------------
+func runInTx(f func(tx *rlib.RRTx) []BizError) []BizError {
+	var errlist []BizError
+	err := rlib.RunInTx(func(tx *rlib.RRTx) error {
+		if errlist = f(tx); len(errlist) > 0 {
+			return errRollback
+		}
+		return nil
+	})
+	if err != nil && err != errRollback {
+		errlist = append(errlist, BizError{Errno: 0, Message: err.Error()})
+	}
+	return errlist
+}
 
 // bizErrSys just encapsulates returning an error in a []BizError.  The Errno
 // is set to 0.
@@ -0,0 +1,84 @@
+// +build sqlite
+
+package bizlogic
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"strings"
+	"testing"
+)
+
+// newTestBiz opens a new test database with one business in it and loads
+// the BizError messages
+func newTestBiz(t *testing.T) *rrtest.Biz {
+	if len(BizErrors) == 0 {
+		m := rlib.LoadCSV("bizerr.csv")
+		for i := 0; i < len(m); i++ {
+			BizErrors = append(BizErrors, BizError{Errno: i, Message: m[i][1]})
+		}
+	}
+	rrtest.OpenDB(t)
+	return rrtest.NewBusiness(t, "REX")
+}
+
+// hasBizErr returns true if errno is in e
+func hasBizErr(e []BizError, errno int) bool {
+	for i := 0; i < len(e); i++ {
+		if e[i].Errno == errno {
+			return true
+		}
+	}
+	return false
+}
+
+// bizErrString returns the messages of e on one line
+func bizErrString(e []BizError) string {
+	var m []string
+	for i := 0; i < len(e); i++ {
+		m = append(m, e[i].Message)
+	}
+	return strings.Join(m, "; ")
+}
+
+// countRows returns the number of rows of business bid in table
+func countRows(t *testing.T, table string, bid int64) int {
+	var n int
+	if err := rlib.RRdb.Dbrr.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE BID=?", bid).Scan(&n); err != nil {
+		t.Fatalf("cannot count %s: %s", table, err.Error())
+	}
+	return n
+}
+
+// failWhen makes the database fail every statement matching event (for
+// example "INSERT ON Assessments WHEN NEW.Amount=75") until the returned
+// function is called. It forces a unit of work to fail part way through.
+func failWhen(t *testing.T, event string) func() {
+	if _, err := rlib.RRdb.Dbrr.Exec("CREATE TRIGGER FailWhen BEFORE " + event + " BEGIN SELECT RAISE(ABORT, 'forced failure'); END"); err != nil {
+		t.Fatalf("cannot create trigger: %s", err.Error())
+	}
+	return func() {
+		if _, err := rlib.RRdb.Dbrr.Exec("DROP TRIGGER FailWhen"); err != nil {
+			t.Fatalf("cannot drop trigger: %s", err.Error())
+		}
+	}
+}
+
+// bookCounts returns the number of rows in the tables a unit of work can
+// change for business bid
+func bookCounts(t *testing.T, bid int64) map[string]int {
+	m := map[string]int{}
+	for _, table := range []string{"Assessments", "Receipt", "ReceiptAllocation", "Journal", "JournalAllocation", "LedgerEntry"} {
+		m[table] = countRows(t, table, bid)
+	}
+	return m
+}
+
+// sameCounts reports every table whose row count differs between a and b
+func sameCounts(t *testing.T, what string, a, b map[string]int) {
+	for k, v := range a {
+		if b[k] != v {
+			t.Errorf("%s: expect %d %s rows, got %d", what, v, k, b[k])
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package bizlogic
+
+import (
+	"net/url"
+	"rentroll/rlib"
+)
+
+// SaveWebhook validates the supplied webhook and writes it to the database.
+// If a.WHID is 0 a new webhook is created, otherwise the existing webhook
+// is updated.
+//
+// INPUTS
+//    a = the webhook to save
+//
+// RETURNS
+//    a slice of BizErrors
+//-----------------------------------------------------------------------------
+func SaveWebhook(a *rlib.Webhook) []BizError {
+	var e []BizError
+	u, err := url.Parse(a.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return append(e, bizErrf(InvalidWebhookURL, a.URL))
+	}
+	if a.WHID == 0 {
+		_, err = rlib.InsertWebhook(a)
+	} else {
+		err = rlib.UpdateWebhook(a)
+	}
+	if err != nil {
+		return AddErrToBizErrlist(err, e)
+	}
+	return nil
+}
@@ -0,0 +1,656 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gotable"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"rentroll/rcsv"
+	"rentroll/rlib"
+	"rentroll/rrpt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The subcommands run a single report or processing action in batch mode.
+// They are the named form of the -r report numbers:
+//
+//     rentroll [global options] command [options]
+//
+// Every command accepts -b, -j and -k for the business and the period.  The
+// ones that produce a report also accept -format text|csv|json|html|pdf|xlsx
+// and -o file.  Processing actions report what they did as a one row table so
+// that a script can read their result in any format.  Errors are written to
+// stderr, or as a json error object when the format is json, and the process
+// exits with status 1.  A report whose error section, Section3, is set has
+// failed and is handled the same way; a report with no rows is not an error.
+// Bad arguments exit with status 2.
+
+// cliOption describes an option that a subcommand may accept
+type cliOption struct {
+	Bool  bool   // true if the option is a switch that takes no value
+	Value string // default value
+	Usage string // description shown in the help
+}
+
+// cliOptions are the options shared by the subcommands.  Each command lists
+// the ones it accepts by name so that the same option means the same thing
+// everywhere.
+var cliOptions = map[string]cliOption{
+	"b":        {Usage: "business unit designator (BUD); defaults to the global -b"},
+	"j":        {Usage: "period start, as 2018-02-01; defaults to the global -j"},
+	"k":        {Usage: "period stop, as 2018-03-01; defaults to the global -k"},
+	"format":   {Value: "text", Usage: "output format: text, csv, json, html, pdf or xlsx"},
+	"o":        {Usage: "write the output to this file instead of stdout"},
+	"date":     {Usage: "date of the report, as 2018-02-15; defaults to the stop date"},
+	"lid":      {Usage: "ledger, as L004 or 4"},
+	"raid":     {Usage: "rental agreement, as RA003 or 3"},
+	"rid":      {Usage: "rentable, as R004 or 4"},
+	"invoice":  {Usage: "invoice, as IN0001 or 1"},
+	"tcid":     {Usage: "payor, as TC0035 or 35"},
+	"year":     {Usage: "year; defaults to the year of the stop date"},
+	"report":   {Usage: "consolidated report: tb, is, rr, delinq or occ"},
+	"biz":      {Usage: "business groups, BUDs or BIDs separated by ':'; all businesses if omitted"},
+	"type":     {Value: rlib.GLEXPORTIIF, Usage: "export file type: iif or csv"},
+	"reexport": {Bool: true, Usage: "include entries that were already exported"},
+	"preview":  {Bool: true, Usage: "do not record the export"},
+	"trend":    {Bool: true, Usage: "one row per month for the whole business"},
+	"months":   {Usage: "months to project, 12 - 36"},
+	"renew":    {Usage: "renewal probability in percent"},
+	"downtime": {Usage: "vacancy days when a lease is not renewed"},
+	"term":     {Usage: "months of a renewed or new lease"},
+	"internal": {Bool: true, Usage: "show unapplied funds from other payors"},
+	"dir":      {Usage: "write each payor's statement to its own PDF file in this directory"},
+	"repair":   {Bool: true, Usage: "repair the problems that are safe to repair"},
+	"x":        {Bool: true, Usage: "inhibit vacancy checking"},
+}
+
+// cliCommand is a subcommand.  Report generates the tables of a report or
+// of the result of a processing action, which are written in the format
+// chosen with -format.  Raw is for the few commands whose output has a format
+// of its own; it writes to w.
+type cliCommand struct {
+	Name    string                                   // name on the command line
+	Rpt     int                                      // the equivalent -r report number
+	Descr   string                                   // one line description
+	Opts    []string                                 // the cliOptions it accepts besides b, j, k, format and o
+	Report  func(c *cliCtx) ([]gotable.Table, error) // generates the output tables
+	Raw     func(c *cliCtx, w io.Writer) error       // writes its own output
+	RawText bool                                     // Raw writes to stdout itself and cannot use -o
+}
+
+// cliCtx is the state of a subcommand being run
+type cliCtx struct {
+	cmd   *cliCommand
+	ctx   DispatchCtx
+	ri    rrpt.ReporterInfo
+	rof   int                // output format
+	out   string             // output file name, stdout if empty
+	title string             // page header for multi-table pdf output
+	sopt  map[string]*string // string options
+	bopt  map[string]*bool   // switches
+	qp    url.Values         // query parameters for the report
+}
+
+// cliUsageError is an error in the arguments of a subcommand
+type cliUsageError struct {
+	cmd string
+	msg string
+}
+
+func (e *cliUsageError) Error() string {
+	return fmt.Sprintf("%s: %s", e.cmd, e.msg)
+}
+
+// cliCommands is the list of subcommands, in the order they are listed by
+// help
+var cliCommands = []cliCommand{
+	{Name: "process", Rpt: 0, Descr: "generate journal records then ledger entries for the period", Opts: []string{"x"}, Report: cliProcess},
+	{Name: "gen-journals", Rpt: 18, Descr: "generate journal records for the period", Opts: []string{"x"}, Report: cliGenJournals},
+	{Name: "gen-ledgers", Rpt: 19, Descr: "generate ledger entries for the period", Report: cliGenLedgers},
+	{Name: "gen-vacancy", Rpt: 15, Descr: "generate vacancy journal records for the period", Report: cliGenVacancy},
+	{Name: "gen-ledger-markers", Rpt: 16, Descr: "generate ledger markers as of the stop date", Report: cliGenLedgerMarkers},
+	{Name: "rebuild-ledgers", Rpt: 29, Descr: "remove and regenerate the ledger entries of the period", Report: cliRebuildLedgers},
+	{Name: "delete-business", Rpt: 22, Descr: "delete every record of the business", Report: cliDeleteBusiness},
+	{Name: "backup-files", Rpt: 21, Descr: "list the database backup files", Report: cliBackupFiles},
+	{Name: "journal", Rpt: 1, Descr: "journal report", Report: cliTable(rrpt.JournalReportTable)},
+	{Name: "ledger", Rpt: 2, Descr: "ledger report, one table per ledger", Report: cliTables(rrpt.LedgerReportTable, "Ledger")},
+	{Name: "rentroll", Rpt: 4, Descr: "rentroll report", Report: cliTable(rrpt.RRReportTable)},
+	{Name: "rentable-counts", Rpt: 7, Descr: "count of rentables by rentable type", Report: cliTable(rrpt.RentableCountByRentableTypeReportTable)},
+	{Name: "statements", Rpt: 8, Descr: "rental agreement statements", Report: cliTables(rrpt.RptStatementReportTable, "Statement")},
+	{Name: "invoice", Rpt: 9, Descr: "print an invoice, text only", Opts: []string{"invoice"}, Raw: cliInvoice, RawText: true},
+	{Name: "ledger-activity", Rpt: 10, Descr: "ledger activity report, one table per ledger", Report: cliTables(rrpt.LedgerActivityReportTable, "Ledger Activity")},
+	{Name: "gsr", Rpt: 11, Descr: "rentable gross scheduled rent", Report: cliTable(rrpt.GSRReportTable)},
+	{Name: "ra-balance", Rpt: 12, Descr: "balance of a rental agreement's ledger on a date", Opts: []string{"lid", "raid", "date"}, Report: cliRABalance},
+	{Name: "ra-activity", Rpt: 13, Descr: "activity of a rental agreement's ledger over the period", Opts: []string{"lid", "raid"}, Report: cliRAActivity},
+	{Name: "delinquency", Rpt: 14, Descr: "delinquency report as of a date", Opts: []string{"date"}, Report: cliDelinquency},
+	{Name: "ledger-balance", Rpt: 17, Descr: "ledger balance report", Report: cliTable(rrpt.LedgerBalanceReportTable)},
+	{Name: "market-rates", Rpt: 20, Descr: "market rates of a rentable over the period", Opts: []string{"rid"}, Report: cliMarketRates},
+	{Name: "payor-statement", Rpt: 23, Descr: "internal view of a payor's statement", Opts: []string{"tcid"}, Report: cliPayorStatement},
+	{Name: "ap-aging", Rpt: 24, Descr: "accounts payable aging as of the stop date", Report: cliTable(rrpt.APAgingReportTable)},
+	{Name: "vendor-1099", Rpt: 25, Descr: "vendor 1099 totals for a year", Opts: []string{"year"}, Report: cliVendor1099},
+	{Name: "consolidated", Rpt: 26, Descr: "consolidated report over several businesses", Opts: []string{"report", "biz"}, Report: cliConsolidated},
+	{Name: "export-gl", Rpt: 27, Descr: "export journal entries for an accounting system", Opts: []string{"type", "reexport", "preview", "o"}, Raw: cliExportGL},
+	{Name: "verify-posting", Rpt: 28, Descr: "compare the ledgers with a full rebuild, changes nothing", Report: cliVerifyPosting},
+	{Name: "occupancy", Rpt: 30, Descr: "occupancy report", Opts: []string{"trend"}, Report: cliOccupancy},
+	{Name: "snapshot", Rpt: 31, Descr: "the rentroll snapshot of the period", Report: cliTable(rrpt.RRSnapshotTable)},
+	{Name: "snapshot-take", Rpt: 31, Descr: "save the rentroll of the period as a snapshot", Report: cliSnapshotTake},
+	{Name: "snapshot-variance", Rpt: 31, Descr: "compare the snapshot of the period with the one before it", Report: cliTable(rrpt.RRVarianceTable)},
+	{Name: "forecast", Rpt: 32, Descr: "rent and occupancy forecast", Opts: []string{"months", "renew", "downtime", "term"}, Report: cliForecast},
+	{Name: "payor-statements", Rpt: 33, Descr: "statements of all the payors", Opts: []string{"tcid", "internal", "dir"}, Report: cliPayorStatements},
+	{Name: "integrity", Rpt: 34, Descr: "check the integrity of journals, ledgers and allocations", Opts: []string{"repair"}, Report: cliIntegrity},
+}
+
+// cliLookup returns the subcommand named name, or nil if there is none
+func cliLookup(name string) *cliCommand {
+	for i := 0; i < len(cliCommands); i++ {
+		if cliCommands[i].Name == name {
+			return &cliCommands[i]
+		}
+	}
+	return nil
+}
+
+// cliHelp writes the list of subcommands, or the usage of the ones named in
+// args, to w
+func cliHelp(w io.Writer, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(w, "usage: rentroll [global options] command [options]\n\ncommands:\n")
+		for i := 0; i < len(cliCommands); i++ {
+			fmt.Fprintf(w, "    %-20s %s\n", cliCommands[i].Name, cliCommands[i].Descr)
+		}
+		fmt.Fprintf(w, "\nrentroll help command  prints the options of a command\n")
+		return
+	}
+	for _, name := range args {
+		c := cliLookup(name)
+		if c == nil {
+			fmt.Fprintf(w, "unknown command: %s\n", name)
+			continue
+		}
+		cliCommandUsage(w, c)
+	}
+}
+
+// cliCommandOpts returns the names of the options cmd accepts
+func cliCommandOpts(cmd *cliCommand) []string {
+	opts := []string{"b", "j", "k"}
+	if cmd.Report != nil {
+		opts = append(opts, "format", "o")
+	}
+	opts = append(opts, cmd.Opts...)
+	return opts
+}
+
+// cliCommandUsage writes the usage of cmd to w
+func cliCommandUsage(w io.Writer, cmd *cliCommand) {
+	fmt.Fprintf(w, "usage: rentroll [global options] %s [options]\n    %s  (-r %d)\n\noptions:\n", cmd.Name, cmd.Descr, cmd.Rpt)
+	opts := cliCommandOpts(cmd)
+	sort.Strings(opts)
+	for _, name := range opts {
+		o := cliOptions[name]
+		arg := " value"
+		if o.Bool {
+			arg = ""
+		}
+		fmt.Fprintf(w, "    -%-16s %s\n", name+arg, o.Usage)
+	}
+}
+
+// cliParse parses the command line of a subcommand.  args[0] is the command
+// name.
+//
+// RETURNS
+//  the context in which to run the command
+//  any error encountered, a *cliUsageError for bad arguments
+//-----------------------------------------------------------------------------
+func cliParse(args []string) (*cliCtx, error) {
+	cmd := cliLookup(args[0])
+	if cmd == nil {
+		return nil, &cliUsageError{cmd: args[0], msg: "unknown command, run rentroll help for the list of commands"}
+	}
+	c := cliCtx{
+		cmd:  cmd,
+		sopt: map[string]*string{},
+		bopt: map[string]*bool{},
+		qp:   url.Values{},
+	}
+	dflt := map[string]string{"b": App.Bud, "j": App.sStart, "k": App.sStop}
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	for _, name := range cliCommandOpts(cmd) {
+		o := cliOptions[name]
+		if v, ok := dflt[name]; ok {
+			o.Value = v
+		}
+		if o.Bool {
+			c.bopt[name] = fs.Bool(name, false, o.Usage)
+		} else {
+			c.sopt[name] = fs.String(name, o.Value, o.Usage)
+		}
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, &cliUsageError{cmd: cmd.Name, msg: err.Error()}
+	}
+	if fs.NArg() > 0 {
+		return nil, &cliUsageError{cmd: cmd.Name, msg: "unexpected argument: " + fs.Arg(0)}
+	}
+
+	c.rof = gotable.TABLEOUTTEXT
+	if cmd.Report != nil {
+		f, ok := rrpt.ReportOutputFormats[strings.ToLower(strings.TrimSpace(c.opt("format")))]
+		if !ok {
+			return nil, &cliUsageError{cmd: cmd.Name, msg: "unknown format: " + c.opt("format")}
+		}
+		c.rof = f
+	}
+	c.out = c.opt("o")
+	return &c, nil
+}
+
+// opt returns the value of the string option name
+func (c *cliCtx) opt(name string) string {
+	if p, ok := c.sopt[name]; ok {
+		return strings.TrimSpace(*p)
+	}
+	return ""
+}
+
+// flag returns the value of the switch name
+func (c *cliCtx) flag(name string) bool {
+	if p, ok := c.bopt[name]; ok {
+		return *p
+	}
+	return false
+}
+
+// required returns the value of the string option name, or a usage error if
+// it was not supplied
+func (c *cliCtx) required(name string) (string, error) {
+	s := c.opt(name)
+	if len(s) == 0 {
+		return s, &cliUsageError{cmd: c.cmd.Name, msg: fmt.Sprintf("-%s is required", name)}
+	}
+	return s, nil
+}
+
+// id returns the id in the required option name, parsed by f
+func (c *cliCtx) id(name string, f func(string) int64) (int64, error) {
+	s, err := c.required(name)
+	if err != nil {
+		return 0, err
+	}
+	n := f(s)
+	if n <= 0 {
+		return 0, &cliUsageError{cmd: c.cmd.Name, msg: fmt.Sprintf("invalid -%s: %s", name, s)}
+	}
+	return n, nil
+}
+
+// date returns the date in option name, or the stop date if it was not
+// supplied
+func (c *cliCtx) date(name string) (time.Time, error) {
+	s := c.opt(name)
+	if len(s) == 0 {
+		return c.ctx.DtStop, nil
+	}
+	dt, err := rlib.StringToDate(s)
+	if err != nil {
+		return dt, &cliUsageError{cmd: c.cmd.Name, msg: fmt.Sprintf("invalid -%s: %s", name, s)}
+	}
+	return dt, nil
+}
+
+// run runs the subcommand and writes its output.  It returns the exit status
+// of the process.
+func (c *cliCtx) run() int {
+	err := c.exec()
+	if err == nil {
+		return 0
+	}
+	rlib.Ulog("rentroll %s: %s\n", c.cmd.Name, err.Error())
+	if c.rof == rrpt.TABLEOUTJSON {
+		json.NewEncoder(os.Stdout).Encode(map[string]string{"status": "error", "message": err.Error()})
+	} else {
+		fmt.Fprintf(os.Stderr, "rentroll %s\n", err.Error())
+	}
+	if _, ok := err.(*cliUsageError); ok {
+		if c.rof != rrpt.TABLEOUTJSON {
+			cliCommandUsage(os.Stderr, c.cmd)
+		}
+		return 2
+	}
+	return 1
+}
+
+// exec sets up the business and the period, runs the subcommand and writes
+// its output
+func (c *cliCtx) exec() error {
+	var err error
+	c.ctx, err = newStartupCtx(c.opt("b"), c.opt("j"), c.opt("k"))
+	if err != nil {
+		return fmt.Errorf("%s: %s", c.cmd.Name, err.Error())
+	}
+	rlib.InitBizInternals(c.ctx.xbiz.P.BID, &c.ctx.xbiz)
+	rcsv.InitRCSV(&c.ctx.DtStart, &c.ctx.DtStop, &c.ctx.xbiz)
+	c.ri = rrpt.ReporterInfo{OutputFormat: gotable.TABLEOUTTEXT, Bid: c.ctx.xbiz.P.BID, D1: c.ctx.DtStart, D2: c.ctx.DtStop, Xbiz: &c.ctx.xbiz, BlankLineAfterRptName: true}
+	c.title = c.cmd.Descr
+
+	if c.cmd.Raw != nil && c.cmd.RawText {
+		return c.wrap(c.cmd.Raw(c, os.Stdout))
+	}
+
+	var m []gotable.Table
+	if c.cmd.Report != nil {
+		m, err = c.cmd.Report(c)
+		if err == nil {
+			err = cliReportError(m)
+		}
+		if err != nil {
+			return c.wrap(err)
+		}
+	}
+
+	w := io.Writer(os.Stdout)
+	if len(c.out) > 0 {
+		fp, err := os.Create(c.out)
+		if err != nil {
+			return c.wrap(err)
+		}
+		defer fp.Close()
+		w = fp
+	}
+	if c.cmd.Raw != nil {
+		return c.wrap(c.cmd.Raw(c, w))
+	}
+	if len(m) == 1 {
+		err = rrpt.WriteTable(w, &m[0], c.rof)
+	} else {
+		err = rrpt.WriteTables(w, m, c.rof, c.title)
+	}
+	return c.wrap(err)
+}
+
+// wrap adds the command name to err, unless it is a usage error which has it
+// already
+func (c *cliCtx) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*cliUsageError); ok {
+		return err
+	}
+	return fmt.Errorf("%s: %s", c.cmd.Name, err.Error())
+}
+
+// cliReportError returns the error in the error section of the first table
+// in m that has one.  rrpt.NoRecordsFoundMsg is not an error.
+func cliReportError(m []gotable.Table) error {
+	for i := 0; i < len(m); i++ {
+		s := strings.TrimSpace(m[i].Section3)
+		if len(s) > 0 && s != rrpt.NoRecordsFoundMsg {
+			return fmt.Errorf("%s", s)
+		}
+	}
+	return nil
+}
+
+// cliTable returns the Report function of a command that runs report f
+func cliTable(f func(*rrpt.ReporterInfo) gotable.Table) func(*cliCtx) ([]gotable.Table, error) {
+	return func(c *cliCtx) ([]gotable.Table, error) {
+		return []gotable.Table{f(&c.ri)}, nil
+	}
+}
+
+// cliTables returns the Report function of a command that runs the
+// multi-table report f.  title is the page header of its pdf output.
+func cliTables(f func(*rrpt.ReporterInfo) []gotable.Table, title string) func(*cliCtx) ([]gotable.Table, error) {
+	return func(c *cliCtx) ([]gotable.Table, error) {
+		c.title = title
+		return f(&c.ri), nil
+	}
+}
+
+// cliActionTable returns the result of a processing action as a one row
+// table.  n is the number of records the action wrote or removed, or 0 for
+// the actions that do not count them.
+func cliActionTable(c *cliCtx, action string, n int64, comment string) []gotable.Table {
+	var t gotable.Table
+	t.Init()
+	t.SetTitle(strings.ToUpper(c.cmd.Descr[:1]) + c.cmd.Descr[1:] + "\n\n")
+	t.AddColumn("Action", 20, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	t.AddColumn("Business", 10, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	t.AddColumn("Start", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)
+	t.AddColumn("Stop", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)
+	t.AddColumn("Records", 8, gotable.CELLINT, gotable.COLJUSTIFYRIGHT)
+	t.AddColumn("Comment", 40, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	t.AddRow()
+	t.Puts(-1, 0, action)
+	t.Puts(-1, 1, c.ctx.xbiz.P.Designation)
+	t.Putd(-1, 2, c.ctx.DtStart)
+	t.Putd(-1, 3, c.ctx.DtStop)
+	t.Puti(-1, 4, n)
+	t.Puts(-1, 5, comment)
+	return []gotable.Table{t}
+}
+
+func cliProcess(c *cliCtx) ([]gotable.Table, error) {
+	rlib.GenerateJournalRecords(&c.ctx.xbiz, &c.ctx.DtStart, &c.ctx.DtStop, App.SkipVacCheck || c.flag("x"))
+	n := rlib.GenerateLedgerEntries(&c.ctx.xbiz, &c.ctx.DtStart, &c.ctx.DtStop)
+	return cliActionTable(c, c.cmd.Name, int64(n), "journal records generated, ledger entries written"), nil
+}
+
+func cliGenJournals(c *cliCtx) ([]gotable.Table, error) {
+	rlib.GenerateJournalRecords(&c.ctx.xbiz, &c.ctx.DtStart, &c.ctx.DtStop, App.SkipVacCheck || c.flag("x"))
+	return cliActionTable(c, c.cmd.Name, 0, "journal records generated"), nil
+}
+
+func cliGenLedgers(c *cliCtx) ([]gotable.Table, error) {
+	n := rlib.GenerateLedgerEntries(&c.ctx.xbiz, &c.ctx.DtStart, &c.ctx.DtStop)
+	return cliActionTable(c, c.cmd.Name, int64(n), "ledger entries written"), nil
+}
+
+func cliGenVacancy(c *cliCtx) ([]gotable.Table, error) {
+	n := rlib.GenVacancyJournals(&c.ctx.xbiz, &c.ctx.DtStart, &c.ctx.DtStop)
+	return cliActionTable(c, c.cmd.Name, int64(n), "vacancy journal records written"), nil
+}
+
+func cliGenLedgerMarkers(c *cliCtx) ([]gotable.Table, error) {
+	rlib.GenerateLedgerMarkers(&c.ctx.xbiz, &c.ctx.DtStop)
+	return cliActionTable(c, c.cmd.Name, 0, "ledger markers generated as of "+c.ctx.DtStop.Format(rlib.RRDATEREPORTFMT)), nil
+}
+
+func cliRebuildLedgers(c *cliCtx) ([]gotable.Table, error) {
+	n := rlib.RebuildLedgerEntries(&c.ctx.xbiz, &c.ctx.DtStart, &c.ctx.DtStop)
+	return cliActionTable(c, c.cmd.Name, int64(n), "ledger entries rebuilt"), nil
+}
+
+func cliVerifyPosting(c *cliCtx) ([]gotable.Table, error) {
+	t, err := rrpt.PostingVerification(&c.ri)
+	if err != nil {
+		return nil, err
+	}
+	return []gotable.Table{t}, nil
+}
+
+func cliDeleteBusiness(c *cliCtx) ([]gotable.Table, error) {
+	n, err := rlib.DeleteBusinessFromDB(c.ctx.xbiz.P.BID)
+	if err != nil {
+		return nil, err
+	}
+	return cliActionTable(c, c.cmd.Name, n, fmt.Sprintf("deleted business %d", c.ctx.xbiz.P.BID)), nil
+}
+
+func cliBackupFiles(c *cliCtx) ([]gotable.Table, error) {
+	t, err := DBBackupFileListTable()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return []gotable.Table{t}, nil
+}
+
+func cliInvoice(c *cliCtx, w io.Writer) error {
+	id, err := c.id("invoice", rcsv.CSVLoaderGetInvoiceNo)
+	if err != nil {
+		return err
+	}
+	return rrpt.InvoiceTextReport(id)
+}
+
+func cliRABalance(c *cliCtx) ([]gotable.Table, error) {
+	lid, err := c.id("lid", rcsv.CSVLoaderGetLedgerNo)
+	if err != nil {
+		return nil, err
+	}
+	raid, err := c.id("raid", rcsv.CSVLoaderGetRAID)
+	if err != nil {
+		return nil, err
+	}
+	dt, err := c.date("date")
+	if err != nil {
+		return nil, err
+	}
+	return []gotable.Table{rrpt.LdgAcctBalOnDateTable(&c.ctx.xbiz, lid, raid, &dt)}, nil
+}
+
+func cliRAActivity(c *cliCtx) ([]gotable.Table, error) {
+	lid, err := c.id("lid", rcsv.CSVLoaderGetLedgerNo)
+	if err != nil {
+		return nil, err
+	}
+	raid, err := c.id("raid", rcsv.CSVLoaderGetRAID)
+	if err != nil {
+		return nil, err
+	}
+	return []gotable.Table{rrpt.RAAccountActivityRangeTable(&c.ctx.xbiz, lid, raid, &c.ctx.DtStart, &c.ctx.DtStop)}, nil
+}
+
+func cliDelinquency(c *cliCtx) ([]gotable.Table, error) {
+	dt, err := c.date("date")
+	if err != nil {
+		return nil, err
+	}
+	c.ri.D2 = dt
+	return []gotable.Table{rrpt.DelinquencyReportTable(&c.ri)}, nil
+}
+
+func cliMarketRates(c *cliCtx) ([]gotable.Table, error) {
+	rid, err := c.id("rid", rcsv.CSVLoaderGetRID)
+	if err != nil {
+		return nil, err
+	}
+	return []gotable.Table{rrpt.RentableMarketRatesTable(&c.ctx.xbiz, rid, &c.ctx.DtStart, &c.ctx.DtStop)}, nil
+}
+
+func cliPayorStatement(c *cliCtx) ([]gotable.Table, error) {
+	tcid, err := c.id("tcid", rcsv.CSVLoaderGetTCID)
+	if err != nil {
+		return nil, err
+	}
+	return []gotable.Table{rrpt.PayorStatement(c.ctx.xbiz.P.BID, tcid, &c.ctx.DtStart, &c.ctx.DtStop, true)}, nil
+}
+
+func cliVendor1099(c *cliCtx) ([]gotable.Table, error) {
+	if s := c.opt("year"); len(s) > 0 {
+		yr, ok := rlib.StringToInt64(s)
+		if !ok {
+			return nil, &cliUsageError{cmd: c.cmd.Name, msg: "invalid -year: " + s}
+		}
+		c.ri.D2 = time.Date(int(yr), time.December, 31, 0, 0, 0, 0, time.UTC)
+	}
+	return []gotable.Table{rrpt.Vendor1099ReportTable(&c.ri)}, nil
+}
+
+func cliConsolidated(c *cliCtx) ([]gotable.Table, error) {
+	var rpts = map[string]func(*rrpt.ReporterInfo) gotable.Table{
+		"tb":     rrpt.ConsolidatedTrialBalanceTable,
+		"is":     rrpt.ConsolidatedIncomeStatementTable,
+		"rr":     rrpt.ConsolidatedRentRollSummaryTable,
+		"delinq": rrpt.ConsolidatedDelinquencyTable,
+		"occ":    rrpt.ConsolidatedOccupancyTable,
+	}
+	s, err := c.required("report")
+	if err != nil {
+		return nil, err
+	}
+	f, ok := rpts[strings.ToLower(s)]
+	if !ok {
+		return nil, &cliUsageError{cmd: c.cmd.Name, msg: "unknown consolidated report: " + s + ", use one of: tb, is, rr, delinq, occ"}
+	}
+	c.ri.BIDList, err = rlib.GetBusinessListFromSpec(c.opt("biz"))
+	if err != nil {
+		return nil, err
+	}
+	return []gotable.Table{f(&c.ri)}, nil
+}
+
+func cliExportGL(c *cliCtx, w io.Writer) error {
+	opt := rlib.GLExportOptions{
+		Format:   strings.ToLower(c.opt("type")),
+		ReExport: c.flag("reexport"),
+		Preview:  c.flag("preview"),
+	}
+	_, err := rlib.ExportGL(c.ctx.xbiz.P.BID, &c.ctx.DtStart, &c.ctx.DtStop, &opt, w)
+	return err
+}
+
+func cliOccupancy(c *cliCtx) ([]gotable.Table, error) {
+	if c.flag("trend") {
+		return []gotable.Table{rrpt.OccupancyTrendTable(&c.ri)}, nil
+	}
+	return []gotable.Table{rrpt.OccupancyReportTable(&c.ri)}, nil
+}
+
+func cliSnapshotTake(c *cliCtx) ([]gotable.Table, error) {
+	a, err := rlib.CreateRentRollSnapshot(c.ctx.xbiz.P.BID, &c.ctx.DtStart, &c.ctx.DtStop, 0)
+	if err != nil {
+		return nil, err
+	}
+	return cliActionTable(c, c.cmd.Name, 1, "rent roll snapshot "+rlib.IDtoShortString("RRS", a.RRSID)+" saved"), nil
+}
+
+func cliForecast(c *cliCtx) ([]gotable.Table, error) {
+	for _, name := range []string{"months", "renew", "downtime", "term"} {
+		if s := c.opt(name); len(s) > 0 {
+			c.qp.Set(name, s)
+		}
+	}
+	c.ri.QueryParams = &c.qp
+	return []gotable.Table{rrpt.RentForecastTable(&c.ri)}, nil
+}
+
+func cliPayorStatements(c *cliCtx) ([]gotable.Table, error) {
+	if c.flag("internal") {
+		c.qp.Set("internal", "true")
+	}
+	if s := c.opt("tcid"); len(s) > 0 {
+		tcid, err := c.id("tcid", rcsv.CSVLoaderGetTCID)
+		if err != nil {
+			return nil, err
+		}
+		c.qp.Set("tcid", fmt.Sprintf("%d", tcid))
+	}
+	c.ri.QueryParams = &c.qp
+	if dir := c.opt("dir"); len(dir) > 0 {
+		files, err := rrpt.PayorStatementPDFs(&c.ri, dir)
+		if err != nil {
+			return nil, err
+		}
+		return cliActionTable(c, c.cmd.Name, int64(len(files)), "statements written to "+dir), nil
+	}
+	c.title = "Statement"
+	return rrpt.PayorStatementBatchTable(&c.ri), nil
+}
+
+func cliIntegrity(c *cliCtx) ([]gotable.Table, error) {
+	if c.flag("repair") {
+		c.qp.Set("repair", "true")
+	}
+	c.ri.QueryParams = &c.qp
+	return []gotable.Table{rrpt.IntegrityCheckTable(&c.ri)}, nil
+}
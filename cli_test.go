@@ -0,0 +1,88 @@
+package main
+
+import (
+	"gotable"
+	"rentroll/rlib"
+	"rentroll/rrpt"
+	"testing"
+	"time"
+)
+
+// Every option a command lists is defined, and every command has output
+func TestCLICommands(t *testing.T) {
+	names := map[string]bool{}
+	for i := 0; i < len(cliCommands); i++ {
+		c := &cliCommands[i]
+		if names[c.Name] {
+			t.Errorf("%s: command listed twice", c.Name)
+		}
+		names[c.Name] = true
+		if (c.Report == nil) == (c.Raw == nil) {
+			t.Errorf("%s: expect either Report or Raw", c.Name)
+		}
+		for _, o := range cliCommandOpts(c) {
+			if _, ok := cliOptions[o]; !ok {
+				t.Errorf("%s: option -%s is not defined", c.Name, o)
+			}
+		}
+	}
+}
+
+func TestCLIParse(t *testing.T) {
+	App.Bud, App.sStart, App.sStop = "REX", "2018-01-01", "2018-02-01"
+
+	c, err := cliParse([]string{"journal", "-format", "JSON", "-o", "j.json", "-j", "2018-01-15"})
+	if err != nil {
+		t.Fatalf("cliParse: %s", err.Error())
+	}
+	if c.rof != rrpt.TABLEOUTJSON || c.out != "j.json" {
+		t.Errorf("expect json to j.json, got format %d to %q", c.rof, c.out)
+	}
+	if c.opt("b") != "REX" || c.opt("j") != "2018-01-15" || c.opt("k") != "2018-02-01" {
+		t.Errorf("expect -b, -k from the global options and -j from the command, got %q %q %q", c.opt("b"), c.opt("j"), c.opt("k"))
+	}
+
+	// defaults, switches and options that are not supplied
+	if c, err = cliParse([]string{"export-gl", "-reexport"}); err != nil {
+		t.Fatalf("cliParse: %s", err.Error())
+	}
+	if c.opt("type") != rlib.GLEXPORTIIF || !c.flag("reexport") || c.flag("preview") || c.rof != gotable.TABLEOUTTEXT {
+		t.Errorf("expect type %s, reexport and not preview, got %q %t %t", rlib.GLEXPORTIIF, c.opt("type"), c.flag("reexport"), c.flag("preview"))
+	}
+	if _, err = c.required("o"); err == nil {
+		t.Errorf("expect -o to be required")
+	}
+	c.ctx.DtStop = time.Date(2018, time.February, 1, 0, 0, 0, 0, time.UTC)
+	if dt, err := c.date("date"); err != nil || !dt.Equal(c.ctx.DtStop) {
+		t.Errorf("expect the stop date when -date is not supplied, got %s %v", dt, err)
+	}
+
+	bad := [][]string{
+		{"no-such-command"},
+		{"journal", "-format", "doc"},
+		{"journal", "-raid", "3"}, // not an option of journal
+		{"journal", "extra"},
+		{"integrity", "-repair=maybe"},
+	}
+	for _, args := range bad {
+		_, err := cliParse(args)
+		if _, ok := err.(*cliUsageError); !ok {
+			t.Errorf("%v: expect a usage error, got %v", args, err)
+		}
+	}
+}
+
+// A report that sets its error section has failed; one with no rows has not
+func TestCLIReportError(t *testing.T) {
+	var a, b gotable.Table
+	a.Init()
+	b.Init()
+	b.SetSection3(rrpt.NoRecordsFoundMsg)
+	if err := cliReportError([]gotable.Table{a, b}); err != nil {
+		t.Errorf("expect no error, got %s", err.Error())
+	}
+	b.SetSection3("cannot read rentables\n")
+	if err := cliReportError([]gotable.Table{a, b}); err == nil || err.Error() != "cannot read rentables" {
+		t.Errorf("expect the error of the second table, got %v", err)
+	}
+}
@@ -8,10 +8,17 @@
 -- ASMID = Assessment id
 -- ATypeLID = assessment type id
 -- AVAILID = availability id
+-- BGID = Business group id
 -- BID = Business id
+-- BIID = Bill item id
+-- BILLID = Bill id
 -- BLDGID = Building id
+-- BPID = Bill payment id
 -- CID = custom attribute id
 -- DISBID = disbursement id
+-- DRID = dirty range id
+-- EVID = outbox event id
+-- GLEXID = GL export id
 -- JAID = Journal allocation id
 -- JID = Journal id
 -- JMID = Journal marker id
@@ -28,7 +35,11 @@
 -- RSPID = unit specialty id
 -- RTID = Rentable type id
 -- TCID = Transactant id
+-- UID = employee id, from the phonebook or DirectoryPerson
 -- USERID = User id
+-- VENDID = Vendor id
+-- WHDID = webhook delivery id
+-- WHID = webhook id
 
 DROP DATABASE IF EXISTS rentroll;
 CREATE DATABASE rentroll;
@@ -417,6 +428,30 @@ CREATE TABLE Business (
 );
 --    ParkingPermitInUse SMALLINT NOT NULL DEFAULT 0,           -- yes/no  0 = no, 1 = yes
 
+-- ===========================================
+--   BUSINESS GROUPS
+-- ===========================================
+-- A named set of businesses, such as a region or an owner's portfolio.
+-- Groups are used to select businesses for consolidated reports.
+CREATE TABLE BusinessGroup (
+    BGID BIGINT NOT NULL AUTO_INCREMENT,
+    Name VARCHAR(100) NOT NULL DEFAULT '',                      -- must be unique
+    GroupType VARCHAR(50) NOT NULL DEFAULT '',                  -- region, owner, ...
+    Description VARCHAR(256) NOT NULL DEFAULT '',
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,  -- when was this record last written
+    LastModBy BIGINT NOT NULL DEFAULT 0,                        -- employee UID (from phonebook) that modified it
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,               -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0,                         -- employee UID (from phonebook) that created this record
+    PRIMARY KEY (BGID)
+);
+
+CREATE TABLE BusinessGroupMember (
+    BGID BIGINT NOT NULL DEFAULT 0,                             -- which group
+    BID BIGINT NOT NULL DEFAULT 0,                              -- member business
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,               -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0                          -- employee UID (from phonebook) that created this record
+);
+
 -- ===========================================
 --   RENTABLE TYPES
 -- ===========================================
@@ -883,6 +918,90 @@ CREATE TABLE Expense (
     PRIMARY KEY (EXPID)
 );
 
+-- **************************************
+-- ****                              ****
+-- ****     ACCOUNTS PAYABLE         ****
+-- ****                              ****
+-- **************************************
+-- the people and companies we pay
+CREATE TABLE Vendor (
+    VENDID BIGINT NOT NULL AUTO_INCREMENT,                  -- unique id for this vendor
+    BID BIGINT NOT NULL DEFAULT 0,                          -- Business id
+    Name VARCHAR(100) NOT NULL DEFAULT '',                  -- vendor name, as it appears on checks
+    Address VARCHAR(100) NOT NULL DEFAULT '',
+    Address2 VARCHAR(100) NOT NULL DEFAULT '',
+    City VARCHAR(100) NOT NULL DEFAULT '',
+    State CHAR(25) NOT NULL DEFAULT '',
+    PostalCode VARCHAR(100) NOT NULL DEFAULT '',
+    Country VARCHAR(100) NOT NULL DEFAULT '',
+    Phone VARCHAR(100) NOT NULL DEFAULT '',
+    Email VARCHAR(100) NOT NULL DEFAULT '',
+    TaxID VARCHAR(25) NOT NULL DEFAULT '',                  -- EIN or SSN, needed for 1099 reporting
+    DefaultLID BIGINT NOT NULL DEFAULT 0,                   -- default expense GL account for this vendor's bills
+    FLAGS BIGINT NOT NULL DEFAULT 0,                        -- bit 0 = 1099 vendor, bit 1 = inactive
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,  -- when was this record last written
+    LastModBy BIGINT NOT NULL DEFAULT 0,                    -- employee UID (from phonebook) that modified it
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,           -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0,                     -- employee UID (from phonebook) that created this record
+    PRIMARY KEY (VENDID)
+);
+
+-- a bill received from a vendor
+CREATE TABLE Bill (
+    BILLID BIGINT NOT NULL AUTO_INCREMENT,                  -- unique id for this bill
+    RPBILLID BIGINT NOT NULL DEFAULT 0,                     -- reversal parent Bill, if it is non-zero, then the bill has been reversed.
+    BID BIGINT NOT NULL DEFAULT 0,                          -- Business id
+    VENDID BIGINT NOT NULL DEFAULT 0,                       -- who sent the bill
+    APLID BIGINT NOT NULL DEFAULT 0,                        -- the Accounts Payable GL account credited by this bill
+    Dt DATE NOT NULL DEFAULT '1970-01-01 00:00:00',         -- bill date
+    DtDue DATE NOT NULL DEFAULT '1970-01-01 00:00:00',      -- when payment is due
+    Amount DECIMAL(19,4) NOT NULL DEFAULT 0.0,              -- total of all the bill items
+    DocNo VARCHAR(50) NOT NULL DEFAULT '',                  -- the vendor's invoice number
+    FLAGS BIGINT NOT NULL DEFAULT 0,                        -- bits 0-1: 0 = unpaid, 1 = partially paid, 2 = fully paid; bit 2 = reversed
+    Comment VARCHAR(256) NOT NULL DEFAULT '',
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,  -- when was this record last written
+    LastModBy BIGINT NOT NULL DEFAULT 0,                    -- employee UID (from phonebook) that modified it
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,           -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0,                     -- employee UID (from phonebook) that created this record
+    PRIMARY KEY (BILLID)
+);
+
+-- the line items of a bill, each posted to an expense account
+CREATE TABLE BillItem (
+    BIID BIGINT NOT NULL AUTO_INCREMENT,                    -- unique id for this line item
+    BILLID BIGINT NOT NULL DEFAULT 0,                       -- the bill this item belongs to
+    BID BIGINT NOT NULL DEFAULT 0,                          -- Business id
+    LID BIGINT NOT NULL DEFAULT 0,                          -- the expense GL account debited
+    RID BIGINT NOT NULL DEFAULT 0,                          -- optional Rentable this item applies to
+    Amount DECIMAL(19,4) NOT NULL DEFAULT 0.0,
+    Description VARCHAR(256) NOT NULL DEFAULT '',
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,  -- when was this record last written
+    LastModBy BIGINT NOT NULL DEFAULT 0,                    -- employee UID (from phonebook) that modified it
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,           -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0,                     -- employee UID (from phonebook) that created this record
+    PRIMARY KEY (BIID)
+);
+
+-- a payment made against a bill from a depository
+CREATE TABLE BillPayment (
+    BPID BIGINT NOT NULL AUTO_INCREMENT,                    -- unique id for this bill payment
+    RPBPID BIGINT NOT NULL DEFAULT 0,                       -- reversal parent BillPayment
+    BID BIGINT NOT NULL DEFAULT 0,                          -- Business id
+    BILLID BIGINT NOT NULL DEFAULT 0,                       -- the bill being paid
+    VENDID BIGINT NOT NULL DEFAULT 0,                       -- who was paid, used for 1099 totals
+    DEPID BIGINT NOT NULL DEFAULT 0,                        -- the Depository the funds came from
+    Dt DATE NOT NULL DEFAULT '1970-01-01 00:00:00',         -- payment date
+    Amount DECIMAL(19,4) NOT NULL DEFAULT 0.0,
+    DocNo VARCHAR(50) NOT NULL DEFAULT '',                  -- check number, ACH trace number, etc.
+    FLAGS BIGINT NOT NULL DEFAULT 0,                        -- bit 2 = reversed
+    Comment VARCHAR(256) NOT NULL DEFAULT '',
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,  -- when was this record last written
+    LastModBy BIGINT NOT NULL DEFAULT 0,                    -- employee UID (from phonebook) that modified it
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,           -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0,                     -- employee UID (from phonebook) that created this record
+    PRIMARY KEY (BPID)
+);
+
 -- **************************************
 -- ****                              ****
 -- ****     AccountRule              ****
@@ -1141,6 +1260,37 @@ CREATE TABLE JournalMarkerAudit (
     ModTime TIMESTAMP                       -- timestamp of change
 );
 
+-- GLExport records each export of journal entries to an external accounting
+-- system.  GLExportJournal lists the Journal entries included in each export
+-- so that later exports can skip them.  Rebuilding the Journal gives its
+-- entries new JIDs, so entries are matched by Type, ID, Dt and Amount.
+CREATE TABLE GLExport (
+    GLEXID BIGINT NOT NULL AUTO_INCREMENT,                         -- unique id for this export
+    BID BIGINT NOT NULL DEFAULT 0,                                 -- Business id
+    Format VARCHAR(20) NOT NULL DEFAULT '',                        -- iif, csv
+    DtStart DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',       -- start of the exported range
+    DtStop DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',        -- end of the exported range (not inclusive)
+    JournalCount BIGINT NOT NULL DEFAULT 0,                        -- number of Journal entries exported
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,  -- when was this record last written
+    LastModBy BIGINT NOT NULL DEFAULT 0,                           -- employee UID (from phonebook) that modified it
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,                  -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0,                            -- employee UID (from phonebook) that created this record
+    PRIMARY KEY (GLEXID)
+);
+
+CREATE TABLE GLExportJournal (
+    GLEXID BIGINT NOT NULL DEFAULT 0,                              -- the export
+    BID BIGINT NOT NULL DEFAULT 0,                                 -- Business id
+    JID BIGINT NOT NULL DEFAULT 0,                                 -- Journal entry included in the export
+    Type BIGINT NOT NULL DEFAULT 0,                                -- Journal.Type
+    ID BIGINT NOT NULL DEFAULT 0,                                  -- Journal.ID
+    Dt DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',            -- Journal.Dt
+    Amount DECIMAL(19,4) NOT NULL DEFAULT 0.0,                     -- Journal.Amount
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,                  -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0,                            -- employee UID (from phonebook) that created this record
+    PRIMARY KEY (GLEXID, JID)
+);
+
 -- **************************************
 -- ****                              ****
 -- ****           LEDGERS            ****
@@ -1221,3 +1371,171 @@ CREATE TABLE LedgerMarkerAudit (
     ModTime TIMESTAMP                           -- timestamp of change
 );
 
+
+-- **************************************
+-- ****                              ****
+-- ****           WEBHOOKS           ****
+-- ****                              ****
+-- **************************************
+CREATE TABLE Webhook (
+    WHID BIGINT NOT NULL AUTO_INCREMENT,                      -- unique id for this webhook
+    BID BIGINT NOT NULL DEFAULT 0,                            -- Business id
+    URL VARCHAR(1024) NOT NULL DEFAULT '',                    -- where events are POSTed
+    Secret VARCHAR(256) NOT NULL DEFAULT '',                  -- key used to sign the body of each POST
+    EventTypes VARCHAR(1024) NOT NULL DEFAULT '',             -- comma separated list of event types, receipt.* matches all receipt events, empty or * means all
+    FLAGS BIGINT NOT NULL DEFAULT 0,                          -- bit 0 = inactive
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,  -- when was this record last written
+    LastModBy BIGINT NOT NULL DEFAULT 0,                      -- employee UID (from phonebook) that modified it
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,             -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0,                       -- employee UID (from phonebook) that created this record
+    PRIMARY KEY (WHID)
+);
+
+-- OutboxEvent is written in the same operation that creates the object the
+-- event describes.  One WebhookDelivery is created for each Webhook that
+-- subscribes to the event.
+CREATE TABLE OutboxEvent (
+    EVID BIGINT NOT NULL AUTO_INCREMENT,                      -- unique id for this event
+    BID BIGINT NOT NULL DEFAULT 0,                            -- Business id
+    EventType VARCHAR(100) NOT NULL DEFAULT '',               -- receipt.created, assessment.reversed, ...
+    ObjID BIGINT NOT NULL DEFAULT 0,                          -- id of the object the event describes: RCPTID, ASMID, ...
+    Payload MEDIUMTEXT NOT NULL,                              -- json representation of the object
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,             -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0,                       -- employee UID (from phonebook) that created this record
+    PRIMARY KEY (EVID)
+);
+
+CREATE TABLE WebhookDelivery (
+    WHDID BIGINT NOT NULL AUTO_INCREMENT,                     -- unique id for this delivery
+    WHID BIGINT NOT NULL DEFAULT 0,                           -- the webhook
+    EVID BIGINT NOT NULL DEFAULT 0,                           -- the event being delivered
+    BID BIGINT NOT NULL DEFAULT 0,                            -- Business id
+    Status SMALLINT NOT NULL DEFAULT 0,                       -- 0 = pending, 1 = delivered, 2 = failed, no more attempts will be made
+    Attempts BIGINT NOT NULL DEFAULT 0,                       -- number of attempts made so far
+    NextAttempt DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',  -- when to try next
+    LastAttempt DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',  -- when the last attempt was made
+    HTTPStatus BIGINT NOT NULL DEFAULT 0,                     -- status code returned by the last attempt
+    LastError VARCHAR(1024) NOT NULL DEFAULT '',              -- error from the last attempt
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,  -- when was this record last written
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,             -- when was this record created
+    PRIMARY KEY (WHDID)
+);
+
+-- **************************************
+-- ****                              ****
+-- ****      EMPLOYEE DIRECTORY      ****
+-- ****                              ****
+-- **************************************
+-- The employees of a standalone installation, used when config.json has
+-- "Directory": "local".  Otherwise employees are in the phonebook database.
+CREATE TABLE DirectoryPerson (
+    UID BIGINT NOT NULL AUTO_INCREMENT,                       -- employee UID, used in LastModBy, CreateBy, CSAgent, ...
+    FirstName VARCHAR(100) NOT NULL DEFAULT '',
+    LastName VARCHAR(100) NOT NULL DEFAULT '',
+    PreferredName VARCHAR(100) NOT NULL DEFAULT '',           -- name the person goes by, if not FirstName
+    Email VARCHAR(100) NOT NULL DEFAULT '',
+    Phone VARCHAR(100) NOT NULL DEFAULT '',
+    FLAGS BIGINT NOT NULL DEFAULT 0,                          -- bit 0 = inactive
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,  -- when was this record last written
+    LastModBy BIGINT NOT NULL DEFAULT 0,                      -- employee UID that modified it
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,             -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0,                       -- employee UID that created this record
+    PRIMARY KEY (UID)
+);
+
+-- **************************************
+-- ****                              ****
+-- ****        DIRTY RANGES          ****
+-- ****                              ****
+-- **************************************
+-- Journal and ledger entries are posted as transactions are saved.  What is
+-- computed from the state of a rentable over time, its vacancy, is recorded
+-- here as needing recomputation when a change affects it.
+CREATE TABLE DirtyRange (
+    DRID BIGINT NOT NULL AUTO_INCREMENT,                      -- unique id for this range
+    BID BIGINT NOT NULL DEFAULT 0,                            -- Business id
+    RID BIGINT NOT NULL DEFAULT 0,                            -- the rentable whose vacancy must be recomputed
+    DtStart DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',  -- start of the range
+    DtStop DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',   -- end of the range
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,             -- when was this record created
+    PRIMARY KEY (DRID)
+);
+
+-- **************************************
+-- ****                              ****
+-- ****       REPORT SCHEDULES       ****
+-- ****                              ****
+-- **************************************
+-- Reports that the ReportSchedules worker generates when NextRun arrives.
+-- The output is written to Directory, with a manifest, and emailed to the
+-- Recipients.
+CREATE TABLE ReportSchedule (
+    RSID BIGINT NOT NULL AUTO_INCREMENT,                      -- unique id for this schedule
+    BID BIGINT NOT NULL DEFAULT 0,                            -- Business id
+    Name VARCHAR(100) NOT NULL DEFAULT '',                    -- name of the schedule
+    Report VARCHAR(100) NOT NULL DEFAULT '',                  -- report name as used by the web service report handler, RPTrr, RPTdelinq, ...
+    Params VARCHAR(1024) NOT NULL DEFAULT '',                 -- additional report parameters, url encoded
+    DateRule SMALLINT NOT NULL DEFAULT 0,                     -- 0 = previous month, 1 = month to date, 2 = current month, 3 = previous week
+    OutputFormat SMALLINT NOT NULL DEFAULT 0,                 -- report output format, as in the rof web service parameter
+    Cycle SMALLINT NOT NULL DEFAULT 0,                        -- how often it runs, 4 = daily, 5 = weekly, 6 = monthly, ... 0 = once
+    NextRun DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',  -- when it runs next
+    LastRun DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',  -- when it last ran
+    LastError VARCHAR(256) NOT NULL DEFAULT '',               -- error from the last run, empty if it succeeded
+    Recipients VARCHAR(1024) NOT NULL DEFAULT '',             -- comma separated email addresses the output is sent to
+    Directory VARCHAR(1024) NOT NULL DEFAULT '',              -- subdirectory of ReportArchive (config.json) where output files are written
+    FLAGS BIGINT NOT NULL DEFAULT 0,                          -- bit 0 = inactive
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,  -- when was this record last written
+    LastModBy BIGINT NOT NULL DEFAULT 0,                      -- employee UID (from phonebook) that modified it
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,             -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0,                       -- employee UID (from phonebook) that created this record
+    PRIMARY KEY (RSID)
+);
+
+-- **************************************
+-- ****                              ****
+-- ****     RENT ROLL SNAPSHOTS      ****
+-- ****                              ****
+-- **************************************
+-- The rent roll of a period as it was when the snapshot was taken.  The
+-- RentRollSnapshots worker takes one for each business at the end of every
+-- month.
+CREATE TABLE RentRollSnapshot (
+    RRSID BIGINT NOT NULL AUTO_INCREMENT,                     -- unique id for this snapshot
+    BID BIGINT NOT NULL DEFAULT 0,                            -- Business id
+    DtStart DATE NOT NULL DEFAULT '1970-01-01',               -- start of the rent roll period
+    DtStop DATE NOT NULL DEFAULT '1970-01-01',                -- end of the rent roll period, not included
+    PeriodGSR DECIMAL(19,4) NOT NULL DEFAULT 0.0,             -- grand total of the period GSR
+    EndReceivable DECIMAL(19,4) NOT NULL DEFAULT 0.0,         -- grand total of the receivables at DtStop
+    EndSecDep DECIMAL(19,4) NOT NULL DEFAULT 0.0,             -- grand total of the security deposits at DtStop
+    Payload MEDIUMTEXT NOT NULL,                              -- json list of the rent roll rows
+    CreateTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,             -- when was this record created
+    CreateBy BIGINT NOT NULL DEFAULT 0,                       -- employee UID (from phonebook) that created this record, 0 = month-end worker
+    PRIMARY KEY (RRSID)
+);
+
+-- **************************************
+-- ****                              ****
+-- ****        SCHEMA VERSION        ****
+-- ****                              ****
+-- **************************************
+-- One row for each migration in rlib.Migrations.  A new database has the
+-- latest schema, so all of them are listed.  When adding a migration, add
+-- its row here too.
+CREATE TABLE SchemaVersion (
+    Version BIGINT NOT NULL,                                  -- migration number
+    Name VARCHAR(100) NOT NULL DEFAULT '',                    -- what the migration does
+    AppliedTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,            -- when it was applied
+    PRIMARY KEY (Version)
+);
+
+INSERT INTO SchemaVersion (Version,Name) VALUES
+    (1,'baseline'),
+    (2,'accounts payable: vendors, bills, bill payments'),
+    (3,'business groups'),
+    (4,'journal export tracking'),
+    (5,'webhooks and the event outbox'),
+    (6,'local employee directory'),
+    (7,'dirty range tracking for incremental posting'),
+    (8,'report schedules'),
+    (9,'rent roll snapshots'),
+    (10,'match exported journal entries by content');
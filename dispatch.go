@@ -3,11 +3,13 @@ package main
 import (
 	"fmt"
 	"gotable"
+	"net/url"
 	"os"
 	"rentroll/rcsv"
 	"rentroll/rlib"
 	"rentroll/rrpt"
 	"strings"
+	"time"
 )
 
 // RRPHreport et al are categorizations of commands
@@ -27,11 +29,17 @@ func RunCommandLine(ctx *DispatchCtx) {
 
 	switch ctx.Report {
 	case 1: // JOURNAL
+		if xlsxReport(rrpt.JournalReportTable, &ri) {
+			break
+		}
 		// JournalReportText(&ctx.xbiz, &ctx.DtStart, &ctx.DtStop)
 		tbl := rrpt.JournalReport(&ri)
 		fmt.Print(tbl)
 
 	case 2: // LEDGER
+		if xlsxMultiReport(rrpt.LedgerReportTable, &ri) {
+			break
+		}
 		// LedgerReportText(&ctx.xbiz, &ctx.DtStart, &ctx.DtStop)
 		m := rrpt.LedgerReportTable(&ri)
 		for i := 0; i < len(m); i++ {
@@ -41,12 +49,21 @@ func RunCommandLine(ctx *DispatchCtx) {
 	case 3: // INTERNAL ACCT RULE TEST
 		intTest(&ctx.xbiz, &ctx.DtStart, &ctx.DtStop)
 	case 4: // RENTROLL REPORT
+		if xlsxReport(rrpt.RRReportTable, &ri) {
+			break
+		}
 		rrpt.RRTextReport(&ri)
 	case 6: // available
 	case 7: // RENTABLE COUNT BY TYPE
+		if xlsxReport(rrpt.RentableCountByRentableTypeReportTable, &ri) {
+			break
+		}
 		t := rrpt.RentableCountByRentableTypeReportTable(&ri)
 		fmt.Print(t.String())
 	case 8: // STATEMENT
+		if xlsxMultiReport(rrpt.RptStatementReportTable, &ri) {
+			break
+		}
 		fmt.Print(rrpt.RptStatementTextReport(&ri))
 	case 9: // Invoice
 		// ctx.Report format:  9,IN0001  or  9,1   -- both say that we want Invoice 1 to be printed
@@ -58,12 +75,18 @@ func RunCommandLine(ctx *DispatchCtx) {
 		invoiceno := rcsv.CSVLoaderGetInvoiceNo(sa[1])
 		rrpt.InvoiceTextReport(invoiceno)
 	case 10: // LEDGER ACTIVITY
+		if xlsxMultiReport(rrpt.LedgerActivityReportTable, &ri) {
+			break
+		}
 		m := rrpt.LedgerActivityReportTable(&ri)
 		for i := 0; i < len(m); i++ {
 			fmt.Print(m[i])
 			fmt.Printf("\n\n")
 		}
 	case 11: // RENTABLE GSR
+		if xlsxReport(rrpt.GSRReportTable, &ri) {
+			break
+		}
 		rrpt.GSRTextReport(&ri)
 	case 12: // LEDGERBALANCE ON DATE
 		// ctx.Report format:  12,LID,RAID,date
@@ -104,12 +127,18 @@ func RunCommandLine(ctx *DispatchCtx) {
 			os.Exit(1)
 		}
 		ri.D2 = dt
+		if xlsxReport(rrpt.DelinquencyReportTable, &ri) {
+			break
+		}
 		rrpt.DelinquencyTextReport(&ri)
 	case 15: // Process Vacancy...
 		rlib.GenVacancyJournals(&ctx.xbiz, &ctx.DtStart, &ctx.DtStop)
 	case 16: // Process LedgerMarkers Only
 		rlib.GenerateLedgerMarkers(&ctx.xbiz, &ctx.DtStop)
 	case 17: // LEDGER BALANCE REPORT
+		if xlsxReport(rrpt.LedgerBalanceReportTable, &ri) {
+			break
+		}
 		rrpt.PrintLedgerBalanceReport(&ri)
 	case 18: // Process Journal Entries only
 		rlib.GenerateJournalRecords(&ctx.xbiz, &ctx.DtStart, &ctx.DtStop, App.SkipVacCheck)
@@ -143,15 +172,317 @@ func RunCommandLine(ctx *DispatchCtx) {
 			fmt.Printf("Bad number: %s\n", sa[1])
 		}
 		tbl := rrpt.PayorStatement(ctx.xbiz.P.BID, tcid, &ctx.DtStart, &ctx.DtStop, true)
+		if len(App.XLSXFile) > 0 {
+			writeXLSXFile([]gotable.Table{tbl})
+			break
+		}
 		s, err := tbl.SprintTable()
 		if err != nil {
 			rlib.LogAndPrintError("RunCommandLine", err)
 			return
 		}
 		fmt.Print(s)
+	case 24: // ACCOUNTS PAYABLE AGING as of the stop date
+		if xlsxReport(rrpt.APAgingReportTable, &ri) {
+			break
+		}
+		fmt.Print(rrpt.APAgingReport(&ri))
+	case 25: // VENDOR 1099 TOTALS
+		// ctx.Report format:  25,year   -- defaults to the year of the stop date
+		sa := strings.Split(ctx.Args, ",")
+		if len(sa) > 1 {
+			yr, ok := rlib.StringToInt64(sa[1])
+			if !ok {
+				fmt.Printf("Bad year: %s.  Example:  -r 25,2017\n", sa[1])
+				os.Exit(1)
+			}
+			ri.D2 = time.Date(int(yr), time.December, 31, 0, 0, 0, 0, time.UTC)
+		}
+		if xlsxReport(rrpt.Vendor1099ReportTable, &ri) {
+			break
+		}
+		fmt.Print(rrpt.Vendor1099Report(&ri))
+	case 26: // CONSOLIDATED REPORTS
+		// ctx.Report format:  26,report,bizlist
+		//     report:  tb | is | rr | delinq | occ
+		//     bizlist: business groups, BUDs or BIDs separated by ':'.  All businesses if omitted
+		sa := strings.Split(ctx.Args, ",")
+		if len(sa) < 2 {
+			fmt.Printf("Missing one or more parameters.  Example:  -r 26,tb,West:REX\n")
+			os.Exit(1)
+		}
+		var rpts = map[string]func(*rrpt.ReporterInfo) gotable.Table{
+			"tb":     rrpt.ConsolidatedTrialBalanceTable,
+			"is":     rrpt.ConsolidatedIncomeStatementTable,
+			"rr":     rrpt.ConsolidatedRentRollSummaryTable,
+			"delinq": rrpt.ConsolidatedDelinquencyTable,
+			"occ":    rrpt.ConsolidatedOccupancyTable,
+		}
+		f, ok := rpts[strings.ToLower(strings.TrimSpace(sa[1]))]
+		if !ok {
+			fmt.Printf("Unknown consolidated report: %s.  Use one of: tb, is, rr, delinq, occ\n", sa[1])
+			os.Exit(1)
+		}
+		spec := ""
+		if len(sa) > 2 {
+			spec = sa[2]
+		}
+		var err error
+		ri.BIDList, err = rlib.GetBusinessListFromSpec(spec)
+		if err != nil {
+			fmt.Printf("%s\n", err.Error())
+			os.Exit(1)
+		}
+		if xlsxReport(f, &ri) {
+			break
+		}
+		tbl := f(&ri)
+		fmt.Print(rrpt.ReportToString(&tbl, &ri))
+	case 27: // EXPORT JOURNAL ENTRIES FOR AN ACCOUNTING SYSTEM
+		// ctx.Report format:  27,format,option...
+		//     format:  iif | csv
+		//     option:  reexport -- include entries that were already exported
+		//              preview  -- do not record the export
+		sa := strings.Split(ctx.Args, ",")
+		opt := rlib.GLExportOptions{Format: rlib.GLEXPORTIIF}
+		if len(sa) > 1 {
+			opt.Format = strings.ToLower(strings.TrimSpace(sa[1]))
+		}
+		for i := 2; i < len(sa); i++ {
+			switch strings.ToLower(strings.TrimSpace(sa[i])) {
+			case "reexport":
+				opt.ReExport = true
+			case "preview":
+				opt.Preview = true
+			default:
+				fmt.Printf("Unknown option: %s.  Example:  -r 27,iif,preview\n", sa[i])
+				os.Exit(1)
+			}
+		}
+		if _, err := rlib.ExportGL(ctx.xbiz.P.BID, &ctx.DtStart, &ctx.DtStop, &opt, os.Stdout); err != nil {
+			fmt.Printf("%s\n", err.Error())
+			os.Exit(1)
+		}
+	case 28: // VERIFY POSTING AGAINST A FULL REBUILD, changes nothing
+		tbl, err := rrpt.PostingVerification(&ri)
+		if err != nil {
+			fmt.Printf("%s\n", err.Error())
+			os.Exit(1)
+		}
+		if len(App.XLSXFile) > 0 {
+			writeXLSXFile([]gotable.Table{tbl})
+			break
+		}
+		fmt.Print(rrpt.ReportToString(&tbl, &ri))
+	case 29: // FULL LEDGER REBUILD -- removes and regenerates the LedgerEntries in the range
+		rlib.RebuildLedgerEntries(&ctx.xbiz, &ctx.DtStart, &ctx.DtStop)
+	case 30: // OCCUPANCY
+		// ctx.Report format:  30,option...
+		//     option:  trend -- one row per month for the whole business
+		//              csv   -- print as CSV
+		sa := strings.Split(ctx.Args, ",")
+		f := rrpt.OccupancyReportTable
+		csv := false
+		for i := 1; i < len(sa); i++ {
+			switch strings.ToLower(strings.TrimSpace(sa[i])) {
+			case "trend":
+				f = rrpt.OccupancyTrendTable
+			case "csv":
+				csv = true
+			default:
+				fmt.Printf("Unknown option: %s.  Example:  -r 30,trend,csv\n", sa[i])
+				os.Exit(1)
+			}
+		}
+		if xlsxReport(f, &ri) {
+			break
+		}
+		tbl := f(&ri)
+		if csv {
+			if err := tbl.CSVprintTable(os.Stdout); err != nil {
+				rlib.LogAndPrintError("RunCommandLine", err)
+			}
+			break
+		}
+		fmt.Print(rrpt.ReportToString(&tbl, &ri))
+	case 31: // RENTROLL SNAPSHOTS
+		// ctx.Report format:  31,option
+		//     option:  take     -- save the rentroll of the period as a snapshot
+		//              variance -- compare the snapshot of the period with the one before it
+		//     with no option the snapshot of the period is shown
+		sa := strings.Split(ctx.Args, ",")
+		opt := ""
+		if len(sa) > 1 {
+			opt = strings.ToLower(strings.TrimSpace(sa[1]))
+		}
+		switch opt {
+		case "":
+			if xlsxReport(rrpt.RRSnapshotTable, &ri) {
+				break
+			}
+			fmt.Print(rrpt.RRSnapshotReport(&ri))
+		case "take":
+			a, err := rlib.CreateRentRollSnapshot(ctx.xbiz.P.BID, &ctx.DtStart, &ctx.DtStop, 0)
+			if err != nil {
+				fmt.Printf("%s\n", err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Rent roll snapshot %s saved for %s - %s\n", rlib.IDtoShortString("RRS", a.RRSID), ctx.DtStart.Format(rlib.RRDATEREPORTFMT), ctx.DtStop.Format(rlib.RRDATEREPORTFMT))
+		case "variance":
+			if xlsxReport(rrpt.RRVarianceTable, &ri) {
+				break
+			}
+			fmt.Print(rrpt.RRVarianceReport(&ri))
+		default:
+			fmt.Printf("Unknown option: %s.  Example:  -r 31,variance\n", opt)
+			os.Exit(1)
+		}
+	case 32: // RENT FORECAST
+		// ctx.Report format:  32,option...
+		//     option:  months=n    -- months to project, 12 - 36
+		//              renew=p     -- renewal probability in percent
+		//              downtime=n  -- vacancy days when a lease is not renewed
+		//              term=n      -- months of a renewed or new lease
+		//              csv         -- print as CSV
+		sa := strings.Split(ctx.Args, ",")
+		qp := url.Values{}
+		csv := false
+		for i := 1; i < len(sa); i++ {
+			opt := strings.ToLower(strings.TrimSpace(sa[i]))
+			kv := strings.SplitN(opt, "=", 2)
+			switch {
+			case opt == "csv":
+				csv = true
+			case len(kv) == 2 && (kv[0] == "months" || kv[0] == "renew" || kv[0] == "downtime" || kv[0] == "term"):
+				qp.Set(kv[0], kv[1])
+			default:
+				fmt.Printf("Unknown option: %s.  Example:  -r 32,months=24,renew=60,downtime=45\n", sa[i])
+				os.Exit(1)
+			}
+		}
+		ri.QueryParams = &qp
+		if xlsxReport(rrpt.RentForecastTable, &ri) {
+			break
+		}
+		tbl := rrpt.RentForecastTable(&ri)
+		if csv {
+			if err := tbl.CSVprintTable(os.Stdout); err != nil {
+				rlib.LogAndPrintError("RunCommandLine", err)
+			}
+			break
+		}
+		fmt.Print(rrpt.ReportToString(&tbl, &ri))
+	case 33: // PAYOR STATEMENTS
+		// ctx.Report format:  33,option...
+		//     option:  pdf=file   -- write all the statements to one PDF file
+		//              dir=dir    -- write each payor's statement to its own PDF file in dir
+		//              tcid=n     -- the statement of payor n only
+		//              internal   -- show unapplied funds from other payors
+		//     with no pdf or dir option the statements are printed as text
+		sa := strings.Split(ctx.Args, ",")
+		qp := url.Values{}
+		pdf, dir := "", ""
+		for i := 1; i < len(sa); i++ {
+			opt := strings.TrimSpace(sa[i])
+			kv := strings.SplitN(opt, "=", 2)
+			switch {
+			case strings.ToLower(opt) == "internal":
+				qp.Set("internal", "true")
+			case len(kv) == 2 && strings.ToLower(kv[0]) == "pdf":
+				pdf = kv[1]
+			case len(kv) == 2 && strings.ToLower(kv[0]) == "dir":
+				dir = kv[1]
+			case len(kv) == 2 && strings.ToLower(kv[0]) == "tcid":
+				qp.Set("tcid", kv[1])
+			default:
+				fmt.Printf("Unknown option: %s.  Example:  -r 33,pdf=statements.pdf\n", sa[i])
+				os.Exit(1)
+			}
+		}
+		ri.QueryParams = &qp
+		switch {
+		case len(pdf) > 0:
+			fp, err := os.Create(pdf)
+			if err != nil {
+				fmt.Printf("%s\n", err.Error())
+				os.Exit(1)
+			}
+			err = rrpt.PayorStatementBatchPDF(&ri, fp)
+			fp.Close()
+			if err != nil {
+				fmt.Printf("%s\n", err.Error())
+				os.Exit(1)
+			}
+		case len(dir) > 0:
+			files, err := rrpt.PayorStatementPDFs(&ri, dir)
+			if err != nil {
+				fmt.Printf("%s\n", err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("%d statements written to %s\n", len(files), dir)
+		default:
+			fmt.Print(rrpt.PayorStatementBatchReport(&ri))
+		}
+	case 34: // INTEGRITY CHECK
+		// ctx.Report format:  34[,repair]
+		//     repair -- repair the problems that are safe to repair
+		sa := strings.Split(ctx.Args, ",")
+		qp := url.Values{}
+		for i := 1; i < len(sa); i++ {
+			switch strings.ToLower(strings.TrimSpace(sa[i])) {
+			case "repair":
+				qp.Set("repair", "true")
+			default:
+				fmt.Printf("Unknown option: %s.  Example:  -r 34,repair\n", sa[i])
+				os.Exit(1)
+			}
+		}
+		ri.QueryParams = &qp
+		if xlsxReport(rrpt.IntegrityCheckTable, &ri) {
+			break
+		}
+		fmt.Print(rrpt.IntegrityCheckReport(&ri))
 
 	default:
 		rlib.GenerateJournalRecords(&ctx.xbiz, &ctx.DtStart, &ctx.DtStop, App.SkipVacCheck)
 		rlib.GenerateLedgerEntries(&ctx.xbiz, &ctx.DtStart, &ctx.DtStop)
 	}
 }
+
+// xlsxReport writes the table generated by f to the workbook named with -xlsx.
+// It returns false, without generating the report, if no workbook was
+// requested.
+func xlsxReport(f func(*rrpt.ReporterInfo) gotable.Table, ri *rrpt.ReporterInfo) bool {
+	if len(App.XLSXFile) == 0 {
+		return false
+	}
+	writeXLSXFile([]gotable.Table{f(ri)})
+	return true
+}
+
+// xlsxMultiReport is xlsxReport for reports made of several tables. Each
+// table is written to its own sheet.
+func xlsxMultiReport(f func(*rrpt.ReporterInfo) []gotable.Table, ri *rrpt.ReporterInfo) bool {
+	if len(App.XLSXFile) == 0 {
+		return false
+	}
+	writeXLSXFile(f(ri))
+	return true
+}
+
+// writeXLSXFile writes the tables in m to the workbook named with -xlsx
+func writeXLSXFile(m []gotable.Table) {
+	fp, err := os.Create(App.XLSXFile)
+	if err != nil {
+		fmt.Printf("Cannot create %s: %s\n", App.XLSXFile, err.Error())
+		os.Exit(1)
+	}
+	err = rrpt.MultiTableXLSXPrint(m, fp)
+	if err1 := fp.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		fmt.Printf("Error writing %s: %s\n", App.XLSXFile, err.Error())
+		os.Exit(1)
+	}
+}
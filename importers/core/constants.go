@@ -58,3 +58,6 @@ var SpecialCharsReplacer = strings.NewReplacer(
 	",", "", "<", "", ".", "", ">", "", "/", "", "?", "", // line4
 	" ", "", // whitespace
 )
+
+// DryRunReportNote heads the report of a dry run import
+const DryRunReportNote = "DRY RUN: no changes were saved. The counts and records below show what would be imported.\n\n"
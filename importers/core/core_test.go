@@ -1,7 +1,9 @@
 package core
 
 import (
+	"path/filepath"
 	"reflect"
+	"rentroll/rlib"
 	"testing"
 )
 
@@ -101,3 +103,236 @@ func TestEmailValidation(t *testing.T) {
 		}
 	}
 }
+
+// ======== TEST FOR METHODS DEFINED IN `mapping.go` ========
+
+// testMapping is a small mapping in the style of a resident rent roll export
+var testMapping = Mapping{
+	Name: "Test",
+	Columns: map[string][]string{
+		"Unit":       {"Unit"},
+		"Style":      {"Unit Type", "BD/BA"},
+		"Status":     {"Status"},
+		"Name":       {"Tenant"},
+		"Rent":       {"Rent"},
+		"LeaseStart": {"Lease From"},
+	},
+	Transforms: map[string][]string{
+		"Rent":       {"money"},
+		"LeaseStart": {"date"},
+	},
+	DateFormats: []string{"01/02/2006", "2006-01-02"},
+	NameFormat:  "first last",
+	StatusCodes: map[string]string{
+		"current":       "occupied",
+		"vacant":        "vacant",
+		"vacant-rented": "vacant",
+		"notice":        "occupied",
+	},
+	Defaults:     map[string]string{"Style": "Standard"},
+	SkipPrefixes: []string{"->", "total"},
+}
+
+// Testing for `header detection`
+func TestMappingHeaderIndex(t *testing.T) {
+	m := testMapping
+
+	// CASE: POSITIVE, headers are matched without spaces, punctuation or case
+	idx, missing := m.HeaderIndex([]string{"UNIT", "bd / ba", "Tenant", "Status", "Rent", "Lease-From"})
+	if len(missing) != 0 {
+		t.Errorf("[TestMappingHeaderIndex] Expected no missing fields, but got `%v`", missing)
+	}
+	if idx["Style"] != 1 || idx["LeaseStart"] != 5 {
+		t.Errorf("[TestMappingHeaderIndex] Expected Style at 1 and LeaseStart at 5, but got `%v`", idx)
+	}
+
+	// CASE: NEGATIVE, a title row is not a header row
+	_, missing = m.HeaderIndex([]string{"Rent Roll", "As of 10/1/2017"})
+	if len(missing) != 3 {
+		t.Errorf("[TestMappingHeaderIndex] Expected Unit, Style and Status missing, but got `%v`", missing)
+	}
+}
+
+// Testing for `value transforms`
+func TestMappingTransformValue(t *testing.T) {
+	m := testMapping
+	cases := []struct {
+		field, in, out string
+	}{
+		{"Rent", "$1,250.00", "1250.00"},
+		{"Rent", "($75.50)", "-75.50"},
+		{"LeaseStart", "03/01/2017", "3/1/2017"},
+		{"LeaseStart", "2017-12-31", "12/31/2017"},
+		{"LeaseStart", "", ""},
+		{"Unit", "101", "101"},
+	}
+	for _, c := range cases {
+		v, err := m.TransformValue(c.field, c.in)
+		if err != nil || v != c.out {
+			t.Errorf("[TestMappingTransformValue] Expected `%s` for %s `%s`, but got `%s` (err = %v)", c.out, c.field, c.in, v, err)
+		}
+	}
+
+	// CASE: NEGATIVE, a date in none of the formats
+	if _, err := m.TransformValue("LeaseStart", "31.12.2017"); err == nil {
+		t.Errorf("[TestMappingTransformValue] Expected an error for an unrecognized date")
+	}
+}
+
+// Testing for `status codes`
+func TestMappingMapStatus(t *testing.T) {
+	m := testMapping
+	cases := map[string]string{
+		"Current":         "occupied",
+		"Vacant-Rented":   "vacant",
+		"Notice-Unrented": "occupied",
+		"":                "",
+		"Demolished":      "",
+	}
+	for in, out := range cases {
+		if st := m.MapStatus(in); st != out {
+			t.Errorf("[TestMappingMapStatus] Expected `%s` for `%s`, but got `%s`", out, in, st)
+		}
+	}
+}
+
+// Testing for `name splitting`
+func TestMappingSplitName(t *testing.T) {
+	m := testMapping
+	first, last := m.SplitName("Mary Ann Smith, John Smith")
+	if first != "Mary Ann" || last != "Smith" {
+		t.Errorf("[TestMappingSplitName] Expected `Mary Ann` `Smith`, but got `%s` `%s`", first, last)
+	}
+
+	m.NameFormat = "last, first"
+	first, last = m.SplitName("Smith, John")
+	if first != "John" || last != "Smith" {
+		t.Errorf("[TestMappingSplitName] Expected `John` `Smith`, but got `%s` `%s`", first, last)
+	}
+}
+
+// Testing for `row mapping`
+func TestMappingMapRow(t *testing.T) {
+	m := testMapping
+	idx, _ := m.HeaderIndex([]string{"Unit", "Unit Type", "Status", "Tenant", "Rent", "Lease From"})
+
+	r, errs := m.MapRow(idx, []string{"101", "", "Current", "John Smith", "$900.00", "bad date"})
+	if len(errs) != 1 {
+		t.Errorf("[TestMappingMapRow] Expected 1 error, but got `%v`", errs)
+	}
+	if r["Style"] != "Standard" || r["FirstName"] != "John" || r["LastName"] != "Smith" || r["Rent"] != "900.00" {
+		t.Errorf("[TestMappingMapRow] Unexpected row `%v`", r)
+	}
+	if !m.IsDataRow(r) {
+		t.Errorf("[TestMappingMapRow] Expected unit 101 to be a data row")
+	}
+
+	r, _ = m.MapRow(idx, []string{"Total", "", "", "", "$900.00", ""})
+	if m.IsDataRow(r) {
+		t.Errorf("[TestMappingMapRow] Expected the total row not to be a data row")
+	}
+}
+
+// ======== TEST FOR METHODS DEFINED IN `mapped.go` ========
+
+// Testing for `shipped mappings`
+func TestShippedMappings(t *testing.T) {
+	files, _ := filepath.Glob("mappings/*.json")
+	if len(files) < 1 {
+		t.Fatalf("[TestShippedMappings] Expected the appfolio mapping, but found `%v`", files)
+	}
+	for _, f := range files {
+		if _, err := LoadMapping(f); err != nil {
+			t.Errorf("[TestShippedMappings] %s", err.Error())
+		}
+	}
+}
+
+// newTestImport loads the test mapping name for reading rows, nothing is
+// written to the database. The test mappings read the sample files of the
+// importer tests, they are not shipped.
+func newTestImport(t *testing.T, name string) *mappedImport {
+	m, err := LoadMapping(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatalf("cannot load mapping %s: %s", name, err.Error())
+	}
+	return &mappedImport{
+		m:         m,
+		timestamp: "ts",
+		rows:      map[int]MappedRow{},
+		status:    map[int]string{},
+		unitMap:   map[int]string{},
+		caLines:   map[string]int{},
+		csvErrors: map[int][]string{},
+	}
+}
+
+// Testing for `reading a onesite rent roll through its mapping`
+func TestMappedOnesiteRows(t *testing.T) {
+	mi := newTestImport(t, "onesite")
+	if !mi.readRows(rlib.LoadCSV("../../test/importers/onesite/onesite_sample/onesite.csv")) {
+		t.Fatalf("[TestMappedOnesiteRows] readRows failed: %v", mi.csvErrors)
+	}
+	mi.mapStatus()
+
+	// the data ends at the blank row above the totals
+	if len(mi.rows) != 6 {
+		t.Errorf("[TestMappedOnesiteRows] Expected 6 units, but got %d", len(mi.rows))
+	}
+	r := mi.rows[7]
+	if r[FieldUnit] != "6303-016" || r[FieldStyle] != "A1-HOSP" || r[FieldFirstName] != "Jimmy" || r[FieldLastName] != "Chen" {
+		t.Errorf("[TestMappedOnesiteRows] Unexpected first row `%v`", r)
+	}
+	if r["SqFt"] != "656" || r[FieldMarketRent] != "700" || r[FieldRent] != "500" || r[FieldLeaseStart] != "10/1/2015" {
+		t.Errorf("[TestMappedOnesiteRows] Unexpected values in first row `%v`", r)
+	}
+	for ln, st := range map[int]string{7: StatusOccupied, 9: StatusOccupied, 12: StatusModel} {
+		if mi.status[ln] != st {
+			t.Errorf("[TestMappedOnesiteRows] Expected status %s for line %d, but got `%s`", st, ln, mi.status[ln])
+		}
+	}
+}
+
+// Testing for `reading a paged roomkey report and its guest export`
+func TestMappedRoomKeyRows(t *testing.T) {
+	dir := "../../test/importers/roomkey/roomkey_sample_guest/"
+	mi := newTestImport(t, "roomkey")
+	if !mi.readRows(rlib.LoadCSV(dir + "roomkey.csv")) {
+		t.Fatalf("[TestMappedRoomKeyRows] readRows failed: %v", mi.csvErrors)
+	}
+	if !mi.readLookup(rlib.LoadCSV(dir + "guestdataexport.csv")) {
+		t.Fatalf("[TestMappedRoomKeyRows] readLookup failed: %v", mi.csvErrors)
+	}
+	mi.mapStatus()
+
+	// the date in is one column right of its header, the description row
+	// below the guest goes to the notes
+	r := mi.rows[7]
+	if r[FieldUnit] != "6608118" || r[FieldLastName] != "adams" || r[FieldLeaseStart] != "9/15/2016" || r["ReservationDate"] != "9/14/2016" {
+		t.Errorf("[TestMappedRoomKeyRows] Unexpected first row `%v`", r)
+	}
+	if r[FieldNotes] != "Res:85179. Dieter called for a new DVD player. Called MNT to take it over." {
+		t.Errorf("[TestMappedRoomKeyRows] Unexpected notes `%s`", r[FieldNotes])
+	}
+	if mi.status[7] != StatusOccupied {
+		t.Errorf("[TestMappedRoomKeyRows] Expected every guest occupied, got `%s`", mi.status[7])
+	}
+
+	// the guest export fills in contact data, but not an invalid email
+	if r = mi.rows[13]; r[FieldEmail] != "boalking1@gmail.com" || r["City"] != "Oklahoma City" {
+		t.Errorf("[TestMappedRoomKeyRows] Expected the guest export data, but got `%v`", r)
+	}
+	if r = mi.rows[9]; r[FieldEmail] != "" || r[FieldPhone] != "2514581075" {
+		t.Errorf("[TestMappedRoomKeyRows] Expected no email and phone 2514581075, but got `%v`", r)
+	}
+
+	// the header repeats on the second page with the columns moved
+	if r = mi.rows[29]; r[FieldUnit] != "6373283" || r[FieldStyle] != "1 BDR NS/POC-Q/POC" || r[FieldRent] != "3.21" {
+		t.Errorf("[TestMappedRoomKeyRows] Unexpected row on the second page `%v`", r)
+	}
+	for ln := range mi.rows {
+		if ln == 27 || ln == 28 {
+			t.Errorf("[TestMappedRoomKeyRows] The page and header rows are not data, got line %d", ln)
+		}
+	}
+}
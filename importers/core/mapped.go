@@ -0,0 +1,720 @@
+package core
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path"
+	"rentroll/rcsv"
+	"rentroll/rlib"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mappedCSVPrefix holds the prefix of the temporary csv files written for
+// each type of record, the import timestamp completes the file name
+var mappedCSVPrefix = map[int]string{
+	DBRentableType:    "mappedRentableTypes_",
+	DBPeople:          "mappedPeople_",
+	DBRentable:        "mappedRentable_",
+	DBRentalAgreement: "mappedRentalAgreement_",
+	DBCustomAttr:      "mappedCustomAttributes_",
+}
+
+// MappedStatusWriteCSV holds the set of csv types written for a unit in each
+// canonical status. Only occupied units have residents and agreements.
+var MappedStatusWriteCSV = map[string][]int{
+	StatusOccupied: {RENTABLETYPECSV, PEOPLECSV, RENTABLECSV, RENTALAGREEMENTCSV},
+	StatusVacant:   {RENTABLETYPECSV, RENTABLECSV},
+	StatusModel:    {RENTABLETYPECSV, RENTABLECSV},
+	StatusEmployee: {RENTABLETYPECSV, RENTABLECSV},
+	StatusOffline:  {RENTABLETYPECSV, RENTABLECSV},
+}
+
+// mappedRRStatus is the rentroll rentable status code for each canonical status
+var mappedRRStatus = map[string]string{
+	StatusOccupied: "1", // online
+	StatusVacant:   "1", // online
+	StatusModel:    "2", // admin
+	StatusEmployee: "3", // employee
+	StatusOffline:  "5", // offline
+}
+
+// mappedSkipList contains the rcsv errors about records that already exist,
+// they are logged but not reported
+var mappedSkipList = []string{
+	rcsv.DupTransactant,
+	rcsv.DupRentableType,
+	rcsv.DupCustomAttribute,
+	rcsv.DupRentable,
+	rcsv.RentableAlreadyRented,
+}
+
+const mappedTCIDPrefix = "TC000"
+
+// mappedImport holds the state of one import of a csv file through a Mapping
+type mappedImport struct {
+	m          *Mapping
+	lookupPath string // the file of m.Lookup, if any
+	business   *rlib.Business
+	userValues map[string]string // user supplied and default values, see MappedDefaultValues
+	tempStore  string            // folder for the temporary csv files
+	timestamp  string            // makes the temporary files and notes unique
+	dtStart    string            // today, start date of every record
+	dtStop     string            // open end date
+	rows       map[int]MappedRow // csv line number -> row
+	status     map[int]string    // csv line number -> canonical status
+	tcid       map[int]string    // csv line number -> TCID of the resident
+	unitMap    map[int]string    // csv line number -> unit name
+	caLines    map[string]int    // rentable type style -> csv line of its custom attribute values
+	csvErrors  map[int][]string  // csv line number -> "E:<dbtype>:reason" and "W:<dbtype>:reason"
+	summary    map[int]map[string]int
+}
+
+// MappedDefaultValues are used for the values the user does not supply
+var MappedDefaultValues = map[string]string{
+	"ManageToBudget": "1",
+	"RentCycle":      "6",
+	"Proration":      "4",
+	"GSRPC":          "4",
+	"AssignmentTime": "1",
+	"Renewal":        "2",
+}
+
+// addErr records an error or warning for csv line ln. kind is "E" or "W".
+func (mi *mappedImport) addErr(ln int, kind string, dbType int, reason string) {
+	mi.csvErrors[ln] = append(mi.csvErrors[ln], kind+":<"+DBTypeMapStrings[dbType]+">:"+reason)
+}
+
+// note returns the Notes value that identifies the person loaded from csv
+// line ln, it is used to find the person's TCID after the people are loaded.
+// The resident notes of the row follow the identifier.
+func (mi *mappedImport) note(ln int) string {
+	s := normalizeHeader(mi.m.Name) + "$" + mi.timestamp + "$" + strconv.Itoa(ln)
+	if n := mi.rows[ln][FieldNotes]; len(n) > 0 {
+		s += " " + n
+	}
+	return s
+}
+
+// tempCSVName returns the name of the temporary csv file for dbType
+func (mi *mappedImport) tempCSVName(dbType int) string {
+	return path.Join(mi.tempStore, mappedCSVPrefix[dbType]+mi.timestamp+".csv")
+}
+
+// clearTempCSVFiles removes the temporary csv files
+func (mi *mappedImport) clearTempCSVFiles() {
+	for dbType := range mappedCSVPrefix {
+		os.Remove(mi.tempCSVName(dbType))
+	}
+}
+
+// canWrite reports whether records of csvType are written for line ln
+func (mi *mappedImport) canWrite(ln, csvType int) bool {
+	return IntegerInSlice(csvType, MappedStatusWriteCSV[mi.status[ln]])
+}
+
+// record builds a csv record with the columns of the rcsv struct p. Each
+// column gets its value from vals, then from the row through the mapping
+// section fm if the row has a value for the mapped field.
+func record(p interface{}, vals map[string]string, fm map[string]string, r MappedRow) []string {
+	cols, _ := GetStructFields(p)
+	rec := make([]string, len(cols))
+	for i, c := range cols {
+		rec[i] = vals[c]
+		if f, ok := fm[c]; ok && len(r[f]) > 0 {
+			rec[i] = r[f]
+		}
+	}
+	return rec
+}
+
+// writeTempCSV writes the header of rcsv struct p and the records to the
+// temporary csv file for dbType
+func (mi *mappedImport) writeTempCSV(dbType int, p interface{}, recs [][]string) error {
+	f, err := os.Create(mi.tempCSVName(dbType))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	cols, _ := GetStructFields(p)
+	w.Write(cols)
+	w.WriteAll(recs)
+	return w.Error()
+}
+
+// defaults returns the values every record starts with
+func (mi *mappedImport) defaults() map[string]string {
+	vals := map[string]string{}
+	for k, v := range mi.userValues {
+		vals[k] = v
+	}
+	vals["BUD"] = mi.business.Designation
+	vals["DtStart"] = mi.dtStart
+	vals["DtStop"] = mi.dtStop
+	return vals
+}
+
+// blankRow reports whether every cell of row is blank
+func blankRow(row []string) bool {
+	for _, c := range row {
+		if len(strings.TrimSpace(c)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// readRows finds the header row and maps every data row below it, adding the
+// continuation rows to the data row above them. Rows whose status cannot be
+// mapped are reported and not imported.
+//
+// RETURNS
+//  false if the csv file has no header row or no data, the reason is in
+//  csvErrors[-1]
+//-----------------------------------------------------------------------------
+func (mi *mappedImport) readRows(t [][]string) bool {
+	var idx map[string]int
+	var missing []string
+	hdr := -1
+	for i := 0; i < len(t) && hdr < 0; i++ {
+		var miss []string
+		if idx, miss = mi.m.HeaderIndex(t[i]); len(miss) == 0 {
+			hdr = i
+		} else if missing == nil || len(miss) < len(missing) {
+			missing = miss
+		}
+	}
+	if hdr < 0 {
+		mi.csvErrors[-1] = append(mi.csvErrors[-1], "Required data column(s) missing: "+strings.Join(missing, ", "))
+		return false
+	}
+
+	prev := 0 // line of the last data row
+	c := mi.m.Continuation
+	for i := hdr + 1; i < len(t); i++ {
+		ln := i + 1 // line numbers in the report start at 1
+		if mi.m.EndAtBlankRow && blankRow(t[i]) {
+			break
+		}
+		if mi.m.RepeatedHeaders {
+			if x, miss := mi.m.HeaderIndex(t[i]); len(miss) == 0 {
+				idx = x
+				continue
+			}
+		}
+		r, errs := mi.m.MapRow(idx, t[i])
+		if !mi.m.IsDataRow(r) {
+			if c != nil && prev > 0 && c.Column < len(t[i]) {
+				if s := strings.TrimSpace(t[i][c.Column]); len(s) > 0 {
+					mi.rows[prev][c.Field] = strings.TrimSpace(mi.rows[prev][c.Field] + " " + s)
+				}
+			}
+			continue
+		}
+		prev = ln
+		mi.rows[ln] = r
+		mi.unitMap[ln] = r[FieldUnit]
+		for _, err := range errs {
+			mi.addErr(ln, "W", DBRentable, err.Error())
+		}
+	}
+	if len(mi.rows) == 0 {
+		mi.csvErrors[-1] = append(mi.csvErrors[-1], "There are no data rows present")
+		return false
+	}
+	return true
+}
+
+// readLookup reads the lookup file of the mapping and fills in the blank
+// fields of the rows it has data for.
+//
+// RETURNS
+//  false if the lookup file has no header row, the reason is in csvErrors[-1]
+//-----------------------------------------------------------------------------
+func (mi *mappedImport) readLookup(t [][]string) bool {
+	lk := mi.m.Lookup
+	key := func(s string) string { return strings.ToLower(strings.TrimSpace(s)) }
+	hdr := -1
+	var idx map[string]int
+	for i := 0; i < len(t) && hdr < 0; i++ {
+		if idx = columnIndex(lk.Columns, t[i]); len(idx) > 0 {
+			if _, ok := idx[lk.Key]; ok {
+				hdr = i
+			}
+		}
+	}
+	if hdr < 0 {
+		mi.csvErrors[-1] = append(mi.csvErrors[-1], lk.Name+" file: required data column(s) missing: "+lk.Key)
+		return false
+	}
+
+	// rows that share a key cannot be told apart
+	count := map[string]int{}
+	for _, r := range mi.rows {
+		count[key(r[lk.Key])]++
+	}
+	for i := hdr + 1; i < len(t); i++ {
+		if idx[lk.Key] >= len(t[i]) {
+			continue
+		}
+		k := key(t[i][idx[lk.Key]])
+		if len(k) == 0 || count[k] != 1 {
+			continue
+		}
+		for _, r := range mi.rows {
+			if key(r[lk.Key]) != k {
+				continue
+			}
+			for f, j := range idx {
+				if j >= len(t[i]) || len(r[f]) > 0 {
+					continue
+				}
+				if v, err := mi.m.TransformValue(f, strings.TrimSpace(t[i][j])); err == nil {
+					r[f] = v
+				}
+			}
+		}
+	}
+	return true
+}
+
+// mapStatus formats the rows and maps their statuses. Rows whose status
+// cannot be mapped are reported and not imported.
+func (mi *mappedImport) mapStatus() {
+	for ln, r := range mi.rows {
+		mi.m.FormatRow(r)
+		if mi.status[ln] = mi.m.MapStatus(r[FieldStatus]); len(mi.status[ln]) == 0 {
+			mi.addErr(ln, "E", DBRentable, fmt.Sprintf("Unknown unit status %q", r[FieldStatus]))
+		}
+	}
+}
+
+// lines returns the line numbers of the data rows in order
+func (mi *mappedImport) lines() []int {
+	var a []int
+	for ln := range mi.rows {
+		a = append(a, ln)
+	}
+	sort.Ints(a)
+	return a
+}
+
+// load runs an rcsv loader over the temporary csv file for dbType and
+// records its errors against the csv lines the records came from. trace maps
+// the line of the temporary file to the csv line.
+//
+// RETURNS
+//  false if an rcsv error could not be parsed
+//-----------------------------------------------------------------------------
+func (mi *mappedImport) load(dbType int, handler func(string) []error, trace map[int]int) bool {
+	fname := mi.tempCSVName(dbType)
+	for _, err := range handler(fname) {
+		if dbType == DBPeople && (strings.Contains(err.Error(), "PrimaryEmail") || strings.Contains(err.Error(), "CellPhone")) {
+			// the person already exists, rent to them
+			lineNo, _, ok := ParseRCSVError(err, dbType)
+			if !ok {
+				return false
+			}
+			ln := trace[lineNo]
+			id := mi.rows[ln][FieldEmail]
+			if !strings.Contains(err.Error(), "PrimaryEmail") {
+				id = mi.rows[ln][FieldPhone]
+			}
+			t := rlib.GetTransactantByPhoneOrEmail(mi.business.BID, id)
+			if t.TCID == 0 {
+				mi.addErr(ln, "E", DBPeople, "Unable to get people information")
+			} else {
+				rlib.Ulog("DUPLICATE RECORD ERROR <%s>: %s\n", fname, err.Error())
+				mi.tcid[ln] = mappedTCIDPrefix + strconv.FormatInt(t.TCID, 10)
+			}
+			continue
+		}
+		if IsRCSVSkipError(err) {
+			rlib.Ulog("DUPLICATE RECORD ERROR <%s>: %s\n", fname, err.Error())
+			continue
+		}
+		lineNo, reason, ok := ParseRCSVError(err, dbType)
+		if !ok {
+			return false
+		}
+		ln := trace[lineNo]
+		mi.csvErrors[ln] = append(mi.csvErrors[ln], reason)
+	}
+	return true
+}
+
+// writeRentableTypesAndPeople writes the temporary csv files of the records
+// that do not depend on other records
+//
+// RETURNS
+//  the trace maps of the rentable type, custom attribute and people files
+//  any error writing the files
+//-----------------------------------------------------------------------------
+func (mi *mappedImport) writeRentableTypesAndPeople() (map[int]int, map[int]int, map[int]int, error) {
+	rtTrace, caTrace, pTrace := map[int]int{}, map[int]int{}, map[int]int{}
+	var rts, cas, people [][]string
+	styles := map[string]bool{}
+	attrs := map[string]bool{}
+	names := map[string]bool{}
+	for _, ln := range mi.lines() {
+		r := mi.rows[ln]
+		if mi.canWrite(ln, RENTABLETYPECSV) && !styles[r[FieldStyle]] {
+			styles[r[FieldStyle]] = true
+			vals := mi.defaults()
+			vals["Style"] = r[FieldStyle]
+			vals["Name"] = r[FieldStyle]
+			rts = append(rts, record(&RentableTypeCSV{}, vals, mi.m.RentableTypeCSV, r))
+			rtTrace[len(rts)+1] = ln // first line of the file is the header
+
+			// the first row of each rentable type supplies its custom attributes
+			mi.caLines[r[FieldStyle]] = ln
+			for _, ca := range mi.m.CustomAttributes {
+				v := r[ca.Field]
+				if len(v) == 0 || attrs[ca.Name+"$"+v] {
+					continue
+				}
+				attrs[ca.Name+"$"+v] = true
+				cas = append(cas, []string{mi.business.Designation, ca.Name, ca.ValueType, v, ca.Units})
+				caTrace[len(cas)+1] = ln
+			}
+		}
+		if !mi.canWrite(ln, PEOPLECSV) {
+			continue
+		}
+		mi.tcid[ln] = ""
+
+		// people who cannot be told apart could be the same person
+		name := strings.ToLower(r[FieldFirstName] + " " + r[FieldLastName])
+		if len(r[FieldEmail]) == 0 && len(r[FieldPhone]) == 0 {
+			if names[name] {
+				mi.addErr(ln, "W", DBPeople, "There is at least one other person with the name \""+
+					strings.TrimSpace(name)+"\" who also has no unique identifiers such as cell phone number or email.")
+			}
+			names[name] = true
+		}
+		vals := mi.defaults()
+		vals["FirstName"] = r[FieldFirstName]
+		vals["LastName"] = r[FieldLastName]
+		vals["PrimaryEmail"] = r[FieldEmail]
+		vals["WorkPhone"] = r[FieldPhone]
+		rec := record(&PeopleCSV{}, vals, mi.m.PeopleCSV, r)
+		cols, _ := GetStructFields(&PeopleCSV{})
+		for i := range cols {
+			if cols[i] == "Notes" {
+				rec[i] = mi.note(ln)
+			}
+		}
+		people = append(people, rec)
+		pTrace[len(people)+1] = ln
+	}
+	if err := mi.writeTempCSV(DBRentableType, &RentableTypeCSV{}, rts); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := mi.writeTempCSV(DBCustomAttr, &CustomAttributeCSV{}, cas); err != nil {
+		return nil, nil, nil, err
+	}
+	mi.summary[DBRentableType]["possible"] = len(rts)
+	mi.summary[DBPeople]["possible"] = len(people)
+	if len(mi.m.CustomAttributes) > 0 {
+		mi.summary[DBCustomAttr]["possible"] = len(cas)
+	}
+	return rtTrace, caTrace, pTrace, mi.writeTempCSV(DBPeople, &PeopleCSV{}, people)
+}
+
+// insertCustomAttributeRefs attaches the custom attributes to the rentable
+// types once both are loaded. rcsv has no loader for the references.
+func (mi *mappedImport) insertCustomAttributeRefs() {
+	var styles []string
+	for style := range mi.caLines {
+		styles = append(styles, style)
+	}
+	sort.Strings(styles)
+	for _, style := range styles {
+		ln := mi.caLines[style]
+		for _, ca := range mi.m.CustomAttributes {
+			v := mi.rows[ln][ca.Field]
+			if len(v) == 0 {
+				continue
+			}
+			mi.summary[DBCustomAttrRef]["possible"]++
+			rt, err := rlib.GetRentableTypeByStyle(style, mi.business.BID)
+			if err != nil || rt.RTID == 0 {
+				mi.addErr(ln, "E", DBCustomAttrRef, "Unable to insert custom attribute, rentable type "+style+" was not loaded")
+				continue
+			}
+			t, _ := strconv.ParseInt(ca.ValueType, 10, 64)
+			c := rlib.GetCustomAttributeByVals(t, ca.Name, v, ca.Units)
+			if c.CID == 0 {
+				mi.addErr(ln, "E", DBCustomAttrRef, "Unable to insert custom attribute, "+ca.Name+" "+v+" was not loaded")
+				continue
+			}
+			a := rlib.CustomAttributeRef{ElementType: rlib.ELEMRENTABLETYPE, BID: mi.business.BID, ID: rt.RTID, CID: c.CID}
+			if ref := rlib.GetCustomAttributeRef(a.ElementType, a.ID, a.CID); ref.CID == a.CID && ref.ID == a.ID {
+				continue
+			}
+			if err = rlib.InsertCustomAttributeRef(&a); err != nil {
+				rlib.Ulog("insertCustomAttributeRefs: %s\n", err.Error())
+				mi.addErr(ln, "E", DBCustomAttrRef, "Unable to insert custom attribute")
+			}
+		}
+	}
+}
+
+// writeRentablesAndAgreements writes the temporary csv files of the rentables
+// and rental agreements once the TCIDs of the residents are known
+func (mi *mappedImport) writeRentablesAndAgreements() (map[int]int, map[int]int, error) {
+	rTrace, raTrace := map[int]int{}, map[int]int{}
+	var rentables, ras [][]string
+	units := map[string]bool{} // a unit listed once for each of its residents is one rentable
+	for _, ln := range mi.lines() {
+		r := mi.rows[ln]
+		if mi.canWrite(ln, RENTABLECSV) && !units[r[FieldUnit]] {
+			units[r[FieldUnit]] = true
+			vals := mi.defaults()
+			vals["Name"] = r[FieldUnit]
+			vals["RentableStatus"] = mappedRRStatus[mi.status[ln]] + "," + mi.dtStart + ","
+			vals["RentableTypeRef"] = r[FieldStyle] + "," + mi.dtStart + ","
+			rentables = append(rentables, record(&RentableCSV{}, vals, mi.m.RentableCSV, r))
+			rTrace[len(rentables)+1] = ln
+		}
+		if !mi.canWrite(ln, RENTALAGREEMENTCSV) {
+			continue
+		}
+		if len(mi.tcid[ln]) == 0 {
+			mi.addErr(ln, "E", DBRentalAgreement, "No resident was loaded for this unit, the rental agreement was not created")
+			continue
+		}
+		start, stop := r[FieldLeaseStart], r[FieldLeaseEnd]
+		if len(start) == 0 {
+			start = mi.dtStart
+			mi.addErr(ln, "W", DBRentalAgreement, "No lease start date found. Using default value: "+start)
+		}
+		if len(stop) == 0 {
+			stop = mi.dtStop
+			mi.addErr(ln, "W", DBRentalAgreement, "No lease end date found. Using default value: "+stop)
+		}
+		spec := mi.tcid[ln] + "," + start + "," + stop
+		vals := mi.defaults()
+		vals["AgreementStart"], vals["AgreementStop"] = start, stop
+		vals["RentStart"], vals["RentStop"] = start, stop
+		vals["PossessionStart"], vals["PossessionStop"] = start, stop
+		vals["PayorSpec"] = spec
+		vals["UserSpec"] = spec
+		vals["RentableSpec"] = r[FieldUnit] + "," + r[FieldRent]
+		ras = append(ras, record(&RentalAgreementCSV{}, vals, mi.m.RentalAgreementCSV, r))
+		raTrace[len(ras)+1] = ln
+	}
+	if err := mi.writeTempCSV(DBRentable, &RentableCSV{}, rentables); err != nil {
+		return nil, nil, err
+	}
+	mi.summary[DBRentable]["possible"] = len(rentables)
+	mi.summary[DBRentalAgreement]["possible"] = len(ras)
+	return rTrace, raTrace, mi.writeTempCSV(DBRentalAgreement, &RentalAgreementCSV{}, ras)
+}
+
+// loadMappedCSV loads the csv file into mi.business.
+//
+// RETURNS
+//  true if there was an internal error
+//-----------------------------------------------------------------------------
+func (mi *mappedImport) loadMappedCSV(csvPath string) bool {
+	funcname := "loadMappedCSV"
+	if !mi.readRows(rlib.LoadCSV(csvPath)) {
+		return false
+	}
+	if mi.m.Lookup != nil && len(mi.lookupPath) > 0 && !mi.readLookup(rlib.LoadCSV(mi.lookupPath)) {
+		return false
+	}
+	mi.mapStatus()
+
+	rtTrace, caTrace, pTrace, err := mi.writeRentableTypesAndPeople()
+	if err != nil {
+		rlib.Ulog("%s: INTERNAL ERROR <TEMP CSV>: %s\n", funcname, err.Error())
+		return true
+	}
+	if !mi.load(DBRentableType, rcsv.LoadRentableTypesCSV, rtTrace) ||
+		!mi.load(DBCustomAttr, rcsv.LoadCustomAttributesCSV, caTrace) {
+		return true
+	}
+	mi.insertCustomAttributeRefs()
+	if !mi.load(DBPeople, rcsv.LoadPeopleCSV, pTrace) {
+		return true
+	}
+
+	// find the people by the note they were loaded with, people that already
+	// existed were found by load
+	for ln := range mi.tcid {
+		if tcid := rlib.GetTCIDByNote(mi.note(ln)); tcid != 0 {
+			mi.tcid[ln] = mappedTCIDPrefix + strconv.Itoa(tcid)
+		}
+	}
+
+	rTrace, raTrace, err := mi.writeRentablesAndAgreements()
+	if err != nil {
+		rlib.Ulog("%s: INTERNAL ERROR <TEMP CSV>: %s\n", funcname, err.Error())
+		return true
+	}
+	if !mi.load(DBRentable, rcsv.LoadRentablesCSV, rTrace) ||
+		!mi.load(DBRentalAgreement, rcsv.LoadRentalAgreementCSV, raTrace) {
+		return true
+	}
+	return false
+}
+
+// MappedCSVHandler imports the csv file at csvPath, exported by the system
+// that mapping m describes, into business. The data is loaded into a new
+// business while the existing one is held aside, and it only replaces the
+// existing one if the import succeeds. If dryRunMode is 1 the imported data
+// is always discarded.
+//
+// INPUTS
+//  m            - the mapping of the source system
+//  csvPath      - the csv file to import
+//  lookupPath   - the file described by m.Lookup, may be blank
+//  tempStore    - folder for the temporary rcsv files
+//  testMode     - if 1 the temporary files are kept
+//  userRRValues - rent cycle, proration and other values from the user,
+//                 MappedDefaultValues supplies any that are missing
+//  business     - the business being imported
+//  debugMode    - if 1 the report lists every record that was loaded
+//  dryRunMode   - if 1 nothing is saved
+//
+// RETURNS
+//  the report
+//  true if there was an internal error
+//  true if the data was loaded
+//-----------------------------------------------------------------------------
+func MappedCSVHandler(
+	m *Mapping,
+	csvPath string,
+	lookupPath string,
+	tempStore string,
+	testMode int,
+	userRRValues map[string]string,
+	business *rlib.Business,
+	debugMode int,
+	dryRunMode int,
+) (string, bool, bool) {
+	csvLoaded := true
+	csvReport := ""
+	currentTime := time.Now()
+
+	mi := mappedImport{
+		m:          m,
+		lookupPath: lookupPath,
+		business:   business,
+		userValues: map[string]string{},
+		tempStore:  tempStore,
+		timestamp:  currentTime.Format(time.RFC3339Nano),
+		dtStart:    currentTime.Format(MappedDateFmt),
+		dtStop:     "12/31/9999",
+		rows:       map[int]MappedRow{},
+		status:     map[int]string{},
+		tcid:       map[int]string{},
+		unitMap:    map[int]string{},
+		caLines:    map[string]int{},
+		csvErrors:  map[int][]string{},
+		summary: map[int]map[string]int{
+			DBRentableType:    {"imported": 0, "possible": 0, "issues": 0},
+			DBPeople:          {"imported": 0, "possible": 0, "issues": 0},
+			DBRentable:        {"imported": 0, "possible": 0, "issues": 0},
+			DBRentalAgreement: {"imported": 0, "possible": 0, "issues": 0},
+		},
+	}
+	if len(m.CustomAttributes) > 0 {
+		mi.summary[DBCustomAttr] = map[string]int{"imported": 0, "possible": 0, "issues": 0}
+		mi.summary[DBCustomAttrRef] = map[string]int{"imported": 0, "possible": 0, "issues": 0}
+	}
+	for k, v := range MappedDefaultValues {
+		mi.userValues[k] = v
+	}
+	for k, v := range userRRValues {
+		if len(v) > 0 {
+			mi.userValues[k] = v
+		}
+	}
+
+	// ====== Hold the existing business aside =====
+	stage, err := BeginStagedImport(business)
+	if err != nil {
+		return "\n\n" + err.Error(), false, false
+	}
+	finish := func(keep bool) {
+		if keep {
+			stage.Commit()
+		} else {
+			stage.Rollback()
+		}
+		if testMode != 1 {
+			mi.clearTempCSVFiles()
+		}
+	}
+
+	if internalErr := mi.loadMappedCSV(csvPath); internalErr {
+		finish(false)
+		return csvReport, true, csvLoaded
+	}
+
+	title := "Accord RentRoll " + m.Name + " Importer\n"
+	files := "Import File: " + csvPath + "\n"
+	if m.Lookup != nil && len(lookupPath) > 0 {
+		files += m.Lookup.Name + " File: " + lookupPath + "\n"
+	}
+	if len(mi.csvErrors) > 0 {
+		csvReport, csvLoaded = mappedErrorReport(title, business, mi.csvErrors, mi.unitMap, mi.summary, files, debugMode, currentTime)
+	} else {
+		csvReport = mappedSuccessReport(title, business, mi.summary, files, debugMode, currentTime)
+	}
+
+	if dryRunMode == 1 {
+		csvReport = DryRunReportNote + csvReport
+		finish(false)
+	} else {
+		finish(csvLoaded)
+	}
+	return csvReport, false, csvLoaded
+}
+
+// IsRCSVSkipError reports whether an rcsv error is about a record that
+// already exists, such errors are not reported to the user
+func IsRCSVSkipError(err error) bool {
+	for _, dup := range mappedSkipList {
+		if strings.Contains(err.Error(), dup) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRCSVError parses an rcsv loader error, which has the form
+// "{FunctionName}: line {LineNumber} - errorReason".
+//
+// RETURNS
+//  the line number of the temporary csv file
+//  the reason, prefixed with "E:<dbType>:" for the detailed report
+//  false if the error is not in the expected form
+//-----------------------------------------------------------------------------
+func ParseRCSVError(rcsvErr error, dbType int) (int, string, bool) {
+	s := strings.SplitN(rcsvErr.Error(), ":", 2)
+	if len(s) < 2 {
+		rlib.Ulog("INTERNAL ERRORS: RCSV Error is not in format of `{FunctionName}: line {LineNumber} - errorReason` for error: %s", rcsvErr.Error())
+		return 0, "", false
+	}
+	s = strings.SplitN(s[1], "-", 2)
+	if len(s) < 2 {
+		rlib.Ulog("INTERNAL ERRORS: RCSV Error is not in format of `{FunctionName}: line {LineNumber} - errorReason` for error: %s", rcsvErr.Error())
+		return 0, "", false
+	}
+	errText := strings.Replace(strings.TrimSpace(s[1]), "\n", "", -1)
+	errText = "E:<" + DBTypeMapStrings[dbType] + ">:" + errText
+	lineNo, err := strconv.Atoi(strings.TrimSpace(strings.Replace(s[0], "line", "", -1)))
+	if err != nil {
+		rlib.Ulog("INTERNAL ERRORS: RCSV Error is not in format of `{FunctionName}: line {LineNumber} - errorReason` for error: %s", errText)
+		return lineNo, errText, false
+	}
+	return lineNo, errText, true
+}
@@ -0,0 +1,220 @@
+package core
+
+import (
+	"fmt"
+	"gotable"
+	"rentroll/rlib"
+	"rentroll/rrpt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mappedReportHeader returns the date, time and file lines of the report,
+// files holds the line of each file that was imported
+func mappedReportHeader(importTime time.Time, files string) string {
+	tz, _ := importTime.Zone()
+	s := "Date: " + importTime.Format(MappedDateFmt) + "\n"
+	s += "Time: " + importTime.Format(time.Kitchen) + " " + tz + "\n"
+	s += files
+	s += "\n"
+	return s
+}
+
+// mappedSummaryReport returns the table of possible and imported counts for
+// each type of record
+func mappedSummaryReport(
+	title string,
+	summaryCount map[int]map[string]int,
+	BID int64,
+	currentTime time.Time,
+	files string,
+) string {
+	var tbl gotable.Table
+	tbl.Init()
+	tbl.SetTitle(title)
+	tbl.SetSection1(mappedReportHeader(currentTime, files))
+	tbl.SetSection2("Summary")
+
+	tbl.AddColumn("Data Type", 30, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Total Possible", 10, gotable.CELLINT, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Total Imported", 10, gotable.CELLINT, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Issues", 10, gotable.CELLINT, gotable.COLJUSTIFYLEFT)
+
+	GetImportedCount(summaryCount, BID)
+
+	var dbTypes []int
+	for dbType := range summaryCount {
+		dbTypes = append(dbTypes, dbType)
+	}
+	sort.Ints(dbTypes)
+	for _, dbType := range dbTypes {
+		countMap := summaryCount[dbType]
+		tbl.AddRow()
+		tbl.Puts(-1, 0, DBTypeMap[dbType])
+		tbl.Puti(-1, 1, int64(countMap["possible"]))
+		tbl.Puti(-1, 2, int64(countMap["imported"]))
+		tbl.Puti(-1, 3, int64(countMap["issues"]))
+	}
+
+	s, err := tbl.SprintTable()
+	if err != nil {
+		rlib.Ulog("mappedSummaryReport: error = %s", err.Error())
+	}
+	return s
+}
+
+// mappedDetailedReport lists the errors and warnings of each csv line. It
+// counts the issues of each type of record in summaryCount.
+//
+// RETURNS
+//  the report
+//  false if there were any errors, the data must not be kept
+//-----------------------------------------------------------------------------
+func mappedDetailedReport(
+	csvErrors map[int][]string,
+	unitMap map[int]string,
+	summaryCount map[int]map[string]int,
+) (string, bool) {
+	loaded := true
+
+	var tbl gotable.Table
+	tbl.Init()
+	tbl.SetTitle("DETAILED REPORT BY UNIT")
+	tbl.AddColumn("Input Line", 6, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Unit Name", 20, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Description", 100, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+
+	// -1 means the file could not be read at all
+	if e, ok := csvErrors[-1]; ok {
+		tbl.AddRow()
+		tbl.Puts(-1, 0, "")
+		tbl.Puts(-1, 1, "")
+		tbl.Puts(-1, 2, e[0])
+		s, err := tbl.SprintTable()
+		if err != nil {
+			rlib.Ulog("mappedDetailedReport: error = %s", err)
+		}
+		return s, false
+	}
+
+	var lines []int
+	for ln := range csvErrors {
+		lines = append(lines, ln)
+	}
+	sort.Ints(lines)
+
+	for _, ln := range lines {
+		var rowErrors, rowWarnings []string
+		for _, reason := range csvErrors[ln] {
+			switch {
+			case strings.HasPrefix(reason, "E:"):
+				loaded = false
+				if reason = reason[2:]; !StringInSlice(reason, rowErrors) {
+					rowErrors = append(rowErrors, reason)
+				}
+			case strings.HasPrefix(reason, "W:"):
+				if reason = reason[2:]; !StringInSlice(reason, rowWarnings) {
+					rowWarnings = append(rowWarnings, reason)
+				}
+			}
+		}
+		put := func(text, prefix string) {
+			sa := strings.SplitN(text, ">:", 2)
+			if len(sa) < 2 {
+				return
+			}
+			dbType, _ := strconv.Atoi(strings.Replace(sa[0], "<", "", -1))
+			if _, ok := summaryCount[dbType]; ok {
+				summaryCount[dbType]["issues"]++
+			}
+			tbl.AddRow()
+			tbl.Puts(-1, 0, strconv.Itoa(ln))
+			tbl.Puts(-1, 1, unitMap[ln])
+			tbl.Puts(-1, 2, prefix+sa[1])
+		}
+		for _, e := range rowErrors {
+			put(e, "")
+		}
+		for _, w := range rowWarnings {
+			put(w, "Warning: ")
+		}
+	}
+
+	s, err := tbl.SprintTable()
+	if err != nil {
+		rlib.Ulog("mappedDetailedReport: error = %s", err)
+	}
+	return s, loaded
+}
+
+// mappedRCSVReport lists every record that was loaded, customAttr adds the
+// custom attributes and their references
+func mappedRCSVReport(business *rlib.Business, customAttr bool) string {
+	var r = []rrpt.ReporterInfo{
+		{ReportNo: 5, OutputFormat: gotable.TABLEOUTTEXT, Handler: rrpt.RRreportRentableTypes, Bid: business.BID},
+		{ReportNo: 6, OutputFormat: gotable.TABLEOUTTEXT, Handler: rrpt.RRreportRentables, Bid: business.BID},
+		{ReportNo: 7, OutputFormat: gotable.TABLEOUTTEXT, Handler: rrpt.RRreportPeople, Bid: business.BID},
+		{ReportNo: 9, OutputFormat: gotable.TABLEOUTTEXT, Handler: rrpt.RRreportRentalAgreements, Bid: business.BID},
+	}
+	if customAttr {
+		r = append(r,
+			rrpt.ReporterInfo{ReportNo: 14, OutputFormat: gotable.TABLEOUTTEXT, Handler: rrpt.RRreportCustomAttributes, Bid: business.BID},
+			rrpt.ReporterInfo{ReportNo: 15, OutputFormat: gotable.TABLEOUTTEXT, Handler: rrpt.RRreportCustomAttributeRefs, Bid: business.BID},
+		)
+	}
+
+	title := fmt.Sprintf("RECORDS FOR BUSINESS UNIT DESIGNATION: %s", business.Name)
+	s := strings.Repeat("=", len(title)) + "\n" + title + "\n" + strings.Repeat("=", len(title)) + "\n\n"
+	for i := 0; i < len(r); i++ {
+		s += r[i].Handler(&r[i])
+		s += strings.Repeat("=", len(title)) + "\n"
+	}
+	return s
+}
+
+// mappedSuccessReport is the report of an import without errors or warnings
+func mappedSuccessReport(
+	title string,
+	business *rlib.Business,
+	summaryCount map[int]map[string]int,
+	files string,
+	debugMode int,
+	currentTime time.Time,
+) string {
+	s := mappedSummaryReport(title, summaryCount, business.BID, currentTime, files) + "\n"
+	if debugMode == 1 {
+		_, ca := summaryCount[DBCustomAttr]
+		s += mappedRCSVReport(business, ca)
+	}
+	return s
+}
+
+// mappedErrorReport is the report of an import with errors or warnings. The
+// detailed report is generated first because it counts the issues shown in
+// the summary.
+//
+// RETURNS
+//  the report
+//  false if there were any errors, the data must not be kept
+//-----------------------------------------------------------------------------
+func mappedErrorReport(
+	title string,
+	business *rlib.Business,
+	csvErrors map[int][]string,
+	unitMap map[int]string,
+	summaryCount map[int]map[string]int,
+	files string,
+	debugMode int,
+	currentTime time.Time,
+) (string, bool) {
+	detailed, loaded := mappedDetailedReport(csvErrors, unitMap, summaryCount)
+	s := mappedSummaryReport(title, summaryCount, business.BID, currentTime, files) + "\n"
+	s += detailed + "\n"
+	if loaded && debugMode == 1 {
+		_, ca := summaryCount[DBCustomAttr]
+		s += mappedRCSVReport(business, ca)
+	}
+	return s, loaded
+}
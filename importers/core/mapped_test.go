@@ -0,0 +1,82 @@
+// +build sqlite
+
+package core
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"strings"
+	"testing"
+)
+
+// mappedTestImport imports csv into a new business through the test
+// mapping name and returns the imported business
+func mappedTestImport(t *testing.T, name, csv, lookup string) (*rlib.Business, string) {
+	rrtest.OpenDB(t)
+	// the rcsv loaders look the business up by its lower case designation,
+	// designations compare without case in MySQL but not in SQLite
+	b := rrtest.NewBusiness(t, "iso")
+	m, err := LoadMapping("testdata/" + name + ".json")
+	if err != nil {
+		t.Fatalf("LoadMapping: %s", err.Error())
+	}
+	business := rlib.GetBusinessByDesignation(b.BUD)
+	report, internalErr, loaded := MappedCSVHandler(m, csv, lookup, t.TempDir(), 0, map[string]string{"BUD": b.BUD}, &business, 0, 0)
+	if internalErr || !loaded {
+		t.Fatalf("expect %s to load, got internal error %v and report:\n%s", csv, internalErr, report)
+	}
+	if business.BID == b.BID {
+		t.Fatalf("expect the import to replace business %d", b.BID)
+	}
+	return &business, report
+}
+
+func TestMappedCSVHandlerOnesite(t *testing.T) {
+	business, _ := mappedTestImport(t, "onesite", "../../test/importers/onesite/onesite_sample/onesite.csv", "")
+
+	// four floor plans, their first units have three different square footages
+	counts := []struct {
+		what   string
+		expect int
+		got    int
+	}{
+		{"rentable types", 4, rlib.GetCountBusinessRentableTypes(business.BID)},
+		{"custom attributes", 3, rlib.GetCountBusinessCustomAttributes(business.BID)},
+		{"custom attribute references", 4, rlib.GetCountBusinessCustomAttrRefs(business.BID)},
+		{"rentables", 6, rlib.GetCountBusinessRentables(business.BID)},
+		{"people", 5, rlib.GetCountBusinessTransactants(business.BID)},
+		{"rental agreements", 5, rlib.GetCountBusinessRentalAgreements(business.BID)},
+	}
+	for _, c := range counts {
+		if c.got != c.expect {
+			t.Errorf("expect %d %s, got %d", c.expect, c.what, c.got)
+		}
+	}
+	rt, err := rlib.GetRentableTypeByStyle("HRP2", business.BID)
+	if err != nil {
+		t.Fatalf("GetRentableTypeByStyle: %s", err.Error())
+	}
+	ca := rlib.GetCustomAttributeByVals(1, "Square Feet", "840", "sqft")
+	if ref := rlib.GetCustomAttributeRef(rlib.ELEMRENTABLETYPE, rt.RTID, ca.CID); ca.CID == 0 || ref.CID != ca.CID {
+		t.Errorf("expect rentable type HRP2 to have Square Feet 840")
+	}
+}
+
+func TestMappedCSVHandlerRoomKey(t *testing.T) {
+	dir := "../../test/importers/roomkey/roomkey_sample_guest/"
+	business, report := mappedTestImport(t, "roomkey", dir+"roomkey.csv", dir+"guestdataexport.csv")
+	if !strings.Contains(report, "Guest Export File: "+dir+"guestdataexport.csv") {
+		t.Errorf("expect the report to name the guest export file, got:\n%s", report)
+	}
+
+	// 23 guests in 21 rooms, a room is rented to the first of its guests.
+	// The agreements need the people, who are found by their notes.
+	people, ras := rlib.GetCountBusinessTransactants(business.BID), rlib.GetCountBusinessRentalAgreements(business.BID)
+	if people != 23 || ras != 21 {
+		t.Errorf("expect 23 people and 21 rental agreements, got %d people and %d agreements", people, ras)
+	}
+	tc := rlib.GetTransactantByPhoneOrEmail(business.BID, "boalking1@gmail.com")
+	if tc.TCID == 0 || tc.FirstName != "Mohammed" || tc.LastName != "Alfailakawi" || tc.City != "Oklahoma City" {
+		t.Errorf("expect Mohammed Alfailakawi of Oklahoma City from the guest export, got %#v", tc)
+	}
+}
@@ -0,0 +1,471 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Canonical fields of a mapped csv row. A Mapping names the source columns
+// that hold each of them, the importer only ever works with these names.
+const (
+	FieldUnit       = "Unit"       // unit name, rows without one are ignored
+	FieldStyle      = "Style"      // floor plan or unit type, becomes the rentable type
+	FieldStatus     = "Status"     // unit status, looked up in StatusCodes
+	FieldName       = "Name"       // resident name, split according to NameFormat
+	FieldFirstName  = "FirstName"  // resident first name, if the source has its own column
+	FieldLastName   = "LastName"   // resident last name, if the source has its own column
+	FieldEmail      = "Email"      // resident email
+	FieldPhone      = "Phone"      // resident phone
+	FieldMarketRent = "MarketRent" // market rent for the unit type
+	FieldRent       = "Rent"       // contract rent
+	FieldLeaseStart = "LeaseStart" // lease start date
+	FieldLeaseEnd   = "LeaseEnd"   // lease end date
+	FieldMoveIn     = "MoveIn"     // move in date
+	FieldMoveOut    = "MoveOut"    // move out date
+	FieldNotes      = "Notes"      // notes kept with the resident
+)
+
+// Canonical unit statuses. StatusCodes maps the codes of the source system
+// to one of these.
+const (
+	StatusOccupied = "occupied"
+	StatusVacant   = "vacant"
+	StatusModel    = "model"
+	StatusEmployee = "employee"
+	StatusOffline  = "offline"
+)
+
+// MappedDateFmt is the format dates are written in for the rcsv loaders
+const MappedDateFmt = "1/2/2006"
+
+// Mapping describes the csv export of a property management system. It is
+// read from a json file so that a new source system can be imported by
+// writing a mapping instead of a new importer.
+//
+// Columns lists the header names that may hold each canonical field. Headers
+// are compared after removing special characters and spaces and lowering
+// the case, so "Lease From" matches "leasefrom". Rows above the header row
+// are skipped.  Transforms lists the transforms applied to a field's value,
+// in order, see TransformValue.  StatusCodes maps a source status (lower
+// case) to a canonical status, an exact match is tried first and then the
+// longest code contained in the value. Defaults supplies the value of a
+// canonical field that is blank, a default Status lets a source without a
+// status column be imported.
+//
+// Paged reports repeat their header row, with RepeatedHeaders every header
+// row indexes the columns of the rows below it again. HeaderOffsets is for
+// values that sit to the right of their header. EndAtBlankRow ends the data
+// at the first blank row below the header. Continuation rows carry more text
+// for the data row above them and Formats build a field from other fields,
+// "{Field}" is replaced by the value of Field. CustomAttributes are attached
+// to the rentable type of each row and Lookup describes a second file with
+// more about each row, see LookupMapping.
+//
+// RentableTypeCSV, PeopleCSV, RentableCSV and RentalAgreementCSV map rcsv
+// columns to canonical fields for anything beyond what the importer fills in
+// itself (the specs, references, dates and defaults of each record).
+type Mapping struct {
+	Name               string                   // name of the source system, shown in the report
+	Columns            map[string][]string      // canonical field -> possible header names
+	Required           []string                 // canonical fields whose column must be present
+	Transforms         map[string][]string      // canonical field -> transforms
+	DateFormats        []string                 // go layouts tried, in order, by the date transform
+	NameFormat         string                   // "last, first" (default) or "first last"
+	StatusCodes        map[string]string        // source status -> canonical status
+	Defaults           map[string]string        // canonical field -> value used when blank
+	SkipPrefixes       []string                 // rows whose unit starts with one of these are not data
+	RepeatedHeaders    bool                     // header rows repeat below the first one
+	HeaderOffsets      map[string]int           // canonical field -> columns right of its header holding the value
+	EndAtBlankRow      bool                     // the data ends at the first blank row
+	Continuation       *ContinuationRule        // rows that continue the data row above them
+	Formats            map[string]string        // canonical field -> template of "{Field}" references
+	CustomAttributes   []CustomAttributeMapping // custom attributes of the rentable types
+	Lookup             *LookupMapping           // a second file with more data about the rows
+	RentableTypeCSV    map[string]string        // rcsv column -> canonical field
+	PeopleCSV          map[string]string        // rcsv column -> canonical field
+	RentableCSV        map[string]string        // rcsv column -> canonical field
+	RentalAgreementCSV map[string]string        // rcsv column -> canonical field
+}
+
+// ContinuationRule describes the rows that continue the data row above them,
+// such as the notes a report prints below a guest. A row that is not a data
+// row and has text in Column adds the text to Field of the data row above it.
+type ContinuationRule struct {
+	Column int    // column index of the text
+	Field  string // canonical field the text is added to
+}
+
+// CustomAttributeMapping attaches the value of Field to the rentable type of
+// each row as the custom attribute Name
+type CustomAttributeMapping struct {
+	Field     string // canonical field holding the value
+	Name      string // custom attribute name
+	ValueType string // rlib custom attribute value type, "1" is an integer
+	Units     string // units of the value
+}
+
+// LookupMapping describes a second csv file with more data about the rows of
+// the first, such as a guest list exported next to a room report. Its rows
+// are matched on Key, a canonical field of both files, and fill in the blank
+// fields of the row they match. A key that several rows share does not tell
+// them apart, such rows get nothing from the lookup file.
+type LookupMapping struct {
+	Name    string              // name of the file, shown in the report
+	Key     string              // canonical field the rows are matched on
+	Columns map[string][]string // canonical field -> possible header names
+}
+
+// LoadMapping reads the mapping in the json file fname and validates it
+func LoadMapping(fname string) (*Mapping, error) {
+	var m Mapping
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%s: %s", fname, err.Error())
+	}
+	if err = m.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %s", fname, err.Error())
+	}
+	return &m, nil
+}
+
+// Validate checks that the mapping can be used to import a csv file
+func (m *Mapping) Validate() error {
+	if len(m.Name) == 0 {
+		return fmt.Errorf("mapping has no Name")
+	}
+	for _, f := range m.requiredFields() {
+		if len(m.Columns[f]) == 0 {
+			return fmt.Errorf("mapping has no columns for required field %s", f)
+		}
+	}
+	if st, ok := m.Defaults[FieldStatus]; ok {
+		if _, ok = MappedStatusWriteCSV[st]; !ok {
+			return fmt.Errorf("default status %q is unknown", st)
+		}
+	}
+	for f := range m.HeaderOffsets {
+		if len(m.Columns[f]) == 0 {
+			return fmt.Errorf("header offset for field %s, which has no columns", f)
+		}
+	}
+	if m.Continuation != nil && (m.Continuation.Column < 0 || len(m.Continuation.Field) == 0) {
+		return fmt.Errorf("Continuation needs a Column and a Field")
+	}
+	for _, ca := range m.CustomAttributes {
+		if len(ca.Field) == 0 || len(ca.Name) == 0 {
+			return fmt.Errorf("custom attribute needs a Field and a Name")
+		}
+		if _, err := strconv.ParseInt(ca.ValueType, 10, 64); err != nil {
+			return fmt.Errorf("custom attribute %s: ValueType %q is not a number", ca.Name, ca.ValueType)
+		}
+	}
+	if m.Lookup != nil && (len(m.Lookup.Key) == 0 || len(m.Lookup.Columns[m.Lookup.Key]) == 0) {
+		return fmt.Errorf("Lookup has no columns for its Key")
+	}
+	for f, ta := range m.Transforms {
+		for _, t := range ta {
+			if _, ok := valueTransforms[t]; !ok && t != "date" {
+				return fmt.Errorf("unknown transform %q for field %s", t, f)
+			}
+		}
+	}
+	for code, st := range m.StatusCodes {
+		if _, ok := MappedStatusWriteCSV[st]; !ok {
+			return fmt.Errorf("status code %q maps to unknown status %q", code, st)
+		}
+	}
+	switch m.NameFormat {
+	case "", "last, first", "first last":
+	default:
+		return fmt.Errorf("unknown NameFormat %q", m.NameFormat)
+	}
+	return nil
+}
+
+// requiredFields returns the canonical fields whose column must be present.
+// Status is not required if the mapping has a default for it.
+func (m *Mapping) requiredFields() []string {
+	a := []string{FieldUnit, FieldStyle}
+	if _, ok := m.Defaults[FieldStatus]; !ok {
+		a = append(a, FieldStatus)
+	}
+	for _, f := range m.Required {
+		if !StringInSlice(f, a) {
+			a = append(a, f)
+		}
+	}
+	return a
+}
+
+// normalizeHeader reduces a column header or header alias to the form they
+// are compared in
+func normalizeHeader(s string) string {
+	return strings.ToLower(SpecialCharsReplacer.Replace(s))
+}
+
+// HeaderIndex looks for the columns of the mapping in row.
+//
+// RETURNS
+//
+//	canonical field -> column index for every field found in row, moved by
+//	HeaderOffsets
+//	the required fields (Unit, Style, Status and m.Required) not found
+//
+// -----------------------------------------------------------------------------
+func (m *Mapping) HeaderIndex(row []string) (map[string]int, []string) {
+	idx := columnIndex(m.Columns, row)
+	for f, n := range m.HeaderOffsets {
+		if j, ok := idx[f]; ok {
+			idx[f] = j + n
+		}
+	}
+	var missing []string
+	for _, f := range m.requiredFields() {
+		if _, ok := idx[f]; !ok {
+			missing = append(missing, f)
+		}
+	}
+	return idx, missing
+}
+
+// columnIndex returns the column index in row of every canonical field of
+// columns whose header it finds
+func columnIndex(columns map[string][]string, row []string) map[string]int {
+	idx := map[string]int{}
+	for j := 0; j < len(row); j++ {
+		h := normalizeHeader(row[j])
+		if len(h) == 0 {
+			continue
+		}
+		for f, aliases := range columns {
+			if _, ok := idx[f]; ok {
+				continue
+			}
+			for _, a := range aliases {
+				if normalizeHeader(a) == h {
+					idx[f] = j
+					break
+				}
+			}
+		}
+	}
+	return idx
+}
+
+// valueTransforms are the transforms a mapping can apply to a value, other
+// than "date" which depends on the mapping's DateFormats
+var valueTransforms = map[string]func(string) string{
+	"trim":   strings.TrimSpace,
+	"upper":  strings.ToUpper,
+	"lower":  strings.ToLower,
+	"money":  moneyTransform,
+	"digits": digitsTransform,
+	"email":  emailTransform,
+}
+
+// moneyTransform removes currency symbols and thousands separators, an
+// amount in parentheses is negative: "($1,250.00)" -> "-1250.00"
+func moneyTransform(s string) string {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")")
+	s = strings.NewReplacer("$", "", ",", "", "(", "", ")", "", " ", "").Replace(s)
+	if neg && len(s) > 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// digitsTransform keeps only the digits of s
+func digitsTransform(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			b = append(b, s[i])
+		}
+	}
+	return string(b)
+}
+
+// emailTransform blanks a value that is not an email address, exports often
+// hold notes such as "get at check in" in their email column
+func emailTransform(s string) string {
+	if !IsValidEmail(s) {
+		return ""
+	}
+	return s
+}
+
+// TransformValue applies the transforms of field to s. The date transform
+// parses s with each of the mapping's DateFormats in turn and writes the date
+// in MappedDateFmt.
+//
+// RETURNS
+//
+//	the transformed value
+//	an error if the value is not a date that any of the DateFormats parse
+//
+// -----------------------------------------------------------------------------
+func (m *Mapping) TransformValue(field, s string) (string, error) {
+	for _, t := range m.Transforms[field] {
+		if t != "date" {
+			s = valueTransforms[t](s)
+			continue
+		}
+		s = strings.TrimSpace(s)
+		if len(s) == 0 {
+			continue
+		}
+		var dt time.Time
+		var err error
+		for _, f := range m.DateFormats {
+			if dt, err = time.Parse(f, s); err == nil {
+				break
+			}
+		}
+		if err != nil || len(m.DateFormats) == 0 {
+			return s, fmt.Errorf("%s: %q is not a recognized date", field, s)
+		}
+		s = dt.Format(MappedDateFmt)
+	}
+	return s, nil
+}
+
+// MapStatus returns the canonical status for the source status s, or "" if
+// it cannot be mapped
+func (m *Mapping) MapStatus(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) == 0 {
+		return ""
+	}
+	if st, ok := m.StatusCodes[s]; ok {
+		return st
+	}
+	if _, ok := MappedStatusWriteCSV[s]; ok {
+		return s // already canonical, such as a default status
+	}
+	// longest codes first so that "vacant-rented" wins over "vacant"
+	var codes []string
+	for code := range m.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if len(codes[i]) != len(codes[j]) {
+			return len(codes[i]) > len(codes[j])
+		}
+		return codes[i] < codes[j]
+	})
+	for _, code := range codes {
+		if strings.Contains(s, code) {
+			return m.StatusCodes[code]
+		}
+	}
+	return ""
+}
+
+// SplitName splits a resident name into first and last name according to
+// the mapping's NameFormat. When a name lists several residents only the
+// first one is used.
+func (m *Mapping) SplitName(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if m.NameFormat == "first last" {
+		if i := strings.IndexAny(s, ",;"); i >= 0 {
+			s = strings.TrimSpace(s[:i])
+		}
+		sa := strings.Fields(s)
+		if len(sa) == 0 {
+			return "", ""
+		}
+		return strings.Join(sa[:len(sa)-1], " "), sa[len(sa)-1]
+	}
+	if i := strings.Index(s, ";"); i >= 0 {
+		s = s[:i]
+	}
+	sa := strings.SplitN(s, ",", 2)
+	if len(sa) < 2 {
+		return "", strings.TrimSpace(sa[0])
+	}
+	return strings.TrimSpace(sa[1]), strings.TrimSpace(sa[0])
+}
+
+// MappedRow is one data row of the csv file, keyed by canonical field
+type MappedRow map[string]string
+
+// MapRow builds the MappedRow for the csv row using the header index idx.
+// Transforms and Defaults are applied and a combined Name is split into
+// FirstName and LastName unless the source has separate columns for them.
+//
+// RETURNS
+//
+//	the mapped row
+//	the value errors found, the row is still usable
+//
+// -----------------------------------------------------------------------------
+func (m *Mapping) MapRow(idx map[string]int, row []string) (MappedRow, []error) {
+	var errs []error
+	r := MappedRow{}
+	for f, j := range idx {
+		if j >= len(row) {
+			continue
+		}
+		v, err := m.TransformValue(f, strings.TrimSpace(row[j]))
+		if err != nil {
+			errs = append(errs, err)
+			v = ""
+		}
+		r[f] = v
+	}
+	for f, v := range m.Defaults {
+		if len(r[f]) == 0 {
+			r[f] = v
+		}
+	}
+	if len(r[FieldName]) > 0 && len(r[FieldFirstName]) == 0 && len(r[FieldLastName]) == 0 {
+		r[FieldFirstName], r[FieldLastName] = m.SplitName(r[FieldName])
+	}
+	return r, errs
+}
+
+// FormatRow builds the fields that have a template in Formats from the other
+// fields of r
+func (m *Mapping) FormatRow(r MappedRow) {
+	for f, tmpl := range m.Formats {
+		var sa []string
+		for k, v := range r {
+			sa = append(sa, "{"+k+"}", v)
+		}
+		s := strings.NewReplacer(sa...).Replace(tmpl)
+		// fields the row has no value for are blank
+		for {
+			i := strings.Index(s, "{")
+			j := strings.Index(s, "}")
+			if i < 0 || j < i {
+				break
+			}
+			s = s[:i] + s[j+1:]
+		}
+		r[f] = strings.Join(strings.Fields(s), " ")
+	}
+}
+
+// IsDataRow reports whether a mapped row holds a unit. Blank rows, property
+// group headings and totals (SkipPrefixes) are not data.
+func (m *Mapping) IsDataRow(r MappedRow) bool {
+	u := strings.ToLower(r[FieldUnit])
+	if len(u) == 0 {
+		return false
+	}
+	for _, p := range m.SkipPrefixes {
+		if strings.HasPrefix(u, strings.ToLower(p)) {
+			return false
+		}
+	}
+	return true
+}
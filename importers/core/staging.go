@@ -0,0 +1,92 @@
+package core
+
+import (
+	"fmt"
+	"rentroll/rlib"
+)
+
+// heldBUDSuffix is appended to the designation of the business being
+// imported while its existing data is held aside during the import
+const heldBUDSuffix = "~import"
+
+// StagedImport makes an import all or nothing. The loaders insert records
+// one at a time through rcsv, so rather than deleting the existing data for
+// the business up front, the existing business is renamed and held aside
+// and the import is loaded into a new business with the original
+// designation. If the import succeeds the held business is deleted,
+// otherwise the new business is deleted and the held business gets its
+// designation back, leaving no trace of the import.
+type StagedImport struct {
+	Held     rlib.Business  // the business as it was before the import, renamed while it is held
+	Business *rlib.Business // the new business the data is loaded into
+}
+
+// HeldBUD returns the designation the existing business bud is given while
+// it is held aside during an import
+func HeldBUD(bud string) string {
+	return bud + heldBUDSuffix
+}
+
+// BeginStagedImport holds the existing business aside and creates the new
+// business the import is loaded into. On return business refers to the new
+// business.
+//
+// INPUTS
+//  business - the business being imported
+//
+// RETURNS
+//  the staged import, which must be finished with Commit or Rollback
+//  any error encountered
+//-----------------------------------------------------------------------------
+func BeginStagedImport(business *rlib.Business) (*StagedImport, error) {
+	s := StagedImport{Held: *business, Business: business}
+	bud := business.Designation
+	held := HeldBUD(bud)
+
+	// if an earlier import never finished, the held business is the only
+	// copy of the original data. Leave it to a person to sort out.
+	if b := rlib.GetBusinessByDesignation(held); b.BID > 0 {
+		return nil, fmt.Errorf("business %s holds the data from an earlier import of %s that did not finish", held, bud)
+	}
+
+	s.Held.Designation = held
+	if err := rlib.UpdateBusiness(&s.Held); err != nil {
+		return nil, err
+	}
+	rlib.RRdb.BUDlist = rlib.BuildBusinessDesignationMap()
+
+	business.BID = 0
+	if _, err := rlib.InsertBusiness(business); err != nil {
+		s.restoreHeld(bud)
+		*business = s.Held
+		return nil, fmt.Errorf("could not create business %s for import: %s", bud, err.Error())
+	}
+	return &s, nil
+}
+
+// restoreHeld gives the held business its designation back
+func (s *StagedImport) restoreHeld(bud string) {
+	s.Held.Designation = bud
+	if err := rlib.UpdateBusiness(&s.Held); err != nil {
+		rlib.Ulog("StagedImport: could not restore business %s (BID = %d): %s\n", bud, s.Held.BID, err.Error())
+	}
+	rlib.RRdb.BUDlist = rlib.BuildBusinessDesignationMap()
+}
+
+// Commit keeps the imported data. The held business and all of its data are
+// deleted.
+func (s *StagedImport) Commit() {
+	rlib.DeleteBusinessFromDB(s.Held.BID)
+}
+
+// Rollback deletes the new business and everything that was loaded into it,
+// then restores the held business exactly as it was. On return the caller's
+// business refers to the original business again.
+func (s *StagedImport) Rollback() {
+	bud := s.Business.Designation
+	if s.Business.BID > 0 {
+		rlib.DeleteBusinessFromDB(s.Business.BID)
+	}
+	s.restoreHeld(bud)
+	*s.Business = s.Held
+}
@@ -0,0 +1,104 @@
+// +build sqlite
+
+package core
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+)
+
+// countGLAccounts returns the number of GL accounts of business bid
+func countGLAccounts(t *testing.T, bid int64) int {
+	var n int
+	if err := rlib.RRdb.Dbrr.QueryRow("SELECT COUNT(*) FROM GLAccount WHERE BID=?", bid).Scan(&n); err != nil {
+		t.Fatalf("cannot count GL accounts: %s", err.Error())
+	}
+	return n
+}
+
+// stageImport begins a staged import of business b and loads one GL
+// account into the new business
+func stageImport(t *testing.T, b *rrtest.Biz) (*StagedImport, *rlib.Business) {
+	business := rlib.GetBusinessByDesignation(b.BUD)
+	s, err := BeginStagedImport(&business)
+	if err != nil {
+		t.Fatalf("BeginStagedImport: %s", err.Error())
+	}
+	if business.BID == b.BID || business.Designation != b.BUD {
+		t.Fatalf("expect a new business %s, got BID %d designation %s", b.BUD, business.BID, business.Designation)
+	}
+	if x := rlib.GetBusinessByDesignation(HeldBUD(b.BUD)); x.BID != b.BID {
+		t.Errorf("expect business %d held as %s, got %d", b.BID, HeldBUD(b.BUD), x.BID)
+	}
+	l := rlib.GLAccount{BID: business.BID, GLNumber: "10001", Name: "Imported Bank Account", AcctType: "Cash", Status: 2, AllowPost: 1}
+	if _, err = rlib.InsertLedger(&l); err != nil {
+		t.Fatalf("InsertLedger: %s", err.Error())
+	}
+	return s, &business
+}
+
+func TestStagedImportRollback(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	n := countGLAccounts(t, b.BID)
+
+	s, business := stageImport(t, b)
+	newBID := business.BID
+	s.Rollback()
+
+	if business.BID != b.BID || business.Designation != b.BUD {
+		t.Errorf("after Rollback the caller's business must be the original %d %s, got %d %s", b.BID, b.BUD, business.BID, business.Designation)
+	}
+	x := rlib.GetBusinessByDesignation(b.BUD)
+	if x.BID != b.BID || x.Name != b.BUD+" Apartments" {
+		t.Errorf("expect the original business %d %q, got %d %q", b.BID, b.BUD+" Apartments", x.BID, x.Name)
+	}
+	if x = rlib.GetBusinessByDesignation(HeldBUD(b.BUD)); x.BID != 0 {
+		t.Errorf("business %s must be gone after Rollback, got BID %d", HeldBUD(b.BUD), x.BID)
+	}
+	if m := countGLAccounts(t, b.BID); m != n {
+		t.Errorf("the original business must keep its %d GL accounts, got %d", n, m)
+	}
+	if m := countGLAccounts(t, newBID); m != 0 {
+		t.Errorf("the imported GL accounts must be deleted, got %d", m)
+	}
+	if rlib.RRdb.BUDlist[b.BUD] != b.BID {
+		t.Errorf("BUDlist: expect %s -> %d, got %d", b.BUD, b.BID, rlib.RRdb.BUDlist[b.BUD])
+	}
+}
+
+func TestStagedImportCommit(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+
+	s, business := stageImport(t, b)
+	s.Commit()
+
+	if x := rlib.GetBusinessByDesignation(b.BUD); x.BID != business.BID {
+		t.Errorf("expect the imported business %d to be %s, got %d", business.BID, b.BUD, x.BID)
+	}
+	if x := rlib.GetBusinessByDesignation(HeldBUD(b.BUD)); x.BID != 0 {
+		t.Errorf("the held business must be deleted after Commit, got BID %d", x.BID)
+	}
+	if m := countGLAccounts(t, b.BID); m != 0 {
+		t.Errorf("the held business' GL accounts must be deleted, got %d", m)
+	}
+	if m := countGLAccounts(t, business.BID); m != 1 {
+		t.Errorf("expect the 1 imported GL account, got %d", m)
+	}
+}
+
+func TestStagedImportUnfinished(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	stageImport(t, b) // never finished
+
+	business := rlib.GetBusinessByDesignation(b.BUD)
+	if _, err := BeginStagedImport(&business); err == nil {
+		t.Errorf("BeginStagedImport must refuse while %s holds the data of an unfinished import", HeldBUD(b.BUD))
+	}
+	if x := rlib.GetBusinessByDesignation(HeldBUD(b.BUD)); x.BID != b.BID {
+		t.Errorf("the held business %d must be left alone, got %d", b.BID, x.BID)
+	}
+}
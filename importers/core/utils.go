@@ -1,8 +1,10 @@
 package core
 
 import (
+	"fmt"
 	"regexp"
 	"rentroll/rlib"
+	"strconv"
 )
 
 // StringInSlice used to check whether string a
@@ -61,3 +63,30 @@ func GetImportedCount(summaryCount map[int]map[string]int, BID int64) {
 		}
 	}
 }
+
+// ValidateUserSuppliedValues validates the rent cycle, proration and GSRPC
+// values supplied by the user and looks up the business with designation
+// userValues["BUD"]
+func ValidateUserSuppliedValues(userValues map[string]string) ([]error, *rlib.Business) {
+	var errorList []error
+	var accrualRateOptText = `| 0: one time only | 1: secondly | 2: minutely | 3: hourly | 4: daily | 5: weekly | 6: monthly | 7: quarterly | 8: yearly |`
+
+	business := rlib.GetBusinessByDesignation(userValues["BUD"])
+	if business.BID == 0 {
+		errorList = append(errorList,
+			fmt.Errorf("Supplied Business Unit Designation does not exists"))
+	}
+
+	for _, k := range []string{"RentCycle", "Proration", "GSRPC"} {
+		n, err := strconv.Atoi(userValues[k])
+		if err != nil || n < 0 || n > 8 {
+			name := k
+			if k == "RentCycle" {
+				name = "Frequency"
+			}
+			errorList = append(errorList,
+				fmt.Errorf("Please, choose %s value from this\n%s", name, accrualRateOptText))
+		}
+	}
+	return errorList, &business
+}
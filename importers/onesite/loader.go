@@ -222,21 +222,6 @@ func loadOneSiteCSV(
 		return traceUnitMap, csvErrors, internalErrFlag
 	}
 
-	// =================================
-	// DELETE DATA RELATED TO BUSINESS ID
-	// =================================
-	// detele business related data before starting to import in database
-	rlib.DeleteBusinessFromDB(business.BID)
-	bid, err := rlib.InsertBusiness(business)
-	if err != nil {
-		rlib.Ulog("INTERNAL ERROR <INSERT BUSINESS>: %s\n", err.Error())
-		return traceUnitMap, csvErrors, internalErrFlag
-	}
-	// set new BID as we have deleted and inserted it again
-	// TODO:  remove this step after sman's next push
-	// in InsertBusiness it will be set automatically
-	business.BID = bid
-
 	// ========================================================
 	// WRITE DATA FOR CUSTOM ATTRIBUTE, RENTABLE TYPE, PEOPLE CSV
 	// ========================================================
@@ -794,10 +779,16 @@ func loadOneSiteCSV(
 }
 
 // rollBackImportOperation func used to clear out the things
-// that created by program temporarily while loading onesite data
-//  and if any error occurs
-func rollBackImportOperation(timestamp string) {
-	clearSplittedTempCSVFiles(timestamp)
+// that created by program while loading onesite data
+// if any error occurs or if it is a dry run. Everything
+// imported is deleted and the existing business is restored.
+// Unless testmode is enabled the temporary csv files are
+// removed too.
+func rollBackImportOperation(stage *core.StagedImport, timestamp string, testMode int) {
+	stage.Rollback()
+	if testMode != 1 {
+		clearSplittedTempCSVFiles(timestamp)
+	}
 }
 
 // clearSplittedTempCSVFiles func used only to clear
@@ -811,13 +802,18 @@ func clearSplittedTempCSVFiles(timestamp string) {
 }
 
 // CSVHandler is main function to handle user uploaded
-// csv and extract information
+// csv and extract information. The data is loaded into a
+// new business while the existing one is held aside, and it
+// only replaces the existing one if the import succeeds. If
+// dryRunMode is 1 the imported data is always discarded, the
+// report shows what would be imported.
 func CSVHandler(
 	csvPath string,
 	testMode int,
 	userRRValues map[string]string,
 	business *rlib.Business,
 	debugMode int,
+	dryRunMode int,
 ) (string, bool, bool) {
 
 	// return report, internal error flag, done (csv loaded or not)
@@ -848,32 +844,43 @@ func CSVHandler(
 		core.DBRentalAgreement: {"imported": 0, "possible": 0, "issues": 0},
 	}
 
+	// ====== Hold the existing business aside =====
+	stage, err := core.BeginStagedImport(business)
+	if err != nil {
+		csvReport = "\n\n" + err.Error()
+		return csvReport, false, false
+	}
+
 	// ====== Call onesite loader =====
 	unitMap, csvErrs, internalErr := loadOneSiteCSV(
 		csvPath, testMode, userRRValues,
 		business, currentTime, currentTimeFormat,
 		summaryReportCount)
 
-	// if internal error then just return from here, nothing to do
+	// if internal error then undo everything and return from here
 	if internalErr {
+		rollBackImportOperation(stage, currentTimeFormat, testMode)
 		return csvReport, internalErr, csvLoaded
 	}
 
 	// check if there any errors from onesite loader
 	if len(csvErrs) > 0 {
 		csvReport, csvLoaded = errorReporting(business, csvErrs, unitMap, summaryReportCount, csvPath, debugMode, currentTime)
-
-		// if not testmode then only do rollback
-		if testMode != 1 {
-			rollBackImportOperation(currentTimeFormat)
-		}
-
-		return csvReport, internalErr, csvLoaded
+	} else {
+		// ===== 4. Generate Report =====
+		csvReport = successReport(business, summaryReportCount, csvPath, debugMode, currentTime)
 	}
 
-	// ===== 4. Generate Report =====
-	csvReport = successReport(business, summaryReportCount, csvPath, debugMode, currentTime)
+	// ===== 5. Keep or discard the imported data =====
+	if dryRunMode == 1 {
+		csvReport = core.DryRunReportNote + csvReport
+		rollBackImportOperation(stage, currentTimeFormat, testMode)
+	} else if !csvLoaded {
+		rollBackImportOperation(stage, currentTimeFormat, testMode)
+	} else {
+		stage.Commit()
+	}
 
-	// ===== 5. Return =====
+	// ===== 6. Return =====
 	return csvReport, internalErr, csvLoaded
 }
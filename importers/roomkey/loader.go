@@ -188,21 +188,6 @@ func loadRoomKeyCSV(
 		return csvErrors, internalErrFlag
 	}
 
-	// =================================
-	// DELETE DATA RELATED TO BUSINESS ID
-	// =================================
-	// detele business related data before starting to import in database
-	rlib.DeleteBusinessFromDB(business.BID)
-	bid, err := rlib.InsertBusiness(business)
-	if err != nil {
-		rlib.Ulog("INTERNAL ERROR <INSERT BUSINESS>: %s\n", err.Error())
-		return csvErrors, internalErrFlag
-	}
-	// set new BID as we have deleted and inserted it again
-	// TODO:  remove this step after sman's next push
-	// in InsertBusiness it will be set automatically
-	business.BID = bid
-
 	// ========================================================
 	// WRITE DATA FOR RENTABLE TYPE, PEOPLE CSV
 	// ========================================================
@@ -698,10 +683,16 @@ func loadGuestInfoCSV(
 }
 
 // rollBackImportOperation func used to clear out the things
-// that created by program temporarily while loading onesite data
-//  and if any error occurs
-func rollBackImportOperation(timestamp string) {
-	clearSplittedTempCSVFiles(timestamp)
+// that created by program while loading roomkey data
+// if any error occurs or if it is a dry run. Everything
+// imported is deleted and the existing business is restored.
+// Unless testmode is enabled the temporary csv files are
+// removed too.
+func rollBackImportOperation(stage *core.StagedImport, timestamp string, testMode int) {
+	stage.Rollback()
+	if testMode != 1 {
+		clearSplittedTempCSVFiles(timestamp)
+	}
 }
 
 // clearSplittedTempCSVFiles func used only to clear
@@ -715,7 +706,11 @@ func clearSplittedTempCSVFiles(timestamp string) {
 }
 
 // CSVHandler is main function to handle user uploaded
-// csv and extract information
+// csv and extract information. The data is loaded into a
+// new business while the existing one is held aside, and it
+// only replaces the existing one if the import succeeds. If
+// dryRunMode is 1 the imported data is always discarded, the
+// report shows what would be imported.
 func CSVHandler(
 	csvPath string,
 	GuestInfoCSV string,
@@ -723,6 +718,7 @@ func CSVHandler(
 	userRRValues map[string]string,
 	business *rlib.Business,
 	debugMode int,
+	dryRunMode int,
 ) (string, bool, bool) {
 
 	// init values
@@ -763,32 +759,43 @@ func CSVHandler(
 		}
 	}
 
+	// ---------------------- hold the existing business aside ----------------------------------------
+	stage, err := core.BeginStagedImport(business)
+	if err != nil {
+		csvReport = "\n\n" + err.Error()
+		return csvReport, false, false
+	}
+
 	// ---------------------- call roomkey loader ----------------------------------------
 	csvErrs, internalErr := loadRoomKeyCSV(csvPath, guestInfo, testMode, userRRValues,
 		business, currentTime, currentTimeFormat,
 		summaryReportCount)
 
-	// if internal error then just return from here, nothing to do
+	// if internal error then undo everything and return from here
 	if internalErr {
+		rollBackImportOperation(stage, currentTimeFormat, testMode)
 		return csvReport, internalErr, csvLoaded
 	}
 
-	// check if there any errors from onesite loader
+	// check if there any errors from roomkey loader
 	if len(csvErrs) > 0 {
 		csvReport, csvLoaded = errorReporting(business, csvErrs, summaryReportCount, csvPath, GuestInfoCSV, debugMode, currentTime)
-
-		// if not testmode then only do rollback
-		if testMode != 1 {
-			rollBackImportOperation(currentTimeFormat)
-		}
-
-		return csvReport, internalErr, csvLoaded
+	} else {
+		// ===== 4. Geneate Report =====
+		csvReport = successReport(business, summaryReportCount, csvPath, GuestInfoCSV, debugMode, currentTime)
 	}
 
-	// ===== 4. Geneate Report =====
-	csvReport = successReport(business, summaryReportCount, csvPath, GuestInfoCSV, debugMode, currentTime)
+	// ===== 5. Keep or discard the imported data =====
+	if dryRunMode == 1 {
+		csvReport = core.DryRunReportNote + csvReport
+		rollBackImportOperation(stage, currentTimeFormat, testMode)
+	} else if !csvLoaded {
+		rollBackImportOperation(stage, currentTimeFormat, testMode)
+	} else {
+		stage.Commit()
+	}
 
-	// ===== 5. Return =====
+	// ===== 6. Return =====
 	return csvReport, internalErr, csvLoaded
 
 }
@@ -27,31 +27,12 @@ func initRentRoll() {
 }
 
 func createStartupCtx() DispatchCtx {
-	var ctx DispatchCtx
-	var err error
-
-	ctx.DtStart, err = rlib.StringToDate(App.sStart)
+	ctx, err := newStartupCtx(App.Bud, App.sStart, App.sStop)
 	if err != nil {
-		os.Exit(1)
-	}
-	ctx.DtStop, err = rlib.StringToDate(App.sStop)
-	if err != nil {
-		fmt.Printf("Invalid start date:  %s\n", App.sStop)
+		fmt.Printf("%s\n", err.Error())
 		os.Exit(1)
 	}
 
-	des := strings.ToLower(strings.TrimSpace(App.Bud)) // this should be BUD
-	if len(des) == 0 {                                 // make sure it's not empty
-		fmt.Printf("No BUD specified. A BUD is required for batch mode operation\n")
-		os.Exit(1)
-	}
-	ctx.xbiz.P = rlib.GetBusinessByDesignation(des) // see if we can find the biz
-	if len(ctx.xbiz.P.Designation) == 0 {
-		rlib.Ulog("Business Unit with designation %s does not exist\n", des)
-		os.Exit(1)
-	}
-	rlib.GetXBusiness(ctx.xbiz.P.BID, &ctx.xbiz)
-
 	// App.Report is a string, of the format:
 	//   n[,s1[,s2[...]]]
 	// Example:
@@ -66,7 +47,45 @@ func createStartupCtx() DispatchCtx {
 	ctx.Args = App.Report
 	ctx.CSVLoadStr = strings.TrimSpace(App.CSVLoad)
 	// fmt.Printf("ctx.CSVLoadStr = %s\n", ctx.CSVLoadStr)
+	return ctx
+}
+
+// newStartupCtx returns a context for the business with designation bud over
+// the period start - stop
+//
+// INPUTS
+//  bud   - the business unit designator
+//  start - the period start, as a date string
+//  stop  - the period stop, as a date string
+//
+// RETURNS
+//  the context
+//  any error encountered
+//-----------------------------------------------------------------------------
+func newStartupCtx(bud, start, stop string) (DispatchCtx, error) {
+	var ctx DispatchCtx
+	var err error
+
+	ctx.DtStart, err = rlib.StringToDate(start)
+	if err != nil {
+		return ctx, fmt.Errorf("Invalid start date:  %s", start)
+	}
+	ctx.DtStop, err = rlib.StringToDate(stop)
+	if err != nil {
+		return ctx, fmt.Errorf("Invalid stop date:  %s", stop)
+	}
+
+	des := strings.ToLower(strings.TrimSpace(bud)) // this should be BUD
+	if len(des) == 0 {                             // make sure it's not empty
+		return ctx, fmt.Errorf("No BUD specified. A BUD is required for batch mode operation")
+	}
+	ctx.xbiz.P = rlib.GetBusinessByDesignation(des) // see if we can find the biz
+	if len(ctx.xbiz.P.Designation) == 0 {
+		rlib.Ulog("Business Unit with designation %s does not exist\n", des)
+		return ctx, fmt.Errorf("Business Unit with designation %s does not exist", des)
+	}
+	rlib.GetXBusiness(ctx.xbiz.P.BID, &ctx.xbiz)
 	ctx.Cmd = 1
 	ctx.OutputFormat = gotable.TABLEOUTTEXT
-	return ctx
+	return ctx, nil
 }
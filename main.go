@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path"
 	"phonebook/lib"
 	"rentroll/rcsv"
 	"rentroll/rlib"
@@ -18,6 +19,7 @@ import (
 	"tws"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/kardianos/osext"
 )
 
 // DispatchCtx is a type of struct needed for the Dispatch function. It defines
@@ -54,6 +56,10 @@ var App struct {
 	Bud          string   // BUD from the command line
 	CertFile     string   // public certificate
 	KeyFile      string   //private key file
+	Migrate      bool     // apply pending schema migrations, then exit
+	SQLite       string   // if set, the SQLite database file to use instead of MySQL
+	XLSXFile     string   // if set, write the -r report to this Excel workbook instead of printing it
+	Command      []string // subcommand and its options, from the end of the command line
 	//DBRR         string   // rentroll database
 	RootStaticDir string // root directory settings
 }
@@ -76,12 +82,15 @@ func readCommandLineArgs() {
 	pCert := flag.String("C", "localhost.crt", "Cert file")
 	pBud := flag.String("b", "", "Business Unit Identifier (BUD)")
 	verPtr := flag.Bool("v", false, "prints the version to stdout")
-	rptPtr := flag.String("r", "0", "report: 0 = generate Journal records, 1 = Journal, 2 = Rentable, 4=Rentroll, 5=AssessmentCheck, 6=LedgerBalance, 7=RentableCountByType, 8=Statement, 9=Invoice, 10=LedgerActivity, 11=RentableGSR, 12-RALedgerBalanceOnDate,LID,RAID,Date, 13-RAAcctActivity,LID,RAID, 14,Date=delinqRpt")
+	rptPtr := flag.String("r", "0", "report: 0 = generate Journal records, 1 = Journal, 2 = Rentable, 4=Rentroll, 5=AssessmentCheck, 6=LedgerBalance, 7=RentableCountByType, 8=Statement, 9=Invoice, 10=LedgerActivity, 11=RentableGSR, 12-RALedgerBalanceOnDate,LID,RAID,Date, 13-RAAcctActivity,LID,RAID, 14,Date=delinqRpt, 24=APAging, 25,Year=Vendor1099, 26,Rpt,BizList=Consolidated, 27,iif|csv[,reexport][,preview]=ExportJournal")
 	pLoad := flag.String("L", "", "CSV Load index,filename")
 	portPtr := flag.Int("p", 8270, "port on which RentRoll server listens")
 	bPtr := flag.Bool("A", false, "if specified run as a batch process, do not start http")
 	xPtr := flag.Bool("x", false, "if specified, inhibit vacancy checking")
+	migratePtr := flag.Bool("migrate", false, "apply pending database schema migrations, then exit")
 	noconPtr := flag.Bool("nocon", false, "if specified, inhibit Console output")
+	sqlitePtr := flag.String("sqlite", "", "use this SQLite database file instead of MySQL")
+	xlsxPtr := flag.String("xlsx", "", "write the -r report to this Excel (.xlsx) file")
 	rsd := flag.String("rsd", "./", "Root Static Directory path") // it will pick static content from provided path, default will be current directory
 
 	flag.Parse()
@@ -107,9 +116,16 @@ func readCommandLineArgs() {
 	App.SkipVacCheck = *xPtr
 	App.CertFile = *pCert
 	App.KeyFile = *pKey
+	App.Migrate = *migratePtr
+	App.SQLite = *sqlitePtr
+	App.XLSXFile = *xlsxPtr
 	// fmt.Printf("*pLoad = %s\n", *pLoad)
 	App.CSVLoad = *pLoad
 	App.RootStaticDir = *rsd
+	App.Command = flag.Args()
+	if len(App.Command) > 0 {
+		App.BatchMode = true // a subcommand is always run as a batch process
+	}
 }
 
 func intTest(xbiz *rlib.XBusiness, d1, d2 *time.Time) {
@@ -131,18 +147,71 @@ func HomeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// openSQLite opens the SQLite database file App.SQLite as both the RentRoll
+// and the phonebook database. A new file gets the tables in schema.sql, which
+// is packaged in the same directory as rentroll.
+func openSQLite() {
+	folder, err := osext.ExecutableFolder()
+	if err != nil {
+		fmt.Printf("Cannot find the executable folder: %s\n", err.Error())
+		os.Exit(1)
+	}
+	App.dbrr, err = rlib.OpenSQLiteDB(App.SQLite, path.Join(folder, "schema.sql"))
+	if err != nil {
+		fmt.Printf("SQLite database %s: Error = %v\n", App.SQLite, err)
+		os.Exit(1)
+	}
+	App.dbdir = App.dbrr
+}
+
+// doMigrate applies the schema migrations the RentRoll database does not
+// have yet
+func doMigrate() {
+	v, err := rlib.GetSchemaVersion(App.dbrr)
+	if err != nil {
+		fmt.Printf("%s\n", err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("Database schema version: %d\n", v)
+	n, err := rlib.MigrateSchema(App.dbrr, func(m *rlib.Migration) {
+		fmt.Printf("Applying migration %d: %s\n", m.Version, m.Name)
+		rlib.Ulog("Applying schema migration %d: %s\n", m.Version, m.Name)
+	})
+	if err != nil {
+		fmt.Printf("%s\n", err.Error())
+		rlib.Ulog("%s\n", err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("%d migrations applied, schema version is now %d\n", n, rlib.SchemaVersionLatest())
+}
+
 func initHTTP() {
 	rlib.Ulog("Rentroll static file directory = %s\n", App.RootStaticDir)
 	Chttp.Handle("/", http.FileServer(http.Dir(App.RootStaticDir)))
 	http.HandleFunc("/", HomeHandler)
 	http.HandleFunc("/home/", HomeUIHandler)
 	http.HandleFunc("/v1/", ws.V1ServiceHandler)
+	http.HandleFunc("/v2/", ws.V2ServiceHandler)
 	http.HandleFunc("/wsvc/", webServiceHandler)
 }
 
 func main() {
 	var err error
+	var cmd *cliCtx
 	readCommandLineArgs()
+	if len(App.Command) > 0 {
+		if App.Command[0] == "help" {
+			cliHelp(os.Stdout, App.Command[1:])
+			os.Exit(0)
+		}
+		if cmd, err = cliParse(App.Command); err != nil {
+			fmt.Fprintf(os.Stderr, "rentroll %s\n", err.Error())
+			if c := cliLookup(App.Command[0]); c != nil {
+				cliCommandUsage(os.Stderr, c)
+			}
+			os.Exit(2)
+		}
+	}
 	// fmt.Printf("App.CSVLoad = %s\n", App.CSVLoad)
 	//==============================================
 	// Open the logfile and begin logging...
@@ -153,53 +222,88 @@ func main() {
 	log.SetOutput(App.LogFile)
 	rlib.Ulog("*** Accord RENTROLL ***\n")
 
-	//----------------------------
-	// Open RentRoll database
-	//----------------------------
-	if err = rlib.RRReadConfig(); err != nil {
-		fmt.Printf("sql.Open for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
-		os.Exit(1)
-	}
+	if len(App.SQLite) > 0 {
+		rlib.RRReadConfig() // optional, there is no database server to configure
+		openSQLite()
+		defer App.dbrr.Close()
+	} else {
+		//----------------------------
+		// Open RentRoll database
+		//----------------------------
+		if err = rlib.RRReadConfig(); err != nil {
+			fmt.Printf("sql.Open for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
+			os.Exit(1)
+		}
 
-	s := extres.GetSQLOpenString(rlib.AppConfig.RRDbname, &rlib.AppConfig)
-	App.dbrr, err = sql.Open("mysql", s)
-	if nil != err {
-		fmt.Printf("sql.Open for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
-		os.Exit(1)
-	}
-	defer App.dbrr.Close()
-	err = App.dbrr.Ping()
-	if nil != err {
-		fmt.Printf("DBRR.Ping for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
-		os.Exit(1)
+		s := extres.GetSQLOpenString(rlib.AppConfig.RRDbname, &rlib.AppConfig)
+		App.dbrr, err = sql.Open("mysql", s)
+		if nil != err {
+			fmt.Printf("sql.Open for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
+			os.Exit(1)
+		}
+		defer App.dbrr.Close()
+		err = App.dbrr.Ping()
+		if nil != err {
+			fmt.Printf("DBRR.Ping for database=%s, dbuser=%s: Error = %v\n", rlib.AppConfig.RRDbname, rlib.AppConfig.RRDbuser, err)
+			os.Exit(1)
+		}
+
+		//----------------------------
+		// Open Phonebook database, unless employees are in the local directory
+		//----------------------------
+		var d rlib.Directory
+		if d, err = rlib.NewDirectory(rlib.RRConfig.Directory); err != nil {
+			fmt.Printf("Directory configuration: Error = %v\n", err)
+			rlib.Ulog("Directory configuration: Error = %v\n", err)
+			os.Exit(1)
+		}
+		if d.Name() == rlib.DirectoryPhonebook {
+			s = extres.GetSQLOpenString(rlib.AppConfig.Dbname, &rlib.AppConfig)
+			App.dbdir, err = sql.Open("mysql", s)
+			if nil != err {
+				fmt.Printf("sql.Open: Error = %v\n", err)
+				os.Exit(1)
+			}
+			err = App.dbdir.Ping()
+			if nil != err {
+				fmt.Printf("dbdir.Ping: Error = %v\n", err)
+				os.Exit(1)
+			}
+		}
 	}
 
 	//----------------------------
-	// Open Phonebook database
+	// Check the schema version
 	//----------------------------
-	s = extres.GetSQLOpenString(rlib.AppConfig.Dbname, &rlib.AppConfig)
-	App.dbdir, err = sql.Open("mysql", s)
-	if nil != err {
-		fmt.Printf("sql.Open: Error = %v\n", err)
-		os.Exit(1)
+	if App.Migrate {
+		doMigrate()
+		os.Exit(0)
 	}
-	err = App.dbdir.Ping()
-	if nil != err {
-		fmt.Printf("dbdir.Ping: Error = %v\n", err)
+	if err = rlib.CheckSchemaVersion(App.dbrr); err != nil {
+		fmt.Printf("%s\n", err.Error())
+		rlib.Ulog("%s\n", err.Error())
 		os.Exit(1)
 	}
 
 	rlib.InitDBHelpers(App.dbrr, App.dbdir)
 	initRentRoll()
 
-	if App.BatchMode {
+	if cmd != nil {
+		if rc := cmd.run(); rc != 0 {
+			os.Exit(rc)
+		}
+	} else if App.BatchMode {
 		ctx := createStartupCtx()
 		rcsv.InitRCSV(&ctx.DtStart, &ctx.DtStop, &ctx.xbiz)
 		RunCommandLine(&ctx)
 	} else {
-		tws.Init(rlib.RRdb.Dbrr, rlib.RRdb.Dbdir) // starts the scheduler in a go routine. only initialize when we're in server mode
-		worker.Init()                             // register Rentroll's TWS workers
-		initHTTP()                                // identify the handlers
+		dbdir := rlib.RRdb.Dbdir
+		if dbdir == nil {
+			dbdir = rlib.RRdb.Dbrr // no phonebook, employees are in the local directory
+		}
+		tws.Init(rlib.RRdb.Dbrr, dbdir) // starts the scheduler in a go routine. only initialize when we're in server mode
+		worker.Init()                   // register Rentroll's TWS workers
+		initHTTP()                      // identify the handlers
 		rlib.Ulog("RentRoll initiating HTTP service on port %d and HTTPS on port %d\n", App.PortRR, App.PortRR+1)
 
 		go http.ListenAndServeTLS(fmt.Sprintf(":%d", App.PortRR+1), App.CertFile, App.KeyFile, nil)
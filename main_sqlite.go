@@ -0,0 +1,7 @@
+// +build sqlite
+
+package main
+
+// The SQLite driver needs cgo, so it is only linked in when rentroll is
+// built with the sqlite tag:  go build -tags sqlite
+import _ "github.com/mattn/go-sqlite3"
@@ -204,6 +204,10 @@ func CreateAR(sa []string, lineno int) (int, error) {
 	//----------------------------------------------------------------
 	b.Description = sa[Description]
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	_, err = rlib.InsertAR(&b)
 	if err != nil {
 		return CsvErrorSensitivity, fmt.Errorf("%s: error inserting AR = %v", funcname, err)
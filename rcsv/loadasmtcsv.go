@@ -242,13 +242,19 @@ func CreateAssessmentsFromCSV(sa []string, lineno int) (int, error) {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - this is a duplicate of an existing assessment: %s", funcname, lineno, adup.IDtoString())
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	_, err = rlib.InsertAssessment(&a)
 	if err != nil {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - error inserting assessment: %v", funcname, lineno, err)
 	}
 
-	// process this new assessment over the requested time range...
-	rlib.ProcessJournalEntry(&a, Rcsv.Xbiz, &Rcsv.DtStart, &Rcsv.DtStop, false)
+	// journal this new assessment over the requested time range and post it...
+	if err = rlib.ProcessJournalEntry(&a, Rcsv.Xbiz, &Rcsv.DtStart, &Rcsv.DtStop, true); err != nil {
+		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - error journaling assessment: %s", funcname, lineno, err.Error())
+	}
 
 	return 0, nil
 }
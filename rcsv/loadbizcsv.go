@@ -115,6 +115,10 @@ func CreatePhonebookLinkedBusiness(sa []string, lineno int) (int, error) {
 		b.Designation = des
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	// fmt.Printf("Business to save:  %#v\n", b)
 	_, err = rlib.InsertBusiness(&b)
 	if err != nil {
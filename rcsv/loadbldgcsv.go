@@ -79,6 +79,10 @@ func CreateBuilding(sa []string, lineno int) (int, error) {
 	b.PostalCode = strings.TrimSpace(sa[6])
 	b.Country = strings.TrimSpace(sa[7])
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	//-------------------------------------------------------------------
 	// OK, just insert the record and we're done
 	//-------------------------------------------------------------------
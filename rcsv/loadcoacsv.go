@@ -195,6 +195,10 @@ func CreateLedgerMarkers(sa []string, lineno int) (int, error) {
 	// rlib.Console("LOADCSV - SAVE:  Inserting = %v\n", inserting)
 	// rlib.Console("                 l = %#v\n", l)
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	// Insert / Update the rlib.GLAccount first, we may need the LID
 	if inserting {
 		var lid int64
@@ -87,6 +87,10 @@ func CreateCustomAttributes(sa []string, lineno int) (int, error) {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - %s:: skipping this because a custom attribute with Type = %d, Name = %s, Value = %s, Units = %s already exists", funcname, lineno, DupCustomAttribute, c.Type, c.Name, c.Value, c.Units)
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	_, err = rlib.InsertCustomAttribute(&c)
 	if err != nil {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - Could not insert CustomAttribute. err = %v", funcname, lineno, err)
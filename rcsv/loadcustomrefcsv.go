@@ -81,6 +81,10 @@ func CreateCustomAttributeRefs(sa []string, lineno int) (int, error) {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - This reference already exists, no changes made", funcname, lineno)
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	err = rlib.InsertCustomAttributeRef(&c)
 	if err != nil {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - Could not insert CustomAttributeRef. err = %v", funcname, lineno, err)
@@ -130,6 +130,10 @@ func CreateDepositsFromCSV(sa []string, lineno int) (int, error) {
 	// 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d -  error inserting deposit part: %v", funcname, lineno, err)
 	// 	}
 	// }
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	errlist := bizlogic.SaveDeposit(&d, rcpts)
 	if len(errlist) > 0 {
 		srr := ""
@@ -97,6 +97,10 @@ func CreateDepositoriesFromCSV(sa []string, lineno int) (int, error) {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d -  depository with account number %s already exists", funcname, lineno, d.AccountNo)
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	_, err = rlib.InsertDepository(&d)
 	if err != nil {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d -  error inserting depository: %v", funcname, lineno, err)
@@ -0,0 +1,64 @@
+package rcsv
+
+import (
+	"fmt"
+	"rentroll/rlib"
+	"strings"
+)
+
+// 0          1         2              3      4
+// FirstName, LastName, PreferredName, Email, Phone
+// Kelly,Jones,,kjones@example.com,123-456-7890
+
+// CreateDirectoryPersons reads a local directory string array and creates a database record
+// for the employee it describes
+func CreateDirectoryPersons(sa []string, lineno int) (int, error) {
+	funcname := "CreateDirectoryPersons"
+	var a rlib.DirectoryPerson
+	const (
+		FirstName     = 0
+		LastName      = iota
+		PreferredName = iota
+		Email         = iota
+		Phone         = iota
+	)
+
+	// csvCols is an array that defines all the columns that should be in this csv file
+	var csvCols = []CSVColumn{
+		{"FirstName", FirstName},
+		{"LastName", LastName},
+		{"PreferredName", PreferredName},
+		{"Email", Email},
+		{"Phone", Phone},
+	}
+
+	y, err := ValidateCSVColumnsErr(csvCols, sa, funcname, lineno)
+	if y {
+		return 1, err
+	}
+	if lineno == 1 {
+		return 0, nil // we've validated the col headings, all is good, send the next line
+	}
+
+	a.FirstName = strings.TrimSpace(sa[FirstName])
+	a.LastName = strings.TrimSpace(sa[LastName])
+	a.PreferredName = strings.TrimSpace(sa[PreferredName])
+	a.Email = strings.TrimSpace(sa[Email])
+	a.Phone = strings.TrimSpace(sa[Phone])
+	if len(a.FirstName) == 0 && len(a.LastName) == 0 {
+		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - a FirstName or LastName is required", funcname, lineno)
+	}
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
+	if _, err = rlib.InsertDirectoryPerson(&a); err != nil {
+		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - Error inserting DirectoryPerson.  err = %s", funcname, lineno, err.Error())
+	}
+	return 0, nil
+}
+
+// LoadDirectoryCSV loads a csv file with the employees of the local directory
+func LoadDirectoryCSV(fname string) []error {
+	return LoadRentRollCSV(fname, CreateDirectoryPersons)
+}
@@ -68,6 +68,10 @@ func CreateDepositMethod(sa []string, lineno int) (int, error) {
 	}
 
 	a.Method = name
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	rlib.InsertDepositMethod(&a)
 	return 0, nil
 }
@@ -3,6 +3,8 @@ package rcsv
 import (
 	"fmt"
 	"rentroll/rlib"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -36,6 +38,9 @@ const (
 	CSVDeposit                  = iota
 	CSVNoteTypes                = iota
 	CSVInvoices                 = iota
+	CSVVehicles                 = iota
+	CSVAccountRules             = iota
+	CSVDirectory                = iota
 )
 
 // CSVLoader is a struct to define a csv loading function
@@ -50,39 +55,46 @@ type CSVLoader struct {
 var CSVLoaders = []CSVLoader{
 	{Name: "Assessments", Index: CSVAssessments, Loader: LoadAssessmentsCSV},
 	{Name: "Receipts", Index: CSVReceipts, Loader: LoadReceiptsCSV},
-	// {Name: "Business", Index: CSVBusiness, Loader: LoadBusinessCSV},
-	// {Name: "StringTables", Index: CSVStringTables, Loader: LoadStringTablesCSV},
-	// {Name: "PaymentTypes", Index: CSVPaymentTypes, Loader: LoadPaymentTypesCSV},
-	// {Name: "DepositMethods", Index: CSVDepositMethods, Loader: LoadDepositMethodsCSV},
-	// {Name: "Sources", Index: CSVSources, Loader: LoadSourcesCSV},
-	// {Name: "RentableTypes", Index: CSVRentableTypes, Loader: LoadRentableTypesCSV},
-	// {Name: "CustomAttributes", Index: CSVCustomAttributes, Loader: LoadCustomAttributesCSV},
-	// {Name: "Depository", Index: CSVDepository, Loader: LoadDepositoryCSV},
-	// {Name: "RentalSpecialties", Index: CSVRentalSpecialties, Loader: LoadRentalSpecialtiesCSV},
-	// {Name: "Building", Index: CSVBuilding, Loader: LoadBuildingCSV},
-	// {Name: "People", Index: CSVPeople, Loader: LoadPeopleCSV},
-	// {Name: "Rentables", Index: CSVRentables, Loader: LoadRentablesCSV},
-	// {Name: "RentableSpecialtyRefs", Index: CSVRentableSpecialtyRefs, Loader: LoadRentableSpecialtyRefsCSV},
-	// {Name: "RentalAgreementTemplates", Index: CSVRentalAgreementTemplates, Loader: LoadRentalAgreementTemplatesCSV},
-	// {Name: "RentalAgreement", Index: CSVRentalAgreement, Loader: LoadRentalAgreementCSV},
-	// {Name: "Pets", Index: CSVPets, Loader: LoadPetsCSV},
-	// {Name: "ChartOfAccounts", Index: CSVChartOfAccounts, Loader: LoadChartOfAccountsCSV},
-	// {Name: "RatePlans", Index: CSVRatePlans, Loader: LoadRatePlansCSV},
-	// {Name: "RatePlanRefs", Index: CSVRatePlanRefs, Loader: LoadRatePlanRefsCSV},
-	// {Name: "RatePlanRefRTRates", Index: CSVRatePlanRefRTRates, Loader: LoadRatePlanRefRTRatesCSV},
-	// {Name: "RatePlanRefSPRates", Index: CSVRatePlanRefSPRates, Loader: LoadRatePlanRefSPRatesCSV},
-	// {Name: "Deposit", Index: CSVDeposit, Loader: LoadDepositCSV},
-	// {Name: "CustomAttributeRefs", Index: CSVCustomAttributeRefs, Loader: LoadCustomAttributeRefsCSV},
-	// {Name: "NoteTypes", Index: CSVNoteTypes, Loader: LoadNoteTypesCSV},
-	// {Name: "Invoices", Index: CSVInvoices, Loader: LoadInvoicesCSV},
-	// {Name: "Building", Index: CSVBuilding, Loader: LoadBuildingCSV},
+	{Name: "Business", Index: CSVBusiness, Loader: LoadBusinessCSV},
+	{Name: "StringTables", Index: CSVStringTables, Loader: LoadStringTablesCSV},
+	{Name: "PaymentTypes", Index: CSVPaymentTypes, Loader: LoadPaymentTypesCSV},
+	{Name: "DepositMethods", Index: CSVDepositMethods, Loader: LoadDepositMethodsCSV},
+	{Name: "Sources", Index: CSVSources, Loader: LoadSourcesCSV},
+	{Name: "RentableTypes", Index: CSVRentableTypes, Loader: LoadRentableTypesCSV},
+	{Name: "CustomAttributes", Index: CSVCustomAttributes, Loader: LoadCustomAttributesCSV},
+	{Name: "Depository", Index: CSVDepository, Loader: LoadDepositoryCSV},
+	{Name: "RentalSpecialties", Index: CSVRentalSpecialties, Loader: LoadRentalSpecialtiesCSV},
+	{Name: "Building", Index: CSVBuilding, Loader: LoadBuildingCSV},
+	{Name: "People", Index: CSVPeople, Loader: LoadPeopleCSV},
+	{Name: "Vehicles", Index: CSVVehicles, Loader: LoadVehicleCSV},
+	{Name: "Rentables", Index: CSVRentables, Loader: LoadRentablesCSV},
+	{Name: "RentableSpecialtyRefs", Index: CSVRentableSpecialtyRefs, Loader: LoadRentableSpecialtyRefsCSV},
+	{Name: "RentalAgreementTemplates", Index: CSVRentalAgreementTemplates, Loader: LoadRentalAgreementTemplatesCSV},
+	{Name: "RentalAgreement", Index: CSVRentalAgreement, Loader: LoadRentalAgreementCSV},
+	{Name: "Pets", Index: CSVPets, Loader: LoadPetsCSV},
+	{Name: "ChartOfAccounts", Index: CSVChartOfAccounts, Loader: LoadChartOfAccountsCSV},
+	{Name: "AccountRules", Index: CSVAccountRules, Loader: LoadARCSV},
+	{Name: "RatePlans", Index: CSVRatePlans, Loader: LoadRatePlansCSV},
+	{Name: "RatePlanRefs", Index: CSVRatePlanRefs, Loader: LoadRatePlanRefsCSV},
+	{Name: "RatePlanRefRTRates", Index: CSVRatePlanRefRTRates, Loader: LoadRatePlanRefRTRatesCSV},
+	{Name: "RatePlanRefSPRates", Index: CSVRatePlanRefSPRates, Loader: LoadRatePlanRefSPRatesCSV},
+	{Name: "Deposit", Index: CSVDeposit, Loader: LoadDepositCSV},
+	{Name: "CustomAttributeRefs", Index: CSVCustomAttributeRefs, Loader: LoadCustomAttributeRefsCSV},
+	{Name: "NoteTypes", Index: CSVNoteTypes, Loader: LoadNoteTypesCSV},
+	{Name: "Invoices", Index: CSVInvoices, Loader: LoadInvoicesCSV},
+	{Name: "Directory", Index: CSVDirectory, Loader: LoadDirectoryCSV},
 }
 
-// Rcsv contains the shared data used by the RCS loaders
+// Rcsv contains the shared data used by the RCS loaders. If ValidateOnly is
+// true the loaders check every line of the file, including its references to
+// records already in the database, but they do not write anything. Lines
+// that refer to records created by earlier lines of the same file will report
+// those records as missing.
 var Rcsv struct {
-	DtStart time.Time
-	DtStop  time.Time
-	Xbiz    *rlib.XBusiness
+	DtStart      time.Time
+	DtStop       time.Time
+	Xbiz         *rlib.XBusiness
+	ValidateOnly bool
 }
 
 // InitRCSV initializes the shared data used by they RCS loaders.
@@ -103,3 +115,44 @@ func DispatchCSV(index int, fname string) string {
 	}
 	return fmt.Sprintf("CSV Loader %d not found", index)
 }
+
+// GetCSVLoader returns the loader with the supplied name. Names are matched
+// without regard to case.
+func GetCSVLoader(name string) (CSVLoader, bool) {
+	for i := 0; i < len(CSVLoaders); i++ {
+		if strings.EqualFold(CSVLoaders[i].Name, name) {
+			return CSVLoaders[i], true
+		}
+	}
+	return CSVLoader{}, false
+}
+
+// CSVLoadError is one error reported by a csv loader, split into the line of
+// the csv file and the reason
+type CSVLoadError struct {
+	Line    int    // line number in the csv file, 0 if the error is not about a line
+	Message string // the reason
+}
+
+// CSVErrors converts the errors returned by a loader to CSVLoadErrors. The
+// loaders format their errors as "FunctionName: line N - reason", errors in
+// any other form are returned with Line = 0 and the whole error text as the
+// Message.
+func CSVErrors(m []error) []CSVLoadError {
+	var r []CSVLoadError
+	for i := 0; i < len(m); i++ {
+		e := CSVLoadError{Message: strings.TrimSpace(m[i].Error())}
+		sa := strings.SplitN(e.Message, ":", 2)
+		if len(sa) == 2 {
+			sb := strings.SplitN(strings.TrimSpace(sa[1]), "-", 2)
+			if len(sb) == 2 && strings.HasPrefix(sb[0], "line ") {
+				if n, err := strconv.Atoi(strings.TrimSpace(sb[0][5:])); err == nil {
+					e.Line = n
+					e.Message = strings.TrimSpace(sb[1])
+				}
+			}
+		}
+		r = append(r, e)
+	}
+	return r
+}
@@ -135,6 +135,10 @@ func CreateInvoicesFromCSV(sa []string, lineno int) (int, error) {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d -  error getting Rental Agreement %d: %v", funcname, lineno, RAID, err)
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	//-------------------------------------------------------------------
 	// We have all we need. Write the records.  First, the Invoice itself
 	//-------------------------------------------------------------------
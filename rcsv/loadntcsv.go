@@ -49,6 +49,10 @@ func CreateNoteTypes(sa []string, lineno int) (int, error) {
 	if len(nt.Name) == 0 {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - No Name found for the NoteType", funcname, lineno)
 	}
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	_, err = rlib.InsertNoteType(&nt)
 	if err != nil {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - Error inserting NoteType.  err = %s", funcname, lineno, err.Error())
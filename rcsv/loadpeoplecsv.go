@@ -433,6 +433,10 @@ func CreatePeopleFromCSV(sa []string, lineno int) (int, error) {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - CompanyName is required for a company", funcname, lineno)
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	//-------------------------------------------------------------------
 	// If there's a notelist, create it now...
 	//-------------------------------------------------------------------
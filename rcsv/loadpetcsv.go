@@ -104,6 +104,10 @@ func CreateRentalAgreementPetsFromCSV(sa []string, lineno int) (int, error) {
 	}
 	pet.DtStop = DtStop
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	_, err = rlib.InsertRentalAgreementPet(&pet)
 	if nil != err {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - Could not save pet, err = %v", funcname, lineno, err)
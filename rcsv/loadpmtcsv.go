@@ -59,6 +59,10 @@ func CreatePaymentTypeFromCSV(sa []string, lineno int) (int, error) {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - Skipping because payment type named %s already exists", funcname, lineno, pt.Name)
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	//-------------------------------------------------------------------
 	// OK, just insert the record and we're done
 	//-------------------------------------------------------------------
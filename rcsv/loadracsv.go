@@ -237,7 +237,7 @@ func CreateRentalAgreement(sa []string, lineno int) (int, error) {
 	// Notes
 	//-------------------------------------------------------------------
 	note := strings.TrimSpace(sa[Notes])
-	if len(note) > 0 {
+	if len(note) > 0 && !Rcsv.ValidateOnly {
 		var nl rlib.NoteList
 		nl.BID = ra.BID
 		nl.NLID, err = rlib.InsertNoteList(&nl)
@@ -277,6 +277,10 @@ func CreateRentalAgreement(sa []string, lineno int) (int, error) {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - No valid payors for this rental agreement", funcname, lineno)
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	//------------------------------------
 	// Write the rental agreement record
 	//-----------------------------------
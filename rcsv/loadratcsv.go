@@ -62,6 +62,10 @@ func CreateRentalAgreementTemplate(sa []string, lineno int) (int, error) {
 	}
 
 	a.RATemplateName = des
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	rlib.InsertRentalAgreementTemplate(&a)
 	return 0, nil
 }
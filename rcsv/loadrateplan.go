@@ -77,6 +77,10 @@ func CreateRatePlans(sa []string, lineno int) (int, error) {
 
 	//return CsvErrorSensitivity, fmt.Errorf("FLAGS = 0x%x", FLAGS)
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	rpid, err := rlib.InsertRatePlan(&rp)
 	if err != nil {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - Error inserting RatePlan.  err = %s", funcname, lineno, err.Error())
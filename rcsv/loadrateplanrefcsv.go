@@ -154,6 +154,10 @@ func CreateRatePlanRef(sa []string, lineno int) (int, error) {
 		}
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	//-------------------------------------------------------------------
 	// Insert the record
 	//-------------------------------------------------------------------
@@ -227,6 +227,10 @@ func CreateReceiptsFromCSV(sa []string, lineno int) (int, error) {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - this is a duplicate of an existing receipt: %s", funcname, lineno, rdup.IDtoString())
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	rcptid, err := rlib.InsertReceipt(&r)
 	if err != nil {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d -  error inserting receipt: %v", funcname, lineno, err)
@@ -275,9 +279,16 @@ func CreateReceiptsFromCSV(sa []string, lineno int) (int, error) {
 	}
 
 	//-------------------------------------------------------------------
-	// Process the receipt...
+	// Process the receipt and post it...
 	//-------------------------------------------------------------------
-	rlib.ProcessNewReceipt(Rcsv.Xbiz, &Rcsv.DtStart, &Rcsv.DtStop, &r)
+	j, err := rlib.ProcessNewReceipt(Rcsv.Xbiz, &Rcsv.DtStart, &Rcsv.DtStop, &r)
+	if err != nil {
+		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - error journaling receipt: %s", funcname, lineno, err.Error())
+	}
+	rlib.InitLedgerCache()
+	if _, err = rlib.GenerateLedgerEntriesFromJournal(Rcsv.Xbiz, &j, &Rcsv.DtStart, &Rcsv.DtStop); err != nil {
+		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - error posting receipt: %s", funcname, lineno, err.Error())
+	}
 
 	return 0, nil
 }
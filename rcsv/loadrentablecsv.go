@@ -220,6 +220,10 @@ func CreateRentables(sa []string, lineno int) (int, error) {
 		n = append(n, rt) // add this struct to the list
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	//-------------------------------------------------------------------
 	// OK, just insert the record and its sub-records and we're done
 	//-------------------------------------------------------------------
@@ -74,6 +74,10 @@ func CreateRentalSpecialty(sa []string, lineno int) (int, error) {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d  - rlib.Business %s already has a rlib.RentableSpecialty named %s", funcname, lineno, des, a.Name)
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	//-------------------------------------------------------------------
 	// OK, just insert the record and we're done
 	//-------------------------------------------------------------------
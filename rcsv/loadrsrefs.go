@@ -95,6 +95,10 @@ func CreateRentableSpecialtyRefsCSV(sa []string, lineno int) (int, error) {
 		return CsvErrorSensitivity, fmt.Errorf("%s", err.Error())
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	err = rlib.InsertRentableSpecialtyRef(&a)
 	if err != nil {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - error inserting assessment: %v", funcname, lineno, err)
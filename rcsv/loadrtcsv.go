@@ -123,6 +123,10 @@ func CreateRentableType(sa []string, lineno int) (int, error) {
 	}
 	a.ManageToBudget = int64(n64)
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	rtid, err := rlib.InsertRentableType(&a)
 	if err != nil {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - Error inserting Rentable Type: %s", funcname, lineno, err.Error())
@@ -111,6 +111,10 @@ func CreateRatePlanRefRTRate(sa []string, lineno int) (int, error) {
 		a.FLAGS |= rlib.FlRTRpct
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	//-------------------------------------------------------------------
 	// Insert the record
 	//-------------------------------------------------------------------
@@ -23,6 +23,11 @@ var (
 
 func writeStringList() error {
 	var err error
+	if Rcsv.ValidateOnly {
+		var b rlib.StringList
+		a = b // nothing is written, just start the next list
+		return err
+	}
 	if len(a.Name) > 0 {
 		var t rlib.StringList
 		rlib.GetStringListByName(a.BID, a.Name, &t) // do we already have a stringlist by this name?
@@ -135,6 +135,10 @@ func CreateRatePlanRefSPRate(sa []string, lineno int) (int, error) {
 			p.FLAGS |= rlib.FlSPRpct // mark it as a percentage
 		}
 
+		if Rcsv.ValidateOnly {
+			continue
+		}
+
 		//-------------------------------------------------------------------
 		// Insert the record
 		//-------------------------------------------------------------------
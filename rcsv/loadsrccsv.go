@@ -71,6 +71,10 @@ func CreateSourceCSV(sa []string, lineno int) (int, error) {
 	//-------------------------------------------------------------------
 	a.Industry = strings.TrimSpace(sa[Industy])
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	_, err = rlib.InsertDemandSource(&a)
 	if err != nil {
 		return CsvErrorSensitivity, fmt.Errorf("%s: line %d - error inserting DemandSource: %v", funcname, lineno, err)
@@ -141,6 +141,10 @@ func CreateVehicleFromCSV(sa []string, lineno int) (int, error) {
 		}
 	}
 
+	if Rcsv.ValidateOnly {
+		return 0, nil
+	}
+
 	//-------------------------------------------------------------------
 	// OK, just insert the records and we're done
 	//-------------------------------------------------------------------
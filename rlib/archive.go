@@ -0,0 +1,668 @@
+package rlib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A business archive holds everything that belongs to one business in a form
+// that can be loaded into another database.  It is a JSON lines file.  The
+// first line is an ArchiveHeader, every other line is an ArchiveRecord.  The
+// records are written in the order of archiveTables, so a record only refers
+// to records of its own table or of tables that come before it, with the few
+// exceptions handled by ImportBusiness.
+const (
+	ArchiveFormat  = "rentroll-archive" // value of ArchiveHeader.Format
+	ArchiveVersion = 1                  // current version of the archive format
+)
+
+// ArchiveHeader is the first line of a business archive
+type ArchiveHeader struct {
+	Format  string         // always ArchiveFormat
+	Version int            // ArchiveVersion of the program that wrote it
+	BID     int64          // BID of the business in the database it came from
+	BUD     string         // its designation
+	Created time.Time      // when the archive was written
+	Rows    map[string]int // number of records of each table
+}
+
+// ArchiveRecord is one row of one table. Column values are strings, numbers
+// or null.
+type ArchiveRecord struct {
+	Table string
+	Row   map[string]interface{}
+}
+
+// ArchiveImportResult describes a completed import
+type ArchiveImportResult struct {
+	BID        int64          // BID of the new business
+	BUD        string         // its designation
+	Rows       map[string]int // number of records loaded into each table
+	Unresolved int            // references to records that were not in the archive, they were set to 0
+}
+
+// archiveTable describes how the rows of a table are exported and how the
+// ids they contain are remapped when they are imported
+type archiveTable struct {
+	Name string            // table name
+	Key  string            // AUTO_INCREMENT primary key, "" if the table has none
+	Refs map[string]string // column -> table whose Key the column holds
+
+	// Dyn handles columns whose referenced table depends on another column of
+	// the row, such as Journal.ID. It returns the table, "" if the value is
+	// not an id and must be copied unchanged.
+	Dyn map[string]func(r map[string]interface{}) string
+
+	// Rules are the columns that hold account rules. The assessment ids in
+	// their ASM(n) terms are remapped like an Assessments reference.
+	Rules []string
+}
+
+// archiveASM matches the ASM(n) terms of an account rule
+var archiveASM = regexp.MustCompile(`ASM\(\s*(\d+)\s*\)`)
+
+// journalIDTable returns the table referenced by the ID column of a Journal row
+func journalIDTable(r map[string]interface{}) string {
+	switch archiveInt(r["Type"]) {
+	case JNLTYPEUNAS:
+		return "Rentable"
+	case JNLTYPEASMT:
+		return "Assessments"
+	case JNLTYPERCPT:
+		return "Receipt"
+	case JNLTYPEEXP:
+		return "Expense"
+	case JNLTYPEXFER: // the receipt whose funds were transferred
+		return "Receipt"
+	case JNLTYPEBILL:
+		return "Bill"
+	case JNLTYPEBPMT:
+		return "BillPayment"
+	}
+	return ""
+}
+
+// customAttrRefIDTable returns the table referenced by the ID column of a
+// CustomAttrRef row
+func customAttrRefIDTable(r map[string]interface{}) string {
+	switch archiveInt(r["ElementType"]) {
+	case ELEMRENTABLETYPE:
+		return "RentableTypes"
+	case ELEMRATEPLAN:
+		return "RatePlan"
+	case ELEMTRANSACTANT, ELEMUSER, ELEMPROSPECT, ELEMAPPLICANT, ELEMPAYOR:
+		return "Transactant"
+	case ELEMRENTABLE:
+		return "Rentable"
+	case ELEMRENTALAGREEMENT:
+		return "RentalAgreement"
+	}
+	return ""
+}
+
+// archiveTables lists the tables in a business archive, in the order they are
+// written and loaded. Business groups, MRHistory, the event outbox, and
+// dirty ranges are not part of an archive. Groups and outbox events belong to
+// the database rather than the business, MRHistory has no BID, and dirty
+// ranges only matter to the database they were recorded in. Webhooks and
+// report schedules are left out too: they hold the receiver URLs, secrets
+// and email recipients of the business, and a copy loaded on another server
+// would send it live events and reports.
+var archiveTables = []archiveTable{
+	{Name: "Business", Key: "BID"},
+	{Name: "StringList", Key: "SLID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "SLString", Key: "SLSID", Refs: map[string]string{"BID": "Business", "SLID": "StringList"}},
+	{Name: "NoteType", Key: "NTID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "NoteList", Key: "NLID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "CustomAttr", Key: "CID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "Tax", Key: "TAXID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "TaxRate", Refs: map[string]string{"BID": "Business", "TAXID": "Tax"}},
+	{Name: "PaymentType", Key: "PMTID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "BusinessPaymentTypes", Refs: map[string]string{"BID": "Business", "PMTID": "PaymentType"}},
+	{Name: "DepositMethod", Key: "DPMID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "AvailabilityTypes", Key: "AVAILID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "OtherDeliverables", Key: "ODID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "RentableTypes", Key: "RTID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "RentableMarketRate", Key: "RMRID", Refs: map[string]string{"BID": "Business", "RTID": "RentableTypes"}},
+	{Name: "RentableTypeTax", Refs: map[string]string{"BID": "Business", "RTID": "RentableTypes", "TAXID": "Tax"}},
+	{Name: "RentableSpecialty", Key: "RSPID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "Building", Key: "BLDGID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "Rentable", Key: "RID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "RentableStatus", Key: "RSID", Refs: map[string]string{"BID": "Business", "RID": "Rentable"}},
+	{Name: "RentableTypeRef", Key: "RTRID", Refs: map[string]string{"BID": "Business", "RID": "Rentable", "RTID": "RentableTypes"}},
+	{Name: "RentableSpecialtyRef", Refs: map[string]string{"BID": "Business", "RID": "Rentable", "RSPID": "RentableSpecialty"}},
+	{Name: "RatePlan", Key: "RPID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "RatePlanRef", Key: "RPRID", Refs: map[string]string{"BID": "Business", "RPID": "RatePlan"}},
+	{Name: "RatePlanRefRTRate", Refs: map[string]string{"BID": "Business", "RPRID": "RatePlanRef", "RTID": "RentableTypes"}},
+	{Name: "RatePlanRefSPRate", Refs: map[string]string{"BID": "Business", "RPRID": "RatePlanRef", "RTID": "RentableTypes", "RSPID": "RentableSpecialty"}},
+	{Name: "RatePlanOD", Refs: map[string]string{"BID": "Business", "RPRID": "RatePlanRef", "ODID": "OtherDeliverables"}},
+	{Name: "RentalAgreementTemplate", Key: "RATID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "RentalAgreement", Key: "RAID", Refs: map[string]string{"BID": "Business", "RATID": "RentalAgreementTemplate", "NLID": "NoteList"}},
+	{Name: "DemandSource", Key: "SourceSLSID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "LeadSource", Key: "LSID", Refs: map[string]string{"BID": "Business", "IndustrySLID": "StringList"}},
+	{Name: "Transactant", Key: "TCID", Refs: map[string]string{"BID": "Business", "NLID": "NoteList"}},
+	{Name: "Prospect", Refs: map[string]string{"BID": "Business", "TCID": "Transactant", "DeclineReasonSLSID": "SLString", "OutcomeSLSID": "SLString", "RAID": "RentalAgreement"}},
+	{Name: "User", Refs: map[string]string{"BID": "Business", "TCID": "Transactant", "SourceSLSID": "DemandSource"}},
+	{Name: "Payor", Refs: map[string]string{"BID": "Business", "TCID": "Transactant"}},
+	{Name: "Vehicle", Key: "VID", Refs: map[string]string{"BID": "Business", "TCID": "Transactant"}},
+	{Name: "CommissionLedger", Key: "CLID", Refs: map[string]string{"BID": "Business", "RAID": "RentalAgreement", "RID": "Rentable"}},
+	{Name: "RentalAgreementRentables", Key: "RARID", Refs: map[string]string{"BID": "Business", "RAID": "RentalAgreement", "RID": "Rentable", "CLID": "CommissionLedger"}},
+	{Name: "RentalAgreementPayors", Key: "RAPID", Refs: map[string]string{"BID": "Business", "RAID": "RentalAgreement", "TCID": "Transactant"}},
+	{Name: "RentableUsers", Key: "RUID", Refs: map[string]string{"BID": "Business", "RID": "Rentable", "TCID": "Transactant"}},
+	{Name: "RentalAgreementTax", Refs: map[string]string{"BID": "Business", "RAID": "RentalAgreement"}},
+	{Name: "RentalAgreementPets", Key: "PETID", Refs: map[string]string{"BID": "Business", "RAID": "RentalAgreement"}},
+	{Name: "Notes", Key: "NID", Refs: map[string]string{"BID": "Business", "NLID": "NoteList", "PNID": "Notes", "NTID": "NoteType", "RID": "Rentable", "RAID": "RentalAgreement", "TCID": "Transactant"}},
+	{Name: "CustomAttrRef", Refs: map[string]string{"BID": "Business", "CID": "CustomAttr"},
+		Dyn: map[string]func(r map[string]interface{}) string{"ID": customAttrRefIDTable}},
+	{Name: "GLAccount", Key: "LID", Refs: map[string]string{"BID": "Business", "PLID": "GLAccount", "RAID": "RentalAgreement", "TCID": "Transactant"}},
+	{Name: "BusinessAssessments", Refs: map[string]string{"BID": "Business", "ATypeLID": "GLAccount"}},
+	{Name: "AR", Key: "ARID", Refs: map[string]string{"BID": "Business", "SubARID": "AR", "DebitLID": "GLAccount", "CreditLID": "GLAccount"}},
+	{Name: "SubAR", Key: "SARID", Refs: map[string]string{"BID": "Business", "ARID": "AR", "SubARID": "AR"}},
+	{Name: "Depository", Key: "DEPID", Refs: map[string]string{"BID": "Business", "LID": "GLAccount"}},
+	{Name: "Deposit", Key: "DID", Refs: map[string]string{"BID": "Business", "DEPID": "Depository", "DPMID": "DepositMethod"}},
+	{Name: "Receipt", Key: "RCPTID", Refs: map[string]string{"BID": "Business", "PRCPTID": "Receipt", "TCID": "Transactant", "PMTID": "PaymentType", "DEPID": "Depository", "DID": "Deposit", "RAID": "RentalAgreement", "ARID": "AR"},
+		Rules: []string{"AcctRuleApply"}},
+	{Name: "DepositPart", Key: "DPID", Refs: map[string]string{"BID": "Business", "DID": "Deposit", "RCPTID": "Receipt"}},
+	{Name: "Invoice", Key: "InvoiceNo", Refs: map[string]string{"BID": "Business"}},
+	{Name: "InvoicePayor", Refs: map[string]string{"BID": "Business", "InvoiceNo": "Invoice", "PID": "Transactant"}},
+	{Name: "Assessments", Key: "ASMID", Refs: map[string]string{"BID": "Business", "PASMID": "Assessments", "RPASMID": "Assessments", "AGRCPTID": "Receipt", "RID": "Rentable", "ATypeLID": "GLAccount", "RAID": "RentalAgreement", "InvoiceNo": "Invoice", "ARID": "AR"}},
+	{Name: "AssessmentTax", Refs: map[string]string{"BID": "Business", "ASMID": "Assessments", "TAXID": "Tax"}},
+	{Name: "InvoiceAssessment", Refs: map[string]string{"BID": "Business", "InvoiceNo": "Invoice", "ASMID": "Assessments"}},
+	{Name: "ReceiptAllocation", Key: "RCPAID", Refs: map[string]string{"BID": "Business", "RCPTID": "Receipt", "RAID": "RentalAgreement", "ASMID": "Assessments"},
+		Rules: []string{"AcctRule"}},
+	{Name: "Expense", Key: "EXPID", Refs: map[string]string{"BID": "Business", "RPEXPID": "Expense", "RID": "Rentable", "RAID": "RentalAgreement", "ARID": "AR"}},
+	{Name: "Vendor", Key: "VENDID", Refs: map[string]string{"BID": "Business", "DefaultLID": "GLAccount"}},
+	{Name: "Bill", Key: "BILLID", Refs: map[string]string{"BID": "Business", "RPBILLID": "Bill", "VENDID": "Vendor", "APLID": "GLAccount"}},
+	{Name: "BillItem", Key: "BIID", Refs: map[string]string{"BID": "Business", "BILLID": "Bill", "LID": "GLAccount", "RID": "Rentable"}},
+	{Name: "BillPayment", Key: "BPID", Refs: map[string]string{"BID": "Business", "RPBPID": "BillPayment", "BILLID": "Bill", "VENDID": "Vendor", "DEPID": "Depository"}},
+	{Name: "Journal", Key: "JID", Refs: map[string]string{"BID": "Business"},
+		Dyn: map[string]func(r map[string]interface{}) string{"ID": journalIDTable}},
+	{Name: "JournalAudit", Refs: map[string]string{"BID": "Business", "JID": "Journal"}},
+	{Name: "JournalAllocation", Key: "JAID", Refs: map[string]string{"BID": "Business", "JID": "Journal", "RID": "Rentable", "RAID": "RentalAgreement", "TCID": "Transactant", "RCPTID": "Receipt", "ASMID": "Assessments", "EXPID": "Expense"},
+		Rules: []string{"AcctRule"}},
+	{Name: "JournalMarker", Key: "JMID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "JournalMarkerAudit", Refs: map[string]string{"BID": "Business", "JMID": "JournalMarker"}},
+	{Name: "LedgerEntry", Key: "LEID", Refs: map[string]string{"BID": "Business", "JID": "Journal", "JAID": "JournalAllocation", "LID": "GLAccount", "RAID": "RentalAgreement", "RID": "Rentable", "TCID": "Transactant"}},
+	{Name: "LedgerAudit", Refs: map[string]string{"BID": "Business", "LEID": "LedgerEntry"}},
+	{Name: "LedgerMarker", Key: "LMID", Refs: map[string]string{"BID": "Business", "LID": "GLAccount", "RAID": "RentalAgreement", "RID": "Rentable", "TCID": "Transactant"}},
+	{Name: "LedgerMarkerAudit", Refs: map[string]string{"BID": "Business", "LMID": "LedgerMarker"}},
+	{Name: "GLExport", Key: "GLEXID", Refs: map[string]string{"BID": "Business"}},
+	{Name: "GLExportJournal", Refs: map[string]string{"BID": "Business", "GLEXID": "GLExport", "JID": "Journal"},
+		Dyn: map[string]func(r map[string]interface{}) string{"ID": journalIDTable}},
+}
+
+// archiveTableIndex returns the index of table name in archiveTables, or -1
+func archiveTableIndex(name string) int {
+	for i := 0; i < len(archiveTables); i++ {
+		if archiveTables[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// archiveInt returns the integer value of an archived column. Numbers are
+// json.Number when read from an archive and int64 when read from the
+// database.
+func archiveInt(v interface{}) int64 {
+	switch x := v.(type) {
+	case int64:
+		return x
+	case float64:
+		return int64(x)
+	case json.Number:
+		i, _ := strconv.ParseInt(x.String(), 10, 64)
+		return i
+	case string:
+		i, _ := strconv.ParseInt(x, 10, 64)
+		return i
+	}
+	return 0
+}
+
+// archiveValue converts a value read from the database to the form it is
+// archived in
+func archiveValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case []byte:
+		return string(x)
+	case time.Time:
+		return x.Format("2006-01-02 15:04:05")
+	}
+	return v
+}
+
+// archiveSQLValue converts an archived value to a statement argument
+func archiveSQLValue(v interface{}) interface{} {
+	if n, ok := v.(json.Number); ok {
+		return n.String()
+	}
+	return v
+}
+
+// ExportBusiness writes the archive of business bid to w.
+//
+// INPUTS
+//    bid = the business to export
+//    w   = where to write the archive
+//
+// RETURNS
+//    the header that was written, its Rows member has the number of records
+//        of each table
+//    any error encountered
+//-----------------------------------------------------------------------------
+func ExportBusiness(bid int64, w io.Writer) (ArchiveHeader, error) {
+	funcname := "ExportBusiness"
+	var b Business
+	GetBusiness(bid, &b)
+	if b.BID == 0 {
+		return ArchiveHeader{}, fmt.Errorf("%s: business %d not found", funcname, bid)
+	}
+	h := ArchiveHeader{Format: ArchiveFormat, Version: ArchiveVersion, BID: bid, BUD: b.Designation, Created: time.Now(), Rows: map[string]int{}}
+
+	//------------------------------------------------------------------
+	// The header comes first but has the row counts, so count them now
+	//------------------------------------------------------------------
+	for i := 0; i < len(archiveTables); i++ {
+		var n int
+		q := fmt.Sprintf("SELECT COUNT(*) FROM `%s` WHERE BID=?", archiveTables[i].Name)
+		if err := RRdb.Dbrr.QueryRow(q, bid).Scan(&n); err != nil {
+			return h, fmt.Errorf("%s: %s: %s", funcname, archiveTables[i].Name, err.Error())
+		}
+		h.Rows[archiveTables[i].Name] = n
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	if err := enc.Encode(&h); err != nil {
+		return h, fmt.Errorf("%s: %s", funcname, err.Error())
+	}
+	for i := 0; i < len(archiveTables); i++ {
+		if err := exportTable(&archiveTables[i], bid, enc); err != nil {
+			return h, fmt.Errorf("%s: %s: %s", funcname, archiveTables[i].Name, err.Error())
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return h, fmt.Errorf("%s: %s", funcname, err.Error())
+	}
+	return h, nil
+}
+
+// exportTable writes an ArchiveRecord for each row of table t that belongs
+// to business bid
+func exportTable(t *archiveTable, bid int64, enc *json.Encoder) error {
+	return archiveRows(t, bid, "", func(row map[string]interface{}) error {
+		rec := ArchiveRecord{Table: t.Name, Row: row}
+		return enc.Encode(&rec)
+	})
+}
+
+// archiveRows calls f with each row of table t that belongs to business bid.
+// where, if not empty, is an extra condition the rows must meet.
+func archiveRows(t *archiveTable, bid int64, where string, f func(row map[string]interface{}) error) error {
+	q := fmt.Sprintf("SELECT * FROM `%s` WHERE BID=?", t.Name)
+	if len(where) > 0 {
+		q += " AND " + where
+	}
+	if len(t.Key) > 0 {
+		q += " ORDER BY " + t.Key
+	}
+	rows, err := RRdb.Dbrr.Query(q, bid)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	vals := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := 0; i < len(cols); i++ {
+		ptrs[i] = &vals[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		row := map[string]interface{}{}
+		for i := 0; i < len(cols); i++ {
+			row[cols[i]] = archiveValue(vals[i])
+		}
+		if err = f(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// archiveFixup is a reference that could not be remapped when its row was
+// inserted because the record it refers to had not been loaded yet
+type archiveFixup struct {
+	Table  string // table of the row to update
+	Key    string // its key column
+	KeyVal int64  // the new key of the row
+	Col    string // column to set
+	Ref    string // table the column refers to
+	Old    int64  // the id in the archive
+	Rule   string // the archived account rule if Col is one of the table's Rules
+}
+
+// archiveIDMap maps the ids in an archive to the ids of the records loaded
+// from it
+type archiveIDMap map[string]map[int64]int64
+
+// set records that the record of table with id old was loaded with id new
+func (m archiveIDMap) set(table string, old, new int64) {
+	if m[table] == nil {
+		m[table] = map[int64]int64{}
+	}
+	m[table][old] = new
+}
+
+// get returns the new id for id old of table
+func (m archiveIDMap) get(table string, old int64) (int64, bool) {
+	id, ok := m[table][old]
+	return id, ok
+}
+
+// remapRow replaces the ids in row with the ids they were loaded as. Ids of
+// 0 mean "none" and are left as they are. The columns whose records have not
+// been loaded yet are set to 0 and returned along with their old values and
+// the tables they refer to.
+func (m archiveIDMap) remapRow(t *archiveTable, row map[string]interface{}) (cols []string, olds []int64, refs []string) {
+	remap := func(col, ref string) {
+		v, ok := row[col]
+		if !ok || len(ref) == 0 {
+			return
+		}
+		old := archiveInt(v)
+		if old == 0 {
+			return
+		}
+		if id, ok := m.get(ref, old); ok {
+			row[col] = id
+			return
+		}
+		row[col] = int64(0)
+		cols = append(cols, col)
+		olds = append(olds, old)
+		refs = append(refs, ref)
+	}
+	for _, col := range archiveSortedKeys(t.Refs) {
+		remap(col, t.Refs[col])
+	}
+	for col, f := range t.Dyn {
+		remap(col, f(row))
+	}
+	return cols, olds, refs
+}
+
+// remapRule replaces the assessment ids in the ASM(n) terms of account rule
+// rule with the ids they were loaded as. If an assessment has not been
+// loaded and final is false, rule is returned unchanged along with false.
+// If final is true such an id is set to 0 and counted in the returned
+// number of unresolved ids.
+func (m archiveIDMap) remapRule(rule string, final bool) (string, bool, int) {
+	ok, unresolved := true, 0
+	s := archiveASM.ReplaceAllStringFunc(rule, func(t string) string {
+		old, _ := strconv.ParseInt(archiveASM.FindStringSubmatch(t)[1], 10, 64)
+		if old == 0 {
+			return t
+		}
+		id, found := m.get("Assessments", old)
+		if !found {
+			ok = false
+			unresolved++
+		}
+		return fmt.Sprintf("ASM(%d)", id)
+	})
+	if !ok && !final {
+		return rule, false, unresolved
+	}
+	return s, ok, unresolved
+}
+
+// archiveSortedKeys returns the keys of m in sorted order
+func archiveSortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ImportBusiness loads an archive written by ExportBusiness as a new business.
+// Every record gets a new id and every reference to it is changed to match,
+// including the ASM(n) terms of account rules.
+// The whole archive is loaded in one transaction; if anything fails nothing
+// is saved.
+//
+// A reference to a record that is not in the archive, such as the Journal
+// entry of an assessment that was purged, is set to 0 and counted in the
+// result's Unresolved member.
+//
+// INPUTS
+//    r   = the archive
+//    bud = designation for the new business. If it is empty the designation
+//          in the archive is used. It must not already be in use.
+//
+// RETURNS
+//    a description of the import
+//    any error encountered
+//-----------------------------------------------------------------------------
+func ImportBusiness(r io.Reader, bud string) (ArchiveImportResult, error) {
+	funcname := "ImportBusiness"
+	var res = ArchiveImportResult{Rows: map[string]int{}}
+
+	dec := json.NewDecoder(bufio.NewReader(r))
+	dec.UseNumber()
+	var h ArchiveHeader
+	if err := dec.Decode(&h); err != nil {
+		return res, fmt.Errorf("%s: cannot read archive header: %s", funcname, err.Error())
+	}
+	if h.Format != ArchiveFormat {
+		return res, fmt.Errorf("%s: not a business archive", funcname)
+	}
+	if h.Version < 1 || h.Version > ArchiveVersion {
+		return res, fmt.Errorf("%s: unsupported archive version %d, this program reads up to version %d", funcname, h.Version, ArchiveVersion)
+	}
+	res.BUD = strings.TrimSpace(bud)
+	if len(res.BUD) == 0 {
+		res.BUD = h.BUD
+	}
+	if b := GetBusinessByDesignation(res.BUD); b.BID > 0 {
+		return res, fmt.Errorf("%s: business %s already exists", funcname, res.BUD)
+	}
+
+	err := RunInTx(func(tx *RRTx) error {
+		l := newArchiveLoader(tx, &res)
+		last := 0
+		for line := 2; ; line++ {
+			var rec ArchiveRecord
+			err := dec.Decode(&rec)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("record %d: %s", line, err.Error())
+			}
+			i := archiveTableIndex(rec.Table)
+			if i < 0 {
+				return fmt.Errorf("record %d: unknown table %q", line, rec.Table)
+			}
+			if i < last {
+				return fmt.Errorf("record %d: %s records must come before %s records", line, rec.Table, archiveTables[last].Name)
+			}
+			last = i
+			if _, err = l.load(&archiveTables[i], rec.Row); err != nil {
+				return fmt.Errorf("record %d: %s", line, err.Error())
+			}
+		}
+		return l.finish()
+	})
+	if err != nil {
+		return res, fmt.Errorf("%s: %s", funcname, err.Error())
+	}
+	RRdb.BUDlist = BuildBusinessDesignationMap()
+	return res, nil
+}
+
+// archiveLoader inserts archived rows as new records of business res.BUD
+type archiveLoader struct {
+	tx     *RRTx
+	m      archiveIDMap
+	fixups []archiveFixup
+	res    *ArchiveImportResult
+	cols   map[string]map[string]bool // table -> its columns, read as needed
+}
+
+// newArchiveLoader returns a loader that inserts rows within tx and
+// describes what it loads in res
+func newArchiveLoader(tx *RRTx, res *ArchiveImportResult) *archiveLoader {
+	return &archiveLoader{tx: tx, m: archiveIDMap{}, res: res, cols: map[string]map[string]bool{}}
+}
+
+// columns returns the columns of table, as the database has them
+func (l *archiveLoader) columns(table string) (map[string]bool, error) {
+	if m, ok := l.cols[table]; ok {
+		return m, nil
+	}
+	rows, err := l.tx.Query(fmt.Sprintf("SELECT * FROM `%s` WHERE 1=0", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]bool{}
+	for i := 0; i < len(cols); i++ {
+		m[cols[i]] = true
+	}
+	l.cols[table] = m
+	return m, nil
+}
+
+// load inserts row into table t, replacing the ids in it with the ids of the
+// records they were loaded as. The row of the Business table must be loaded
+// first, it is given the designation l.res.BUD.
+//
+// RETURNS
+//    the new id of the record, 0 if the table has no key
+//    any error encountered
+//-----------------------------------------------------------------------------
+func (l *archiveLoader) load(t *archiveTable, row map[string]interface{}) (int64, error) {
+	if t.Name == "Business" {
+		if l.res.BID > 0 {
+			return 0, fmt.Errorf("there is more than one business")
+		}
+		row["BUD"] = l.res.BUD
+	} else if l.res.BID == 0 {
+		return 0, fmt.Errorf("%s record comes before the business", t.Name)
+	}
+
+	old := archiveInt(row[t.Key])
+	if len(t.Key) > 0 {
+		delete(row, t.Key)
+	}
+	cols, olds, refs := l.m.remapRow(t, row)
+	var rules []string // rules that refer to assessments not loaded yet
+	for _, col := range t.Rules {
+		rule, ok := row[col].(string)
+		if !ok {
+			continue
+		}
+		if row[col], ok, _ = l.m.remapRule(rule, false); !ok {
+			rules = append(rules, col)
+		}
+	}
+	known, err := l.columns(t.Name)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %s", t.Name, err.Error())
+	}
+	id, err := archiveInsert(l.tx, t.Name, row, known)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %s", t.Name, err.Error())
+	}
+	if len(t.Key) > 0 {
+		l.m.set(t.Name, old, id)
+	}
+	if t.Name == "Business" {
+		l.res.BID = id
+	}
+	for j := 0; j < len(cols); j++ {
+		if len(t.Key) == 0 {
+			l.res.Unresolved++ // its record would have been loaded already
+			continue
+		}
+		l.fixups = append(l.fixups, archiveFixup{Table: t.Name, Key: t.Key, KeyVal: id, Col: cols[j], Ref: refs[j], Old: olds[j]})
+	}
+	for _, col := range rules {
+		l.fixups = append(l.fixups, archiveFixup{Table: t.Name, Key: t.Key, KeyVal: id, Col: col, Ref: "Assessments", Rule: row[col].(string)})
+	}
+	l.res.Rows[t.Name]++
+	return id, nil
+}
+
+// finish sets the references that could not be set when their rows were
+// loaded. It must be called after every row is loaded.
+func (l *archiveLoader) finish() error {
+	if l.res.BID == 0 {
+		return fmt.Errorf("there is no business")
+	}
+	for i := 0; i < len(l.fixups); i++ {
+		f := &l.fixups[i]
+		var v interface{}
+		if len(f.Rule) > 0 {
+			rule, _, n := l.m.remapRule(f.Rule, true)
+			l.res.Unresolved += n
+			v = rule
+		} else {
+			id, ok := l.m.get(f.Ref, f.Old)
+			if !ok {
+				l.res.Unresolved++
+				continue
+			}
+			v = id
+		}
+		q := fmt.Sprintf("UPDATE `%s` SET `%s`=? WHERE `%s`=?", f.Table, f.Col, f.Key)
+		if _, err := l.tx.Exec(q, v, f.KeyVal); err != nil {
+			return fmt.Errorf("%s %s=%d: %s", f.Table, f.Key, f.KeyVal, err.Error())
+		}
+	}
+	return nil
+}
+
+// archiveInsert inserts row into table and returns the id of the new record.
+// Every column of row must be in known, the columns of the table, so that
+// the column names taken from the archive are safe to put in the SQL.
+func archiveInsert(tx *RRTx, table string, row map[string]interface{}, known map[string]bool) (int64, error) {
+	var cols []string
+	for k := range row {
+		if !known[k] {
+			return 0, fmt.Errorf("unknown column %q", k)
+		}
+		cols = append(cols, k)
+	}
+	sort.Strings(cols)
+	args := make([]interface{}, len(cols))
+	for i := 0; i < len(cols); i++ {
+		args[i] = archiveSQLValue(row[cols[i]])
+	}
+	q := fmt.Sprintf("INSERT INTO `%s` (`%s`) VALUES(?%s)", table, strings.Join(cols, "`,`"), strings.Repeat(",?", len(cols)-1))
+	res, err := tx.Exec(q, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
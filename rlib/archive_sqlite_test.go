@@ -0,0 +1,142 @@
+// +build sqlite
+
+package rlib_test
+
+import (
+	"bytes"
+	"fmt"
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"strings"
+	"testing"
+)
+
+// archiveID returns the first column of the first row of query q
+func archiveID(t *testing.T, q string, args ...interface{}) int64 {
+	var id int64
+	if err := rlib.RRdb.Dbrr.QueryRow(q, args...).Scan(&id); err != nil {
+		t.Fatalf("%s: %s", q, err.Error())
+	}
+	return id
+}
+
+// archiveRule returns the string in the first column of the first row of q
+func archiveRule(t *testing.T, q string, args ...interface{}) string {
+	var s string
+	if err := rlib.RRdb.Dbrr.QueryRow(q, args...).Scan(&s); err != nil {
+		t.Fatalf("%s: %s", q, err.Error())
+	}
+	return s
+}
+
+// A business exported and imported again has the same records, and every id
+// in them, including those in account rules, Journal and GLExportJournal
+// entries, is the id of the new record
+func TestArchiveRoundTrip(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	a := journalAssessment(t, b, "Late Fee", rrtest.Dt(2017, 3, 5), 50)
+	exportGL(t, b, rlib.GLExportOptions{Format: rlib.GLEXPORTCSV})
+
+	rule := func(asmid int64) string {
+		return fmt.Sprintf("ASM(%d) d 10999 50.00,ASM(%d) c 11001 50.00", asmid, asmid)
+	}
+	r := rlib.Receipt{BID: b.BID, TCID: b.TCID, PMTID: b.PMTID, RAID: b.RAID, ARID: b.ARID["Receive Payment"], Dt: rrtest.Dt(2017, 3, 6), Amount: 50, AcctRuleApply: rule(a.ASMID)}
+	if _, err := rlib.InsertReceipt(&r); err != nil {
+		t.Fatalf("InsertReceipt: %s", err.Error())
+	}
+	ra := rlib.ReceiptAllocation{RCPTID: r.RCPTID, BID: b.BID, RAID: b.RAID, Dt: r.Dt, Amount: 50, ASMID: a.ASMID, AcctRule: rule(a.ASMID)}
+	if _, err := rlib.InsertReceiptAllocation(&ra); err != nil {
+		t.Fatalf("InsertReceiptAllocation: %s", err.Error())
+	}
+	j := rlib.Journal{BID: b.BID, Dt: r.Dt, Amount: 50, Type: rlib.JNLTYPEXFER, ID: r.RCPTID}
+	if _, err := rlib.InsertJournal(&j); err != nil {
+		t.Fatalf("InsertJournal: %s", err.Error())
+	}
+	ja := rlib.JournalAllocation{JID: j.JID, BID: b.BID, RAID: b.RAID, RCPTID: r.RCPTID, ASMID: a.ASMID, Amount: 50, AcctRule: rule(a.ASMID)}
+	if err := rlib.InsertJournalAllocationEntry(&ja); err != nil {
+		t.Fatalf("InsertJournalAllocationEntry: %s", err.Error())
+	}
+
+	var buf bytes.Buffer
+	h, err := rlib.ExportBusiness(b.BID, &buf)
+	if err != nil {
+		t.Fatalf("ExportBusiness: %s", err.Error())
+	}
+	res, err := rlib.ImportBusiness(&buf, "REX2")
+	if err != nil {
+		t.Fatalf("ImportBusiness: %s", err.Error())
+	}
+	if res.Unresolved != 0 {
+		t.Errorf("expect every reference to be resolved, %d were not", res.Unresolved)
+	}
+	for table, n := range h.Rows {
+		if res.Rows[table] != n {
+			t.Errorf("%s: expect %d records, got %d", table, n, res.Rows[table])
+		}
+	}
+	if h.Rows["GLExportJournal"] == 0 {
+		t.Errorf("expect the GL export to be in the archive")
+	}
+
+	asmid := archiveID(t, "SELECT ASMID FROM Assessments WHERE BID=?", res.BID)
+	rcptid := archiveID(t, "SELECT RCPTID FROM Receipt WHERE BID=?", res.BID)
+	if asmid == a.ASMID || rcptid == r.RCPTID {
+		t.Fatalf("expect new ids, got assessment %d and receipt %d", asmid, rcptid)
+	}
+	rules := []struct {
+		what string
+		q    string
+	}{
+		{"Receipt.AcctRuleApply", "SELECT AcctRuleApply FROM Receipt WHERE BID=?"},
+		{"ReceiptAllocation.AcctRule", "SELECT AcctRule FROM ReceiptAllocation WHERE BID=?"},
+		{"JournalAllocation.AcctRule", "SELECT AcctRule FROM JournalAllocation WHERE BID=? AND ASMID>0 AND RCPTID>0"},
+	}
+	for _, c := range rules {
+		if s := archiveRule(t, c.q, res.BID); s != rule(asmid) {
+			t.Errorf("%s: expect %q, got %q", c.what, rule(asmid), s)
+		}
+	}
+	if id := archiveID(t, "SELECT ID FROM Journal WHERE BID=? AND Type=?", res.BID, rlib.JNLTYPEXFER); id != rcptid {
+		t.Errorf("transfer Journal ID: expect receipt %d, got %d", rcptid, id)
+	}
+	if id := archiveID(t, "SELECT ID FROM GLExportJournal WHERE BID=? AND Type=?", res.BID, rlib.JNLTYPEASMT); id != asmid {
+		t.Errorf("GLExportJournal ID: expect assessment %d, got %d", asmid, id)
+	}
+}
+
+// Webhooks are not exported, and an archive with a record of a table or a
+// column that is not archived is rejected and nothing is loaded
+func TestArchiveImportRejects(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	if _, err := rlib.InsertWebhook(&rlib.Webhook{BID: b.BID, URL: "https://example.com/hook", Secret: "s3cret", EventTypes: "*"}); err != nil {
+		t.Fatalf("InsertWebhook: %s", err.Error())
+	}
+	var buf bytes.Buffer
+	h, err := rlib.ExportBusiness(b.BID, &buf)
+	if err != nil {
+		t.Fatalf("ExportBusiness: %s", err.Error())
+	}
+	if _, ok := h.Rows["Webhook"]; ok || strings.Contains(buf.String(), "s3cret") {
+		t.Errorf("expect the webhook to be left out of the archive")
+	}
+
+	lines := strings.SplitN(buf.String(), "\n", 3) // the header and the business
+	m := []struct {
+		what, record, expect string
+	}{
+		{"webhook", `{"Table":"Webhook","Row":{"BID":1,"URL":"https://example.com/hook"}}`, "unknown table"},
+		{"unknown column", `{"Table":"StringList","Row":{"BID":1,"Name":"x","Name) VALUES(1); DROP TABLE Journal; --":1}}`, "unknown column"},
+	}
+	for i := 0; i < len(m); i++ {
+		bud := fmt.Sprintf("REJ%d", i)
+		_, err = rlib.ImportBusiness(strings.NewReader(lines[0]+"\n"+lines[1]+"\n"+m[i].record+"\n"), bud)
+		if err == nil || !strings.Contains(err.Error(), m[i].expect) {
+			t.Errorf("%s: expect an error about the %s, got %v", m[i].what, m[i].expect, err)
+		}
+		if x := rlib.GetBusinessByDesignation(bud); x.BID != 0 {
+			t.Errorf("%s: expect nothing loaded, got business %d", m[i].what, x.BID)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package rlib
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Every reference must be to an archived table. A reference to a table that
+// is loaded later can only be set after its row is inserted, which needs the
+// row's key.
+func TestArchiveTables(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < len(archiveTables); i++ {
+		a := &archiveTables[i]
+		if seen[a.Name] {
+			t.Errorf("%s is listed twice", a.Name)
+		}
+		seen[a.Name] = true
+		for col, ref := range a.Refs {
+			j := archiveTableIndex(ref)
+			if j < 0 {
+				t.Errorf("%s.%s refers to %s, which is not archived", a.Name, col, ref)
+			} else if j >= i && len(a.Key) == 0 {
+				t.Errorf("%s.%s refers to %s, which is loaded after it", a.Name, col, ref)
+			}
+		}
+	}
+}
+
+func TestArchiveRemapRow(t *testing.T) {
+	m := archiveIDMap{}
+	m.set("Business", 3, 10)
+	m.set("RentalAgreement", 7, 21)
+	m.set("Assessments", 40, 90)
+	m.set("Receipt", 40, 95)
+
+	ta := &archiveTables[archiveTableIndex("Assessments")]
+	row := map[string]interface{}{
+		"BID":      json.Number("3"),
+		"RAID":     json.Number("7"),
+		"PASMID":   json.Number("40"),
+		"AGRCPTID": json.Number("5"), // receipt not loaded yet
+		"RID":      json.Number("0"), // none
+		"Amount":   json.Number("1000.0000"),
+	}
+	cols, olds, refs := m.remapRow(ta, row)
+	expect := map[string]int64{"BID": 10, "RAID": 21, "PASMID": 90, "AGRCPTID": 0, "RID": 0}
+	for col, id := range expect {
+		if got := archiveInt(row[col]); got != id {
+			t.Errorf("%s: expect %d, got %d", col, id, got)
+		}
+	}
+	if row["Amount"] != json.Number("1000.0000") {
+		t.Errorf("Amount was changed to %v", row["Amount"])
+	}
+	if len(cols) != 1 || cols[0] != "AGRCPTID" || olds[0] != 5 || refs[0] != "Receipt" {
+		t.Errorf("expect AGRCPTID 5 Receipt to be unresolved, got %v %v %v", cols, olds, refs)
+	}
+
+	tj := &archiveTables[archiveTableIndex("Journal")]
+	row = map[string]interface{}{"BID": json.Number("3"), "Type": json.Number("1"), "ID": json.Number("40")}
+	m.remapRow(tj, row)
+	if got := archiveInt(row["ID"]); got != 90 {
+		t.Errorf("Journal assessment ID: expect 90, got %d", got)
+	}
+	row = map[string]interface{}{"BID": json.Number("3"), "Type": json.Number("4"), "ID": json.Number("40")}
+	m.remapRow(tj, row)
+	if got := archiveInt(row["ID"]); got != 95 {
+		t.Errorf("Journal transfer ID: expect 95, got %d", got)
+	}
+
+	tg := &archiveTables[archiveTableIndex("GLExportJournal")]
+	row = map[string]interface{}{"BID": json.Number("3"), "Type": json.Number("2"), "ID": json.Number("40")}
+	m.remapRow(tg, row)
+	if got := archiveInt(row["ID"]); got != 95 {
+		t.Errorf("GLExportJournal receipt ID: expect 95, got %d", got)
+	}
+}
+
+func TestArchiveRemapRule(t *testing.T) {
+	m := archiveIDMap{}
+	m.set("Assessments", 3, 30)
+	m.set("Assessments", 4, 40)
+
+	rule := "ASM(3) c 11001 266.67, ASM(3) d 10001 266.67, ASM(4) c 11001 5.33,ASM(4) d 10001 5.33"
+	s, ok, n := m.remapRule(rule, false)
+	if expect := "ASM(30) c 11001 266.67, ASM(30) d 10001 266.67, ASM(40) c 11001 5.33,ASM(40) d 10001 5.33"; s != expect || !ok || n != 0 {
+		t.Errorf("expect %q, got %q %v %d", expect, s, ok, n)
+	}
+	if s, _, _ = m.remapRule("d 10001 _, c 11001 _", false); s != "d 10001 _, c 11001 _" {
+		t.Errorf("a rule without ASM terms was changed to %q", s)
+	}
+
+	// ASM(9) has not been loaded, the rule is left alone until the end
+	rule = "ASM(3) c 11001 9.33, ASM(9) d 10001 9.33"
+	if s, ok, _ = m.remapRule(rule, false); s != rule || ok {
+		t.Errorf("expect %q to be left unchanged, got %q %v", rule, s, ok)
+	}
+	if s, ok, n = m.remapRule(rule, true); s != "ASM(30) c 11001 9.33, ASM(0) d 10001 9.33" || ok || n != 1 {
+		t.Errorf("expect ASM(9) to be set to 0, got %q %v %d", s, ok, n)
+	}
+}
@@ -0,0 +1,76 @@
+package rlib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BizListSeparators are the characters that may be used to separate the
+// entries of a business list specification
+const BizListSeparators = ":; "
+
+// GetBusinessListFromSpec returns the list of BIDs described by spec. The
+// spec is a list of entries separated by any of BizListSeparators. Each
+// entry may be a BusinessGroup name, a Business Unit Designation, or a BID.
+// Entries are checked in that order. A BID appears in the list only once.
+// If spec is empty, or is "all", every business is returned.
+//
+// INPUTS
+//    spec = the list of businesses, for example  "West:REX:4"
+//
+// RETURNS
+//    the list of BIDs
+//    any error encountered
+//-----------------------------------------------------------------------------
+func GetBusinessListFromSpec(spec string) ([]int64, error) {
+	var m []int64
+	spec = strings.TrimSpace(spec)
+	if len(spec) == 0 || strings.ToLower(spec) == "all" {
+		bl, err := GetAllBusinesses()
+		if err != nil {
+			return m, err
+		}
+		for i := 0; i < len(bl); i++ {
+			m = append(m, bl[i].BID)
+		}
+		return m, nil
+	}
+
+	seen := map[int64]bool{}
+	add := func(bid int64) {
+		if !seen[bid] {
+			seen[bid] = true
+			m = append(m, bid)
+		}
+	}
+	sa := strings.FieldsFunc(spec, func(r rune) bool { return strings.ContainsRune(BizListSeparators, r) })
+	for i := 0; i < len(sa); i++ {
+		s := strings.TrimSpace(sa[i])
+		g, err := GetBusinessGroupByName(s)
+		if err == nil && g.BGID > 0 {
+			bids, err := GetBusinessGroupMembers(g.BGID)
+			if err != nil {
+				return m, err
+			}
+			for j := 0; j < len(bids); j++ {
+				add(bids[j])
+			}
+			continue
+		}
+		if bid, ok := RRdb.BUDlist[s]; ok {
+			add(bid)
+			continue
+		}
+		bid, ok := StringToInt64(s)
+		if ok && bid > 0 {
+			var b Business
+			GetBusiness(bid, &b)
+			if b.BID > 0 {
+				add(bid)
+				continue
+			}
+		}
+		return m, fmt.Errorf("%q is not a business group, business unit designation, or business id", s)
+	}
+	return m, nil
+}
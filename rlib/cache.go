@@ -19,4 +19,5 @@ func InitCaches() {
 	go SecDepBalCacheController()
 	go GLAcctCacheController()
 	go ARCacheController()
+	go KPICacheController()
 }
@@ -0,0 +1,132 @@
+package rlib
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CloneOptions describes the new business made by CloneBusiness
+type CloneOptions struct {
+	BUD       string // designation of the new business, required
+	Name      string // name of the new business, default is the template's name
+	ShiftDays int    // number of days to move the market rate dates by, may be negative
+	UID       int64  // phonebook UID of the person making the clone
+}
+
+// cloneTables are the configuration tables copied by CloneBusiness, in the
+// order of archiveTables. Where is an extra condition the rows must meet.
+// GL accounts for a rental agreement or a payor are made as transactions are
+// recorded, so they are not copied.
+var cloneTables = []struct {
+	Name  string
+	Where string
+}{
+	{Name: "Business"},
+	{Name: "StringList"},
+	{Name: "SLString"},
+	{Name: "NoteType"},
+	{Name: "PaymentType"},
+	{Name: "BusinessPaymentTypes"},
+	{Name: "DepositMethod"},
+	{Name: "RentableTypes"},
+	{Name: "RentableMarketRate"},
+	{Name: "GLAccount", Where: "RAID=0 AND TCID=0"},
+	{Name: "BusinessAssessments"},
+	{Name: "AR"},
+	{Name: "SubAR"},
+	{Name: "Depository"},
+}
+
+// cloneShiftDate moves the archived date v by days. The dates that mean
+// "no limit", TIME0 and the year 9999, are not moved.
+func cloneShiftDate(v interface{}, days int) interface{} {
+	s, ok := v.(string)
+	if !ok || days == 0 {
+		return v
+	}
+	dt, err := time.Parse("2006-01-02 15:04:05", s)
+	if err != nil || !dt.After(TIME0) || dt.Year() >= 9999 {
+		return v
+	}
+	return dt.AddDate(0, 0, days).Format("2006-01-02 15:04:05")
+}
+
+// CloneBusiness makes a new business with the configuration of business bid:
+// its chart of accounts, account rules, payment types, deposit methods,
+// depositories, rentable types and market rates, string lists and note
+// types. No rentables, people, agreements or transactions are copied. The
+// new records get new ids and the references between them (GL account
+// parents, the debit and credit accounts of account rules, sub account rules,
+// ...) are updated to match. Nothing is saved unless the whole business is
+// copied.
+//
+// INPUTS
+//    bid = the template business
+//    o   = what to call the new business and how to change what is copied
+//
+// RETURNS
+//    a description of the new business, with the number of records of each
+//        table that were copied
+//    any error encountered
+//-----------------------------------------------------------------------------
+func CloneBusiness(bid int64, o *CloneOptions) (ArchiveImportResult, error) {
+	funcname := "CloneBusiness"
+	var res = ArchiveImportResult{Rows: map[string]int{}, BUD: strings.TrimSpace(o.BUD)}
+
+	var b Business
+	GetBusiness(bid, &b)
+	if b.BID == 0 {
+		return res, fmt.Errorf("%s: business %d not found", funcname, bid)
+	}
+	if len(res.BUD) == 0 {
+		return res, fmt.Errorf("%s: the new business needs a designation", funcname)
+	}
+	if b2 := GetBusinessByDesignation(res.BUD); b2.BID > 0 {
+		return res, fmt.Errorf("%s: business %s already exists", funcname, res.BUD)
+	}
+
+	err := RunInTx(func(tx *RRTx) error {
+		l := newArchiveLoader(tx, &res)
+		for i := 0; i < len(cloneTables); i++ {
+			t := &archiveTables[archiveTableIndex(cloneTables[i].Name)]
+			var rows []map[string]interface{}
+			err := archiveRows(t, bid, cloneTables[i].Where, func(row map[string]interface{}) error {
+				rows = append(rows, row)
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %s", t.Name, err.Error())
+			}
+			for j := 0; j < len(rows); j++ {
+				row := rows[j]
+				delete(row, "CreateTS")
+				delete(row, "LastModTime")
+				for _, col := range []string{"CreateBy", "LastModBy"} {
+					if _, ok := row[col]; ok {
+						row[col] = o.UID
+					}
+				}
+				switch t.Name {
+				case "Business":
+					if len(strings.TrimSpace(o.Name)) > 0 {
+						row["Name"] = strings.TrimSpace(o.Name)
+					}
+				case "RentableMarketRate":
+					row["DtStart"] = cloneShiftDate(row["DtStart"], o.ShiftDays)
+					row["DtStop"] = cloneShiftDate(row["DtStop"], o.ShiftDays)
+				}
+				if _, err = l.load(t, row); err != nil {
+					return err
+				}
+			}
+		}
+		return l.finish()
+	})
+	if err != nil {
+		return res, fmt.Errorf("%s: %s", funcname, err.Error())
+	}
+	RRdb.BUDlist = BuildBusinessDesignationMap()
+	ClearWebhookCache()
+	return res, nil
+}
@@ -0,0 +1,51 @@
+package rlib
+
+import "testing"
+
+// The cloned tables must be loaded in archive order, and everything they
+// refer to must be cloned too, except the rental agreement and payor of GL
+// accounts, which are excluded by the Where condition.
+func TestCloneTables(t *testing.T) {
+	cloned := map[string]bool{}
+	last := -1
+	for i := 0; i < len(cloneTables); i++ {
+		j := archiveTableIndex(cloneTables[i].Name)
+		if j < 0 {
+			t.Errorf("%s is not an archived table", cloneTables[i].Name)
+			continue
+		}
+		if j <= last {
+			t.Errorf("%s is out of order", cloneTables[i].Name)
+		}
+		last = j
+		cloned[cloneTables[i].Name] = true
+	}
+	skip := map[string]bool{"GLAccount.RAID": true, "GLAccount.TCID": true}
+	for i := 0; i < len(cloneTables); i++ {
+		a := &archiveTables[archiveTableIndex(cloneTables[i].Name)]
+		for col, ref := range a.Refs {
+			if !cloned[ref] && !skip[a.Name+"."+col] {
+				t.Errorf("%s.%s refers to %s, which is not cloned", a.Name, col, ref)
+			}
+		}
+	}
+}
+
+func TestCloneShiftDate(t *testing.T) {
+	var m = []struct {
+		d      string
+		days   int
+		expect string
+	}{
+		{"2017-01-01 00:00:00", 365, "2018-01-01 00:00:00"},
+		{"2017-03-01 00:00:00", -1, "2017-02-28 00:00:00"},
+		{"1970-01-01 00:00:00", 30, "1970-01-01 00:00:00"}, // TIME0
+		{"9999-12-31 23:59:59", 30, "9999-12-31 23:59:59"}, // no end
+		{"2017-01-01 00:00:00", 0, "2017-01-01 00:00:00"},
+	}
+	for i := 0; i < len(m); i++ {
+		if got := cloneShiftDate(m[i].d, m[i].days); got != m[i].expect {
+			t.Errorf("cloneShiftDate(%s, %d): expect %s, got %v", m[i].d, m[i].days, m[i].expect, got)
+		}
+	}
+}
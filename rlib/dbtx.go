@@ -0,0 +1,112 @@
+package rlib
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// RRTx is a transaction scoped handle to the RentRoll database. The rlib
+// functions with a Tx suffix accept one and run their statements within the
+// transaction using Tx-aware copies of the RRprepSQL prepared statements.
+// Passing a nil *RRTx to any of them runs the statements directly against
+// the database, just like the function without the suffix.
+//
+// An RRTx must only be used by one goroutine. Reads of reference data that
+// a unit of work does not change (business, account rules, rentable types,
+// and so forth) are still made outside the transaction.
+type RRTx struct {
+	Tx    *sql.Tx                 // the underlying transaction
+	stmts map[*sql.Stmt]*sql.Stmt // RRprepSQL statement -> transaction specific statement
+}
+
+// BeginTx starts a new transaction on the RentRoll database
+func BeginTx() (*RRTx, error) {
+	tx, err := RRdb.Dbrr.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &RRTx{Tx: tx, stmts: map[*sql.Stmt]*sql.Stmt{}}, nil
+}
+
+// Stmt returns the version of prepared statement s to use within t.  If t is
+// nil, s is returned.  The transaction specific statements are closed when
+// the transaction is committed or rolled back.
+func (t *RRTx) Stmt(s *sql.Stmt) *sql.Stmt {
+	if t == nil {
+		return s
+	}
+	ts, ok := t.stmts[s]
+	if !ok {
+		ts = t.Tx.Stmt(s)
+		t.stmts[s] = ts
+	}
+	return ts
+}
+
+// Query executes a query that returns rows within t, or directly against the
+// database if t is nil
+func (t *RRTx) Query(q string, args ...interface{}) (*sql.Rows, error) {
+	if t == nil {
+		return RRdb.Dbrr.Query(q, args...)
+	}
+	return t.Tx.Query(q, args...)
+}
+
+// QueryRow executes a query that returns at most one row within t, or
+// directly against the database if t is nil
+func (t *RRTx) QueryRow(q string, args ...interface{}) *sql.Row {
+	if t == nil {
+		return RRdb.Dbrr.QueryRow(q, args...)
+	}
+	return t.Tx.QueryRow(q, args...)
+}
+
+// Exec executes a query that does not return rows within t, or directly
+// against the database if t is nil
+func (t *RRTx) Exec(q string, args ...interface{}) (sql.Result, error) {
+	if t == nil {
+		return RRdb.Dbrr.Exec(q, args...)
+	}
+	return t.Tx.Exec(q, args...)
+}
+
+// Commit commits the transaction
+func (t *RRTx) Commit() error {
+	return t.Tx.Commit()
+}
+
+// Rollback aborts the transaction
+func (t *RRTx) Rollback() error {
+	return t.Tx.Rollback()
+}
+
+// RunInTx runs f as a single unit of work. If f returns an error or panics
+// the transaction is rolled back, otherwise it is committed.
+//
+// INPUTS
+//    f = the unit of work, it must do all of its database updates through tx
+//
+// RETURNS
+//    the error returned by f, or any error beginning or committing the
+//    transaction
+//-----------------------------------------------------------------------------
+func RunInTx(f func(tx *RRTx) error) (err error) {
+	funcname := "RunInTx"
+	tx, err := BeginTx()
+	if err != nil {
+		return fmt.Errorf("%s: could not begin transaction: %s", funcname, err.Error())
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+	if err = f(tx); err != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			Ulog("%s: error rolling back transaction: %s\n", funcname, rerr.Error())
+		}
+		return err
+	}
+	return tx.Commit()
+}
@@ -44,6 +44,35 @@ const (
 	RCPTFULLYALLOCATED   = 2
 	RCPTREVERSED         = 4
 
+	// BILLUNPAID et al are flags for vendor bills
+	BILLUNPAID      = 0
+	BILLPARTIALPAID = 1
+	BILLFULLYPAID   = 2
+	BILLREVERSED    = 4
+
+	// VENDOR1099 et al are flags for vendors
+	VENDOR1099     = 1 << 0 // payments to this vendor are reported on a 1099
+	VENDORINACTIVE = 1 << 1 // vendor is no longer used
+
+	// WEBHOOKINACTIVE is a flag for webhooks
+	WEBHOOKINACTIVE = 1 << 0 // do not deliver events to this webhook
+
+	// WHDPENDING et al are the states of a WebhookDelivery
+	WHDPENDING   = 0 // not yet delivered
+	WHDDELIVERED = 1 // delivered successfully
+	WHDFAILED    = 2 // all attempts failed
+
+	// REPORTSCHEDINACTIVE is a flag for report schedules
+	REPORTSCHEDINACTIVE = 1 << 0 // do not run this schedule
+
+	// RSDATEPREVMONTH et al are the date range rules of a ReportSchedule. The
+	// range is computed from the date of the run.
+	RSDATEPREVMONTH   = 0 // the month before the run date
+	RSDATEMONTHTODATE = 1 // the first of the month up to the run date
+	RSDATECURMONTH    = 2 // the whole month of the run date
+	RSDATEPREVWEEK    = 3 // Monday through Sunday of the week before the run date
+	RSDATELAST        = 3 // this should be maintained as matching the highest index value in the group
+
 	// RTACTIVE et all are flags for rentableTypes
 	RTACTIVE   = 0
 	RTINACTIVE = 1
@@ -133,6 +162,8 @@ const (
 	JNLTYPERCPT = 2 // record is the result of a Receipt
 	JNLTYPEEXP  = 3 // record is the result of an Expense
 	JNLTYPEXFER = 4 // funds transfer between accounts
+	JNLTYPEBILL = 5 // record is the result of a vendor Bill
+	JNLTYPEBPMT = 6 // record is the result of a Bill payment
 
 	JOURNALTYPEASMID  = 1
 	JOURNALTYPERCPTID = 2
@@ -715,6 +746,98 @@ type Expense struct {
 	CreateBy    int64
 }
 
+// Vendor is a person or company that the business pays
+type Vendor struct {
+	VENDID      int64     // unique id for this vendor
+	BID         int64     // which business
+	Name        string    // vendor name, as it appears on checks
+	Address     string    // mailing address
+	Address2    string    // second line of mailing address
+	City        string    // city
+	State       string    // state
+	PostalCode  string    // zip
+	Country     string    // country
+	Phone       string    // main phone number
+	Email       string    // main email address
+	TaxID       string    // EIN or SSN, needed for 1099 reporting
+	DefaultLID  int64     // default expense GL account for this vendor's bills
+	FLAGS       uint64    // 1<<0 = 1099 vendor, 1<<1 = inactive
+	LastModTime time.Time // when was this record last written
+	LastModBy   int64     // employee UID (from phonebook) that modified it
+	CreateTS    time.Time // when was this record created
+	CreateBy    int64     // employee UID (from phonebook) that created it
+}
+
+// DirectoryPerson is an employee in the local directory, the directory used
+// when rentroll runs without the phonebook
+type DirectoryPerson struct {
+	UID           int64     // employee id, used in LastModBy, CreateBy, CSAgent, ...
+	FirstName     string    // first name
+	LastName      string    // last name
+	PreferredName string    // name the person goes by, if not FirstName
+	Email         string    // email address
+	Phone         string    // phone number
+	FLAGS         uint64    // 1<<0 = inactive
+	LastModTime   time.Time // when was this record last written
+	LastModBy     int64     // employee UID that modified it
+	CreateTS      time.Time // when was this record created
+	CreateBy      int64     // employee UID that created it
+}
+
+// Bill is an amount owed to a Vendor. The Amount is the sum of its BillItems.
+type Bill struct {
+	BILLID      int64      // unique id for this bill
+	RPBILLID    int64      // reversal parent Bill
+	BID         int64      // which business
+	VENDID      int64      // who sent the bill
+	APLID       int64      // the Accounts Payable GL account credited by this bill
+	Dt          time.Time  // bill date
+	DtDue       time.Time  // when payment is due
+	Amount      float64    // total of all the bill items
+	DocNo       string     // the vendor's invoice number
+	FLAGS       uint64     // bits 0-1: 0 = unpaid, 1 = partially paid, 2 = fully paid; bit 2 = reversed
+	Comment     string     // any notes on this bill
+	LastModTime time.Time  // when was this record last written
+	LastModBy   int64      // employee UID (from phonebook) that modified it
+	CreateTS    time.Time  // when was this record created
+	CreateBy    int64      // employee UID (from phonebook) that created it
+	BI          []BillItem // the line items of this bill
+}
+
+// BillItem is a line item of a Bill. It is posted to the expense account LID.
+type BillItem struct {
+	BIID        int64     // unique id for this line item
+	BILLID      int64     // the bill this item belongs to
+	BID         int64     // which business
+	LID         int64     // the expense GL account debited
+	RID         int64     // optional Rentable this item applies to
+	Amount      float64   // amount of this item
+	Description string    // what was purchased
+	LastModTime time.Time // when was this record last written
+	LastModBy   int64     // employee UID (from phonebook) that modified it
+	CreateTS    time.Time // when was this record created
+	CreateBy    int64     // employee UID (from phonebook) that created it
+}
+
+// BillPayment is a payment made from a Depository against a Bill
+type BillPayment struct {
+	BPID        int64     // unique id for this bill payment
+	RPBPID      int64     // reversal parent BillPayment
+	BID         int64     // which business
+	BILLID      int64     // the bill being paid
+	VENDID      int64     // who was paid, used for 1099 totals
+	DEPID       int64     // the Depository the funds came from
+	Dt          time.Time // payment date
+	Amount      float64   // amount paid
+	DocNo       string    // check number, ACH trace number, etc.
+	FLAGS       uint64    // bit 2 = reversed
+	Comment     string    // any notes on this payment
+	LastModTime time.Time // when was this record last written
+	LastModBy   int64     // employee UID (from phonebook) that modified it
+	CreateTS    time.Time // when was this record created
+	CreateBy    int64     // employee UID (from phonebook) that created it
+}
+
 // AR is the table that defines the AcctRules for Assessments, Expenses and Receipts
 type AR struct {
 	ARID        int64
@@ -752,6 +875,27 @@ type SubAR struct {
 	CreateBy    int64     // employee UID (from phonebook) that created it
 }
 
+// BusinessGroup is a named set of businesses, such as a region or an owner's
+// portfolio. It is used to select businesses for consolidated reports.
+type BusinessGroup struct {
+	BGID        int64     // unique id for this group
+	Name        string    // unique name of the group
+	GroupType   string    // region, owner, ...
+	Description string    // what the group is for
+	LastModTime time.Time // when was this record last written
+	LastModBy   int64     // employee UID (from phonebook) that modified it
+	CreateTS    time.Time // when was this record created
+	CreateBy    int64     // employee UID (from phonebook) that created it
+}
+
+// BusinessGroupMember associates a business with a BusinessGroup
+type BusinessGroupMember struct {
+	BGID     int64     // which group
+	BID      int64     // member business
+	CreateTS time.Time // when was this record created
+	CreateBy int64     // employee UID (from phonebook) that created it
+}
+
 // Business is the set of attributes describing a rental or hotel Business
 type Business struct {
 	BID                   int64
@@ -1140,6 +1284,127 @@ type JournalMarker struct {
 	CreateBy    int64     // employee UID (from phonebook) that created it
 }
 
+// DirtyRange is a period of time over which the vacancy of a rentable must be
+// recomputed, because something it depends on has changed
+type DirtyRange struct {
+	DRID     int64     // unique id for this range
+	BID      int64     // which business
+	RID      int64     // the rentable
+	DtStart  time.Time // start of the range
+	DtStop   time.Time // end of the range
+	CreateTS time.Time // when was this record created
+}
+
+// GLExport records an export of Journal entries to an external accounting system
+type GLExport struct {
+	GLEXID       int64     // unique id for this export
+	BID          int64     // which business
+	Format       string    // iif, csv
+	DtStart      time.Time // start of the exported range
+	DtStop       time.Time // end of the exported range (not inclusive)
+	JournalCount int64     // number of Journal entries exported
+	LastModTime  time.Time // when was this record last written
+	LastModBy    int64     // employee UID (from phonebook) that modified it
+	CreateTS     time.Time // when was this record created
+	CreateBy     int64     // employee UID (from phonebook) that created it
+}
+
+// GLExportJournal marks a Journal entry as having been included in a GLExport.
+// Type, ID, Dt and Amount are copied from the Journal entry. Rebuilding the
+// Journal gives its entries new JIDs, so an entry is matched with its export
+// by these values rather than by JID.
+type GLExportJournal struct {
+	GLEXID   int64     // the export
+	BID      int64     // which business
+	JID      int64     // the Journal entry that was exported
+	Type     int64     // Journal Type
+	ID       int64     // Journal ID: the ASMID, RCPTID, RID, ... that caused the entry
+	Dt       time.Time // Journal date
+	Amount   float64   // Journal amount
+	CreateTS time.Time // when was this record created
+	CreateBy int64     // employee UID (from phonebook) that created it
+}
+
+// Webhook describes a url to which events are delivered
+type Webhook struct {
+	WHID        int64     // unique id for this webhook
+	BID         int64     // which business
+	URL         string    // where events are POSTed
+	Secret      string    // key used to sign the body of each POST
+	EventTypes  string    // comma separated list of event types, receipt.* matches all receipt events, empty or * means all
+	FLAGS       uint64    // bit 0 = inactive
+	LastModTime time.Time // when was this record last written
+	LastModBy   int64     // employee UID (from phonebook) that modified it
+	CreateTS    time.Time // when was this record created
+	CreateBy    int64     // employee UID (from phonebook) that created it
+}
+
+// OutboxEvent describes a change that is to be delivered to Webhooks
+type OutboxEvent struct {
+	EVID      int64     // unique id for this event
+	BID       int64     // which business
+	EventType string    // receipt.created, assessment.reversed, ...
+	ObjID     int64     // id of the object the event describes
+	Payload   string    // json representation of the object
+	CreateTS  time.Time // when was this record created
+	CreateBy  int64     // employee UID (from phonebook) that created it
+}
+
+// WebhookDelivery tracks the delivery of an OutboxEvent to a Webhook
+type WebhookDelivery struct {
+	WHDID       int64     // unique id for this delivery
+	WHID        int64     // the webhook
+	EVID        int64     // the event being delivered
+	BID         int64     // which business
+	Status      int64     // WHDPENDING, WHDDELIVERED, WHDFAILED
+	Attempts    int64     // number of attempts made so far
+	NextAttempt time.Time // when to try next
+	LastAttempt time.Time // when the last attempt was made
+	HTTPStatus  int64     // status code returned by the last attempt
+	LastError   string    // error from the last attempt
+	LastModTime time.Time // when was this record last written
+	CreateTS    time.Time // when was this record created
+}
+
+// ReportSchedule describes a report that is generated periodically and
+// written to a directory and optionally emailed
+type ReportSchedule struct {
+	RSID         int64     // unique id for this schedule
+	BID          int64     // which business
+	Name         string    // name of the schedule
+	Report       string    // report name as used by the web service report handler, for example RPTrr
+	Params       string    // additional report parameters, url encoded, for example tcid=5&internal=1
+	DateRule     int64     // RSDATEPREVMONTH, RSDATEMONTHTODATE, ...
+	OutputFormat int64     // report output format, as in the rof web service parameter
+	Cycle        int64     // how often it runs, CYCLEDAILY, CYCLEWEEKLY, ...  CYCLENORECUR runs once
+	NextRun      time.Time // when it runs next
+	LastRun      time.Time // when it last ran
+	LastError    string    // error from the last run, empty if it succeeded
+	Recipients   string    // comma separated email addresses the output is sent to
+	Directory    string    // subdirectory of RRConfig.ReportArchive where output files are written
+	FLAGS        uint64    // bit 0 = inactive
+	LastModTime  time.Time // when was this record last written
+	LastModBy    int64     // employee UID (from phonebook) that modified it
+	CreateTS     time.Time // when was this record created
+	CreateBy     int64     // employee UID (from phonebook) that created it
+}
+
+// RentRollSnapshot is the rent roll of a business for a period as it was
+// computed when the snapshot was taken. Payload holds the rows, so the rent
+// roll can be shown later exactly as it was, even after back-dated changes.
+type RentRollSnapshot struct {
+	RRSID         int64     // unique id for this snapshot
+	BID           int64     // which business
+	DtStart       time.Time // start of the rent roll period
+	DtStop        time.Time // end of the rent roll period, not included
+	PeriodGSR     float64   // grand total of the period GSR
+	EndReceivable float64   // grand total of the receivables at DtStop
+	EndSecDep     float64   // grand total of the security deposits at DtStop
+	Payload       string    // json list of the RentRollStaticInfo rows
+	CreateTS      time.Time // when was this record created
+	CreateBy      int64     // employee UID (from phonebook) that created it, 0 for the month-end worker
+}
+
 // LedgerEntry is the structure for LedgerEntry attributes
 type LedgerEntry struct {
 	LEID        int64
@@ -1311,6 +1576,7 @@ type RRprepSQL struct {
 	GetAssessmentType                       *sql.Stmt
 	GetAssessmentTypeByName                 *sql.Stmt
 	GetBuilding                             *sql.Stmt
+	GetBuildingsByBusiness                  *sql.Stmt
 	GetBusiness                             *sql.Stmt
 	GetBusinessByDesignation                *sql.Stmt
 	GetCustomAttribute                      *sql.Stmt
@@ -1399,6 +1665,7 @@ type RRprepSQL struct {
 	GetRentableTypeDown                     *sql.Stmt
 	GetRentableTypeRef                      *sql.Stmt
 	GetRentableTypeRefsByRange              *sql.Stmt
+	GetRentableTypeRefsByRTID               *sql.Stmt
 	GetRentableUser                         *sql.Stmt
 	GetRentableUserByRBT                    *sql.Stmt
 	GetRentableUsersInRange                 *sql.Stmt
@@ -1424,6 +1691,7 @@ type RRprepSQL struct {
 	GetTransactantTypeDown                  *sql.Stmt
 	GetUnallocatedReceipts                  *sql.Stmt
 	GetUnallocatedReceiptsByPayor           *sql.Stmt
+	GetUnallocatedReceiptsCount             *sql.Stmt
 	GetUnitAssessments                      *sql.Stmt
 	GetUnpaidAssessmentsByRAID              *sql.Stmt
 	GetUser                                 *sql.Stmt
@@ -1563,6 +1831,83 @@ type RRprepSQL struct {
 	DeleteSubARs                            *sql.Stmt
 	GetJournalAllocationsByASMandRCPTID     *sql.Stmt
 	GetJournalByTypeAndID                   *sql.Stmt
+	GetBusinessGroup                        *sql.Stmt
+	GetBusinessGroupByName                  *sql.Stmt
+	GetAllBusinessGroups                    *sql.Stmt
+	InsertBusinessGroup                     *sql.Stmt
+	UpdateBusinessGroup                     *sql.Stmt
+	DeleteBusinessGroup                     *sql.Stmt
+	GetBusinessGroupMembers                 *sql.Stmt
+	InsertBusinessGroupMember               *sql.Stmt
+	DeleteBusinessGroupMembers              *sql.Stmt
+	GetVendor                               *sql.Stmt
+	GetVendorByName                         *sql.Stmt
+	GetAllVendors                           *sql.Stmt
+	InsertVendor                            *sql.Stmt
+	UpdateVendor                            *sql.Stmt
+	DeleteVendor                            *sql.Stmt
+	GetDirtyRanges                          *sql.Stmt
+	InsertDirtyRange                        *sql.Stmt
+	DeleteDirtyRange                        *sql.Stmt
+	GetJournalMarkerCovering                *sql.Stmt
+	GetUnpostedJournalsInRange              *sql.Stmt
+	GetVacancyJournalsInRange               *sql.Stmt
+	GetDirectoryPerson                      *sql.Stmt
+	GetAllDirectoryPersons                  *sql.Stmt
+	InsertDirectoryPerson                   *sql.Stmt
+	UpdateDirectoryPerson                   *sql.Stmt
+	DeleteDirectoryPerson                   *sql.Stmt
+	GetBill                                 *sql.Stmt
+	GetBillsByVendor                        *sql.Stmt
+	GetBillsThroughDate                     *sql.Stmt
+	InsertBill                              *sql.Stmt
+	UpdateBill                              *sql.Stmt
+	DeleteBill                              *sql.Stmt
+	GetBillItems                            *sql.Stmt
+	InsertBillItem                          *sql.Stmt
+	UpdateBillItem                          *sql.Stmt
+	DeleteBillItem                          *sql.Stmt
+	DeleteBillItems                         *sql.Stmt
+	GetBillPayment                          *sql.Stmt
+	GetBillPayments                         *sql.Stmt
+	GetBillPaymentsThroughDate              *sql.Stmt
+	GetVendorPaymentTotals                  *sql.Stmt
+	InsertBillPayment                       *sql.Stmt
+	UpdateBillPayment                       *sql.Stmt
+	DeleteBillPayment                       *sql.Stmt
+	GetGLExport                             *sql.Stmt
+	GetGLExports                            *sql.Stmt
+	InsertGLExport                          *sql.Stmt
+	DeleteGLExport                          *sql.Stmt
+	GetGLExportedJournalsInRange            *sql.Stmt
+	InsertGLExportJournal                   *sql.Stmt
+	DeleteGLExportJournals                  *sql.Stmt
+	LockBusinessForGLExport                 *sql.Stmt
+	GetWebhook                              *sql.Stmt
+	GetWebhooks                             *sql.Stmt
+	GetActiveWebhooks                       *sql.Stmt
+	InsertWebhook                           *sql.Stmt
+	UpdateWebhook                           *sql.Stmt
+	DeleteWebhook                           *sql.Stmt
+	GetOutboxEvent                          *sql.Stmt
+	InsertOutboxEvent                       *sql.Stmt
+	GetWebhookDeliveries                    *sql.Stmt
+	GetPendingWebhookDeliveries             *sql.Stmt
+	InsertWebhookDelivery                   *sql.Stmt
+	UpdateWebhookDelivery                   *sql.Stmt
+	DeleteWebhookDeliveries                 *sql.Stmt
+	GetReportSchedule                       *sql.Stmt
+	GetReportSchedules                      *sql.Stmt
+	GetDueReportSchedules                   *sql.Stmt
+	InsertReportSchedule                    *sql.Stmt
+	UpdateReportSchedule                    *sql.Stmt
+	DeleteReportSchedule                    *sql.Stmt
+	GetRentRollSnapshot                     *sql.Stmt
+	GetRentRollSnapshotByPeriod             *sql.Stmt
+	GetRentRollSnapshotBefore               *sql.Stmt
+	GetRentRollSnapshots                    *sql.Stmt
+	InsertRentRollSnapshot                  *sql.Stmt
+	DeleteRentRollSnapshot                  *sql.Stmt
 }
 
 // AllTables is an array of strings containing the names of every table in the RentRoll database
@@ -1571,7 +1916,11 @@ var AllTables = []string{
 	"AssessmentTax",
 	"Assessments",
 	"AvailabilityTypes",
+	"Bill",
+	"BillItem",
+	"BillPayment",
 	"Building",
+	"BusinessGroupMember",
 	"Business",
 	"BusinessAssessments",
 	"BusinessPaymentTypes",
@@ -1583,8 +1932,11 @@ var AllTables = []string{
 	"DepositMethod",
 	"DepositPart",
 	"Depository",
+	"DirtyRange",
 	"Expense",
 	"GLAccount",
+	"GLExport",
+	"GLExportJournal",
 	"Invoice",
 	"InvoiceAssessment",
 	"InvoicePayor",
@@ -1602,6 +1954,7 @@ var AllTables = []string{
 	"NoteList",
 	"NoteType",
 	"Notes",
+	"OutboxEvent",
 	"OtherDeliverables",
 	"PaymentType",
 	"Payor",
@@ -1613,6 +1966,7 @@ var AllTables = []string{
 	"RatePlanRefSPRate",
 	"Receipt",
 	"ReceiptAllocation",
+	"RentRollSnapshot",
 	"Rentable",
 	"RentableMarketRate",
 	"RentableSpecialty",
@@ -1628,6 +1982,7 @@ var AllTables = []string{
 	"RentalAgreementRentables",
 	"RentalAgreementTax",
 	"RentalAgreementTemplate",
+	"ReportSchedule",
 	"SLString",
 	"StringList",
 	"SubAR",
@@ -1636,6 +1991,9 @@ var AllTables = []string{
 	"Transactant",
 	"User",
 	"Vehicle",
+	"Vendor",
+	"Webhook",
+	"WebhookDelivery",
 }
 
 // DeleteBusinessFromDB deletes information from all tables if it is part of the supplied BID.
@@ -1661,6 +2019,7 @@ type PBprepSQL struct {
 	GetCompanyByDesignation      *sql.Stmt
 	GetCompany                   *sql.Stmt
 	GetBusinessUnitByDesignation *sql.Stmt
+	GetPerson                    *sql.Stmt
 }
 
 // BusinessTypeLists is a struct holding a collection of Types associated with a business
@@ -1677,6 +2036,7 @@ type BusinessTypeLists struct {
 var RRdb struct {
 	Prepstmt RRprepSQL
 	PBsql    PBprepSQL
+	Dir      Directory                    // where employees, business units and companies are looked up
 	Dbdir    *sql.DB                      // phonebook db
 	Dbrr     *sql.DB                      //rentroll db
 	BizTypes map[int64]*BusinessTypeLists // details about a business
@@ -1700,15 +2060,24 @@ func BuildBusinessDesignationMap() map[string]int64 {
 	return sl
 }
 
-// InitDBHelpers initializes the db infrastructure
+// InitDBHelpers initializes the db infrastructure. The directory is the one
+// named in config.json, or the local directory if there is no phonebook
+// database, dbdir is nil.
 func InitDBHelpers(dbrr, dbdir *sql.DB) {
 	RRdb.Dbdir = dbdir
 	RRdb.Dbrr = dbrr
 	RRdb.BizTypes = make(map[int64]*BusinessTypeLists)
 	RRdb.DBFields = map[string]string{}
 	buildPreparedStatements()
-	buildPBPreparedStatements()
+	RRdb.Dir, _ = NewDirectory(RRConfig.Directory) // RRReadConfig has checked it
+	if dbdir == nil {
+		RRdb.Dir = LocalDirectory{}
+	}
+	if RRdb.Dir.Name() == DirectoryPhonebook {
+		buildPBPreparedStatements()
+	}
 	InitCaches()
+	ClearWebhookCache() // the cached webhooks may be from another database
 
 	RRdb.BUDlist = BuildBusinessDesignationMap()
 
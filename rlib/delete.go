@@ -20,6 +20,36 @@ func DeleteAssessment(asmid int64) error {
 	return err
 }
 
+// DeleteBusinessGroup deletes the BusinessGroup with the supplied BGID along
+// with its membership records
+func DeleteBusinessGroup(id int64) error {
+	if err := DeleteBusinessGroupMembers(id); err != nil {
+		return err
+	}
+	_, err := RRdb.Prepstmt.DeleteBusinessGroup.Exec(id)
+	if err != nil {
+		Ulog("Error deleting BusinessGroup for BGID = %d, error: %v\n", id, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteBusinessGroupMembers removes all businesses from the group with the supplied BGID
+func DeleteBusinessGroupMembers(id int64) error {
+	return DeleteBusinessGroupMembersTx(nil, id)
+}
+
+// DeleteBusinessGroupMembersTx is DeleteBusinessGroupMembers performed within
+// transaction tx. If tx is nil the database is used directly.
+func DeleteBusinessGroupMembersTx(tx *RRTx, id int64) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.DeleteBusinessGroupMembers).Exec(id)
+	if err != nil {
+		Ulog("Error deleting BusinessGroupMembers for BGID = %d, error: %v\n", id, err)
+		return err
+	}
+	return nil
+}
+
 // DeleteCustomAttribute deletes CustomAttribute records with the supplied id
 func DeleteCustomAttribute(id int64) error {
 	_, err := RRdb.Prepstmt.DeleteCustomAttribute.Exec(id)
@@ -77,7 +107,13 @@ func DeleteDepositMethod(id int64) {
 
 // DeleteDepositPart deletes ALL the DepositParts associated with the supplied id
 func DeleteDepositPart(id int64) error {
-	_, err := RRdb.Prepstmt.DeleteDepositPart.Exec(id)
+	return DeleteDepositPartTx(nil, id)
+}
+
+// DeleteDepositPartTx is DeleteDepositPart performed within transaction tx. If
+// tx is nil the database is used directly.
+func DeleteDepositPartTx(tx *RRTx, id int64) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.DeleteDepositPart).Exec(id)
 	if err != nil {
 		Ulog("Error deleting DepositParts where DID = %d, error: %v\n", id, err)
 	}
@@ -94,6 +130,120 @@ func DeleteExpense(id int64) error {
 	return nil
 }
 
+// DeleteGLExport deletes the GLExport with the supplied GLEXID. The Journal
+// entries it covered are released so that they will be exported again.
+func DeleteGLExport(id int64) error {
+	_, err := RRdb.Prepstmt.DeleteGLExportJournals.Exec(id)
+	if err != nil {
+		Ulog("Error deleting GLExportJournals for GLEXID = %d, error: %v\n", id, err)
+		return err
+	}
+	_, err = RRdb.Prepstmt.DeleteGLExport.Exec(id)
+	if err != nil {
+		Ulog("Error deleting GLExport for GLEXID = %d, error: %v\n", id, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteWebhook deletes the Webhook with the supplied WHID along with its
+// delivery history
+func DeleteWebhook(id int64) error {
+	_, err := RRdb.Prepstmt.DeleteWebhookDeliveries.Exec(id)
+	if err != nil {
+		Ulog("Error deleting WebhookDeliveries for WHID = %d, error: %v\n", id, err)
+		return err
+	}
+	_, err = RRdb.Prepstmt.DeleteWebhook.Exec(id)
+	ClearWebhookCache()
+	if err != nil {
+		Ulog("Error deleting Webhook for WHID = %d, error: %v\n", id, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteReportSchedule deletes the ReportSchedule with the supplied RSID
+func DeleteReportSchedule(id int64) error {
+	_, err := RRdb.Prepstmt.DeleteReportSchedule.Exec(id)
+	if err != nil {
+		Ulog("Error deleting ReportSchedule for RSID = %d, error: %v\n", id, err)
+	}
+	return err
+}
+
+// DeleteRentRollSnapshot deletes the RentRollSnapshot with the supplied RRSID
+func DeleteRentRollSnapshot(id int64) error {
+	_, err := RRdb.Prepstmt.DeleteRentRollSnapshot.Exec(id)
+	if err != nil {
+		Ulog("Error deleting RentRollSnapshot for RRSID = %d, error: %v\n", id, err)
+	}
+	return err
+}
+
+// DeleteVendor deletes the Vendor record with the supplied VENDID
+func DeleteVendor(id int64) error {
+	_, err := RRdb.Prepstmt.DeleteVendor.Exec(id)
+	if err != nil {
+		Ulog("Error deleting Vendor for VENDID = %d, error: %v\n", id, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteDirectoryPerson deletes the DirectoryPerson record with the supplied UID
+func DeleteDirectoryPerson(uid int64) error {
+	_, err := RRdb.Prepstmt.DeleteDirectoryPerson.Exec(uid)
+	if err != nil {
+		Ulog("Error deleting DirectoryPerson for UID = %d, error: %v\n", uid, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteBill deletes the Bill with the supplied BILLID along with all of its BillItems
+func DeleteBill(id int64) error {
+	if err := DeleteBillItems(id); err != nil {
+		return err
+	}
+	_, err := RRdb.Prepstmt.DeleteBill.Exec(id)
+	if err != nil {
+		Ulog("Error deleting Bill for BILLID = %d, error: %v\n", id, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteBillItem deletes the BillItem record with the supplied BIID
+func DeleteBillItem(id int64) error {
+	_, err := RRdb.Prepstmt.DeleteBillItem.Exec(id)
+	if err != nil {
+		Ulog("Error deleting BillItem for BIID = %d, error: %v\n", id, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteBillItems deletes the BillItem records with the supplied BILLID
+func DeleteBillItems(id int64) error {
+	_, err := RRdb.Prepstmt.DeleteBillItems.Exec(id)
+	if err != nil {
+		Ulog("Error deleting BillItem for BILLID = %d, error: %v\n", id, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteBillPayment deletes the BillPayment record with the supplied BPID
+func DeleteBillPayment(id int64) error {
+	_, err := RRdb.Prepstmt.DeleteBillPayment.Exec(id)
+	if err != nil {
+		Ulog("Error deleting BillPayment for BPID = %d, error: %v\n", id, err)
+		return err
+	}
+	return nil
+}
+
 // DeleteInvoice deletes the Invoice associated with the supplied id
 // For convenience, this routine calls DeleteInvoiceAssessments. The InvoiceAssessments are
 // tightly bound to the Invoice. If a Invoice is deleted, the parts should be deleted as well.
@@ -126,18 +276,47 @@ func DeleteJournalAllocation(id int64) {
 
 // DeleteJournalAllocations deletes the allocation records associated with the supplied jid
 func DeleteJournalAllocations(jid int64) {
-	_, err := RRdb.Prepstmt.DeleteJournalAllocations.Exec(jid)
+	DeleteJournalAllocationsTx(nil, jid)
+}
+
+// DeleteJournalAllocationsTx is DeleteJournalAllocations performed within
+// transaction tx. If tx is nil the database is used directly.
+func DeleteJournalAllocationsTx(tx *RRTx, jid int64) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.DeleteJournalAllocations).Exec(jid)
 	if err != nil {
 		Ulog("Error deleting Journal allocations for JID = %d, error: %v\n", jid, err)
 	}
+	return err
 }
 
 // DeleteJournal deletes the Journal record with the supplied jid
 func DeleteJournal(jid int64) {
-	_, err := RRdb.Prepstmt.DeleteJournal.Exec(jid)
+	DeleteJournalTx(nil, jid)
+}
+
+// DeleteJournalTx is DeleteJournal performed within transaction tx. If tx is
+// nil the database is used directly.
+func DeleteJournalTx(tx *RRTx, jid int64) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.DeleteJournal).Exec(jid)
 	if err != nil {
 		Ulog("Error deleting Journal entry for JID = %d, error: %v\n", jid, err)
 	}
+	return err
+}
+
+// DeleteDirtyRange deletes the DirtyRange record with the supplied drid
+func DeleteDirtyRange(drid int64) error {
+	return DeleteDirtyRangeTx(nil, drid)
+}
+
+// DeleteDirtyRangeTx is DeleteDirtyRange performed within transaction tx. If
+// tx is nil the database is used directly.
+func DeleteDirtyRangeTx(tx *RRTx, drid int64) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.DeleteDirtyRange).Exec(drid)
+	if err != nil {
+		Ulog("Error deleting DirtyRange for DRID = %d, error: %v\n", drid, err)
+	}
+	return err
 }
 
 // DeleteJournalMarker deletes the JournalMarker record for the supplied jmid
@@ -150,7 +329,13 @@ func DeleteJournalMarker(jmid int64) {
 
 // DeleteLedgerEntry deletes the LedgerEntry record with the supplied id
 func DeleteLedgerEntry(id int64) error {
-	_, err := RRdb.Prepstmt.DeleteLedgerEntry.Exec(id)
+	return DeleteLedgerEntryTx(nil, id)
+}
+
+// DeleteLedgerEntryTx is DeleteLedgerEntry performed within transaction tx.
+// If tx is nil the database is used directly.
+func DeleteLedgerEntryTx(tx *RRTx, id int64) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.DeleteLedgerEntry).Exec(id)
 	if err != nil {
 		Ulog("Error deleting LedgerEntry for LEID = %d, error: %v\n", id, err)
 	}
@@ -320,7 +505,21 @@ func DeleteRentableTypeRefWithRTID(rtid int64) error {
 
 // DeleteRentableTypeRef deletes RentableTypeRef records with the supplied rtrid
 func DeleteRentableTypeRef(rtrid int64) error {
-	_, err := RRdb.Prepstmt.DeleteRentableTypeRef.Exec(rtrid)
+	return RunInTx(func(tx *RRTx) error {
+		return DeleteRentableTypeRefTx(tx, rtrid)
+	})
+}
+
+// DeleteRentableTypeRefTx is DeleteRentableTypeRef performed within
+// transaction tx. The vacancy of the rentable is marked dirty in tx. If tx is
+// nil the database is used directly.
+func DeleteRentableTypeRefTx(tx *RRTx, rtrid int64) error {
+	if b, err := GetRentableTypeRefTx(tx, rtrid); err == nil {
+		if err = MarkDirtyTx(tx, b.BID, b.RID, &b.DtStart, &b.DtStop); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Stmt(RRdb.Prepstmt.DeleteRentableTypeRef).Exec(rtrid)
 	if err != nil {
 		Ulog("Error deleting RentableTypeRef with rtrid=%d\n", rtrid, err)
 	}
@@ -329,7 +528,22 @@ func DeleteRentableTypeRef(rtrid int64) error {
 
 // DeleteRentableMarketRateInstance deletes RentableMarketRate instance with given RMRID
 func DeleteRentableMarketRateInstance(rmrid int64) error {
-	_, err := RRdb.Prepstmt.DeleteRentableMarketRateInstance.Exec(rmrid)
+	return RunInTx(func(tx *RRTx) error {
+		return DeleteRentableMarketRateInstanceTx(tx, rmrid)
+	})
+}
+
+// DeleteRentableMarketRateInstanceTx is DeleteRentableMarketRateInstance
+// performed within transaction tx. The vacancy of every rentable of the
+// RentableType is marked dirty in tx for the period of the market rate. If
+// tx is nil the database is used directly.
+func DeleteRentableMarketRateInstanceTx(tx *RRTx, rmrid int64) error {
+	if b, err := GetRentableMarketRateInstanceTx(tx, rmrid); err == nil {
+		if err = MarkRentableTypeDirtyTx(tx, b.BID, b.RTID, &b.DtStart, &b.DtStop); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Stmt(RRdb.Prepstmt.DeleteRentableMarketRateInstance).Exec(rmrid)
 	if err != nil {
 		Ulog("Error deleting RentableMarketRate with rmrid=%d, error: %v\n", rmrid, err)
 	}
@@ -338,17 +552,48 @@ func DeleteRentableMarketRateInstance(rmrid int64) error {
 
 // DeleteRentableSpecialtyRef deletes RentableSpecialtyRef records with the supplied rid, dtstart and dtstop
 func DeleteRentableSpecialtyRef(rid int64, dtstart, dtstop *time.Time) error {
-	_, err := RRdb.Prepstmt.DeleteRentableSpecialtyRef.Exec(rid, dtstart, dtstop)
+	return RunInTx(func(tx *RRTx) error {
+		return DeleteRentableSpecialtyRefTx(tx, rid, dtstart, dtstop)
+	})
+}
+
+// DeleteRentableSpecialtyRefTx is DeleteRentableSpecialtyRef performed within
+// transaction tx. The vacancy of the rentable is marked dirty in tx. If tx is
+// nil the database is used directly.
+func DeleteRentableSpecialtyRefTx(tx *RRTx, rid int64, dtstart, dtstop *time.Time) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.DeleteRentableSpecialtyRef).Exec(rid, dtstart, dtstop)
 	if err != nil {
 		Ulog("Error deleting RentableSpecialtyRef with rid=%d, dtstart=%s, dtstop=%s, error: %v\n",
 			rid, dtstart.Format(RRDATEINPFMT), dtstop.Format(RRDATEINPFMT), err)
+		return err
 	}
-	return err
+	var r Rentable
+	if err = ReadRentable(tx.Stmt(RRdb.Prepstmt.GetRentable).QueryRow(rid), &r); err != nil {
+		if IsSQLNoResultsError(err) {
+			return nil // no rentable, no vacancy to recompute
+		}
+		return err
+	}
+	return MarkDirtyTx(tx, r.BID, rid, dtstart, dtstop)
 }
 
 // DeleteRentableStatus deletes RentableStatus records with the supplied rsid
 func DeleteRentableStatus(rsid int64) error {
-	_, err := RRdb.Prepstmt.DeleteRentableStatus.Exec(rsid)
+	return RunInTx(func(tx *RRTx) error {
+		return DeleteRentableStatusTx(tx, rsid)
+	})
+}
+
+// DeleteRentableStatusTx is DeleteRentableStatus performed within
+// transaction tx. The vacancy of the rentable is marked dirty in tx. If tx is
+// nil the database is used directly.
+func DeleteRentableStatusTx(tx *RRTx, rsid int64) error {
+	if b, err := GetRentableStatusTx(tx, rsid); err == nil {
+		if err = MarkDirtyTx(tx, b.BID, b.RID, &b.DtStart, &b.DtStop); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Stmt(RRdb.Prepstmt.DeleteRentableStatus).Exec(rsid)
 	if err != nil {
 		Ulog("Error deleting RentableStatus with rsid=%d\n", rsid, err)
 	}
@@ -393,7 +638,21 @@ func DeleteRentalAgreementPet(petid int64) error {
 
 // DeleteRentalAgreementRentable deletes the rentable with the specified id from the database
 func DeleteRentalAgreementRentable(id int64) error {
-	_, err := RRdb.Prepstmt.DeleteRentalAgreementRentable.Exec(id)
+	return RunInTx(func(tx *RRTx) error {
+		return DeleteRentalAgreementRentableTx(tx, id)
+	})
+}
+
+// DeleteRentalAgreementRentableTx is DeleteRentalAgreementRentable performed
+// within transaction tx. The vacancy of the rentable is marked dirty in tx.
+// If tx is nil the database is used directly.
+func DeleteRentalAgreementRentableTx(tx *RRTx, id int64) error {
+	if b, err := GetRentalAgreementRentableTx(tx, id); err == nil {
+		if err = MarkDirtyTx(tx, b.BID, b.RID, &b.RARDtStart, &b.RARDtStop); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Stmt(RRdb.Prepstmt.DeleteRentalAgreementRentable).Exec(id)
 	if err != nil {
 		Ulog("Error deleting id=%d error: %v\n", id, err)
 	}
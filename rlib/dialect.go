@@ -0,0 +1,464 @@
+package rlib
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Dialect hides the differences between the SQL databases RentRoll can use.
+// The SQL in rlib and ws is written for MySQL. A database opened with
+// OpenDialectDB runs every statement through its dialect's SQL func first,
+// so the same prepared statements work on any of them.
+type Dialect interface {
+	Name() string               // name of the dialect, "mysql" or "sqlite"
+	SQL(q string) string        // rewrites MySQL statement q for this database
+	TableExistsSQL() string     // query for the number of tables named ?
+	Schema(ddl string) []string // turns schema.sql into statements for this database
+	DirectorySchema() []string  // phonebook tables to create in the RentRoll database, if any
+}
+
+// DBDialect is the dialect of the RentRoll database. It is MySQL unless the
+// database was opened with OpenDialectDB.
+var DBDialect Dialect = MySQLDialect{}
+
+//=============================================================================
+//  MySQL
+//=============================================================================
+
+// MySQLDialect is the dialect of MySQL, which the RentRoll SQL is written for
+type MySQLDialect struct{}
+
+// Name returns the name of the dialect
+func (MySQLDialect) Name() string { return "mysql" }
+
+// SQL returns q unchanged
+func (MySQLDialect) SQL(q string) string { return q }
+
+// TableExistsSQL returns the query for the number of tables named ?
+func (MySQLDialect) TableExistsSQL() string {
+	return "SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=?"
+}
+
+// Schema splits ddl into its statements
+func (MySQLDialect) Schema(ddl string) []string {
+	return splitSQLStatements(ddl)
+}
+
+// DirectorySchema returns nil, the phonebook is a separate MySQL database
+func (MySQLDialect) DirectorySchema() []string { return nil }
+
+//=============================================================================
+//  SQLite
+//=============================================================================
+
+// SQLiteDialect is the dialect of SQLite. It allows rentroll to run with a
+// local file as its database, for development and small installations. The
+// phonebook tables rentroll reads are kept in the same file.
+type SQLiteDialect struct{}
+
+// Name returns the name of the dialect
+func (SQLiteDialect) Name() string { return "sqlite" }
+
+// TableExistsSQL returns the query for the number of tables named ?
+func (SQLiteDialect) TableExistsSQL() string {
+	return "SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?"
+}
+
+// SQL rewrites the MySQL functions SQLite does not have:
+//
+//    CONCAT(a,b,...)                              -> (a || b || ...)
+//    GROUP_CONCAT(x ORDER BY y SEPARATOR ', ')    -> GROUP_CONCAT(x, ', ')
+//    GROUP_CONCAT(DISTINCT x SEPARATOR ', ')      -> REPLACE(GROUP_CONCAT(DISTINCT x), ',', ', ')
+//    NOW()                                        -> CURRENT_TIMESTAMP
+//    SELECT ... FOR UPDATE                        -> SELECT ...
+//
+// SQLite cannot order the values of GROUP_CONCAT, so ORDER BY is dropped.
+// A SQLite transaction holds the database write lock from the time it
+// begins, see sqliteDSNOptions, so it needs no row locks and FOR UPDATE is
+// dropped too.
+func (SQLiteDialect) SQL(q string) string {
+	q = rewriteSQLCalls(q, "CONCAT", func(args string) string {
+		return "(" + strings.Join(splitSQLTopLevel(args, ","), " || ") + ")"
+	})
+	q = rewriteSQLCalls(q, "GROUP_CONCAT", sqliteGroupConcat)
+	q = rewriteSQLCalls(q, "NOW", func(args string) string { return "CURRENT_TIMESTAMP" })
+	q = sqliteForUpdate.ReplaceAllString(q, "")
+	return q
+}
+
+// sqliteGroupConcat returns the SQLite version of the MySQL GROUP_CONCAT
+// call with arguments args
+func sqliteGroupConcat(args string) string {
+	sep := "','"
+	if i := indexSQLTopLevel(args, "SEPARATOR"); i >= 0 {
+		sep = strings.TrimSpace(args[i+len("SEPARATOR"):])
+		args = args[:i]
+	}
+	if i := indexSQLTopLevel(args, "ORDER BY"); i >= 0 {
+		args = args[:i]
+	}
+	args = strings.TrimSpace(args)
+	if strings.HasPrefix(strings.ToUpper(args), "DISTINCT ") {
+		s := "GROUP_CONCAT(" + args + ")"
+		if sep == "','" {
+			return s
+		}
+		return "REPLACE(" + s + ", ','," + sep + ")"
+	}
+	return "GROUP_CONCAT(" + args + "," + sep + ")"
+}
+
+var (
+	sqliteForUpdate = regexp.MustCompile(`(?i)\s+FOR\s+UPDATE\s*$`)
+	sqliteAutoInc   = regexp.MustCompile(`(?i)\b(\w+)\s+\w+(\s*\(\d+\))?\s+NOT\s+NULL\s+AUTO_INCREMENT`)
+	sqliteOnUpdate  = regexp.MustCompile(`(?i)\s+ON\s+UPDATE\s+CURRENT_TIMESTAMP`)
+	sqliteKeyDef    = regexp.MustCompile(`(?i),\s*(UNIQUE\s+)?(KEY|INDEX)\s+\w*\s*\([^)]*\)`)
+	sqliteTableOpts = regexp.MustCompile(`\)[^)]*$`)
+)
+
+// Schema turns the MySQL ddl in schema.sql into SQLite statements. The
+// database level statements (CREATE DATABASE, USE, GRANT, SET) are dropped.
+// AUTO_INCREMENT columns become INTEGER PRIMARY KEY AUTOINCREMENT, and the
+// MySQL only clauses are removed.
+func (SQLiteDialect) Schema(ddl string) []string {
+	var m []string
+	for _, s := range splitSQLStatements(ddl) {
+		u := strings.ToUpper(s)
+		switch {
+		case strings.HasPrefix(u, "DROP DATABASE"), strings.HasPrefix(u, "CREATE DATABASE"),
+			strings.HasPrefix(u, "USE "), strings.HasPrefix(u, "GRANT "), strings.HasPrefix(u, "SET "):
+			continue
+		case strings.HasPrefix(u, "CREATE TABLE"):
+			if sm := sqliteAutoInc.FindStringSubmatch(s); sm != nil {
+				s = sqliteAutoInc.ReplaceAllString(s, "$1 INTEGER PRIMARY KEY AUTOINCREMENT")
+				pk := regexp.MustCompile(`(?i),\s*PRIMARY\s+KEY\s*\(\s*` + sm[1] + `\s*\)`)
+				s = pk.ReplaceAllString(s, "")
+			}
+			s = sqliteOnUpdate.ReplaceAllString(s, "")
+			s = sqliteKeyDef.ReplaceAllString(s, "")
+			s = sqliteTableOpts.ReplaceAllString(s, ")")
+		}
+		m = append(m, s)
+	}
+	return m
+}
+
+// DirectorySchema returns the phonebook tables rentroll reads. With SQLite
+// they are in the RentRoll database file.
+func (SQLiteDialect) DirectorySchema() []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS classes (
+    ClassCode INTEGER PRIMARY KEY AUTOINCREMENT,
+    CoCode BIGINT NOT NULL DEFAULT 0,
+    Name VARCHAR(100) NOT NULL DEFAULT '',
+    Designation VARCHAR(3) NOT NULL DEFAULT '',
+    Description VARCHAR(256) NOT NULL DEFAULT '',
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    LastModBy BIGINT NOT NULL DEFAULT 0
+)`,
+		`CREATE TABLE IF NOT EXISTS companies (
+    CoCode INTEGER PRIMARY KEY AUTOINCREMENT,
+    LegalName VARCHAR(50) NOT NULL DEFAULT '',
+    CommonName VARCHAR(50) NOT NULL DEFAULT '',
+    Address VARCHAR(35) NOT NULL DEFAULT '',
+    Address2 VARCHAR(35) NOT NULL DEFAULT '',
+    City VARCHAR(25) NOT NULL DEFAULT '',
+    State CHAR(25) NOT NULL DEFAULT '',
+    PostalCode VARCHAR(10) NOT NULL DEFAULT '',
+    Country VARCHAR(25) NOT NULL DEFAULT '',
+    Phone VARCHAR(25) NOT NULL DEFAULT '',
+    Fax VARCHAR(25) NOT NULL DEFAULT '',
+    Email VARCHAR(35) NOT NULL DEFAULT '',
+    Designation VARCHAR(3) NOT NULL DEFAULT '',
+    Active SMALLINT NOT NULL DEFAULT 0,
+    EmploysPersonnel SMALLINT NOT NULL DEFAULT 0,
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    LastModBy BIGINT NOT NULL DEFAULT 0
+)`,
+		`CREATE TABLE IF NOT EXISTS people (
+    UID INTEGER PRIMARY KEY AUTOINCREMENT,
+    FirstName VARCHAR(100) NOT NULL DEFAULT '',
+    LastName VARCHAR(100) NOT NULL DEFAULT '',
+    PreferredName VARCHAR(100) NOT NULL DEFAULT '',
+    PrimaryEmail VARCHAR(100) NOT NULL DEFAULT '',
+    OfficePhone VARCHAR(100) NOT NULL DEFAULT ''
+)`,
+	}
+}
+
+//=============================================================================
+//  SQL text helpers
+//=============================================================================
+
+// splitSQLStatements removes the comments from a file of SQL statements and
+// returns the statements, without their terminating semicolons
+func splitSQLStatements(ddl string) []string {
+	var sb strings.Builder
+	for _, line := range strings.Split(ddl, "\n") {
+		scanSQL(line, func(i, depth int) bool {
+			if strings.HasPrefix(line[i:], "--") {
+				line = line[:i]
+				return false
+			}
+			return true
+		})
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	var m []string
+	for _, s := range splitSQLTopLevel(sb.String(), ";") {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			m = append(m, s)
+		}
+	}
+	return m
+}
+
+// scanSQL calls f with the index of each character of q that is not within a
+// quoted string, and the parenthesis depth at that point. Scanning stops if
+// f returns false.
+func scanSQL(q string, f func(i, depth int) bool) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+			continue
+		case ')':
+			depth--
+		}
+		if !f(i, depth) {
+			return
+		}
+		if c == '(' {
+			depth++
+		}
+	}
+}
+
+// indexSQLTopLevel returns the index of the first occurrence of word in q
+// that is outside of any quotes or parentheses, or -1. The match is not case
+// sensitive.
+func indexSQLTopLevel(q, word string) int {
+	idx := -1
+	uq := strings.ToUpper(q)
+	uw := strings.ToUpper(word)
+	scanSQL(q, func(i, depth int) bool {
+		if depth == 0 && strings.HasPrefix(uq[i:], uw) {
+			idx = i
+			return false
+		}
+		return true
+	})
+	return idx
+}
+
+// splitSQLTopLevel splits q at each sep that is outside of any quotes or
+// parentheses
+func splitSQLTopLevel(q, sep string) []string {
+	var m []string
+	start := 0
+	scanSQL(q, func(i, depth int) bool {
+		if depth == 0 && i >= start && strings.HasPrefix(q[i:], sep) {
+			m = append(m, q[start:i])
+			start = i + len(sep)
+		}
+		return true
+	})
+	return append(m, q[start:])
+}
+
+// rewriteSQLCalls replaces each call of function name in q with the value f
+// returns for the call's arguments. Calls within the arguments are rewritten
+// first.
+func rewriteSQLCalls(q, name string, f func(args string) string) string {
+	re := regexp.MustCompile(`(?i)\b` + name + `\s*\(`)
+	var sb strings.Builder
+	for {
+		loc := re.FindStringIndex(q)
+		if loc == nil {
+			sb.WriteString(q)
+			return sb.String()
+		}
+		// skip a match within a quoted string or that is part of a longer name
+		if loc[0] > 0 && (q[loc[0]-1] == '_' || q[loc[0]-1] == '.') || inSQLQuote(q, loc[0]) {
+			sb.WriteString(q[:loc[1]])
+			q = q[loc[1]:]
+			continue
+		}
+		end := -1
+		rest := q[loc[1]:]
+		scanSQL(rest, func(i, depth int) bool {
+			if depth < 0 {
+				end = i
+				return false
+			}
+			return true
+		})
+		if end < 0 {
+			sb.WriteString(q)
+			return sb.String()
+		}
+		sb.WriteString(q[:loc[0]])
+		sb.WriteString(f(rewriteSQLCalls(rest[:end], name, f)))
+		q = rest[end+1:]
+	}
+}
+
+// inSQLQuote returns true if index n of q is within a quoted string
+func inSQLQuote(q string, n int) bool {
+	in := true
+	scanSQL(q[:n+1], func(i, depth int) bool {
+		if i == n {
+			in = false
+		}
+		return true
+	})
+	return in
+}
+
+//=============================================================================
+//  Opening a database with a dialect
+//=============================================================================
+
+// dialectDriver is a database/sql driver that rewrites every statement with
+// its dialect before passing it to the real driver
+type dialectDriver struct {
+	inner driver.Driver
+	d     Dialect
+}
+
+// dialectConn is a connection of a dialectDriver
+type dialectConn struct {
+	inner driver.Conn
+	d     Dialect
+}
+
+// Open opens a connection with the real driver
+func (dd *dialectDriver) Open(name string) (driver.Conn, error) {
+	c, err := dd.inner.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &dialectConn{inner: c, d: dd.d}, nil
+}
+
+// Prepare prepares the dialect's version of query
+func (c *dialectConn) Prepare(query string) (driver.Stmt, error) {
+	return c.inner.Prepare(c.d.SQL(query))
+}
+
+// Close closes the connection
+func (c *dialectConn) Close() error { return c.inner.Close() }
+
+// Begin starts a transaction
+func (c *dialectConn) Begin() (driver.Tx, error) { return c.inner.Begin() }
+
+var (
+	dialectDriversMu sync.Mutex
+	dialectDrivers   = map[string]bool{}
+)
+
+// OpenDialectDB opens a database whose SQL differs from MySQL, and makes d
+// the dialect of the RentRoll database. The driver for driverName must
+// already be registered, for SQLite that means building with the sqlite tag.
+//
+// INPUTS
+//    d          = the dialect of the database
+//    driverName = the database/sql driver to use, for example "sqlite3"
+//    dsn        = the data source name passed to the driver
+//
+// RETURNS
+//    the database
+//    any error encountered
+//-----------------------------------------------------------------------------
+func OpenDialectDB(d Dialect, driverName, dsn string) (*sql.DB, error) {
+	funcname := "OpenDialectDB"
+	name := "rentroll-" + d.Name() + "-" + driverName
+
+	dialectDriversMu.Lock()
+	if !dialectDrivers[name] {
+		db, err := sql.Open(driverName, "")
+		if err != nil {
+			dialectDriversMu.Unlock()
+			return nil, fmt.Errorf("%s: %s (was rentroll built with %s support?)", funcname, err.Error(), d.Name())
+		}
+		sql.Register(name, &dialectDriver{inner: db.Driver(), d: d})
+		db.Close()
+		dialectDrivers[name] = true
+	}
+	dialectDriversMu.Unlock()
+
+	db, err := sql.Open(name, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", funcname, err.Error())
+	}
+	DBDialect = d
+	return db, nil
+}
+
+// CreateSchema creates the RentRoll tables in an empty database. ddl is the
+// contents of schema.sql, it is converted for the database's dialect.
+func CreateSchema(db *sql.DB, ddl string) error {
+	m := append(DBDialect.Schema(ddl), DBDialect.DirectorySchema()...)
+	for i := 0; i < len(m); i++ {
+		if _, err := db.Exec(m[i]); err != nil {
+			return fmt.Errorf("CreateSchema: %s\nstatement: %s", err.Error(), m[i])
+		}
+	}
+	return nil
+}
+
+// sqliteDSNOptions are the connection options of a SQLite database. SQLite
+// allows one writer at a time. In WAL mode readers do not block the writer,
+// so the reads of reference data that an RRTx makes outside the transaction
+// proceed while it is open. Transactions take the write lock when they
+// begin, and a writer waits up to busy_timeout ms for the lock rather than
+// failing with "database is locked".
+const sqliteDSNOptions = "?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+
+// OpenSQLiteDB opens the SQLite database in file fname for use as both the
+// RentRoll and the phonebook database. If the file has no RentRoll tables
+// they are created from the schema in file schemaFile.
+func OpenSQLiteDB(fname, schemaFile string) (*sql.DB, error) {
+	funcname := "OpenSQLiteDB"
+	db, err := OpenDialectDB(SQLiteDialect{}, "sqlite3", fname+sqliteDSNOptions)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := schemaTableExists(db, "Business")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %s", funcname, err.Error())
+	}
+	if !ok {
+		b, err := ioutil.ReadFile(schemaFile)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%s: cannot read schema: %s", funcname, err.Error())
+		}
+		if err = CreateSchema(db, string(b)); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%s: %s", funcname, err.Error())
+		}
+	}
+	return db, nil
+}
@@ -0,0 +1,65 @@
+package rlib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSQLiteSQL(t *testing.T) {
+	var d SQLiteDialect
+	m := []struct {
+		q, expect string
+	}{
+		{"SELECT BID,Name FROM Business WHERE BUD=?", "SELECT BID,Name FROM Business WHERE BUD=?"},
+		{"SELECT CONCAT(FirstName,' ',LastName) FROM Transactant", "SELECT (FirstName || ' ' || LastName) FROM Transactant"},
+		{"SELECT GROUP_CONCAT(DISTINCT Rentable.RentableName ORDER BY Rentable.RentableName ASC SEPARATOR ', ') FROM Rentable",
+			"SELECT REPLACE(GROUP_CONCAT(DISTINCT Rentable.RentableName), ',',', ') FROM Rentable"},
+		{"SELECT GROUP_CONCAT(Name SEPARATOR '; ') FROM AR", "SELECT GROUP_CONCAT(Name,'; ') FROM AR"},
+		{"SELECT GROUP_CONCAT(DISTINCT CONCAT(FirstName,' ',LastName) SEPARATOR ', ') FROM Transactant",
+			"SELECT REPLACE(GROUP_CONCAT(DISTINCT (FirstName || ' ' || LastName)), ',',', ') FROM Transactant"},
+		{"UPDATE Receipt SET LastModTime=NOW() WHERE RCPTID=?", "UPDATE Receipt SET LastModTime=CURRENT_TIMESTAMP WHERE RCPTID=?"},
+		{"SELECT Comment FROM Receipt WHERE Comment='CONCAT(a,b)'", "SELECT Comment FROM Receipt WHERE Comment='CONCAT(a,b)'"},
+		{"SELECT BID FROM Business WHERE BID=? FOR UPDATE", "SELECT BID FROM Business WHERE BID=?"},
+	}
+	for i := 0; i < len(m); i++ {
+		if got := d.SQL(m[i].q); got != m[i].expect {
+			t.Errorf("%d: expect %q, got %q", i, m[i].expect, got)
+		}
+	}
+}
+
+func TestSQLiteSchema(t *testing.T) {
+	ddl := `DROP DATABASE IF EXISTS rentroll;
+CREATE DATABASE rentroll;
+USE rentroll;
+GRANT ALL PRIVILEGES ON rentroll.* TO 'ec2-user'@'localhost';
+set GLOBAL sql_mode='ALLOW_INVALID_DATES';
+
+-- a table; with a comment
+CREATE TABLE Tax (
+    TAXID BIGINT NOT NULL AUTO_INCREMENT,                   -- unique identifier
+    Name VARCHAR(50) NOT NULL DEFAULT '',                   -- a name; for this tax
+    LastModTime TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY(TAXID),
+    KEY (Name)
+) ENGINE=InnoDB;
+
+INSERT INTO Tax (Name) VALUES('a;b');
+`
+	m := SQLiteDialect{}.Schema(ddl)
+	if len(m) != 2 {
+		t.Fatalf("expect 2 statements, got %d: %q", len(m), m)
+	}
+	s := m[0]
+	for _, bad := range []string{"AUTO_INCREMENT", "ON UPDATE", "PRIMARY KEY(", "KEY (Name)", "ENGINE", "--"} {
+		if strings.Contains(s, bad) {
+			t.Errorf("create table still has %q: %s", bad, s)
+		}
+	}
+	if !strings.Contains(s, "TAXID INTEGER PRIMARY KEY AUTOINCREMENT") {
+		t.Errorf("TAXID is not the autoincrement key: %s", s)
+	}
+	if m[1] != "INSERT INTO Tax (Name) VALUES('a;b')" {
+		t.Errorf("insert: got %q", m[1])
+	}
+}
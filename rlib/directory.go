@@ -0,0 +1,175 @@
+package rlib
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Directory kinds, the values of "Directory" in config.json
+const (
+	DirectoryPhonebook = "phonebook" // the Accord phonebook database, the default
+	DirectoryLocal     = "local"     // the DirectoryPerson table in the RentRoll database
+)
+
+// Directory is where rentroll looks up the things it does not keep itself:
+// the employees whose UIDs are stored in LastModBy, CreateBy, CSAgent,
+// Approver, AccountRep, ... and the business units and companies that
+// report headers are made from.
+type Directory interface {
+	Name() string                                        // the kind of directory, DirectoryPhonebook or DirectoryLocal
+	GetBusinessUnit(des string) (BusinessUnit, error)    // business unit with designation des
+	GetCompany(cocode int64) (Company, error)            // company cocode
+	GetCompanyByDesignation(des string) (Company, error) // company with designation des
+	GetPerson(uid int64) (DirectoryPerson, error)        // employee uid
+}
+
+// NewDirectory returns the directory of the supplied kind. An empty kind is
+// the phonebook.
+func NewDirectory(kind string) (Directory, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", DirectoryPhonebook:
+		return PhonebookDirectory{}, nil
+	case DirectoryLocal:
+		return LocalDirectory{}, nil
+	}
+	return nil, fmt.Errorf("unknown directory: %s. Use %q or %q", kind, DirectoryPhonebook, DirectoryLocal)
+}
+
+//=============================================================================
+//  Phonebook
+//=============================================================================
+
+// PhonebookDirectory looks everything up in the phonebook database, RRdb.Dbdir
+type PhonebookDirectory struct{}
+
+// Name returns the kind of directory
+func (PhonebookDirectory) Name() string { return DirectoryPhonebook }
+
+// GetBusinessUnit returns the phonebook class with designation des
+func (PhonebookDirectory) GetBusinessUnit(des string) (BusinessUnit, error) {
+	var c BusinessUnit
+	err := RRdb.PBsql.GetBusinessUnitByDesignation.QueryRow(des).Scan(&c.ClassCode, &c.CoCode, &c.Name, &c.Designation, &c.Description, &c.LastModTime, &c.LastModBy)
+	return c, err
+}
+
+// GetCompany returns the phonebook company cocode
+func (PhonebookDirectory) GetCompany(cocode int64) (Company, error) {
+	return readPBCompany(RRdb.PBsql.GetCompany.QueryRow(cocode))
+}
+
+// GetCompanyByDesignation returns the phonebook company with designation des
+func (PhonebookDirectory) GetCompanyByDesignation(des string) (Company, error) {
+	return readPBCompany(RRdb.PBsql.GetCompanyByDesignation.QueryRow(des))
+}
+
+// GetPerson returns the phonebook person uid
+func (PhonebookDirectory) GetPerson(uid int64) (DirectoryPerson, error) {
+	var a DirectoryPerson
+	err := RRdb.PBsql.GetPerson.QueryRow(uid).Scan(&a.UID, &a.FirstName, &a.LastName, &a.PreferredName, &a.Email, &a.Phone)
+	return a, err
+}
+
+// readPBCompany reads a Company from a row of the phonebook companies table
+func readPBCompany(row *sql.Row) (Company, error) {
+	var c Company
+	err := row.Scan(&c.CoCode, &c.LegalName, &c.CommonName,
+		&c.Address, &c.Address2, &c.City, &c.State, &c.PostalCode, &c.Country, &c.Phone,
+		&c.Fax, &c.Email, &c.Designation, &c.Active, &c.EmploysPersonnel, &c.LastModTime,
+		&c.LastModBy)
+	return c, err
+}
+
+//=============================================================================
+//  Local
+//=============================================================================
+
+// LocalDirectory is the directory of a standalone installation. Employees
+// are in the DirectoryPerson table. Each business is its own business unit,
+// named after the business, and there are no companies.
+type LocalDirectory struct{}
+
+// Name returns the kind of directory
+func (LocalDirectory) Name() string { return DirectoryLocal }
+
+// GetBusinessUnit returns the business unit for the business with
+// designation des
+func (LocalDirectory) GetBusinessUnit(des string) (BusinessUnit, error) {
+	var c BusinessUnit
+	b := GetBusinessByDesignation(des)
+	if b.BID == 0 {
+		return c, sql.ErrNoRows
+	}
+	c.Name = b.Name
+	c.Designation = b.Designation
+	c.LastModTime = b.LastModTime
+	c.LastModBy = int(b.LastModBy)
+	return c, nil
+}
+
+// GetCompany returns sql.ErrNoRows, the local directory has no companies
+func (LocalDirectory) GetCompany(cocode int64) (Company, error) {
+	return Company{}, sql.ErrNoRows
+}
+
+// GetCompanyByDesignation returns sql.ErrNoRows, the local directory has no
+// companies
+func (LocalDirectory) GetCompanyByDesignation(des string) (Company, error) {
+	return Company{}, sql.ErrNoRows
+}
+
+// GetPerson returns the DirectoryPerson uid
+func (LocalDirectory) GetPerson(uid int64) (DirectoryPerson, error) {
+	return GetDirectoryPerson(uid)
+}
+
+//=============================================================================
+//  Employee names
+//=============================================================================
+
+// dirNames caches the employee names looked up by GetDirectoryName
+var dirNames = struct {
+	sync.Mutex
+	m map[int64]string
+}{m: map[int64]string{}}
+
+// DirectoryPersonName returns the name a person goes by: the preferred name
+// if there is one, otherwise the first name, followed by the last name
+func DirectoryPersonName(a *DirectoryPerson) string {
+	first := a.PreferredName
+	if len(first) == 0 {
+		first = a.FirstName
+	}
+	return strings.TrimSpace(first + " " + a.LastName)
+}
+
+// GetDirectoryName returns the name of employee uid, for reports and audit
+// trails. If uid is 0 the name is blank. If uid is not in the directory its
+// name is "UID " followed by the number.
+func GetDirectoryName(uid int64) string {
+	if uid == 0 || RRdb.Dir == nil {
+		return ""
+	}
+	dirNames.Lock()
+	defer dirNames.Unlock()
+	if s, ok := dirNames.m[uid]; ok {
+		return s
+	}
+	s := fmt.Sprintf("UID %d", uid)
+	if a, err := RRdb.Dir.GetPerson(uid); err == nil {
+		if n := DirectoryPersonName(&a); len(n) > 0 {
+			s = n
+		}
+	}
+	dirNames.m[uid] = s
+	return s
+}
+
+// ClearDirectoryNames empties the cache of names used by GetDirectoryName.
+// It is needed after a person in the local directory is changed.
+func ClearDirectoryNames() {
+	dirNames.Lock()
+	dirNames.m = map[int64]string{}
+	dirNames.Unlock()
+}
@@ -0,0 +1,40 @@
+package rlib
+
+import "testing"
+
+func TestNewDirectory(t *testing.T) {
+	m := map[string]string{
+		"":          DirectoryPhonebook,
+		"phonebook": DirectoryPhonebook,
+		"Local":     DirectoryLocal,
+		" local ":   DirectoryLocal,
+	}
+	for kind, expect := range m {
+		d, err := NewDirectory(kind)
+		if err != nil {
+			t.Errorf("%q: %s", kind, err.Error())
+		} else if d.Name() != expect {
+			t.Errorf("%q: expect %s, got %s", kind, expect, d.Name())
+		}
+	}
+	if _, err := NewDirectory("ldap"); err == nil {
+		t.Errorf("ldap: expect an error")
+	}
+}
+
+func TestDirectoryPersonName(t *testing.T) {
+	m := []struct {
+		a      DirectoryPerson
+		expect string
+	}{
+		{DirectoryPerson{FirstName: "Robert", LastName: "Smith"}, "Robert Smith"},
+		{DirectoryPerson{FirstName: "Robert", PreferredName: "Bob", LastName: "Smith"}, "Bob Smith"},
+		{DirectoryPerson{LastName: "Smith"}, "Smith"},
+		{DirectoryPerson{}, ""},
+	}
+	for i := 0; i < len(m); i++ {
+		if got := DirectoryPersonName(&m[i].a); got != m[i].expect {
+			t.Errorf("%d: expect %q, got %q", i, m[i].expect, got)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+package rlib
+
+// ComputeBusinessKPI exports computeBusinessKPI to the rlib_test tests,
+// which bypass the KPI cache with it
+var ComputeBusinessKPI = computeBusinessKPI
@@ -10,6 +10,7 @@ import (
 const (
 	LiabilitySecDep    = "Liability Security Deposit"
 	AccountsReceivable = "Accounts Receivable"
+	AccountsPayable    = "Accounts Payable"
 )
 
 // RDateFmt is an array of date / time formats that RentRoll accepts for datetime input
@@ -41,6 +42,7 @@ var QBAcctInfo = []struct {
 	{"Cash", false},             // Asset         D +   C -
 	{"Expense", false},          // Expense Acct  D +   C -
 	{"Liabilities", true},       // Liabilities   D -   C +
+	{AccountsPayable, true},     // Liabilities   D -   C +
 	{LiabilitySecDep, true},     // Liabilities   D -   C +
 	{"Income", true},            // Income Acct   D -   C +
 	{"Income Offset", true},     // Income Acct   D -   C +
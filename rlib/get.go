@@ -135,7 +135,13 @@ func GetAllRentableAssessments(RID int64, d1, d2 *time.Time) []Assessment {
 
 // GetUnpaidAssessmentsByRAID for the supplied RAID
 func GetUnpaidAssessmentsByRAID(RAID int64) []Assessment {
-	rows, err := RRdb.Prepstmt.GetUnpaidAssessmentsByRAID.Query(RAID)
+	return GetUnpaidAssessmentsByRAIDTx(nil, RAID)
+}
+
+// GetUnpaidAssessmentsByRAIDTx is GetUnpaidAssessmentsByRAID performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetUnpaidAssessmentsByRAIDTx(tx *RRTx, RAID int64) []Assessment {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetUnpaidAssessmentsByRAID).Query(RAID)
 	Errcheck(err)
 	return GetAssessmentsByRows(rows)
 }
@@ -148,7 +154,22 @@ func GetUnpaidAssessmentsByRAID(RAID int64) []Assessment {
 // RETURNS
 //    array of matching assessments
 func GetAssessmentInstancesByParent(id int64, d1, d2 *time.Time) []Assessment {
-	rows, err := RRdb.Prepstmt.GetAssessmentInstancesByParent.Query(id, d1, d2)
+	return GetAssessmentInstancesByParentTx(nil, id, d1, d2)
+}
+
+// GetAssessmentInstancesByParentTx is GetAssessmentInstancesByParent performed
+// within transaction tx. If tx is nil the database is used directly.
+func GetAssessmentInstancesByParentTx(tx *RRTx, id int64, d1, d2 *time.Time) []Assessment {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetAssessmentInstancesByParent).Query(id, d1, d2)
+	Errcheck(err)
+	return GetAssessmentsByRows(rows)
+}
+
+// GetSingleInstanceAssessments returns the non-recurring assessments and the
+// instances of recurring assessments of business bid that are in effect
+// during d1 - d2
+func GetSingleInstanceAssessments(bid int64, d1, d2 *time.Time) []Assessment {
+	rows, err := RRdb.Prepstmt.GetAllSingleInstanceAssessments.Query(bid, d2, d1)
 	Errcheck(err)
 	return GetAssessmentsByRows(rows)
 }
@@ -167,16 +188,28 @@ func GetAssessmentsByRows(rows *sql.Rows) []Assessment {
 
 // GetAssessment returns the Assessment struct for the account with the supplied asmid
 func GetAssessment(asmid int64) (Assessment, error) {
+	return GetAssessmentTx(nil, asmid)
+}
+
+// GetAssessmentTx is GetAssessment performed within transaction tx. If tx is
+// nil the database is used directly.
+func GetAssessmentTx(tx *RRTx, asmid int64) (Assessment, error) {
 	var a Assessment
-	row := RRdb.Prepstmt.GetAssessment.QueryRow(asmid)
+	row := tx.Stmt(RRdb.Prepstmt.GetAssessment).QueryRow(asmid)
 	ReadAssessment(row, &a)
 	return a, nil
 }
 
 // GetAssessmentInstance returns the Assessment struct for the account with the supplied asmid
 func GetAssessmentInstance(start *time.Time, pasmid int64) (Assessment, error) {
+	return GetAssessmentInstanceTx(nil, start, pasmid)
+}
+
+// GetAssessmentInstanceTx is GetAssessmentInstance performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetAssessmentInstanceTx(tx *RRTx, start *time.Time, pasmid int64) (Assessment, error) {
 	var a Assessment
-	row := RRdb.Prepstmt.GetAssessmentInstance.QueryRow(start, pasmid)
+	row := tx.Stmt(RRdb.Prepstmt.GetAssessmentInstance).QueryRow(start, pasmid)
 	ReadAssessment(row, &a)
 	return a, nil
 }
@@ -184,8 +217,14 @@ func GetAssessmentInstance(start *time.Time, pasmid int64) (Assessment, error) {
 // GetAssessmentFirstInstance returns the Assessment struct for the first instance of the
 // recurring series with PASMID = pasmid
 func GetAssessmentFirstInstance(pasmid int64) (Assessment, error) {
+	return GetAssessmentFirstInstanceTx(nil, pasmid)
+}
+
+// GetAssessmentFirstInstanceTx is GetAssessmentFirstInstance performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetAssessmentFirstInstanceTx(tx *RRTx, pasmid int64) (Assessment, error) {
 	var a Assessment
-	row := RRdb.Prepstmt.GetAssessmentFirstInstance.QueryRow(pasmid)
+	row := tx.Stmt(RRdb.Prepstmt.GetAssessmentFirstInstance).QueryRow(pasmid)
 	ReadAssessment(row, &a)
 	return a, nil
 }
@@ -213,6 +252,25 @@ func GetBuilding(id int64) Building {
 	return t
 }
 
+// GetBusinessBuildings returns the buildings of business bid in the order
+// they were created
+func GetBusinessBuildings(bid int64) ([]Building, error) {
+	var m []Building
+	rows, err := RRdb.Prepstmt.GetBuildingsByBusiness.Query(bid)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t Building
+		if err = rows.Scan(&t.BLDGID, &t.BID, &t.Address, &t.Address2, &t.City, &t.State, &t.PostalCode, &t.Country, &t.CreateTS, &t.CreateBy, &t.LastModTime, &t.LastModBy); err != nil {
+			return m, err
+		}
+		m = append(m, t)
+	}
+	return m, rows.Err()
+}
+
 //=======================================================
 //  B U S I N E S S
 //=======================================================
@@ -246,6 +304,58 @@ func GetBusinessByDesignation(des string) Business {
 	return a
 }
 
+// GetBusinessGroup reads the BusinessGroup with the supplied BGID
+func GetBusinessGroup(id int64) (BusinessGroup, error) {
+	var a BusinessGroup
+	row := RRdb.Prepstmt.GetBusinessGroup.QueryRow(id)
+	err := ReadBusinessGroup(row, &a)
+	return a, err
+}
+
+// GetBusinessGroupByName reads the BusinessGroup with the supplied name
+func GetBusinessGroupByName(name string) (BusinessGroup, error) {
+	var a BusinessGroup
+	row := RRdb.Prepstmt.GetBusinessGroupByName.QueryRow(name)
+	err := ReadBusinessGroup(row, &a)
+	return a, err
+}
+
+// GetAllBusinessGroups returns all BusinessGroups sorted by type and name
+func GetAllBusinessGroups() ([]BusinessGroup, error) {
+	var m []BusinessGroup
+	rows, err := RRdb.Prepstmt.GetAllBusinessGroups.Query()
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a BusinessGroup
+		if err = ReadBusinessGroups(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
+
+// GetBusinessGroupMembers returns the BIDs of the businesses in the group with the supplied BGID
+func GetBusinessGroupMembers(id int64) ([]int64, error) {
+	var m []int64
+	rows, err := RRdb.Prepstmt.GetBusinessGroupMembers.Query(id)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a BusinessGroupMember
+		if err = rows.Scan(&a.BGID, &a.BID, &a.CreateTS, &a.CreateBy); err != nil {
+			return m, err
+		}
+		m = append(m, a.BID)
+	}
+	return m, rows.Err()
+}
+
 // GetXBusiness loads the XBusiness struct for the supplied Business id.
 func GetXBusiness(bid int64, xbiz *XBusiness) {
 	if xbiz.P.BID == 0 && bid > 0 {
@@ -371,8 +481,14 @@ func GetAllDemandSources(id int64) ([]DemandSource, error) {
 
 // GetDeposit reads a Deposit structure based on the supplied Deposit id
 func GetDeposit(id int64) (Deposit, error) {
+	return GetDepositTx(nil, id)
+}
+
+// GetDepositTx is GetDeposit performed within transaction tx. If tx is nil the
+// database is used directly.
+func GetDepositTx(tx *RRTx, id int64) (Deposit, error) {
 	var a Deposit
-	row := RRdb.Prepstmt.GetDeposit.QueryRow(id)
+	row := tx.Stmt(RRdb.Prepstmt.GetDeposit).QueryRow(id)
 	err := ReadDeposit(row, &a)
 	return a, err
 }
@@ -444,8 +560,14 @@ func GetAllDepositories(bid int64) []Depository {
 
 // GetDepositParts reads a DepositPart structure based on the supplied DepositPart DID
 func GetDepositParts(id int64) ([]DepositPart, error) {
+	return GetDepositPartsTx(nil, id)
+}
+
+// GetDepositPartsTx is GetDepositParts performed within transaction tx. If tx
+// is nil the database is used directly.
+func GetDepositPartsTx(tx *RRTx, id int64) ([]DepositPart, error) {
 	var m []DepositPart
-	rows, err := RRdb.Prepstmt.GetDepositParts.Query(id)
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetDepositParts).Query(id)
 	Errcheck(err)
 	defer rows.Close()
 
@@ -495,13 +617,465 @@ func GetAllDepositMethods(bid int64) []DepositMethod {
 
 // GetExpense reads a Expense structure based on the supplied Expense id
 func GetExpense(id int64) (Expense, error) {
+	return GetExpenseTx(nil, id)
+}
+
+// GetExpenseTx is GetExpense performed within transaction tx. If tx is nil the
+// database is used directly.
+func GetExpenseTx(tx *RRTx, id int64) (Expense, error) {
 	var a Expense
 	var err error
-	row := RRdb.Prepstmt.GetExpense.QueryRow(id)
+	row := tx.Stmt(RRdb.Prepstmt.GetExpense).QueryRow(id)
 	err = ReadExpense(row, &a)
 	return a, err
 }
 
+//=======================================================
+//  G L   E X P O R T
+//=======================================================
+
+// GetGLExport reads a GLExport structure based on the supplied GLEXID
+func GetGLExport(id int64) (GLExport, error) {
+	var a GLExport
+	row := RRdb.Prepstmt.GetGLExport.QueryRow(id)
+	err := ReadGLExport(row, &a)
+	return a, err
+}
+
+// GetGLExports returns all the GLExports for the supplied business, most
+// recent first
+func GetGLExports(bid int64) ([]GLExport, error) {
+	var m []GLExport
+	rows, err := RRdb.Prepstmt.GetGLExports.Query(bid)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a GLExport
+		if err = ReadGLExports(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
+
+// GetGLExportedKeysInRange returns the number of times each Journal entry
+// dated in [d1,d2) has been exported, keyed by GLExportKey
+func GetGLExportedKeysInRange(bid int64, d1, d2 *time.Time) (map[GLExportKey]int, error) {
+	return GetGLExportedKeysInRangeTx(nil, bid, d1, d2)
+}
+
+// GetGLExportedKeysInRangeTx is GetGLExportedKeysInRange performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetGLExportedKeysInRangeTx(tx *RRTx, bid int64, d1, d2 *time.Time) (map[GLExportKey]int, error) {
+	m := map[GLExportKey]int{}
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetGLExportedJournalsInRange).Query(bid, d1, d2)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a GLExportJournal
+		if err = ReadGLExportJournals(rows, &a); err != nil {
+			return m, err
+		}
+		m[a.Key()]++
+	}
+	return m, rows.Err()
+}
+
+//=======================================================
+//  W E B H O O K S
+//=======================================================
+
+// GetWebhook reads a Webhook structure based on the supplied WHID
+func GetWebhook(id int64) (Webhook, error) {
+	var a Webhook
+	row := RRdb.Prepstmt.GetWebhook.QueryRow(id)
+	err := ReadWebhook(row, &a)
+	return a, err
+}
+
+// getWebhookList runs the supplied Webhook query and returns the results
+func getWebhookList(q *sql.Stmt, bid int64) ([]Webhook, error) {
+	var m []Webhook
+	rows, err := q.Query(bid)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a Webhook
+		if err = ReadWebhooks(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
+
+// GetWebhooks returns all the Webhooks for the supplied business
+func GetWebhooks(bid int64) ([]Webhook, error) {
+	return getWebhookList(RRdb.Prepstmt.GetWebhooks, bid)
+}
+
+// GetActiveWebhooks returns the Webhooks for the supplied business that are
+// not marked inactive
+func GetActiveWebhooks(bid int64) ([]Webhook, error) {
+	return getWebhookList(RRdb.Prepstmt.GetActiveWebhooks, bid)
+}
+
+// GetOutboxEvent reads an OutboxEvent structure based on the supplied EVID
+func GetOutboxEvent(id int64) (OutboxEvent, error) {
+	var a OutboxEvent
+	row := RRdb.Prepstmt.GetOutboxEvent.QueryRow(id)
+	err := ReadOutboxEvent(row, &a)
+	return a, err
+}
+
+// getWebhookDeliveryList runs the supplied WebhookDelivery query and returns
+// the results
+func getWebhookDeliveryList(q *sql.Stmt, args ...interface{}) ([]WebhookDelivery, error) {
+	var m []WebhookDelivery
+	rows, err := q.Query(args...)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a WebhookDelivery
+		if err = ReadWebhookDeliveries(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
+
+// GetWebhookDeliveries returns the n most recent deliveries for the Webhook
+// with the supplied WHID
+func GetWebhookDeliveries(whid int64, n int) ([]WebhookDelivery, error) {
+	return getWebhookDeliveryList(RRdb.Prepstmt.GetWebhookDeliveries, whid, n)
+}
+
+// GetPendingWebhookDeliveries returns up to n pending deliveries whose next
+// attempt is due at or before now
+func GetPendingWebhookDeliveries(now *time.Time, n int) ([]WebhookDelivery, error) {
+	return getWebhookDeliveryList(RRdb.Prepstmt.GetPendingWebhookDeliveries, now, n)
+}
+
+//=======================================================
+//  R E P O R T   S C H E D U L E S
+//=======================================================
+
+// GetReportSchedule reads a ReportSchedule structure based on the supplied RSID
+func GetReportSchedule(id int64) (ReportSchedule, error) {
+	var a ReportSchedule
+	row := RRdb.Prepstmt.GetReportSchedule.QueryRow(id)
+	err := ReadReportSchedule(row, &a)
+	return a, err
+}
+
+// getReportScheduleList runs the supplied ReportSchedule query and returns
+// the results
+func getReportScheduleList(q *sql.Stmt, args ...interface{}) ([]ReportSchedule, error) {
+	var m []ReportSchedule
+	rows, err := q.Query(args...)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a ReportSchedule
+		if err = ReadReportSchedules(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
+
+// GetReportSchedules returns all the ReportSchedules for the supplied business
+func GetReportSchedules(bid int64) ([]ReportSchedule, error) {
+	return getReportScheduleList(RRdb.Prepstmt.GetReportSchedules, bid)
+}
+
+// GetDueReportSchedules returns the active ReportSchedules of all businesses
+// whose next run is at or before now
+func GetDueReportSchedules(now *time.Time) ([]ReportSchedule, error) {
+	return getReportScheduleList(RRdb.Prepstmt.GetDueReportSchedules, now)
+}
+
+//=======================================================
+//  R E N T   R O L L   S N A P S H O T S
+//=======================================================
+
+// GetRentRollSnapshot reads a RentRollSnapshot structure based on the supplied RRSID
+func GetRentRollSnapshot(id int64) (RentRollSnapshot, error) {
+	var a RentRollSnapshot
+	row := RRdb.Prepstmt.GetRentRollSnapshot.QueryRow(id)
+	err := ReadRentRollSnapshot(row, &a)
+	return a, err
+}
+
+// GetRentRollSnapshotByPeriod returns the latest RentRollSnapshot of the
+// business for the period d1 - d2. RRSID is 0 if there is none.
+func GetRentRollSnapshotByPeriod(bid int64, d1, d2 *time.Time) (RentRollSnapshot, error) {
+	var a RentRollSnapshot
+	row := RRdb.Prepstmt.GetRentRollSnapshotByPeriod.QueryRow(bid, d1, d2)
+	err := ReadRentRollSnapshot(row, &a)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return a, err
+}
+
+// GetRentRollSnapshotBefore returns the RentRollSnapshot of the business
+// whose period ends closest to, but not after, dt.  RRSID is 0 if there is
+// none.
+func GetRentRollSnapshotBefore(bid int64, dt *time.Time) (RentRollSnapshot, error) {
+	var a RentRollSnapshot
+	row := RRdb.Prepstmt.GetRentRollSnapshotBefore.QueryRow(bid, dt)
+	err := ReadRentRollSnapshot(row, &a)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return a, err
+}
+
+// GetRentRollSnapshots returns all the RentRollSnapshots for the supplied
+// business, the most recent period first
+func GetRentRollSnapshots(bid int64) ([]RentRollSnapshot, error) {
+	var m []RentRollSnapshot
+	rows, err := RRdb.Prepstmt.GetRentRollSnapshots.Query(bid)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a RentRollSnapshot
+		if err = ReadRentRollSnapshots(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
+
+//=======================================================
+//  A C C O U N T S   P A Y A B L E
+//=======================================================
+
+// GetVendor reads a Vendor structure based on the supplied VENDID
+func GetVendor(id int64) (Vendor, error) {
+	var a Vendor
+	row := RRdb.Prepstmt.GetVendor.QueryRow(id)
+	err := ReadVendor(row, &a)
+	return a, err
+}
+
+// GetVendorByName reads the Vendor with the supplied name in business bid
+func GetVendorByName(bid int64, name string) (Vendor, error) {
+	var a Vendor
+	row := RRdb.Prepstmt.GetVendorByName.QueryRow(bid, name)
+	err := ReadVendor(row, &a)
+	return a, err
+}
+
+// GetAllVendors returns all the Vendors for the supplied business, sorted by name
+func GetAllVendors(bid int64) ([]Vendor, error) {
+	var m []Vendor
+	rows, err := RRdb.Prepstmt.GetAllVendors.Query(bid)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a Vendor
+		if err = ReadVendors(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
+
+// GetDirectoryPerson reads a DirectoryPerson structure based on the supplied UID
+func GetDirectoryPerson(uid int64) (DirectoryPerson, error) {
+	var a DirectoryPerson
+	row := RRdb.Prepstmt.GetDirectoryPerson.QueryRow(uid)
+	err := ReadDirectoryPerson(row, &a)
+	return a, err
+}
+
+// GetAllDirectoryPersons returns everyone in the local directory, sorted by name
+func GetAllDirectoryPersons() ([]DirectoryPerson, error) {
+	var m []DirectoryPerson
+	rows, err := RRdb.Prepstmt.GetAllDirectoryPersons.Query()
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a DirectoryPerson
+		if err = ReadDirectoryPersons(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
+
+// GetBill reads a Bill structure based on the supplied BILLID. The
+// BillItems are loaded into the BI slice.
+func GetBill(id int64) (Bill, error) {
+	return GetBillTx(nil, id)
+}
+
+// GetBillTx is GetBill performed within transaction tx. If tx is nil the
+// database is used directly.
+func GetBillTx(tx *RRTx, id int64) (Bill, error) {
+	var a Bill
+	row := tx.Stmt(RRdb.Prepstmt.GetBill).QueryRow(id)
+	err := ReadBill(row, &a)
+	if err != nil {
+		return a, err
+	}
+	a.BI, err = GetBillItemsTx(tx, id)
+	return a, err
+}
+
+// getBillList reads all the Bills in the supplied rows. BillItems are not loaded.
+func getBillList(rows *sql.Rows) ([]Bill, error) {
+	var m []Bill
+	for rows.Next() {
+		var a Bill
+		if err := ReadBills(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
+
+// GetBillsByVendor returns the Bills from vendor vid dated in the range
+// d1 <= Dt < d2. BillItems are not loaded.
+func GetBillsByVendor(vid int64, d1, d2 *time.Time) ([]Bill, error) {
+	rows, err := RRdb.Prepstmt.GetBillsByVendor.Query(vid, d1, d2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return getBillList(rows)
+}
+
+// GetBillsThroughDate returns the non-reversed bills of business bid dated
+// on or before dt, sorted by due date. BillItems are not loaded.
+func GetBillsThroughDate(bid int64, dt *time.Time) ([]Bill, error) {
+	rows, err := RRdb.Prepstmt.GetBillsThroughDate.Query(bid, dt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return getBillList(rows)
+}
+
+// GetBillItems returns the line items for the supplied BILLID
+func GetBillItems(id int64) ([]BillItem, error) {
+	return GetBillItemsTx(nil, id)
+}
+
+// GetBillItemsTx is GetBillItems performed within transaction tx. If tx is nil
+// the database is used directly.
+func GetBillItemsTx(tx *RRTx, id int64) ([]BillItem, error) {
+	var m []BillItem
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetBillItems).Query(id)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a BillItem
+		if err = ReadBillItems(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
+
+// GetBillPayment reads a BillPayment structure based on the supplied BPID
+func GetBillPayment(id int64) (BillPayment, error) {
+	var a BillPayment
+	row := RRdb.Prepstmt.GetBillPayment.QueryRow(id)
+	err := ReadBillPayment(row, &a)
+	return a, err
+}
+
+// getBillPaymentList reads all the BillPayments in the supplied rows
+func getBillPaymentList(rows *sql.Rows) ([]BillPayment, error) {
+	var m []BillPayment
+	for rows.Next() {
+		var a BillPayment
+		if err := ReadBillPayments(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
+
+// GetBillPayments returns all payments, including reversals, made against
+// the supplied BILLID
+func GetBillPayments(id int64) ([]BillPayment, error) {
+	rows, err := RRdb.Prepstmt.GetBillPayments.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return getBillPaymentList(rows)
+}
+
+// GetBillPaymentsThroughDate returns the non-reversed payments made against
+// the supplied BILLID on or before dt
+func GetBillPaymentsThroughDate(id int64, dt *time.Time) ([]BillPayment, error) {
+	return GetBillPaymentsThroughDateTx(nil, id, dt)
+}
+
+// GetBillPaymentsThroughDateTx is GetBillPaymentsThroughDate performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetBillPaymentsThroughDateTx(tx *RRTx, id int64, dt *time.Time) ([]BillPayment, error) {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetBillPaymentsThroughDate).Query(id, dt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return getBillPaymentList(rows)
+}
+
+// GetVendorPaymentTotals returns a map of VENDID to the total amount paid
+// to that vendor by business bid in the range d1 <= Dt < d2. Reversed
+// payments are not included.
+func GetVendorPaymentTotals(bid int64, d1, d2 *time.Time) (map[int64]float64, error) {
+	m := map[int64]float64{}
+	rows, err := RRdb.Prepstmt.GetVendorPaymentTotals.Query(bid, d1, d2)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var vid int64
+		var amt float64
+		if err = rows.Scan(&vid, &amt); err != nil {
+			return m, err
+		}
+		m[vid] = amt
+	}
+	return m, rows.Err()
+}
+
 //=======================================================
 //  I N V O I C E
 //=======================================================
@@ -578,8 +1152,14 @@ func GetInvoicePayors(id int64) ([]InvoicePayor, error) {
 
 // GetJournal returns the Journal struct for the journal entry with the supplied id
 func GetJournal(jid int64) Journal {
+	return GetJournalTx(nil, jid)
+}
+
+// GetJournalTx is GetJournal performed within transaction tx. If tx is nil the
+// database is used directly.
+func GetJournalTx(tx *RRTx, jid int64) Journal {
 	var r Journal
-	row := RRdb.Prepstmt.GetJournal.QueryRow(jid)
+	row := tx.Stmt(RRdb.Prepstmt.GetJournal).QueryRow(jid)
 	ReadJournal(row, &r)
 	return r
 }
@@ -598,17 +1178,75 @@ func GetJournal(jid int64) Journal {
 // they are idempotent -- essentially: instances of recurring assessments and vacancy instances.  This call
 // is made prior to generating new ones to ensure that we don't have double entries for the same thing.
 func GetJournalVacancy(id int64, dt1, dt2 *time.Time) Journal {
+	return GetJournalVacancyTx(nil, id, dt1, dt2)
+}
+
+// GetJournalVacancyTx is GetJournalVacancy performed within transaction tx.
+// If tx is nil the database is used directly.
+func GetJournalVacancyTx(tx *RRTx, id int64, dt1, dt2 *time.Time) Journal {
 	var r Journal
-	row := RRdb.Prepstmt.GetJournalVacancy.QueryRow(id, dt1, dt2)
+	row := tx.Stmt(RRdb.Prepstmt.GetJournalVacancy).QueryRow(id, dt1, dt2)
 	ReadJournal(row, &r)
 	return r
 }
 
+// GetVacancyJournalsInRange returns the vacancy Journal entries of Rentable
+// rid in business bid dated in [d1 - d2). The JournalAllocations are loaded.
+func GetVacancyJournalsInRange(bid, rid int64, d1, d2 *time.Time) []Journal {
+	return GetVacancyJournalsInRangeTx(nil, bid, rid, d1, d2)
+}
+
+// GetVacancyJournalsInRangeTx is GetVacancyJournalsInRange performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetVacancyJournalsInRangeTx(tx *RRTx, bid, rid int64, d1, d2 *time.Time) []Journal {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetVacancyJournalsInRange).Query(bid, rid, d1, d2)
+	Errcheck(err)
+	return getJournalRowsWithAllocationsTx(tx, rows)
+}
+
+// GetUnpostedJournalsInRange returns the Journal entries of business bid
+// dated in [d1 - d2) that have no LedgerEntries. The JournalAllocations are
+// loaded.
+func GetUnpostedJournalsInRange(bid int64, d1, d2 *time.Time) []Journal {
+	rows, err := RRdb.Prepstmt.GetUnpostedJournalsInRange.Query(bid, d1, d2)
+	Errcheck(err)
+	return getJournalRowsWithAllocations(rows)
+}
+
+// getJournalRowsWithAllocations reads the Journal entries in rows and then
+// loads their JournalAllocations
+func getJournalRowsWithAllocations(rows *sql.Rows) []Journal {
+	return getJournalRowsWithAllocationsTx(nil, rows)
+}
+
+// getJournalRowsWithAllocationsTx is getJournalRowsWithAllocations with the
+// JournalAllocations read within transaction tx
+func getJournalRowsWithAllocationsTx(tx *RRTx, rows *sql.Rows) []Journal {
+	var t = []Journal{}
+	for rows.Next() {
+		var r Journal
+		ReadJournals(rows, &r)
+		t = append(t, r)
+	}
+	Errcheck(rows.Err())
+	rows.Close()
+	for i := 0; i < len(t); i++ {
+		GetJournalAllocationsTx(tx, &t[i])
+	}
+	return t
+}
+
 // GetJournalByTypeAndID returns the Journal struct for entries match the supplied
 // Type and ID fields
 func GetJournalByTypeAndID(t, id int64) Journal {
+	return GetJournalByTypeAndIDTx(nil, t, id)
+}
+
+// GetJournalByTypeAndIDTx is GetJournalByTypeAndID performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetJournalByTypeAndIDTx(tx *RRTx, t, id int64) Journal {
 	var r Journal
-	row := RRdb.Prepstmt.GetJournalByTypeAndID.QueryRow(t, id)
+	row := tx.Stmt(RRdb.Prepstmt.GetJournalByTypeAndID).QueryRow(t, id)
 	ReadJournal(row, &r)
 	return r
 }
@@ -625,7 +1263,13 @@ func GetJournalByReceiptID(id int64) Journal {
 // GetJournalsByReceiptID returns a slice of Journal structs where it references the supplied
 // receiptID
 func GetJournalsByReceiptID(id int64) []Journal {
-	rows, err := RRdb.Prepstmt.GetJournalByReceiptID.Query(id)
+	return GetJournalsByReceiptIDTx(nil, id)
+}
+
+// GetJournalsByReceiptIDTx is GetJournalsByReceiptID performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetJournalsByReceiptIDTx(tx *RRTx, id int64) []Journal {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetJournalByReceiptID).Query(id)
 	Errcheck(err)
 	defer rows.Close()
 	var t = []Journal{}
@@ -651,6 +1295,18 @@ func GetJournalMarkers(n int64) []JournalMarker {
 	return t
 }
 
+// GetJournalMarkerCovering returns the latest Journal marker of business bid
+// whose period contains d1 - d2. If there is none the JMID is 0.
+func GetJournalMarkerCovering(bid int64, d1, d2 *time.Time) JournalMarker {
+	var r JournalMarker
+	row := RRdb.Prepstmt.GetJournalMarkerCovering.QueryRow(bid, d1, d2)
+	err := row.Scan(&r.JMID, &r.BID, &r.State, &r.DtStart, &r.DtStop, &r.CreateTS, &r.CreateBy)
+	if err != nil && err != sql.ErrNoRows {
+		Ulog("GetJournalMarkerCovering: %s\n", err.Error())
+	}
+	return r
+}
+
 // GetLastJournalMarker returns the last Journal marker or nil if no Journal markers exist
 func GetLastJournalMarker() JournalMarker {
 	t := GetJournalMarkers(1)
@@ -676,7 +1332,13 @@ func GetJournalAllocation(jaid int64) JournalAllocation {
 // GetJournalAllocations loads all Journal allocations associated with the supplied Journal id into
 // the RA array within a Journal structure
 func GetJournalAllocations(j *Journal) {
-	rows, err := RRdb.Prepstmt.GetJournalAllocations.Query(j.JID)
+	GetJournalAllocationsTx(nil, j)
+}
+
+// GetJournalAllocationsTx is GetJournalAllocations performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetJournalAllocationsTx(tx *RRTx, j *Journal) {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetJournalAllocations).Query(j.JID)
 	Errcheck(err)
 	j.JA = getJournalAllocationRows(rows)
 }
@@ -684,7 +1346,13 @@ func GetJournalAllocations(j *Journal) {
 // GetJournalAllocationByASMID returns an array of JournalAllocation records that reference
 // the supplied ASMID.
 func GetJournalAllocationByASMID(id int64) []JournalAllocation {
-	rows, err := RRdb.Prepstmt.GetJournalAllocationsByASMID.Query(id)
+	return GetJournalAllocationByASMIDTx(nil, id)
+}
+
+// GetJournalAllocationByASMIDTx is GetJournalAllocationByASMID performed
+// within transaction tx. If tx is nil the database is used directly.
+func GetJournalAllocationByASMIDTx(tx *RRTx, id int64) []JournalAllocation {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetJournalAllocationsByASMID).Query(id)
 	Errcheck(err)
 	return getJournalAllocationRows(rows)
 }
@@ -695,7 +1363,13 @@ func GetJournalAllocationByASMID(id int64) []JournalAllocation {
 // SubARs automatically generate an associated Assessment.
 //----------------------------------------------------------------------------
 func GetJournalAllocationByASMandRCPTID(id int64) []JournalAllocation {
-	rows, err := RRdb.Prepstmt.GetJournalAllocationsByASMandRCPTID.Query(id)
+	return GetJournalAllocationByASMandRCPTIDTx(nil, id)
+}
+
+// GetJournalAllocationByASMandRCPTIDTx is GetJournalAllocationByASMandRCPTID
+// performed within transaction tx. If tx is nil the database is used directly.
+func GetJournalAllocationByASMandRCPTIDTx(tx *RRTx, id int64) []JournalAllocation {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetJournalAllocationsByASMandRCPTID).Query(id)
 	Errcheck(err)
 	return getJournalAllocationRows(rows)
 }
@@ -965,15 +1639,27 @@ func GetLedger(lid int64) GLAccount {
 
 // GetLedgerEntryByJAID returns the GLAccount struct for the supplied LID
 func GetLedgerEntryByJAID(bid, lid, jaid int64) LedgerEntry {
+	return GetLedgerEntryByJAIDTx(nil, bid, lid, jaid)
+}
+
+// GetLedgerEntryByJAIDTx is GetLedgerEntryByJAID performed within transaction
+// tx. If tx is nil the database is used directly.
+func GetLedgerEntryByJAIDTx(tx *RRTx, bid, lid, jaid int64) LedgerEntry {
 	var a LedgerEntry
-	row := RRdb.Prepstmt.GetLedgerEntryByJAID.QueryRow(bid, lid, jaid)
+	row := tx.Stmt(RRdb.Prepstmt.GetLedgerEntryByJAID).QueryRow(bid, lid, jaid)
 	ReadLedgerEntry(row, &a)
 	return a
 }
 
 // GetLedgerEntriesByJAID returns the GLAccount struct for the supplied LID
 func GetLedgerEntriesByJAID(bid, jaid int64) []LedgerEntry {
-	rows, err := RRdb.Prepstmt.GetLedgerEntriesByJAID.Query(bid, jaid)
+	return GetLedgerEntriesByJAIDTx(nil, bid, jaid)
+}
+
+// GetLedgerEntriesByJAIDTx is GetLedgerEntriesByJAID performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetLedgerEntriesByJAIDTx(tx *RRTx, bid, jaid int64) []LedgerEntry {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetLedgerEntriesByJAID).Query(bid, jaid)
 	Errcheck(err)
 	var m []LedgerEntry
 	for rows.Next() {
@@ -1110,7 +1796,13 @@ func GetAllLedgerEntriesForRID(d1, d2 *time.Time, rid int64) ([]LedgerEntry, err
 
 // GetAllLedgerEntriesInRange returns a list of Ledger Entries for the supplied business and time period
 func GetAllLedgerEntriesInRange(bid int64, d1, d2 *time.Time) ([]LedgerEntry, error) {
-	rows, err := RRdb.Prepstmt.GetAllLedgerEntriesInRange.Query(bid, d1, d2)
+	return GetAllLedgerEntriesInRangeTx(nil, bid, d1, d2)
+}
+
+// GetAllLedgerEntriesInRangeTx is GetAllLedgerEntriesInRange performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetAllLedgerEntriesInRangeTx(tx *RRTx, bid int64, d1, d2 *time.Time) ([]LedgerEntry, error) {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetAllLedgerEntriesInRange).Query(bid, d1, d2)
 	Errcheck(err)
 	defer rows.Close()
 	return GetLedgerEntryArray(rows)
@@ -1373,8 +2065,14 @@ func GetAllRatePlanRefSPRates(rprid, rtid int64) []RatePlanRefSPRate {
 
 // GetReceipt returns a Receipt structure for the supplied RCPTID
 func GetReceipt(rcptid int64) Receipt {
-	r := GetReceiptNoAllocations(rcptid)
-	GetReceiptAllocations(rcptid, &r)
+	return GetReceiptTx(nil, rcptid)
+}
+
+// GetReceiptTx is GetReceipt performed within transaction tx. If tx is nil the
+// database is used directly.
+func GetReceiptTx(tx *RRTx, rcptid int64) Receipt {
+	r := GetReceiptNoAllocationsTx(tx, rcptid)
+	GetReceiptAllocationsTx(tx, rcptid, &r)
 	return r
 }
 
@@ -1389,8 +2087,14 @@ func GetReceiptAllocation(id int64) ReceiptAllocation {
 // GetReceiptNoAllocations returns a Receipt structure for the supplied RCPTID.
 // It does not get the receipt allocations
 func GetReceiptNoAllocations(rcptid int64) Receipt {
+	return GetReceiptNoAllocationsTx(nil, rcptid)
+}
+
+// GetReceiptNoAllocationsTx is GetReceiptNoAllocations performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetReceiptNoAllocationsTx(tx *RRTx, rcptid int64) Receipt {
 	var r Receipt
-	row := RRdb.Prepstmt.GetReceipt.QueryRow(rcptid)
+	row := tx.Stmt(RRdb.Prepstmt.GetReceipt).QueryRow(rcptid)
 	ReadReceipt(row, &r)
 	return r
 }
@@ -1406,7 +2110,13 @@ func GetReceiptDuplicate(dt *time.Time, amt float64, docno string) Receipt {
 // GetReceiptAllocations loads all Receipt allocations associated with the supplied Receipt id into
 // the RA array within a Receipt structure
 func GetReceiptAllocations(rcptid int64, r *Receipt) {
-	rows, err := RRdb.Prepstmt.GetReceiptAllocations.Query(rcptid)
+	GetReceiptAllocationsTx(nil, rcptid, r)
+}
+
+// GetReceiptAllocationsTx is GetReceiptAllocations performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetReceiptAllocationsTx(tx *RRTx, rcptid int64, r *Receipt) {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetReceiptAllocations).Query(rcptid)
 	Errcheck(err)
 	defer rows.Close()
 	r.RA = make([]ReceiptAllocation, 0)
@@ -1459,7 +2169,13 @@ func GetASMReceiptAllocationsInRAIDDateRange(raid int64, d1, d2 *time.Time) []Re
 // This call is used primarily to determine how much payment is left to make on a partially paid
 // assessment.
 func GetReceiptAllocationsByASMID(bid, asmid int64) []ReceiptAllocation {
-	rows, err := RRdb.Prepstmt.GetReceiptAllocationsByASMID.Query(bid, asmid)
+	return GetReceiptAllocationsByASMIDTx(nil, bid, asmid)
+}
+
+// GetReceiptAllocationsByASMIDTx is GetReceiptAllocationsByASMID performed
+// within transaction tx. If tx is nil the database is used directly.
+func GetReceiptAllocationsByASMIDTx(tx *RRTx, bid, asmid int64) []ReceiptAllocation {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetReceiptAllocationsByASMID).Query(bid, asmid)
 	Errcheck(err)
 	return GetReceiptAllocationList(rows)
 }
@@ -1471,7 +2187,13 @@ func GetReceiptAllocationsByASMID(bid, asmid int64) []ReceiptAllocation {
 //   dt = date for all allocations to be on or prior to
 // @returns  []ReceiptAllocation
 func GetReceiptAllocationsThroughDate(id int64, dt *time.Time) []ReceiptAllocation {
-	rows, err := RRdb.Prepstmt.GetReceiptAllocationsThroughDate.Query(id, dt)
+	return GetReceiptAllocationsThroughDateTx(nil, id, dt)
+}
+
+// GetReceiptAllocationsThroughDateTx is GetReceiptAllocationsThroughDate
+// performed within transaction tx. If tx is nil the database is used directly.
+func GetReceiptAllocationsThroughDateTx(tx *RRTx, id int64, dt *time.Time) []ReceiptAllocation {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetReceiptAllocationsThroughDate).Query(id, dt)
 	Errcheck(err)
 	return GetReceiptAllocationList(rows)
 }
@@ -1506,7 +2228,13 @@ func GetReceiptAllocationAmountsOnDate(id int64, dt *time.Time) (float64, float6
 // GetUnallocatedReceiptsByPayor returns the receipts paid by the supplied payor tcid that
 // have not yet been fully allocated.
 func GetUnallocatedReceiptsByPayor(bid, tcid int64) []Receipt {
-	rows, err := RRdb.Prepstmt.GetUnallocatedReceiptsByPayor.Query(bid, tcid)
+	return GetUnallocatedReceiptsByPayorTx(nil, bid, tcid)
+}
+
+// GetUnallocatedReceiptsByPayorTx is GetUnallocatedReceiptsByPayor performed
+// within transaction tx. If tx is nil the database is used directly.
+func GetUnallocatedReceiptsByPayorTx(tx *RRTx, bid, tcid int64) []Receipt {
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetUnallocatedReceiptsByPayor).Query(bid, tcid)
 	Errcheck(err)
 	defer rows.Close()
 	var t = []Receipt{}
@@ -1514,9 +2242,12 @@ func GetUnallocatedReceiptsByPayor(bid, tcid int64) []Receipt {
 		var r Receipt
 		ReadReceipts(rows, &r)
 		r.RA = make([]ReceiptAllocation, 0) // the receipt may be partially allocated
-		GetReceiptAllocations(r.RCPTID, &r)
 		t = append(t, r)
 	}
+	rows.Close() // a transaction cannot run another query while rows are open
+	for i := 0; i < len(t); i++ {
+		GetReceiptAllocationsTx(tx, t[i].RCPTID, &t[i])
+	}
 	return t
 }
 
@@ -1528,6 +2259,15 @@ func GetPayorUnallocatedReceiptsCount(bid, tcid int64) int {
 	return i
 }
 
+// GetUnallocatedReceiptsCount returns a count of the receipts of the business
+// that are not fully allocated, not counting voided receipts
+func GetUnallocatedReceiptsCount(bid int64) int {
+	var i int
+	row := RRdb.Prepstmt.GetUnallocatedReceiptsCount.QueryRow(bid)
+	row.Scan(&i)
+	return i
+}
+
 //=======================================================
 //  R E N T A B L E
 //=======================================================
@@ -1683,8 +2423,14 @@ func GetRentableSpecialtyRefsByRange(bid, rid int64, d1, d2 *time.Time) []Rentab
 
 // GetRentableTypeRef gets RentableTypeRef record for given RTRID -- RentableTypeRef ID (unique ID)
 func GetRentableTypeRef(rtrid int64) (RentableTypeRef, error) {
+	return GetRentableTypeRefTx(nil, rtrid)
+}
+
+// GetRentableTypeRefTx is GetRentableTypeRef performed within transaction
+// tx. If tx is nil the database is used directly.
+func GetRentableTypeRefTx(tx *RRTx, rtrid int64) (RentableTypeRef, error) {
 	var rtr RentableTypeRef
-	row := RRdb.Prepstmt.GetRentableTypeRef.QueryRow(rtrid)
+	row := tx.Stmt(RRdb.Prepstmt.GetRentableTypeRef).QueryRow(rtrid)
 	err := ReadRentableTypeRef(row, &rtr)
 	return rtr, err
 }
@@ -1721,6 +2467,27 @@ func GetRentableTypeRefsByRange(RID int64, d1, d2 *time.Time) []RentableTypeRef
 	return GetRTRefs(rows)
 }
 
+// GetRentableTypeRefsByRTIDTx loads the RentableTypeRef records of
+// RentableType rtid that overlap d1 - d2, ordered by Rentable and start date.
+// It is performed within transaction tx. If tx is nil the database is used
+// directly.
+func GetRentableTypeRefsByRTIDTx(tx *RRTx, rtid int64, d1, d2 *time.Time) ([]RentableTypeRef, error) {
+	var m []RentableTypeRef
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetRentableTypeRefsByRTID).Query(rtid, d1, d2)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a RentableTypeRef
+		if err = ReadRentableTypeRefs(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
+
 // GetRentableTypeRefs loads all the RentableTypeRef records for a particular
 func GetRentableTypeRefs(RID int64) []RentableTypeRef {
 	rows, err := RRdb.Prepstmt.GetRentableTypeRefs.Query(RID)
@@ -1752,8 +2519,14 @@ func GetRentableTypeRefForDate(RID int64, d1 *time.Time) RentableTypeRef {
 
 // GetRentableStatus gets RentableStatus record for given RSID -- RentableStatus ID (unique ID)
 func GetRentableStatus(rsid int64) (RentableStatus, error) {
+	return GetRentableStatusTx(nil, rsid)
+}
+
+// GetRentableStatusTx is GetRentableStatus performed within transaction tx.
+// If tx is nil the database is used directly.
+func GetRentableStatusTx(tx *RRTx, rsid int64) (RentableStatus, error) {
 	var rs RentableStatus
-	row := RRdb.Prepstmt.GetRentableStatus.QueryRow(rsid)
+	row := tx.Stmt(RRdb.Prepstmt.GetRentableStatus).QueryRow(rsid)
 	err := ReadRentableStatus(row, &rs)
 	return rs, err
 }
@@ -1867,8 +2640,14 @@ func GetRentableMarketRates(rt *RentableType) {
 
 // GetRentableMarketRateInstance returns instance of rentableMarketRate for given RMRID
 func GetRentableMarketRateInstance(rmrid int64) (RentableMarketRate, error) {
+	return GetRentableMarketRateInstanceTx(nil, rmrid)
+}
+
+// GetRentableMarketRateInstanceTx is GetRentableMarketRateInstance performed
+// within transaction tx. If tx is nil the database is used directly.
+func GetRentableMarketRateInstanceTx(tx *RRTx, rmrid int64) (RentableMarketRate, error) {
 	var rmr RentableMarketRate
-	row := RRdb.Prepstmt.GetRentableMarketRateInstance.QueryRow(rmrid)
+	row := tx.Stmt(RRdb.Prepstmt.GetRentableMarketRateInstance).QueryRow(rmrid)
 	err := ReadRentableMarketRate(row, &rmr)
 	return rmr, err
 }
@@ -2015,7 +2794,13 @@ func GetRARentableForDate(raid int64, d1 *time.Time, rar *RentalAgreementRentabl
 
 // GetRentalAgreementRentable returns Rentable record matching the supplied RARID
 func GetRentalAgreementRentable(rarid int64) (RentalAgreementRentable, error) {
-	row := RRdb.Prepstmt.GetRentalAgreementRentable.QueryRow(rarid)
+	return GetRentalAgreementRentableTx(nil, rarid)
+}
+
+// GetRentalAgreementRentableTx is GetRentalAgreementRentable performed within
+// transaction tx. If tx is nil the database is used directly.
+func GetRentalAgreementRentableTx(tx *RRTx, rarid int64) (RentalAgreementRentable, error) {
+	row := tx.Stmt(RRdb.Prepstmt.GetRentalAgreementRentable).QueryRow(rarid)
 	var r RentalAgreementRentable
 	err := ReadRentalAgreementRentable(row, &r)
 	return r, err
@@ -2438,3 +3223,32 @@ func GetCountBusinessRentalAgreements(bid int64) int {
 	}
 	return id
 }
+
+//=======================================================
+//  DIRTY RANGE
+//=======================================================
+
+// GetDirtyRanges returns the DirtyRanges of business bid that overlap
+// d1 - d2, ordered by Rentable and start date
+func GetDirtyRanges(bid int64, d1, d2 *time.Time) ([]DirtyRange, error) {
+	return GetDirtyRangesTx(nil, bid, d1, d2)
+}
+
+// GetDirtyRangesTx is GetDirtyRanges performed within transaction tx. If tx
+// is nil the database is used directly.
+func GetDirtyRangesTx(tx *RRTx, bid int64, d1, d2 *time.Time) ([]DirtyRange, error) {
+	var m []DirtyRange
+	rows, err := tx.Stmt(RRdb.Prepstmt.GetDirtyRanges).Query(bid, d2, d1)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a DirtyRange
+		if err = ReadDirtyRanges(rows, &a); err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	return m, rows.Err()
+}
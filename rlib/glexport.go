@@ -0,0 +1,320 @@
+package rlib
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GL export formats
+const (
+	GLEXPORTIIF = "iif" // QuickBooks Interchange Format
+	GLEXPORTCSV = "csv" // flat csv, one line per ledger entry
+)
+
+// GLExportCSVColumns are the columns that can be included in a csv export
+// along with the function that formats each one
+var GLExportCSVColumns = map[string]func(e *GLExportEntry) string{
+	"Date":     func(e *GLExportEntry) string { return e.Dt.Format(RRDATEINPFMT) },
+	"JID":      func(e *GLExportEntry) string { return fmt.Sprintf("%d", e.JID) },
+	"LID":      func(e *GLExportEntry) string { return fmt.Sprintf("%d", e.LID) },
+	"GLNumber": func(e *GLExportEntry) string { return e.GLNumber },
+	"Account":  func(e *GLExportEntry) string { return e.AcctName },
+	"Debit":    func(e *GLExportEntry) string { return glExportAmount(e.Amount) },
+	"Credit":   func(e *GLExportEntry) string { return glExportAmount(-e.Amount) },
+	"Amount":   func(e *GLExportEntry) string { return fmt.Sprintf("%.2f", e.Amount) },
+	"Memo":     func(e *GLExportEntry) string { return e.Memo },
+	"RAID":     func(e *GLExportEntry) string { return fmt.Sprintf("%d", e.RAID) },
+	"RID":      func(e *GLExportEntry) string { return fmt.Sprintf("%d", e.RID) },
+	"TCID":     func(e *GLExportEntry) string { return fmt.Sprintf("%d", e.TCID) },
+}
+
+// GLExportCSVDefaultColumns is the column list used for a csv export when
+// the caller does not supply one
+var GLExportCSVDefaultColumns = []string{"Date", "JID", "GLNumber", "Debit", "Credit", "Memo", "RAID", "RID", "TCID"}
+
+// GLExportOptions controls what is exported and how
+type GLExportOptions struct {
+	Format   string   // GLEXPORTIIF or GLEXPORTCSV
+	Columns  []string // csv columns, GLExportCSVDefaultColumns if empty
+	ReExport bool     // if true, include Journal entries that were already exported
+	Preview  bool     // if true, do not record the export
+	UID      int64    // who is doing the export
+}
+
+// GLExportKey identifies an exported Journal entry, see GLExportJournal
+type GLExportKey struct {
+	Type  int64 // Journal Type
+	ID    int64 // Journal ID
+	Dt    int64 // Journal date as a Unix time
+	Cents int64 // Journal amount in cents
+}
+
+// Key returns the GLExportKey of a
+func (a *GLExportJournal) Key() GLExportKey {
+	return GLExportKey{Type: a.Type, ID: a.ID, Dt: a.Dt.Unix(), Cents: int64(math.Round(a.Amount * 100))}
+}
+
+// GLExportEntry is one ledger entry as it will be exported
+type GLExportEntry struct {
+	JID      int64
+	LID      int64
+	Dt       time.Time
+	GLNumber string
+	AcctName string
+	Amount   float64 // debits are positive, credits are negative
+	Memo     string
+	RAID     int64
+	RID      int64
+	TCID     int64
+}
+
+// glExportAmount returns amt formatted to the cent if it is positive,
+// otherwise it returns an empty string.  It is used for the Debit and
+// Credit columns.
+func glExportAmount(amt float64) string {
+	if RoundToCent(amt) <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", amt)
+}
+
+// glExportField removes characters that would break a tab delimited IIF line
+func glExportField(s string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+}
+
+// glExportJournalKey returns the GLExportKey of Journal entry j
+func glExportJournalKey(j *Journal) GLExportKey {
+	a := GLExportJournal{Type: j.Type, ID: j.ID, Dt: j.Dt, Amount: j.Amount}
+	return a.Key()
+}
+
+// ExportGL writes the Journal entries for business bid dated in [d1,d2) to
+// w in the requested format. Unless opt.ReExport is set, Journal entries
+// that have already been exported are skipped. An entry counts as exported
+// if an export holds an entry with the same Type, ID, date and amount, so
+// the entries remade by a rebuild of the Journal are not exported again.
+// Unless opt.Preview is set, the export is recorded in the same transaction
+// that reads the earlier exports and the entries to export, so that the
+// entries will not be exported again. The transaction first takes a lock on
+// the business, so concurrent exports of a business run one after the
+// other and the second one sees what the first recorded. Nothing is written
+// to w unless the export was recorded.
+//
+// INPUTS
+//    bid = the business
+//    d1  = start of the range
+//    d2  = end of the range (not inclusive)
+//    opt = export options
+//    w   = where to write the export
+//
+// RETURNS
+//    the GLExport describing what was exported
+//    any error encountered
+//-----------------------------------------------------------------------------
+func ExportGL(bid int64, d1, d2 *time.Time, opt *GLExportOptions, w io.Writer) (GLExport, error) {
+	var (
+		funcname = "ExportGL"
+		gx       = GLExport{BID: bid, Format: opt.Format, DtStart: *d1, DtStop: *d2, CreateBy: opt.UID, LastModBy: opt.UID}
+		buf      bytes.Buffer
+	)
+
+	if opt.Format != GLEXPORTIIF && opt.Format != GLEXPORTCSV {
+		return gx, fmt.Errorf("%s: unknown export format: %s", funcname, opt.Format)
+	}
+	cols := opt.Columns
+	if len(cols) == 0 {
+		cols = GLExportCSVDefaultColumns
+	}
+	for i := 0; i < len(cols); i++ {
+		if _, ok := GLExportCSVColumns[cols[i]]; !ok {
+			return gx, fmt.Errorf("%s: unknown csv column: %s", funcname, cols[i])
+		}
+	}
+
+	export := func(tx *RRTx) error {
+		var err error
+		if tx != nil {
+			if err = lockGLExportTx(tx, bid); err != nil {
+				return err
+			}
+		}
+		done := map[GLExportKey]int{}
+		if !opt.ReExport {
+			if done, err = GetGLExportedKeysInRangeTx(tx, bid, d1, d2); err != nil {
+				return err
+			}
+		}
+		jl, m, err := getGLExportEntries(tx, bid, d1, d2, done)
+		if err != nil {
+			return err
+		}
+		switch opt.Format {
+		case GLEXPORTIIF:
+			err = writeGLExportIIF(&buf, jl, m)
+		case GLEXPORTCSV:
+			err = writeGLExportCSV(&buf, cols, jl, m)
+		}
+		if err != nil {
+			return err
+		}
+
+		gx.JournalCount = int64(len(jl))
+		if opt.Preview || len(jl) == 0 {
+			return nil
+		}
+		if _, err = InsertGLExportTx(tx, &gx); err != nil {
+			return err
+		}
+		for i := 0; i < len(jl); i++ {
+			a := GLExportJournal{GLEXID: gx.GLEXID, BID: bid, JID: jl[i].JID, Type: jl[i].Type, ID: jl[i].ID, Dt: jl[i].Dt, Amount: jl[i].Amount, CreateBy: opt.UID}
+			if err = InsertGLExportJournalTx(tx, &a); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if opt.Preview {
+		err = export(nil)
+	} else {
+		err = RunInTx(export)
+	}
+	if err != nil {
+		return gx, err
+	}
+	_, err = w.Write(buf.Bytes())
+	return gx, err
+}
+
+// lockGLExportTx takes the lock that serializes the exports of business bid.
+// It is held until tx is committed or rolled back.
+func lockGLExportTx(tx *RRTx, bid int64) error {
+	var id int64
+	err := tx.Stmt(RRdb.Prepstmt.LockBusinessForGLExport).QueryRow(bid).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("lockGLExportTx: could not lock business %d: %s", bid, err.Error())
+	}
+	return nil
+}
+
+// getGLExportEntries returns the Journal entries to export in date order
+// along with a map of JID to the ledger entries for that Journal entry.
+// done holds the number of times each Journal entry has been exported. One
+// Journal entry is skipped for each time its key was exported. The entries
+// are read within tx.
+func getGLExportEntries(tx *RRTx, bid int64, d1, d2 *time.Time, done map[GLExportKey]int) ([]Journal, map[int64][]GLExportEntry, error) {
+	var jl []Journal
+	m := map[int64][]GLExportEntry{}
+
+	le, err := GetAllLedgerEntriesInRangeTx(tx, bid, d1, d2)
+	if err != nil {
+		return jl, m, err
+	}
+	sort.Slice(le, func(i, j int) bool {
+		if le[i].Dt.Equal(le[j].Dt) {
+			if le[i].JID == le[j].JID {
+				return le[i].LEID < le[j].LEID
+			}
+			return le[i].JID < le[j].JID
+		}
+		return le[i].Dt.Before(le[j].Dt)
+	})
+
+	accts := map[int64]GLAccount{}
+	journals := map[int64]Journal{}
+	skip := map[int64]bool{}
+	for i := 0; i < len(le); i++ {
+		if skip[le[i].JID] || le[i].JID == 0 {
+			continue
+		}
+		j, ok := journals[le[i].JID]
+		if !ok {
+			j = GetJournalTx(tx, le[i].JID)
+			if k := glExportJournalKey(&j); done[k] > 0 {
+				done[k]--
+				skip[le[i].JID] = true
+				continue
+			}
+			journals[le[i].JID] = j
+			jl = append(jl, j)
+		}
+		a, ok := accts[le[i].LID]
+		if !ok {
+			a = GetLedger(le[i].LID)
+			accts[le[i].LID] = a
+		}
+		memo := le[i].Comment
+		if len(memo) == 0 {
+			memo = j.Comment
+		}
+		m[le[i].JID] = append(m[le[i].JID], GLExportEntry{
+			JID:      le[i].JID,
+			LID:      le[i].LID,
+			Dt:       le[i].Dt,
+			GLNumber: a.GLNumber,
+			AcctName: a.Name,
+			Amount:   le[i].Amount,
+			Memo:     memo,
+			RAID:     le[i].RAID,
+			RID:      le[i].RID,
+			TCID:     le[i].TCID,
+		})
+	}
+	return jl, m, nil
+}
+
+// writeGLExportIIF writes the entries as QuickBooks general journal
+// transactions. QuickBooks requires each transaction to balance, so an error
+// is returned for any Journal entry whose ledger entries do not sum to 0.
+func writeGLExportIIF(w io.Writer, jl []Journal, m map[int64][]GLExportEntry) error {
+	fmt.Fprintf(w, "!TRNS\tTRNSID\tTRNSTYPE\tDATE\tACCNT\tAMOUNT\tDOCNUM\tMEMO\n")
+	fmt.Fprintf(w, "!SPL\tSPLID\tTRNSTYPE\tDATE\tACCNT\tAMOUNT\tDOCNUM\tMEMO\n")
+	fmt.Fprintf(w, "!ENDTRNS\n")
+	for i := 0; i < len(jl); i++ {
+		e := m[jl[i].JID]
+		tot := float64(0)
+		for k := 0; k < len(e); k++ {
+			tot += e[k].Amount
+		}
+		docnum := IDtoShortString("J", jl[i].JID)
+		if RoundToCent(tot) != 0 {
+			return fmt.Errorf("journal entry %s does not balance, it is off by %.2f", docnum, tot)
+		}
+		for k := 0; k < len(e); k++ {
+			lt := "SPL"
+			if k == 0 {
+				lt = "TRNS"
+			}
+			fmt.Fprintf(w, "%s\t\tGENERAL JOURNAL\t%s\t%s\t%.2f\t%s\t%s\n",
+				lt, e[k].Dt.Format(RRDATEFMT4), glExportField(e[k].AcctName), e[k].Amount, docnum, glExportField(e[k].Memo))
+		}
+		fmt.Fprintf(w, "ENDTRNS\n")
+	}
+	return nil
+}
+
+// writeGLExportCSV writes one line per ledger entry with the supplied columns
+func writeGLExportCSV(w io.Writer, cols []string, jl []Journal, m map[int64][]GLExportEntry) error {
+	wr := csv.NewWriter(w)
+	wr.Write(cols)
+	for i := 0; i < len(jl); i++ {
+		e := m[jl[i].JID]
+		for k := 0; k < len(e); k++ {
+			rec := make([]string, len(cols))
+			for c := 0; c < len(cols); c++ {
+				rec[c] = GLExportCSVColumns[cols[c]](&e[k])
+			}
+			wr.Write(rec)
+		}
+	}
+	wr.Flush()
+	return wr.Error()
+}
@@ -0,0 +1,188 @@
+// +build sqlite
+
+package rlib_test
+
+import (
+	"bytes"
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// journalAssessment saves a non-recurring assessment on rental agreement
+// b.RAID and journals and posts it
+func journalAssessment(t *testing.T, b *rrtest.Biz, ar string, dt time.Time, amount float64) rlib.Assessment {
+	a := rlib.Assessment{BID: b.BID, RID: b.RID[0], RAID: b.RAID, ARID: b.ARID[ar], Amount: amount, Start: dt, Stop: dt, RentCycle: rlib.RECURNONE}
+	if _, err := rlib.InsertAssessment(&a); err != nil {
+		t.Fatalf("InsertAssessment: %s", err.Error())
+	}
+	d1, d2 := time.Date(dt.Year(), dt.Month(), 1, 0, 0, 0, 0, time.UTC), dt.AddDate(0, 1, 0)
+	rlib.InitLedgerCache()
+	rlib.ProcessJournalEntry(&a, &b.XBiz, &d1, &d2, true)
+	return a
+}
+
+// rejournalAssessment removes the Journal entry of a and its ledger entries
+// and makes them again, as a rebuild of the Journal does. The new entry has
+// a new JID.
+func rejournalAssessment(t *testing.T, b *rrtest.Biz, a *rlib.Assessment) {
+	j := rlib.GetJournalByTypeAndID(rlib.JNLTYPEASMT, a.ASMID)
+	rlib.GetJournalAllocations(&j)
+	for i := 0; i < len(j.JA); i++ {
+		le := rlib.GetLedgerEntriesByJAID(b.BID, j.JA[i].JAID)
+		for k := 0; k < len(le); k++ {
+			rlib.DeleteLedgerEntry(le[k].LEID)
+		}
+	}
+	rlib.DeleteJournalAllocations(j.JID)
+	rlib.DeleteJournal(j.JID)
+	d1, d2 := time.Date(a.Start.Year(), a.Start.Month(), 1, 0, 0, 0, 0, time.UTC), a.Start.AddDate(0, 1, 0)
+	rlib.InitLedgerCache()
+	rlib.ProcessJournalEntry(a, &b.XBiz, &d1, &d2, true)
+	if j2 := rlib.GetJournalByTypeAndID(rlib.JNLTYPEASMT, a.ASMID); j2.JID == 0 || j2.JID == j.JID {
+		t.Fatalf("expect a new Journal entry for assessment %d, got JID %d (was %d)", a.ASMID, j2.JID, j.JID)
+	}
+}
+
+func exportGL(t *testing.T, b *rrtest.Biz, opt rlib.GLExportOptions) (rlib.GLExport, string) {
+	var buf bytes.Buffer
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	gx, err := rlib.ExportGL(b.BID, &d1, &d2, &opt, &buf)
+	if err != nil {
+		t.Fatalf("ExportGL: %s", err.Error())
+	}
+	return gx, buf.String()
+}
+
+func TestExportGLCSV(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	journalAssessment(t, b, "Late Fee", rrtest.Dt(2017, 3, 5), 50)
+
+	_, s := exportGL(t, b, rlib.GLExportOptions{Format: rlib.GLEXPORTCSV, Columns: []string{"Date", "GLNumber", "Debit", "Credit"}, Preview: true})
+	expect := "Date,GLNumber,Debit,Credit\n2017-03-05,11001,50.00,\n2017-03-05,42003,,50.00\n"
+	if s != expect {
+		t.Errorf("expect:\n%s\ngot:\n%s", expect, s)
+	}
+
+	var buf bytes.Buffer
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	if _, err := rlib.ExportGL(b.BID, &d1, &d2, &rlib.GLExportOptions{Format: rlib.GLEXPORTCSV, Columns: []string{"Date", "Nope"}}, &buf); err == nil {
+		t.Errorf("expect an error for an unknown csv column")
+	}
+	if _, err := rlib.ExportGL(b.BID, &d1, &d2, &rlib.GLExportOptions{Format: "qbo"}, &buf); err == nil {
+		t.Errorf("expect an error for an unknown format")
+	}
+}
+
+func TestExportGLIIF(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	a := journalAssessment(t, b, "Late Fee", rrtest.Dt(2017, 3, 5), 50)
+	j := rlib.GetJournalByTypeAndID(rlib.JNLTYPEASMT, a.ASMID)
+
+	_, s := exportGL(t, b, rlib.GLExportOptions{Format: rlib.GLEXPORTIIF, Preview: true})
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expect 3 header lines, a TRNS, a SPL and an ENDTRNS line, got:\n%s", s)
+	}
+	if !strings.HasPrefix(lines[0], "!TRNS\t") || !strings.HasPrefix(lines[1], "!SPL\t") || lines[2] != "!ENDTRNS" {
+		t.Errorf("bad IIF header:\n%s", strings.Join(lines[:3], "\n"))
+	}
+	docnum := rlib.IDtoShortString("J", j.JID)
+	trns := strings.Split(lines[3], "\t")
+	spl := strings.Split(lines[4], "\t")
+	if trns[0] != "TRNS" || trns[2] != "GENERAL JOURNAL" || trns[3] != "03/05/2017" || trns[4] != "Accounts Receivable" || trns[5] != "50.00" || trns[6] != docnum {
+		t.Errorf("bad TRNS line: %q", lines[3])
+	}
+	if spl[0] != "SPL" || spl[4] != "Late Fees" || spl[5] != "-50.00" || spl[6] != docnum {
+		t.Errorf("bad SPL line: %q", lines[4])
+	}
+	if lines[5] != "ENDTRNS" {
+		t.Errorf("expect ENDTRNS, got %q", lines[5])
+	}
+}
+
+// An exported Journal entry is not exported again, even after a rebuild of
+// the Journal gives it a new JID, unless ReExport is set
+func TestExportGLDedupe(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	a := journalAssessment(t, b, "Late Fee", rrtest.Dt(2017, 3, 5), 50)
+	csv := rlib.GLExportOptions{Format: rlib.GLEXPORTCSV, Columns: []string{"GLNumber", "Amount"}}
+
+	preview := csv
+	preview.Preview = true
+	if gx, _ := exportGL(t, b, preview); gx.JournalCount != 1 || gx.GLEXID != 0 {
+		t.Errorf("preview: expect 1 journal entry and no export recorded, got %d, GLEXID %d", gx.JournalCount, gx.GLEXID)
+	}
+	if gx, _ := exportGL(t, b, csv); gx.JournalCount != 1 || gx.GLEXID == 0 {
+		t.Fatalf("first export: expect 1 journal entry recorded, got %d, GLEXID %d", gx.JournalCount, gx.GLEXID)
+	}
+	if gx, s := exportGL(t, b, csv); gx.JournalCount != 0 || s != "GLNumber,Amount\n" {
+		t.Errorf("second export: expect nothing, got %d journal entries:\n%s", gx.JournalCount, s)
+	}
+
+	rejournalAssessment(t, b, &a)
+	if gx, s := exportGL(t, b, csv); gx.JournalCount != 0 {
+		t.Errorf("export after a rebuild: expect nothing, got %d journal entries:\n%s", gx.JournalCount, s)
+	}
+
+	journalAssessment(t, b, "Late Fee", rrtest.Dt(2017, 3, 5), 50) // a second, identical late fee
+	if gx, s := exportGL(t, b, csv); gx.JournalCount != 1 || s != "GLNumber,Amount\n11001,50.00\n42003,-50.00\n" {
+		t.Errorf("export after a second late fee: expect it alone, got %d journal entries:\n%s", gx.JournalCount, s)
+	}
+
+	re := csv
+	re.ReExport, re.Preview = true, true
+	if gx, _ := exportGL(t, b, re); gx.JournalCount != 2 {
+		t.Errorf("reexport: expect 2 journal entries, got %d", gx.JournalCount)
+	}
+	m, err := rlib.GetGLExports(b.BID)
+	if err != nil || len(m) != 2 {
+		t.Errorf("expect 2 exports recorded, got %d (%v)", len(m), err)
+	}
+}
+
+// Concurrent exports of a business run one after the other, so each Journal
+// entry is exported and recorded once
+func TestExportGLConcurrent(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	journalAssessment(t, b, "Late Fee", rrtest.Dt(2017, 3, 5), 50)
+	journalAssessment(t, b, "Late Fee", rrtest.Dt(2017, 3, 6), 25)
+
+	const n = 4
+	counts := make(chan int64, n)
+	errs := make(chan error, n)
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	for i := 0; i < n; i++ {
+		go func() {
+			var buf bytes.Buffer
+			gx, err := rlib.ExportGL(b.BID, &d1, &d2, &rlib.GLExportOptions{Format: rlib.GLEXPORTCSV}, &buf)
+			errs <- err
+			counts <- gx.JournalCount
+		}()
+	}
+	total := int64(0)
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("ExportGL: %s", err.Error())
+		}
+		total += <-counts
+	}
+	if total != 2 {
+		t.Errorf("expect the 2 journal entries to be exported once in all, got %d", total)
+	}
+	m, err := rlib.GetGLExportedKeysInRange(b.BID, &d1, &d2)
+	if err != nil {
+		t.Fatalf("GetGLExportedKeysInRange: %s", err.Error())
+	}
+	for k, c := range m {
+		if c != 1 {
+			t.Errorf("journal entry %d of type %d: expect it recorded once, got %d", k.ID, k.Type, c)
+		}
+	}
+}
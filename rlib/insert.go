@@ -28,6 +28,12 @@ func InsertAR(a *AR) (int64, error) {
 // InsertAssessment writes a new assessmenttype record to the database. If the record is successfully written,
 // the ASMID field is set to its new value.
 func InsertAssessment(a *Assessment) (int64, error) {
+	return InsertAssessmentTx(nil, a)
+}
+
+// InsertAssessmentTx is InsertAssessment performed within transaction tx. If
+// tx is nil the database is used directly.
+func InsertAssessmentTx(tx *RRTx, a *Assessment) (int64, error) {
 	var rid = int64(0)
 
 	//
@@ -39,12 +45,13 @@ func InsertAssessment(a *Assessment) (int64, error) {
 	// 	// os.Exit(1)
 	// }
 
-	res, err := RRdb.Prepstmt.InsertAssessment.Exec(a.PASMID, a.RPASMID, a.AGRCPTID, a.BID, a.RID, a.ATypeLID, a.RAID, a.Amount, a.Start, a.Stop, a.RentCycle, a.ProrationCycle, a.InvoiceNo, a.AcctRule, a.ARID, a.FLAGS, a.Comment, a.CreateBy, a.LastModBy)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertAssessment).Exec(a.PASMID, a.RPASMID, a.AGRCPTID, a.BID, a.RID, a.ATypeLID, a.RAID, a.Amount, a.Start, a.Stop, a.RentCycle, a.ProrationCycle, a.InvoiceNo, a.AcctRule, a.ARID, a.FLAGS, a.Comment, a.CreateBy, a.LastModBy)
 	if nil == err {
 		id, err := res.LastInsertId()
 		if err == nil {
 			rid = int64(id)
 			a.ASMID = rid
+			return rid, emitInsertEvent(tx, a.BID, "assessment", a.RPASMID, rid, a, a.CreateBy)
 		}
 	} else {
 		err = insertError(err, "Insert", *a)
@@ -99,6 +106,43 @@ func InsertBusiness(b *Business) (int64, error) {
 	return bid, err
 }
 
+// InsertBusinessGroup writes a new BusinessGroup record to the database
+func InsertBusinessGroup(a *BusinessGroup) (int64, error) {
+	return InsertBusinessGroupTx(nil, a)
+}
+
+// InsertBusinessGroupTx is InsertBusinessGroup performed within transaction
+// tx. If tx is nil the database is used directly.
+func InsertBusinessGroupTx(tx *RRTx, a *BusinessGroup) (int64, error) {
+	var rid = int64(0)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertBusinessGroup).Exec(a.Name, a.GroupType, a.Description, a.CreateBy, a.LastModBy)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.BGID = rid
+		}
+	} else {
+		err = insertError(err, "BusinessGroup", *a)
+	}
+	return rid, err
+}
+
+// InsertBusinessGroupMember writes a new BusinessGroupMember record to the database
+func InsertBusinessGroupMember(a *BusinessGroupMember) error {
+	return InsertBusinessGroupMemberTx(nil, a)
+}
+
+// InsertBusinessGroupMemberTx is InsertBusinessGroupMember performed within
+// transaction tx. If tx is nil the database is used directly.
+func InsertBusinessGroupMemberTx(tx *RRTx, a *BusinessGroupMember) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.InsertBusinessGroupMember).Exec(a.BGID, a.BID, a.CreateBy)
+	if nil != err {
+		return insertError(err, "BusinessGroupMember", *a)
+	}
+	return err
+}
+
 // InsertCustomAttribute writes a new User record to the database
 func InsertCustomAttribute(a *CustomAttribute) (int64, error) {
 	var tid = int64(0)
@@ -137,13 +181,20 @@ func InsertDemandSource(a *DemandSource) (int64, error) {
 
 // InsertDeposit writes a new Deposit record to the database
 func InsertDeposit(a *Deposit) (int64, error) {
+	return InsertDepositTx(nil, a)
+}
+
+// InsertDepositTx is InsertDeposit performed within transaction tx. If tx is
+// nil the database is used directly.
+func InsertDepositTx(tx *RRTx, a *Deposit) (int64, error) {
 	var rid = int64(0)
-	res, err := RRdb.Prepstmt.InsertDeposit.Exec(a.BID, a.DEPID, a.DPMID, a.Dt, a.Amount, a.ClearedAmount, a.FLAGS, a.CreateBy, a.LastModBy)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertDeposit).Exec(a.BID, a.DEPID, a.DPMID, a.Dt, a.Amount, a.ClearedAmount, a.FLAGS, a.CreateBy, a.LastModBy)
 	if nil == err {
 		id, err := res.LastInsertId()
 		if err == nil {
 			rid = int64(id)
 			a.DID = rid
+			return rid, emitInsertEvent(tx, a.BID, "deposit", 0, rid, a, a.CreateBy)
 		}
 	} else {
 		err = insertError(err, "Deposit", *a)
@@ -162,7 +213,13 @@ func InsertDepositMethod(a *DepositMethod) error {
 
 // InsertDepositPart writes a new DepositPart record to the database
 func InsertDepositPart(a *DepositPart) error {
-	_, err := RRdb.Prepstmt.InsertDepositPart.Exec(a.DID, a.BID, a.RCPTID, a.CreateBy, a.LastModBy)
+	return InsertDepositPartTx(nil, a)
+}
+
+// InsertDepositPartTx is InsertDepositPart performed within transaction tx. If
+// tx is nil the database is used directly.
+func InsertDepositPartTx(tx *RRTx, a *DepositPart) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.InsertDepositPart).Exec(a.DID, a.BID, a.RCPTID, a.CreateBy, a.LastModBy)
 	if nil != err {
 		return insertError(err, "DepositPart", *a)
 	}
@@ -191,13 +248,20 @@ func InsertDepository(a *Depository) (int64, error) {
 
 // InsertExpense writes a new Expense record to the database
 func InsertExpense(a *Expense) error {
+	return InsertExpenseTx(nil, a)
+}
+
+// InsertExpenseTx is InsertExpense performed within transaction tx. If tx is
+// nil the database is used directly.
+func InsertExpenseTx(tx *RRTx, a *Expense) error {
 	var rid = int64(0)
-	res, err := RRdb.Prepstmt.InsertExpense.Exec(a.RPEXPID, a.BID, a.RID, a.RAID, a.Amount, a.Dt, a.AcctRule, a.ARID, a.FLAGS, a.Comment, a.CreateBy, a.LastModBy)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertExpense).Exec(a.RPEXPID, a.BID, a.RID, a.RAID, a.Amount, a.Dt, a.AcctRule, a.ARID, a.FLAGS, a.Comment, a.CreateBy, a.LastModBy)
 	if nil == err {
 		id, err := res.LastInsertId()
 		if err == nil {
 			rid = int64(id)
 			a.EXPID = rid
+			return emitInsertEvent(tx, a.BID, "expense", a.RPEXPID, rid, a, a.CreateBy)
 		}
 	} else {
 		return insertError(err, "Expense", *a)
@@ -205,6 +269,110 @@ func InsertExpense(a *Expense) error {
 	return nil
 }
 
+//======================================
+//  ACCOUNTS PAYABLE
+//======================================
+
+// InsertVendor writes a new Vendor record to the database
+func InsertVendor(a *Vendor) (int64, error) {
+	var rid = int64(0)
+	res, err := RRdb.Prepstmt.InsertVendor.Exec(a.BID, a.Name, a.Address, a.Address2, a.City, a.State, a.PostalCode, a.Country, a.Phone, a.Email, a.TaxID, a.DefaultLID, a.FLAGS, a.CreateBy, a.LastModBy)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.VENDID = rid
+		}
+	} else {
+		err = insertError(err, "Vendor", *a)
+	}
+	return rid, err
+}
+
+// InsertDirectoryPerson writes a new DirectoryPerson record to the database
+func InsertDirectoryPerson(a *DirectoryPerson) (int64, error) {
+	var rid = int64(0)
+	res, err := RRdb.Prepstmt.InsertDirectoryPerson.Exec(a.FirstName, a.LastName, a.PreferredName, a.Email, a.Phone, a.FLAGS, a.LastModBy, a.CreateBy)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.UID = rid
+		}
+	} else {
+		err = insertError(err, "DirectoryPerson", *a)
+	}
+	return rid, err
+}
+
+// InsertBill writes a new Bill record to the database
+func InsertBill(a *Bill) (int64, error) {
+	return InsertBillTx(nil, a)
+}
+
+// InsertBillTx is InsertBill performed within transaction tx. If tx is nil the
+// database is used directly.
+func InsertBillTx(tx *RRTx, a *Bill) (int64, error) {
+	var rid = int64(0)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertBill).Exec(a.RPBILLID, a.BID, a.VENDID, a.APLID, a.Dt, a.DtDue, a.Amount, a.DocNo, a.FLAGS, a.Comment, a.CreateBy, a.LastModBy)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.BILLID = rid
+			return rid, emitInsertEvent(tx, a.BID, "bill", a.RPBILLID, rid, a, a.CreateBy)
+		}
+	} else {
+		err = insertError(err, "Bill", *a)
+	}
+	return rid, err
+}
+
+// InsertBillItem writes a new BillItem record to the database
+func InsertBillItem(a *BillItem) (int64, error) {
+	return InsertBillItemTx(nil, a)
+}
+
+// InsertBillItemTx is InsertBillItem performed within transaction tx. If tx is
+// nil the database is used directly.
+func InsertBillItemTx(tx *RRTx, a *BillItem) (int64, error) {
+	var rid = int64(0)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertBillItem).Exec(a.BILLID, a.BID, a.LID, a.RID, a.Amount, a.Description, a.CreateBy, a.LastModBy)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.BIID = rid
+		}
+	} else {
+		err = insertError(err, "BillItem", *a)
+	}
+	return rid, err
+}
+
+// InsertBillPayment writes a new BillPayment record to the database
+func InsertBillPayment(a *BillPayment) (int64, error) {
+	return InsertBillPaymentTx(nil, a)
+}
+
+// InsertBillPaymentTx is InsertBillPayment performed within transaction tx. If
+// tx is nil the database is used directly.
+func InsertBillPaymentTx(tx *RRTx, a *BillPayment) (int64, error) {
+	var rid = int64(0)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertBillPayment).Exec(a.RPBPID, a.BID, a.BILLID, a.VENDID, a.DEPID, a.Dt, a.Amount, a.DocNo, a.FLAGS, a.Comment, a.CreateBy, a.LastModBy)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.BPID = rid
+			return rid, emitInsertEvent(tx, a.BID, "billpayment", a.RPBPID, rid, a, a.CreateBy)
+		}
+	} else {
+		err = insertError(err, "BillPayment", *a)
+	}
+	return rid, err
+}
+
 //======================================
 //  INVOICE
 //======================================
@@ -244,9 +412,15 @@ func InsertInvoicePayor(a *InvoicePayor) error {
 
 // InsertJournal writes a new Journal entry to the database
 func InsertJournal(j *Journal) (int64, error) {
+	return InsertJournalTx(nil, j)
+}
+
+// InsertJournalTx is InsertJournal performed within transaction tx. If tx is
+// nil the database is used directly.
+func InsertJournalTx(tx *RRTx, j *Journal) (int64, error) {
 	var id = int64(0)
 
-	res, err := RRdb.Prepstmt.InsertJournal.Exec(j.BID, j.Dt, j.Amount, j.Type, j.ID, j.Comment, j.CreateBy, j.LastModBy)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertJournal).Exec(j.BID, j.Dt, j.Amount, j.Type, j.ID, j.Comment, j.CreateBy, j.LastModBy)
 	if nil == err {
 		nid, err := res.LastInsertId()
 		if err == nil {
@@ -260,8 +434,14 @@ func InsertJournal(j *Journal) (int64, error) {
 // InsertJournalAllocationEntry writes a new JournalAllocation record to the database. Also sets JAID with its
 // newly assigned id.
 func InsertJournalAllocationEntry(ja *JournalAllocation) error {
+	return InsertJournalAllocationEntryTx(nil, ja)
+}
+
+// InsertJournalAllocationEntryTx is InsertJournalAllocationEntry performed
+// within transaction tx. If tx is nil the database is used directly.
+func InsertJournalAllocationEntryTx(tx *RRTx, ja *JournalAllocation) error {
 	// debug.PrintStack()
-	res, err := RRdb.Prepstmt.InsertJournalAllocation.Exec(ja.BID, ja.JID, ja.RID, ja.RAID, ja.TCID, ja.RCPTID, ja.Amount, ja.ASMID, ja.EXPID, ja.AcctRule, ja.CreateBy)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertJournalAllocation).Exec(ja.BID, ja.JID, ja.RID, ja.RAID, ja.TCID, ja.RCPTID, ja.Amount, ja.ASMID, ja.EXPID, ja.AcctRule, ja.CreateBy)
 	if nil == err {
 		id, err := res.LastInsertId()
 		if err == nil {
@@ -277,6 +457,178 @@ func InsertJournalMarker(jm *JournalMarker) error {
 	return err
 }
 
+//======================================
+//  DIRTY RANGE
+//======================================
+
+// InsertDirtyRange writes a new DirtyRange record to the database
+func InsertDirtyRange(a *DirtyRange) (int64, error) {
+	return InsertDirtyRangeTx(nil, a)
+}
+
+// InsertDirtyRangeTx is InsertDirtyRange performed within transaction tx. If
+// tx is nil the database is used directly.
+func InsertDirtyRangeTx(tx *RRTx, a *DirtyRange) (int64, error) {
+	var rid = int64(0)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertDirtyRange).Exec(a.BID, a.RID, a.DtStart, a.DtStop)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.DRID = rid
+		}
+	} else {
+		err = insertError(err, "DirtyRange", *a)
+	}
+	return rid, err
+}
+
+//======================================
+//  WEBHOOK
+//======================================
+
+// InsertWebhook writes a new Webhook record to the database
+func InsertWebhook(a *Webhook) (int64, error) {
+	var rid = int64(0)
+	res, err := RRdb.Prepstmt.InsertWebhook.Exec(a.BID, a.URL, a.Secret, a.EventTypes, a.FLAGS, a.LastModBy, a.CreateBy)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.WHID = rid
+			ClearWebhookCache()
+		}
+	} else {
+		err = insertError(err, "Webhook", *a)
+	}
+	return rid, err
+}
+
+// InsertOutboxEvent writes a new OutboxEvent record to the database
+func InsertOutboxEvent(a *OutboxEvent) (int64, error) {
+	return InsertOutboxEventTx(nil, a)
+}
+
+// InsertOutboxEventTx is InsertOutboxEvent performed within transaction tx. If
+// tx is nil the database is used directly.
+func InsertOutboxEventTx(tx *RRTx, a *OutboxEvent) (int64, error) {
+	var rid = int64(0)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertOutboxEvent).Exec(a.BID, a.EventType, a.ObjID, a.Payload, a.CreateBy)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.EVID = rid
+		}
+	} else {
+		err = insertError(err, "OutboxEvent", *a)
+	}
+	return rid, err
+}
+
+// InsertWebhookDelivery writes a new WebhookDelivery record to the database
+func InsertWebhookDelivery(a *WebhookDelivery) (int64, error) {
+	return InsertWebhookDeliveryTx(nil, a)
+}
+
+// InsertWebhookDeliveryTx is InsertWebhookDelivery performed within
+// transaction tx. If tx is nil the database is used directly.
+func InsertWebhookDeliveryTx(tx *RRTx, a *WebhookDelivery) (int64, error) {
+	var rid = int64(0)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertWebhookDelivery).Exec(a.WHID, a.EVID, a.BID, a.Status, a.Attempts, a.NextAttempt, a.LastAttempt, a.HTTPStatus, a.LastError)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.WHDID = rid
+		}
+	} else {
+		err = insertError(err, "WebhookDelivery", *a)
+	}
+	return rid, err
+}
+
+//======================================
+//  REPORT SCHEDULE
+//======================================
+
+// InsertReportSchedule writes a new ReportSchedule record to the database
+func InsertReportSchedule(a *ReportSchedule) (int64, error) {
+	var rid = int64(0)
+	res, err := RRdb.Prepstmt.InsertReportSchedule.Exec(a.BID, a.Name, a.Report, a.Params, a.DateRule, a.OutputFormat, a.Cycle, a.NextRun, a.LastRun, a.LastError, a.Recipients, a.Directory, a.FLAGS, a.LastModBy, a.CreateBy)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.RSID = rid
+		}
+	} else {
+		err = insertError(err, "ReportSchedule", *a)
+	}
+	return rid, err
+}
+
+//======================================
+//  RENT ROLL SNAPSHOT
+//======================================
+
+// InsertRentRollSnapshot writes a new RentRollSnapshot record to the database
+func InsertRentRollSnapshot(a *RentRollSnapshot) (int64, error) {
+	var rid = int64(0)
+	res, err := RRdb.Prepstmt.InsertRentRollSnapshot.Exec(a.BID, a.DtStart, a.DtStop, a.PeriodGSR, a.EndReceivable, a.EndSecDep, a.Payload, a.CreateBy)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.RRSID = rid
+		}
+	} else {
+		err = insertError(err, "RentRollSnapshot", *a)
+	}
+	return rid, err
+}
+
+//======================================
+//  GL EXPORT
+//======================================
+
+// InsertGLExport writes a new GLExport record to the database
+func InsertGLExport(a *GLExport) (int64, error) {
+	return InsertGLExportTx(nil, a)
+}
+
+// InsertGLExportTx is InsertGLExport performed within transaction tx. If tx
+// is nil the database is used directly.
+func InsertGLExportTx(tx *RRTx, a *GLExport) (int64, error) {
+	var rid = int64(0)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertGLExport).Exec(a.BID, a.Format, a.DtStart, a.DtStop, a.JournalCount, a.CreateBy, a.LastModBy)
+	if nil == err {
+		id, err := res.LastInsertId()
+		if err == nil {
+			rid = int64(id)
+			a.GLEXID = rid
+		}
+	} else {
+		err = insertError(err, "GLExport", *a)
+	}
+	return rid, err
+}
+
+// InsertGLExportJournal writes a new GLExportJournal record to the database
+func InsertGLExportJournal(a *GLExportJournal) error {
+	return InsertGLExportJournalTx(nil, a)
+}
+
+// InsertGLExportJournalTx is InsertGLExportJournal performed within
+// transaction tx. If tx is nil the database is used directly.
+func InsertGLExportJournalTx(tx *RRTx, a *GLExportJournal) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.InsertGLExportJournal).Exec(a.GLEXID, a.BID, a.JID, a.Type, a.ID, a.Dt, a.Amount, a.CreateBy)
+	if nil != err {
+		return insertError(err, "GLExportJournal", *a)
+	}
+	return nil
+}
+
 //======================================
 //  LEDGER MARKER
 //======================================
@@ -297,8 +649,14 @@ func InsertLedgerMarker(l *LedgerMarker) error {
 
 // InsertLedgerEntry writes a new LedgerEntry to the database
 func InsertLedgerEntry(l *LedgerEntry) (int64, error) {
+	return InsertLedgerEntryTx(nil, l)
+}
+
+// InsertLedgerEntryTx is InsertLedgerEntry performed within transaction tx. If
+// tx is nil the database is used directly.
+func InsertLedgerEntryTx(tx *RRTx, l *LedgerEntry) (int64, error) {
 	var rid = int64(0)
-	res, err := RRdb.Prepstmt.InsertLedgerEntry.Exec(l.BID, l.JID, l.JAID, l.LID, l.RAID, l.RID, l.TCID, l.Dt, l.Amount, l.Comment, l.CreateBy, l.LastModBy)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertLedgerEntry).Exec(l.BID, l.JID, l.JAID, l.LID, l.RAID, l.RID, l.TCID, l.Dt, l.Amount, l.Comment, l.CreateBy, l.LastModBy)
 	if nil == err {
 		id, err := res.LastInsertId()
 		if err == nil {
@@ -513,13 +871,20 @@ func InsertRentable(a *Rentable) (int64, error) {
 // InsertReceipt writes a new Receipt record to the database. If the record is successfully written,
 // the RCPTID field is set to its new value.
 func InsertReceipt(r *Receipt) (int64, error) {
+	return InsertReceiptTx(nil, r)
+}
+
+// InsertReceiptTx is InsertReceipt performed within transaction tx. If tx is
+// nil the database is used directly.
+func InsertReceiptTx(tx *RRTx, r *Receipt) (int64, error) {
 	var tid = int64(0)
-	res, err := RRdb.Prepstmt.InsertReceipt.Exec(r.PRCPTID, r.BID, r.TCID, r.PMTID, r.DEPID, r.DID, r.RAID, r.Dt, r.DocNo, r.Amount, r.AcctRuleReceive, r.ARID, r.AcctRuleApply, r.FLAGS, r.Comment, r.OtherPayorName, r.CreateBy, r.LastModBy)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertReceipt).Exec(r.PRCPTID, r.BID, r.TCID, r.PMTID, r.DEPID, r.DID, r.RAID, r.Dt, r.DocNo, r.Amount, r.AcctRuleReceive, r.ARID, r.AcctRuleApply, r.FLAGS, r.Comment, r.OtherPayorName, r.CreateBy, r.LastModBy)
 	if nil == err {
 		id, err := res.LastInsertId()
 		if err == nil {
 			tid = int64(id)
 			r.RCPTID = tid
+			return tid, emitInsertEvent(tx, r.BID, "receipt", r.PRCPTID, tid, r, r.CreateBy)
 		}
 	} else {
 		err = insertError(err, "Receipt", *r)
@@ -529,8 +894,14 @@ func InsertReceipt(r *Receipt) (int64, error) {
 
 // InsertReceiptAllocation writes a new ReceiptAllocation record to the database
 func InsertReceiptAllocation(a *ReceiptAllocation) (int64, error) {
+	return InsertReceiptAllocationTx(nil, a)
+}
+
+// InsertReceiptAllocationTx is InsertReceiptAllocation performed within
+// transaction tx. If tx is nil the database is used directly.
+func InsertReceiptAllocationTx(tx *RRTx, a *ReceiptAllocation) (int64, error) {
 	var tid = int64(0)
-	res, err := RRdb.Prepstmt.InsertReceiptAllocation.Exec(a.RCPTID, a.BID, a.RAID, a.Dt, a.Amount, a.ASMID, a.FLAGS, a.AcctRule, a.CreateBy, a.LastModBy)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertReceiptAllocation).Exec(a.RCPTID, a.BID, a.RAID, a.Dt, a.Amount, a.ASMID, a.FLAGS, a.AcctRule, a.CreateBy, a.LastModBy)
 	if nil == err {
 		id, err := res.LastInsertId()
 		if err == nil {
@@ -545,13 +916,20 @@ func InsertReceiptAllocation(a *ReceiptAllocation) (int64, error) {
 
 // InsertRentalAgreement writes a new RentalAgreement record to the database
 func InsertRentalAgreement(a *RentalAgreement) (int64, error) {
+	return InsertRentalAgreementTx(nil, a)
+}
+
+// InsertRentalAgreementTx is InsertRentalAgreement performed within
+// transaction tx. If tx is nil the database is used directly.
+func InsertRentalAgreementTx(tx *RRTx, a *RentalAgreement) (int64, error) {
 	var tid = int64(0)
-	res, err := RRdb.Prepstmt.InsertRentalAgreement.Exec(a.RATID, a.BID, a.NLID, a.AgreementStart, a.AgreementStop, a.PossessionStart, a.PossessionStop, a.RentStart, a.RentStop, a.RentCycleEpoch, a.UnspecifiedAdults, a.UnspecifiedChildren, a.Renewal, a.SpecialProvisions, a.LeaseType, a.ExpenseAdjustmentType, a.ExpensesStop, a.ExpenseStopCalculation, a.BaseYearEnd, a.ExpenseAdjustment, a.EstimatedCharges, a.RateChange, a.NextRateChange, a.PermittedUses, a.ExclusiveUses, a.ExtensionOption, a.ExtensionOptionNotice, a.ExpansionOption, a.ExpansionOptionNotice, a.RightOfFirstRefusal, a.FLAGS, a.CreateBy, a.LastModBy)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertRentalAgreement).Exec(a.RATID, a.BID, a.NLID, a.AgreementStart, a.AgreementStop, a.PossessionStart, a.PossessionStop, a.RentStart, a.RentStop, a.RentCycleEpoch, a.UnspecifiedAdults, a.UnspecifiedChildren, a.Renewal, a.SpecialProvisions, a.LeaseType, a.ExpenseAdjustmentType, a.ExpensesStop, a.ExpenseStopCalculation, a.BaseYearEnd, a.ExpenseAdjustment, a.EstimatedCharges, a.RateChange, a.NextRateChange, a.PermittedUses, a.ExclusiveUses, a.ExtensionOption, a.ExtensionOptionNotice, a.ExpansionOption, a.ExpansionOptionNotice, a.RightOfFirstRefusal, a.FLAGS, a.CreateBy, a.LastModBy)
 	if nil == err {
 		id, err := res.LastInsertId()
 		if err == nil {
 			tid = int64(id)
 			a.RAID = tid
+			return tid, emitInsertEvent(tx, a.BID, "rentalagreement", 0, tid, a, a.CreateBy)
 		}
 	} else {
 		err = insertError(err, "RentalAgreement", *a)
@@ -593,19 +971,32 @@ func InsertRentalAgreementPet(a *RentalAgreementPet) (int64, error) {
 // InsertRentalAgreementRentable writes a new User record to the database
 func InsertRentalAgreementRentable(a *RentalAgreementRentable) (int64, error) {
 	var tid = int64(0)
-	res, err := RRdb.Prepstmt.InsertRentalAgreementRentable.Exec(a.RAID, a.BID, a.RID, a.CLID, a.ContractRent, a.RARDtStart, a.RARDtStop, a.CreateBy)
-	if nil == err {
-		id, err := res.LastInsertId()
-		if err == nil {
-			tid = int64(id)
-			a.RARID = tid
-		}
-	} else {
-		err = insertError(err, "RentalAgreementRentable", *a)
-	}
+	err := RunInTx(func(tx *RRTx) error {
+		var err error
+		tid, err = InsertRentalAgreementRentableTx(tx, a)
+		return err
+	})
 	return tid, err
 }
 
+// InsertRentalAgreementRentableTx is InsertRentalAgreementRentable performed
+// within transaction tx. The vacancy of the rentable is marked dirty in tx.
+// If tx is nil the database is used directly.
+func InsertRentalAgreementRentableTx(tx *RRTx, a *RentalAgreementRentable) (int64, error) {
+	var tid = int64(0)
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertRentalAgreementRentable).Exec(a.RAID, a.BID, a.RID, a.CLID, a.ContractRent, a.RARDtStart, a.RARDtStop, a.CreateBy)
+	if nil != err {
+		return tid, insertError(err, "RentalAgreementRentable", *a)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return tid, err
+	}
+	tid = int64(id)
+	a.RARID = tid
+	return tid, MarkDirtyTx(tx, a.BID, a.RID, &a.RARDtStart, &a.RARDtStop)
+}
+
 //=======================================================
 //  RENTAL AGREEMENT TEMPLATE
 //=======================================================
@@ -633,8 +1024,21 @@ func InsertRentableSpecialty(a *RentableSpecialty) error {
 
 // InsertRentableMarketRates writes a new marketrate record to the database
 func InsertRentableMarketRates(r *RentableMarketRate) error {
-	_, err := RRdb.Prepstmt.InsertRentableMarketRates.Exec(r.RTID, r.BID, r.MarketRate, r.DtStart, r.DtStop, r.CreateBy)
-	return err
+	return RunInTx(func(tx *RRTx) error {
+		return InsertRentableMarketRatesTx(tx, r)
+	})
+}
+
+// InsertRentableMarketRatesTx is InsertRentableMarketRates performed within
+// transaction tx. The vacancy of every rentable of the RentableType is marked
+// dirty in tx for the period of the market rate. If tx is nil the database is
+// used directly.
+func InsertRentableMarketRatesTx(tx *RRTx, r *RentableMarketRate) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.InsertRentableMarketRates).Exec(r.RTID, r.BID, r.MarketRate, r.DtStart, r.DtStop, r.CreateBy)
+	if err != nil {
+		return err
+	}
+	return MarkRentableTypeDirtyTx(tx, r.BID, r.RTID, &r.DtStart, &r.DtStop)
 }
 
 // InsertRentableType writes a new RentableType record to the database
@@ -655,35 +1059,66 @@ func InsertRentableType(a *RentableType) (int64, error) {
 
 // InsertRentableSpecialtyRef writes a new RentableSpecialty record to the database
 func InsertRentableSpecialtyRef(a *RentableSpecialtyRef) error {
-	_, err := RRdb.Prepstmt.InsertRentableSpecialtyRef.Exec(a.BID, a.RID, a.RSPID, a.DtStart, a.DtStop, a.CreateBy, a.LastModBy)
-	return err
+	return RunInTx(func(tx *RRTx) error {
+		return InsertRentableSpecialtyRefTx(tx, a)
+	})
+}
+
+// InsertRentableSpecialtyRefTx is InsertRentableSpecialtyRef performed within
+// transaction tx. The vacancy of the rentable is marked dirty in tx. If tx is
+// nil the database is used directly.
+func InsertRentableSpecialtyRefTx(tx *RRTx, a *RentableSpecialtyRef) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.InsertRentableSpecialtyRef).Exec(a.BID, a.RID, a.RSPID, a.DtStart, a.DtStop, a.CreateBy, a.LastModBy)
+	if err != nil {
+		return err
+	}
+	return MarkDirtyTx(tx, a.BID, a.RID, &a.DtStart, &a.DtStop)
 }
 
 // InsertRentableStatus writes a new RentableStatus record to the database
 func InsertRentableStatus(a *RentableStatus) error {
-	res, err := RRdb.Prepstmt.InsertRentableStatus.Exec(a.RID, a.BID, a.DtStart, a.DtStop, a.DtNoticeToVacate, a.UseStatus, a.LeaseStatus, a.CreateBy, a.LastModBy)
+	return RunInTx(func(tx *RRTx) error {
+		return InsertRentableStatusTx(tx, a)
+	})
+}
+
+// InsertRentableStatusTx is InsertRentableStatus performed within transaction
+// tx. The vacancy of the rentable is marked dirty in tx. If tx is nil the
+// database is used directly.
+func InsertRentableStatusTx(tx *RRTx, a *RentableStatus) error {
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertRentableStatus).Exec(a.RID, a.BID, a.DtStart, a.DtStop, a.DtNoticeToVacate, a.UseStatus, a.LeaseStatus, a.CreateBy, a.LastModBy)
 	if nil != err {
 		return insertError(err, "RentableStatus", *a)
 	}
 	id, err := res.LastInsertId()
-	if err == nil {
-		a.RSID = int64(id)
+	if err != nil {
+		return err
 	}
-	return err
-
+	a.RSID = int64(id)
+	return MarkDirtyTx(tx, a.BID, a.RID, &a.DtStart, &a.DtStop)
 }
 
 // InsertRentableTypeRef writes a new RentableTypeRef record to the database
 func InsertRentableTypeRef(a *RentableTypeRef) error {
-	res, err := RRdb.Prepstmt.InsertRentableTypeRef.Exec(a.RID, a.BID, a.RTID, a.OverrideRentCycle, a.OverrideProrationCycle, a.DtStart, a.DtStop, a.CreateBy, a.LastModBy)
+	return RunInTx(func(tx *RRTx) error {
+		return InsertRentableTypeRefTx(tx, a)
+	})
+}
+
+// InsertRentableTypeRefTx is InsertRentableTypeRef performed within
+// transaction tx. The vacancy of the rentable is marked dirty in tx. If tx is
+// nil the database is used directly.
+func InsertRentableTypeRefTx(tx *RRTx, a *RentableTypeRef) error {
+	res, err := tx.Stmt(RRdb.Prepstmt.InsertRentableTypeRef).Exec(a.RID, a.BID, a.RTID, a.OverrideRentCycle, a.OverrideProrationCycle, a.DtStart, a.DtStop, a.CreateBy, a.LastModBy)
 	if nil != err {
 		return insertError(err, "RentableTypeRef", *a)
 	}
 	id, err := res.LastInsertId()
-	if err == nil {
-		a.RTRID = int64(id)
+	if err != nil {
+		return err
 	}
-	return err
+	a.RTRID = int64(id)
+	return MarkDirtyTx(tx, a.BID, a.RID, &a.DtStart, &a.DtStop)
 }
 
 // InsertRentableUser writes a new User record to the database
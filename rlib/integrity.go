@@ -0,0 +1,458 @@
+package rlib
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CheckIntegrity looks for records that disagree with each other: Journal
+// entries whose allocations do not add up, allocations whose account rules
+// do not balance or that were never posted, LedgerMarkers that do not agree
+// with the LedgerEntries since the previous marker, Assessment and Receipt
+// FLAGS that do not agree with their ReceiptAllocations, and rows that refer
+// to records that no longer exist.
+//
+// Some of the problems can be repaired without a person looking at them
+// because the records involved are derived from others: a missing
+// LedgerEntry is posted again, the balance of an open LedgerMarker is
+// recomputed, FLAGS are set from the allocations, and Journal allocations
+// and LedgerMarkers that refer to nothing are removed.  The rest are only
+// reported.  A LedgerEntry that refers to nothing is counted in the balance
+// of the LedgerMarkers after it, so it is not removed.
+
+// Integrity problems found by CheckIntegrity
+const (
+	INTEGJNLAMOUNT  = 1  // a Journal's allocations do not sum to its Amount
+	INTEGUNBALANCED = 2  // a Journal allocation's account rule does not balance
+	INTEGNOLEDGER   = 3  // a Journal allocation has no LedgerEntries
+	INTEGGLMARKER   = 4  // a GL LedgerMarker does not agree with the entries since the previous one
+	INTEGSUBMARKER  = 5  // an RA, Rentable or payor LedgerMarker does not agree with its entries
+	INTEGASMFLAGS   = 6  // an Assessment's paid state does not agree with its allocations
+	INTEGRCPTFLAGS  = 7  // a Receipt's allocated state does not agree with its allocations
+	INTEGOVERALLOC  = 8  // a Receipt or Assessment has more allocated to it than its Amount
+	INTEGORPHANJA   = 9  // a Journal allocation whose Journal does not exist
+	INTEGORPHANLE   = 10 // a LedgerEntry whose Journal allocation does not exist
+	INTEGORPHANLM   = 11 // a LedgerMarker whose GLAccount does not exist
+	INTEGORPHANRA   = 12 // a ReceiptAllocation whose Receipt or Assessment does not exist
+	INTEGORPHANJNL  = 13 // a Journal whose Assessment or Receipt does not exist
+)
+
+// IntegrityFinding is one problem found by CheckIntegrity
+type IntegrityFinding struct {
+	Kind       int       // which problem, INTEGJNLAMOUNT ...
+	Dt         time.Time // date of the record, if it has one
+	Table      string    // table of the record with the problem
+	ID         int64     // id of the record with the problem
+	LID        int64     // GLAccount, if any
+	Expected   float64   // amount the record should have
+	Actual     float64   // amount it has
+	Comment    string    // description of the problem
+	Repairable bool      // true if CheckIntegrity can repair it
+	Repaired   bool      // true if CheckIntegrity repaired it
+}
+
+// CheckIntegrity checks the records of xbiz.  Journal entries, LedgerMarkers,
+// Assessments and Receipts are checked over d1 - d2.  The orphaned row
+// checks cover the whole business.  If repair is true the problems that are
+// safe to repair are repaired.
+//
+// INPUTS
+//  xbiz   - the business, with its chart of accounts loaded
+//  d1, d2 - the period to check
+//  repair - true to repair the problems that can be repaired
+//
+// RETURNS
+//  the problems found, none if the records agree
+//  any error encountered
+//-----------------------------------------------------------------------------
+func CheckIntegrity(xbiz *XBusiness, d1, d2 *time.Time, repair bool) ([]IntegrityFinding, error) {
+	var m []IntegrityFinding
+	checks := []func(*XBusiness, *time.Time, *time.Time, bool) ([]IntegrityFinding, error){
+		checkJournalIntegrity,
+		checkGLMarkerIntegrity,
+		checkSubLedgerMarkerIntegrity,
+		checkAssessmentFlagsIntegrity,
+		checkReceiptFlagsIntegrity,
+		checkOrphanIntegrity,
+	}
+	InitLedgerCache()
+	for i := 0; i < len(checks); i++ {
+		n, err := checks[i](xbiz, d1, d2, repair)
+		m = append(m, n...)
+		if err != nil {
+			return m, err
+		}
+	}
+	sort.SliceStable(m, func(i, j int) bool {
+		if m[i].Kind != m[j].Kind {
+			return m[i].Kind < m[j].Kind
+		}
+		return m[i].ID < m[j].ID
+	})
+	return m, nil
+}
+
+// checkJournalIntegrity checks that the allocations of each Journal entry in
+// d1 - d2 sum to its Amount, that the account rule of each allocation
+// balances, and that each allocation has been posted.  Unposted allocations
+// are posted if repair is true.
+func checkJournalIntegrity(xbiz *XBusiness, d1, d2 *time.Time, repair bool) ([]IntegrityFinding, error) {
+	var m []IntegrityFinding
+	bid := xbiz.P.BID
+	rows, err := RRdb.Prepstmt.GetAllJournalsInRange.Query(bid, d1, d2)
+	if err != nil {
+		return m, err
+	}
+	jnls := getJournalRowsWithAllocations(rows)
+	for i := 0; i < len(jnls); i++ {
+		j := &jnls[i]
+		tot := float64(0)
+		var unposted []int // indexes in m of the allocations that have not been posted
+		for k := 0; k < len(j.JA); k++ {
+			ja := &j.JA[k]
+			tot += ja.Amount
+			ar := ParseAcctRule(xbiz, ja.RID, d1, d2, ja.AcctRule, ja.Amount, 1.0)
+			sum, debits := sumAllocations(&ar)
+			if len(ar) == 0 || RoundToCent(sum) != 0 {
+				m = append(m, IntegrityFinding{Kind: INTEGUNBALANCED, Dt: j.Dt, Table: "JournalAllocation", ID: ja.JAID, Expected: debits, Actual: debits - sum,
+					Comment: fmt.Sprintf("J%08d: debits %.2f, credits %.2f, rule: %s", j.JID, debits, debits-sum, ja.AcctRule)})
+			}
+			if RoundToCent(debits) == 0 {
+				continue // nothing to post
+			}
+			if le := GetLedgerEntriesByJAID(bid, ja.JAID); len(le) == 0 {
+				unposted = append(unposted, len(m))
+				m = append(m, IntegrityFinding{Kind: INTEGNOLEDGER, Dt: j.Dt, Table: "JournalAllocation", ID: ja.JAID, Expected: ja.Amount, Repairable: true,
+					Comment: fmt.Sprintf("J%08d: allocation has not been posted", j.JID)})
+			}
+		}
+		if RoundToCent(tot-j.Amount) != 0 {
+			m = append(m, IntegrityFinding{Kind: INTEGJNLAMOUNT, Dt: j.Dt, Table: "Journal", ID: j.JID, Expected: j.Amount, Actual: tot,
+				Comment: fmt.Sprintf("%d allocations sum to %.2f", len(j.JA), tot)})
+		}
+		if len(unposted) > 0 && repair {
+			if _, err := GenerateLedgerEntriesFromJournal(xbiz, j, d1, d2); err != nil {
+				return m, fmt.Errorf("could not post J%08d: %s", j.JID, err.Error())
+			}
+			for k := 0; k < len(unposted); k++ {
+				m[unposted[k]].Repaired = true
+			}
+		}
+	}
+	return m, nil
+}
+
+// integrityMarkers returns the LedgerMarkers of business bid dated before d2
+// selected by where, in order of account, sub-ledger and date
+func integrityMarkers(bid int64, d2 *time.Time, where string) ([]LedgerMarker, error) {
+	var m []LedgerMarker
+	q := "SELECT " + RRdb.DBFields["LedgerMarker"] + " FROM LedgerMarker WHERE BID=? AND Dt<? AND " + where + " ORDER BY LID,RAID,RID,TCID,Dt,LMID"
+	rows, err := RRdb.Dbrr.Query(q, bid, d2)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var lm LedgerMarker
+		ReadLedgerMarkers(rows, &lm)
+		m = append(m, lm)
+	}
+	return m, rows.Err()
+}
+
+// sameSubLedger returns true if a and b are markers of the same account and
+// sub-ledger
+func sameSubLedger(a, b *LedgerMarker) bool {
+	return a.LID == b.LID && a.RAID == b.RAID && a.RID == b.RID && a.TCID == b.TCID
+}
+
+// integrityActivity returns the sum of the LedgerEntries of account lid in
+// the sub-ledger of marker lm dated in d1 - d2.  A marker with no RAID, RID
+// or TCID covers the whole account.
+func integrityActivity(lm *LedgerMarker, d1, d2 *time.Time) (float64, error) {
+	var tot float64
+	q := "SELECT COALESCE(SUM(Amount),0) FROM LedgerEntry WHERE BID=? AND LID=? AND ?<=Dt AND Dt<?"
+	args := []interface{}{lm.BID, lm.LID, d1, d2}
+	if lm.RAID > 0 {
+		q += " AND RAID=?"
+		args = append(args, lm.RAID)
+	}
+	if lm.RID > 0 {
+		q += " AND RID=?"
+		args = append(args, lm.RID)
+	}
+	if lm.TCID > 0 {
+		q += " AND TCID=?"
+		args = append(args, lm.TCID)
+	}
+	err := RRdb.Dbrr.QueryRow(q, args...).Scan(&tot)
+	return tot, err
+}
+
+// checkMarkerChain checks each marker in m dated in d1 - d2 against the
+// previous marker of the same sub-ledger plus the entries between them.  The
+// Balance of an open marker that disagrees is corrected if repair is true,
+// and the corrected Balance is used for the next marker.
+func checkMarkerChain(m []LedgerMarker, kind int, d1 *time.Time, repair bool) ([]IntegrityFinding, error) {
+	var f []IntegrityFinding
+	for i := 1; i < len(m); i++ {
+		prev, lm := &m[i-1], &m[i]
+		if !sameSubLedger(prev, lm) || lm.Dt.Before(*d1) || lm.State == LMINITIAL {
+			continue
+		}
+		act, err := integrityActivity(lm, &prev.Dt, &lm.Dt)
+		if err != nil {
+			return f, err
+		}
+		exp := RoundToCent(prev.Balance + act)
+		if RoundToCent(lm.Balance) == exp {
+			continue
+		}
+		a := IntegrityFinding{Kind: kind, Dt: lm.Dt, Table: "LedgerMarker", ID: lm.LMID, LID: lm.LID, Expected: exp, Actual: lm.Balance,
+			Repairable: lm.State == LMOPEN,
+			Comment:    fmt.Sprintf("previous marker LM%08d %s balance %.2f, activity %.2f", prev.LMID, prev.Dt.Format(RRDATEFMT4), prev.Balance, act)}
+		switch {
+		case lm.RAID > 0:
+			a.Comment = IDtoShortString("RA", lm.RAID) + ": " + a.Comment
+		case lm.RID > 0:
+			a.Comment = IDtoShortString("R", lm.RID) + ": " + a.Comment
+		case lm.TCID > 0:
+			a.Comment = IDtoShortString("TC", lm.TCID) + ": " + a.Comment
+		}
+		if a.Repairable && repair {
+			lm.Balance = exp
+			if err = UpdateLedgerMarker(lm); err != nil {
+				return f, err
+			}
+			a.Repaired = true
+		}
+		f = append(f, a)
+	}
+	return f, nil
+}
+
+// checkGLMarkerIntegrity checks the GL LedgerMarkers dated in d1 - d2.
+// Accounts that do not allow posting or that have child accounts are
+// skipped, their markers are the sum of other accounts.
+func checkGLMarkerIntegrity(xbiz *XBusiness, d1, d2 *time.Time, repair bool) ([]IntegrityFinding, error) {
+	bid := xbiz.P.BID
+	RRdb.BizTypes[bid].GLAccounts = GetGLAccountMap(bid)
+	m, err := integrityMarkers(bid, d2, "RAID=0 AND RID=0 AND TCID=0")
+	if err != nil {
+		return nil, err
+	}
+	var n []LedgerMarker
+	for i := 0; i < len(m); i++ {
+		l, ok := RRdb.BizTypes[bid].GLAccounts[m[i].LID]
+		if !ok || l.AllowPost == 0 || len(GetGLAccountChildAccts(bid, l.LID)) > 0 {
+			continue
+		}
+		n = append(n, m[i])
+	}
+	return checkMarkerChain(n, INTEGGLMARKER, d1, repair)
+}
+
+// checkSubLedgerMarkerIntegrity checks the RA, Rentable and payor
+// LedgerMarkers dated in d1 - d2 against the GL entries of their sub-ledger
+func checkSubLedgerMarkerIntegrity(xbiz *XBusiness, d1, d2 *time.Time, repair bool) ([]IntegrityFinding, error) {
+	m, err := integrityMarkers(xbiz.P.BID, d2, "LID>0 AND (RAID>0 OR RID>0 OR TCID>0)")
+	if err != nil {
+		return nil, err
+	}
+	return checkMarkerChain(m, INTEGSUBMARKER, d1, repair)
+}
+
+// allocatedState returns the value of FLAGS bits 0-1 for amount of which
+// alloc has been allocated: 0 = none, 1 = some, 2 = all
+func allocatedState(amount, alloc float64) uint64 {
+	switch {
+	case RoundToCent(alloc) == 0:
+		return 0
+	case RoundToCent(amount-alloc) > 0:
+		return 1
+	}
+	return 2
+}
+
+// checkAssessmentFlagsIntegrity checks that the paid state in FLAGS of each
+// Assessment that starts in d1 - d2 agrees with the ReceiptAllocations
+// that pay it.  Recurring assessment definitions, offsets and reversed
+// assessments are not paid and are skipped.
+func checkAssessmentFlagsIntegrity(xbiz *XBusiness, d1, d2 *time.Time, repair bool) ([]IntegrityFinding, error) {
+	var m []IntegrityFinding
+	q := "SELECT a.ASMID,a.Start,a.Amount,a.FLAGS,COALESCE(SUM(ra.Amount),0) FROM Assessments a LEFT JOIN ReceiptAllocation ra ON ra.ASMID=a.ASMID " +
+		"WHERE a.BID=? AND ?<=a.Start AND a.Start<? AND (a.PASMID>0 OR a.RentCycle=0) GROUP BY a.ASMID,a.Start,a.Amount,a.FLAGS"
+	rows, err := RRdb.Dbrr.Query(q, xbiz.P.BID, d1, d2)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var dt time.Time
+		var amt, alloc float64
+		var flags uint64
+		if err = rows.Scan(&id, &dt, &amt, &flags, &alloc); err != nil {
+			return m, err
+		}
+		if flags&ASMREVERSED != 0 || flags&0x3 == 0x3 {
+			continue
+		}
+		if RoundToCent(alloc-amt) > 0 {
+			m = append(m, IntegrityFinding{Kind: INTEGOVERALLOC, Dt: dt, Table: "Assessment", ID: id, Expected: amt, Actual: alloc,
+				Comment: fmt.Sprintf("%.2f has been paid on an assessment of %.2f", alloc, amt)})
+			continue
+		}
+		if st := allocatedState(amt, alloc); st != flags&0x3 {
+			m = append(m, IntegrityFinding{Kind: INTEGASMFLAGS, Dt: dt, Table: "Assessment", ID: id, Expected: amt, Actual: alloc, Repairable: true,
+				Comment: fmt.Sprintf("FLAGS say %s, allocations say %s", asmPaidState[flags&0x3], asmPaidState[st])})
+		}
+	}
+	if err = rows.Err(); err != nil || !repair {
+		return m, err
+	}
+	for i := 0; i < len(m); i++ {
+		if m[i].Kind != INTEGASMFLAGS {
+			continue
+		}
+		a, err := GetAssessment(m[i].ID)
+		if err != nil {
+			return m, err
+		}
+		a.FLAGS = a.FLAGS&^0x3 | allocatedState(m[i].Expected, m[i].Actual)
+		if err = UpdateAssessment(&a); err != nil {
+			return m, err
+		}
+		m[i].Repaired = true
+	}
+	return m, nil
+}
+
+// checkReceiptFlagsIntegrity checks that the allocated state in FLAGS of
+// each Receipt dated in d1 - d2 agrees with the ReceiptAllocations that
+// apply it to assessments.  Voided receipts are skipped.
+func checkReceiptFlagsIntegrity(xbiz *XBusiness, d1, d2 *time.Time, repair bool) ([]IntegrityFinding, error) {
+	var m []IntegrityFinding
+	q := "SELECT r.RCPTID,r.Dt,r.Amount,r.FLAGS,COALESCE(SUM(ra.Amount),0) FROM Receipt r LEFT JOIN ReceiptAllocation ra ON ra.RCPTID=r.RCPTID AND ra.ASMID>0 " +
+		"WHERE r.BID=? AND ?<=r.Dt AND r.Dt<? GROUP BY r.RCPTID,r.Dt,r.Amount,r.FLAGS"
+	rows, err := RRdb.Dbrr.Query(q, xbiz.P.BID, d1, d2)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var dt time.Time
+		var amt, alloc float64
+		var flags uint64
+		if err = rows.Scan(&id, &dt, &amt, &flags, &alloc); err != nil {
+			return m, err
+		}
+		if flags&RCPTvoid != 0 {
+			continue
+		}
+		if RoundToCent(alloc-amt) > 0 {
+			m = append(m, IntegrityFinding{Kind: INTEGOVERALLOC, Dt: dt, Table: "Receipt", ID: id, Expected: amt, Actual: alloc,
+				Comment: fmt.Sprintf("%.2f has been allocated from a receipt of %.2f", alloc, amt)})
+			continue
+		}
+		if st := allocatedState(amt, alloc); st != flags&0x3 {
+			m = append(m, IntegrityFinding{Kind: INTEGRCPTFLAGS, Dt: dt, Table: "Receipt", ID: id, Expected: amt, Actual: alloc, Repairable: true,
+				Comment: fmt.Sprintf("FLAGS say %s, allocations say %s", rcptAllocState[flags&0x3], rcptAllocState[st])})
+		}
+	}
+	if err = rows.Err(); err != nil || !repair {
+		return m, err
+	}
+	for i := 0; i < len(m); i++ {
+		if m[i].Kind != INTEGRCPTFLAGS {
+			continue
+		}
+		r := GetReceipt(m[i].ID)
+		if r.RCPTID == 0 {
+			continue
+		}
+		r.FLAGS = r.FLAGS&^0x3 | allocatedState(m[i].Expected, m[i].Actual)
+		if err = UpdateReceipt(&r); err != nil {
+			return m, err
+		}
+		m[i].Repaired = true
+	}
+	return m, nil
+}
+
+// asmPaidState and rcptAllocState describe FLAGS bits 0-1 of an Assessment
+// and a Receipt
+var asmPaidState = map[uint64]string{0: "unpaid", 1: "partially paid", 2: "fully paid", 3: "offset"}
+var rcptAllocState = map[uint64]string{0: "unallocated", 1: "partially allocated", 2: "fully allocated", 3: "unknown"}
+
+// orphanCheck describes a query for rows that refer to a record that does
+// not exist.  The query selects the id, a date and an amount of each row.
+type orphanCheck struct {
+	kind   int
+	table  string
+	q      string
+	repair func(id int64) error // nil if the row is not safe to remove
+	what   string               // description of the problem
+}
+
+var orphanChecks = []orphanCheck{
+	{INTEGORPHANJA, "JournalAllocation",
+		"SELECT ja.JAID,ja.CreateTS,ja.Amount FROM JournalAllocation ja LEFT JOIN Journal j ON j.JID=ja.JID WHERE ja.BID=? AND j.JID IS NULL",
+		func(id int64) error { DeleteJournalAllocation(id); return nil }, "its Journal entry does not exist"},
+	{INTEGORPHANLE, "LedgerEntry",
+		"SELECT le.LEID,le.Dt,le.Amount FROM LedgerEntry le LEFT JOIN JournalAllocation ja ON ja.JAID=le.JAID WHERE le.BID=? AND le.JAID>0 AND ja.JAID IS NULL",
+		nil, "its Journal allocation does not exist"}, // the markers after it include it
+	{INTEGORPHANLM, "LedgerMarker",
+		"SELECT lm.LMID,lm.Dt,lm.Balance FROM LedgerMarker lm LEFT JOIN GLAccount l ON l.LID=lm.LID WHERE lm.BID=? AND lm.LID>0 AND l.LID IS NULL",
+		DeleteLedgerMarker, "its GLAccount does not exist"},
+	{INTEGORPHANRA, "ReceiptAllocation",
+		"SELECT ra.RCPAID,ra.Dt,ra.Amount FROM ReceiptAllocation ra LEFT JOIN Receipt r ON r.RCPTID=ra.RCPTID WHERE ra.BID=? AND r.RCPTID IS NULL",
+		nil, "its Receipt does not exist"},
+	{INTEGORPHANRA, "ReceiptAllocation",
+		"SELECT ra.RCPAID,ra.Dt,ra.Amount FROM ReceiptAllocation ra LEFT JOIN Assessments a ON a.ASMID=ra.ASMID WHERE ra.BID=? AND ra.ASMID>0 AND a.ASMID IS NULL",
+		nil, "its Assessment does not exist"},
+	{INTEGORPHANJNL, "Journal",
+		fmt.Sprintf("SELECT j.JID,j.Dt,j.Amount FROM Journal j LEFT JOIN Assessments a ON a.ASMID=j.ID WHERE j.BID=? AND j.Type=%d AND a.ASMID IS NULL", JNLTYPEASMT),
+		nil, "its Assessment does not exist"},
+	{INTEGORPHANJNL, "Journal",
+		fmt.Sprintf("SELECT j.JID,j.Dt,j.Amount FROM Journal j LEFT JOIN Receipt r ON r.RCPTID=j.ID WHERE j.BID=? AND j.Type=%d AND r.RCPTID IS NULL", JNLTYPERCPT),
+		nil, "its Receipt does not exist"},
+}
+
+// checkOrphanIntegrity looks for rows of the business that refer to records
+// that do not exist.  The rows that are safe to remove are removed if repair
+// is true.
+func checkOrphanIntegrity(xbiz *XBusiness, d1, d2 *time.Time, repair bool) ([]IntegrityFinding, error) {
+	var m []IntegrityFinding
+	for i := 0; i < len(orphanChecks); i++ {
+		c := &orphanChecks[i]
+		rows, err := RRdb.Dbrr.Query(c.q, xbiz.P.BID)
+		if err != nil {
+			return m, err
+		}
+		var n []IntegrityFinding
+		for rows.Next() {
+			a := IntegrityFinding{Kind: c.kind, Table: c.table, Comment: c.what, Repairable: c.repair != nil}
+			if err = rows.Scan(&a.ID, &a.Dt, &a.Actual); err != nil {
+				rows.Close()
+				return m, err
+			}
+			n = append(n, a)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return m, err
+		}
+		for j := 0; j < len(n) && repair && c.repair != nil; j++ {
+			if err = c.repair(n[j].ID); err != nil {
+				return append(m, n...), err
+			}
+			n[j].Repaired = true
+		}
+		m = append(m, n...)
+	}
+	return m, nil
+}
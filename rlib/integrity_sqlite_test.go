@@ -0,0 +1,124 @@
+// +build sqlite
+
+package rlib_test
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+)
+
+// integrityBusiness returns a business with a late fee journaled and posted
+// in March 2017, whose records agree
+func integrityBusiness(t *testing.T) (*rrtest.Biz, rlib.Journal) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	a := journalAssessment(t, b, "Late Fee", rrtest.Dt(2017, 3, 5), 50)
+	j := rlib.GetJournalByTypeAndID(rlib.JNLTYPEASMT, a.ASMID)
+	rlib.GetJournalAllocations(&j)
+	if len(j.JA) == 0 {
+		t.Fatalf("expect the late fee to be journaled")
+	}
+	if m := checkIntegrity(t, b, false); len(m) != 0 {
+		t.Fatalf("expect no problems in a new business, got %+v", m)
+	}
+	return b, j
+}
+
+// checkIntegrity checks b over 2017 and returns the problems found
+func checkIntegrity(t *testing.T, b *rrtest.Biz, repair bool) []rlib.IntegrityFinding {
+	d1, d2 := rrtest.Dt(2017, 1, 1), rrtest.Dt(2018, 1, 1)
+	m, err := rlib.CheckIntegrity(&b.XBiz, &d1, &d2, repair)
+	if err != nil {
+		t.Fatalf("CheckIntegrity: %s", err.Error())
+	}
+	return m
+}
+
+// expectFinding fails unless m is a single problem of kind on id, repairable
+// and repaired as given
+func expectFinding(t *testing.T, m []rlib.IntegrityFinding, kind int, id int64, repairable, repaired bool) {
+	if len(m) != 1 || m[0].Kind != kind || m[0].ID != id {
+		t.Fatalf("expect problem %d on %d, got %+v", kind, id, m)
+	}
+	if m[0].Repairable != repairable || m[0].Repaired != repaired {
+		t.Errorf("expect repairable %t, repaired %t, got %+v", repairable, repaired, m[0])
+	}
+}
+
+func TestIntegrityUnposted(t *testing.T) {
+	b, j := integrityBusiness(t)
+	jaid := j.JA[0].JAID
+	le := rlib.GetLedgerEntriesByJAID(b.BID, jaid)
+	for i := 0; i < len(le); i++ {
+		rlib.DeleteLedgerEntry(le[i].LEID)
+	}
+	expectFinding(t, checkIntegrity(t, b, false), rlib.INTEGNOLEDGER, jaid, true, false)
+	expectFinding(t, checkIntegrity(t, b, true), rlib.INTEGNOLEDGER, jaid, true, true)
+	if n := len(rlib.GetLedgerEntriesByJAID(b.BID, jaid)); n != len(le) {
+		t.Errorf("expect %d ledger entries posted again, got %d", len(le), n)
+	}
+	if m := checkIntegrity(t, b, false); len(m) != 0 {
+		t.Errorf("expect no problems after the repair, got %+v", m)
+	}
+}
+
+func TestIntegrityMarker(t *testing.T) {
+	b, _ := integrityBusiness(t)
+	lm := rlib.LedgerMarker{BID: b.BID, LID: b.LID["11001"], Dt: rrtest.Dt(2017, 4, 1), Balance: 10, State: rlib.LMOPEN}
+	if err := rlib.InsertLedgerMarker(&lm); err != nil {
+		t.Fatalf("InsertLedgerMarker: %s", err.Error())
+	}
+	m := checkIntegrity(t, b, true)
+	expectFinding(t, m, rlib.INTEGGLMARKER, lm.LMID, true, true)
+	if m[0].Expected != 50 || m[0].Actual != 10 {
+		t.Errorf("expect a balance of 50.00 instead of 10.00, got %+v", m[0])
+	}
+	var bal float64
+	if err := rlib.RRdb.Dbrr.QueryRow("SELECT Balance FROM LedgerMarker WHERE LMID=?", lm.LMID).Scan(&bal); err != nil || bal != 50 {
+		t.Errorf("expect the marker balance to be repaired to 50.00, got %.2f %v", bal, err)
+	}
+	if m = checkIntegrity(t, b, false); len(m) != 0 {
+		t.Errorf("expect no problems after the repair, got %+v", m)
+	}
+}
+
+func TestIntegrityFlags(t *testing.T) {
+	b, _ := integrityBusiness(t)
+	a := rlib.Assessment{BID: b.BID, RID: b.RID[0], RAID: b.RAID, ARID: b.ARID["Rent"], Amount: 1000, Start: rrtest.Dt(2017, 3, 1), Stop: rrtest.Dt(2017, 3, 1), RentCycle: rlib.RECURNONE, FLAGS: 2}
+	if _, err := rlib.InsertAssessment(&a); err != nil {
+		t.Fatalf("InsertAssessment: %s", err.Error())
+	}
+	expectFinding(t, checkIntegrity(t, b, true), rlib.INTEGASMFLAGS, a.ASMID, true, true)
+	if x, err := rlib.GetAssessment(a.ASMID); err != nil || x.FLAGS&0x3 != 0 {
+		t.Errorf("expect the assessment to be marked unpaid, got FLAGS %d %v", x.FLAGS, err)
+	}
+	if m := checkIntegrity(t, b, false); len(m) != 0 {
+		t.Errorf("expect no problems after the repair, got %+v", m)
+	}
+}
+
+// An orphaned Journal allocation is removed; an orphaned LedgerEntry is
+// counted in the markers after it and is only reported
+func TestIntegrityOrphans(t *testing.T) {
+	b, _ := integrityBusiness(t)
+	ja := rlib.JournalAllocation{JID: 999999, BID: b.BID, RAID: b.RAID, Amount: 20, AcctRule: "d 50001 20.00, c 10001 20.00"}
+	if err := rlib.InsertJournalAllocationEntry(&ja); err != nil {
+		t.Fatalf("InsertJournalAllocationEntry: %s", err.Error())
+	}
+	expectFinding(t, checkIntegrity(t, b, true), rlib.INTEGORPHANJA, ja.JAID, true, true)
+	if x := rlib.GetJournalAllocation(ja.JAID); x.JAID != 0 {
+		t.Errorf("expect the orphaned allocation to be removed")
+	}
+
+	le := rlib.LedgerEntry{BID: b.BID, JID: 999999, JAID: 999999, LID: b.LID["50001"], Dt: rrtest.Dt(2017, 6, 1), Amount: 20}
+	leid, err := rlib.InsertLedgerEntry(&le)
+	if err != nil {
+		t.Fatalf("InsertLedgerEntry: %s", err.Error())
+	}
+	expectFinding(t, checkIntegrity(t, b, true), rlib.INTEGORPHANLE, leid, false, false)
+	var n int64
+	if err = rlib.RRdb.Dbrr.QueryRow("SELECT COUNT(*) FROM LedgerEntry WHERE LEID=?", leid).Scan(&n); err != nil || n != 1 {
+		t.Errorf("expect the orphaned ledger entry to be kept, got %d %v", n, err)
+	}
+}
@@ -0,0 +1,23 @@
+package rlib
+
+import "testing"
+
+func TestAllocatedState(t *testing.T) {
+	m := []struct {
+		amount, alloc float64
+		expect        uint64
+	}{
+		{1000, 0, 0},
+		{1000, 0.001, 0},
+		{1000, 250, 1},
+		{1000, 999.99, 1},
+		{1000, 999.999, 2},
+		{1000, 1000, 2},
+		{-50, -50, 2},
+	}
+	for i := 0; i < len(m); i++ {
+		if got := allocatedState(m[i].amount, m[i].alloc); got != m[i].expect {
+			t.Errorf("allocatedState(%.3f, %.3f) = %d, expected %d", m[i].amount, m[i].alloc, got, m[i].expect)
+		}
+	}
+}
@@ -165,7 +165,7 @@ func ProrateAssessment(xbiz *XBusiness, a *Assessment, d, d1, d2 *time.Time) (fl
 //		a - the assessment
 //		d1-d2 - defines the timerange being covered in this period
 //=================================================================================================
-func journalAssessment(xbiz *XBusiness, d time.Time, a *Assessment, d1, d2 *time.Time) (Journal, error) {
+func journalAssessment(tx *RRTx, xbiz *XBusiness, d time.Time, a *Assessment, d1, d2 *time.Time) (Journal, error) {
 	// funcname := "journalAssessment"
 	// Console("Entered %s\n", funcname)
 	// Console("%s: d = %s, d1 = %s, d2 = %s\n", funcname, d.Format(RRDATEREPORTFMT), d1.Format(RRDATEREPORTFMT), d2.Format(RRDATEREPORTFMT))
@@ -198,7 +198,7 @@ func journalAssessment(xbiz *XBusiness, d time.Time, a *Assessment, d1, d2 *time
 		a.Start = start     // adjust to the dates used in the proration
 		a.Stop = stop       // adjust to the dates used in the proration
 		a.Comment = fmt.Sprintf("Prorated: %d %s out of %d", num, ProrationUnits(a.ProrationCycle), den)
-		if err := UpdateAssessment(a); err != nil {
+		if err := UpdateAssessmentTx(tx, a); err != nil {
 			err = fmt.Errorf("Error updating prorated assessment amount: %s", err.Error())
 			return j, err
 		}
@@ -237,7 +237,7 @@ func journalAssessment(xbiz *XBusiness, d time.Time, a *Assessment, d1, d2 *time
 
 	// Console("INSERTING JOURNAL: Date = %s, Type = %d, amount = %f\n", j.Dt, j.Type, j.Amount)
 
-	jid, err := InsertJournal(&j)
+	jid, err := InsertJournalTx(tx, &j)
 	if err != nil {
 		LogAndPrintError("error inserting Journal entry: %v\n", err)
 		return j, err
@@ -261,7 +261,7 @@ func journalAssessment(xbiz *XBusiness, d time.Time, a *Assessment, d1, d2 *time
 		ja.RAID = a.RAID
 
 		// Console("INSERTING JOURNAL-ALLOCATION: ja.JID = %d, ja.ASMID = %d, ja.RAID = %d\n", ja.JID, ja.ASMID, ja.RAID)
-		if err = InsertJournalAllocationEntry(&ja); err != nil {
+		if err = InsertJournalAllocationEntryTx(tx, &ja); err != nil {
 			LogAndPrintError("journalAssessment", err)
 			return j, err
 		}
@@ -300,6 +300,12 @@ func RemoveJournalEntries(xbiz *XBusiness, d1, d2 *time.Time) error {
 // ProcessNewAssessmentInstance creates a Journal entry for the supplied non-recurring assessment
 //=================================================================================================
 func ProcessNewAssessmentInstance(xbiz *XBusiness, d1, d2 *time.Time, a *Assessment) (Journal, error) {
+	return ProcessNewAssessmentInstanceTx(nil, xbiz, d1, d2, a)
+}
+
+// ProcessNewAssessmentInstanceTx is ProcessNewAssessmentInstance performed
+// within transaction tx. If tx is nil the database is used directly.
+func ProcessNewAssessmentInstanceTx(tx *RRTx, xbiz *XBusiness, d1, d2 *time.Time, a *Assessment) (Journal, error) {
 	funcname := "ProcessNewAssessmentInstance"
 	var j Journal
 	var err error
@@ -310,7 +316,7 @@ func ProcessNewAssessmentInstance(xbiz *XBusiness, d1, d2 *time.Time, a *Assessm
 		return j, err
 	}
 	if a.ASMID == 0 && a.RentCycle != RECURNONE {
-		_, err = InsertAssessment(a)
+		_, err = InsertAssessmentTx(tx, a)
 		if nil != err {
 			LogAndPrintError(funcname, err)
 			return j, err
@@ -318,13 +324,19 @@ func ProcessNewAssessmentInstance(xbiz *XBusiness, d1, d2 *time.Time, a *Assessm
 	}
 
 	// Console("%s: Calling journalAssessment for ASMID = %d\n", funcname, a.ASMID)
-	j, err = journalAssessment(xbiz, a.Start, a, d1, d2)
+	j, err = journalAssessment(tx, xbiz, a.Start, a, d1, d2)
 	return j, err
 }
 
 // ProcessNewReceipt creates a Journal entry for the supplied receipt
 //-----------------------------------------------------------------------------
 func ProcessNewReceipt(xbiz *XBusiness, d1, d2 *time.Time, r *Receipt) (Journal, error) {
+	return ProcessNewReceiptTx(nil, xbiz, d1, d2, r)
+}
+
+// ProcessNewReceiptTx is ProcessNewReceipt performed within transaction tx. If
+// tx is nil the database is used directly.
+func ProcessNewReceiptTx(tx *RRTx, xbiz *XBusiness, d1, d2 *time.Time, r *Receipt) (Journal, error) {
 	var j Journal
 	j.BID = xbiz.P.BID
 	j.Amount = RoundToCent(r.Amount)
@@ -332,7 +344,7 @@ func ProcessNewReceipt(xbiz *XBusiness, d1, d2 *time.Time, r *Receipt) (Journal,
 	j.Type = JNLTYPERCPT
 	j.ID = r.RCPTID
 	// j.RAID = r.RAID
-	jid, err := InsertJournal(&j)
+	jid, err := InsertJournalTx(tx, &j)
 	if err != nil {
 		Ulog("Error inserting Journal entry: %v\n", err)
 		return j, err
@@ -350,12 +362,12 @@ func ProcessNewReceipt(xbiz *XBusiness, d1, d2 *time.Time, r *Receipt) (Journal,
 			ja.ASMID = r.RA[i].ASMID
 			ja.AcctRule = r.RA[i].AcctRule
 			if ja.ASMID > 0 { // there may not be an assessment associated, it could be unallocated funds
-				a, _ := GetAssessment(ja.ASMID) // but if there is an associated assessment, then mark the RID and RAID
+				a, _ := GetAssessmentTx(tx, ja.ASMID) // but if there is an associated assessment, then mark the RID and RAID
 				ja.RID = a.RID
 				ja.RAID = r.RA[i].RAID
 			}
 			ja.TCID = r.TCID
-			if err = InsertJournalAllocationEntry(&ja); err != nil {
+			if err = InsertJournalAllocationEntryTx(tx, &ja); err != nil {
 				LogAndPrintError("ProcessNewReceipt", err)
 				return j, err
 			}
@@ -368,6 +380,12 @@ func ProcessNewReceipt(xbiz *XBusiness, d1, d2 *time.Time, r *Receipt) (Journal,
 // ProcessNewExpense adds a new expense instance.
 //-----------------------------------------------------------------------------
 func ProcessNewExpense(a *Expense, xbiz *XBusiness) error {
+	return ProcessNewExpenseTx(nil, a, xbiz)
+}
+
+// ProcessNewExpenseTx is ProcessNewExpense performed within transaction tx. If
+// tx is nil the database is used directly.
+func ProcessNewExpenseTx(tx *RRTx, a *Expense, xbiz *XBusiness) error {
 	InitBizInternals(a.BID, xbiz)
 	var j = Journal{
 		BID:    xbiz.P.BID,
@@ -376,7 +394,7 @@ func ProcessNewExpense(a *Expense, xbiz *XBusiness) error {
 		Type:   JNLTYPEEXP,
 		ID:     a.EXPID,
 	}
-	_, err := InsertJournal(&j)
+	_, err := InsertJournalTx(tx, &j)
 	if err != nil {
 		Ulog("Error inserting Journal Expense entry: %v\n", err)
 		return err
@@ -394,7 +412,7 @@ func ProcessNewExpense(a *Expense, xbiz *XBusiness) error {
 	ja.AcctRule = fmt.Sprintf("d %s %.2f, c %s %.2f",
 		RRdb.BizTypes[a.BID].GLAccounts[dlid].GLNumber, a.Amount,
 		RRdb.BizTypes[a.BID].GLAccounts[clid].GLNumber, a.Amount)
-	if err = InsertJournalAllocationEntry(&ja); err != nil {
+	if err = InsertJournalAllocationEntryTx(tx, &ja); err != nil {
 		LogAndPrintError("ProcessNewReceipt", err)
 		return err
 	}
@@ -402,27 +420,136 @@ func ProcessNewExpense(a *Expense, xbiz *XBusiness) error {
 	d1 := time.Date(a.Dt.Year(), a.Dt.Month(), 1, 0, 0, 0, 0, time.UTC)
 	d2 := d1.AddDate(0, 1, 0)
 	InitLedgerCache()
-	GenerateLedgerEntriesFromJournal(xbiz, &j, &d1, &d2)
-	return nil
+	_, err = GenerateLedgerEntriesFromJournalTx(tx, xbiz, &j, &d1, &d2)
+	return err
+}
+
+// ProcessNewBill creates the Journal records for a vendor Bill. Each BillItem
+// debits its expense account and credits the bill's Accounts Payable account.
+// The bill and its items must already have been saved to the database.
+//-----------------------------------------------------------------------------
+func ProcessNewBill(a *Bill, xbiz *XBusiness) error {
+	return ProcessNewBillTx(nil, a, xbiz)
+}
+
+// ProcessNewBillTx is ProcessNewBill performed within transaction tx. If tx is
+// nil the database is used directly.
+func ProcessNewBillTx(tx *RRTx, a *Bill, xbiz *XBusiness) error {
+	InitBizInternals(a.BID, xbiz)
+	var j = Journal{
+		BID:    xbiz.P.BID,
+		Amount: a.Amount,
+		Dt:     a.Dt,
+		Type:   JNLTYPEBILL,
+		ID:     a.BILLID,
+	}
+	_, err := InsertJournalTx(tx, &j)
+	if err != nil {
+		Ulog("Error inserting Journal Bill entry: %v\n", err)
+		return err
+	}
+	for i := 0; i < len(a.BI); i++ {
+		var ja = JournalAllocation{
+			JID:    j.JID,
+			BID:    j.BID,
+			RID:    a.BI[i].RID,
+			Amount: a.BI[i].Amount,
+		}
+		ja.AcctRule = fmt.Sprintf("d %s %.2f, c %s %.2f",
+			RRdb.BizTypes[a.BID].GLAccounts[a.BI[i].LID].GLNumber, a.BI[i].Amount,
+			RRdb.BizTypes[a.BID].GLAccounts[a.APLID].GLNumber, a.BI[i].Amount)
+		if err = InsertJournalAllocationEntryTx(tx, &ja); err != nil {
+			LogAndPrintError("ProcessNewBill", err)
+			return err
+		}
+		j.JA = append(j.JA, ja)
+	}
+	d1 := time.Date(a.Dt.Year(), a.Dt.Month(), 1, 0, 0, 0, 0, time.UTC)
+	d2 := d1.AddDate(0, 1, 0)
+	InitLedgerCache()
+	_, err = GenerateLedgerEntriesFromJournalTx(tx, xbiz, &j, &d1, &d2)
+	return err
+}
+
+// ProcessNewBillPayment creates the Journal records for a payment against a
+// vendor Bill. It debits the bill's Accounts Payable account and credits the
+// GL account of the Depository the funds came from.
+//-----------------------------------------------------------------------------
+func ProcessNewBillPayment(a *BillPayment, xbiz *XBusiness) error {
+	return ProcessNewBillPaymentTx(nil, a, xbiz)
+}
+
+// ProcessNewBillPaymentTx is ProcessNewBillPayment performed within
+// transaction tx. If tx is nil the database is used directly.
+func ProcessNewBillPaymentTx(tx *RRTx, a *BillPayment, xbiz *XBusiness) error {
+	InitBizInternals(a.BID, xbiz)
+	b, err := GetBillTx(tx, a.BILLID)
+	if err != nil {
+		return err
+	}
+	dep, err := GetDepository(a.DEPID)
+	if err != nil {
+		return err
+	}
+	var j = Journal{
+		BID:    xbiz.P.BID,
+		Amount: a.Amount,
+		Dt:     a.Dt,
+		Type:   JNLTYPEBPMT,
+		ID:     a.BPID,
+	}
+	if _, err = InsertJournalTx(tx, &j); err != nil {
+		Ulog("Error inserting Journal Bill Payment entry: %v\n", err)
+		return err
+	}
+	var ja = JournalAllocation{
+		JID:    j.JID,
+		BID:    j.BID,
+		Amount: a.Amount,
+	}
+	ja.AcctRule = fmt.Sprintf("d %s %.2f, c %s %.2f",
+		RRdb.BizTypes[a.BID].GLAccounts[b.APLID].GLNumber, a.Amount,
+		RRdb.BizTypes[a.BID].GLAccounts[dep.LID].GLNumber, a.Amount)
+	if err = InsertJournalAllocationEntryTx(tx, &ja); err != nil {
+		LogAndPrintError("ProcessNewBillPayment", err)
+		return err
+	}
+	j.JA = append(j.JA, ja)
+	d1 := time.Date(a.Dt.Year(), a.Dt.Month(), 1, 0, 0, 0, 0, time.UTC)
+	d2 := d1.AddDate(0, 1, 0)
+	InitLedgerCache()
+	_, err = GenerateLedgerEntriesFromJournalTx(tx, xbiz, &j, &d1, &d2)
+	return err
 }
 
 // ProcessJournalEntry processes an assessment. It adds instances of recurring
 // assessments for the time period d1-d2 if they do not already exist. Then
-// creates a journal entry for the assessment.
+// creates a journal entry for the assessment. It stops at the first record
+// that cannot be written and returns the error, so that a caller working
+// within a transaction can roll back what was written before it.
 //-----------------------------------------------------------------------------
-func ProcessJournalEntry(a *Assessment, xbiz *XBusiness, d1, d2 *time.Time, updateLedgers bool) {
+func ProcessJournalEntry(a *Assessment, xbiz *XBusiness, d1, d2 *time.Time, updateLedgers bool) error {
+	return ProcessJournalEntryTx(nil, a, xbiz, d1, d2, updateLedgers)
+}
+
+// ProcessJournalEntryTx is ProcessJournalEntry performed within transaction
+// tx. If tx is nil the database is used directly.
+func ProcessJournalEntryTx(tx *RRTx, a *Assessment, xbiz *XBusiness, d1, d2 *time.Time, updateLedgers bool) error {
 	funcname := "ProcessJournalEntry"
 	var j Journal
 	var err error
 	// Console("ProcessJournalEntry: 1. a.ASMID = %d, d1 - d2 = %s - %s\n", a.ASMID, d1.Format(RRDATEREPORTFMT), d2.Format(RRDATEREPORTFMT))
 	if a.RentCycle == RECURNONE {
-		j, err = ProcessNewAssessmentInstance(xbiz, d1, d2, a)
+		j, err = ProcessNewAssessmentInstanceTx(tx, xbiz, d1, d2, a)
 		if err != nil {
 			LogAndPrintError(funcname, err)
-			return
+			return err
 		}
 		if updateLedgers {
-			GenerateLedgerEntriesFromJournal(xbiz, &j, d1, d2)
+			if _, err = GenerateLedgerEntriesFromJournalTx(tx, xbiz, &j, d1, d2); err != nil {
+				LogAndPrintError(funcname, err)
+				return err
+			}
 		}
 	} else if a.RentCycle >= RECURSECONDLY && a.RentCycle <= RECURHOURLY {
 		// TBD
@@ -444,11 +571,13 @@ func ProcessJournalEntry(a *Assessment, xbiz *XBusiness, d1, d2 *time.Time, upda
 
 			// The generation of recurring assessment instances needs to be idempotent.
 			// Check to ensure that this instance does not already exist before generating it
-			a2, _ := GetAssessmentInstance(&a1.Start, a1.PASMID) // if this returns an existing instance (ASMID != 0) then it's already been processed...
-			if a2.ASMID == 0 {                                   // ... otherwise, process this instance
+			a2, _ := GetAssessmentInstanceTx(tx, &a1.Start, a1.PASMID) // if this returns an existing instance (ASMID != 0) then it's already been processed...
+			if a2.ASMID == 0 {                                         // ... otherwise, process this instance
 				// Console("ProcessJournalEntry: 4.0, a1.Amount = %.2f\n", a1.Amount)
-				_, err = InsertAssessment(&a1)
-				Errlog(err)
+				if _, err = InsertAssessmentTx(tx, &a1); err != nil {
+					LogAndPrintError(funcname, err)
+					return err
+				}
 				// Console("ProcessJournalEntry: 4.1, inserted a1.ASMID = %d, a1.Amount = %.2f\n", a1.ASMID, a1.Amount)
 
 				// Rent is assessed on the following cycle: a.RentCycle
@@ -464,13 +593,16 @@ func ProcessJournalEntry(a *Assessment, xbiz *XBusiness, d1, d2 *time.Time, upda
 					dtb = dl[i] // add one full cycle diration
 					dte = dtb.Add(CycleDuration(a.RentCycle, dtb))
 				}
-				j, err := ProcessNewAssessmentInstance(xbiz, &dtb, &dte, &a1)
+				j, err := ProcessNewAssessmentInstanceTx(tx, xbiz, &dtb, &dte, &a1)
 				if err != nil {
 					LogAndPrintError(funcname, err)
-					return
+					return err
 				}
 				if updateLedgers {
-					GenerateLedgerEntriesFromJournal(xbiz, &j, d1, d2)
+					if _, err = GenerateLedgerEntriesFromJournalTx(tx, xbiz, &j, d1, d2); err != nil {
+						LogAndPrintError(funcname, err)
+						return err
+					}
 				}
 			} else if a.RentCycle >= RECURSECONDLY && a.RentCycle <= RECURHOURLY {
 				LogAndPrintError(funcname, fmt.Errorf("Unhandled RentCycle frequency: %d", a.RentCycle))
@@ -478,6 +610,7 @@ func ProcessJournalEntry(a *Assessment, xbiz *XBusiness, d1, d2 *time.Time, upda
 			// Console("ProcessJournalEntry: 5\n")
 		}
 	}
+	return nil
 }
 
 // GenerateRecurInstances creates Assessment instance records for recurring Assessments and then
@@ -491,7 +624,7 @@ func GenerateRecurInstances(xbiz *XBusiness, d1, d2 *time.Time) {
 	for rows.Next() {
 		var a Assessment
 		ReadAssessments(rows, &a)
-		ProcessJournalEntry(&a, xbiz, d1, d2, false)
+		Errlog(ProcessJournalEntry(&a, xbiz, d1, d2, false))
 	}
 	Errcheck(rows.Err())
 }
@@ -520,17 +653,25 @@ func CreateJournalMarker(xbiz *XBusiness, d1, d2 *time.Time) {
 }
 
 // GenerateJournalRecords creates Journal records for Assessments and receipts over the supplied time range.
+// Nothing that already exists is removed. If the period has been processed
+// before, as shown by a JournalMarker covering it, only the vacancy of the
+// rentables with a DirtyRange in the period is recomputed.
 //=================================================================================================
 func GenerateJournalRecords(xbiz *XBusiness, d1, d2 *time.Time, skipVac bool) {
-	// err := RemoveJournalEntries(xbiz, d1, d2)
-	// if err != nil {
-	// 	Ulog("Could not remove existing Journal entries from %s to %s. err = %v\n", d1.Format(RRDATEFMT), d2.Format(RRDATEFMT), err)
-	// 	return
-	// }
+	jm := GetJournalMarkerCovering(xbiz.P.BID, d1, d2)
 	GenerateRecurInstances(xbiz, d1, d2)
 	if !skipVac {
-		GenVacancyJournals(xbiz, d1, d2)
+		if jm.JMID > 0 {
+			if _, err := RecomputeVacancy(xbiz, d1, d2); err != nil {
+				Ulog("GenerateJournalRecords: %s\n", err.Error())
+			}
+		} else {
+			GenVacancyJournals(xbiz, d1, d2)
+			Errlog(ClearDirtyRanges(xbiz.P.BID, 0, d1, d2))
+		}
 	}
 	ProcessReceiptRange(xbiz, d1, d2)
-	CreateJournalMarker(xbiz, d1, d2)
+	if jm.JMID == 0 {
+		CreateJournalMarker(xbiz, d1, d2)
+	}
 }
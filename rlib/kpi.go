@@ -0,0 +1,247 @@
+package rlib
+
+import (
+	"sort"
+	"time"
+)
+
+// KPIRentableType compares the rent of the leased units of a rentable type
+// with its market rate.  Rents are per rent cycle of the rentable type.
+type KPIRentableType struct {
+	RTID            int64   // the rentable type
+	Name            string  // its name
+	Units           int64   // rentables of this type
+	LeasedUnits     int64   // rentables of this type under a rental agreement
+	AvgContractRent float64 // average contract rent of the leased units
+	MarketRate      float64 // market rate of the type
+	RentToMarket    float64 // AvgContractRent as a percentage of MarketRate
+}
+
+// BusinessKPI is the set of headline metrics of a business on a date
+type BusinessKPI struct {
+	BID                 int64             // the business
+	Dt                  time.Time         // date of the metrics
+	Computed            time.Time         // when the metrics were computed
+	Units               int64             // rentables
+	ExcludedUnits       int64             // rentables in administrative, employee or model use
+	AvailableUnits      int64             // Units - ExcludedUnits
+	OccupiedUnits       int64             // available rentables under a rental agreement
+	PreleasedUnits      int64             // available vacant rentables that are leased to start later
+	OnNotice            int64             // rentables whose tenant has given notice to vacate
+	Occupancy           float64           // OccupiedUnits as a percentage of AvailableUnits
+	Leased              float64           // OccupiedUnits + PreleasedUnits as a percentage of AvailableUnits
+	RentableTypes       []KPIRentableType // rent vs. market rate, in order of type name
+	GSR                 float64           // market rent of all the units for the month of Dt
+	Delinquency         float64           // receivable balance at the end of Dt
+	DelinquencyPct      float64           // Delinquency as a percentage of GSR
+	UnallocatedReceipts int64             // receipts that are not fully allocated
+	UnclearedDeposits   int64             // deposits made by Dt that have not fully cleared
+	UnclearedAmount     float64           // the amount of them not yet cleared
+	MoveIns             int64             // rental agreements whose possession started this month through Dt
+	MoveOuts            int64             // rental agreements whose possession stopped this month through Dt
+}
+
+// GetBusinessKPI returns the headline metrics of the business in xbiz on
+// date dt.  The metrics are computed with a few queries over the whole
+// business rather than per rentable, and they are cached per business per
+// day for KPICacheCtx.Expiry.
+//
+// INPUTS
+//  xbiz - the business, with its rentable types
+//  dt   - date of the metrics
+//
+// RETURNS
+//  the metrics
+//  any error encountered
+//-----------------------------------------------------------------------------
+func GetBusinessKPI(xbiz *XBusiness, dt *time.Time) (BusinessKPI, error) {
+	bid := xbiz.P.BID
+	d := time.Date(dt.Year(), dt.Month(), dt.Day(), 0, 0, 0, 0, time.UTC)
+	if b := getCachedKPIEntry(bid, &d); b != nil {
+		return b.k, nil
+	}
+	k, err := computeBusinessKPI(xbiz, &d)
+	if err != nil {
+		return k, err
+	}
+	storeKPIToCache(bid, &d, &k)
+	return k, nil
+}
+
+// computeBusinessKPI does the work of GetBusinessKPI
+func computeBusinessKPI(xbiz *XBusiness, dt *time.Time) (BusinessKPI, error) {
+	bid := xbiz.P.BID
+	k := BusinessKPI{BID: bid, Dt: *dt, Computed: time.Now()}
+	dtNext := dt.AddDate(0, 0, 1)
+	m1, m2 := GetMonthPeriodForDate(dt)
+
+	//--------------------------------------------------------------
+	// the rentables and their types on dt. If overlapping type refs
+	// cover dt, the one that started most recently is used.
+	//--------------------------------------------------------------
+	type unit struct {
+		rtid     int64
+		rc       int64
+		use      int64
+		lease    int64
+		occupied bool
+		rent     float64
+	}
+	units := map[int64]*unit{}
+	q := "SELECT r.RID,COALESCE(t.RTID,0),COALESCE(t.OverrideRentCycle,0) FROM Rentable r LEFT JOIN RentableTypeRef t ON t.RID=r.RID AND t.DtStart<=? AND ?<t.DtStop WHERE r.BID=? ORDER BY r.RID,t.DtStart DESC,t.RTRID DESC"
+	rows, err := RRdb.Dbrr.Query(q, dt, dt, bid)
+	if err != nil {
+		return k, err
+	}
+	for rows.Next() {
+		var rid, rtid, rc int64
+		if err = rows.Scan(&rid, &rtid, &rc); err != nil {
+			rows.Close()
+			return k, err
+		}
+		if _, ok := units[rid]; ok {
+			continue
+		}
+		if rc == 0 {
+			rc = xbiz.RT[rtid].RentCycle
+		}
+		units[rid] = &unit{rtid: rtid, rc: rc}
+	}
+	rows.Close()
+
+	//--------------------------------------------------------------
+	// use and lease status on dt
+	//--------------------------------------------------------------
+	q = "SELECT RID,UseStatus,LeaseStatus FROM RentableStatus WHERE BID=? AND DtStart<=? AND ?<DtStop"
+	if rows, err = RRdb.Dbrr.Query(q, bid, dt, dt); err != nil {
+		return k, err
+	}
+	for rows.Next() {
+		var rid, us, ls int64
+		if err = rows.Scan(&rid, &us, &ls); err != nil {
+			rows.Close()
+			return k, err
+		}
+		if u, ok := units[rid]; ok {
+			u.use, u.lease = us, ls
+		}
+	}
+	rows.Close()
+
+	//--------------------------------------------------------------
+	// rentables under a rental agreement on dt
+	//--------------------------------------------------------------
+	q = "SELECT RID,ContractRent FROM RentalAgreementRentables WHERE BID=? AND RARDtStart<=? AND ?<RARDtStop"
+	if rows, err = RRdb.Dbrr.Query(q, bid, dt, dt); err != nil {
+		return k, err
+	}
+	for rows.Next() {
+		var rid int64
+		var rent float64
+		if err = rows.Scan(&rid, &rent); err != nil {
+			rows.Close()
+			return k, err
+		}
+		if u, ok := units[rid]; ok && !u.occupied {
+			u.occupied, u.rent = true, rent
+		}
+	}
+	rows.Close()
+
+	//--------------------------------------------------------------
+	// occupancy, and rent by rentable type
+	//--------------------------------------------------------------
+	rtm := map[int64]*KPIRentableType{}
+	for _, u := range units {
+		k.Units++
+		t, ok := rtm[u.rtid]
+		if !ok {
+			t = &KPIRentableType{RTID: u.rtid, Name: xbiz.RT[u.rtid].Name, MarketRate: kpiMarketRate(xbiz, u.rtid, dt)}
+			rtm[u.rtid] = t
+		}
+		t.Units++
+		if u.rc != CYCLENORECUR {
+			k.GSR += t.MarketRate * float64(m2.Sub(m1)) / float64(CycleDuration(u.rc, m1))
+		}
+		if u.lease == LEASESTATUSonNoticePreleased || u.lease == LEASESTATUSonNoticeAvailable {
+			k.OnNotice++
+		}
+		if u.use == USESTATUSadmin || u.use == USESTATUSemployee || u.use == USESTATUSmodel {
+			k.ExcludedUnits++
+			continue
+		}
+		k.AvailableUnits++
+		switch {
+		case u.occupied:
+			k.OccupiedUnits++
+			t.LeasedUnits++
+			t.AvgContractRent += u.rent
+		case u.lease == LEASESTATUSvacantRented:
+			k.PreleasedUnits++
+		}
+	}
+	if k.AvailableUnits > 0 {
+		k.Occupancy = 100 * float64(k.OccupiedUnits) / float64(k.AvailableUnits)
+		k.Leased = 100 * float64(k.OccupiedUnits+k.PreleasedUnits) / float64(k.AvailableUnits)
+	}
+	for _, t := range rtm {
+		if t.LeasedUnits > 0 {
+			t.AvgContractRent = RoundToCent(t.AvgContractRent / float64(t.LeasedUnits))
+		}
+		if t.MarketRate != 0 {
+			t.RentToMarket = 100 * t.AvgContractRent / t.MarketRate
+		}
+		k.RentableTypes = append(k.RentableTypes, *t)
+	}
+	sort.Slice(k.RentableTypes, func(i, j int) bool { return k.RentableTypes[i].Name < k.RentableTypes[j].Name })
+	k.GSR = RoundToCent(k.GSR)
+
+	//--------------------------------------------------------------
+	// delinquency
+	//--------------------------------------------------------------
+	if _, ok := RRdb.BizTypes[bid]; ok {
+		for lid, a := range GetGLAccountMap(bid) {
+			if a.AcctType == AccountsReceivable && a.AllowPost == 1 {
+				k.Delinquency += GetAccountBalance(bid, lid, &dtNext)
+			}
+		}
+		k.Delinquency = RoundToCent(k.Delinquency)
+	}
+	if k.GSR != 0 {
+		k.DelinquencyPct = 100 * k.Delinquency / k.GSR
+	}
+
+	//--------------------------------------------------------------
+	// receipts and deposits
+	//--------------------------------------------------------------
+	k.UnallocatedReceipts = int64(GetUnallocatedReceiptsCount(bid))
+	q = "SELECT COUNT(*),COALESCE(SUM(Amount-ClearedAmount),0) FROM Deposit WHERE BID=? AND Dt<? AND ClearedAmount<Amount"
+	if err = RRdb.Dbrr.QueryRow(q, bid, dtNext).Scan(&k.UnclearedDeposits, &k.UnclearedAmount); err != nil {
+		return k, err
+	}
+	k.UnclearedAmount = RoundToCent(k.UnclearedAmount)
+
+	//--------------------------------------------------------------
+	// move-ins and move-outs, month to date
+	//--------------------------------------------------------------
+	q = "SELECT COUNT(*) FROM RentalAgreement WHERE BID=? AND ?<=PossessionStart AND PossessionStart<?"
+	if err = RRdb.Dbrr.QueryRow(q, bid, m1, dtNext).Scan(&k.MoveIns); err != nil {
+		return k, err
+	}
+	q = "SELECT COUNT(*) FROM RentalAgreement WHERE BID=? AND ?<=PossessionStop AND PossessionStop<?"
+	if err = RRdb.Dbrr.QueryRow(q, bid, m1, dtNext).Scan(&k.MoveOuts); err != nil {
+		return k, err
+	}
+	return k, nil
+}
+
+// kpiMarketRate returns the market rate of rentable type rtid on dt
+func kpiMarketRate(xbiz *XBusiness, rtid int64, dt *time.Time) float64 {
+	mr := xbiz.RT[rtid].MR
+	for i := 0; i < len(mr); i++ {
+		if !dt.Before(mr[i].DtStart) && dt.Before(mr[i].DtStop) {
+			return mr[i].MarketRate
+		}
+	}
+	return float64(0)
+}
@@ -0,0 +1,79 @@
+// +build sqlite
+
+package rlib_test
+
+import (
+	"reflect"
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+)
+
+// kpiStatus sets the use and lease status of rentable rid
+func kpiStatus(t *testing.T, rid int64, use, lease int64) {
+	d1, d2 := rrtest.BizStart, rrtest.Forever
+	m := rlib.GetRentableStatusByRange(rid, &d1, &d2)
+	if len(m) != 1 {
+		t.Fatalf("expect one status for RID %d, got %d", rid, len(m))
+	}
+	m[0].UseStatus, m[0].LeaseStatus = use, lease
+	if err := rlib.UpdateRentableStatus(&m[0]); err != nil {
+		t.Fatalf("UpdateRentableStatus: %s", err.Error())
+	}
+}
+
+// 101 is occupied, 102 is vacant and leased to start later, and 103 is a
+// model unit, out of the available units
+func TestComputeBusinessKPI(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	kpiStatus(t, b.RID[1], rlib.USESTATUSinService, rlib.LEASESTATUSvacantRented)
+	kpiStatus(t, b.RID[2], rlib.USESTATUSmodel, rlib.LEASESTATUSvacantNotRented)
+	journalAssessment(t, b, "Late Fee", rrtest.Dt(2017, 3, 5), 60)
+	gla := rlib.RRdb.BizTypes[b.BID].GLAccounts
+
+	dt := rrtest.Dt(2017, 3, 15)
+	k, err := rlib.ComputeBusinessKPI(&b.XBiz, &dt)
+	if err != nil {
+		t.Fatalf("computeBusinessKPI: %s", err.Error())
+	}
+	counts := []struct {
+		what      string
+		got, want int64
+	}{
+		{"Units", k.Units, 3},
+		{"ExcludedUnits", k.ExcludedUnits, 1},
+		{"AvailableUnits", k.AvailableUnits, 2},
+		{"OccupiedUnits", k.OccupiedUnits, 1},
+		{"PreleasedUnits", k.PreleasedUnits, 1},
+	}
+	for _, c := range counts {
+		if c.got != c.want {
+			t.Errorf("%s: expect %d, got %d", c.what, c.want, c.got)
+		}
+	}
+	amounts := []struct {
+		what      string
+		got, want float64
+	}{
+		{"Occupancy", k.Occupancy, 50},
+		{"Leased", k.Leased, 100},
+		{"GSR", k.GSR, 3000},
+		{"Delinquency", k.Delinquency, 60},
+		{"DelinquencyPct", k.DelinquencyPct, 2},
+	}
+	for _, a := range amounts {
+		if rlib.RoundToCent(a.got) != a.want {
+			t.Errorf("%s: expect %.2f, got %.2f", a.what, a.want, a.got)
+		}
+	}
+	if len(k.RentableTypes) != 1 {
+		t.Fatalf("expect 1 rentable type, got %d", len(k.RentableTypes))
+	}
+	if rt := k.RentableTypes[0]; rt.Units != 3 || rt.LeasedUnits != 1 || rt.AvgContractRent != rrtest.MarketRate || rt.RentToMarket != 100 {
+		t.Errorf("expect 3 units, 1 leased at the market rate, got %+v", rt)
+	}
+	if reflect.ValueOf(rlib.RRdb.BizTypes[b.BID].GLAccounts).Pointer() != reflect.ValueOf(gla).Pointer() {
+		t.Errorf("expect the chart of accounts of the business to be left alone")
+	}
+}
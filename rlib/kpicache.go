@@ -0,0 +1,122 @@
+package rlib
+
+import (
+	"fmt"
+	"time"
+)
+
+// KPICacheEntry is the data type for KPI cache entries.
+type KPICacheEntry struct {
+	bid    int64
+	dt     time.Time
+	k      BusinessKPI
+	expire *time.Time
+}
+
+// KPICacheCtx is the context used for the KPI cache.  An entry is not
+// extended when it is read, so the metrics are never older than Expiry.
+var KPICacheCtx = SimpleCacheCtx{
+	Expiry: time.Duration(time.Minute * 10),
+}
+var kpicache = map[string]*KPICacheEntry{} // initialize an empty cache
+
+// KPICacheController is a go routine that will controll access to
+// kpicache when multiple routines are trying to write to it.
+//-----------------------------------------------------------------------------
+func KPICacheController() {
+	KPICacheCtx.SemAck = make(chan int)
+	KPICacheCtx.Sem = make(chan int)
+	for {
+		select {
+		case <-KPICacheCtx.Sem:
+			KPICacheCtx.SemAck <- 1 // Let the caller know they have it
+			<-KPICacheCtx.SemAck    // wait until caller is finished
+		}
+	}
+}
+
+// getKPICacheKey returns the string used as a key in the map for
+// the supplied input variables.
+//
+// INPUTS
+//  bid  - biz id
+//  dt   - date of the metrics
+//
+// RETURNS
+//  a key string
+//-----------------------------------------------------------------------------
+func getKPICacheKey(bid int64, dt *time.Time) string {
+	return fmt.Sprintf("%d %s", bid, dt.Format(RRDATEINPFMT))
+}
+
+// getCachedKPIEntry retrieves the metrics from the cache if they exist and
+// have not expired.
+//
+// INPUTS
+//  bid  - biz id
+//  dt   - date of the metrics
+//
+// RETURNS
+//  pointer to the KPICacheEntry if it exists otherwise it
+//  returns nil.
+//-----------------------------------------------------------------------------
+func getCachedKPIEntry(bid int64, dt *time.Time) *KPICacheEntry {
+	b, ok := kpicache[getKPICacheKey(bid, dt)]
+	if !ok || b == nil || time.Now().After(*b.expire) {
+		return nil
+	}
+	return b
+}
+
+// storeKPIToCache stores the supplied metrics. Since this routine is
+// private, it does not check the cache for an existing entry at this key. It
+// assumes the caller understands how to use it.
+//
+// INPUTS
+//  bid  - biz id
+//  dt   - date of the metrics
+//  k    - the metrics
+//
+// RETURNS
+//  nothing
+//-----------------------------------------------------------------------------
+func storeKPIToCache(bid int64, dt *time.Time, k *BusinessKPI) {
+	t := time.Now().Add(KPICacheCtx.Expiry) // it gets this much time
+	b := KPICacheEntry{
+		bid:    bid,
+		dt:     *dt,
+		k:      *k,
+		expire: &t,
+	}
+	key := getKPICacheKey(bid, dt)
+
+	KPICacheCtx.Sem <- 1    // request write access
+	<-KPICacheCtx.SemAck    // pause until we get access
+	kpicache[key] = &b      // <<<<<<<<<<<<<<<    do the cache update
+	KPICacheCtx.SemAck <- 1 // tell the controller we're done
+}
+
+// CleanKPICache examines all the cache values and essentially
+// removes the ones that have timed out.  If the force flag is true
+// then all entries are removed from the cache
+//
+// INPUTS
+//  force - a boolean where true means remove all entries from the cache
+//
+// RETURNS
+//  nothing
+//-----------------------------------------------------------------------------
+func CleanKPICache(force bool) {
+	now := time.Now()
+	for k, v := range kpicache {
+		if v == nil {
+			continue
+		}
+		if force || now.After(*v.expire) {
+			KPICacheCtx.Sem <- 1    // request write access
+			<-KPICacheCtx.SemAck    // pause until we get access
+			delete(kpicache, k)     // <<<<<<<<<<<<<<<  do the cache update
+			KPICacheCtx.SemAck <- 1 // tell the controller we're done
+		}
+	}
+}
@@ -54,10 +54,34 @@ func GetCachedLedgerByGL(bid int64, s string) GLAccount {
 
 // GenerateLedgerEntriesFromJournal creates all the LedgerEntries necessary
 // to describe the Journal entry provided. The number of LedgerEntries
-// inserted is returned
+// inserted is returned. It stops at the first LedgerEntry that cannot be
+// inserted and returns the error.
 //-----------------------------------------------------------------------------
-func GenerateLedgerEntriesFromJournal(xbiz *XBusiness, j *Journal, d1, d2 *time.Time) int {
+func GenerateLedgerEntriesFromJournal(xbiz *XBusiness, j *Journal, d1, d2 *time.Time) (int, error) {
+	return GenerateLedgerEntriesFromJournalTx(nil, xbiz, j, d1, d2)
+}
+
+// GenerateLedgerEntriesFromJournalTx is GenerateLedgerEntriesFromJournal
+// performed within transaction tx. If tx is nil the database is used directly.
+func GenerateLedgerEntriesFromJournalTx(tx *RRTx, xbiz *XBusiness, j *Journal, d1, d2 *time.Time) (int, error) {
 	nr := 0
+	m := journalLedgerEntries(xbiz, j, d1, d2)
+	for i := 0; i < len(m); i++ {
+		dup := GetLedgerEntryByJAIDTx(tx, m[i].BID, m[i].LID, m[i].JAID)
+		if dup.LEID == 0 {
+			if _, err := InsertLedgerEntryTx(tx, &m[i]); err != nil {
+				return nr, err
+			}
+			nr++
+		}
+	}
+	return nr, nil
+}
+
+// journalLedgerEntries returns the LedgerEntries that describe the Journal
+// entry j. Nothing is written to the database.
+func journalLedgerEntries(xbiz *XBusiness, j *Journal, d1, d2 *time.Time) []LedgerEntry {
+	var t []LedgerEntry
 	for i := 0; i < len(j.JA); i++ {
 		m := ParseAcctRule(xbiz, j.JA[i].RID, d1, d2, j.JA[i].AcctRule, j.JA[i].Amount, 1.0)
 		for k := 0; k < len(m); k++ {
@@ -76,15 +100,11 @@ func GenerateLedgerEntriesFromJournal(xbiz *XBusiness, j *Journal, d1, d2 *time.
 			ledger := GetCachedLedgerByGL(l.BID, m[k].Account)
 			l.LID = ledger.LID
 			if l.Amount >= float64(0.005) || l.Amount < float64(-0.005) { // ignore rounding errors
-				dup := GetLedgerEntryByJAID(l.BID, l.LID, l.JAID) //
-				if dup.LEID == 0 {
-					InsertLedgerEntry(&l)
-					nr++
-				}
+				t = append(t, l)
 			}
 		}
 	}
-	return nr
+	return t
 }
 
 // UpdateRentableLedgerMarkers keeps track of the balance associated with a
@@ -366,11 +386,30 @@ func GenerateLedgerMarkers(xbiz *XBusiness, d2 *time.Time) {
 	//UpdatePayorSubLedgers(xbiz.P.BID, d1, d2)
 }
 
-// GenerateLedgerEntries creates ledgers records based on the Journal records over the supplied time range.
+// GenerateLedgerEntries posts the Journal records over the supplied time
+// range that do not have LedgerEntries yet, then updates the LedgerMarkers.
+// Nothing that has already been posted is removed or changed. The number of
+// LedgerEntries added is returned.
 func GenerateLedgerEntries(xbiz *XBusiness, d1, d2 *time.Time) int {
 	nr := 0
-	// Console("Generate Ledger Records: BID=%d, d1 = %s, d2 = %s\n", xbiz.P.BID, d1.Format(RRDATEFMT4), d2.Format(RRDATEFMT4))
-	// funcname := "GenerateLedgerEntries"
+	InitLedgerCache()
+	m := GetUnpostedJournalsInRange(xbiz.P.BID, d1, d2)
+	for i := 0; i < len(m); i++ {
+		n, err := GenerateLedgerEntriesFromJournal(xbiz, &m[i], d1, d2)
+		Errlog(err)
+		nr += n
+	}
+	GenerateLedgerMarkers(xbiz, d2)
+	return nr
+}
+
+// RebuildLedgerEntries removes the LedgerEntries over the supplied time
+// range and creates them again from the Journal records. This is what
+// GenerateLedgerEntries did before posting became incremental. It is slow on
+// a large business and should only be needed when VerifyPosting finds a
+// problem.
+func RebuildLedgerEntries(xbiz *XBusiness, d1, d2 *time.Time) int {
+	nr := 0
 	err := RemoveLedgerEntries(xbiz, d1, d2)
 	if err != nil {
 		Ulog("Could not remove existing LedgerEntries from %s to %s. err = %v\n", d1.Format(RRDATEFMT), d2.Format(RRDATEFMT), err)
@@ -387,7 +426,9 @@ func GenerateLedgerEntries(xbiz *XBusiness, d1, d2 *time.Time) int {
 		var j Journal
 		ReadJournals(rows, &j)
 		GetJournalAllocations(&j)
-		nr += GenerateLedgerEntriesFromJournal(xbiz, &j, d1, d2)
+		n, err := GenerateLedgerEntriesFromJournal(xbiz, &j, d1, d2)
+		Errlog(err)
+		nr += n
 	}
 	Errcheck(rows.Err())
 	GenerateLedgerMarkers(xbiz, d2)
@@ -0,0 +1,167 @@
+package rlib
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SendMail sends a plain text message with the supplied files attached,
+// using the mail server in RRConfig. The connection is upgraded with
+// STARTTLS when the server offers it; port 465 uses TLS from the start.
+//
+// INPUTS
+//    to      = recipient addresses
+//    subject = subject line
+//    body    = message text
+//    files   = paths of the files to attach
+//
+// RETURNS
+//    any error encountered
+//-----------------------------------------------------------------------------
+func SendMail(to []string, subject, body string, files []string) error {
+	if len(RRConfig.SMTPHost) == 0 {
+		return fmt.Errorf("SendMail: no SMTPHost in config.json")
+	}
+	port := RRConfig.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	msg, err := mailMessage(RRConfig.MailFrom, to, subject, body, files)
+	if err != nil {
+		return fmt.Errorf("SendMail: %s", err.Error())
+	}
+	var auth smtp.Auth
+	if len(RRConfig.SMTPUser) > 0 {
+		auth = smtp.PlainAuth("", RRConfig.SMTPUser, RRConfig.SMTPPass, RRConfig.SMTPHost)
+	}
+	addr := net.JoinHostPort(RRConfig.SMTPHost, strconv.Itoa(port))
+	if port != 465 {
+		err = smtp.SendMail(addr, auth, RRConfig.MailFrom, to, msg)
+	} else {
+		err = mailSendTLS(addr, auth, RRConfig.MailFrom, to, msg)
+	}
+	if err != nil {
+		return fmt.Errorf("SendMail: %s", err.Error())
+	}
+	return nil
+}
+
+// mailSendTLS is smtp.SendMail for a server that expects TLS from the start
+func mailSendTLS(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	host, _, _ := net.SplitHostPort(addr)
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: host})
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if auth != nil {
+		if err = c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(from); err != nil {
+		return err
+	}
+	for i := 0; i < len(to); i++ {
+		if err = c.Rcpt(to[i]); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// mailMessage builds a multipart/mixed message with a quoted-printable text
+// part followed by one base64 part for each file.
+//
+// INPUTS
+//    from    = sender address
+//    to      = recipient addresses
+//    subject = subject line
+//    body    = message text
+//    files   = paths of the files to attach
+//
+// RETURNS
+//    the message, ready to send
+//    any error encountered reading the files
+//-----------------------------------------------------------------------------
+func mailMessage(from string, to []string, subject, body string, files []string) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", "text/plain; charset=UTF-8")
+	h.Set("Content-Transfer-Encoding", "quoted-printable")
+	pw, err := mw.CreatePart(h)
+	if err != nil {
+		return nil, err
+	}
+	qw := quotedprintable.NewWriter(pw)
+	if _, err = qw.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	qw.Close()
+
+	for i := 0; i < len(files); i++ {
+		b, err := ioutil.ReadFile(files[i])
+		if err != nil {
+			return nil, err
+		}
+		name := filepath.Base(files[i])
+		ct := mime.TypeByExtension(filepath.Ext(name))
+		if len(ct) == 0 {
+			ct = "application/octet-stream"
+		}
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Type", mime.FormatMediaType(ct, map[string]string{"name": name}))
+		h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+		h.Set("Content-Transfer-Encoding", "base64")
+		pw, err := mw.CreatePart(h)
+		if err != nil {
+			return nil, err
+		}
+		s := base64.StdEncoding.EncodeToString(b)
+		for len(s) > 76 {
+			fmt.Fprintf(pw, "%s\r\n", s[:76])
+			s = s[76:]
+		}
+		fmt.Fprintf(pw, "%s\r\n", s)
+	}
+	if err = mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
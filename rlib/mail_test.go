@@ -0,0 +1,78 @@
+package rlib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The message is read back as a mail reader would: the headers, the text and
+// the attachment come back as they went in
+func TestMailMessage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rrmail")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	att := bytes.Repeat([]byte("Unit,Rent\r\n101,1000.00\r\n"), 20)
+	path := filepath.Join(dir, "rentroll.csv")
+	if err = ioutil.WriteFile(path, att, 0600); err != nil {
+		t.Fatalf("WriteFile: %s", err.Error())
+	}
+
+	body := "Rent roll for März attached. A line longer than seventy-six characters is wrapped by the encoder."
+	b, err := mailMessage("rr@example.com", []string{"a@example.com", "b@example.com"}, "Rent Roll – März", body, []string{path})
+	if err != nil {
+		t.Fatalf("mailMessage: %s", err.Error())
+	}
+	m, err := mail.ReadMessage(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadMessage: %s", err.Error())
+	}
+	if to, err := m.Header.AddressList("To"); err != nil || len(to) != 2 {
+		t.Errorf("expect 2 recipients, got %v %v", to, err)
+	}
+	var dec mime.WordDecoder
+	if s, err := dec.DecodeHeader(m.Header.Get("Subject")); err != nil || s != "Rent Roll – März" {
+		t.Errorf("expect the subject back, got %q %v", s, err)
+	}
+	mt, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mt != "multipart/mixed" {
+		t.Fatalf("expect multipart/mixed, got %q %v", mt, err)
+	}
+
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	p, err := mr.NextRawPart()
+	if err != nil {
+		t.Fatalf("text part: %s", err.Error())
+	}
+	s, _ := ioutil.ReadAll(quotedprintable.NewReader(p))
+	if string(s) != body {
+		t.Errorf("expect body %q, got %q", body, s)
+	}
+	p, err = mr.NextRawPart()
+	if err != nil {
+		t.Fatalf("attachment: %s", err.Error())
+	}
+	if p.FileName() != "rentroll.csv" || p.Header.Get("Content-Transfer-Encoding") != "base64" {
+		t.Errorf("expect a base64 rentroll.csv, got %q %q", p.FileName(), p.Header.Get("Content-Transfer-Encoding"))
+	}
+	a, _ := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+	if !bytes.Equal(a, att) {
+		t.Errorf("expect the attachment back, got %q", a)
+	}
+	if _, err = mr.NextPart(); err == nil {
+		t.Errorf("expect no more parts")
+	}
+
+	if _, err = mailMessage("rr@example.com", nil, "x", "x", []string{filepath.Join(dir, "missing")}); err == nil {
+		t.Errorf("expect an error for a missing file")
+	}
+}
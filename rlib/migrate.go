@@ -0,0 +1,135 @@
+package rlib
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Migration is one numbered change to the RentRoll schema
+type Migration struct {
+	Version int64    // schema version after the migration is applied
+	Name    string   // what it does
+	Stmts   []string // the SQL statements that make the change
+}
+
+// SchemaVersionTable is the table that records which migrations have been
+// applied to a database
+const SchemaVersionTable = "SchemaVersion"
+
+// SchemaVersionLatest returns the version of the schema this program needs
+func SchemaVersionLatest() int64 {
+	return Migrations[len(Migrations)-1].Version
+}
+
+// schemaTableExists returns true if the database db has the named table
+func schemaTableExists(db *sql.DB, name string) (bool, error) {
+	var n int
+	err := db.QueryRow(DBDialect.TableExistsSQL(), name).Scan(&n)
+	return n > 0, err
+}
+
+// GetSchemaVersion returns the schema version of database db. It is the
+// version of the last migration applied, or 0 if the database has never been
+// versioned.
+func GetSchemaVersion(db *sql.DB) (int64, error) {
+	ok, err := schemaTableExists(db, SchemaVersionTable)
+	if err != nil || !ok {
+		return 0, err
+	}
+	var v sql.NullInt64
+	err = db.QueryRow("SELECT MAX(Version) FROM " + SchemaVersionTable).Scan(&v)
+	return v.Int64, err
+}
+
+// CheckSchemaVersion returns an error if the schema of database db is not
+// the one this program needs
+func CheckSchemaVersion(db *sql.DB) error {
+	v, err := GetSchemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("CheckSchemaVersion: %s", err.Error())
+	}
+	latest := SchemaVersionLatest()
+	switch {
+	case v == 0:
+		return fmt.Errorf("the database has no schema version, it needs migrations up to version %d. Run: rentroll -migrate", latest)
+	case v < latest:
+		return fmt.Errorf("the database schema is version %d, this program needs version %d. Run: rentroll -migrate", v, latest)
+	case v > latest:
+		return fmt.Errorf("the database schema is version %d, which is newer than version %d used by this program. Please upgrade rentroll", v, latest)
+	}
+	return nil
+}
+
+// MigrateSchema applies the migrations database db does not have yet. A
+// database that has never been versioned is assumed to have the version 1
+// schema, the baseline. Each migration is recorded as soon as its statements
+// have run, so if one fails the ones before it are kept and running
+// MigrateSchema again continues from where it stopped.
+//
+// INPUTS
+//    db     = the RentRoll database
+//    report = if not nil it is called before each migration is applied
+//
+// RETURNS
+//    the number of migrations applied
+//    any error encountered
+//-----------------------------------------------------------------------------
+func MigrateSchema(db *sql.DB, report func(m *Migration)) (int, error) {
+	funcname := "MigrateSchema"
+	n := 0
+	v, err := GetSchemaVersion(db)
+	if err != nil {
+		return n, fmt.Errorf("%s: %s", funcname, err.Error())
+	}
+	if v > SchemaVersionLatest() {
+		return n, fmt.Errorf("%s: the database schema is version %d, which is newer than version %d used by this program", funcname, v, SchemaVersionLatest())
+	}
+	if v == 0 {
+		ok, err := schemaTableExists(db, "Business")
+		if err != nil {
+			return n, fmt.Errorf("%s: %s", funcname, err.Error())
+		}
+		if !ok {
+			return n, fmt.Errorf("%s: the database has no RentRoll tables, create it with rrnewdb", funcname)
+		}
+		q := "CREATE TABLE IF NOT EXISTS " + SchemaVersionTable + ` (
+    Version BIGINT NOT NULL,
+    Name VARCHAR(100) NOT NULL DEFAULT '',
+    AppliedTS TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (Version)
+)`
+		if _, err = db.Exec(q); err != nil {
+			return n, fmt.Errorf("%s: %s", funcname, err.Error())
+		}
+		if err = recordMigration(db, &Migrations[0]); err != nil {
+			return n, fmt.Errorf("%s: %s", funcname, err.Error())
+		}
+		v = Migrations[0].Version
+	}
+
+	for i := 0; i < len(Migrations); i++ {
+		m := &Migrations[i]
+		if m.Version <= v {
+			continue
+		}
+		if report != nil {
+			report(m)
+		}
+		for j := 0; j < len(m.Stmts); j++ {
+			if _, err = db.Exec(m.Stmts[j]); err != nil {
+				return n, fmt.Errorf("%s: migration %d (%s), statement %d: %s", funcname, m.Version, m.Name, j+1, err.Error())
+			}
+		}
+		if err = recordMigration(db, m); err != nil {
+			return n, fmt.Errorf("%s: %s", funcname, err.Error())
+		}
+		n++
+	}
+	return n, nil
+}
+
+// recordMigration adds m to the migrations applied to db
+func recordMigration(db *sql.DB, m *Migration) error {
+	_, err := db.Exec("INSERT INTO "+SchemaVersionTable+" (Version,Name) VALUES(?,?)", m.Version, m.Name)
+	return err
+}
@@ -0,0 +1,45 @@
+// +build sqlite
+
+package rlib_test
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+)
+
+// Migration 10 adds the Journal values to the GLExportJournal rows of a
+// version 9 database
+func TestMigrateGLExportJournalKeys(t *testing.T) {
+	rrtest.OpenDB(t)
+	db := rlib.RRdb.Dbrr
+	for _, q := range []string{
+		"DROP TABLE GLExportJournal",
+		rlib.Migrations[3].Stmts[1], // GLExportJournal as version 4 made it
+		"DELETE FROM SchemaVersion WHERE Version=10",
+		"INSERT INTO Journal (BID,Dt,Amount,Type,ID) VALUES(1,'2017-03-05 00:00:00',50,1,7)",
+		"INSERT INTO GLExportJournal (GLEXID,BID,JID) VALUES(1,1,1)",
+		"INSERT INTO GLExportJournal (GLEXID,BID,JID) VALUES(1,1,2)", // Journal entry since removed
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatalf("%s: %s", q, err.Error())
+		}
+	}
+	if n, err := rlib.MigrateSchema(db, nil); err != nil || n != 1 {
+		t.Fatalf("MigrateSchema: expect 1 migration applied, got %d, error %v", n, err)
+	}
+	if v, _ := rlib.GetSchemaVersion(db); v != rlib.SchemaVersionLatest() {
+		t.Errorf("expect schema version %d, got %d", rlib.SchemaVersionLatest(), v)
+	}
+
+	rlib.InitDBHelpers(db, nil) // prepare the statements for the new columns
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	m, err := rlib.GetGLExportedKeysInRange(1, &d1, &d2)
+	if err != nil {
+		t.Fatalf("GetGLExportedKeysInRange: %s", err.Error())
+	}
+	k := rlib.GLExportKey{Type: 1, ID: 7, Dt: rrtest.Dt(2017, 3, 5).Unix(), Cents: 5000}
+	if len(m) != 1 || m[k] != 1 {
+		t.Errorf("expect exported key %#v, got %#v", k, m)
+	}
+}
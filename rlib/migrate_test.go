@@ -0,0 +1,45 @@
+package rlib
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The migrations must be numbered 1, 2, 3, ... and db/schema/schema.sql,
+// which makes new databases, must be at the latest version.
+func TestMigrations(t *testing.T) {
+	for i := 0; i < len(Migrations); i++ {
+		if Migrations[i].Version != int64(i+1) {
+			t.Errorf("Migrations[%d] is version %d, expect %d", i, Migrations[i].Version, i+1)
+		}
+	}
+
+	b, err := ioutil.ReadFile("../db/schema/schema.sql")
+	if err != nil {
+		t.Fatalf("cannot read schema.sql: %s", err.Error())
+	}
+	schema := string(b)
+	var latest int64
+	for _, m := range regexp.MustCompile(`\((\d+),'`).FindAllStringSubmatch(schema[strings.Index(schema, "INSERT INTO SchemaVersion"):], -1) {
+		v, _ := strconv.ParseInt(m[1], 10, 64)
+		if v > latest {
+			latest = v
+		}
+	}
+	if latest != SchemaVersionLatest() {
+		t.Errorf("schema.sql is version %d, the latest migration is %d", latest, SchemaVersionLatest())
+	}
+
+	// every table a migration creates must be in schema.sql
+	re := regexp.MustCompile(`CREATE TABLE IF NOT EXISTS (\w+)`)
+	for i := 0; i < len(Migrations); i++ {
+		for _, s := range Migrations[i].Stmts {
+			if m := re.FindStringSubmatch(s); m != nil && !strings.Contains(schema, "CREATE TABLE "+m[1]+" (") {
+				t.Errorf("migration %d creates table %s, which is not in schema.sql", Migrations[i].Version, m[1])
+			}
+		}
+	}
+}
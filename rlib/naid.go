@@ -473,3 +473,45 @@ func (t *Transactant) SingleLineAddress() string {
 	}
 	return a
 }
+
+//-------------------------------------------------
+//  VENDOR
+//-------------------------------------------------
+
+// IDtoString is the method to produce a consistent printable id string
+func (t *Vendor) IDtoString() string {
+	return IDtoString("VEND", t.VENDID)
+}
+
+// IDtoShortString is the method to produce a consistent printable id string
+func (t *Vendor) IDtoShortString() string {
+	return IDtoShortString("VEND", t.VENDID)
+}
+
+//-------------------------------------------------
+//  BILL
+//-------------------------------------------------
+
+// IDtoString is the method to produce a consistent printable id string
+func (t *Bill) IDtoString() string {
+	return IDtoString("BILL", t.BILLID)
+}
+
+// IDtoShortString is the method to produce a consistent printable id string
+func (t *Bill) IDtoShortString() string {
+	return IDtoShortString("BILL", t.BILLID)
+}
+
+//-------------------------------------------------
+//  BILLPAYMENT
+//-------------------------------------------------
+
+// IDtoString is the method to produce a consistent printable id string
+func (t *BillPayment) IDtoString() string {
+	return IDtoString("BPMT", t.BPID)
+}
+
+// IDtoShortString is the method to produce a consistent printable id string
+func (t *BillPayment) IDtoShortString() string {
+	return IDtoShortString("BPMT", t.BPID)
+}
@@ -0,0 +1,462 @@
+package rlib
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Journal and ledger posting is incremental. Assessments, receipts and
+// expenses are journaled and posted to the ledgers when they are saved, and
+// GenerateLedgerEntries only posts the Journal entries that have no
+// LedgerEntries. Vacancy is the exception: it is not saved by anyone, it is
+// computed from the rental agreements, rentable statuses and rentable types.
+// When one of those changes, the period it affects is recorded as a
+// DirtyRange for the rentable, and RecomputeVacancy redoes the vacancy of
+// just those rentables over just those periods.
+//
+// VerifyPosting compares what is in the database with what a full rebuild
+// would produce, without changing anything.
+
+// MarkDirty records that the vacancy of Rentable rid in business bid must be
+// recomputed over d1 - d2. Ranges of the same rentable that overlap d1 - d2
+// are merged into it.
+func MarkDirty(bid, rid int64, d1, d2 *time.Time) error {
+	return MarkDirtyTx(nil, bid, rid, d1, d2)
+}
+
+// MarkDirtyTx is MarkDirty performed within transaction tx, so the dirty
+// range is written along with the change that caused it or not at all. If
+// tx is nil the database is used directly.
+func MarkDirtyTx(tx *RRTx, bid, rid int64, d1, d2 *time.Time) error {
+	if bid == 0 || rid == 0 || !d1.Before(*d2) {
+		return nil
+	}
+	a := DirtyRange{BID: bid, RID: rid, DtStart: *d1, DtStop: *d2}
+	m, err := GetDirtyRangesTx(tx, bid, d1, d2)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(m); i++ {
+		if m[i].RID != rid {
+			continue
+		}
+		if m[i].DtStart.Before(a.DtStart) {
+			a.DtStart = m[i].DtStart
+		}
+		if m[i].DtStop.After(a.DtStop) {
+			a.DtStop = m[i].DtStop
+		}
+		if err = DeleteDirtyRangeTx(tx, m[i].DRID); err != nil {
+			return err
+		}
+	}
+	_, err = InsertDirtyRangeTx(tx, &a)
+	return err
+}
+
+// MarkRentableTypeDirtyTx marks the vacancy of every Rentable of
+// RentableType rtid in business bid dirty for the part of d1 - d2 during
+// which it is of that type. It is used when something every rentable of
+// the type depends on, such as its market rate, changes. It is performed
+// within transaction tx. If tx is nil the database is used directly.
+func MarkRentableTypeDirtyTx(tx *RRTx, bid, rtid int64, d1, d2 *time.Time) error {
+	m, err := GetRentableTypeRefsByRTIDTx(tx, rtid, d1, d2)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(m); i++ {
+		dt1, dt2 := m[i].DtStart, m[i].DtStop
+		if dt1.Before(*d1) {
+			dt1 = *d1
+		}
+		if dt2.After(*d2) {
+			dt2 = *d2
+		}
+		if err = MarkDirtyTx(tx, bid, m[i].RID, &dt1, &dt2); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dirtyRangeRemainder returns the parts of dirty range a that are outside of
+// d1 - d2. There are none if d1 - d2 covers a, two if a extends past both
+// ends of d1 - d2.
+func dirtyRangeRemainder(a *DirtyRange, d1, d2 *time.Time) []DirtyRange {
+	var m []DirtyRange
+	if a.DtStart.Before(*d1) {
+		b := *a
+		b.DRID = 0
+		b.DtStop = *d1
+		m = append(m, b)
+	}
+	if a.DtStop.After(*d2) {
+		b := *a
+		b.DRID = 0
+		b.DtStart = *d2
+		m = append(m, b)
+	}
+	return m
+}
+
+// ClearDirtyRanges removes d1 - d2 from the dirty ranges of Rentable rid in
+// business bid, or from the dirty ranges of all its rentables if rid is 0.
+// The parts of a range outside d1 - d2 are kept.
+func ClearDirtyRanges(bid, rid int64, d1, d2 *time.Time) error {
+	return ClearDirtyRangesTx(nil, bid, rid, d1, d2)
+}
+
+// ClearDirtyRangesTx is ClearDirtyRanges performed within transaction tx. If
+// tx is nil the database is used directly.
+func ClearDirtyRangesTx(tx *RRTx, bid, rid int64, d1, d2 *time.Time) error {
+	m, err := GetDirtyRangesTx(tx, bid, d1, d2)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(m); i++ {
+		if rid != 0 && m[i].RID != rid {
+			continue
+		}
+		if err = DeleteDirtyRangeTx(tx, m[i].DRID); err != nil {
+			return err
+		}
+		n := dirtyRangeRemainder(&m[i], d1, d2)
+		for j := 0; j < len(n); j++ {
+			if _, err = InsertDirtyRangeTx(tx, &n[j]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RecomputeVacancy redoes the vacancy Journal entries, and their
+// LedgerEntries, for the rentables of xbiz that have a dirty range
+// overlapping d1 - d2. The dirty ranges are cleared for d1 - d2. It is one
+// unit of work: if anything fails, nothing is changed. Nothing is changed if
+// the period has been closed, or if a vacancy entry that has been exported
+// to the general ledger would be changed or removed. Exported entries must
+// be corrected with an adjustment in the general ledger, and the dirty
+// ranges are kept so that VerifyPosting reports them.
+//
+// RETURNS
+//    the number of vacancy Journal entries added
+//    any error encountered
+//-----------------------------------------------------------------------------
+func RecomputeVacancy(xbiz *XBusiness, d1, d2 *time.Time) (int, error) {
+	nr := 0
+	err := RunInTx(func(tx *RRTx) error {
+		var err error
+		nr, err = RecomputeVacancyTx(tx, xbiz, d1, d2)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return nr, nil
+}
+
+// RecomputeVacancyTx is RecomputeVacancy performed within transaction tx. If
+// tx is nil the database is used directly and the changes made before an
+// error are kept.
+func RecomputeVacancyTx(tx *RRTx, xbiz *XBusiness, d1, d2 *time.Time) (int, error) {
+	funcname := "RecomputeVacancy"
+	nr := 0
+	bid := xbiz.P.BID
+	jm := GetJournalMarkerCovering(bid, d1, d2)
+	if jm.JMID > 0 && jm.State != LMOPEN {
+		return nr, fmt.Errorf("%s: the period %s - %s is closed", funcname, d1.Format(RRDATEFMT4), d2.Format(RRDATEFMT4))
+	}
+	m, err := GetDirtyRangesTx(tx, bid, d1, d2)
+	if err != nil {
+		return nr, err
+	}
+	exported, err := GetGLExportedKeysInRangeTx(tx, bid, d1, d2)
+	if err != nil {
+		return nr, err
+	}
+	done := map[int64]bool{}
+	InitLedgerCache()
+	for i := 0; i < len(m); i++ {
+		rid := m[i].RID
+		if done[rid] {
+			continue
+		}
+		done[rid] = true
+
+		//--------------------------------------------------------------
+		// remove the old vacancy entries, then make them again
+		//--------------------------------------------------------------
+		removed := map[GLExportKey]bool{} // exported entries that were removed
+		jv := GetVacancyJournalsInRangeTx(tx, bid, rid, d1, d2)
+		for j := 0; j < len(jv); j++ {
+			if k := glExportJournalKey(&jv[j]); exported[k] > 0 {
+				removed[k] = true
+			}
+			for k := 0; k < len(jv[j].JA); k++ {
+				le := GetLedgerEntriesByJAIDTx(tx, bid, jv[j].JA[k].JAID)
+				for l := 0; l < len(le); l++ {
+					if err = DeleteLedgerEntryTx(tx, le[l].LEID); err != nil {
+						return nr, err
+					}
+				}
+			}
+			if err = DeleteJournalAllocationsTx(tx, jv[j].JID); err != nil {
+				return nr, err
+			}
+			if err = DeleteJournalTx(tx, jv[j].JID); err != nil {
+				return nr, err
+			}
+		}
+		r := GetRentable(rid)
+		if r.RID > 0 {
+			n, err := ProcessRentableTx(tx, xbiz, d1, d2, &r)
+			nr += n
+			if err != nil {
+				return nr, err
+			}
+		}
+
+		//--------------------------------------------------------------
+		// an exported entry may only be made again exactly as it was
+		//--------------------------------------------------------------
+		if len(removed) > 0 {
+			jn := GetVacancyJournalsInRangeTx(tx, bid, rid, d1, d2)
+			for j := 0; j < len(jn); j++ {
+				delete(removed, glExportJournalKey(&jn[j]))
+			}
+			for j := 0; j < len(jv); j++ {
+				if removed[glExportJournalKey(&jv[j])] {
+					return nr, fmt.Errorf("%s: the vacancy of rentable %s on %s (Journal entry %d, %.2f) has been exported to the general ledger and would change, post an adjustment instead",
+						funcname, r.RentableName, jv[j].Dt.Format(RRDATEFMT4), jv[j].JID, jv[j].Amount)
+				}
+			}
+		}
+		if err = ClearDirtyRangesTx(tx, bid, rid, d1, d2); err != nil {
+			return nr, err
+		}
+	}
+	return nr, nil
+}
+
+// Posting problems found by VerifyPosting
+const (
+	POSTMISSINGLE  = 1 // a LedgerEntry a rebuild would create is missing
+	POSTEXTRALE    = 2 // there is a LedgerEntry a rebuild would not create
+	POSTAMOUNT     = 3 // a LedgerEntry has a different amount than a rebuild would give it
+	POSTNOJOURNAL  = 4 // an assessment or receipt has no Journal entry
+	POSTVACANCY    = 5 // the vacancy entries of a rentable differ from a rebuild
+	POSTDIRTYRANGE = 6 // a rentable's vacancy still needs to be recomputed
+)
+
+// PostingDiff is one difference between the journal and ledger records in
+// the database and what a full rebuild would produce
+type PostingDiff struct {
+	Kind     int       // which problem, POSTMISSINGLE ...
+	Dt       time.Time // date of the record
+	JID      int64     // Journal entry, if any
+	JAID     int64     // Journal allocation, if any
+	LID      int64     // GLAccount, if any
+	RID      int64     // Rentable, if any
+	ID       int64     // ASMID or RCPTID for POSTNOJOURNAL
+	Expected float64   // amount a rebuild would give
+	Actual   float64   // amount in the database
+	Comment  string    // description of the problem
+}
+
+// postingKey identifies the LedgerEntries of one JournalAllocation in one
+// GLAccount
+type postingKey struct {
+	JAID, LID int64
+}
+
+// VerifyPosting compares the LedgerEntries and vacancy Journal entries of
+// xbiz over d1 - d2 with what a full rebuild would produce, and checks that
+// every assessment and receipt in the period has been journaled. Nothing in
+// the database is changed.
+//
+// RETURNS
+//    the differences found, none if posting is correct
+//    any error encountered
+//-----------------------------------------------------------------------------
+func VerifyPosting(xbiz *XBusiness, d1, d2 *time.Time) ([]PostingDiff, error) {
+	var d []PostingDiff
+	bid := xbiz.P.BID
+
+	//--------------------------------------------------------------
+	// LedgerEntries
+	//--------------------------------------------------------------
+	expect := map[postingKey]*LedgerEntry{}
+	InitLedgerCache()
+	jnls, err := postingJournals(bid, d1, d2)
+	if err != nil {
+		return d, err
+	}
+	for i := 0; i < len(jnls); i++ {
+		m := journalLedgerEntries(xbiz, &jnls[i], d1, d2)
+		for k := 0; k < len(m); k++ {
+			key := postingKey{m[k].JAID, m[k].LID}
+			if l, ok := expect[key]; ok {
+				l.Amount += m[k].Amount
+				continue
+			}
+			expect[key] = &m[k]
+		}
+	}
+	le, err := GetAllLedgerEntriesInRange(bid, d1, d2)
+	if err != nil {
+		return d, err
+	}
+	actual := map[postingKey]*LedgerEntry{}
+	for i := 0; i < len(le); i++ {
+		key := postingKey{le[i].JAID, le[i].LID}
+		if l, ok := actual[key]; ok {
+			l.Amount += le[i].Amount
+			continue
+		}
+		actual[key] = &le[i]
+	}
+	for k, e := range expect {
+		a, ok := actual[k]
+		switch {
+		case !ok:
+			d = append(d, PostingDiff{Kind: POSTMISSINGLE, Dt: e.Dt, JID: e.JID, JAID: e.JAID, LID: e.LID, RID: e.RID, Expected: e.Amount,
+				Comment: "LedgerEntry missing"})
+		case RoundToCent(a.Amount-e.Amount) != 0:
+			d = append(d, PostingDiff{Kind: POSTAMOUNT, Dt: e.Dt, JID: e.JID, JAID: e.JAID, LID: e.LID, RID: e.RID, Expected: e.Amount, Actual: a.Amount,
+				Comment: "LedgerEntry amount differs"})
+		}
+	}
+	for k, a := range actual {
+		if _, ok := expect[k]; !ok {
+			d = append(d, PostingDiff{Kind: POSTEXTRALE, Dt: a.Dt, JID: a.JID, JAID: a.JAID, LID: a.LID, RID: a.RID, Actual: a.Amount,
+				Comment: "LedgerEntry has no matching Journal allocation"})
+		}
+	}
+
+	//--------------------------------------------------------------
+	// Assessments and receipts without a Journal entry
+	//--------------------------------------------------------------
+	asm := GetSingleInstanceAssessments(bid, d1, d2)
+	for i := 0; i < len(asm); i++ {
+		if asm[i].Start.Before(*d1) || !asm[i].Start.Before(*d2) {
+			continue
+		}
+		if j := GetJournalByTypeAndID(JNLTYPEASMT, asm[i].ASMID); j.JID == 0 {
+			d = append(d, PostingDiff{Kind: POSTNOJOURNAL, Dt: asm[i].Start, RID: asm[i].RID, ID: asm[i].ASMID, Expected: asm[i].Amount,
+				Comment: fmt.Sprintf("Assessment %s has no Journal entry", asm[i].IDtoString())})
+		}
+	}
+	rcpt := GetReceipts(bid, d1, d2)
+	for i := 0; i < len(rcpt); i++ {
+		if j := GetJournalByReceiptID(rcpt[i].RCPTID); j.JID == 0 {
+			d = append(d, PostingDiff{Kind: POSTNOJOURNAL, Dt: rcpt[i].Dt, ID: rcpt[i].RCPTID, Expected: rcpt[i].Amount,
+				Comment: fmt.Sprintf("Receipt RCPT%08d has no Journal entry", rcpt[i].RCPTID)})
+		}
+	}
+
+	//--------------------------------------------------------------
+	// Vacancy
+	//--------------------------------------------------------------
+	dirty := map[int64]bool{}
+	dr, err := GetDirtyRanges(bid, d1, d2)
+	if err != nil {
+		return d, err
+	}
+	for i := 0; i < len(dr); i++ {
+		if !dirty[dr[i].RID] {
+			dirty[dr[i].RID] = true
+			d = append(d, PostingDiff{Kind: POSTDIRTYRANGE, Dt: dr[i].DtStart, RID: dr[i].RID,
+				Comment: fmt.Sprintf("vacancy needs to be recomputed from %s", dr[i].DtStart.Format(RRDATEFMT4))})
+		}
+	}
+	rows, err := RRdb.Prepstmt.GetAllRentablesByBusiness.Query(bid)
+	if err != nil {
+		return d, err
+	}
+	var rlist []Rentable
+	for rows.Next() {
+		var r Rentable
+		if err = ReadRentables(rows, &r); err != nil {
+			rows.Close()
+			return d, err
+		}
+		rlist = append(rlist, r)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return d, err
+	}
+	for i := 0; i < len(rlist); i++ {
+		var e, a float64
+		m := VacancyDetect(xbiz, d1, d2, rlist[i].RID)
+		for k := 0; k < len(m); k++ {
+			e += RoundToCent(m[k].Amount)
+		}
+		jv := GetVacancyJournalsInRange(bid, rlist[i].RID, d1, d2)
+		for k := 0; k < len(jv); k++ {
+			a += jv[k].Amount
+		}
+		if RoundToCent(a-e) != 0 {
+			d = append(d, PostingDiff{Kind: POSTVACANCY, Dt: *d1, RID: rlist[i].RID, Expected: e, Actual: a,
+				Comment: fmt.Sprintf("vacancy of %s differs", rlist[i].RentableName)})
+		}
+	}
+	sort.SliceStable(d, func(i, j int) bool {
+		if !d[i].Dt.Equal(d[j].Dt) {
+			return d[i].Dt.Before(d[j].Dt)
+		}
+		if d[i].Kind != d[j].Kind {
+			return d[i].Kind < d[j].Kind
+		}
+		return d[i].JAID < d[j].JAID || (d[i].JAID == d[j].JAID && d[i].LID < d[j].LID)
+	})
+	return d, nil
+}
+
+// postingJournals returns the Journal entries of business bid in d1 - d2,
+// with their allocations. Unlike GetJournalAllocations, a failed read is
+// returned rather than ending the program, so that VerifyPosting never
+// reports a clean posting it did not actually compare.
+func postingJournals(bid int64, d1, d2 *time.Time) ([]Journal, error) {
+	var m []Journal
+	rows, err := RRdb.Prepstmt.GetAllJournalsInRange.Query(bid, d1, d2)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a Journal
+		err = rows.Scan(&a.JID, &a.BID, &a.Dt, &a.Amount, &a.Type, &a.ID, &a.Comment, &a.CreateTS, &a.CreateBy, &a.LastModTime, &a.LastModBy)
+		if err != nil {
+			return m, err
+		}
+		m = append(m, a)
+	}
+	if err = rows.Err(); err != nil {
+		return m, err
+	}
+	for i := 0; i < len(m); i++ {
+		ja, err := RRdb.Prepstmt.GetJournalAllocations.Query(m[i].JID)
+		if err != nil {
+			return m, err
+		}
+		for ja.Next() {
+			var a JournalAllocation
+			err = ja.Scan(&a.JAID, &a.BID, &a.JID, &a.RID, &a.RAID, &a.TCID, &a.RCPTID, &a.Amount, &a.ASMID, &a.EXPID, &a.AcctRule, &a.CreateTS, &a.CreateBy)
+			if err != nil {
+				ja.Close()
+				return m, err
+			}
+			m[i].JA = append(m[i].JA, a)
+		}
+		err = ja.Err()
+		ja.Close()
+		if err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,199 @@
+// +build sqlite
+
+package rlib_test
+
+import (
+	"errors"
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"strings"
+	"testing"
+)
+
+// vacantBusiness returns a business whose vacancy has been journaled and
+// posted for March 2017. Rentables 102 and 103 are vacant.
+func vacantBusiness(t *testing.T) *rrtest.Biz {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	rlib.InitLedgerCache()
+	if n := rlib.GenVacancyJournals(&b.XBiz, &d1, &d2); n != 2 {
+		t.Fatalf("expect vacancy entries for 2 rentables, got %d", n)
+	}
+	if err := rlib.ClearDirtyRanges(b.BID, 0, &d1, &d2); err != nil {
+		t.Fatalf("ClearDirtyRanges: %s", err.Error())
+	}
+	return b
+}
+
+// vacancyJournals returns the vacancy Journal entries of rentable rid in
+// March 2017
+func vacancyJournals(b *rrtest.Biz, rid int64) []rlib.Journal {
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	return rlib.GetVacancyJournalsInRange(b.BID, rid, &d1, &d2)
+}
+
+// dirtyRanges returns the number of dirty ranges of b in March 2017
+func dirtyRanges(t *testing.T, b *rrtest.Biz) int {
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	m, err := rlib.GetDirtyRanges(b.BID, &d1, &d2)
+	if err != nil {
+		t.Fatalf("GetDirtyRanges: %s", err.Error())
+	}
+	return len(m)
+}
+
+// rent102 rents rentable 102 for March 2017, which makes its vacancy dirty
+func rent102(t *testing.T, b *rrtest.Biz) {
+	rar := rlib.RentalAgreementRentable{RAID: b.RAID, BID: b.BID, RID: b.RID[1], ContractRent: rrtest.MarketRate, RARDtStart: rrtest.Dt(2017, 3, 1), RARDtStop: rrtest.Dt(2017, 4, 1)}
+	if _, err := rlib.InsertRentalAgreementRentable(&rar); err != nil {
+		t.Fatalf("InsertRentalAgreementRentable: %s", err.Error())
+	}
+	if dirtyRanges(t, b) == 0 {
+		t.Fatalf("expect renting 102 to make its vacancy dirty")
+	}
+}
+
+func TestRecomputeVacancy(t *testing.T) {
+	b := vacantBusiness(t)
+	j103 := vacancyJournals(b, b.RID[2])
+	rent102(t, b)
+
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	if _, err := rlib.RecomputeVacancy(&b.XBiz, &d1, &d2); err != nil {
+		t.Fatalf("RecomputeVacancy: %s", err.Error())
+	}
+	if n := len(vacancyJournals(b, b.RID[1])); n != 0 {
+		t.Errorf("expect no vacancy for the rented 102, got %d entries", n)
+	}
+	if j := vacancyJournals(b, b.RID[2]); len(j) != len(j103) || j[0].JID != j103[0].JID {
+		t.Errorf("expect the vacancy of 103 to be left alone")
+	}
+	if n := dirtyRanges(t, b); n != 0 {
+		t.Errorf("expect the dirty ranges to be cleared, got %d", n)
+	}
+}
+
+// An exported vacancy entry is not changed, and nothing else is either
+func TestRecomputeVacancyExported(t *testing.T) {
+	b := vacantBusiness(t)
+	exportGL(t, b, rlib.GLExportOptions{Format: rlib.GLEXPORTCSV})
+	j102 := vacancyJournals(b, b.RID[1])
+	if len(j102) == 0 {
+		t.Fatalf("expect 102 to be vacant")
+	}
+
+	// made again exactly as it was exported
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	if err := rlib.MarkDirty(b.BID, b.RID[1], &d1, &d2); err != nil {
+		t.Fatalf("MarkDirty: %s", err.Error())
+	}
+	if _, err := rlib.RecomputeVacancy(&b.XBiz, &d1, &d2); err != nil {
+		t.Fatalf("expect an unchanged exported entry to be recomputed, got %s", err.Error())
+	}
+	j102 = vacancyJournals(b, b.RID[1])
+
+	// changed
+	rent102(t, b)
+	n := dirtyRanges(t, b)
+	_, err := rlib.RecomputeVacancy(&b.XBiz, &d1, &d2)
+	if err == nil || !strings.Contains(err.Error(), "exported") {
+		t.Fatalf("expect an error about the exported entry, got %v", err)
+	}
+	if j := vacancyJournals(b, b.RID[1]); len(j) != len(j102) || j[0].JID != j102[0].JID {
+		t.Errorf("expect the exported vacancy of 102 to be kept")
+	}
+	for i := 0; i < len(j102[0].JA); i++ {
+		if le := rlib.GetLedgerEntriesByJAID(b.BID, j102[0].JA[i].JAID); len(le) == 0 {
+			t.Errorf("expect the ledger entries of JAID %d to be kept", j102[0].JA[i].JAID)
+		}
+	}
+	if m := dirtyRanges(t, b); m != n {
+		t.Errorf("expect the %d dirty ranges to be kept, got %d", n, m)
+	}
+}
+
+// A change to the market rate of a RentableType makes the vacancy of all its
+// rentables dirty, in the transaction of the change
+func TestMarketRateMarksDirty(t *testing.T) {
+	b := vacantBusiness(t)
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	mr := rlib.RentableMarketRate{RTID: b.RTID, BID: b.BID, MarketRate: 1200, DtStart: d1, DtStop: d2}
+
+	rollback := errors.New("rollback")
+	err := rlib.RunInTx(func(tx *rlib.RRTx) error {
+		if err := rlib.InsertRentableMarketRatesTx(tx, &mr); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if err != rollback {
+		t.Fatalf("expect the rollback error, got %v", err)
+	}
+	if n := dirtyRanges(t, b); n != 0 {
+		t.Fatalf("expect no dirty ranges after a rollback, got %d", n)
+	}
+
+	if err = rlib.InsertRentableMarketRates(&mr); err != nil {
+		t.Fatalf("InsertRentableMarketRates: %s", err.Error())
+	}
+	if n := dirtyRanges(t, b); n != len(b.RID) {
+		t.Fatalf("expect all %d rentables of the type to be dirty, got %d", len(b.RID), n)
+	}
+	if _, err = rlib.RecomputeVacancy(&b.XBiz, &d1, &d2); err != nil {
+		t.Fatalf("RecomputeVacancy: %s", err.Error())
+	}
+
+	rt := rlib.RentableType{RTID: b.RTID}
+	rlib.GetRentableMarketRates(&rt)
+	for i := 0; i < len(rt.MR); i++ {
+		if rt.MR[i].DtStart.Equal(d1) {
+			mr.RMRID = rt.MR[i].RMRID
+		}
+	}
+	if mr.RMRID == 0 {
+		t.Fatalf("cannot find the March market rate")
+	}
+	if err = rlib.DeleteRentableMarketRateInstance(mr.RMRID); err != nil {
+		t.Fatalf("DeleteRentableMarketRateInstance: %s", err.Error())
+	}
+	if n := dirtyRanges(t, b); n != len(b.RID) {
+		t.Errorf("expect deleting the market rate to make all %d rentables dirty, got %d", len(b.RID), n)
+	}
+}
+
+func TestSpecialtyRefMarksDirty(t *testing.T) {
+	b := vacantBusiness(t)
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	if err := rlib.InsertRentableSpecialtyRef(&rlib.RentableSpecialtyRef{BID: b.BID, RID: b.RID[1], RSPID: 1, DtStart: d1, DtStop: d2}); err != nil {
+		t.Fatalf("InsertRentableSpecialtyRef: %s", err.Error())
+	}
+	if n := dirtyRanges(t, b); n != 1 {
+		t.Fatalf("expect 102 to be dirty, got %d dirty ranges", n)
+	}
+	if err := rlib.ClearDirtyRanges(b.BID, 0, &d1, &d2); err != nil {
+		t.Fatalf("ClearDirtyRanges: %s", err.Error())
+	}
+	if err := rlib.DeleteRentableSpecialtyRef(b.RID[1], &d1, &d2); err != nil {
+		t.Fatalf("DeleteRentableSpecialtyRef: %s", err.Error())
+	}
+	if n := dirtyRanges(t, b); n != 1 {
+		t.Errorf("expect deleting the specialty to make 102 dirty, got %d dirty ranges", n)
+	}
+}
+
+// A journal allocation that cannot be read fails the verification rather
+// than being reported as posting without differences
+func TestVerifyPostingReadError(t *testing.T) {
+	b := vacantBusiness(t)
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	if _, err := rlib.VerifyPosting(&b.XBiz, &d1, &d2); err != nil {
+		t.Fatalf("VerifyPosting: %s", err.Error())
+	}
+	if _, err := rlib.RRdb.Dbrr.Exec("UPDATE JournalAllocation SET Amount='unreadable' WHERE BID=?", b.BID); err != nil {
+		t.Fatalf("UPDATE JournalAllocation: %s", err.Error())
+	}
+	if _, err := rlib.VerifyPosting(&b.XBiz, &d1, &d2); err == nil {
+		t.Errorf("expect an error from an unreadable journal allocation")
+	}
+}
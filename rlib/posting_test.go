@@ -0,0 +1,39 @@
+package rlib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDirtyRangeRemainder(t *testing.T) {
+	dt := func(m, d int) time.Time { return time.Date(2018, time.Month(m), d, 0, 0, 0, 0, time.UTC) }
+	d1, d2 := dt(3, 1), dt(4, 1)
+	m := []struct {
+		start, stop time.Time
+		expect      [][2]time.Time
+	}{
+		{dt(3, 5), dt(3, 20), nil},                                           // inside the cleared period
+		{dt(3, 1), dt(4, 1), nil},                                            // exactly the cleared period
+		{dt(2, 1), dt(3, 10), [][2]time.Time{{dt(2, 1), d1}}},                // starts before
+		{dt(3, 10), dt(5, 1), [][2]time.Time{{d2, dt(5, 1)}}},                // ends after
+		{dt(1, 1), dt(6, 1), [][2]time.Time{{dt(1, 1), d1}, {d2, dt(6, 1)}}}, // covers it
+	}
+	for i := 0; i < len(m); i++ {
+		a := DirtyRange{DRID: 9, BID: 1, RID: 4, DtStart: m[i].start, DtStop: m[i].stop}
+		r := dirtyRangeRemainder(&a, &d1, &d2)
+		if len(r) != len(m[i].expect) {
+			t.Errorf("%d: expect %d ranges, got %d", i, len(m[i].expect), len(r))
+			continue
+		}
+		for j := 0; j < len(r); j++ {
+			if r[j].DRID != 0 || r[j].RID != 4 || r[j].BID != 1 {
+				t.Errorf("%d.%d: bad range %#v", i, j, r[j])
+			}
+			if !r[j].DtStart.Equal(m[i].expect[j][0]) || !r[j].DtStop.Equal(m[i].expect[j][1]) {
+				t.Errorf("%d.%d: expect %s - %s, got %s - %s", i, j,
+					m[i].expect[j][0].Format(RRDATEFMT4), m[i].expect[j][1].Format(RRDATEFMT4),
+					r[j].DtStart.Format(RRDATEFMT4), r[j].DtStop.Format(RRDATEFMT4))
+			}
+		}
+	}
+}
@@ -160,6 +160,8 @@ func buildPreparedStatements() {
 	RRdb.DBFields["Building"] = flds
 	RRdb.Prepstmt.GetBuilding, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM Building WHERE BLDGID=?")
 	Errcheck(err)
+	RRdb.Prepstmt.GetBuildingsByBusiness, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM Building WHERE BID=? ORDER BY BLDGID ASC")
+	Errcheck(err)
 	s1, s2, _, s4, s5 = GenSQLInsertAndUpdateStrings(flds)
 	RRdb.Prepstmt.InsertBuilding, err = RRdb.Dbrr.Prepare("INSERT INTO Building (" + s1 + ") VALUES(" + s2 + ")")
 	Errcheck(err)
@@ -186,6 +188,35 @@ func buildPreparedStatements() {
 	RRdb.Prepstmt.GetAllBusinessSpecialtyTypes, err = RRdb.Dbrr.Prepare("SELECT RSPID,BID,Name,Fee,Description FROM RentableSpecialty WHERE BID=?")
 	Errcheck(err)
 
+	//==========================================
+	// Business Group
+	//==========================================
+	flds = "BGID,Name,GroupType,Description,LastModTime,LastModBy,CreateTS,CreateBy"
+	RRdb.DBFields["BusinessGroup"] = flds
+	RRdb.Prepstmt.GetBusinessGroup, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM BusinessGroup WHERE BGID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.GetBusinessGroupByName, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM BusinessGroup WHERE Name=?")
+	Errcheck(err)
+	RRdb.Prepstmt.GetAllBusinessGroups, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM BusinessGroup ORDER BY GroupType ASC, Name ASC")
+	Errcheck(err)
+	s1, s2, s3, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertBusinessGroup, err = RRdb.Dbrr.Prepare("INSERT INTO BusinessGroup (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.UpdateBusinessGroup, err = RRdb.Dbrr.Prepare("UPDATE BusinessGroup SET " + s3 + " WHERE BGID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteBusinessGroup, err = RRdb.Dbrr.Prepare("DELETE FROM BusinessGroup WHERE BGID=?")
+	Errcheck(err)
+
+	flds = "BGID,BID,CreateTS,CreateBy"
+	RRdb.DBFields["BusinessGroupMember"] = flds
+	RRdb.Prepstmt.GetBusinessGroupMembers, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM BusinessGroupMember WHERE BGID=? ORDER BY BID ASC")
+	Errcheck(err)
+	_, _, _, s4, s5 = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertBusinessGroupMember, err = RRdb.Dbrr.Prepare("INSERT INTO BusinessGroupMember (" + s4 + ") VALUES (" + s5 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteBusinessGroupMembers, err = RRdb.Dbrr.Prepare("DELETE FROM BusinessGroupMember WHERE BGID=?")
+	Errcheck(err)
+
 	//==========================================
 	// Custom Attribute
 	//==========================================
@@ -322,6 +353,99 @@ func buildPreparedStatements() {
 	RRdb.Prepstmt.UpdateExpense, err = RRdb.Dbrr.Prepare("UPDATE Expense SET " + s3 + " WHERE EXPID=?")
 	Errcheck(err)
 
+	//==========================================
+	// VENDOR
+	//==========================================
+	flds = "VENDID,BID,Name,Address,Address2,City,State,PostalCode,Country,Phone,Email,TaxID,DefaultLID,FLAGS,LastModTime,LastModBy,CreateTS,CreateBy"
+	RRdb.DBFields["Vendor"] = flds
+	RRdb.Prepstmt.GetVendor, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM Vendor WHERE VENDID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.GetVendorByName, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM Vendor WHERE BID=? AND Name=?")
+	Errcheck(err)
+	RRdb.Prepstmt.GetAllVendors, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM Vendor WHERE BID=? ORDER BY Name ASC")
+	Errcheck(err)
+	s1, s2, s3, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertVendor, err = RRdb.Dbrr.Prepare("INSERT INTO Vendor (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.UpdateVendor, err = RRdb.Dbrr.Prepare("UPDATE Vendor SET " + s3 + " WHERE VENDID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteVendor, err = RRdb.Dbrr.Prepare("DELETE FROM Vendor WHERE VENDID=?")
+	Errcheck(err)
+
+	//==========================================
+	// DIRECTORY PERSON
+	//==========================================
+	flds = "UID,FirstName,LastName,PreferredName,Email,Phone,FLAGS,LastModTime,LastModBy,CreateTS,CreateBy"
+	RRdb.DBFields["DirectoryPerson"] = flds
+	RRdb.Prepstmt.GetDirectoryPerson, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM DirectoryPerson WHERE UID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.GetAllDirectoryPersons, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM DirectoryPerson ORDER BY LastName ASC, FirstName ASC")
+	Errcheck(err)
+	s1, s2, s3, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertDirectoryPerson, err = RRdb.Dbrr.Prepare("INSERT INTO DirectoryPerson (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.UpdateDirectoryPerson, err = RRdb.Dbrr.Prepare("UPDATE DirectoryPerson SET " + s3 + " WHERE UID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteDirectoryPerson, err = RRdb.Dbrr.Prepare("DELETE FROM DirectoryPerson WHERE UID=?")
+	Errcheck(err)
+
+	//==========================================
+	// BILL
+	//==========================================
+	flds = "BILLID,RPBILLID,BID,VENDID,APLID,Dt,DtDue,Amount,DocNo,FLAGS,Comment,LastModTime,LastModBy,CreateTS,CreateBy"
+	RRdb.DBFields["Bill"] = flds
+	RRdb.Prepstmt.GetBill, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM Bill WHERE BILLID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.GetBillsByVendor, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM Bill WHERE VENDID=? AND ?<=Dt AND Dt<? ORDER BY Dt ASC")
+	Errcheck(err)
+	RRdb.Prepstmt.GetBillsThroughDate, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM Bill WHERE BID=? AND Dt<=? AND (FLAGS & 4)=0 ORDER BY DtDue ASC")
+	Errcheck(err)
+	s1, s2, s3, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertBill, err = RRdb.Dbrr.Prepare("INSERT INTO Bill (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.UpdateBill, err = RRdb.Dbrr.Prepare("UPDATE Bill SET " + s3 + " WHERE BILLID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteBill, err = RRdb.Dbrr.Prepare("DELETE FROM Bill WHERE BILLID=?")
+	Errcheck(err)
+
+	//==========================================
+	// BILL ITEM
+	//==========================================
+	flds = "BIID,BILLID,BID,LID,RID,Amount,Description,LastModTime,LastModBy,CreateTS,CreateBy"
+	RRdb.DBFields["BillItem"] = flds
+	RRdb.Prepstmt.GetBillItems, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM BillItem WHERE BILLID=? ORDER BY BIID ASC")
+	Errcheck(err)
+	s1, s2, s3, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertBillItem, err = RRdb.Dbrr.Prepare("INSERT INTO BillItem (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.UpdateBillItem, err = RRdb.Dbrr.Prepare("UPDATE BillItem SET " + s3 + " WHERE BIID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteBillItem, err = RRdb.Dbrr.Prepare("DELETE FROM BillItem WHERE BIID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteBillItems, err = RRdb.Dbrr.Prepare("DELETE FROM BillItem WHERE BILLID=?")
+	Errcheck(err)
+
+	//==========================================
+	// BILL PAYMENT
+	//==========================================
+	flds = "BPID,RPBPID,BID,BILLID,VENDID,DEPID,Dt,Amount,DocNo,FLAGS,Comment,LastModTime,LastModBy,CreateTS,CreateBy"
+	RRdb.DBFields["BillPayment"] = flds
+	RRdb.Prepstmt.GetBillPayment, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM BillPayment WHERE BPID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.GetBillPayments, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM BillPayment WHERE BILLID=? ORDER BY Dt ASC")
+	Errcheck(err)
+	RRdb.Prepstmt.GetBillPaymentsThroughDate, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM BillPayment WHERE BILLID=? AND Dt<=? AND (FLAGS & 4)=0 ORDER BY Dt ASC")
+	Errcheck(err)
+	RRdb.Prepstmt.GetVendorPaymentTotals, err = RRdb.Dbrr.Prepare("SELECT VENDID,SUM(Amount) FROM BillPayment WHERE BID=? AND ?<=Dt AND Dt<? AND (FLAGS & 4)=0 GROUP BY VENDID")
+	Errcheck(err)
+	s1, s2, s3, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertBillPayment, err = RRdb.Dbrr.Prepare("INSERT INTO BillPayment (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.UpdateBillPayment, err = RRdb.Dbrr.Prepare("UPDATE BillPayment SET " + s3 + " WHERE BPID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteBillPayment, err = RRdb.Dbrr.Prepare("DELETE FROM BillPayment WHERE BPID=?")
+	Errcheck(err)
+
 	//==========================================
 	// INVOICE
 	//==========================================
@@ -383,6 +507,10 @@ func buildPreparedStatements() {
 	Errcheck(err)
 	RRdb.Prepstmt.GetJournalByTypeAndID, err = RRdb.Dbrr.Prepare("SELECT " + flds + " from Journal WHERE Type=? AND ID=?")
 	Errcheck(err)
+	RRdb.Prepstmt.GetUnpostedJournalsInRange, err = RRdb.Dbrr.Prepare("SELECT " + flds + " from Journal WHERE BID=? AND ?<=Dt AND Dt<? AND NOT EXISTS (SELECT LEID FROM LedgerEntry WHERE LedgerEntry.JID=Journal.JID) ORDER BY Dt ASC, JID ASC")
+	Errcheck(err)
+	RRdb.Prepstmt.GetVacancyJournalsInRange, err = RRdb.Dbrr.Prepare("SELECT " + flds + " from Journal WHERE BID=? AND Type=0 AND ID=? AND ?<=Dt AND Dt<?")
+	Errcheck(err)
 
 	s1, s2, _, _, _ = GenSQLInsertAndUpdateStrings(flds)
 	RRdb.Prepstmt.InsertJournal, err = RRdb.Dbrr.Prepare("INSERT INTO Journal (" + s1 + ") VALUES(" + s2 + ")")
@@ -424,6 +552,8 @@ func buildPreparedStatements() {
 	Errcheck(err)
 	RRdb.Prepstmt.GetJournalMarkers, err = RRdb.Dbrr.Prepare("SELECT " + flds + " from JournalMarker ORDER BY JMID DESC LIMIT ?")
 	Errcheck(err)
+	RRdb.Prepstmt.GetJournalMarkerCovering, err = RRdb.Dbrr.Prepare("SELECT " + flds + " from JournalMarker WHERE BID=? AND DtStart<=? AND ?<=DtStop ORDER BY JMID DESC LIMIT 1")
+	Errcheck(err)
 
 	s1, s2, _, _, _ = GenSQLInsertAndUpdateStrings(flds)
 	RRdb.Prepstmt.InsertJournalMarker, err = RRdb.Dbrr.Prepare("INSERT INTO JournalMarker (" + s1 + ") VALUES(" + s2 + ")")
@@ -431,6 +561,131 @@ func buildPreparedStatements() {
 	RRdb.Prepstmt.DeleteJournalMarker, err = RRdb.Dbrr.Prepare("DELETE FROM JournalMarker WHERE JMID=?")
 	Errcheck(err)
 
+	//==========================================
+	// Dirty Ranges
+	//==========================================
+	flds = "DRID,BID,RID,DtStart,DtStop,CreateTS"
+	RRdb.DBFields["DirtyRange"] = flds
+	RRdb.Prepstmt.GetDirtyRanges, err = RRdb.Dbrr.Prepare("SELECT " + flds + " from DirtyRange WHERE BID=? AND DtStart<? AND ?<DtStop ORDER BY RID ASC, DtStart ASC")
+	Errcheck(err)
+	s1, s2, _, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertDirtyRange, err = RRdb.Dbrr.Prepare("INSERT INTO DirtyRange (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteDirtyRange, err = RRdb.Dbrr.Prepare("DELETE FROM DirtyRange WHERE DRID=?")
+	Errcheck(err)
+
+	//==========================================
+	// GL Export
+	//==========================================
+	flds = "GLEXID,BID,Format,DtStart,DtStop,JournalCount,LastModTime,LastModBy,CreateTS,CreateBy"
+	RRdb.DBFields["GLExport"] = flds
+	RRdb.Prepstmt.GetGLExport, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM GLExport WHERE GLEXID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.GetGLExports, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM GLExport WHERE BID=? ORDER BY GLEXID DESC")
+	Errcheck(err)
+	s1, s2, _, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertGLExport, err = RRdb.Dbrr.Prepare("INSERT INTO GLExport (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteGLExport, err = RRdb.Dbrr.Prepare("DELETE FROM GLExport WHERE GLEXID=?")
+	Errcheck(err)
+
+	flds = "GLEXID,BID,JID,Type,ID,Dt,Amount,CreateTS,CreateBy"
+	RRdb.DBFields["GLExportJournal"] = flds
+	RRdb.Prepstmt.GetGLExportedJournalsInRange, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM GLExportJournal WHERE BID=? AND ?<=Dt AND Dt<?")
+	Errcheck(err)
+	_, _, _, s4, s5 = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertGLExportJournal, err = RRdb.Dbrr.Prepare("INSERT INTO GLExportJournal (" + s4 + ") VALUES(" + s5 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteGLExportJournals, err = RRdb.Dbrr.Prepare("DELETE FROM GLExportJournal WHERE GLEXID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.LockBusinessForGLExport, err = RRdb.Dbrr.Prepare("SELECT BID FROM Business WHERE BID=? FOR UPDATE")
+	Errcheck(err)
+
+	//==========================================
+	// Webhook
+	//==========================================
+	flds = "WHID,BID,URL,Secret,EventTypes,FLAGS,LastModTime,LastModBy,CreateTS,CreateBy"
+	RRdb.DBFields["Webhook"] = flds
+	RRdb.Prepstmt.GetWebhook, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM Webhook WHERE WHID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.GetWebhooks, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM Webhook WHERE BID=? ORDER BY WHID ASC")
+	Errcheck(err)
+	RRdb.Prepstmt.GetActiveWebhooks, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM Webhook WHERE BID=? AND FLAGS & 1 = 0 ORDER BY WHID ASC")
+	Errcheck(err)
+	s1, s2, s3, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertWebhook, err = RRdb.Dbrr.Prepare("INSERT INTO Webhook (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.UpdateWebhook, err = RRdb.Dbrr.Prepare("UPDATE Webhook SET " + s3 + " WHERE WHID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteWebhook, err = RRdb.Dbrr.Prepare("DELETE FROM Webhook WHERE WHID=?")
+	Errcheck(err)
+
+	//==========================================
+	// Outbox Event
+	//==========================================
+	flds = "EVID,BID,EventType,ObjID,Payload,CreateTS,CreateBy"
+	RRdb.DBFields["OutboxEvent"] = flds
+	RRdb.Prepstmt.GetOutboxEvent, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM OutboxEvent WHERE EVID=?")
+	Errcheck(err)
+	s1, s2, _, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertOutboxEvent, err = RRdb.Dbrr.Prepare("INSERT INTO OutboxEvent (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+
+	//==========================================
+	// Webhook Delivery
+	//==========================================
+	flds = "WHDID,WHID,EVID,BID,Status,Attempts,NextAttempt,LastAttempt,HTTPStatus,LastError,LastModTime,CreateTS"
+	RRdb.DBFields["WebhookDelivery"] = flds
+	RRdb.Prepstmt.GetWebhookDeliveries, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM WebhookDelivery WHERE WHID=? ORDER BY WHDID DESC LIMIT ?")
+	Errcheck(err)
+	RRdb.Prepstmt.GetPendingWebhookDeliveries, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM WebhookDelivery WHERE Status=0 AND NextAttempt<=? ORDER BY NextAttempt ASC, WHDID ASC LIMIT ?")
+	Errcheck(err)
+	s1, s2, s3, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertWebhookDelivery, err = RRdb.Dbrr.Prepare("INSERT INTO WebhookDelivery (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.UpdateWebhookDelivery, err = RRdb.Dbrr.Prepare("UPDATE WebhookDelivery SET " + s3 + " WHERE WHDID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteWebhookDeliveries, err = RRdb.Dbrr.Prepare("DELETE FROM WebhookDelivery WHERE WHID=?")
+	Errcheck(err)
+
+	//==========================================
+	// Report Schedule
+	//==========================================
+	flds = "RSID,BID,Name,Report,Params,DateRule,OutputFormat,Cycle,NextRun,LastRun,LastError,Recipients,Directory,FLAGS,LastModTime,LastModBy,CreateTS,CreateBy"
+	RRdb.DBFields["ReportSchedule"] = flds
+	RRdb.Prepstmt.GetReportSchedule, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM ReportSchedule WHERE RSID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.GetReportSchedules, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM ReportSchedule WHERE BID=? ORDER BY RSID ASC")
+	Errcheck(err)
+	RRdb.Prepstmt.GetDueReportSchedules, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM ReportSchedule WHERE FLAGS & 1 = 0 AND NextRun<=? ORDER BY NextRun ASC, RSID ASC")
+	Errcheck(err)
+	s1, s2, s3, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertReportSchedule, err = RRdb.Dbrr.Prepare("INSERT INTO ReportSchedule (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.UpdateReportSchedule, err = RRdb.Dbrr.Prepare("UPDATE ReportSchedule SET " + s3 + " WHERE RSID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteReportSchedule, err = RRdb.Dbrr.Prepare("DELETE FROM ReportSchedule WHERE RSID=?")
+	Errcheck(err)
+
+	//==========================================
+	// Rent Roll Snapshot
+	//==========================================
+	flds = "RRSID,BID,DtStart,DtStop,PeriodGSR,EndReceivable,EndSecDep,Payload,CreateTS,CreateBy"
+	RRdb.DBFields["RentRollSnapshot"] = flds
+	RRdb.Prepstmt.GetRentRollSnapshot, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM RentRollSnapshot WHERE RRSID=?")
+	Errcheck(err)
+	RRdb.Prepstmt.GetRentRollSnapshotByPeriod, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM RentRollSnapshot WHERE BID=? AND DtStart=? AND DtStop=? ORDER BY RRSID DESC LIMIT 1")
+	Errcheck(err)
+	RRdb.Prepstmt.GetRentRollSnapshotBefore, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM RentRollSnapshot WHERE BID=? AND DtStop<=? ORDER BY DtStop DESC, DtStart DESC, RRSID DESC LIMIT 1")
+	Errcheck(err)
+	RRdb.Prepstmt.GetRentRollSnapshots, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM RentRollSnapshot WHERE BID=? ORDER BY DtStart DESC, DtStop DESC, RRSID DESC")
+	Errcheck(err)
+	s1, s2, _, _, _ = GenSQLInsertAndUpdateStrings(flds)
+	RRdb.Prepstmt.InsertRentRollSnapshot, err = RRdb.Dbrr.Prepare("INSERT INTO RentRollSnapshot (" + s1 + ") VALUES(" + s2 + ")")
+	Errcheck(err)
+	RRdb.Prepstmt.DeleteRentRollSnapshot, err = RRdb.Dbrr.Prepare("DELETE FROM RentRollSnapshot WHERE RRSID=?")
+	Errcheck(err)
+
 	//==========================================
 	// LEDGER-->  GLAccount
 	//==========================================
@@ -753,6 +1008,8 @@ func buildPreparedStatements() {
 	Errcheck(err)
 	RRdb.Prepstmt.GetPayorUnallocatedReceiptsCount, err = RRdb.Dbrr.Prepare("SELECT COUNT(*) FROM Receipt WHERE BID=? AND TCID=? AND (FLAGS & 3)<2 AND 0=(FLAGS & 4)")
 	Errcheck(err)
+	RRdb.Prepstmt.GetUnallocatedReceiptsCount, err = RRdb.Dbrr.Prepare("SELECT COUNT(*) FROM Receipt WHERE BID=? AND (FLAGS & 3)<2 AND 0=(FLAGS & 4)")
+	Errcheck(err)
 
 	s1, s2, s3, _, _ = GenSQLInsertAndUpdateStrings(flds)
 	RRdb.Prepstmt.InsertReceipt, err = RRdb.Dbrr.Prepare("INSERT INTO Receipt (" + s1 + ") VALUES(" + s2 + ")")
@@ -964,6 +1221,8 @@ func buildPreparedStatements() {
 	Errcheck(err)
 	RRdb.Prepstmt.GetRentableTypeRefsByRange, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM RentableTypeRef WHERE RID=? AND DtStop>? AND DtStart<? ORDER BY DtStart ASC")
 	Errcheck(err)
+	RRdb.Prepstmt.GetRentableTypeRefsByRTID, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM RentableTypeRef WHERE RTID=? AND DtStop>? AND DtStart<? ORDER BY RID ASC, DtStart ASC")
+	Errcheck(err)
 	RRdb.Prepstmt.GetRentableTypeRefs, err = RRdb.Dbrr.Prepare("SELECT " + flds + " FROM RentableTypeRef WHERE RID=? ORDER BY DtStart ASC")
 	Errcheck(err)
 
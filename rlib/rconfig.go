@@ -1,8 +1,10 @@
 package rlib
 
 import (
+	"encoding/json"
 	"extres"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"time"
 
@@ -12,6 +14,19 @@ import (
 // AppConfig is the shared struct of configuration values
 var AppConfig extres.ExternalResources
 
+// RRConfig holds the configuration values that only RentRoll uses. They are
+// read from the same config.json as AppConfig.
+var RRConfig struct {
+	Directory       string // where employees are looked up: "phonebook" (default) or "local"
+	ReportArchive   string // directory where scheduled reports are written
+	SMTPHost        string // mail server used to send scheduled reports
+	SMTPPort        int    // mail server port, 587 if not set
+	SMTPUser        string // mail server login, no authentication if empty
+	SMTPPass        string // mail server password
+	MailFrom        string // sender address of mail from RentRoll
+	IntegrityRepair bool   // the nightly integrity check repairs the problems that are safe to repair
+}
+
 // RRReadConfig will read the configuration file "config.json" if
 // it exists in the current directory
 func RRReadConfig(fPath ...string) error {
@@ -35,6 +50,18 @@ func RRReadConfig(fPath ...string) error {
 	if err != nil {
 		log.Fatal(err)
 	}
+	b, err := ioutil.ReadFile(fname)
+	if err == nil {
+		err = json.Unmarshal(b, &RRConfig)
+	}
+	if err == nil {
+		_, err = NewDirectory(RRConfig.Directory)
+	}
+	if err != nil {
+		fmt.Printf("Error reading %s: %s\n", fname, err.Error())
+		Ulog("Error reading %s: %s\n", fname, err.Error())
+		return err
+	}
 	RRdb.Zone, err = time.LoadLocation(AppConfig.Timezone)
 	if err != nil {
 		fmt.Printf("Error loading timezone %s : %s\n", AppConfig.Timezone, err.Error())
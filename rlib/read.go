@@ -39,6 +39,16 @@ func ReadBusinesses(rows *sql.Rows, a *Business) {
 	Errcheck(rows.Scan(&a.BID, &a.Designation, &a.Name, &a.DefaultRentCycle, &a.DefaultProrationCycle, &a.DefaultGSRPC, &a.CreateTS, &a.CreateBy, &a.LastModTime, &a.LastModBy))
 }
 
+// ReadBusinessGroup reads a full BusinessGroup structure from the database based on the supplied row object
+func ReadBusinessGroup(row *sql.Row, a *BusinessGroup) error {
+	return row.Scan(&a.BGID, &a.Name, &a.GroupType, &a.Description, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadBusinessGroups reads a full BusinessGroup structure from the database based on the supplied rows object
+func ReadBusinessGroups(rows *sql.Rows, a *BusinessGroup) error {
+	return rows.Scan(&a.BGID, &a.Name, &a.GroupType, &a.Description, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
 // ReadCustomAttribute reads a full CustomAttribute structure from the database based on the supplied row object
 func ReadCustomAttribute(row *sql.Row, a *CustomAttribute) {
 	Errcheck(row.Scan(&a.CID, &a.BID, &a.Type, &a.Name, &a.Value, &a.Units, &a.CreateTS, &a.CreateBy, &a.LastModTime, &a.LastModBy))
@@ -119,6 +129,116 @@ func ReadExpenses(rows *sql.Rows, a *Expense) error {
 	return rows.Scan(&a.EXPID, &a.RPEXPID, &a.BID, &a.RID, &a.RAID, &a.Amount, &a.Dt, &a.AcctRule, &a.ARID, &a.FLAGS, &a.Comment, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
 }
 
+// ReadGLExport reads a full GLExport structure from the database based on the supplied row object
+func ReadGLExport(row *sql.Row, a *GLExport) error {
+	return row.Scan(&a.GLEXID, &a.BID, &a.Format, &a.DtStart, &a.DtStop, &a.JournalCount, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadGLExports reads a full GLExport structure from the database based on the supplied rows object
+func ReadGLExports(rows *sql.Rows, a *GLExport) error {
+	return rows.Scan(&a.GLEXID, &a.BID, &a.Format, &a.DtStart, &a.DtStop, &a.JournalCount, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadGLExportJournals reads a full GLExportJournal structure from the database based on the supplied rows object
+func ReadGLExportJournals(rows *sql.Rows, a *GLExportJournal) error {
+	return rows.Scan(&a.GLEXID, &a.BID, &a.JID, &a.Type, &a.ID, &a.Dt, &a.Amount, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadDirtyRanges reads a full DirtyRange structure from the database based on the supplied rows object
+func ReadDirtyRanges(rows *sql.Rows, a *DirtyRange) error {
+	return rows.Scan(&a.DRID, &a.BID, &a.RID, &a.DtStart, &a.DtStop, &a.CreateTS)
+}
+
+// ReadWebhook reads a full Webhook structure from the database based on the supplied row object
+func ReadWebhook(row *sql.Row, a *Webhook) error {
+	return row.Scan(&a.WHID, &a.BID, &a.URL, &a.Secret, &a.EventTypes, &a.FLAGS, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadWebhooks reads a full Webhook structure from the database based on the supplied rows object
+func ReadWebhooks(rows *sql.Rows, a *Webhook) error {
+	return rows.Scan(&a.WHID, &a.BID, &a.URL, &a.Secret, &a.EventTypes, &a.FLAGS, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadOutboxEvent reads a full OutboxEvent structure from the database based on the supplied row object
+func ReadOutboxEvent(row *sql.Row, a *OutboxEvent) error {
+	return row.Scan(&a.EVID, &a.BID, &a.EventType, &a.ObjID, &a.Payload, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadWebhookDeliveries reads a full WebhookDelivery structure from the database based on the supplied rows object
+func ReadWebhookDeliveries(rows *sql.Rows, a *WebhookDelivery) error {
+	return rows.Scan(&a.WHDID, &a.WHID, &a.EVID, &a.BID, &a.Status, &a.Attempts, &a.NextAttempt, &a.LastAttempt, &a.HTTPStatus, &a.LastError, &a.LastModTime, &a.CreateTS)
+}
+
+// ReadReportSchedule reads a full ReportSchedule structure from the database based on the supplied row object
+func ReadReportSchedule(row *sql.Row, a *ReportSchedule) error {
+	return row.Scan(&a.RSID, &a.BID, &a.Name, &a.Report, &a.Params, &a.DateRule, &a.OutputFormat, &a.Cycle, &a.NextRun, &a.LastRun, &a.LastError, &a.Recipients, &a.Directory, &a.FLAGS, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadReportSchedules reads a full ReportSchedule structure from the database based on the supplied rows object
+func ReadReportSchedules(rows *sql.Rows, a *ReportSchedule) error {
+	return rows.Scan(&a.RSID, &a.BID, &a.Name, &a.Report, &a.Params, &a.DateRule, &a.OutputFormat, &a.Cycle, &a.NextRun, &a.LastRun, &a.LastError, &a.Recipients, &a.Directory, &a.FLAGS, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadRentRollSnapshot reads a full RentRollSnapshot structure from the database based on the supplied row object
+func ReadRentRollSnapshot(row *sql.Row, a *RentRollSnapshot) error {
+	return row.Scan(&a.RRSID, &a.BID, &a.DtStart, &a.DtStop, &a.PeriodGSR, &a.EndReceivable, &a.EndSecDep, &a.Payload, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadRentRollSnapshots reads a full RentRollSnapshot structure from the database based on the supplied rows object
+func ReadRentRollSnapshots(rows *sql.Rows, a *RentRollSnapshot) error {
+	return rows.Scan(&a.RRSID, &a.BID, &a.DtStart, &a.DtStop, &a.PeriodGSR, &a.EndReceivable, &a.EndSecDep, &a.Payload, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadVendor reads a full Vendor structure from the database based on the supplied row object
+func ReadVendor(row *sql.Row, a *Vendor) error {
+	return row.Scan(&a.VENDID, &a.BID, &a.Name, &a.Address, &a.Address2, &a.City, &a.State, &a.PostalCode, &a.Country, &a.Phone, &a.Email, &a.TaxID, &a.DefaultLID, &a.FLAGS, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadVendors reads a full Vendor structure from the database based on the supplied rows object
+func ReadVendors(rows *sql.Rows, a *Vendor) error {
+	return rows.Scan(&a.VENDID, &a.BID, &a.Name, &a.Address, &a.Address2, &a.City, &a.State, &a.PostalCode, &a.Country, &a.Phone, &a.Email, &a.TaxID, &a.DefaultLID, &a.FLAGS, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadDirectoryPerson reads a full DirectoryPerson structure from the database based on the supplied row object
+func ReadDirectoryPerson(row *sql.Row, a *DirectoryPerson) error {
+	return row.Scan(&a.UID, &a.FirstName, &a.LastName, &a.PreferredName, &a.Email, &a.Phone, &a.FLAGS, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadDirectoryPersons reads a full DirectoryPerson structure from the database based on the supplied rows object
+func ReadDirectoryPersons(rows *sql.Rows, a *DirectoryPerson) error {
+	return rows.Scan(&a.UID, &a.FirstName, &a.LastName, &a.PreferredName, &a.Email, &a.Phone, &a.FLAGS, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadBill reads a full Bill structure from the database based on the supplied row object
+func ReadBill(row *sql.Row, a *Bill) error {
+	return row.Scan(&a.BILLID, &a.RPBILLID, &a.BID, &a.VENDID, &a.APLID, &a.Dt, &a.DtDue, &a.Amount, &a.DocNo, &a.FLAGS, &a.Comment, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadBills reads a full Bill structure from the database based on the supplied rows object
+func ReadBills(rows *sql.Rows, a *Bill) error {
+	return rows.Scan(&a.BILLID, &a.RPBILLID, &a.BID, &a.VENDID, &a.APLID, &a.Dt, &a.DtDue, &a.Amount, &a.DocNo, &a.FLAGS, &a.Comment, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadBillItem reads a full BillItem structure from the database based on the supplied row object
+func ReadBillItem(row *sql.Row, a *BillItem) error {
+	return row.Scan(&a.BIID, &a.BILLID, &a.BID, &a.LID, &a.RID, &a.Amount, &a.Description, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadBillItems reads a full BillItem structure from the database based on the supplied rows object
+func ReadBillItems(rows *sql.Rows, a *BillItem) error {
+	return rows.Scan(&a.BIID, &a.BILLID, &a.BID, &a.LID, &a.RID, &a.Amount, &a.Description, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadBillPayment reads a full BillPayment structure from the database based on the supplied row object
+func ReadBillPayment(row *sql.Row, a *BillPayment) error {
+	return row.Scan(&a.BPID, &a.RPBPID, &a.BID, &a.BILLID, &a.VENDID, &a.DEPID, &a.Dt, &a.Amount, &a.DocNo, &a.FLAGS, &a.Comment, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
+// ReadBillPayments reads a full BillPayment structure from the database based on the supplied rows object
+func ReadBillPayments(rows *sql.Rows, a *BillPayment) error {
+	return rows.Scan(&a.BPID, &a.RPBPID, &a.BID, &a.BILLID, &a.VENDID, &a.DEPID, &a.Dt, &a.Amount, &a.DocNo, &a.FLAGS, &a.Comment, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy)
+}
+
 // ReadGLAccount reads a full Ledger structure of data from the database based on the supplied Rows pointer.
 func ReadGLAccount(row *sql.Row, a *GLAccount) {
 	Errcheck(row.Scan(&a.LID, &a.PLID, &a.BID, &a.RAID, &a.TCID, &a.GLNumber,
@@ -0,0 +1,72 @@
+package rlib
+
+import (
+	"strings"
+	"time"
+)
+
+// ReportScheduleDateRuleNames are the names of the RSDATE* rules, indexed
+// by rule
+var ReportScheduleDateRuleNames = []string{
+	"previous month",
+	"month to date",
+	"current month",
+	"previous week",
+}
+
+// ReportScheduleRange returns the report period for the date range rule of a
+// ReportSchedule that runs on the date of now. As with all RentRoll periods,
+// the stop date is not included in the range.
+//
+// INPUTS
+//    rule = RSDATEPREVMONTH, RSDATEMONTHTODATE, ...
+//    now  = time of the run
+//
+// RETURNS
+//    the start and stop dates of the report
+//-----------------------------------------------------------------------------
+func ReportScheduleRange(rule int64, now time.Time) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	som := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	switch rule {
+	case RSDATEMONTHTODATE:
+		return som, today.AddDate(0, 0, 1)
+	case RSDATECURMONTH:
+		return som, som.AddDate(0, 1, 0)
+	case RSDATEPREVWEEK:
+		// days since Monday
+		n := (int(today.Weekday()) + 6) % 7
+		monday := today.AddDate(0, 0, -n)
+		return monday.AddDate(0, 0, -7), monday
+	}
+	return som.AddDate(0, -1, 0), som
+}
+
+// NextReportRun returns the first run time of rs after now. Runs are always
+// a whole number of cycles after rs.NextRun, so a schedule that was not run
+// for a while keeps its time of day.  A schedule with no cycle has no next
+// run, the zero time is returned.
+//-----------------------------------------------------------------------------
+func NextReportRun(rs *ReportSchedule, now time.Time) time.Time {
+	if rs.Cycle < CYCLEDAILY || rs.Cycle > CYCLEYEARLY {
+		return time.Time{}
+	}
+	t := rs.NextRun
+	for !t.After(now) {
+		t = NextPeriod(&t, rs.Cycle)
+	}
+	return t
+}
+
+// ReportScheduleRecipients returns the email addresses in the comma
+// separated list s
+func ReportScheduleRecipients(s string) []string {
+	var m []string
+	sa := strings.Split(s, ",")
+	for i := 0; i < len(sa); i++ {
+		if a := strings.TrimSpace(sa[i]); len(a) > 0 {
+			m = append(m, a)
+		}
+	}
+	return m
+}
@@ -0,0 +1,56 @@
+package rlib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReportScheduleRange(t *testing.T) {
+	dt := func(m, d int) time.Time { return time.Date(2018, time.Month(m), d, 0, 0, 0, 0, time.UTC) }
+	now := time.Date(2018, time.March, 14, 7, 30, 0, 0, time.UTC) // a Wednesday
+	m := []struct {
+		rule     int64
+		d1, d2   time.Time
+		ruleName string
+	}{
+		{RSDATEPREVMONTH, dt(2, 1), dt(3, 1), "previous month"},
+		{RSDATEMONTHTODATE, dt(3, 1), dt(3, 15), "month to date"},
+		{RSDATECURMONTH, dt(3, 1), dt(4, 1), "current month"},
+		{RSDATEPREVWEEK, dt(3, 5), dt(3, 12), "previous week"},
+	}
+	for i := 0; i < len(m); i++ {
+		d1, d2 := ReportScheduleRange(m[i].rule, now)
+		if !d1.Equal(m[i].d1) || !d2.Equal(m[i].d2) {
+			t.Errorf("%s: expect %s - %s, got %s - %s", m[i].ruleName, m[i].d1.Format(RRDATEFMT4), m[i].d2.Format(RRDATEFMT4), d1.Format(RRDATEFMT4), d2.Format(RRDATEFMT4))
+		}
+	}
+
+	// a Monday run reports on the whole of the week before
+	d1, d2 := ReportScheduleRange(RSDATEPREVWEEK, dt(3, 12))
+	if !d1.Equal(dt(3, 5)) || !d2.Equal(dt(3, 12)) {
+		t.Errorf("previous week on a Monday: got %s - %s", d1.Format(RRDATEFMT4), d2.Format(RRDATEFMT4))
+	}
+}
+
+func TestNextReportRun(t *testing.T) {
+	monday := time.Date(2018, time.March, 5, 7, 0, 0, 0, time.UTC)
+	rs := ReportSchedule{Cycle: CYCLEWEEKLY, NextRun: monday}
+
+	// the run that is due moves one week on
+	next := NextReportRun(&rs, monday.Add(5*time.Minute))
+	if expect := monday.AddDate(0, 0, 7); !next.Equal(expect) {
+		t.Errorf("expect %s, got %s", expect, next)
+	}
+
+	// runs missed while the server was down are skipped, the time of day is kept
+	next = NextReportRun(&rs, monday.AddDate(0, 0, 20))
+	if expect := monday.AddDate(0, 0, 21); !next.Equal(expect) {
+		t.Errorf("expect %s, got %s", expect, next)
+	}
+
+	// a schedule that runs once has no next run
+	rs.Cycle = CYCLENORECUR
+	if next = NextReportRun(&rs, monday); !next.IsZero() {
+		t.Errorf("expect no next run, got %s", next)
+	}
+}
@@ -0,0 +1,264 @@
+package rlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// RRVARMOVEIN et al describe how a unit changed between two rent roll
+// snapshots. A unit can have several of them.
+const (
+	RRVARMOVEIN     = 1 << 0 // a rental agreement holds the unit at the end of the later snapshot that did not at the end of the earlier one
+	RRVARMOVEOUT    = 1 << 1 // the rental agreement that held the unit at the end of the earlier snapshot no longer does
+	RRVARRENT       = 1 << 2 // the rent cycle GSR changed
+	RRVARRECEIVABLE = 1 << 3 // the ending receivable changed
+)
+
+// RentRollVarianceNames is the text for each bit of RentRollVariance.Kind
+var RentRollVarianceNames = []struct {
+	Bit  uint64
+	Name string
+}{
+	{RRVARMOVEIN, "move-in"},
+	{RRVARMOVEOUT, "move-out"},
+	{RRVARRENT, "rent change"},
+	{RRVARRECEIVABLE, "receivable change"},
+}
+
+// RentRollSnapshotUnit summarizes one component of a rent roll snapshot: a
+// rentable, or a rental agreement that has no rentable.
+type RentRollSnapshotUnit struct {
+	Key           string  // R<RID> for a rentable, RA<RAID> for a rental agreement without one
+	RID           int64   // the rentable, 0 for a rental agreement without one
+	Rentable      string  // rentable name
+	RentableType  string  // rentable type name
+	RAID          int64   // rental agreement holding the unit at the end of the period, 0 if vacant
+	Payors        string  // payors of RAID
+	GSR           float64 // rent cycle GSR
+	PeriodGSR     float64 // GSR for the period
+	EndReceivable float64 // receivable at the end of the period
+	EndSecDep     float64 // security deposit at the end of the period
+}
+
+// RentRollVariance describes the change of one unit between two rent roll
+// snapshots
+type RentRollVariance struct {
+	Kind uint64               // RRVARMOVEIN, RRVARMOVEOUT, ...
+	Prev RentRollSnapshotUnit // the unit in the earlier snapshot, zero if it was not there
+	Cur  RentRollSnapshotUnit // the unit in the later snapshot, zero if it is not there
+}
+
+// CreateRentRollSnapshot computes the rent roll of the business for the
+// period d1 - d2 and saves it as a RentRollSnapshot.  A business can have only
+// one snapshot for a period.  To take it again, delete the existing one.
+//
+// INPUTS
+//  bid    - the business
+//  d1, d2 - the period
+//  uid    - employee taking the snapshot, 0 for the month-end worker
+//
+// RETURNS
+//  the snapshot
+//  any error encountered
+//-----------------------------------------------------------------------------
+func CreateRentRollSnapshot(bid int64, d1, d2 *time.Time, uid int64) (RentRollSnapshot, error) {
+	var a RentRollSnapshot
+	b, err := GetRentRollSnapshotByPeriod(bid, d1, d2)
+	if err != nil {
+		return a, err
+	}
+	if b.RRSID > 0 {
+		return a, fmt.Errorf("a rent roll snapshot for %s - %s already exists: %s", d1.Format(RRDATEINPFMT), d2.Format(RRDATEINPFMT), IDtoShortString("RRS", b.RRSID))
+	}
+	rows, _, _, err := GetRentRollRows(bid, *d1, *d2, -1, -1)
+	if err != nil {
+		return a, err
+	}
+	payload, err := json.Marshal(rows)
+	if err != nil {
+		return a, err
+	}
+	a = RentRollSnapshot{BID: bid, DtStart: *d1, DtStop: *d2, Payload: string(payload), CreateBy: uid}
+	for i := 0; i < len(rows); i++ {
+		if rows[i].FLAGS&RentRollGrandTotalRow != 0 {
+			a.PeriodGSR = rows[i].PeriodGSR.Float64
+			a.EndReceivable = rows[i].EndReceivable
+			a.EndSecDep = rows[i].EndSecDep
+		}
+	}
+	_, err = InsertRentRollSnapshot(&a)
+	return a, err
+}
+
+// GetRentRollSnapshotRows returns the rent roll rows saved in snapshot a
+func GetRentRollSnapshotRows(a *RentRollSnapshot) ([]RentRollStaticInfo, error) {
+	var m []RentRollStaticInfo
+	if err := json.Unmarshal([]byte(a.Payload), &m); err != nil {
+		return m, fmt.Errorf("rent roll snapshot %d: %s", a.RRSID, err.Error())
+	}
+	return m, nil
+}
+
+// TakeMonthEndRentRollSnapshots takes the rent roll snapshot of the month
+// before now for every business that does not have one yet.
+//
+// RETURNS
+//  the number of snapshots taken
+//  the last error encountered. A business with an error does not stop the
+//  others.
+//-----------------------------------------------------------------------------
+func TakeMonthEndRentRollSnapshots(now time.Time) (int, error) {
+	const funcname = "TakeMonthEndRentRollSnapshots"
+	var lasterr error
+	n := 0
+	m, err := GetAllBusinesses()
+	if err != nil {
+		return 0, err
+	}
+	d2, _ := GetMonthPeriodForDate(&now)
+	d1 := d2.AddDate(0, -1, 0)
+	for i := 0; i < len(m); i++ {
+		a, err := GetRentRollSnapshotByPeriod(m[i].BID, &d1, &d2)
+		if err == nil && a.RRSID == 0 {
+			_, err = CreateRentRollSnapshot(m[i].BID, &d1, &d2, 0)
+			if err == nil {
+				n++
+			}
+		}
+		if err != nil {
+			Ulog("%s: %s: %s\n", funcname, m[i].Designation, err.Error())
+			lasterr = err
+		}
+	}
+	return n, lasterr
+}
+
+// RentRollSnapshotUnits summarizes the rows of a rent roll for the period
+// ending at dtStop, one RentRollSnapshotUnit for each rentable and for each
+// rental agreement without a rentable.
+//-----------------------------------------------------------------------------
+func RentRollSnapshotUnits(rows []RentRollStaticInfo, dtStop *time.Time) []RentRollSnapshotUnit {
+	var m []RentRollSnapshotUnit
+	var u *RentRollSnapshotUnit
+	for i := 0; i < len(rows); i++ {
+		r := &rows[i]
+		switch {
+		case r.FLAGS&RentRollGrandTotalRow != 0:
+			u = nil
+			continue
+		case r.FLAGS&RentRollBlankRow != 0:
+			u = nil
+			continue
+		case r.FLAGS&RentRollMainRow != 0:
+			m = append(m, RentRollSnapshotUnit{
+				RID:          r.RID.Int64,
+				Rentable:     r.RentableName.String,
+				RentableType: r.RentableType.String,
+				GSR:          r.RentCycleGSR.Float64,
+			})
+			u = &m[len(m)-1]
+			if u.RID > 0 {
+				u.Key = "R" + strconv.FormatInt(u.RID, 10)
+			} else {
+				u.Key = "RA" + strconv.FormatInt(r.RAID.Int64, 10)
+			}
+		}
+		if u == nil {
+			continue
+		}
+		if r.FLAGS&RentRollSubTotalRow != 0 {
+			u.PeriodGSR = r.PeriodGSR.Float64
+			u.EndReceivable = r.EndReceivable
+			u.EndSecDep = r.EndSecDep
+			continue
+		}
+
+		// Rows after the first one for a rental agreement have no dates. The
+		// first row of the agreement that is still in possession at the end of
+		// the period gives the occupant.  Gaps have no RAID.
+		if r.RAID.Valid && r.RAID.Int64 > 0 && r.PossessionStop.Valid && !DateAtTimeZero(r.PossessionStop.Time).Before(DateAtTimeZero(*dtStop)) {
+			u.RAID = r.RAID.Int64
+			u.Payors = r.Payors.String
+		}
+	}
+	return m
+}
+
+// GetRentRollVariance compares the units of two rent roll snapshots and
+// returns the units that changed, in order of rentable name.  Units with no
+// rentable come last.
+//
+// INPUTS
+//  prev - units of the earlier snapshot
+//  cur  - units of the later snapshot
+//-----------------------------------------------------------------------------
+func GetRentRollVariance(prev, cur []RentRollSnapshotUnit) []RentRollVariance {
+	var m []RentRollVariance
+	pm := map[string]RentRollSnapshotUnit{}
+	var keys []string
+	for i := 0; i < len(prev); i++ {
+		pm[prev[i].Key] = prev[i]
+		keys = append(keys, prev[i].Key)
+	}
+	cm := map[string]RentRollSnapshotUnit{}
+	for i := 0; i < len(cur); i++ {
+		cm[cur[i].Key] = cur[i]
+		if _, ok := pm[cur[i].Key]; !ok {
+			keys = append(keys, cur[i].Key)
+		}
+	}
+	for _, k := range keys {
+		v := RentRollVariance{Prev: pm[k], Cur: cm[k]}
+		if v.Cur.RAID > 0 && v.Cur.RAID != v.Prev.RAID {
+			v.Kind |= RRVARMOVEIN
+		}
+		if v.Prev.RAID > 0 && v.Prev.RAID != v.Cur.RAID {
+			v.Kind |= RRVARMOVEOUT
+		}
+		if math.Abs(v.Cur.GSR-v.Prev.GSR) >= 0.005 {
+			v.Kind |= RRVARRENT
+		}
+		if math.Abs(v.Cur.EndReceivable-v.Prev.EndReceivable) >= 0.005 {
+			v.Kind |= RRVARRECEIVABLE
+		}
+		if v.Kind != 0 {
+			m = append(m, v)
+		}
+	}
+	sort.SliceStable(m, func(i, j int) bool {
+		ui, uj := m[i].Unit(), m[j].Unit()
+		if (ui.RID == 0) != (uj.RID == 0) {
+			return uj.RID == 0
+		}
+		return ui.Rentable < uj.Rentable
+	})
+	return m
+}
+
+// Unit returns the unit of v in the later snapshot, or in the earlier one if
+// it is not in the later one
+func (v *RentRollVariance) Unit() *RentRollSnapshotUnit {
+	if len(v.Cur.Key) > 0 {
+		return &v.Cur
+	}
+	return &v.Prev
+}
+
+// RentRollVarianceKindString returns the names of the bits set in kind,
+// separated by commas
+func RentRollVarianceKindString(kind uint64) string {
+	s := ""
+	for _, v := range RentRollVarianceNames {
+		if kind&v.Bit != 0 {
+			if len(s) > 0 {
+				s += ", "
+			}
+			s += v.Name
+		}
+	}
+	return s
+}
@@ -0,0 +1,111 @@
+package rlib
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// rrTestRows returns the rent roll rows of one rentable and one rental
+// agreement without a rentable, as GetRentRollRows would return them
+func rrTestRows(raid int64, possStop time.Time, gsr, endRcv float64) []RentRollStaticInfo {
+	d1 := time.Date(2018, time.February, 1, 0, 0, 0, 0, time.UTC)
+	var m []RentRollStaticInfo
+	var r RentRollStaticInfo
+	r.FLAGS = RentRollMainRow
+	r.RID.Scan(int64(7))
+	r.RentableName.Scan("Unit 7")
+	r.RentableType.Scan("Studio")
+	r.RentCycleGSR = NullFloat64{Float64: gsr, Valid: true}
+	if raid > 0 {
+		r.RAID.Scan(raid)
+		r.Payors.Scan("Pat Payor")
+		r.PossessionStart.Scan(d1.AddDate(0, -6, 0))
+		r.PossessionStop.Scan(possStop)
+	}
+	m = append(m, r)
+	m = append(m, RentRollStaticInfo{BID: 1, FLAGS: RentRollSubTotalRow, EndReceivable: endRcv})
+	m = append(m, RentRollStaticInfo{FLAGS: RentRollBlankRow})
+
+	var f RentRollStaticInfo
+	f.FLAGS = RentRollMainRow
+	f.RAID.Scan(int64(40))
+	m = append(m, f)
+	m = append(m, RentRollStaticInfo{BID: 1, FLAGS: RentRollSubTotalRow, EndReceivable: 25})
+	m = append(m, RentRollStaticInfo{FLAGS: RentRollBlankRow})
+	m = append(m, RentRollStaticInfo{BID: 1, FLAGS: RentRollGrandTotalRow, EndReceivable: endRcv + 25})
+	return m
+}
+
+// rrTestUnits saves rows as a snapshot would, then reads the units back
+func rrTestUnits(t *testing.T, rows []RentRollStaticInfo, dtStop time.Time) []RentRollSnapshotUnit {
+	b, err := json.Marshal(rows)
+	if err != nil {
+		t.Fatalf("json.Marshal: %s", err.Error())
+	}
+	a := RentRollSnapshot{Payload: string(b)}
+	m, err := GetRentRollSnapshotRows(&a)
+	if err != nil {
+		t.Fatalf("GetRentRollSnapshotRows: %s", err.Error())
+	}
+	return RentRollSnapshotUnits(m, &dtStop)
+}
+
+func TestRentRollSnapshotUnits(t *testing.T) {
+	feb := time.Date(2018, time.February, 1, 0, 0, 0, 0, time.UTC)
+	mar := feb.AddDate(0, 1, 0)
+	u := rrTestUnits(t, rrTestRows(3, mar.AddDate(1, 0, 0), 1000, 50), mar)
+	if len(u) != 2 {
+		t.Fatalf("expect 2 units, got %d", len(u))
+	}
+	if u[0].Key != "R7" || u[0].RAID != 3 || u[0].Payors != "Pat Payor" || u[0].GSR != 1000 || u[0].EndReceivable != 50 {
+		t.Errorf("rentable unit: got %#v", u[0])
+	}
+	if u[1].Key != "RA40" || u[1].RID != 0 || u[1].EndReceivable != 25 {
+		t.Errorf("rental agreement without rentable: got %#v", u[1])
+	}
+
+	// an agreement that ends during the period does not hold the unit at its end
+	u = rrTestUnits(t, rrTestRows(3, feb.AddDate(0, 0, 14), 1000, 50), mar)
+	if u[0].RAID != 0 {
+		t.Errorf("expect unit to be vacant at the end of the period, got RAID %d", u[0].RAID)
+	}
+}
+
+func TestGetRentRollVariance(t *testing.T) {
+	feb := time.Date(2018, time.February, 1, 0, 0, 0, 0, time.UTC)
+	mar := feb.AddDate(0, 1, 0)
+	apr := mar.AddDate(0, 1, 0)
+	far := apr.AddDate(1, 0, 0)
+	prev := rrTestUnits(t, rrTestRows(3, far, 1000, 50), mar)
+
+	m := []struct {
+		descr string
+		cur   []RentRollSnapshotUnit
+		kind  uint64
+	}{
+		{"no change", rrTestUnits(t, rrTestRows(3, far, 1000, 50), apr), 0},
+		{"move-out", rrTestUnits(t, rrTestRows(3, mar.AddDate(0, 0, 9), 1000, 50), apr), RRVARMOVEOUT},
+		{"turnover", rrTestUnits(t, rrTestRows(4, far, 1000, 50), apr), RRVARMOVEIN | RRVARMOVEOUT},
+		{"rent and receivable", rrTestUnits(t, rrTestRows(3, far, 1050, 75.5), apr), RRVARRENT | RRVARRECEIVABLE},
+	}
+	for i := 0; i < len(m); i++ {
+		v := GetRentRollVariance(prev, m[i].cur)
+		if m[i].kind == 0 {
+			if len(v) != 0 {
+				t.Errorf("%s: expect no variance, got %d", m[i].descr, len(v))
+			}
+			continue
+		}
+		if len(v) != 1 || v[0].Kind != m[i].kind || v[0].Unit().Key != "R7" {
+			t.Errorf("%s: expect one variance for R7 of kind %s, got %#v", m[i].descr, RentRollVarianceKindString(m[i].kind), v)
+		}
+	}
+
+	// a vacant unit in the earlier snapshot shows a move-in
+	vacant := rrTestUnits(t, rrTestRows(0, far, 1000, 50), mar)
+	v := GetRentRollVariance(vacant, rrTestUnits(t, rrTestRows(3, far, 1000, 50), apr))
+	if len(v) != 1 || v[0].Kind != RRVARMOVEIN || RentRollVarianceKindString(v[0].Kind) != "move-in" {
+		t.Errorf("move-in: got %#v", v)
+	}
+}
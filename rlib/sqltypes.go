@@ -139,6 +139,10 @@ func (ni *NullInt64) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON for NullInt64
 func (ni *NullInt64) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*ni = NullInt64{}
+		return nil
+	}
 	err := json.Unmarshal(b, &ni.Int64)
 	ni.Valid = (err == nil)
 	return err
@@ -154,6 +158,10 @@ func (nb *NullBool) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON for NullBool
 func (nb *NullBool) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*nb = NullBool{}
+		return nil
+	}
 	err := json.Unmarshal(b, &nb.Bool)
 	nb.Valid = (err == nil)
 	return err
@@ -169,6 +177,10 @@ func (nf *NullFloat64) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON for NullFloat64
 func (nf *NullFloat64) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*nf = NullFloat64{}
+		return nil
+	}
 	err := json.Unmarshal(b, &nf.Float64)
 	nf.Valid = (err == nil)
 	return err
@@ -184,6 +196,10 @@ func (ns *NullString) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON for NullString
 func (ns *NullString) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*ns = NullString{}
+		return nil
+	}
 	err := json.Unmarshal(b, &ns.String)
 	ns.Valid = (err == nil)
 	return err
@@ -200,6 +216,10 @@ func (nt *NullDate) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON for NullDate
 func (nt *NullDate) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*nt = NullDate{}
+		return nil
+	}
 	s := string(b)
 	s = Stripchars(s, "\"")
 
@@ -2,6 +2,7 @@ package rlib
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -117,3 +118,110 @@ func ReceiptSummary(raidlist []int64, d1, d2 *time.Time) ([]ReceiptListEntry, er
 	}
 	return rl, nil
 }
+
+// StatementAging breaks the amount owed on a statement down by the age of
+// the charges it is made of.  Ages are counted back in months from the end
+// of the statement, so monthly charges fall into one bucket each.
+type StatementAging struct {
+	Current float64 // charged in the month before the end of the statement
+	Over30  float64 // charged 1 - 2 months before
+	Over60  float64 // charged 2 - 3 months before
+	Over90  float64 // charged more than 3 months before
+}
+
+// Total returns the amount owed
+func (a *StatementAging) Total() float64 {
+	return a.Current + a.Over30 + a.Over60 + a.Over90
+}
+
+// Add adds the amounts of b to a
+func (a *StatementAging) Add(b *StatementAging) {
+	a.Current += b.Current
+	a.Over30 += b.Over30
+	a.Over60 += b.Over60
+	a.Over90 += b.Over90
+}
+
+// addAged adds amt, charged on dt, to the bucket for its age on asof
+func (a *StatementAging) addAged(amt float64, dt, asof *time.Time) {
+	switch d := DateAtTimeZero(*dt); {
+	case !d.Before(asof.AddDate(0, -1, 0)):
+		a.Current += amt
+	case !d.Before(asof.AddDate(0, -2, 0)):
+		a.Over30 += amt
+	case !d.Before(asof.AddDate(0, -3, 0)):
+		a.Over60 += amt
+	default:
+		a.Over90 += amt
+	}
+}
+
+// GetStatementAging ages the closing balance of rental agreement statement
+// b as of b.DtStop.  Payments are taken to pay the oldest charges first, so
+// the balance is made of the most recent assessments in the statement and in
+// the gap before it.  Whatever is left is aged from the ledger marker the
+// statement starts from.  A credit balance is shown as Current.
+//
+// INPUTS
+//  b - the statement of a rental agreement, as GetRAIDStatementInfo returns it
+//
+// RETURNS
+//  the aging of the closing balance
+//-----------------------------------------------------------------------------
+func GetStatementAging(b *RAAcctBal) StatementAging {
+	var a StatementAging
+	bal := RoundToCent(b.ClosingBal)
+	if bal <= 0 {
+		a.Current = bal
+		return a
+	}
+	var m RAStmtEntries
+	m = append(m, b.Gap...)
+	m = append(m, b.Stmt...)
+	sort.Stable(m)
+	for i := len(m) - 1; i >= 0 && bal > 0; i-- {
+		if m[i].T != 1 || m[i].Reverse || m[i].Amt <= 0 {
+			continue
+		}
+		amt := m[i].Amt
+		if amt > bal {
+			amt = bal
+		}
+		a.addAged(amt, &m[i].Dt, &b.DtStop)
+		bal = RoundToCent(bal - amt)
+	}
+	if bal > 0 {
+		a.addAged(bal, &b.LmStart.Dt, &b.DtStop)
+	}
+	return a
+}
+
+// GetStatementPayors returns the payors of business bid who may need a
+// statement for the period d1 - d2: the payors of every rental agreement
+// active in the period, and everyone who made a receipt in the period.
+// Voided receipts are not counted.
+//
+// RETURNS
+//  the TCIDs of the payors, in ascending order
+//  any error encountered
+//-----------------------------------------------------------------------------
+func GetStatementPayors(bid int64, d1, d2 *time.Time) ([]int64, error) {
+	var m []int64
+	q := "SELECT DISTINCT TCID FROM RentalAgreementPayors WHERE BID=? AND DtStart<? AND ?<DtStop UNION SELECT DISTINCT TCID FROM Receipt WHERE BID=? AND ?<=Dt AND Dt<? AND 0=(FLAGS & 4)"
+	rows, err := RRdb.Dbrr.Query(q, bid, d2, d1, bid, d1, d2)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tcid int64
+		if err = rows.Scan(&tcid); err != nil {
+			return m, err
+		}
+		if tcid > 0 {
+			m = append(m, tcid)
+		}
+	}
+	sort.Slice(m, func(i, j int) bool { return m[i] < m[j] })
+	return m, rows.Err()
+}
@@ -0,0 +1,50 @@
+package rlib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetStatementAging(t *testing.T) {
+	lm := time.Date(2017, time.September, 1, 0, 0, 0, 0, time.UTC)
+	d1 := time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)
+	d2 := d1.AddDate(0, 1, 0)
+	asm := func(dt time.Time, amt float64) RAStmtEntry { return RAStmtEntry{T: 1, Amt: amt, Dt: dt} }
+	rcpt := func(dt time.Time, amt float64) RAStmtEntry { return RAStmtEntry{T: 2, Amt: amt, Dt: dt} }
+	b := RAAcctBal{
+		DtStart: d1,
+		DtStop:  d2,
+		LmStart: LedgerMarker{Dt: lm},
+		Gap: RAStmtEntries{
+			asm(time.Date(2017, time.November, 1, 0, 0, 0, 0, time.UTC), 1000),
+			asm(time.Date(2017, time.December, 1, 0, 0, 0, 0, time.UTC), 1000),
+		},
+		Stmt: RAStmtEntries{
+			asm(time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC), 1000),
+			rcpt(time.Date(2018, time.January, 3, 0, 0, 0, 0, time.UTC), 1000),
+			{T: 1, Amt: 75, Dt: time.Date(2018, time.January, 10, 0, 0, 0, 0, time.UTC), Reverse: true},
+		},
+	}
+
+	m := []struct {
+		descr string
+		bal   float64
+		want  StatementAging
+	}{
+		{"current charge only", 600, StatementAging{Current: 600}},
+		{"two months", 1500, StatementAging{Current: 1000, Over30: 500}},
+		{"three months", 3000, StatementAging{Current: 1000, Over30: 1000, Over60: 1000}},
+		{"balance before the gap", 3250, StatementAging{Current: 1000, Over30: 1000, Over60: 1000, Over90: 250}},
+		{"credit", -40, StatementAging{Current: -40}},
+	}
+	for i := 0; i < len(m); i++ {
+		b.ClosingBal = m[i].bal
+		a := GetStatementAging(&b)
+		if a != m[i].want {
+			t.Errorf("%s: expect %#v, got %#v", m[i].descr, m[i].want, a)
+		}
+		if a.Total() != m[i].bal {
+			t.Errorf("%s: expect total %.2f, got %.2f", m[i].descr, m[i].bal, a.Total())
+		}
+	}
+}
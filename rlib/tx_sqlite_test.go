@@ -0,0 +1,69 @@
+// +build sqlite
+
+package rlib_test
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+	"time"
+)
+
+// An assessment saved within a transaction is journaled and posted by reads
+// and writes on both sides of the transaction, the account rule and GL
+// account reads are made outside of it. None of them may wait on the open
+// transaction.
+func TestInsertAssessmentTxSQLite(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+
+	a := rlib.Assessment{
+		BID:       b.BID,
+		RID:       b.RID[0],
+		RAID:      b.RAID,
+		ARID:      b.ARID["Late Fee"],
+		Amount:    50,
+		Start:     rrtest.Dt(2017, 3, 5),
+		Stop:      rrtest.Dt(2017, 3, 5),
+		RentCycle: rlib.RECURNONE,
+	}
+	d1, d2 := rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- rlib.RunInTx(func(tx *rlib.RRTx) error {
+			if _, err := rlib.InsertAssessmentTx(tx, &a); err != nil {
+				return err
+			}
+			rlib.InitLedgerCache()
+			return rlib.ProcessJournalEntryTx(tx, &a, &b.XBiz, &d1, &d2, true)
+		})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("RunInTx: %s", err.Error())
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatalf("InsertAssessmentTx did not finish, the transaction is waiting on itself")
+	}
+
+	j := rlib.GetJournalByTypeAndID(rlib.JNLTYPEASMT, a.ASMID)
+	if j.JID == 0 {
+		t.Fatalf("no journal entry for assessment %d", a.ASMID)
+	}
+	if j.Amount != 50 {
+		t.Errorf("journal amount: expect 50.00, got %.2f", j.Amount)
+	}
+	rlib.GetJournalAllocations(&j)
+	if len(j.JA) != 1 {
+		t.Fatalf("expect 1 journal allocation, got %d", len(j.JA))
+	}
+	le := rlib.GetLedgerEntriesByJAID(b.BID, j.JA[0].JAID)
+	sum := map[int64]float64{}
+	for i := 0; i < len(le); i++ {
+		sum[le[i].LID] += le[i].Amount
+	}
+	if len(le) != 2 || sum[b.LID["11001"]] != 50 || sum[b.LID["42003"]] != -50 {
+		t.Errorf("expect a 50.00 debit to 11001 and credit to 42003, got %#v", le)
+	}
+}
@@ -16,8 +16,14 @@ func UpdateAR(a *AR) error {
 
 // UpdateAssessment updates an Assessment record
 func UpdateAssessment(a *Assessment) error {
+	return UpdateAssessmentTx(nil, a)
+}
+
+// UpdateAssessmentTx is UpdateAssessment performed within transaction tx. If
+// tx is nil the database is used directly.
+func UpdateAssessmentTx(tx *RRTx, a *Assessment) error {
 	// debug.PrintStack()
-	_, err := RRdb.Prepstmt.UpdateAssessment.Exec(a.PASMID, a.RPASMID, a.AGRCPTID, a.BID, a.RID, a.ATypeLID, a.RAID, a.Amount, a.Start, a.Stop, a.RentCycle, a.ProrationCycle, a.InvoiceNo, a.AcctRule, a.ARID, a.FLAGS, a.Comment, a.LastModBy, a.ASMID)
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateAssessment).Exec(a.PASMID, a.RPASMID, a.AGRCPTID, a.BID, a.RID, a.ATypeLID, a.RAID, a.Amount, a.Start, a.Stop, a.RentCycle, a.ProrationCycle, a.InvoiceNo, a.AcctRule, a.ARID, a.FLAGS, a.Comment, a.LastModBy, a.ASMID)
 	return updateError(err, "Assessment", *a)
 }
 
@@ -27,6 +33,18 @@ func UpdateBusiness(a *Business) error {
 	return updateError(err, "Business", *a)
 }
 
+// UpdateBusinessGroup updates a BusinessGroup record
+func UpdateBusinessGroup(a *BusinessGroup) error {
+	return UpdateBusinessGroupTx(nil, a)
+}
+
+// UpdateBusinessGroupTx is UpdateBusinessGroup performed within transaction
+// tx. If tx is nil the database is used directly.
+func UpdateBusinessGroupTx(tx *RRTx, a *BusinessGroup) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateBusinessGroup).Exec(a.Name, a.GroupType, a.Description, a.LastModBy, a.BGID)
+	return updateError(err, "BusinessGroup", *a)
+}
+
 // UpdateCustomAttribute updates an CustomAttribute record
 func UpdateCustomAttribute(a *CustomAttribute) error {
 	_, err := RRdb.Prepstmt.UpdateCustomAttribute.Exec(a.BID, a.Type, a.Name, a.Value, a.Units, a.LastModBy, a.CID)
@@ -41,7 +59,13 @@ func UpdateDemandSource(a *DemandSource) error {
 
 // UpdateDeposit updates a Deposit record
 func UpdateDeposit(a *Deposit) error {
-	_, err := RRdb.Prepstmt.UpdateDeposit.Exec(a.BID, a.DEPID, a.DPMID, a.Dt, a.Amount, a.ClearedAmount, a.FLAGS, a.LastModBy, a.DID)
+	return UpdateDepositTx(nil, a)
+}
+
+// UpdateDepositTx is UpdateDeposit performed within transaction tx. If tx is
+// nil the database is used directly.
+func UpdateDepositTx(tx *RRTx, a *Deposit) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateDeposit).Exec(a.BID, a.DEPID, a.DPMID, a.Dt, a.Amount, a.ClearedAmount, a.FLAGS, a.LastModBy, a.DID)
 	return updateError(err, "Deposit", *a)
 }
 
@@ -65,10 +89,58 @@ func UpdateDepositPart(a *DepositPart) error {
 
 // UpdateExpense updates a Expense record
 func UpdateExpense(a *Expense) error {
-	_, err := RRdb.Prepstmt.UpdateExpense.Exec(a.RPEXPID, a.BID, a.RID, a.RAID, a.Amount, a.Dt, a.AcctRule, a.ARID, a.FLAGS, a.Comment, a.LastModBy, a.EXPID)
+	return UpdateExpenseTx(nil, a)
+}
+
+// UpdateExpenseTx is UpdateExpense performed within transaction tx. If tx is
+// nil the database is used directly.
+func UpdateExpenseTx(tx *RRTx, a *Expense) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateExpense).Exec(a.RPEXPID, a.BID, a.RID, a.RAID, a.Amount, a.Dt, a.AcctRule, a.ARID, a.FLAGS, a.Comment, a.LastModBy, a.EXPID)
 	return updateError(err, "Expense", *a)
 }
 
+// UpdateVendor updates a Vendor record
+func UpdateVendor(a *Vendor) error {
+	_, err := RRdb.Prepstmt.UpdateVendor.Exec(a.BID, a.Name, a.Address, a.Address2, a.City, a.State, a.PostalCode, a.Country, a.Phone, a.Email, a.TaxID, a.DefaultLID, a.FLAGS, a.LastModBy, a.VENDID)
+	return updateError(err, "Vendor", *a)
+}
+
+// UpdateDirectoryPerson updates a DirectoryPerson record
+func UpdateDirectoryPerson(a *DirectoryPerson) error {
+	_, err := RRdb.Prepstmt.UpdateDirectoryPerson.Exec(a.FirstName, a.LastName, a.PreferredName, a.Email, a.Phone, a.FLAGS, a.LastModBy, a.UID)
+	return updateError(err, "DirectoryPerson", *a)
+}
+
+// UpdateBill updates a Bill record
+func UpdateBill(a *Bill) error {
+	return UpdateBillTx(nil, a)
+}
+
+// UpdateBillTx is UpdateBill performed within transaction tx. If tx is nil the
+// database is used directly.
+func UpdateBillTx(tx *RRTx, a *Bill) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateBill).Exec(a.RPBILLID, a.BID, a.VENDID, a.APLID, a.Dt, a.DtDue, a.Amount, a.DocNo, a.FLAGS, a.Comment, a.LastModBy, a.BILLID)
+	return updateError(err, "Bill", *a)
+}
+
+// UpdateBillItem updates a BillItem record
+func UpdateBillItem(a *BillItem) error {
+	_, err := RRdb.Prepstmt.UpdateBillItem.Exec(a.BILLID, a.BID, a.LID, a.RID, a.Amount, a.Description, a.LastModBy, a.BIID)
+	return updateError(err, "BillItem", *a)
+}
+
+// UpdateBillPayment updates a BillPayment record
+func UpdateBillPayment(a *BillPayment) error {
+	return UpdateBillPaymentTx(nil, a)
+}
+
+// UpdateBillPaymentTx is UpdateBillPayment performed within transaction tx. If
+// tx is nil the database is used directly.
+func UpdateBillPaymentTx(tx *RRTx, a *BillPayment) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateBillPayment).Exec(a.RPBPID, a.BID, a.BILLID, a.VENDID, a.DEPID, a.Dt, a.Amount, a.DocNo, a.FLAGS, a.Comment, a.LastModBy, a.BPID)
+	return updateError(err, "BillPayment", *a)
+}
+
 // UpdateInvoice updates a Invoice record
 func UpdateInvoice(a *Invoice) error {
 	_, err := RRdb.Prepstmt.UpdateInvoice.Exec(a.BID, a.Dt, a.DtDue, a.Amount, a.DeliveredBy, a.LastModBy, a.InvoiceNo)
@@ -89,7 +161,13 @@ func UpdateLedger(a *GLAccount) error {
 
 // UpdateJournalAllocation updates a JournalAllocation record
 func UpdateJournalAllocation(a *JournalAllocation) error {
-	_, err := RRdb.Prepstmt.UpdateJournalAllocation.Exec(a.BID, a.JID, a.RID, a.RAID, a.TCID, a.RCPTID, a.Amount, a.ASMID, a.EXPID, a.AcctRule, a.JAID)
+	return UpdateJournalAllocationTx(nil, a)
+}
+
+// UpdateJournalAllocationTx is UpdateJournalAllocation performed within
+// transaction tx. If tx is nil the database is used directly.
+func UpdateJournalAllocationTx(tx *RRTx, a *JournalAllocation) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateJournalAllocation).Exec(a.BID, a.JID, a.RID, a.RAID, a.TCID, a.RCPTID, a.Amount, a.ASMID, a.EXPID, a.AcctRule, a.JAID)
 	return updateError(err, "JournalAllocation", *a)
 }
 
@@ -122,8 +200,25 @@ func UpdateRentable(a *Rentable) error {
 
 // UpdateRentableStatus updates a RentableStatus record in the database
 func UpdateRentableStatus(a *RentableStatus) error {
-	_, err := RRdb.Prepstmt.UpdateRentableStatus.Exec(a.RID, a.BID, a.DtStart, a.DtStop, a.DtNoticeToVacate, a.UseStatus, a.LeaseStatus, a.LastModBy, a.RSID)
-	return updateError(err, "RentableStatus", *a)
+	return RunInTx(func(tx *RRTx) error {
+		return UpdateRentableStatusTx(tx, a)
+	})
+}
+
+// UpdateRentableStatusTx is UpdateRentableStatus performed within transaction
+// tx. The vacancy of the rentable is marked dirty in tx for the old and the
+// new period. If tx is nil the database is used directly.
+func UpdateRentableStatusTx(tx *RRTx, a *RentableStatus) error {
+	if b, err := GetRentableStatusTx(tx, a.RSID); err == nil {
+		if err = MarkDirtyTx(tx, b.BID, b.RID, &b.DtStart, &b.DtStop); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateRentableStatus).Exec(a.RID, a.BID, a.DtStart, a.DtStop, a.DtNoticeToVacate, a.UseStatus, a.LeaseStatus, a.LastModBy, a.RSID)
+	if err != nil {
+		return updateError(err, "RentableStatus", *a)
+	}
+	return MarkDirtyTx(tx, a.BID, a.RID, &a.DtStart, &a.DtStop)
 }
 
 // UpdateRatePlan updates a RatePlan record in the database
@@ -152,13 +247,25 @@ func UpdateRatePlanRefSPRate(a *RatePlanRefSPRate) error {
 
 // UpdateReceipt updates a Receipt record in the database
 func UpdateReceipt(a *Receipt) error {
-	_, err := RRdb.Prepstmt.UpdateReceipt.Exec(a.PRCPTID, a.BID, a.TCID, a.PMTID, a.DEPID, a.DID, a.RAID, a.Dt, a.DocNo, a.Amount, a.AcctRuleReceive, a.ARID, a.AcctRuleApply, a.FLAGS, a.Comment, a.OtherPayorName, a.LastModBy, a.RCPTID)
+	return UpdateReceiptTx(nil, a)
+}
+
+// UpdateReceiptTx is UpdateReceipt performed within transaction tx. If tx is
+// nil the database is used directly.
+func UpdateReceiptTx(tx *RRTx, a *Receipt) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateReceipt).Exec(a.PRCPTID, a.BID, a.TCID, a.PMTID, a.DEPID, a.DID, a.RAID, a.Dt, a.DocNo, a.Amount, a.AcctRuleReceive, a.ARID, a.AcctRuleApply, a.FLAGS, a.Comment, a.OtherPayorName, a.LastModBy, a.RCPTID)
 	return updateError(err, "Receipt", *a)
 }
 
 // UpdateReceiptAllocation updates a ReceiptAllocation record in the database
 func UpdateReceiptAllocation(a *ReceiptAllocation) error {
-	_, err := RRdb.Prepstmt.UpdateReceiptAllocation.Exec(a.RCPTID, a.BID, a.RAID, a.Dt, a.Amount, a.ASMID, a.FLAGS, a.AcctRule, a.LastModBy, a.RCPAID)
+	return UpdateReceiptAllocationTx(nil, a)
+}
+
+// UpdateReceiptAllocationTx is UpdateReceiptAllocation performed within
+// transaction tx. If tx is nil the database is used directly.
+func UpdateReceiptAllocationTx(tx *RRTx, a *ReceiptAllocation) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateReceiptAllocation).Exec(a.RCPTID, a.BID, a.RAID, a.Dt, a.Amount, a.ASMID, a.FLAGS, a.AcctRule, a.LastModBy, a.RCPAID)
 	return updateError(err, "ReceiptAllocation", *a)
 }
 
@@ -189,20 +296,67 @@ func UpdateRentalAgreementPet(a *RentalAgreementPet) error {
 
 // UpdateRentalAgreementRentable updates a RentalAgreementRentable record in the database
 func UpdateRentalAgreementRentable(a *RentalAgreementRentable) error {
-	_, err := RRdb.Prepstmt.UpdateRentalAgreementRentable.Exec(a.RAID, a.BID, a.RID, a.CLID, a.ContractRent, a.RARDtStart, a.RARDtStop, a.RARID)
-	return updateError(err, "RentalAgreementRentable", *a)
+	return RunInTx(func(tx *RRTx) error {
+		return UpdateRentalAgreementRentableTx(tx, a)
+	})
+}
+
+// UpdateRentalAgreementRentableTx is UpdateRentalAgreementRentable performed
+// within transaction tx. The vacancy of the rentable is marked dirty in tx
+// for the old and the new period. If tx is nil the database is used directly.
+func UpdateRentalAgreementRentableTx(tx *RRTx, a *RentalAgreementRentable) error {
+	if b, err := GetRentalAgreementRentableTx(tx, a.RARID); err == nil {
+		if err = MarkDirtyTx(tx, b.BID, b.RID, &b.RARDtStart, &b.RARDtStop); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateRentalAgreementRentable).Exec(a.RAID, a.BID, a.RID, a.CLID, a.ContractRent, a.RARDtStart, a.RARDtStop, a.RARID)
+	if err != nil {
+		return updateError(err, "RentalAgreementRentable", *a)
+	}
+	return MarkDirtyTx(tx, a.BID, a.RID, &a.RARDtStart, &a.RARDtStop)
 }
 
 // UpdateRentableSpecialtyRef updates a RentableSpecialtyRef record in the database
 func UpdateRentableSpecialtyRef(a *RentableSpecialtyRef) error {
-	_, err := RRdb.Prepstmt.UpdateRentableSpecialtyRef.Exec(a.RSPID, a.LastModBy, a.RID, a.DtStart, a.DtStop)
-	return updateError(err, "RentableSpecialtyRef", *a)
+	return RunInTx(func(tx *RRTx) error {
+		return UpdateRentableSpecialtyRefTx(tx, a)
+	})
+}
+
+// UpdateRentableSpecialtyRefTx is UpdateRentableSpecialtyRef performed within
+// transaction tx. The vacancy of the rentable is marked dirty in tx. If tx is
+// nil the database is used directly.
+func UpdateRentableSpecialtyRefTx(tx *RRTx, a *RentableSpecialtyRef) error {
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateRentableSpecialtyRef).Exec(a.RSPID, a.LastModBy, a.RID, a.DtStart, a.DtStop)
+	if err != nil {
+		return updateError(err, "RentableSpecialtyRef", *a)
+	}
+	return MarkDirtyTx(tx, a.BID, a.RID, &a.DtStart, &a.DtStop)
 }
 
 // UpdateRentableMarketRateInstance updates the given instance of RentableMarketRate
 func UpdateRentableMarketRateInstance(a *RentableMarketRate) error {
-	_, err := RRdb.Prepstmt.UpdateRentableMarketRateInstance.Exec(a.RTID, a.BID, a.MarketRate, a.DtStart, a.DtStop, a.RMRID)
-	return updateError(err, "RentableMarketRate", *a)
+	return RunInTx(func(tx *RRTx) error {
+		return UpdateRentableMarketRateInstanceTx(tx, a)
+	})
+}
+
+// UpdateRentableMarketRateInstanceTx is UpdateRentableMarketRateInstance
+// performed within transaction tx. The vacancy of every rentable of the
+// RentableType is marked dirty in tx for the old and the new period of the
+// market rate. If tx is nil the database is used directly.
+func UpdateRentableMarketRateInstanceTx(tx *RRTx, a *RentableMarketRate) error {
+	if b, err := GetRentableMarketRateInstanceTx(tx, a.RMRID); err == nil {
+		if err = MarkRentableTypeDirtyTx(tx, b.BID, b.RTID, &b.DtStart, &b.DtStop); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateRentableMarketRateInstance).Exec(a.RTID, a.BID, a.MarketRate, a.DtStart, a.DtStop, a.RMRID)
+	if err != nil {
+		return updateError(err, "RentableMarketRate", *a)
+	}
+	return MarkRentableTypeDirtyTx(tx, a.BID, a.RTID, &a.DtStart, &a.DtStop)
 }
 
 // UpdateRentableType updates a RentableType record in the database
@@ -219,9 +373,26 @@ func ReactivateRentableType(a *RentableType) error {
 
 // UpdateRentableTypeRef updates a RentableTypeRef record in the database
 func UpdateRentableTypeRef(a *RentableTypeRef) error {
+	return RunInTx(func(tx *RRTx) error {
+		return UpdateRentableTypeRefTx(tx, a)
+	})
+}
+
+// UpdateRentableTypeRefTx is UpdateRentableTypeRef performed within
+// transaction tx. The vacancy of the rentable is marked dirty in tx for the
+// old and the new period. If tx is nil the database is used directly.
+func UpdateRentableTypeRefTx(tx *RRTx, a *RentableTypeRef) error {
 	//  SET BID=?,RTID=?,OverrideRentCycle=?,OverrideProrationCycle=?,LastModBy=? WHERE RID=? and DtStart=? and DtStop=?"
-	_, err := RRdb.Prepstmt.UpdateRentableTypeRef.Exec(a.RID, a.BID, a.RTID, a.OverrideRentCycle, a.OverrideProrationCycle, a.DtStart, a.DtStop, a.LastModBy, a.RTRID)
-	return updateError(err, "RentableTypeRef", *a)
+	if b, err := GetRentableTypeRefTx(tx, a.RTRID); err == nil {
+		if err = MarkDirtyTx(tx, b.BID, b.RID, &b.DtStart, &b.DtStop); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Stmt(RRdb.Prepstmt.UpdateRentableTypeRef).Exec(a.RID, a.BID, a.RTID, a.OverrideRentCycle, a.OverrideProrationCycle, a.DtStart, a.DtStop, a.LastModBy, a.RTRID)
+	if err != nil {
+		return updateError(err, "RentableTypeRef", *a)
+	}
+	return MarkDirtyTx(tx, a.BID, a.RID, &a.DtStart, &a.DtStop)
 }
 
 // UpdateRentableUser updates a RentableUser record in the database
@@ -275,3 +446,22 @@ func UpdateVehicle(a *Vehicle) error {
 	_, err := RRdb.Prepstmt.UpdateVehicle.Exec(a.TCID, a.BID, a.VehicleType, a.VehicleMake, a.VehicleModel, a.VehicleColor, a.VehicleYear, a.LicensePlateState, a.LicensePlateNumber, a.ParkingPermitNumber, a.DtStart, a.DtStop, a.LastModBy, a.VID)
 	return updateError(err, "Vehicle", *a)
 }
+
+// UpdateWebhook updates a Webhook record
+func UpdateWebhook(a *Webhook) error {
+	_, err := RRdb.Prepstmt.UpdateWebhook.Exec(a.BID, a.URL, a.Secret, a.EventTypes, a.FLAGS, a.LastModBy, a.WHID)
+	ClearWebhookCache()
+	return updateError(err, "Webhook", *a)
+}
+
+// UpdateWebhookDelivery updates a WebhookDelivery record
+func UpdateWebhookDelivery(a *WebhookDelivery) error {
+	_, err := RRdb.Prepstmt.UpdateWebhookDelivery.Exec(a.WHID, a.EVID, a.BID, a.Status, a.Attempts, a.NextAttempt, a.LastAttempt, a.HTTPStatus, a.LastError, a.WHDID)
+	return updateError(err, "WebhookDelivery", *a)
+}
+
+// UpdateReportSchedule updates a ReportSchedule record
+func UpdateReportSchedule(a *ReportSchedule) error {
+	_, err := RRdb.Prepstmt.UpdateReportSchedule.Exec(a.BID, a.Name, a.Report, a.Params, a.DateRule, a.OutputFormat, a.Cycle, a.NextRun, a.LastRun, a.LastError, a.Recipients, a.Directory, a.FLAGS, a.LastModBy, a.RSID)
+	return updateError(err, "ReportSchedule", *a)
+}
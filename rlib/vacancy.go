@@ -40,6 +40,15 @@ func temporaryGetLTLAR(bid int64) string {
 // The return value is the number of vacancy records added
 //============================================================================================
 func ProcessRentable(xbiz *XBusiness, d1, d2 *time.Time, r *Rentable) int {
+	nr, err := ProcessRentableTx(nil, xbiz, d1, d2, r)
+	Errlog(err)
+	return nr
+}
+
+// ProcessRentableTx is ProcessRentable performed within transaction tx. If tx
+// is nil the database is used directly. It stops at the first error and
+// returns it along with the number of vacancy records added.
+func ProcessRentableTx(tx *RRTx, xbiz *XBusiness, d1, d2 *time.Time, r *Rentable) (int, error) {
 	nr := 0
 	m := VacancyDetect(xbiz, d1, d2, r.RID)
 	// fmt.Printf("ProcessRentable: r = %s (%d), period=(%s - %s) len(m) = %d\n", r.Name, r.RID, d1.Format("Jan 2"), d2.Format("Jan 2"), len(m))
@@ -63,13 +72,15 @@ func ProcessRentable(xbiz *XBusiness, d1, d2 *time.Time, r *Rentable) int {
 		//       By convention, the period for Vacancy detection is:  supplied range, date/time of journal entry =
 		//       rentcycle - 1 prorationcycle (or one second whichever is larger)
 		// These entries must be idempotent. Make sure it does not already exist.
-		jv := GetJournalVacancy(r.RID, &j.Dt, &m[i].DtStop)
+		jv := GetJournalVacancyTx(tx, r.RID, &j.Dt, &m[i].DtStop)
 		if jv.JID != 0 { // if the JID >0 ..
 			continue // then this entry was already generated, keep going
 		}
 
-		jid, err := InsertJournal(&j)
-		Errlog(err)
+		jid, err := InsertJournalTx(tx, &j)
+		if err != nil {
+			return nr, err
+		}
 		nr++
 		if jid > 0 {
 			var ja JournalAllocation
@@ -81,13 +92,17 @@ func ProcessRentable(xbiz *XBusiness, d1, d2 *time.Time, r *Rentable) int {
 			ja.RID = r.RID
 			ja.BID = r.BID
 			// fmt.Printf("VACANCY: inserting journalAllocation entry: %#v\n", ja)
-			InsertJournalAllocationEntry(&ja)
+			if err = InsertJournalAllocationEntryTx(tx, &ja); err != nil {
+				return nr, err
+			}
 			j.JA = append(j.JA, ja)
 		}
 		InitLedgerCache()
-		GenerateLedgerEntriesFromJournal(xbiz, &j, d1, d2)
+		if _, err = GenerateLedgerEntriesFromJournalTx(tx, xbiz, &j, d1, d2); err != nil {
+			return nr, err
+		}
 	}
-	return nr
+	return nr, nil
 }
 
 // GenVacancyJournals creates Journal entries that cover vacancy for
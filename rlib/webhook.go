@@ -0,0 +1,255 @@
+package rlib
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Webhook delivery settings
+const (
+	WebhookMaxAttempts  = 10               // a delivery is marked failed after this many attempts
+	WebhookRetryBase    = time.Minute      // wait after the first failure, doubled after each additional failure
+	WebhookRetryMax     = 12 * time.Hour   // longest wait between attempts
+	WebhookBatchSize    = 100              // max deliveries attempted per call to DeliverWebhooks
+	WebhookMaxErrLength = 256              // LastError is truncated to this length
+	webhookTimeout      = 15 * time.Second // http timeout for a single delivery
+)
+
+// WebhookClient is the http client used to deliver events. It can be
+// replaced for testing.
+var WebhookClient = &http.Client{Timeout: webhookTimeout}
+
+// WebhookEnvelope is the json body POSTed to a webhook url
+type WebhookEnvelope struct {
+	ID      int64           `json:"id"`
+	Type    string          `json:"type"`
+	BID     int64           `json:"bid"`
+	Created time.Time       `json:"created"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// webhookMatches returns true if the webhook is subscribed to events of type
+// etype.  The EventTypes list is comma separated. An empty list or "*"
+// matches every event, "receipt.*" matches every receipt event.
+func webhookMatches(wh *Webhook, etype string) bool {
+	if len(strings.TrimSpace(wh.EventTypes)) == 0 {
+		return true
+	}
+	sa := strings.Split(wh.EventTypes, ",")
+	for i := 0; i < len(sa); i++ {
+		s := strings.TrimSpace(sa[i])
+		switch {
+		case s == "*" || s == etype:
+			return true
+		case strings.HasSuffix(s, ".*") && strings.HasPrefix(etype, s[:len(s)-1]):
+			return true
+		}
+	}
+	return false
+}
+
+// EmitEvent records an event in the outbox and queues a delivery for each
+// active webhook of business bid that subscribes to etype. Nothing is written
+// if no webhook is interested.  The webhooks come from the webhook cache, so
+// a business without webhooks costs no query.  Errors are logged, they never
+// fail the caller's operation, use EmitEventTx to make the event part of it.
+//
+// INPUTS
+//    bid   = the business
+//    etype = the event type, for example receipt.created
+//    objid = id of the object the event describes
+//    obj   = the object, its json representation is the event payload
+//    uid   = who caused the event
+//-----------------------------------------------------------------------------
+func EmitEvent(bid int64, etype string, objid int64, obj interface{}, uid int64) {
+	if err := EmitEventTx(nil, bid, etype, objid, obj, uid); err != nil {
+		Ulog("EmitEvent: %s\n", err.Error())
+	}
+}
+
+// EmitEventTx is EmitEvent performed within transaction tx. It returns the
+// first error it encounters, so that the caller can roll back the write the
+// event describes rather than commit it without its event. If tx is nil the
+// database is used directly.
+func EmitEventTx(tx *RRTx, bid int64, etype string, objid int64, obj interface{}, uid int64) error {
+	funcname := "EmitEvent"
+	m, err := GetCachedActiveWebhooks(bid)
+	if err != nil {
+		return fmt.Errorf("%s: error getting webhooks for BID = %d: %s", funcname, bid, err.Error())
+	}
+	var hooks []Webhook
+	for i := 0; i < len(m); i++ {
+		if webhookMatches(&m[i], etype) {
+			hooks = append(hooks, m[i])
+		}
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("%s: error marshaling %s payload: %s", funcname, etype, err.Error())
+	}
+	ev := OutboxEvent{BID: bid, EventType: etype, ObjID: objid, Payload: string(b), CreateBy: uid}
+	if _, err = InsertOutboxEventTx(tx, &ev); err != nil {
+		return fmt.Errorf("%s: %s", funcname, err.Error())
+	}
+	now := time.Now()
+	for i := 0; i < len(hooks); i++ {
+		d := WebhookDelivery{WHID: hooks[i].WHID, EVID: ev.EVID, BID: bid, Status: WHDPENDING, NextAttempt: now}
+		if _, err = InsertWebhookDeliveryTx(tx, &d); err != nil {
+			return fmt.Errorf("%s: %s", funcname, err.Error())
+		}
+	}
+	return nil
+}
+
+// emitInsertEvent emits the event for a newly inserted object. The event type
+// is name.created, or name.reversed if rpid (the reversal parent) is set.
+// Within a transaction an error is returned so that the insert is rolled
+// back with it. Without one the object is already saved, so the error is
+// only logged.
+func emitInsertEvent(tx *RRTx, bid int64, name string, rpid, id int64, obj interface{}, uid int64) error {
+	etype := name + ".created"
+	if rpid > 0 {
+		etype = name + ".reversed"
+	}
+	err := EmitEventTx(tx, bid, etype, id, obj, uid)
+	if err != nil && tx == nil {
+		Ulog("%s\n", err.Error())
+		return nil
+	}
+	return err
+}
+
+// WebhookSignature returns the value of the X-RentRoll-Signature header for
+// body. It is the hex encoded HMAC-SHA256 of the body keyed with the
+// webhook's secret, prefixed with "sha256=".
+func WebhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookBackoff returns how long to wait before the next attempt after the
+// supplied number of failed attempts
+func WebhookBackoff(attempts int64) time.Duration {
+	d := WebhookRetryBase
+	for i := int64(1); i < attempts; i++ {
+		d *= 2
+		if d >= WebhookRetryMax {
+			return WebhookRetryMax
+		}
+	}
+	return d
+}
+
+// PostWebhook POSTs event ev to webhook wh.
+//
+// RETURNS
+//    the http status code, 0 if no response was received
+//    an error if the event was not accepted
+//-----------------------------------------------------------------------------
+func PostWebhook(wh *Webhook, ev *OutboxEvent, whdid int64) (int, error) {
+	env := WebhookEnvelope{ID: ev.EVID, Type: ev.EventType, BID: ev.BID, Created: ev.CreateTS, Data: json.RawMessage(ev.Payload)}
+	body, err := json.Marshal(&env)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest("POST", wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-RentRoll-Event", ev.EventType)
+	req.Header.Set("X-RentRoll-Delivery", fmt.Sprintf("%d", whdid))
+	req.Header.Set("X-RentRoll-Signature", WebhookSignature(wh.Secret, body))
+
+	resp, err := WebhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// DeliverWebhooks attempts every pending delivery that is due at or before
+// now, up to max of them.  Failed deliveries are rescheduled with exponential
+// backoff and are marked failed after WebhookMaxAttempts attempts.  Deliveries
+// to a webhook that was deactivated are marked failed.
+//
+// RETURNS
+//    the number of deliveries that succeeded
+//    any database error encountered
+//-----------------------------------------------------------------------------
+func DeliverWebhooks(now time.Time, max int) (int, error) {
+	funcname := "DeliverWebhooks"
+	m, err := GetPendingWebhookDeliveries(&now, max)
+	if err != nil {
+		return 0, err
+	}
+	hooks := map[int64]Webhook{}
+	n := 0
+	for i := 0; i < len(m); i++ {
+		d := &m[i]
+		wh, ok := hooks[d.WHID]
+		if !ok {
+			if wh, err = GetWebhook(d.WHID); err != nil {
+				Ulog("%s: error reading webhook %d: %s\n", funcname, d.WHID, err.Error())
+				continue
+			}
+			hooks[d.WHID] = wh
+		}
+		if wh.FLAGS&WEBHOOKINACTIVE != 0 {
+			d.Status = WHDFAILED
+			d.LastError = "webhook is inactive"
+			if err = UpdateWebhookDelivery(d); err != nil {
+				return n, err
+			}
+			continue
+		}
+		ev, err := GetOutboxEvent(d.EVID)
+		if err != nil {
+			Ulog("%s: error reading event %d: %s\n", funcname, d.EVID, err.Error())
+			continue
+		}
+
+		status, err := PostWebhook(&wh, &ev, d.WHDID)
+		d.Attempts++
+		d.LastAttempt = now
+		d.HTTPStatus = int64(status)
+		if err == nil {
+			d.Status = WHDDELIVERED
+			d.LastError = ""
+			n++
+		} else {
+			d.LastError = err.Error()
+			if len(d.LastError) > WebhookMaxErrLength {
+				d.LastError = d.LastError[:WebhookMaxErrLength]
+			}
+			if d.Attempts >= WebhookMaxAttempts {
+				d.Status = WHDFAILED
+			} else {
+				d.NextAttempt = now.Add(WebhookBackoff(d.Attempts))
+			}
+		}
+		if err = UpdateWebhookDelivery(d); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,249 @@
+// +build sqlite
+
+package rlib_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver is a webhook url. It answers with the status codes in
+// replies, one per request, then with 200.
+type webhookReceiver struct {
+	sync.Mutex
+	replies []int
+	body    [][]byte
+	header  []http.Header
+}
+
+func (a *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.Lock()
+	defer a.Unlock()
+	b, _ := ioutil.ReadAll(r.Body)
+	a.body = append(a.body, b)
+	a.header = append(a.header, r.Header)
+	status := http.StatusOK
+	if len(a.replies) > 0 {
+		status, a.replies = a.replies[0], a.replies[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (a *webhookReceiver) count() int {
+	a.Lock()
+	defer a.Unlock()
+	return len(a.body)
+}
+
+// newWebhook starts a receiver and adds a webhook for it to business bid.
+// The receiver is stopped when the test ends.
+func newWebhook(t *testing.T, bid int64, replies ...int) (*webhookReceiver, rlib.Webhook) {
+	rcv := &webhookReceiver{replies: replies}
+	srv := httptest.NewServer(rcv)
+	t.Cleanup(srv.Close)
+	wh := rlib.Webhook{BID: bid, URL: srv.URL + "/hook", Secret: "s3cret", EventTypes: "vendor.*"}
+	if _, err := rlib.InsertWebhook(&wh); err != nil {
+		t.Fatalf("InsertWebhook: %s", err.Error())
+	}
+	return rcv, wh
+}
+
+// webhookDelivery returns the only delivery made for webhook wh
+func webhookDelivery(t *testing.T, wh *rlib.Webhook) rlib.WebhookDelivery {
+	m, err := rlib.GetWebhookDeliveries(wh.WHID, 10)
+	if err != nil || len(m) != 1 {
+		t.Fatalf("expect 1 delivery for webhook %d, got %d (%v)", wh.WHID, len(m), err)
+	}
+	return m[0]
+}
+
+func TestDeliverWebhooks(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	rcv, wh := newWebhook(t, b.BID, http.StatusInternalServerError)
+
+	rlib.EmitEvent(b.BID, "receipt.created", 3, map[string]string{"Name": "ignored"}, 0)
+	rlib.EmitEvent(b.BID, "vendor.created", 7, map[string]string{"Name": "Acme Plumbing"}, 0)
+
+	// the first attempt fails and is retried after WebhookRetryBase
+	now := time.Now().UTC().Truncate(time.Second).Add(time.Second) // after the events were emitted
+	if n, err := rlib.DeliverWebhooks(now, 10); n != 0 || err != nil {
+		t.Fatalf("first attempt: expect 0 delivered, got %d (%v)", n, err)
+	}
+	d := webhookDelivery(t, &wh)
+	if d.Status != rlib.WHDPENDING || d.Attempts != 1 || d.HTTPStatus != http.StatusInternalServerError || len(d.LastError) == 0 {
+		t.Errorf("after a failed attempt: expect pending, 1 attempt, status 500 and an error, got %#v", d)
+	}
+	if !d.NextAttempt.Equal(now.Add(rlib.WebhookRetryBase)) {
+		t.Errorf("expect the next attempt at %s, got %s", now.Add(rlib.WebhookRetryBase), d.NextAttempt)
+	}
+	if n, _ := rlib.DeliverWebhooks(now.Add(time.Second), 10); n != 0 || rcv.count() != 1 {
+		t.Errorf("a delivery must not be retried before it is due, got %d requests", rcv.count())
+	}
+
+	if n, err := rlib.DeliverWebhooks(now.Add(rlib.WebhookRetryBase), 10); n != 1 || err != nil {
+		t.Fatalf("retry: expect 1 delivered, got %d (%v)", n, err)
+	}
+	d = webhookDelivery(t, &wh)
+	if d.Status != rlib.WHDDELIVERED || d.Attempts != 2 || d.HTTPStatus != http.StatusOK || len(d.LastError) != 0 {
+		t.Errorf("after the retry: expect delivered on the 2nd attempt, got %#v", d)
+	}
+	if rcv.count() != 2 {
+		t.Fatalf("expect 2 requests, got %d", rcv.count())
+	}
+
+	body, h := rcv.body[1], rcv.header[1]
+	var env rlib.WebhookEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		t.Fatalf("cannot unmarshal the body %s: %s", body, err.Error())
+	}
+	if env.ID != d.EVID || env.Type != "vendor.created" || env.BID != b.BID || string(env.Data) != `{"Name":"Acme Plumbing"}` {
+		t.Errorf("bad envelope: %s", body)
+	}
+	mac := hmac.New(sha256.New, []byte(wh.Secret))
+	mac.Write(body)
+	if sig := "sha256=" + hex.EncodeToString(mac.Sum(nil)); h.Get("X-RentRoll-Signature") != sig {
+		t.Errorf("expect signature %s, got %s", sig, h.Get("X-RentRoll-Signature"))
+	}
+	if h.Get("X-RentRoll-Event") != "vendor.created" || h.Get("Content-Type") != "application/json" {
+		t.Errorf("bad headers: %v", h)
+	}
+	if n, _ := rlib.DeliverWebhooks(now.Add(time.Hour), 10); n != 0 || rcv.count() != 2 {
+		t.Errorf("a delivered event must not be sent again, got %d requests", rcv.count())
+	}
+}
+
+func TestDeliverWebhooksGivesUp(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	var replies []int
+	for i := 0; i <= rlib.WebhookMaxAttempts; i++ {
+		replies = append(replies, http.StatusServiceUnavailable)
+	}
+	rcv, wh := newWebhook(t, b.BID, replies...)
+	rlib.EmitEvent(b.BID, "vendor.created", 7, map[string]string{"Name": "Acme Plumbing"}, 0)
+
+	now := time.Now().UTC().Truncate(time.Second).Add(time.Second) // after the events were emitted
+	for i := 0; i < rlib.WebhookMaxAttempts+2; i++ {
+		rlib.DeliverWebhooks(now, 10)
+		now = now.Add(rlib.WebhookRetryMax)
+	}
+	d := webhookDelivery(t, &wh)
+	if d.Status != rlib.WHDFAILED || d.Attempts != rlib.WebhookMaxAttempts || rcv.count() != rlib.WebhookMaxAttempts {
+		t.Errorf("expect the delivery to fail after %d attempts, got status %d after %d attempts and %d requests", rlib.WebhookMaxAttempts, d.Status, d.Attempts, rcv.count())
+	}
+
+	// deliveries to an inactive webhook fail without a request
+	_, wh2 := newWebhook(t, b.BID)
+	rlib.EmitEvent(b.BID, "vendor.created", 8, map[string]string{"Name": "Bob's Paint"}, 0)
+	wh2.FLAGS |= rlib.WEBHOOKINACTIVE
+	if err := rlib.UpdateWebhook(&wh2); err != nil {
+		t.Fatalf("UpdateWebhook: %s", err.Error())
+	}
+	rlib.DeliverWebhooks(now, 10)
+	if d = webhookDelivery(t, &wh2); d.Status != rlib.WHDFAILED || d.Attempts != 0 {
+		t.Errorf("inactive webhook: expect failed with no attempts, got %#v", d)
+	}
+}
+
+// The active webhooks of a business are cached. Saving or deleting a
+// webhook clears the cache, so the next event sees the change.
+func TestWebhookCache(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	rlib.EmitEvent(b.BID, "vendor.created", 7, map[string]string{"Name": "Acme Plumbing"}, 0) // caches no webhooks
+
+	_, wh := newWebhook(t, b.BID)
+	rlib.EmitEvent(b.BID, "vendor.created", 8, map[string]string{"Name": "Bob's Paint"}, 0)
+	webhookDelivery(t, &wh)
+
+	wh.EventTypes = "receipt.*"
+	if err := rlib.UpdateWebhook(&wh); err != nil {
+		t.Fatalf("UpdateWebhook: %s", err.Error())
+	}
+	rlib.EmitEvent(b.BID, "vendor.created", 9, map[string]string{"Name": "Carl's Carpets"}, 0)
+	webhookDelivery(t, &wh)
+
+	if err := rlib.DeleteWebhook(wh.WHID); err != nil {
+		t.Fatalf("DeleteWebhook: %s", err.Error())
+	}
+	if m, err := rlib.GetCachedActiveWebhooks(b.BID); err != nil || len(m) != 0 {
+		t.Errorf("after the delete: expect no webhooks, got %d (%v)", len(m), err)
+	}
+}
+
+// The event for a rental agreement inserted within a transaction is
+// written in the transaction, it is rolled back along with the insert
+func TestEmitEventTx(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	wh := rlib.Webhook{BID: b.BID, URL: "http://localhost/hook", EventTypes: "rentalagreement.*"}
+	if _, err := rlib.InsertWebhook(&wh); err != nil {
+		t.Fatalf("InsertWebhook: %s", err.Error())
+	}
+
+	errRollback := errors.New("roll back")
+	for _, x := range []error{errRollback, nil} {
+		err := rlib.RunInTx(func(tx *rlib.RRTx) error {
+			ra := rlib.RentalAgreement{BID: b.BID, AgreementStart: rrtest.BizStart, AgreementStop: rrtest.RAStop, PossessionStart: rrtest.BizStart, PossessionStop: rrtest.RAStop, RentStart: rrtest.BizStart, RentStop: rrtest.RAStop, RentCycleEpoch: rrtest.BizStart}
+			if _, err := rlib.InsertRentalAgreementTx(tx, &ra); err != nil {
+				return err
+			}
+			return x
+		})
+		if err != x {
+			t.Fatalf("RunInTx: expect %v, got %v", x, err)
+		}
+	}
+	m, err := rlib.GetWebhookDeliveries(wh.WHID, 10)
+	if err != nil || len(m) != 1 {
+		t.Fatalf("expect 1 delivery, for the committed rental agreement, got %d (%v)", len(m), err)
+	}
+	ev, err := rlib.GetOutboxEvent(m[0].EVID)
+	if err != nil || ev.EventType != "rentalagreement.created" {
+		t.Errorf("expect a rentalagreement.created event, got %q (%v)", ev.EventType, err)
+	}
+}
+
+// A failure to write the event of an insert made within a transaction fails
+// the insert, so the transaction is rolled back rather than committed
+// without its event
+func TestEmitEventTxFails(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	wh := rlib.Webhook{BID: b.BID, URL: "http://localhost/hook", EventTypes: "rentalagreement.*"}
+	if _, err := rlib.InsertWebhook(&wh); err != nil {
+		t.Fatalf("InsertWebhook: %s", err.Error())
+	}
+	if _, err := rlib.RRdb.Dbrr.Exec("CREATE TRIGGER FailDelivery BEFORE INSERT ON WebhookDelivery BEGIN SELECT RAISE(ABORT, 'forced failure'); END"); err != nil {
+		t.Fatalf("cannot create trigger: %s", err.Error())
+	}
+	var raid int64
+	err := rlib.RunInTx(func(tx *rlib.RRTx) error {
+		ra := rlib.RentalAgreement{BID: b.BID, AgreementStart: rrtest.BizStart, AgreementStop: rrtest.RAStop, PossessionStart: rrtest.BizStart, PossessionStop: rrtest.RAStop, RentStart: rrtest.BizStart, RentStop: rrtest.RAStop, RentCycleEpoch: rrtest.BizStart}
+		var err error
+		raid, err = rlib.InsertRentalAgreementTx(tx, &ra)
+		return err
+	})
+	if err == nil {
+		t.Fatalf("expect the insert to fail with its event")
+	}
+	if ra, _ := rlib.GetRentalAgreement(raid); raid != 0 && ra.RAID != 0 {
+		t.Errorf("expect rental agreement %d to be rolled back", raid)
+	}
+	var n int
+	if err = rlib.RRdb.Dbrr.QueryRow("SELECT COUNT(*) FROM OutboxEvent WHERE BID=?", b.BID).Scan(&n); err != nil || n != 0 {
+		t.Errorf("expect no outbox event, got %d (%v)", n, err)
+	}
+}
@@ -0,0 +1,70 @@
+package rlib
+
+import (
+	"sync"
+	"time"
+)
+
+// WebhookCacheEntry is the data type for webhook cache entries. It holds
+// the active webhooks of a business, m is empty if it has none.
+type WebhookCacheEntry struct {
+	bid    int64
+	m      []Webhook
+	expire *time.Time
+}
+
+// WebhookCacheExpiry is how long the active webhooks of a business are
+// cached. The cache is cleared whenever this process saves or deletes a
+// webhook, the expiry limits how long a change made by another process
+// goes unseen.
+var WebhookCacheExpiry = time.Duration(time.Minute * 5)
+
+var (
+	webhookcache   = map[int64]*WebhookCacheEntry{} // initialize an empty cache
+	webhookcacheMu sync.Mutex                       // guards webhookcache
+)
+
+// GetCachedActiveWebhooks returns the active webhooks of business bid. They
+// are read from the database the first time they are needed and then
+// served from the cache, so emitting an event does not cost a query per
+// write.
+//
+// INPUTS
+//  bid  - biz id
+//
+// RETURNS
+//  the active webhooks of bid
+//  any error encountered reading them
+//-----------------------------------------------------------------------------
+func GetCachedActiveWebhooks(bid int64) ([]Webhook, error) {
+	now := time.Now()
+	webhookcacheMu.Lock()
+	b, ok := webhookcache[bid]
+	webhookcacheMu.Unlock()
+	if ok && b != nil && now.Before(*b.expire) {
+		return b.m, nil
+	}
+
+	m, err := GetActiveWebhooks(bid)
+	if err != nil {
+		return m, err
+	}
+	t := now.Add(WebhookCacheExpiry)
+	webhookcacheMu.Lock()
+	webhookcache[bid] = &WebhookCacheEntry{bid: bid, m: m, expire: &t}
+	webhookcacheMu.Unlock()
+	return m, nil
+}
+
+// ClearWebhookCache removes all entries from the webhook cache. It is
+// called whenever a webhook is saved or deleted. An update can move a
+// webhook to another business, so every business is cleared.
+//
+// RETURNS
+//  nothing
+//-----------------------------------------------------------------------------
+func ClearWebhookCache() {
+	webhookcacheMu.Lock()
+	webhookcache = map[int64]*WebhookCacheEntry{}
+	webhookcacheMu.Unlock()
+}
@@ -0,0 +1,198 @@
+package rrpt
+
+import (
+	"fmt"
+	"gotable"
+	"rentroll/rlib"
+	"time"
+)
+
+// Vendor1099Threshold is the minimum total paid to a vendor in a calendar
+// year that requires a 1099 to be filed
+const Vendor1099Threshold = float64(600)
+
+// APAgingReportTable generates a table of the unpaid vendor bills as of ri.D2.
+// The unpaid balance of each bill is placed in a column based on how many
+// days past due it is.
+func APAgingReportTable(ri *ReporterInfo) gotable.Table {
+	funcname := "APAgingReportTable"
+
+	ri.RptHeaderD1 = false
+	ri.RptHeaderD2 = true
+
+	tbl := getRRTable()
+	tbl.AddColumn("Vendor", 30, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)    // vendor name
+	tbl.AddColumn("Bill", 12, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)      // bill id
+	tbl.AddColumn("Doc No", 15, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)    // vendor's invoice number
+	tbl.AddColumn("Bill Date", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)   // date of the bill
+	tbl.AddColumn("Due Date", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)    // when payment is due
+	tbl.AddColumn("Current", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)   // not yet due
+	tbl.AddColumn("1 - 30", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)    // 1 to 30 days past due
+	tbl.AddColumn("31 - 60", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)   // 31 to 60 days past due
+	tbl.AddColumn("61 - 90", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)   // 61 to 90 days past due
+	tbl.AddColumn("Over 90", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)   // more than 90 days past due
+	tbl.AddColumn("Total Due", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT) // unpaid balance of the bill
+
+	const (
+		VendorName = 0
+		BillID     = iota
+		DocNo      = iota
+		BillDt     = iota
+		DueDt      = iota
+		Current    = iota
+		Due30      = iota
+		Due60      = iota
+		Due90      = iota
+		DueOver90  = iota
+		TotalDue   = iota
+	)
+
+	err := TableReportHeaderBlock(&tbl, "Accounts Payable Aging", funcname, ri)
+	if err != nil {
+		rlib.LogAndPrintError(funcname, err)
+		return tbl
+	}
+
+	m, err := rlib.GetBillsThroughDate(ri.Xbiz.P.BID, &ri.D2)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+
+	vendors := map[int64]string{}
+	totalErrs := 0
+	for i := 0; i < len(m); i++ {
+		var bal float64
+		p, err := rlib.GetBillPaymentsThroughDate(m[i].BILLID, &ri.D2)
+		if err != nil {
+			totalErrs++
+			rlib.LogAndPrintError(funcname, err)
+			continue
+		}
+		bal = m[i].Amount
+		for j := 0; j < len(p); j++ {
+			bal -= p[j].Amount
+		}
+		if rlib.RoundToCent(bal) == 0 {
+			continue
+		}
+		if _, ok := vendors[m[i].VENDID]; !ok {
+			v, _ := rlib.GetVendor(m[i].VENDID)
+			vendors[m[i].VENDID] = v.Name
+		}
+
+		col := Current
+		days := int(ri.D2.Sub(m[i].DtDue).Hours() / 24)
+		switch {
+		case days <= 0:
+			col = Current
+		case days <= 30:
+			col = Due30
+		case days <= 60:
+			col = Due60
+		case days <= 90:
+			col = Due90
+		default:
+			col = DueOver90
+		}
+
+		tbl.AddRow()
+		tbl.Puts(-1, VendorName, vendors[m[i].VENDID])
+		tbl.Puts(-1, BillID, m[i].IDtoShortString())
+		tbl.Puts(-1, DocNo, m[i].DocNo)
+		tbl.Putd(-1, BillDt, m[i].Dt)
+		tbl.Putd(-1, DueDt, m[i].DtDue)
+		for k := Current; k <= DueOver90; k++ {
+			tbl.Putf(-1, k, float64(0))
+		}
+		tbl.Putf(-1, col, bal)
+		tbl.Putf(-1, TotalDue, bal)
+	}
+
+	if len(tbl.Row) == 0 {
+		tbl.SetSection3(NoRecordsFoundMsg)
+		return tbl
+	}
+	tbl.Sort(0, len(tbl.Row)-1, VendorName)
+	tbl.AddLineAfter(len(tbl.Row) - 1)
+	tbl.InsertSumRow(len(tbl.Row), 0, len(tbl.Row)-1, []int{Current, Due30, Due60, Due90, DueOver90, TotalDue})
+	if totalErrs > 0 {
+		tbl.SetSection3(fmt.Sprintf("Encountered %d errors while creating this report. See log.", totalErrs))
+	}
+	return tbl
+}
+
+// APAgingReport returns a string version of the AP Aging report
+func APAgingReport(ri *ReporterInfo) string {
+	tbl := APAgingReportTable(ri)
+	return ReportToString(&tbl, ri)
+}
+
+// Vendor1099ReportTable generates a table of the total amount paid to each
+// 1099 vendor during the calendar year containing the last day of the report
+// range. ri.D2 is the day after the range, so -k 2018-01-01 reports 2017.
+// Vendors paid at least Vendor1099Threshold are marked as requiring a 1099.
+func Vendor1099ReportTable(ri *ReporterInfo) gotable.Table {
+	funcname := "Vendor1099ReportTable"
+
+	ri.D1 = time.Date(ri.D2.AddDate(0, 0, -1).Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	ri.D2 = ri.D1.AddDate(1, 0, 0)
+	ri.RptHeaderD1 = true
+	ri.RptHeaderD2 = true
+
+	tbl := getRRTable()
+	tbl.AddColumn("Vendor", 30, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)        // vendor name
+	tbl.AddColumn("Tax ID", 15, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)        // EIN or SSN
+	tbl.AddColumn("Address", 40, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)       // mailing address
+	tbl.AddColumn("Total Paid", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)    // total payments in the year
+	tbl.AddColumn("1099 Required", 13, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT) // at or over the threshold
+
+	err := TableReportHeaderBlock(&tbl, fmt.Sprintf("Vendor 1099 Totals %d", ri.D1.Year()), funcname, ri)
+	if err != nil {
+		rlib.LogAndPrintError(funcname, err)
+		return tbl
+	}
+
+	tot, err := rlib.GetVendorPaymentTotals(ri.Xbiz.P.BID, &ri.D1, &ri.D2)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+	m, err := rlib.GetAllVendors(ri.Xbiz.P.BID)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+	for i := 0; i < len(m); i++ {
+		if m[i].FLAGS&rlib.VENDOR1099 == 0 {
+			continue
+		}
+		amt, ok := tot[m[i].VENDID]
+		if !ok {
+			continue
+		}
+		req := "no"
+		if rlib.RoundToCent(amt) >= Vendor1099Threshold {
+			req = "yes"
+		}
+		tbl.AddRow()
+		tbl.Puts(-1, 0, m[i].Name)
+		tbl.Puts(-1, 1, m[i].TaxID)
+		tbl.Puts(-1, 2, fmt.Sprintf("%s %s, %s %s %s", m[i].Address, m[i].Address2, m[i].City, m[i].State, m[i].PostalCode))
+		tbl.Putf(-1, 3, amt)
+		tbl.Puts(-1, 4, req)
+	}
+	if len(tbl.Row) == 0 {
+		tbl.SetSection3(NoRecordsFoundMsg)
+		return tbl
+	}
+	tbl.AddLineAfter(len(tbl.Row) - 1)
+	tbl.InsertSumRow(len(tbl.Row), 0, len(tbl.Row)-1, []int{3})
+	return tbl
+}
+
+// Vendor1099Report returns a string version of the Vendor 1099 report
+func Vendor1099Report(ri *ReporterInfo) string {
+	tbl := Vendor1099ReportTable(ri)
+	return ReportToString(&tbl, ri)
+}
@@ -0,0 +1,122 @@
+// +build sqlite
+
+package rrpt
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+	"time"
+)
+
+// addBill saves vendor v's bill for amount due on due, with a payment of paid
+// made on the first of the month the bill is due in
+func addBill(t *testing.T, b *rrtest.Biz, v *rlib.Vendor, docno string, due time.Time, amount, paid float64) {
+	a := rlib.Bill{BID: b.BID, VENDID: v.VENDID, APLID: b.LID["20001"], Dt: due.AddDate(0, 0, -30), DtDue: due, Amount: amount, DocNo: docno}
+	if _, err := rlib.InsertBill(&a); err != nil {
+		t.Fatalf("InsertBill: %s", err.Error())
+	}
+	if paid == 0 {
+		return
+	}
+	p := rlib.BillPayment{BID: b.BID, BILLID: a.BILLID, VENDID: v.VENDID, DEPID: b.DEPID, Dt: time.Date(due.Year(), due.Month(), 1, 0, 0, 0, 0, time.UTC), Amount: paid}
+	if _, err := rlib.InsertBillPayment(&p); err != nil {
+		t.Fatalf("InsertBillPayment: %s", err.Error())
+	}
+}
+
+func TestAPAgingReport(t *testing.T) {
+	const (
+		colDocNo, colCurrent, colOver90, colTotal = 2, 5, 9, 10
+	)
+	b, ri := newTestReporter(t, rrtest.Dt(2017, 6, 1), rrtest.Dt(2017, 7, 1))
+	v := rlib.Vendor{BID: b.BID, Name: "Acme Plumbing", DefaultLID: b.LID["50001"]}
+	if _, err := rlib.InsertVendor(&v); err != nil {
+		t.Fatalf("InsertVendor: %s", err.Error())
+	}
+	addBill(t, b, &v, "CUR", rrtest.Dt(2017, 7, 15), 100, 0)  // not yet due
+	addBill(t, b, &v, "D30", rrtest.Dt(2017, 6, 15), 200, 50) // 16 days past due, 150 unpaid
+	addBill(t, b, &v, "D60", rrtest.Dt(2017, 5, 1), 300, 0)   // 61 days past due
+	addBill(t, b, &v, "D90", rrtest.Dt(2017, 3, 1), 400, 0)   // 122 days past due
+	addBill(t, b, &v, "PAID", rrtest.Dt(2017, 6, 1), 500, 500)
+	addBill(t, b, &v, "LATER", rrtest.Dt(2017, 8, 31), 600, 0) // billed after D2
+
+	tbl := APAgingReportTable(ri)
+	if s := tbl.GetSection3(); s != "" {
+		t.Fatalf("unexpected error: %s", s)
+	}
+	expect := map[string]struct {
+		col int
+		bal float64
+	}{
+		"CUR": {colCurrent, 100},
+		"D30": {colCurrent + 1, 150},
+		"D60": {colCurrent + 3, 300},
+		"D90": {colOver90, 400},
+	}
+	if len(tbl.Row) != len(expect)+1 {
+		t.Fatalf("expect %d bills and a total row, got %d rows", len(expect), len(tbl.Row))
+	}
+	for i := 0; i < len(tbl.Row)-1; i++ {
+		x, ok := expect[cells(&tbl, i, colDocNo)]
+		if !ok {
+			t.Errorf("unexpected bill %s", cells(&tbl, i, colDocNo))
+			continue
+		}
+		for k := colCurrent; k <= colOver90; k++ {
+			want := float64(0)
+			if k == x.col {
+				want = x.bal
+			}
+			if got := cellf(&tbl, i, k); got != want {
+				t.Errorf("bill %s column %d: expect %.2f, got %.2f", cells(&tbl, i, colDocNo), k, want, got)
+			}
+		}
+	}
+	if got := cellf(&tbl, len(tbl.Row)-1, colTotal); got != 950 {
+		t.Errorf("total due: expect 950.00, got %.2f", got)
+	}
+}
+
+func TestVendor1099Report(t *testing.T) {
+	// -k 2018-01-01 sets D2 to the first day after 2017
+	b, ri := newTestReporter(t, rrtest.Dt(2017, 12, 1), rrtest.Dt(2018, 1, 1))
+	v := []rlib.Vendor{
+		{BID: b.BID, Name: "Acme Plumbing", FLAGS: rlib.VENDOR1099},
+		{BID: b.BID, Name: "Bob's Paint", FLAGS: rlib.VENDOR1099},
+		{BID: b.BID, Name: "Utility Co"},
+	}
+	for i := 0; i < len(v); i++ {
+		if _, err := rlib.InsertVendor(&v[i]); err != nil {
+			t.Fatalf("InsertVendor: %s", err.Error())
+		}
+	}
+	addBill(t, b, &v[0], "A1", rrtest.Dt(2017, 2, 15), 400, 400)
+	addBill(t, b, &v[0], "A2", rrtest.Dt(2017, 11, 15), 300, 300)
+	addBill(t, b, &v[0], "A3", rrtest.Dt(2018, 1, 15), 900, 900) // paid in 2018
+	addBill(t, b, &v[1], "B1", rrtest.Dt(2017, 5, 15), 250, 250)
+	addBill(t, b, &v[2], "U1", rrtest.Dt(2017, 5, 15), 5000, 5000) // not a 1099 vendor
+
+	tbl := Vendor1099ReportTable(ri)
+	if ri.D1.Year() != 2017 || !ri.D2.Equal(rrtest.Dt(2018, 1, 1)) {
+		t.Errorf("expect the report to cover 2017, got %s - %s", ri.D1.Format(rlib.RRDATEFMT4), ri.D2.Format(rlib.RRDATEFMT4))
+	}
+	if s := tbl.GetSection3(); s != "" {
+		t.Fatalf("unexpected error: %s", s)
+	}
+	if len(tbl.Row) != 3 {
+		t.Fatalf("expect 2 vendors and a total row, got %d rows", len(tbl.Row))
+	}
+	got := map[string]float64{}
+	req := map[string]string{}
+	for i := 0; i < 2; i++ {
+		got[cells(&tbl, i, 0)] = cellf(&tbl, i, 3)
+		req[cells(&tbl, i, 0)] = cells(&tbl, i, 4)
+	}
+	if got["Acme Plumbing"] != 700 || req["Acme Plumbing"] != "yes" {
+		t.Errorf("Acme Plumbing: expect 700.00 and a 1099, got %.2f %s", got["Acme Plumbing"], req["Acme Plumbing"])
+	}
+	if got["Bob's Paint"] != 250 || req["Bob's Paint"] != "no" {
+		t.Errorf("Bob's Paint: expect 250.00 and no 1099, got %.2f %s", got["Bob's Paint"], req["Bob's Paint"])
+	}
+}
@@ -0,0 +1,484 @@
+package rrpt
+
+import (
+	"fmt"
+	"gotable"
+	"rentroll/rlib"
+	"sort"
+	"strings"
+	"time"
+)
+
+// consolidated line types
+const (
+	clineAmount  = 0 // a value that is summed into the total column
+	clineHeader  = 1 // a section label, no values
+	clinePercent = 2 // a percentage, the total is computed by the report
+)
+
+// consolidatedLine is one row of a consolidated report. Amt has one entry
+// for each business in the report, followed by the total.
+type consolidatedLine struct {
+	Label string
+	Type  int
+	Amt   []float64
+}
+
+// getConsolidatedBusinesses loads the businesses for a consolidated report.
+// If ri.BIDList is empty, all businesses are included.
+func getConsolidatedBusinesses(ri *ReporterInfo) ([]rlib.XBusiness, error) {
+	var m []rlib.XBusiness
+	if len(ri.BIDList) == 0 {
+		bl, err := rlib.GetBusinessListFromSpec("")
+		if err != nil {
+			return m, err
+		}
+		ri.BIDList = bl
+	}
+	for i := 0; i < len(ri.BIDList); i++ {
+		var xbiz rlib.XBusiness
+		rlib.InitBizInternals(ri.BIDList[i], &xbiz)
+		if xbiz.P.BID == 0 {
+			return m, fmt.Errorf("business %d not found", ri.BIDList[i])
+		}
+		m = append(m, xbiz)
+	}
+	return m, nil
+}
+
+// newConsolidatedLine returns a line with room for n businesses plus the total
+func newConsolidatedLine(label string, t, n int) consolidatedLine {
+	return consolidatedLine{Label: label, Type: t, Amt: make([]float64, n+1)}
+}
+
+// consolidatedTable builds the table for a consolidated report. There is one
+// column per business and a final column with the consolidated total. The
+// total for clineAmount lines is computed here.
+func consolidatedTable(ri *ReporterInfo, rn, funcname string, bl []rlib.XBusiness, lines []consolidatedLine) gotable.Table {
+	tbl := getRRTable()
+	tbl.AddColumn("Item", 35, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	var names []string
+	for i := 0; i < len(bl); i++ {
+		tbl.AddColumn(bl[i].P.Designation, 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)
+		names = append(names, bl[i].P.Designation)
+	}
+	tbl.AddColumn("Consolidated", 14, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)
+
+	tbl.SetTitle("Consolidated " + rn)
+	var s string
+	if ri.RptHeaderD1 && ri.RptHeaderD2 {
+		s = ri.D1.Format(rlib.RRDATEREPORTFMT) + " - " + ri.D2.Format(rlib.RRDATEREPORTFMT)
+	} else if ri.RptHeaderD1 {
+		s = ri.D1.Format(rlib.RRDATEREPORTFMT)
+	} else if ri.RptHeaderD2 {
+		s = ri.D2.Format(rlib.RRDATEREPORTFMT)
+	}
+	tbl.SetSection1(s)
+	s = "Businesses: " + strings.Join(names, ", ") + "\n"
+	if ri.BlankLineAfterRptName {
+		s += "\n"
+	}
+	tbl.SetSection2(s)
+
+	if len(lines) == 0 {
+		tbl.SetSection3(NoRecordsFoundMsg)
+		return tbl
+	}
+
+	n := len(bl)
+	for i := 0; i < len(lines); i++ {
+		tbl.AddRow()
+		tbl.Puts(-1, 0, lines[i].Label)
+		if lines[i].Type == clineHeader {
+			continue
+		}
+		if lines[i].Type == clineAmount {
+			lines[i].Amt[n] = 0
+			for j := 0; j < n; j++ {
+				lines[i].Amt[n] += lines[i].Amt[j]
+			}
+		}
+		for j := 0; j <= n; j++ {
+			tbl.Putf(-1, j+1, lines[i].Amt[j])
+		}
+	}
+	return tbl
+}
+
+// consolidatedError returns an empty table with the error in section 3
+func consolidatedError(rn, funcname string, err error) gotable.Table {
+	tbl := getRRTable()
+	tbl.SetTitle("Consolidated " + rn)
+	rlib.LogAndPrintError(funcname, err)
+	tbl.SetSection3(err.Error())
+	return tbl
+}
+
+// ConsolidatedTrialBalanceTable generates the balances as of ri.D2 of every
+// postable GL account in the businesses of ri.BIDList. Accounts are matched
+// across businesses by GL number.
+func ConsolidatedTrialBalanceTable(ri *ReporterInfo) gotable.Table {
+	funcname := "ConsolidatedTrialBalanceTable"
+	rn := "Trial Balance"
+	ri.RptHeaderD1 = false
+	ri.RptHeaderD2 = true
+	bl, err := getConsolidatedBusinesses(ri)
+	if err != nil {
+		return consolidatedError(rn, funcname, err)
+	}
+
+	idx := map[string]int{} // GLNumber to index in lines
+	var lines []consolidatedLine
+	for i := 0; i < len(bl); i++ {
+		bid := bl[i].P.BID
+		for _, acct := range rlib.RRdb.BizTypes[bid].GLAccounts {
+			if acct.AllowPost == 0 {
+				continue
+			}
+			k, ok := idx[acct.GLNumber]
+			if !ok {
+				lines = append(lines, newConsolidatedLine(acct.GLNumber+" "+acct.Name, clineAmount, len(bl)))
+				k = len(lines) - 1
+				idx[acct.GLNumber] = k
+			}
+			lines[k].Amt[i] = rlib.GetAccountBalance(bid, acct.LID, &ri.D2)
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Label < lines[j].Label })
+	if len(lines) > 0 {
+		tot := newConsolidatedLine("Total", clineAmount, len(bl))
+		for i := 0; i < len(lines); i++ {
+			for j := 0; j < len(bl); j++ {
+				tot.Amt[j] += lines[i].Amt[j]
+			}
+		}
+		lines = append(lines, tot)
+	}
+	tbl := consolidatedTable(ri, rn, funcname, bl, lines)
+	if len(tbl.Row) > 1 {
+		tbl.AddLineAfter(len(tbl.Row) - 2)
+	}
+	return tbl
+}
+
+// ConsolidatedIncomeStatementTable generates the income and expense activity
+// for the period ri.D1 - ri.D2 in the businesses of ri.BIDList.
+func ConsolidatedIncomeStatementTable(ri *ReporterInfo) gotable.Table {
+	funcname := "ConsolidatedIncomeStatementTable"
+	rn := "Income Statement"
+	ri.RptHeaderD1 = true
+	ri.RptHeaderD2 = true
+	bl, err := getConsolidatedBusinesses(ri)
+	if err != nil {
+		return consolidatedError(rn, funcname, err)
+	}
+
+	n := len(bl)
+	incIdx := map[string]int{}
+	expIdx := map[string]int{}
+	var inc, exp []consolidatedLine
+	for i := 0; i < n; i++ {
+		bid := bl[i].P.BID
+		for _, acct := range rlib.RRdb.BizTypes[bid].GLAccounts {
+			if acct.AllowPost == 0 {
+				continue
+			}
+			t := strings.ToLower(acct.AcctType)
+			isInc := strings.Contains(t, "income")
+			isExp := strings.Contains(t, "expense")
+			if !isInc && !isExp {
+				continue
+			}
+			amt, err := rlib.GetAccountActivity(bid, acct.LID, &ri.D1, &ri.D2)
+			if err != nil {
+				rlib.LogAndPrintError(funcname, err)
+				continue
+			}
+			if rlib.AccountTypeNegateFlag(acct.AcctType) {
+				amt = -amt
+			}
+			lbl := acct.GLNumber + " " + acct.Name
+			if isInc {
+				k, ok := incIdx[acct.GLNumber]
+				if !ok {
+					inc = append(inc, newConsolidatedLine(lbl, clineAmount, n))
+					k = len(inc) - 1
+					incIdx[acct.GLNumber] = k
+				}
+				inc[k].Amt[i] += amt
+			} else {
+				k, ok := expIdx[acct.GLNumber]
+				if !ok {
+					exp = append(exp, newConsolidatedLine(lbl, clineAmount, n))
+					k = len(exp) - 1
+					expIdx[acct.GLNumber] = k
+				}
+				exp[k].Amt[i] += amt
+			}
+		}
+	}
+	sort.Slice(inc, func(i, j int) bool { return inc[i].Label < inc[j].Label })
+	sort.Slice(exp, func(i, j int) bool { return exp[i].Label < exp[j].Label })
+
+	totInc := newConsolidatedLine("Total Income", clineAmount, n)
+	totExp := newConsolidatedLine("Total Expenses", clineAmount, n)
+	net := newConsolidatedLine("Net Income", clineAmount, n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < len(inc); i++ {
+			totInc.Amt[j] += inc[i].Amt[j]
+		}
+		for i := 0; i < len(exp); i++ {
+			totExp.Amt[j] += exp[i].Amt[j]
+		}
+		net.Amt[j] = totInc.Amt[j] - totExp.Amt[j]
+	}
+
+	var lines []consolidatedLine
+	lines = append(lines, newConsolidatedLine("Income", clineHeader, n))
+	lines = append(lines, inc...)
+	lines = append(lines, totInc)
+	lines = append(lines, newConsolidatedLine("Expenses", clineHeader, n))
+	lines = append(lines, exp...)
+	lines = append(lines, totExp, net)
+	return consolidatedTable(ri, rn, funcname, bl, lines)
+}
+
+// consolidatedRentableCounts returns the number of rentables in business
+// bid and the number of them that are under a rental agreement at any time
+// during d1 - d2
+func consolidatedRentableCounts(bid int64, d1, d2 *time.Time) (float64, float64, error) {
+	var cnt, rented float64
+	m, err := consolidatedRentables(bid)
+	for i := 0; i < len(m); i++ {
+		cnt++
+		if len(rlib.GetAgreementsForRentable(m[i].RID, d1, d2)) > 0 {
+			rented++
+		}
+	}
+	return cnt, rented, err
+}
+
+// consolidatedErrors puts the errors encountered while creating a
+// consolidated report into section 3 of its table
+func consolidatedErrors(tbl *gotable.Table, funcname string, errs []string) {
+	if len(errs) == 0 {
+		return
+	}
+	for i := 0; i < len(errs); i++ {
+		rlib.Ulog("%s: %s\n", funcname, errs[i])
+	}
+	tbl.SetSection3(fmt.Sprintf("Encountered %d errors while creating this report:\n%s", len(errs), strings.Join(errs, "\n")))
+}
+
+// ConsolidatedRentRollSummaryTable generates rent roll summary values for the
+// period ri.D1 - ri.D2 in the businesses of ri.BIDList.
+func ConsolidatedRentRollSummaryTable(ri *ReporterInfo) gotable.Table {
+	funcname := "ConsolidatedRentRollSummaryTable"
+	rn := "Rent Roll Summary"
+	ri.RptHeaderD1 = true
+	ri.RptHeaderD2 = true
+	bl, err := getConsolidatedBusinesses(ri)
+	if err != nil {
+		return consolidatedError(rn, funcname, err)
+	}
+
+	n := len(bl)
+	var errs []string
+	rentables := newConsolidatedLine("Rentables", clineAmount, n)
+	rented := newConsolidatedLine("Rented", clineAmount, n)
+	gsr := newConsolidatedLine("Gross Scheduled Rent", clineAmount, n)
+	rcv := newConsolidatedLine("Receivables Balance", clineAmount, n)
+	secdep := newConsolidatedLine("Security Deposits Held", clineAmount, n)
+	for i := 0; i < n; i++ {
+		bid := bl[i].P.BID
+		bud := bl[i].P.Designation
+		rentables.Amt[i], rented.Amt[i], err = consolidatedRentableCounts(bid, &ri.D1, &ri.D2)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: cannot count rentables: %s", bud, err.Error()))
+		}
+
+		m, err := consolidatedRentables(bid)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: cannot read rentables: %s", bud, err.Error()))
+		}
+		for j := 0; j < len(m); j++ {
+			amt, _, _, err := rlib.CalculateLoadedGSR(bid, m[j].RID, &ri.D1, &ri.D2, &bl[i])
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: rentable %s: %s", bud, m[j].RentableName, err.Error()))
+				continue
+			}
+			gsr.Amt[i] += amt
+		}
+
+		for _, lid := range rlib.GetReceivableAccounts(bid) {
+			rcv.Amt[i] += rlib.GetAccountBalance(bid, lid, &ri.D2)
+		}
+		for _, lid := range rlib.GetSecurityDepositsAccounts(bid) {
+			secdep.Amt[i] -= rlib.GetAccountBalance(bid, lid, &ri.D2) // liability, show as positive
+		}
+	}
+	tbl := consolidatedTable(ri, rn, funcname, bl, []consolidatedLine{rentables, rented, gsr, rcv, secdep})
+	consolidatedErrors(&tbl, funcname, errs)
+	return tbl
+}
+
+// consolidatedRentables returns the rentables of business bid
+func consolidatedRentables(bid int64) ([]rlib.Rentable, error) {
+	var m []rlib.Rentable
+	rows, err := rlib.RRdb.Prepstmt.GetAllRentablesByBusiness.Query(bid)
+	if err != nil {
+		return m, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r rlib.Rentable
+		if err = rlib.ReadRentables(rows, &r); err != nil {
+			return m, err
+		}
+		m = append(m, r)
+	}
+	return m, rows.Err()
+}
+
+// consolidatedAgingDays are the upper bounds, in days since the charge, of
+// the aging buckets of the consolidated delinquency report. Charges older
+// than the last bound go into the final bucket.
+var consolidatedAgingDays = []int{30, 60, 90}
+
+// consolidatedOpenCharges ages the open charges of business bid as of dt.
+// An open charge is the part of an assessment to a receivables account that
+// has not been paid by receipts allocated before dt. Its age is the number
+// of days from the start of the assessment to dt. Reversed assessments,
+// offsets and voided allocations are not counted.
+//
+// RETURNS
+//  the open charges in each aging bucket, len(consolidatedAgingDays)+1 of them
+//  any error encountered
+//-----------------------------------------------------------------------------
+func consolidatedOpenCharges(bid int64, dt *time.Time) ([]float64, error) {
+	amt := make([]float64, len(consolidatedAgingDays)+1)
+	rcv := map[int64]bool{}
+	for _, lid := range rlib.GetReceivableAccounts(bid) {
+		rcv[lid] = true
+	}
+	q := "SELECT a.ARID,a.Start,a.Amount-COALESCE((SELECT SUM(ra.Amount) FROM ReceiptAllocation ra WHERE ra.ASMID=a.ASMID AND ra.Dt<? AND (ra.FLAGS & 4)=0),0) FROM Assessments a WHERE a.BID=? AND (a.PASMID!=0 OR a.RentCycle=0) AND a.Start<? AND (a.FLAGS & 4)=0 AND (a.FLAGS & 3)!=3"
+	rows, err := rlib.RRdb.Dbrr.Query(q, dt, bid, dt)
+	if err != nil {
+		return amt, err
+	}
+	defer rows.Close()
+	debit := map[int64]int64{} // ARID -> the GL account the account rule debits
+	for rows.Next() {
+		var arid int64
+		var start time.Time
+		var open float64
+		if err = rows.Scan(&arid, &start, &open); err != nil {
+			return amt, err
+		}
+		if rlib.RoundToCent(open) == 0 {
+			continue
+		}
+		lid, ok := debit[arid]
+		if !ok {
+			ar, err := rlib.GetAR(arid)
+			if err != nil {
+				return amt, err
+			}
+			lid = ar.DebitLID
+			debit[arid] = lid
+		}
+		if !rcv[lid] {
+			continue
+		}
+		days := int(dt.Sub(start).Hours() / 24)
+		k := 0
+		for k < len(consolidatedAgingDays) && days > consolidatedAgingDays[k] {
+			k++
+		}
+		amt[k] += open
+	}
+	return amt, rows.Err()
+}
+
+// ConsolidatedDelinquencyTable generates the aged receivables as of ri.D2
+// for the businesses of ri.BIDList: the open charges broken down by the
+// number of days since they were assessed.
+func ConsolidatedDelinquencyTable(ri *ReporterInfo) gotable.Table {
+	funcname := "ConsolidatedDelinquencyTable"
+	rn := "Delinquency"
+	ri.RptHeaderD1 = false
+	ri.RptHeaderD2 = true
+	bl, err := getConsolidatedBusinesses(ri)
+	if err != nil {
+		return consolidatedError(rn, funcname, err)
+	}
+
+	n := len(bl)
+	var errs []string
+	var lines []consolidatedLine
+	lo := 0
+	for _, hi := range consolidatedAgingDays {
+		lines = append(lines, newConsolidatedLine(fmt.Sprintf("%d - %d Days", lo, hi), clineAmount, n))
+		lo = hi + 1
+	}
+	lines = append(lines, newConsolidatedLine(fmt.Sprintf("Over %d Days", lo-1), clineAmount, n))
+	tot := newConsolidatedLine("Total Open Charges", clineAmount, n)
+	for i := 0; i < n; i++ {
+		amt, err := consolidatedOpenCharges(bl[i].P.BID, &ri.D2)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", bl[i].P.Designation, err.Error()))
+			continue
+		}
+		for k := 0; k < len(amt); k++ {
+			lines[k].Amt[i] = amt[k]
+			tot.Amt[i] += amt[k]
+		}
+	}
+	tbl := consolidatedTable(ri, rn, funcname, bl, append(lines, tot))
+	if len(tbl.Row) > 1 {
+		tbl.AddLineAfter(len(tbl.Row) - 2)
+	}
+	consolidatedErrors(&tbl, funcname, errs)
+	return tbl
+}
+
+// ConsolidatedOccupancyTable generates the physical occupancy on ri.D2 for the
+// businesses of ri.BIDList.
+func ConsolidatedOccupancyTable(ri *ReporterInfo) gotable.Table {
+	funcname := "ConsolidatedOccupancyTable"
+	rn := "Occupancy"
+	ri.RptHeaderD1 = false
+	ri.RptHeaderD2 = true
+	bl, err := getConsolidatedBusinesses(ri)
+	if err != nil {
+		return consolidatedError(rn, funcname, err)
+	}
+
+	n := len(bl)
+	d1 := ri.D2
+	d2 := ri.D2.AddDate(0, 0, 1)
+	rentables := newConsolidatedLine("Rentables", clineAmount, n)
+	occ := newConsolidatedLine("Occupied", clineAmount, n)
+	vac := newConsolidatedLine("Vacant", clineAmount, n)
+	pct := newConsolidatedLine("Occupancy %", clinePercent, n)
+	var tc, to float64
+	var errs []string
+	for i := 0; i < n; i++ {
+		rentables.Amt[i], occ.Amt[i], err = consolidatedRentableCounts(bl[i].P.BID, &d1, &d2)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: cannot count rentables: %s", bl[i].P.Designation, err.Error()))
+		}
+		vac.Amt[i] = rentables.Amt[i] - occ.Amt[i]
+		if rentables.Amt[i] > 0 {
+			pct.Amt[i] = 100 * occ.Amt[i] / rentables.Amt[i]
+		}
+		tc += rentables.Amt[i]
+		to += occ.Amt[i]
+	}
+	if tc > 0 {
+		pct.Amt[n] = 100 * to / tc
+	}
+	tbl := consolidatedTable(ri, rn, funcname, bl, []consolidatedLine{rentables, occ, vac, pct})
+	consolidatedErrors(&tbl, funcname, errs)
+	return tbl
+}
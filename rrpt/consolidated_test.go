@@ -0,0 +1,107 @@
+// +build sqlite
+
+package rrpt
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addCharge saves an assessment of amount to rental agreement b.RAID under
+// account rule ar, starting on dt, with the supplied flags. It is paid by
+// one allocation of paid made on paidDt.
+func addCharge(t *testing.T, b *rrtest.Biz, ar string, dt time.Time, amount float64, flags uint64, paid float64, paidDt time.Time) {
+	a := rlib.Assessment{BID: b.BID, RID: b.RID[0], RAID: b.RAID, ARID: b.ARID[ar], Amount: amount, Start: dt, Stop: dt, FLAGS: flags}
+	if _, err := rlib.InsertAssessment(&a); err != nil {
+		t.Fatalf("InsertAssessment: %s", err.Error())
+	}
+	if paid == 0 {
+		return
+	}
+	ra := rlib.ReceiptAllocation{BID: b.BID, RAID: b.RAID, ASMID: a.ASMID, Dt: paidDt, Amount: paid}
+	if _, err := rlib.InsertReceiptAllocation(&ra); err != nil {
+		t.Fatalf("InsertReceiptAllocation: %s", err.Error())
+	}
+}
+
+func TestConsolidatedDelinquency(t *testing.T) {
+	d2 := rrtest.Dt(2017, 7, 1)
+	b, ri := newTestReporter(t, rrtest.Dt(2017, 6, 1), d2)
+	b2 := rrtest.NewBusiness(t, "BEX")
+	ri.BIDList = []int64{b.BID, b2.BID}
+
+	addCharge(t, b, "Rent", rrtest.Dt(2017, 6, 1), 1000, 1, 400, rrtest.Dt(2017, 6, 5))            // 30 days, 600 open
+	addCharge(t, b, "Rent", rrtest.Dt(2017, 5, 15), 1000, 2, 1000, rrtest.Dt(2017, 7, 10))         // 47 days, paid after D2
+	addCharge(t, b, "Late Fee", rrtest.Dt(2017, 5, 1), 50, 0, 0, d2)                               // 61 days
+	addCharge(t, b, "Rent", rrtest.Dt(2017, 3, 1), 1000, 0, 0, d2)                                 // 122 days
+	addCharge(t, b, "Rent", rrtest.Dt(2017, 4, 1), 1000, 2, 1000, rrtest.Dt(2017, 4, 3))           // paid
+	addCharge(t, b, "Rent", rrtest.Dt(2017, 2, 1), 1000, 4, 0, d2)                                 // reversed
+	addCharge(t, b, "Rent", rrtest.Dt(2017, 7, 1), 1000, 0, 0, d2)                                 // charged on D2
+	addCharge(t, b2, "Rent", rrtest.Dt(2017, 6, 20), 200, 0, 0, d2)                                // 11 days
+	addCharge(t, b2, "Security Deposit", rrtest.Dt(2017, 1, 1), 500, 1, 100, d2.AddDate(0, 0, -1)) // 181 days, 400 open
+
+	tbl := ConsolidatedDelinquencyTable(ri)
+	if s := tbl.GetSection3(); s != "" {
+		t.Fatalf("unexpected error: %s", s)
+	}
+	expect := []struct {
+		label         string
+		rex, bex, tot float64
+	}{
+		{"0 - 30 Days", 600, 200, 800},
+		{"31 - 60 Days", 1000, 0, 1000},
+		{"61 - 90 Days", 50, 0, 50},
+		{"Over 90 Days", 1000, 400, 1400},
+		{"Total Open Charges", 2650, 600, 3250},
+	}
+	if len(tbl.Row) != len(expect) {
+		t.Fatalf("expect %d rows, got %d", len(expect), len(tbl.Row))
+	}
+	for i, x := range expect {
+		if cells(&tbl, i, 0) != x.label {
+			t.Errorf("row %d: expect %q, got %q", i, x.label, cells(&tbl, i, 0))
+		}
+		if cellf(&tbl, i, 1) != x.rex || cellf(&tbl, i, 2) != x.bex || cellf(&tbl, i, 3) != x.tot {
+			t.Errorf("%s: expect %.2f %.2f %.2f, got %.2f %.2f %.2f", x.label, x.rex, x.bex, x.tot, cellf(&tbl, i, 1), cellf(&tbl, i, 2), cellf(&tbl, i, 3))
+		}
+	}
+}
+
+func TestConsolidatedOccupancy(t *testing.T) {
+	b, ri := newTestReporter(t, rrtest.Dt(2017, 6, 1), rrtest.Dt(2017, 6, 15))
+	b2 := rrtest.NewBusiness(t, "BEX")
+	ri.BIDList = []int64{b.BID, b2.BID}
+
+	tbl := ConsolidatedOccupancyTable(ri)
+	if s := tbl.GetSection3(); s != "" {
+		t.Fatalf("unexpected error: %s", s)
+	}
+	// each business rents 1 of its 3 rentables
+	expect := [][]float64{{3, 3, 6}, {1, 1, 2}, {2, 2, 4}}
+	for i, x := range expect {
+		for j := 0; j < 3; j++ {
+			if got := cellf(&tbl, i, j+1); got != x[j] {
+				t.Errorf("%s column %d: expect %.0f, got %.0f", cells(&tbl, i, 0), j+1, x[j], got)
+			}
+		}
+	}
+	if got := cellf(&tbl, 3, 3); rlib.RoundToCent(got) != 33.33 {
+		t.Errorf("consolidated occupancy: expect 33.33%%, got %.2f", got)
+	}
+}
+
+func TestConsolidatedErrors(t *testing.T) {
+	tbl := getRRTable()
+	consolidatedErrors(&tbl, "test", nil)
+	if s := tbl.GetSection3(); s != "" {
+		t.Errorf("no errors: expect an empty section 3, got %q", s)
+	}
+	consolidatedErrors(&tbl, "test", []string{"REX: rentable 101: no market rate", "BEX: cannot read rentables"})
+	s := tbl.GetSection3()
+	if !strings.Contains(s, "2 errors") || !strings.Contains(s, "rentable 101: no market rate") || !strings.Contains(s, "BEX: cannot read rentables") {
+		t.Errorf("expect both errors in section 3, got %q", s)
+	}
+}
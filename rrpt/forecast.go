@@ -0,0 +1,444 @@
+package rrpt
+
+import (
+	"fmt"
+	"gotable"
+	"rentroll/rlib"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ForecastMinMonths et al bound the number of months of a forecast and give
+// the assumptions used for rentable types that do not set their own
+const (
+	ForecastMinMonths       = 12                    // shortest forecast
+	ForecastMaxMonths       = 36                    // longest forecast
+	ForecastDefaultRenewal  = 0.5                   // probability that a lease is renewed when it expires
+	ForecastDefaultDowntime = 30                    // days a unit is vacant when a lease is not renewed
+	ForecastDefaultTerm     = 12                    // months of a renewed or new lease
+	ForecastRenewalCA       = "Renewal Probability" // RentableType custom attribute, percent
+	ForecastDowntimeCA      = "Vacancy Days"        // RentableType custom attribute, days
+)
+
+// ForecastAssumptions are the assumptions used to project the rent of a
+// rentable type once its current leases expire
+type ForecastAssumptions struct {
+	Renewal  float64 // probability, 0 - 1, that a lease is renewed when it expires
+	Downtime int     // days a unit is vacant before a new lease when it is not renewed
+	Term     int     // months of a renewed or new lease
+}
+
+// ForecastMonth is the projected rent and occupancy of a business for one
+// month.
+type ForecastMonth struct {
+	DtStart       time.Time // start of the month
+	DtStop        time.Time // end of the month, not included
+	Units         int64     // rentables in the forecast
+	OccupiedUnits float64   // expected number of occupied rentables, averaged over the month
+	Expiring      int64     // scheduled lease expirations in the month
+	ScheduledRent float64   // instances of the recurring rent assessments of the current leases
+	LeaseRent     float64   // expected market rent of renewals and new leases
+	VacancyLoss   float64   // market rent of the days units are expected to be vacant
+	MarketGSR     float64   // market rent of all the units
+}
+
+// Occupancy returns the expected occupancy of f as a percentage
+func (f *ForecastMonth) Occupancy() float64 {
+	if f.Units == 0 {
+		return 0
+	}
+	return 100 * f.OccupiedUnits / float64(f.Units)
+}
+
+// Rent returns the total projected rent of f
+func (f *ForecastMonth) Rent() float64 {
+	return f.ScheduledRent + f.LeaseRent
+}
+
+// forecastPercent converts a percentage such as "60" or "60%" to a
+// probability
+func forecastPercent(s string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%")), 64)
+	if err != nil || f < 0 || f > 100 {
+		return 0, fmt.Errorf("invalid percentage: %s", s)
+	}
+	return f / 100, nil
+}
+
+// GetForecastAssumptions returns the assumptions for rentable type rtid. The
+// custom attributes ForecastRenewalCA and ForecastDowntimeCA of the rentable
+// type override the defaults in def.
+func GetForecastAssumptions(xbiz *rlib.XBusiness, rtid int64, def *ForecastAssumptions) (ForecastAssumptions, error) {
+	a := *def
+	rt, ok := xbiz.RT[rtid]
+	if !ok {
+		return a, nil
+	}
+	if c, ok := rt.CA[ForecastRenewalCA]; ok {
+		f, err := forecastPercent(c.Value)
+		if err != nil {
+			return a, fmt.Errorf("%s %s: %s", rt.Name, ForecastRenewalCA, err.Error())
+		}
+		a.Renewal = f
+	}
+	if c, ok := rt.CA[ForecastDowntimeCA]; ok {
+		n, err := strconv.Atoi(strings.TrimSpace(c.Value))
+		if err != nil || n < 0 {
+			return a, fmt.Errorf("%s %s: invalid number of days: %s", rt.Name, ForecastDowntimeCA, c.Value)
+		}
+		a.Downtime = n
+	}
+	return a, nil
+}
+
+// forecastOccupancy returns the probability that a rentable whose known
+// leases end at dtExp is occupied on day dt, which is not before dtExp. The
+// rentable turns over every a.Term months after dtExp. At each turnover it
+// stays occupied with probability a.Renewal, otherwise it is vacant for
+// a.Downtime days. leased is false if the rentable has no lease at all, it
+// then stays vacant for the first a.Downtime days.
+func forecastOccupancy(dt, dtExp *time.Time, a *ForecastAssumptions, leased bool) float64 {
+	k := 0
+	exp := *dtExp
+	for {
+		next := dtExp.AddDate(0, (k+1)*a.Term, 0)
+		if a.Term <= 0 || next.After(*dt) {
+			break
+		}
+		k++
+		exp = next
+	}
+	if dt.Before(exp.AddDate(0, 0, a.Downtime)) {
+		if k == 0 && !leased {
+			return 0
+		}
+		return a.Renewal
+	}
+	return 1
+}
+
+// GetRentForecast projects the rent and occupancy of the business in xbiz
+// for the given number of months starting with the month of d1.
+//
+// Rent under the current leases comes from the instances of their recurring
+// rent assessments, up to the AgreementStop of each rental agreement.  After the
+// last known lease of a rentable expires, its rent is the market rate of its
+// rentable type, reduced by the expected vacancy given by the assumptions of
+// the type. Other recurring charges, such as fees, are not rent and are not
+// in the forecast.
+//
+// INPUTS
+//  xbiz   - the business
+//  d1     - start of the forecast, the first month starts on the first of
+//           the month of d1
+//  months - number of months, ForecastMinMonths - ForecastMaxMonths
+//  def    - assumptions for rentable types that do not set their own
+//
+// RETURNS
+//  one ForecastMonth for each month
+//  any error encountered
+//-----------------------------------------------------------------------------
+func GetRentForecast(xbiz *rlib.XBusiness, d1 *time.Time, months int, def *ForecastAssumptions) ([]ForecastMonth, error) {
+	var t []ForecastMonth
+	bid := xbiz.P.BID
+	start := time.Date(d1.Year(), d1.Month(), 1, 0, 0, 0, 0, d1.Location())
+	stop := start.AddDate(0, months, 0)
+	for i := 0; i < months; i++ {
+		t = append(t, ForecastMonth{DtStart: start.AddDate(0, i, 0), DtStop: start.AddDate(0, i+1, 0)})
+	}
+
+	var rl []rlib.Rentable
+	rows, err := rlib.RRdb.Prepstmt.GetAllRentablesByBusiness.Query(bid)
+	if err != nil {
+		return t, err
+	}
+	for rows.Next() {
+		var r rlib.Rentable
+		if err = rlib.ReadRentables(rows, &r); err != nil {
+			rows.Close()
+			return t, err
+		}
+		rl = append(rl, r)
+	}
+	rows.Close()
+
+	//--------------------------------------------------------------
+	// when each rental agreement ends
+	//--------------------------------------------------------------
+	raStop := map[int64]time.Time{}
+	agreementStop := func(raid int64) (time.Time, error) {
+		if dt, ok := raStop[raid]; ok {
+			return dt, nil
+		}
+		ra, err := rlib.GetRentalAgreement(raid)
+		if err != nil {
+			return ra.AgreementStop, err
+		}
+		raStop[raid] = ra.AgreementStop
+		return ra.AgreementStop, nil
+	}
+
+	//--------------------------------------------------------------
+	// scheduled rent from the recurring rent assessments
+	//--------------------------------------------------------------
+	rent := rentARs(bid)
+	rows, err = rlib.RRdb.Prepstmt.GetRecurringAssessmentsByBusiness.Query(bid, stop, start)
+	if err != nil {
+		return t, err
+	}
+	var asms []rlib.Assessment
+	for rows.Next() {
+		var a rlib.Assessment
+		rlib.ReadAssessments(rows, &a)
+		if a.FLAGS&rlib.ASMREVERSED != 0 || a.FLAGS&0x3 == 0x3 || !rent[a.ARID] { // reversed, an offset, or not rent
+			continue
+		}
+		asms = append(asms, a)
+	}
+	rows.Close()
+	for i := 0; i < len(asms); i++ {
+		a := &asms[i]
+		dtStop := a.Stop
+		if a.RAID > 0 {
+			raEnd, err := agreementStop(a.RAID)
+			if err != nil {
+				return t, err
+			}
+			if raEnd.Before(dtStop) {
+				dtStop = raEnd
+			}
+		}
+		for j := 0; j < len(t); j++ {
+			m := rlib.GetRecurrences(&t[j].DtStart, &t[j].DtStop, &a.Start, &dtStop, a.RentCycle)
+			for k := 0; k < len(m); k++ {
+				if m[k].Before(dtStop) {
+					t[j].ScheduledRent += a.Amount
+				}
+			}
+		}
+	}
+
+	//--------------------------------------------------------------
+	// occupancy and market rent, day by day
+	//--------------------------------------------------------------
+	assume := map[int64]ForecastAssumptions{}
+	for i := 0; i < len(rl); i++ {
+		r := &rl[i]
+		rsa := rlib.GetRentableStatusByRange(r.RID, &start, &start)
+		if len(rsa) > 0 && occupancyExcluded(rsa[0].UseStatus) {
+			continue
+		}
+		rtr := rlib.GetRentableTypeRefForDate(r.RID, &start)
+		a, ok := assume[rtr.RTID]
+		if !ok {
+			if a, err = GetForecastAssumptions(xbiz, rtr.RTID, def); err != nil {
+				return t, err
+			}
+			assume[rtr.RTID] = a
+		}
+		rc := xbiz.RT[rtr.RTID].RentCycle
+		if rtr.OverrideRentCycle != 0 {
+			rc = rtr.OverrideRentCycle
+		}
+
+		// the known leases, and when the last one ends
+		rra := rlib.GetAgreementsForRentable(r.RID, &start, &stop)
+		exp := start
+		for j := 0; j < len(rra); j++ {
+			raEnd, err := agreementStop(rra[j].RAID)
+			if err != nil {
+				return t, err
+			}
+			if raEnd.Before(rra[j].RARDtStop) {
+				rra[j].RARDtStop = raEnd
+			}
+			if rra[j].RARDtStop.After(exp) {
+				exp = rra[j].RARDtStop
+			}
+		}
+		leased := len(rra) > 0
+
+		for j := 0; j < len(t); j++ {
+			f := &t[j]
+			f.Units++
+			if leased && !exp.Before(f.DtStart) && exp.Before(f.DtStop) {
+				f.Expiring++
+			}
+			days := f.DtStop.Sub(f.DtStart).Hours() / 24
+			var daily float64
+			if rc != rlib.CYCLENORECUR {
+				mr := rlib.GetRentableMarketRate(xbiz, r.RID, &f.DtStart, &f.DtStop)
+				daily = mr * float64(f.DtStop.Sub(f.DtStart)) / float64(rlib.CycleDuration(rc, f.DtStart)) / days
+			}
+			for dt := f.DtStart; dt.Before(f.DtStop); dt = dt.AddDate(0, 0, 1) {
+				f.MarketGSR += daily
+				occ := float64(0)
+				if dt.Before(exp) {
+					for k := 0; k < len(rra); k++ {
+						if !dt.Before(rra[k].RARDtStart) && dt.Before(rra[k].RARDtStop) {
+							occ = 1
+							break
+						}
+					}
+				} else {
+					occ = forecastOccupancy(&dt, &exp, &a, leased)
+					f.LeaseRent += occ * daily
+				}
+				f.OccupiedUnits += occ / days
+				f.VacancyLoss += (1 - occ) * daily
+			}
+		}
+	}
+	for j := 0; j < len(t); j++ {
+		t[j].ScheduledRent = rlib.RoundToCent(t[j].ScheduledRent)
+		t[j].LeaseRent = rlib.RoundToCent(t[j].LeaseRent)
+		t[j].VacancyLoss = rlib.RoundToCent(t[j].VacancyLoss)
+		t[j].MarketGSR = rlib.RoundToCent(t[j].MarketGSR)
+		t[j].OccupiedUnits = rlib.RoundToCent(t[j].OccupiedUnits)
+	}
+	return t, nil
+}
+
+// forecastParams reads the forecast options in ri.QueryParams:
+//     months   = number of months, by default the months in ri.D1 - ri.D2
+//     renew    = renewal probability in percent
+//     downtime = vacancy days when a lease is not renewed
+//     term     = months of a renewed or new lease
+func forecastParams(ri *ReporterInfo) (int, ForecastAssumptions, error) {
+	def := ForecastAssumptions{Renewal: ForecastDefaultRenewal, Downtime: ForecastDefaultDowntime, Term: ForecastDefaultTerm}
+	months := (ri.D2.Year()-ri.D1.Year())*12 + int(ri.D2.Month()) - int(ri.D1.Month())
+	if ri.QueryParams == nil {
+		return months, def, nil
+	}
+	qp := ri.QueryParams
+	if s := qp.Get("months"); len(s) > 0 {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return months, def, fmt.Errorf("invalid months: %s", s)
+		}
+		months = n
+	}
+	if s := qp.Get("renew"); len(s) > 0 {
+		f, err := forecastPercent(s)
+		if err != nil {
+			return months, def, fmt.Errorf("renew: %s", err.Error())
+		}
+		def.Renewal = f
+	}
+	if s := qp.Get("downtime"); len(s) > 0 {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return months, def, fmt.Errorf("invalid downtime: %s", s)
+		}
+		def.Downtime = n
+	}
+	if s := qp.Get("term"); len(s) > 0 {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return months, def, fmt.Errorf("invalid term: %s", s)
+		}
+		def.Term = n
+	}
+	return months, def, nil
+}
+
+// RentForecastTable generates the projected rent and occupancy of the
+// business for each month, starting with the month of ri.D1.  The number of
+// months is that of ri.D1 - ri.D2, or the months query parameter, kept
+// within ForecastMinMonths - ForecastMaxMonths.
+func RentForecastTable(ri *ReporterInfo) gotable.Table {
+	const funcname = "RentForecastTable"
+	tbl := getRRTable()
+
+	months, def, err := forecastParams(ri)
+	if months < ForecastMinMonths {
+		months = ForecastMinMonths
+	}
+	if months > ForecastMaxMonths {
+		months = ForecastMaxMonths
+	}
+	ri.D1 = time.Date(ri.D1.Year(), ri.D1.Month(), 1, 0, 0, 0, 0, ri.D1.Location())
+	ri.D2 = ri.D1.AddDate(0, months, 0)
+	ri.RptHeaderD1 = true
+	ri.RptHeaderD2 = true
+
+	tbl.AddColumn("Month", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)                  // first day of the month
+	tbl.AddColumn("Units", 8, gotable.CELLINT, gotable.COLJUSTIFYRIGHT)                   // rentables
+	tbl.AddColumn("Occupied", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)             // expected occupied rentables
+	tbl.AddColumn("Occupancy %", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)          // expected occupancy
+	tbl.AddColumn("Expiring Leases", 10, gotable.CELLINT, gotable.COLJUSTIFYRIGHT)        // scheduled lease expirations
+	tbl.AddColumn("Market GSR", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)           // market rent of all the units
+	tbl.AddColumn("Vacancy Loss", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)         // market rent of the expected vacancy
+	tbl.AddColumn("Scheduled Rent", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)       // recurring rent assessments of the current leases
+	tbl.AddColumn("Projected Lease Rent", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT) // renewals and new leases
+	tbl.AddColumn("Projected Rent", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)       // scheduled rent + projected lease rent
+
+	err1 := TableReportHeaderBlock(&tbl, "Rent Forecast", funcname, ri)
+	if err1 != nil {
+		rlib.LogAndPrintError(funcname, err1)
+		return tbl
+	}
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+
+	m, err := GetRentForecast(ri.Xbiz, &ri.D1, months, &def)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+	var tot ForecastMonth
+	for i := 0; i < len(m); i++ {
+		tbl.AddRow()
+		tbl.Putd(-1, 0, m[i].DtStart)
+		tbl.Puti(-1, 1, m[i].Units)
+		tbl.Putf(-1, 2, m[i].OccupiedUnits)
+		tbl.Putf(-1, 3, m[i].Occupancy())
+		tbl.Puti(-1, 4, m[i].Expiring)
+		tbl.Putf(-1, 5, m[i].MarketGSR)
+		tbl.Putf(-1, 6, m[i].VacancyLoss)
+		tbl.Putf(-1, 7, m[i].ScheduledRent)
+		tbl.Putf(-1, 8, m[i].LeaseRent)
+		tbl.Putf(-1, 9, m[i].Rent())
+		tot.Expiring += m[i].Expiring
+		tot.MarketGSR += m[i].MarketGSR
+		tot.VacancyLoss += m[i].VacancyLoss
+		tot.ScheduledRent += m[i].ScheduledRent
+		tot.LeaseRent += m[i].LeaseRent
+	}
+	if len(m) > 0 {
+		tbl.AddLineAfter(len(tbl.Row) - 1)
+		tbl.AddRow()
+		tbl.Puts(-1, 0, "Total")
+		tbl.Puti(-1, 4, tot.Expiring)
+		tbl.Putf(-1, 5, tot.MarketGSR)
+		tbl.Putf(-1, 6, tot.VacancyLoss)
+		tbl.Putf(-1, 7, tot.ScheduledRent)
+		tbl.Putf(-1, 8, tot.LeaseRent)
+		tbl.Putf(-1, 9, tot.Rent())
+	}
+	addTableNote(&tbl, forecastAssumptionText(ri.Xbiz, &def))
+	return tbl
+}
+
+// forecastAssumptionText describes the assumptions used for each rentable
+// type
+func forecastAssumptionText(xbiz *rlib.XBusiness, def *ForecastAssumptions) string {
+	s := fmt.Sprintf("Assumptions: renewal %.0f%%, %d vacancy days, %d month leases", def.Renewal*100, def.Downtime, def.Term)
+	var m []int64
+	for rtid := range xbiz.RT {
+		m = append(m, rtid)
+	}
+	sort.Slice(m, func(i, j int) bool { return xbiz.RT[m[i]].Name < xbiz.RT[m[j]].Name })
+	for _, rtid := range m {
+		a, err := GetForecastAssumptions(xbiz, rtid, def)
+		if err != nil || a == *def {
+			continue
+		}
+		s += fmt.Sprintf("; %s: renewal %.0f%%, %d vacancy days", xbiz.RT[rtid].Name, a.Renewal*100, a.Downtime)
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+// +build sqlite
+
+package rrpt
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+)
+
+func TestForecastOccupancy(t *testing.T) {
+	a := ForecastAssumptions{Renewal: 0.6, Downtime: 30, Term: 12}
+	exp := rrtest.Dt(2018, 1, 1)
+	m := []struct {
+		what   string
+		dt     int // days after exp
+		leased bool
+		a      ForecastAssumptions
+		expect float64
+	}{
+		{"renewal at expiry", 0, true, a, 0.6},
+		{"last day of downtime", 29, true, a, 0.6},
+		{"after downtime", 30, true, a, 1},
+		{"second turnover", 365 + 5, true, a, 0.6},
+		{"after second downtime", 365 + 30, true, a, 1},
+		{"never leased, in downtime", 10, false, a, 0},
+		{"never leased, after downtime", 30, false, a, 1},
+		{"never leased, first turnover", 365 + 5, false, a, 0.6},
+		{"no downtime", 0, true, ForecastAssumptions{Renewal: 0.6, Term: 12}, 1},
+		{"no term", 800, true, ForecastAssumptions{Renewal: 0.6, Downtime: 30}, 1},
+		{"no term, in downtime", 5, true, ForecastAssumptions{Renewal: 0.6, Downtime: 30}, 0.6},
+	}
+	for _, x := range m {
+		dt := exp.AddDate(0, 0, x.dt)
+		if f := forecastOccupancy(&dt, &exp, &x.a, x.leased); f != x.expect {
+			t.Errorf("%s: expect %.2f, got %.2f", x.what, x.expect, f)
+		}
+	}
+}
+
+// Only the rent of the current lease is scheduled; after it ends, and for the
+// vacant rentables, rent is the market rate less the expected vacancy
+func TestRentForecast(t *testing.T) {
+	b, _ := newTestReporter(t, rrtest.Dt(2017, 11, 1), rrtest.Dt(2018, 11, 1))
+	for _, x := range []struct {
+		ar     string
+		amount float64
+	}{{"Rent", rrtest.MarketRate}, {"Late Fee", 50}} {
+		a := rlib.Assessment{BID: b.BID, RID: b.RID[0], RAID: b.RAID, ARID: b.ARID[x.ar], Amount: x.amount, Start: rrtest.BizStart, Stop: rrtest.RAStop, RentCycle: rlib.RECURMONTHLY, ProrationCycle: rlib.RECURDAILY}
+		if _, err := rlib.InsertAssessment(&a); err != nil {
+			t.Fatalf("InsertAssessment: %s", err.Error())
+		}
+	}
+
+	def := ForecastAssumptions{Renewal: 0.5, Downtime: 30, Term: 12}
+	d1 := rrtest.Dt(2017, 11, 1)
+	m, err := GetRentForecast(&b.XBiz, &d1, 12, &def)
+	if err != nil {
+		t.Fatalf("GetRentForecast: %s", err.Error())
+	}
+	if len(m) != 12 {
+		t.Fatalf("expect 12 months, got %d", len(m))
+	}
+	// 101 is leased to the end of 2017; 102 and 103 are vacant until the
+	// downtime of 30 days from the start of the forecast has passed; in
+	// January 101 is renewed with probability 0.5 for the first 30 days
+	expect := []struct {
+		month                      string
+		occupied, scheduled, lease float64
+		vacancy                    float64
+		expiring                   int64
+	}{
+		{"2017-11", 1, 1000, 0, 2000, 0},
+		{"2017-12", 3, 1000, 2000, 0, 0},
+		{"2018-01", 2.52, 0, 2516.13, 483.87, 1},
+		{"2018-02", 3, 0, 3000, 0, 0},
+	}
+	for i, x := range expect {
+		f := &m[i]
+		if s := f.DtStart.Format("2006-01"); s != x.month {
+			t.Fatalf("month %d: expect %s, got %s", i, x.month, s)
+		}
+		if f.Units != 3 || f.OccupiedUnits != x.occupied || f.ScheduledRent != x.scheduled || f.LeaseRent != x.lease || f.VacancyLoss != x.vacancy || f.Expiring != x.expiring {
+			t.Errorf("%s: expect %d units, %.2f occupied, %.2f scheduled, %.2f lease rent, %.2f vacancy, %d expiring, got %+v", x.month, 3, x.occupied, x.scheduled, x.lease, x.vacancy, x.expiring, *f)
+		}
+		if r := rlib.RoundToCent(f.Rent()); r != rlib.RoundToCent(x.scheduled+x.lease) {
+			t.Errorf("%s: expect rent %.2f, got %.2f", x.month, x.scheduled+x.lease, r)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package rrpt
+
+import "strings"
+
+// SingleTableReports lists the reports made of a single table. Each report
+// can be requested by any of its ReportNames. The web service report handler
+// and scheduled reports find reports here.
+var SingleTableReports = []SingleTableReportHandler{
+	{ReportNames: []string{"RPTapaging", "ap aging"}, TableHandler: APAgingReportTable},
+	{ReportNames: []string{"RPTasmrpt", "assessments"}, TableHandler: RRAssessmentsTable},
+	{ReportNames: []string{"RPTb", "business"}, TableHandler: RRreportBusinessTable},
+	{ReportNames: []string{"RPTcoa", "chart of accounts"}, TableHandler: RRreportChartOfAccountsTable},
+	{ReportNames: []string{"RPTc", "custom attributes"}, TableHandler: RRreportCustomAttributesTable},
+	{ReportNames: []string{"RPTcdelinq", "consolidated delinquency"}, TableHandler: ConsolidatedDelinquencyTable},
+	{ReportNames: []string{"RPTcis", "consolidated income statement"}, TableHandler: ConsolidatedIncomeStatementTable},
+	{ReportNames: []string{"RPTcocc", "consolidated occupancy"}, TableHandler: ConsolidatedOccupancyTable},
+	{ReportNames: []string{"RPTcrr", "consolidated rentroll"}, TableHandler: ConsolidatedRentRollSummaryTable},
+	{ReportNames: []string{"RPTctb", "consolidated trial balance"}, TableHandler: ConsolidatedTrialBalanceTable},
+	{ReportNames: []string{"RPTcr", "custom attribute refs"}, TableHandler: RRreportCustomAttributeRefsTable},
+	{ReportNames: []string{"RPTdelinq", "delinquency"}, TableHandler: DelinquencyReportTable},
+	{ReportNames: []string{"RPTdpm", "deposit methods"}, TableHandler: RRreportDepositMethodsTable},
+	{ReportNames: []string{"RPTdep", "depositories"}, TableHandler: RRreportDepositoryTable},
+	{ReportNames: []string{"RPTforecast", "rent forecast"}, TableHandler: RentForecastTable},
+	{ReportNames: []string{"RPTgsr", "gsr"}, TableHandler: GSRReportTable},
+	{ReportNames: []string{"RPTocc", "occupancy"}, TableHandler: OccupancyReportTable},
+	{ReportNames: []string{"RPTocctrend", "occupancy trend"}, TableHandler: OccupancyTrendTable},
+	{ReportNames: []string{"RPTj", "journals"}, TableHandler: JournalReportTable},
+	{ReportNames: []string{"RPTpeople", "people"}, TableHandler: RRreportPeopleTable},
+	{ReportNames: []string{"RPTpmt", "payment types"}, TableHandler: RRreportPaymentTypesTable},
+	{ReportNames: []string{"RPTr", "rentables"}, TableHandler: RRreportRentablesTable},
+	{ReportNames: []string{"RPTra", "rental agreements"}, TableHandler: RRreportRentalAgreementsTable},
+	{ReportNames: []string{"RPTrat", "rental agreement templates"}, TableHandler: RRreportRentalAgreementTemplatesTable},
+	{ReportNames: []string{"RPTrcpt", "receipts"}, TableHandler: RRReceiptsTable},
+	{ReportNames: []string{"RPTrr", "rentroll"}, TableHandler: RRReportTable},
+	{ReportNames: []string{"RPTrrsnap", "rentroll snapshot"}, TableHandler: RRSnapshotTable},
+	{ReportNames: []string{"RPTrrvar", "rentroll variance"}, TableHandler: RRVarianceTable},
+	{ReportNames: []string{"RPTrt", "rentable types"}, TableHandler: RRreportRentableTypesTable},
+	{ReportNames: []string{"RPTrcbt", "rentable type counts"}, TableHandler: RentableCountByRentableTypeReportTable},
+	{ReportNames: []string{"RPTsl", "string lists"}, TableHandler: RRreportStringListsTable},
+	{ReportNames: []string{"RPTt", "people"}, TableHandler: RRreportPeopleTable},
+	{ReportNames: []string{"RPTtb", "trial balance"}, TableHandler: LedgerBalanceReportTable},
+	{ReportNames: []string{"RPTv1099", "vendor 1099"}, TableHandler: Vendor1099ReportTable},
+	{ReportNames: []string{"RPTintegrity", "integrity check"}, TableHandler: IntegrityCheckTable},
+	{ReportNames: []string{"RPTpayorstmt", "payor statements"}, TableHandler: RRPayorStatement},
+	{ReportNames: []string{"RPTrastmt", "rental agreement statements"}, TableHandler: RRRentalAgreementStatements},
+}
+
+// MultiTableReports lists the reports made of more than one table
+var MultiTableReports = []MultiTableReportHandler{
+	{ReportTitle: "Ledger", ReportNames: []string{"RPTl", "ledger"}, TableHandler: LedgerReportTable},
+	{ReportTitle: "Ledger Activity", ReportNames: []string{"RPTla", "ledger activity"}, TableHandler: LedgerActivityReportTable},
+	{ReportTitle: "Report Statements", ReportNames: []string{"RPTstatements", "report statements"}, TableHandler: RptStatementReportTable},
+	{ReportTitle: "Payor Statements", ReportNames: []string{"RPTpayorstmts", "batch payor statements"}, TableHandler: PayorStatementBatchTable},
+}
+
+// FindReportHandler looks up reportname, ignoring case, in SingleTableReports
+// and MultiTableReports. The Found field of the returned handler that matched
+// is set to true.
+func FindReportHandler(reportname string) (SingleTableReportHandler, MultiTableReportHandler) {
+	var tsh SingleTableReportHandler
+	var tmh MultiTableReportHandler
+
+	// first find it from single table handler
+	for j := 0; j < len(SingleTableReports); j++ {
+		for _, rn := range SingleTableReports[j].ReportNames {
+			if strings.ToLower(rn) == strings.ToLower(reportname) {
+				tsh = SingleTableReports[j]
+				tsh.Found = true
+				return tsh, tmh
+			}
+		}
+	}
+
+	// if not found from single, then find it from multi table handler
+	for j := 0; j < len(MultiTableReports); j++ {
+		for _, rn := range MultiTableReports[j].ReportNames {
+			if strings.ToLower(rn) == strings.ToLower(reportname) {
+				tmh = MultiTableReports[j]
+				tmh.Found = true
+				return tsh, tmh
+			}
+		}
+	}
+	return tsh, tmh
+}
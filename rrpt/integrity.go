@@ -0,0 +1,106 @@
+package rrpt
+
+import (
+	"fmt"
+	"gotable"
+	"rentroll/rlib"
+	"strconv"
+)
+
+// integrityProblem is the short description of each kind of integrity problem
+var integrityProblem = map[int]string{
+	rlib.INTEGJNLAMOUNT:  "Journal allocations",
+	rlib.INTEGUNBALANCED: "Unbalanced rule",
+	rlib.INTEGNOLEDGER:   "Not posted",
+	rlib.INTEGGLMARKER:   "GL marker balance",
+	rlib.INTEGSUBMARKER:  "Sub-ledger marker",
+	rlib.INTEGASMFLAGS:   "Assessment FLAGS",
+	rlib.INTEGRCPTFLAGS:  "Receipt FLAGS",
+	rlib.INTEGOVERALLOC:  "Over allocated",
+	rlib.INTEGORPHANJA:   "Orphaned allocation",
+	rlib.INTEGORPHANLE:   "Orphaned ledger entry",
+	rlib.INTEGORPHANLM:   "Orphaned marker",
+	rlib.INTEGORPHANRA:   "Orphaned rcpt alloc",
+	rlib.INTEGORPHANJNL:  "Orphaned journal",
+}
+
+// integrityIDPrefix is the prefix of the ids of the records in each table
+var integrityIDPrefix = map[string]string{
+	"Assessment":        "ASM",
+	"Journal":           "J",
+	"JournalAllocation": "JA",
+	"LedgerEntry":       "LE",
+	"LedgerMarker":      "LM",
+	"Receipt":           "RCPT",
+	"ReceiptAllocation": "RCPA",
+}
+
+// IntegrityCheckTable generates a table of the integrity problems found in
+// the records of the business over ri.D1 - ri.D2.  If the query parameter
+// repair is true, the problems that are safe to repair are repaired.
+func IntegrityCheckTable(ri *ReporterInfo) gotable.Table {
+	funcname := "IntegrityCheckTable"
+
+	ri.RptHeaderD1 = true
+	ri.RptHeaderD2 = true
+
+	tbl := getRRTable()
+	tbl.AddColumn("Date", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)       // date of the record
+	tbl.AddColumn("Problem", 22, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)  // kind of problem
+	tbl.AddColumn("Record", 14, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)   // the record with the problem
+	tbl.AddColumn("Account", 12, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)  // GL account number
+	tbl.AddColumn("Expected", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT) // amount the record should have
+	tbl.AddColumn("Actual", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)   // amount it has
+	tbl.AddColumn("Repair", 10, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)   // repaired, can be repaired, or blank
+	tbl.AddColumn("Comment", 60, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)  // description
+
+	err := TableReportHeaderBlock(&tbl, "Integrity Check", funcname, ri)
+	if err != nil {
+		rlib.LogAndPrintError(funcname, err)
+		return tbl
+	}
+
+	repair := false
+	if ri.QueryParams != nil {
+		repair, _ = strconv.ParseBool(ri.QueryParams.Get("repair"))
+	}
+	m, err := rlib.CheckIntegrity(ri.Xbiz, &ri.D1, &ri.D2, repair)
+	bid := ri.Xbiz.P.BID
+	rlib.RRdb.BizTypes[bid].GLAccounts = rlib.GetGLAccountMap(bid)
+	repaired := 0
+	for i := 0; i < len(m); i++ {
+		tbl.AddRow()
+		tbl.Putd(-1, 0, m[i].Dt)
+		tbl.Puts(-1, 1, integrityProblem[m[i].Kind])
+		tbl.Puts(-1, 2, rlib.IDtoShortString(integrityIDPrefix[m[i].Table], m[i].ID))
+		tbl.Puts(-1, 3, rlib.RRdb.BizTypes[bid].GLAccounts[m[i].LID].GLNumber)
+		tbl.Putf(-1, 4, m[i].Expected)
+		tbl.Putf(-1, 5, m[i].Actual)
+		switch {
+		case m[i].Repaired:
+			tbl.Puts(-1, 6, "repaired")
+			repaired++
+		case m[i].Repairable:
+			tbl.Puts(-1, 6, "repairable")
+		}
+		tbl.Puts(-1, 7, m[i].Comment)
+	}
+	switch {
+	case err != nil:
+		tbl.SetSection3(err.Error())
+	case len(m) == 0:
+		addTableNote(&tbl, "no problems found")
+	case repaired > 0:
+		addTableNote(&tbl, fmt.Sprintf("%d problems found, %d repaired", len(m), repaired))
+	default:
+		addTableNote(&tbl, fmt.Sprintf("%d problems found", len(m)))
+	}
+	return tbl
+}
+
+// IntegrityCheckReport returns a string version of the integrity check
+// report
+func IntegrityCheckReport(ri *ReporterInfo) string {
+	tbl := IntegrityCheckTable(ri)
+	return ReportToString(&tbl, ri)
+}
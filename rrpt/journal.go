@@ -183,6 +183,38 @@ func printJournalExpense(tbl *gotable.Table, xbiz *rlib.XBusiness, j *rlib.Journ
 	tbl.AddRow() // nothing in this line, it's blank
 }
 
+func printJournalBill(tbl *gotable.Table, xbiz *rlib.XBusiness, j *rlib.Journal, b *rlib.Bill) {
+	v, _ := rlib.GetVendor(b.VENDID)
+	s := fmt.Sprintf("Bill %s - %s", b.DocNo, v.Name)
+	if len(j.Comment) > 0 {
+		s += " " + j.Comment
+	}
+	tbl.AddRow()
+	tbl.Puts(-1, 0, j.IDtoShortString())
+	tbl.Puts(-1, 1, s)
+	for i := 0; i < len(j.JA); i++ {
+		r := rlib.GetRentable(j.JA[i].RID)
+		processAcctRuleAmount(tbl, xbiz, j.JA[i].RID, j.Dt, j.JA[i].AcctRule, 0, &r, j.JA[i].Amount)
+	}
+	tbl.AddRow() // nothing in this line, it's blank
+}
+
+func printJournalBillPayment(tbl *gotable.Table, xbiz *rlib.XBusiness, j *rlib.Journal, bp *rlib.BillPayment) {
+	v, _ := rlib.GetVendor(bp.VENDID)
+	s := fmt.Sprintf("Bill Payment #%s to %s", bp.DocNo, v.Name)
+	if len(j.Comment) > 0 {
+		s += " " + j.Comment
+	}
+	tbl.AddRow()
+	tbl.Puts(-1, 0, j.IDtoShortString())
+	tbl.Puts(-1, 1, s)
+	var r rlib.Rentable
+	for i := 0; i < len(j.JA); i++ {
+		processAcctRuleAmount(tbl, xbiz, 0, j.Dt, j.JA[i].AcctRule, 0, &r, j.JA[i].Amount)
+	}
+	tbl.AddRow() // nothing in this line, it's blank
+}
+
 func textPrintJournalReceipt(tbl *gotable.Table, ri *ReporterInfo, jctx *jprintctx, j *rlib.Journal, rcpt *rlib.Receipt) {
 	funcname := "textPrintJournalReceipt"
 	// fmt.Printf("Entered: %s,   JID = %d, RCPTID = %d\n", funcname, j.JID, rcpt.RCPTID)
@@ -383,6 +415,12 @@ func textPrintJournalEntry(tbl *gotable.Table, ri *ReporterInfo, jctx *jprintctx
 		a, _ := rlib.GetExpense(j.ID)
 		r := rlib.GetRentable(a.RID)
 		printJournalExpense(tbl, ri.Xbiz, j, &a, &r)
+	case rlib.JNLTYPEBILL:
+		b, _ := rlib.GetBill(j.ID)
+		printJournalBill(tbl, ri.Xbiz, j, &b)
+	case rlib.JNLTYPEBPMT:
+		bp, _ := rlib.GetBillPayment(j.ID)
+		printJournalBillPayment(tbl, ri.Xbiz, j, &bp)
 	default:
 		rlib.LogAndPrint("printJournalEntry: unrecognized type: %d\n", j.Type)
 	}
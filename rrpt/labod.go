@@ -2,6 +2,7 @@ package rrpt
 
 import (
 	"fmt"
+	"gotable"
 	"rentroll/rlib"
 	"time"
 )
@@ -44,3 +45,62 @@ func RAAccountActivityRangeDetail(xbiz *rlib.XBusiness, lid, raid int64, d1, d2
 	fmt.Printf("Account Balance on %10s  -  %10s\n", d2.Format(rlib.RRDATEFMT4), rlib.RRCommaf(bal2))
 	fmt.Printf("Change ---> %8.2f\n", bal2-bal1)
 }
+
+// LdgAcctBalOnDateTable returns a table with the balance of ledger lid for
+// RentalAgreement raid on date dt
+func LdgAcctBalOnDateTable(xbiz *rlib.XBusiness, lid, raid int64, dt *time.Time) gotable.Table {
+	tbl := getRRTable()
+	tbl.SetTitle("Account Balance")
+	tbl.AddColumn("Date", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Account", 10, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Name", 35, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Rental Agreement", 12, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Balance", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)
+	tbl.AddRow()
+	tbl.Putd(-1, 0, *dt)
+	tbl.Puts(-1, 1, rlib.IDtoShortString("L", lid))
+	tbl.Puts(-1, 2, rlib.RRdb.BizTypes[xbiz.P.BID].GLAccounts[lid].Name)
+	tbl.Puts(-1, 3, rlib.IDtoShortString("RA", raid))
+	tbl.Putf(-1, 4, rlib.GetRAAccountBalance(xbiz.P.BID, lid, raid, dt))
+	return tbl
+}
+
+// RAAccountActivityRangeTable returns a table of the ledger entries that
+// affect the RentalAgreement's ledger during d1-d2, with the balance on d1
+// and d2 below the header
+func RAAccountActivityRangeTable(xbiz *rlib.XBusiness, lid, raid int64, d1, d2 *time.Time) gotable.Table {
+	tbl := getRRTable()
+	tbl.SetTitle("Account Activity")
+	tbl.SetSection1(fmt.Sprintf("Rental Agreement: %s\nAccount:  %s  (%s)", rlib.IDtoShortString("RA", raid),
+		rlib.RRdb.BizTypes[xbiz.P.BID].GLAccounts[lid].Name, rlib.IDtoShortString("L", lid)))
+	tbl.SetSection2(fmt.Sprintf("%s - %s", d1.Format(rlib.RRDATEFMT4), d2.Format(rlib.RRDATEFMT4)))
+	tbl.AddColumn("Date", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Amount", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)
+	tbl.AddColumn("LEID", 10, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("JID", 10, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("JAID", 10, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Comment", 35, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	m, err := rlib.GetLedgerEntriesForRAID(d1, d2, raid, lid)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+	for i := 0; i < len(m); i++ {
+		tbl.AddRow()
+		tbl.Putd(-1, 0, m[i].Dt)
+		tbl.Putf(-1, 1, m[i].Amount)
+		tbl.Puts(-1, 2, rlib.IDtoShortString("LE", m[i].LEID))
+		tbl.Puts(-1, 3, rlib.IDtoShortString("J", m[i].JID))
+		tbl.Puts(-1, 4, rlib.IDtoShortString("JA", m[i].JAID))
+		tbl.Puts(-1, 5, m[i].Comment)
+	}
+	if len(tbl.Row) > 0 {
+		tbl.AddLineAfter(len(tbl.Row) - 1)
+		tbl.InsertSumRow(len(tbl.Row), 0, len(tbl.Row)-1, []int{1})
+	}
+	bal1 := rlib.GetRAAccountBalance(xbiz.P.BID, lid, raid, d1)
+	bal2 := rlib.GetRAAccountBalance(xbiz.P.BID, lid, raid, d2)
+	addTableNote(&tbl, fmt.Sprintf("Account Balance on %10s  -  %10s\nAccount Balance on %10s  -  %10s\nChange ---> %8.2f",
+		d1.Format(rlib.RRDATEFMT4), rlib.RRCommaf(bal1), d2.Format(rlib.RRDATEFMT4), rlib.RRCommaf(bal2), bal2-bal1))
+	return tbl
+}
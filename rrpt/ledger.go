@@ -70,6 +70,15 @@ func getLedgerEntryDescription(l *rlib.LedgerEntry) (string, string, string) {
 		return "Expense - " + reason, r.RentableName, sra
 	case rlib.JNLTYPEXFER:
 		return "Transfer", "", sra
+	case rlib.JNLTYPEBILL:
+		b, _ := rlib.GetBill(j.ID)
+		v, _ := rlib.GetVendor(b.VENDID)
+		r := rlib.GetRentable(l.RID)
+		return fmt.Sprintf("Bill %s - %s", b.DocNo, v.Name), r.RentableName, sra
+	case rlib.JNLTYPEBPMT:
+		bp, _ := rlib.GetBillPayment(j.ID)
+		v, _ := rlib.GetVendor(bp.VENDID)
+		return fmt.Sprintf("Bill Payment #%s - %s", bp.DocNo, v.Name), "", sra
 
 	default:
 		fmt.Printf("getLedgerEntryDescription: unrecognized type: %d\n", j.Type)
@@ -0,0 +1,398 @@
+package rrpt
+
+import (
+	"fmt"
+	"gotable"
+	"rentroll/rlib"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RentGLAccountName is part of the name of the gross scheduled rent account,
+// the account rent is credited to and vacancy is posted against
+const RentGLAccountName = "rent-not taxable"
+
+// OccupancyStats is the occupancy of a group of rentables over a period.
+// The rent amounts break down as GSR = ContractRent + LossToLease +
+// VacancyLoss.
+type OccupancyStats struct {
+	DtStart       time.Time // start of the period
+	DtStop        time.Time // end of the period
+	RTID          int64     // rentable type, 0 for all types
+	Rentables     int64     // number of rentables
+	UnitDays      float64   // rentable-days in the period
+	ExcludedDays  float64   // rentable-days in administrative, employee or model use
+	AvailableDays float64   // UnitDays - ExcludedDays
+	LeasedDays    float64   // available rentable-days under a rental agreement
+	GSR           float64   // gross scheduled rent
+	ContractRent  float64   // rent of the rental agreements
+	LossToLease   float64   // market rent of the leased days less the contract rent
+	VacancyLoss   float64   // market rent of the vacant days
+	Concessions   float64   // Income Offsets posted to the rentables
+	Collected     float64   // payments applied to the rentables' rent assessments
+}
+
+// add adds the values of b to s
+func (s *OccupancyStats) add(b *OccupancyStats) {
+	s.Rentables += b.Rentables
+	s.UnitDays += b.UnitDays
+	s.ExcludedDays += b.ExcludedDays
+	s.AvailableDays += b.AvailableDays
+	s.LeasedDays += b.LeasedDays
+	s.GSR += b.GSR
+	s.ContractRent += b.ContractRent
+	s.LossToLease += b.LossToLease
+	s.VacancyLoss += b.VacancyLoss
+	s.Concessions += b.Concessions
+	s.Collected += b.Collected
+}
+
+// Physical returns the physical occupancy, leased days as a percentage of
+// available days
+func (s *OccupancyStats) Physical() float64 {
+	if s.AvailableDays == 0 {
+		return 0
+	}
+	return 100 * s.LeasedDays / s.AvailableDays
+}
+
+// Economic returns the economic occupancy, collected rent as a percentage of
+// GSR
+func (s *OccupancyStats) Economic() float64 {
+	if s.GSR == 0 {
+		return 0
+	}
+	return 100 * s.Collected / s.GSR
+}
+
+// rentARs returns the account rules of business bid whose assessments are
+// rent. They credit the gross scheduled rent account, whose name contains
+// RentGLAccountName, or one of its sub accounts.
+func rentARs(bid int64) map[int64]bool {
+	gla := rlib.GetGLAccountMap(bid)
+	gsr := map[int64]bool{}
+	for lid, a := range gla {
+		if strings.Contains(strings.ToLower(a.Name), RentGLAccountName) {
+			gsr[lid] = true
+		}
+	}
+	for lid, a := range gla {
+		if gsr[a.PLID] {
+			gsr[lid] = true
+		}
+	}
+	m := map[int64]bool{}
+	ars := rlib.GetARsByType(bid, rlib.ARASSESSMENT)
+	for i := 0; i < len(ars); i++ {
+		if gsr[ars[i].CreditLID] {
+			m[ars[i].ARID] = true
+		}
+	}
+	return m
+}
+
+// occupancyExcluded is true for the use states that take a rentable out of
+// the available inventory
+func occupancyExcluded(us int64) bool {
+	return us == rlib.USESTATUSadmin || us == rlib.USESTATUSemployee || us == rlib.USESTATUSmodel
+}
+
+// occupancyMonths splits d1 - d2 into calendar months. The first and last
+// periods are partial if d1 - d2 does not start and end on a month boundary.
+func occupancyMonths(d1, d2 *time.Time) []rlib.Period {
+	var m []rlib.Period
+	for dt := *d1; dt.Before(*d2); {
+		next := time.Date(dt.Year(), dt.Month()+1, 1, 0, 0, 0, 0, dt.Location())
+		if next.After(*d2) {
+			next = *d2
+		}
+		m = append(m, rlib.Period{D1: dt, D2: next})
+		dt = next
+	}
+	return m
+}
+
+// rentableOccupancy computes the occupancy of rentable r over d1 - d2. Only
+// payments of the assessments of the account rules in rent are collected
+// rent.
+func rentableOccupancy(xbiz *rlib.XBusiness, r *rlib.Rentable, d1, d2 *time.Time, offsetLID int64, rent map[int64]bool) (OccupancyStats, error) {
+	s := OccupancyStats{DtStart: *d1, DtStop: *d2, Rentables: 1}
+	rtr := rlib.GetRentableTypeRefForDate(r.RID, d1)
+	s.RTID = rtr.RTID
+	rc := xbiz.RT[rtr.RTID].RentCycle
+	if rtr.OverrideRentCycle != 0 {
+		rc = rtr.OverrideRentCycle
+	}
+
+	//--------------------------------------------------------------
+	// unit-days, day by day
+	//--------------------------------------------------------------
+	rsa := rlib.GetRentableStatusByRange(r.RID, d1, d2)
+	rra := rlib.GetAgreementsForRentable(r.RID, d1, d2)
+	for dt := *d1; dt.Before(*d2); dt = dt.AddDate(0, 0, 1) {
+		s.UnitDays++
+		excluded := false
+		for i := 0; i < len(rsa); i++ {
+			if !dt.Before(rsa[i].DtStart) && dt.Before(rsa[i].DtStop) && occupancyExcluded(rsa[i].UseStatus) {
+				excluded = true
+				break
+			}
+		}
+		if excluded {
+			s.ExcludedDays++
+			continue
+		}
+		s.AvailableDays++
+		for i := 0; i < len(rra); i++ {
+			if !dt.Before(rra[i].RARDtStart) && dt.Before(rra[i].RARDtStop) {
+				s.LeasedDays++
+				break
+			}
+		}
+	}
+
+	//--------------------------------------------------------------
+	// rent
+	//--------------------------------------------------------------
+	gsr, _, _, err := rlib.CalculateLoadedGSR(r.BID, r.RID, d1, d2, xbiz)
+	if err != nil {
+		return s, err
+	}
+	s.GSR = gsr
+	s.VacancyLoss = rlib.VacancyGSR(xbiz, r.RID, d1, d2)
+	raids := map[int64]bool{}
+	for i := 0; i < len(rra); i++ {
+		raids[rra[i].RAID] = true
+		if rc == rlib.CYCLENORECUR {
+			continue
+		}
+		dtstart, dtstop := *d1, *d2
+		if rra[i].RARDtStart.After(dtstart) {
+			dtstart = rra[i].RARDtStart
+		}
+		if rra[i].RARDtStop.Before(dtstop) {
+			dtstop = rra[i].RARDtStop
+		}
+		if dtstop.After(dtstart) {
+			s.ContractRent += rra[i].ContractRent * float64(dtstop.Sub(dtstart)) / float64(rlib.CycleDuration(rc, dtstart))
+		}
+	}
+	s.ContractRent = rlib.RoundToCent(s.ContractRent)
+	s.LossToLease = s.GSR - s.VacancyLoss - s.ContractRent
+
+	if offsetLID > 0 {
+		m, err := rlib.GetLedgerEntriesForRentable(d1, d2, r.RID, offsetLID)
+		if err != nil {
+			return s, err
+		}
+		for i := 0; i < len(m); i++ {
+			s.Concessions += m[i].Amount
+		}
+	}
+	for raid := range raids {
+		m := rlib.GetASMReceiptAllocationsInRAIDDateRange(raid, d1, d2)
+		for i := 0; i < len(m); i++ {
+			a, err := rlib.GetAssessment(m[i].ASMID)
+			if err != nil {
+				return s, err
+			}
+			if a.RID == r.RID && rent[a.ARID] {
+				s.Collected += m[i].Amount
+			}
+		}
+	}
+	return s, nil
+}
+
+// GetOccupancyStats computes the occupancy of the business in xbiz for each
+// month of d1 - d2. For each month there is one OccupancyStats per rentable
+// type, in order of type name, followed by the total for the month with RTID
+// 0.
+func GetOccupancyStats(xbiz *rlib.XBusiness, d1, d2 *time.Time) ([]OccupancyStats, error) {
+	var t []OccupancyStats
+	bid := xbiz.P.BID
+	rlib.RRdb.BizTypes[bid].GLAccounts = rlib.GetGLAccountMap(bid)
+	offsetLID := rlib.GetLIDFromGLAccountName(bid, IncomeOffsetGLAccountName)
+	rent := rentARs(bid)
+
+	var rl []rlib.Rentable
+	rows, err := rlib.RRdb.Prepstmt.GetAllRentablesByBusiness.Query(bid)
+	if err != nil {
+		return t, err
+	}
+	for rows.Next() {
+		var r rlib.Rentable
+		if err = rlib.ReadRentables(rows, &r); err != nil {
+			rows.Close()
+			return t, err
+		}
+		rl = append(rl, r)
+	}
+	rows.Close()
+
+	months := occupancyMonths(d1, d2)
+	for i := 0; i < len(months); i++ {
+		byType := map[int64]*OccupancyStats{}
+		tot := OccupancyStats{DtStart: months[i].D1, DtStop: months[i].D2}
+		for j := 0; j < len(rl); j++ {
+			s, err := rentableOccupancy(xbiz, &rl[j], &months[i].D1, &months[i].D2, offsetLID, rent)
+			if err != nil {
+				return t, fmt.Errorf("rentable %s: %s", rl[j].RentableName, err.Error())
+			}
+			b, ok := byType[s.RTID]
+			if !ok {
+				b = &OccupancyStats{DtStart: s.DtStart, DtStop: s.DtStop, RTID: s.RTID}
+				byType[s.RTID] = b
+			}
+			b.add(&s)
+			tot.add(&s)
+		}
+		var m []OccupancyStats
+		for _, b := range byType {
+			m = append(m, *b)
+		}
+		sort.Slice(m, func(a, b int) bool { return xbiz.RT[m[a].RTID].Name < xbiz.RT[m[b].RTID].Name })
+		t = append(t, m...)
+		t = append(t, tot)
+	}
+	return t, nil
+}
+
+// occupancyColumns adds the columns shared by the occupancy reports
+func occupancyColumns(tbl *gotable.Table) {
+	tbl.AddColumn("Available Days", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT) // rentable-days available
+	tbl.AddColumn("Leased Days", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)    // rentable-days leased
+	tbl.AddColumn("Physical %", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)     // physical occupancy
+	tbl.AddColumn("GSR", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)            // gross scheduled rent
+	tbl.AddColumn("Contract Rent", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)  // rent of the rental agreements
+	tbl.AddColumn("Loss To Lease", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)  // market less contract rent
+	tbl.AddColumn("Vacancy", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)        // vacancy loss
+	tbl.AddColumn("Concessions", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)    // income offsets
+	tbl.AddColumn("Collected", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)      // rent payments received
+	tbl.AddColumn("Economic %", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)     // economic occupancy
+}
+
+// putOccupancy fills in the columns added by occupancyColumns, starting at
+// column c of the last row
+func putOccupancy(tbl *gotable.Table, c int, s *OccupancyStats) {
+	tbl.Putf(-1, c, s.AvailableDays)
+	tbl.Putf(-1, c+1, s.LeasedDays)
+	tbl.Putf(-1, c+2, s.Physical())
+	tbl.Putf(-1, c+3, s.GSR)
+	tbl.Putf(-1, c+4, s.ContractRent)
+	tbl.Putf(-1, c+5, s.LossToLease)
+	tbl.Putf(-1, c+6, s.VacancyLoss)
+	tbl.Putf(-1, c+7, s.Concessions)
+	tbl.Putf(-1, c+8, s.Collected)
+	tbl.Putf(-1, c+9, s.Economic())
+}
+
+// OccupancyReportTable generates the physical and economic occupancy of the
+// business for each month of ri.D1 - ri.D2, by rentable type, with the
+// breakdown of GSR into contract rent, loss to lease and vacancy.
+// Administrative, employee and model units are not counted as available.
+func OccupancyReportTable(ri *ReporterInfo) gotable.Table {
+	funcname := "OccupancyReportTable"
+
+	ri.RptHeaderD1 = true
+	ri.RptHeaderD2 = true
+
+	tbl := getRRTable()
+	tbl.AddColumn("Month", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)           // start of the month
+	tbl.AddColumn("Rentable Type", 20, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT) // rentable type or Total
+	tbl.AddColumn("Units", 6, gotable.CELLINT, gotable.COLJUSTIFYRIGHT)            // number of rentables
+	occupancyColumns(&tbl)
+
+	err := TableReportHeaderBlock(&tbl, "Occupancy", funcname, ri)
+	if err != nil {
+		rlib.LogAndPrintError(funcname, err)
+		return tbl
+	}
+
+	m, err := GetOccupancyStats(ri.Xbiz, &ri.D1, &ri.D2)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+	var tot OccupancyStats
+	for i := 0; i < len(m); i++ {
+		name := "Total"
+		if m[i].RTID > 0 {
+			name = ri.Xbiz.RT[m[i].RTID].Name
+		} else {
+			tot.add(&m[i])
+		}
+		tbl.AddRow()
+		tbl.Putd(-1, 0, m[i].DtStart)
+		tbl.Puts(-1, 1, name)
+		tbl.Puti(-1, 2, m[i].Rentables)
+		putOccupancy(&tbl, 3, &m[i])
+		if m[i].RTID == 0 {
+			tbl.AddLineAfter(len(tbl.Row) - 1)
+		}
+	}
+	if len(tbl.Row) == 0 {
+		tbl.SetSection3(NoRecordsFoundMsg)
+		return tbl
+	}
+	tbl.AddRow()
+	tbl.Putd(-1, 0, ri.D1)
+	tbl.Puts(-1, 1, "Period Total")
+	tbl.Puti(-1, 2, m[len(m)-1].Rentables) // units at the end of the period
+	putOccupancy(&tbl, 3, &tot)
+	return tbl
+}
+
+// OccupancyReport returns a string version of the occupancy report
+func OccupancyReport(ri *ReporterInfo) string {
+	tbl := OccupancyReportTable(ri)
+	return ReportToString(&tbl, ri)
+}
+
+// OccupancyTrendTable generates one row per month of ri.D1 - ri.D2 with the
+// occupancy of the whole business. It is meant to be written as CSV and
+// charted.
+func OccupancyTrendTable(ri *ReporterInfo) gotable.Table {
+	funcname := "OccupancyTrendTable"
+
+	ri.RptHeaderD1 = true
+	ri.RptHeaderD2 = true
+
+	tbl := getRRTable()
+	tbl.AddColumn("Month", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT) // start of the month
+	tbl.AddColumn("Units", 6, gotable.CELLINT, gotable.COLJUSTIFYRIGHT)  // number of rentables
+	occupancyColumns(&tbl)
+
+	err := TableReportHeaderBlock(&tbl, "Occupancy Trend", funcname, ri)
+	if err != nil {
+		rlib.LogAndPrintError(funcname, err)
+		return tbl
+	}
+
+	m, err := GetOccupancyStats(ri.Xbiz, &ri.D1, &ri.D2)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+	for i := 0; i < len(m); i++ {
+		if m[i].RTID != 0 {
+			continue
+		}
+		tbl.AddRow()
+		tbl.Putd(-1, 0, m[i].DtStart)
+		tbl.Puti(-1, 1, m[i].Rentables)
+		putOccupancy(&tbl, 2, &m[i])
+	}
+	if len(tbl.Row) == 0 {
+		tbl.SetSection3(NoRecordsFoundMsg)
+	}
+	return tbl
+}
+
+// OccupancyTrendReport returns a string version of the occupancy trend
+// report
+func OccupancyTrendReport(ri *ReporterInfo) string {
+	tbl := OccupancyTrendTable(ri)
+	return ReportToString(&tbl, ri)
+}
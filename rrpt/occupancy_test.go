@@ -0,0 +1,92 @@
+// +build sqlite
+
+package rrpt
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+)
+
+func TestOccupancyReport(t *testing.T) {
+	b, ri := newTestReporter(t, rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1))
+	addCharge(t, b, "Rent", rrtest.Dt(2017, 3, 1), 1000, 2, 1000, rrtest.Dt(2017, 3, 3))
+	addCharge(t, b, "Late Fee", rrtest.Dt(2017, 3, 6), 50, 2, 50, rrtest.Dt(2017, 3, 6)) // not rent
+
+	tbl := OccupancyReportTable(ri)
+	if s := tbl.GetSection3(); s != "" {
+		t.Fatalf("unexpected error: %s", s)
+	}
+	// the rentable type, the total for March, and the period total
+	if len(tbl.Row) != 3 {
+		t.Fatalf("expect 3 rows, got %d", len(tbl.Row))
+	}
+	if cells(&tbl, 0, 1) != "Flat Studio" || cells(&tbl, 1, 1) != "Total" || cells(&tbl, 2, 1) != "Period Total" {
+		t.Errorf("expect rows Flat Studio, Total, Period Total, got %q %q %q", cells(&tbl, 0, 1), cells(&tbl, 1, 1), cells(&tbl, 2, 1))
+	}
+
+	// 101 is leased at the market rate, 102 and 103 are vacant
+	expect := []struct {
+		col    int
+		name   string
+		expect float64
+	}{
+		{3, "Available Days", 93},
+		{4, "Leased Days", 31},
+		{5, "Physical %", 33.33},
+		{6, "GSR", 3000},
+		{7, "Contract Rent", 1000},
+		{8, "Loss To Lease", 0},
+		{9, "Vacancy", 2000},
+		{11, "Collected", 1000},
+		{12, "Economic %", 33.33},
+	}
+	for r := 0; r < len(tbl.Row); r++ {
+		for _, x := range expect {
+			if got := rlib.RoundToCent(cellf(&tbl, r, x.col)); got != x.expect {
+				t.Errorf("%s %s: expect %.2f, got %.2f", cells(&tbl, r, 1), x.name, x.expect, got)
+			}
+		}
+	}
+}
+
+func TestOccupancyTrend(t *testing.T) {
+	b, ri := newTestReporter(t, rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 6, 1))
+	addCharge(t, b, "Rent", rrtest.Dt(2017, 3, 1), 1000, 2, 1000, rrtest.Dt(2017, 3, 3))
+	addCharge(t, b, "Rent", rrtest.Dt(2017, 4, 1), 1000, 1, 400, rrtest.Dt(2017, 4, 3))
+	addCharge(t, b, "Security Deposit", rrtest.Dt(2017, 4, 1), 500, 2, 500, rrtest.Dt(2017, 4, 3)) // not rent
+
+	tbl := OccupancyTrendTable(ri)
+	if s := tbl.GetSection3(); s != "" {
+		t.Fatalf("unexpected error: %s", s)
+	}
+	expect := []struct {
+		month               string
+		days, leased, rent  float64
+		collected, economic float64
+	}{
+		{"2017-03", 93, 31, 1000, 1000, 33.33},
+		{"2017-04", 90, 30, 1000, 400, 13.33},
+		{"2017-05", 93, 31, 1000, 0, 0},
+	}
+	if len(tbl.Row) != len(expect) {
+		t.Fatalf("expect %d rows, got %d", len(expect), len(tbl.Row))
+	}
+	for i, x := range expect {
+		if m := tbl.Row[i].Col[0].Dval.Format("2006-01"); m != x.month {
+			t.Errorf("row %d: expect %s, got %s", i, x.month, m)
+		}
+		if u := tbl.Row[i].Col[1].Ival; u != 3 {
+			t.Errorf("%s: expect 3 units, got %d", x.month, u)
+		}
+		got := []float64{cellf(&tbl, i, 2), cellf(&tbl, i, 3), cellf(&tbl, i, 4), cellf(&tbl, i, 6), cellf(&tbl, i, 10), rlib.RoundToCent(cellf(&tbl, i, 11))}
+		want := []float64{x.days, x.leased, 33.33, x.rent, x.collected, x.economic}
+		got[2] = rlib.RoundToCent(got[2])
+		for j := range want {
+			if got[j] != want[j] {
+				t.Errorf("%s: expect %v, got %v", x.month, want, got)
+				break
+			}
+		}
+	}
+}
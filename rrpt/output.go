@@ -0,0 +1,176 @@
+package rrpt
+
+import (
+	"encoding/json"
+	"fmt"
+	"gotable"
+	"io"
+	"rentroll/rlib"
+	"strings"
+	"time"
+)
+
+// TABLEOUTJSON is the report output format for json.  Like xlsx, it is
+// written here rather than by gotable.
+const TABLEOUTJSON = 6
+
+// ReportOutputFormats maps the name of each report output format to its
+// value, as in the rof web service parameter
+var ReportOutputFormats = map[string]int{
+	"text": gotable.TABLEOUTTEXT,
+	"html": gotable.TABLEOUTHTML,
+	"csv":  gotable.TABLEOUTCSV,
+	"pdf":  gotable.TABLEOUTPDF,
+	"xlsx": TABLEOUTXLSX,
+	"json": TABLEOUTJSON,
+}
+
+// JSONTable is the json form of a gotable.Table.  Each row is an object
+// whose keys are the column titles.  Int and float cells are numbers, date
+// cells are strings of the form 2018-02-15, and datetime cells are RFC 3339
+// strings.  TotalRows lists the rows that follow a line, the subtotal and
+// total rows, so that a script can skip them.
+type JSONTable struct {
+	Title     string                   `json:"title"`
+	Section1  string                   `json:"section1,omitempty"`
+	Section2  string                   `json:"section2,omitempty"`
+	Section3  string                   `json:"section3,omitempty"`
+	Columns   []string                 `json:"columns"`
+	Rows      []map[string]interface{} `json:"rows"`
+	TotalRows []int                    `json:"totalRows,omitempty"`
+}
+
+// JSONReport is the json form of a report made of one or more tables
+type JSONReport struct {
+	Status string      `json:"status"`
+	Tables []JSONTable `json:"tables"`
+}
+
+// jsonColumnKeys returns the key of each column of t in a row object.  It is
+// the column title, made unique by adding the column number to empty and
+// repeated titles.
+func jsonColumnKeys(t *gotable.Table) []string {
+	var keys []string
+	used := map[string]bool{}
+	for j := 0; j < len(t.ColDefs); j++ {
+		k := strings.TrimSpace(t.ColDefs[j].ColTitle)
+		if len(k) == 0 || used[k] {
+			k = fmt.Sprintf("%s_%d", k, j+1)
+		}
+		used[k] = true
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// jsonCellValue returns the value of c as it is written in json
+func jsonCellValue(c *gotable.Cell) interface{} {
+	switch c.Type {
+	case gotable.CELLINT:
+		return c.Ival
+	case gotable.CELLFLOAT:
+		return c.Fval
+	case gotable.CELLDATE:
+		if c.Dval.IsZero() {
+			return nil
+		}
+		return c.Dval.Format(rlib.RRDATEINPFMT)
+	case gotable.CELLDATETIME:
+		if c.Dval.IsZero() {
+			return nil
+		}
+		return c.Dval.Format(time.RFC3339)
+	}
+	return c.Sval
+}
+
+// GetJSONTable returns the json form of t
+func GetJSONTable(t *gotable.Table) JSONTable {
+	jt := JSONTable{
+		Title:    strings.TrimSpace(t.Title),
+		Section1: strings.TrimSpace(t.Section1),
+		Section2: strings.TrimSpace(t.Section2),
+		Section3: strings.TrimSpace(t.Section3),
+		Columns:  jsonColumnKeys(t),
+		Rows:     []map[string]interface{}{},
+	}
+	total := map[int]bool{}
+	for _, k := range t.LineAfter {
+		total[k+1] = true
+	}
+	for _, k := range t.LineBefore {
+		total[k] = true
+	}
+	for i := 0; i < len(t.Row); i++ {
+		r := map[string]interface{}{}
+		for j := 0; j < len(jt.Columns) && j < len(t.Row[i].Col); j++ {
+			r[jt.Columns[j]] = jsonCellValue(&t.Row[i].Col[j])
+		}
+		jt.Rows = append(jt.Rows, r)
+		if total[i] {
+			jt.TotalRows = append(jt.TotalRows, i)
+		}
+	}
+	return jt
+}
+
+// JSONprintTable writes t to w as a json report with one table
+func JSONprintTable(t *gotable.Table, w io.Writer) error {
+	return MultiTableJSONPrint([]gotable.Table{*t}, w)
+}
+
+// MultiTableJSONPrint writes the tables in m to w as one json report
+func MultiTableJSONPrint(m []gotable.Table, w io.Writer) error {
+	r := JSONReport{Status: "success", Tables: []JSONTable{}}
+	for i := 0; i < len(m); i++ {
+		r.Tables = append(r.Tables, GetJSONTable(&m[i]))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&r)
+}
+
+// WriteTable writes tbl to w in the output format rof
+func WriteTable(w io.Writer, tbl *gotable.Table, rof int) error {
+	switch rof {
+	case gotable.TABLEOUTTEXT:
+		return tbl.TextprintTable(w)
+	case gotable.TABLEOUTHTML:
+		return tbl.HTMLprintTable(w)
+	case gotable.TABLEOUTCSV:
+		return tbl.CSVprintTable(w)
+	case gotable.TABLEOUTPDF:
+		pdfProps := GetReportPDFProps()
+		pdfProps = SetPDFOption(pdfProps, "--header-center", tbl.Title)
+		pdfProps = SetPDFOption(pdfProps, "--page-width", "11in")
+		pdfProps = SetPDFOption(pdfProps, "--page-height", "8.5in")
+		return tbl.PDFprintTable(w, pdfProps)
+	case TABLEOUTXLSX:
+		return XLSXprintTable(tbl, w)
+	case TABLEOUTJSON:
+		return JSONprintTable(tbl, w)
+	}
+	return fmt.Errorf("unknown output format: %d", rof)
+}
+
+// WriteTables writes the tables of a multi-table report to w in the output
+// format rof.  title is the page header of pdf output.
+func WriteTables(w io.Writer, m []gotable.Table, rof int, title string) error {
+	switch rof {
+	case gotable.TABLEOUTTEXT:
+		gotable.MultiTableTextPrint(m, w)
+	case gotable.TABLEOUTHTML:
+		gotable.MultiTableHTMLPrint(m, w)
+	case gotable.TABLEOUTCSV:
+		gotable.MultiTableCSVPrint(m, w)
+	case gotable.TABLEOUTPDF:
+		MultiTablePDFPrint(m, w, title, 11, 8.5, "in")
+	case TABLEOUTXLSX:
+		return MultiTableXLSXPrint(m, w)
+	case TABLEOUTJSON:
+		return MultiTableJSONPrint(m, w)
+	default:
+		return fmt.Errorf("unknown output format: %d", rof)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package rrpt
+
+import (
+	"bytes"
+	"encoding/json"
+	"gotable"
+	"testing"
+	"time"
+)
+
+func TestGetJSONTable(t *testing.T) {
+	tbl := getRRTable()
+	tbl.SetTitle("REX Journal\n")
+	tbl.SetSection1("  January 2018 ")
+	tbl.AddColumn("Name", 10, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("", 6, gotable.CELLINT, gotable.COLJUSTIFYRIGHT)
+	tbl.AddColumn("Name", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)
+	tbl.AddColumn("Date", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Created", 20, gotable.CELLDATETIME, gotable.COLJUSTIFYLEFT)
+	tbl.AddRow()
+	tbl.Puts(-1, 0, "101")
+	tbl.Puti(-1, 1, 3)
+	tbl.Putf(-1, 2, 1000.5)
+	tbl.Putd(-1, 3, time.Date(2018, 1, 15, 0, 0, 0, 0, time.UTC))
+	tbl.Putdt(-1, 4, time.Date(2018, 1, 15, 10, 30, 0, 0, time.UTC))
+	tbl.AddLineAfter(0)
+	tbl.AddRow()
+	tbl.Puts(-1, 0, "Total")
+	tbl.Putf(-1, 2, 1000.5)
+
+	jt := GetJSONTable(&tbl)
+	if jt.Title != "REX Journal" || jt.Section1 != "January 2018" || jt.Section3 != "" {
+		t.Errorf("expect trimmed sections, got %q %q %q", jt.Title, jt.Section1, jt.Section3)
+	}
+	cols := []string{"Name", "_2", "Name_3", "Date", "Created"}
+	if len(jt.Columns) != len(cols) {
+		t.Fatalf("expect columns %v, got %v", cols, jt.Columns)
+	}
+	for i := range cols {
+		if jt.Columns[i] != cols[i] {
+			t.Errorf("expect columns %v, got %v", cols, jt.Columns)
+			break
+		}
+	}
+	if len(jt.Rows) != 2 || len(jt.TotalRows) != 1 || jt.TotalRows[0] != 1 {
+		t.Fatalf("expect 2 rows, the second a total, got %d rows, totals %v", len(jt.Rows), jt.TotalRows)
+	}
+	r := jt.Rows[0]
+	if r["Name"] != "101" || r["_2"] != int64(3) || r["Name_3"] != 1000.5 || r["Date"] != "2018-01-15" || r["Created"] != "2018-01-15T10:30:00Z" {
+		t.Errorf("unexpected row %v", r)
+	}
+	if jt.Rows[1]["Date"] != nil {
+		t.Errorf("expect a zero date to be null, got %v", jt.Rows[1]["Date"])
+	}
+
+	var buf bytes.Buffer
+	if err := MultiTableJSONPrint([]gotable.Table{tbl, tbl}, &buf); err != nil {
+		t.Fatalf("MultiTableJSONPrint: %s", err.Error())
+	}
+	var rpt JSONReport
+	if err := json.Unmarshal(buf.Bytes(), &rpt); err != nil || rpt.Status != "success" || len(rpt.Tables) != 2 {
+		t.Errorf("expect a success report with 2 tables, got %s", buf.String())
+	}
+}
@@ -19,6 +19,12 @@ import (
 //             false = external view (do not show Unapplied Funds section)
 //============================================================================
 func PayorStatement(bid, tcid int64, d1, d2 *time.Time, internal bool) gotable.Table {
+	return payorStatementTable(bid, tcid, d1, d2, internal, nil)
+}
+
+// payorStatementTable builds the statement of PayorStatement.  If psi is not
+// nil it is used as the statement information rather than reading it again.
+func payorStatementTable(bid, tcid int64, d1, d2 *time.Time, internal bool, psi *rlib.PayorStatementInfo) gotable.Table {
 	var t gotable.Table
 	var xbiz rlib.XBusiness
 
@@ -86,11 +92,15 @@ func PayorStatement(bid, tcid int64, d1, d2 *time.Time, internal bool) gotable.T
 	section1 += fmt.Sprintf("Period: %s - %s <br>\n%s", d1.Format(rlib.RRDATEREPORTFMT), d2.Format(rlib.RRDATEREPORTFMT), addr)
 	t.SetSection1(section1)
 
-	m, err := rlib.PayorsStatement(bid, payors, d1, d2)
-	if err != nil {
-		t.SetSection3("Error from PayorsStatement: " + err.Error())
-		return t
+	if psi == nil {
+		m, err := rlib.PayorsStatement(bid, payors, d1, d2)
+		if err != nil {
+			t.SetSection3("Error from PayorsStatement: " + err.Error())
+			return t
+		}
+		psi = &m
 	}
+	m := *psi
 
 	//------------------------------------------------------
 	// Generate the Receipt Summary
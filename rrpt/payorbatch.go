@@ -0,0 +1,319 @@
+package rrpt
+
+import (
+	"fmt"
+	"gotable"
+	"io"
+	"os"
+	"path/filepath"
+	"rentroll/rlib"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatementPageWidth and StatementPageHeight give the page size, in inches,
+// of printed payor statements
+const (
+	StatementPageWidth  = 8.5
+	StatementPageHeight = 11
+)
+
+// StatementPageBreakCSS starts the title of a table on a new page. It starts
+// each payor's statement in a batch of statements on a page of its own.
+var StatementPageBreakCSS = []*gotable.CSSProperty{
+	{Name: "page-break-before", Value: "always"},
+}
+
+// PayorStatementGroup holds the tables that make up the printed statement of
+// one payor: the statement with the business letterhead and the payor's
+// mailing address, the aging summary, and the remittance slip.
+type PayorStatementGroup struct {
+	TCID   int64           // the payor
+	Name   string          // payor name
+	Due    float64         // amount due at the end of the period
+	Tables []gotable.Table // the tables of the statement, in print order
+}
+
+// buildingAddress returns the address of b, one line per element
+func buildingAddress(b *rlib.Building) string {
+	var sa []string
+	if len(b.Address) > 0 {
+		sa = append(sa, b.Address)
+	}
+	if len(b.Address2) > 0 {
+		sa = append(sa, b.Address2)
+	}
+	s := b.City
+	if len(b.State) > 0 {
+		if len(s) > 0 {
+			s += ", "
+		}
+		s += b.State
+	}
+	if len(b.PostalCode) > 0 {
+		s += " " + b.PostalCode
+	}
+	if len(strings.TrimSpace(s)) > 0 {
+		sa = append(sa, strings.TrimSpace(s))
+	}
+	if len(b.Country) > 0 {
+		sa = append(sa, b.Country)
+	}
+	return strings.Join(sa, "\n")
+}
+
+// statementLetterhead returns the letterhead of the statements of business
+// xbiz: its name followed by the address of its first building.
+func statementLetterhead(xbiz *rlib.XBusiness) (string, error) {
+	s := xbiz.P.Name
+	if len(s) == 0 {
+		s = xbiz.P.Designation
+	}
+	m, err := rlib.GetBusinessBuildings(xbiz.P.BID)
+	if err != nil {
+		return s, err
+	}
+	if len(m) > 0 {
+		if a := buildingAddress(&m[0]); len(a) > 0 {
+			s += "\n" + a
+		}
+	}
+	return s, nil
+}
+
+// payorHasActivity returns true if the statement m of payor tcid has any
+// assessments or receipt allocations, a balance, or a receipt from the payor
+func payorHasActivity(m *rlib.PayorStatementInfo, tcid int64) bool {
+	for i := 0; i < len(m.RAB); i++ {
+		if len(m.RAB[i].Stmt) > 0 || rlib.RoundToCent(m.RAB[i].OpeningBal) != 0 || rlib.RoundToCent(m.RAB[i].ClosingBal) != 0 {
+			return true
+		}
+	}
+	for i := 0; i < len(m.RL); i++ {
+		if m.RL[i].R.TCID == tcid {
+			return true
+		}
+	}
+	return false
+}
+
+// payorReceiptStatement returns the statement of payor tcid who made
+// receipts in the period d1 - d2 but is not a payor of any rental agreement
+// in it. The statement lists the receipts only.
+func payorReceiptStatement(bid, tcid int64, d1, d2 *time.Time) rlib.PayorStatementInfo {
+	var m rlib.PayorStatementInfo
+	rl := rlib.GetReceipts(bid, d1, d2)
+	for i := 0; i < len(rl); i++ {
+		if rl[i].TCID != tcid || rl[i].FLAGS&rlib.RCPTREVERSED != 0 {
+			continue
+		}
+		_, alloc, unalloc := rlib.GetReceiptAllocationAmountsOnDate(rl[i].RCPTID, d2)
+		m.RL = append(m.RL, rlib.ReceiptListEntry{R: rl[i], Allocated: alloc, Unallocated: unalloc})
+	}
+	return m
+}
+
+// GetPayorStatementGroups builds the statement of every payor of the
+// business with activity or a balance in the period ri.D1 - ri.D2, in order
+// of payor name. A payor who made receipts in the period but is not a payor
+// of any rental agreement in it gets a statement of the receipts.
+//
+// The query parameters in ri.QueryParams, if any, are:
+//     tcid     = build the statement of this payor only
+//     internal = true to show the unapplied funds of other payors
+//
+// RETURNS
+//  the statements
+//  any error encountered
+//-----------------------------------------------------------------------------
+func GetPayorStatementGroups(ri *ReporterInfo) ([]PayorStatementGroup, error) {
+	var g []PayorStatementGroup
+	var tcid int64
+	internal := false
+	if ri.QueryParams != nil {
+		tcid, _ = strconv.ParseInt(ri.QueryParams.Get("tcid"), 10, 64)
+		internal, _ = strconv.ParseBool(ri.QueryParams.Get("internal"))
+	}
+	bid := ri.Xbiz.P.BID
+	letterhead, err := statementLetterhead(ri.Xbiz)
+	if err != nil {
+		return g, err
+	}
+
+	payors := []int64{tcid}
+	if tcid == 0 {
+		if payors, err = rlib.GetStatementPayors(bid, &ri.D1, &ri.D2); err != nil {
+			return g, err
+		}
+	}
+	dtStmt := ri.D2.AddDate(0, 0, -1) // last day of the period
+	payorcache := map[int64]rlib.Transactant{}
+	for i := 0; i < len(payors); i++ {
+		m, err := rlib.PayorsStatement(bid, []int64{payors[i]}, &ri.D1, &ri.D2)
+		if err != nil {
+			if len(m.RAB) > 0 {
+				return g, err
+			}
+			m = payorReceiptStatement(bid, payors[i], &ri.D1, &ri.D2) // no rental agreements
+		}
+		if !payorHasActivity(&m, payors[i]) {
+			continue
+		}
+		var tr rlib.Transactant
+		if err = rlib.GetTransactant(payors[i], &tr); err != nil {
+			return g, err
+		}
+		p := PayorStatementGroup{TCID: payors[i], Name: rlib.GetNameFromTransactantCache(payors[i], payorcache)}
+
+		//------------------------------------------------------
+		// the statement, with letterhead and mailing address
+		//------------------------------------------------------
+		t := payorStatementTable(bid, payors[i], &ri.D1, &ri.D2, internal, &m)
+		t.SetTitle(letterhead)
+		t.SetSection1(fmt.Sprintf("%s\n%s", p.Name, tr.SingleLineAddress()))
+		t.SetSection2(fmt.Sprintf("Statement  %s - %s", ri.D1.Format(rlib.RRDATEREPORTFMT), dtStmt.Format(rlib.RRDATEREPORTFMT)))
+
+		//------------------------------------------------------
+		// aging of the amount due
+		//------------------------------------------------------
+		var a rlib.StatementAging
+		var ras []string
+		for j := 0; j < len(m.RAB); j++ {
+			b := rlib.GetStatementAging(&m.RAB[j])
+			a.Add(&b)
+			ras = append(ras, rlib.IDtoShortString("RA", m.RAB[j].RAID))
+		}
+		p.Due = rlib.RoundToCent(a.Total())
+		aging := getRRTable()
+		aging.SetTitle("Account Aging")
+		aging.AddColumn("Current", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)
+		aging.AddColumn("30 Days", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)
+		aging.AddColumn("60 Days", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)
+		aging.AddColumn("Over 90 Days", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)
+		aging.AddColumn("Amount Due", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)
+		aging.AddRow()
+		aging.Putf(-1, 0, a.Current)
+		aging.Putf(-1, 1, a.Over30)
+		aging.Putf(-1, 2, a.Over60)
+		aging.Putf(-1, 3, a.Over90)
+		aging.Putf(-1, 4, p.Due)
+
+		//------------------------------------------------------
+		// the remittance slip
+		//------------------------------------------------------
+		slip := getRRTable()
+		slip.SetTitle("- - - - - - - - - -  Please detach and return this portion with your payment  - - - - - - - - - -")
+		slip.SetSection1("Remit to:\n" + letterhead)
+		slip.AddColumn("Payor", 25, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+		slip.AddColumn("Account", 10, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+		slip.AddColumn("Rental Agreements", 20, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+		slip.AddColumn("Statement Date", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)
+		slip.AddColumn("Amount Due", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)
+		slip.AddColumn("Amount Enclosed", 15, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+		slip.AddRow()
+		slip.Puts(-1, 0, p.Name)
+		slip.Puts(-1, 1, rlib.IDtoShortString("TC", payors[i]))
+		slip.Puts(-1, 2, strings.Join(ras, ", "))
+		slip.Putd(-1, 3, dtStmt)
+		slip.Putf(-1, 4, p.Due)
+		slip.Puts(-1, 5, "$_____________")
+
+		p.Tables = []gotable.Table{t, aging, slip}
+		g = append(g, p)
+	}
+	sort.SliceStable(g, func(i, j int) bool { return strings.ToLower(g[i].Name) < strings.ToLower(g[j].Name) })
+	return g, nil
+}
+
+// PayorStatementBatchTable returns the tables of the statements of all the
+// payors with activity or a balance in the period, one group of tables per
+// payor.  See GetPayorStatementGroups.
+func PayorStatementBatchTable(ri *ReporterInfo) []gotable.Table {
+	const funcname = "PayorStatementBatchTable"
+	var m []gotable.Table
+	ri.RptHeaderD1 = true
+	ri.RptHeaderD2 = true
+	g, err := GetPayorStatementGroups(ri)
+	if err != nil {
+		rlib.LogAndPrintError(funcname, err)
+		tbl := getRRTable()
+		tbl.SetTitle("Payor Statements")
+		tbl.SetSection3(err.Error())
+		return append(m, tbl)
+	}
+	for i := 0; i < len(g); i++ {
+		m = append(m, g[i].Tables...)
+	}
+	return m
+}
+
+// PayorStatementBatchPDF writes the statements of all the payors with
+// activity or a balance in the period to w as a single PDF. Each statement
+// starts on a new page.
+func PayorStatementBatchPDF(ri *ReporterInfo, w io.Writer) error {
+	g, err := GetPayorStatementGroups(ri)
+	if err != nil {
+		return err
+	}
+	m := payorStatementPages(g)
+	MultiTablePDFPrint(m, w, "Statement", StatementPageWidth, StatementPageHeight, "in")
+	return nil
+}
+
+// payorStatementPages returns the tables of the statements in g, in print
+// order, with a page break before each statement but the first
+func payorStatementPages(g []PayorStatementGroup) []gotable.Table {
+	var m []gotable.Table
+	for i := 0; i < len(g); i++ {
+		if i > 0 && len(g[i].Tables) > 0 {
+			g[i].Tables[0].SetTitleCSS(StatementPageBreakCSS)
+		}
+		m = append(m, g[i].Tables...)
+	}
+	return m
+}
+
+// PayorStatementPDFs writes the statement of each payor with activity or a
+// balance in the period to a PDF file of its own in directory dir.
+//
+// RETURNS
+//  the names of the files written
+//  any error encountered
+//-----------------------------------------------------------------------------
+func PayorStatementPDFs(ri *ReporterInfo, dir string) ([]string, error) {
+	var files []string
+	g, err := GetPayorStatementGroups(ri)
+	if err != nil {
+		return files, err
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return files, err
+	}
+	for i := 0; i < len(g); i++ {
+		fname := filepath.Join(dir, fmt.Sprintf("%s-%s-Statement-From%sTo%s.pdf", ri.Xbiz.P.Designation, rlib.IDtoShortString("TC", g[i].TCID), GetAttachmentDate(ri.D1), GetAttachmentDate(ri.D2.AddDate(0, 0, -1))))
+		fp, err := os.Create(fname)
+		if err != nil {
+			return files, err
+		}
+		MultiTablePDFPrint(g[i].Tables, fp, "Statement", StatementPageWidth, StatementPageHeight, "in")
+		if err = fp.Close(); err != nil {
+			return files, err
+		}
+		files = append(files, fname)
+	}
+	return files, nil
+}
+
+// PayorStatementBatchReport returns a string version of the statements of
+// all the payors with activity or a balance in the period
+func PayorStatementBatchReport(ri *ReporterInfo) string {
+	var s string
+	m := PayorStatementBatchTable(ri)
+	for _, tbl := range m {
+		s += ReportToString(&tbl, ri) + "\n"
+	}
+	return s
+}
+
@@ -0,0 +1,58 @@
+// +build sqlite
+
+package rrpt
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"testing"
+)
+
+// A payor who made a receipt but is not a payor of any rental agreement
+// gets a statement of the receipt, and every statement in a batch but the
+// first starts on a new page
+func TestPayorStatementGroups(t *testing.T) {
+	b, ri := newTestReporter(t, rrtest.Dt(2017, 3, 1), rrtest.Dt(2017, 4, 1))
+	addCharge(t, b, "Rent", rrtest.Dt(2017, 3, 1), 1000, 0, 0, rrtest.Dt(2017, 3, 1))
+	p := rlib.Transactant{BID: b.BID, FirstName: "Zoe", LastName: "Walker"}
+	tcid, err := rlib.InsertTransactant(&p)
+	if err != nil {
+		t.Fatalf("InsertTransactant: %s", err.Error())
+	}
+	if _, err = rlib.InsertPayor(&rlib.Payor{TCID: tcid, BID: b.BID}); err != nil {
+		t.Fatalf("InsertPayor: %s", err.Error())
+	}
+	r := rlib.Receipt{BID: b.BID, TCID: tcid, PMTID: b.PMTID, ARID: b.ARID["Receive Payment"], Dt: rrtest.Dt(2017, 3, 10), Amount: 250, DocNo: "1234"}
+	if _, err = rlib.InsertReceipt(&r); err != nil {
+		t.Fatalf("InsertReceipt: %s", err.Error())
+	}
+
+	g, err := GetPayorStatementGroups(ri)
+	if err != nil {
+		t.Fatalf("GetPayorStatementGroups: %s", err.Error())
+	}
+	if len(g) != 2 || g[0].TCID != b.TCID || g[1].TCID != tcid {
+		t.Fatalf("expect statements for TCIDs %d and %d, got %+v", b.TCID, tcid, g)
+	}
+	if g[0].Due != 1000 || g[1].Due != 0 {
+		t.Errorf("expect 1000.00 and 0.00 due, got %.2f and %.2f", g[0].Due, g[1].Due)
+	}
+	s := &g[1].Tables[0]
+	if e := s.GetSection3(); e != "" {
+		t.Fatalf("unexpected error: %s", e)
+	}
+	found := false
+	for i := 0; i < len(s.Row); i++ {
+		if cells(s, i, 5) == rlib.IDtoShortString("RCPT", r.RCPTID) && cellf(s, i, 7) == 250 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expect the receipt of 250.00 with 250.00 unapplied on the statement of %d", tcid)
+	}
+
+	m := payorStatementPages(g)
+	if len(m) != len(g[0].Tables)+len(g[1].Tables) {
+		t.Errorf("expect the tables of both statements, got %d", len(m))
+	}
+}
@@ -0,0 +1,88 @@
+package rrpt
+
+import (
+	"fmt"
+	"gotable"
+	"rentroll/rlib"
+)
+
+// postingProblem is the short description of each kind of posting problem
+var postingProblem = map[int]string{
+	rlib.POSTMISSINGLE:  "Missing ledger entry",
+	rlib.POSTEXTRALE:    "Extra ledger entry",
+	rlib.POSTAMOUNT:     "Ledger amount",
+	rlib.POSTNOJOURNAL:  "Not journaled",
+	rlib.POSTVACANCY:    "Vacancy",
+	rlib.POSTDIRTYRANGE: "Vacancy not recomputed",
+}
+
+// PostingVerificationTable generates a table of the differences between the
+// journal and ledger records of the business over ri.D1 - ri.D2 and what a
+// full rebuild would produce. Nothing in the database is changed. If the
+// records could not be read the error is shown in the table.
+func PostingVerificationTable(ri *ReporterInfo) gotable.Table {
+	tbl, err := PostingVerification(ri)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+	}
+	return tbl
+}
+
+// PostingVerification is PostingVerificationTable for callers that must
+// know that the verification failed, such as the command line. When the
+// error is not nil the table has no rows and does not mean that the
+// posting is correct.
+func PostingVerification(ri *ReporterInfo) (gotable.Table, error) {
+	funcname := "PostingVerification"
+
+	ri.RptHeaderD1 = true
+	ri.RptHeaderD2 = true
+
+	tbl := getRRTable()
+	tbl.AddColumn("Date", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)       // date of the record
+	tbl.AddColumn("Problem", 22, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)  // kind of problem
+	tbl.AddColumn("Journal", 12, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)  // journal entry
+	tbl.AddColumn("Rentable", 12, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT) // rentable
+	tbl.AddColumn("Account", 12, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)  // GL account number
+	tbl.AddColumn("Expected", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT) // amount a rebuild would give
+	tbl.AddColumn("Actual", 12, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)   // amount in the database
+	tbl.AddColumn("Comment", 50, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)  // description
+
+	err := TableReportHeaderBlock(&tbl, "Posting Verification", funcname, ri)
+	if err != nil {
+		rlib.LogAndPrintError(funcname, err)
+		return tbl, err
+	}
+
+	m, err := rlib.VerifyPosting(ri.Xbiz, &ri.D1, &ri.D2)
+	if err != nil {
+		rlib.LogAndPrintError(funcname, err)
+		return tbl, err
+	}
+	bid := ri.Xbiz.P.BID
+	rlib.RRdb.BizTypes[bid].GLAccounts = rlib.GetGLAccountMap(bid)
+	for i := 0; i < len(m); i++ {
+		tbl.AddRow()
+		tbl.Putd(-1, 0, m[i].Dt)
+		tbl.Puts(-1, 1, postingProblem[m[i].Kind])
+		tbl.Puts(-1, 2, rlib.IDtoShortString("J", m[i].JID))
+		tbl.Puts(-1, 3, rlib.IDtoShortString("R", m[i].RID))
+		tbl.Puts(-1, 4, rlib.RRdb.BizTypes[bid].GLAccounts[m[i].LID].GLNumber)
+		tbl.Putf(-1, 5, m[i].Expected)
+		tbl.Putf(-1, 6, m[i].Actual)
+		tbl.Puts(-1, 7, m[i].Comment)
+	}
+	if len(tbl.Row) == 0 {
+		addTableNote(&tbl, "no differences found, posting matches a full rebuild")
+		return tbl, nil
+	}
+	addTableNote(&tbl, fmt.Sprintf("%d differences found", len(tbl.Row)))
+	return tbl, nil
+}
+
+// PostingVerificationReport returns a string version of the posting
+// verification report
+func PostingVerificationReport(ri *ReporterInfo) string {
+	tbl := PostingVerificationTable(ri)
+	return ReportToString(&tbl, ri)
+}
@@ -10,12 +10,27 @@ import (
 // RentableMarketRates prints a report of the rentable rid's rent rates between d1 and d2
 func RentableMarketRates(xbiz *rlib.XBusiness, rid int64, d1, d2 *time.Time) {
 	r := rlib.GetRentable(rid)
-	m := rlib.GetRentableTypeRefsByRange(r.RID, d1, d2)
-
 	fmt.Printf("RENTABLE RENT RATES\nRentable: %s  (%s)\nPeriod %s - %s\n\n", r.RentableName, r.IDtoString(), d1.Format(rlib.RRDATEFMT4), d2.Format(rlib.RRDATEFMT4))
+	tbl := RentableMarketRatesTable(xbiz, rid, d1, d2)
+	tbl.SetTitle("")
+	tbl.SetSection1("")
+	s, err := tbl.SprintTable()
+	if err != nil {
+		s += err.Error()
+	}
+	fmt.Print(s)
+}
+
+// RentableMarketRatesTable returns a table of the rentable rid's rent rates
+// between d1 and d2
+func RentableMarketRatesTable(xbiz *rlib.XBusiness, rid int64, d1, d2 *time.Time) gotable.Table {
+	r := rlib.GetRentable(rid)
+	m := rlib.GetRentableTypeRefsByRange(r.RID, d1, d2)
 
 	// table init
 	tbl := getRRTable()
+	tbl.SetTitle("Rentable Rent Rates")
+	tbl.SetSection1(fmt.Sprintf("Rentable: %s  (%s)\nPeriod %s - %s", r.RentableName, r.IDtoString(), d1.Format(rlib.RRDATEFMT4), d2.Format(rlib.RRDATEFMT4)))
 
 	tbl.AddColumn("Start", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)
 	tbl.AddColumn("Stop", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)
@@ -64,9 +79,5 @@ func RentableMarketRates(xbiz *rlib.XBusiness, rid int64, d1, d2 *time.Time) {
 	}
 
 	tbl.TightenColumns()
-	s, err := tbl.SprintTable()
-	if err != nil {
-		s += err.Error()
-	}
-	fmt.Print(s)
+	return tbl
 }
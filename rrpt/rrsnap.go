@@ -0,0 +1,176 @@
+package rrpt
+
+import (
+	"fmt"
+	"gotable"
+	"rentroll/rlib"
+)
+
+// RRSnapshotTable returns the rentroll saved in the rent roll snapshot of
+// the business for the period ri.D1 - ri.D2, exactly as it was when the
+// snapshot was taken.
+func RRSnapshotTable(ri *ReporterInfo) gotable.Table {
+	const funcname = "RRSnapshotTable"
+	tbl := getRRTable()
+
+	ri.RptHeaderD1 = true
+	ri.RptHeaderD2 = true
+	err := TableReportHeaderBlock(&tbl, "Rentroll Snapshot", funcname, ri)
+	if err != nil {
+		rlib.LogAndPrintError(funcname, err)
+		return tbl
+	}
+	rrTableAddColumns(&tbl)
+
+	a, err := rlib.GetRentRollSnapshotByPeriod(ri.Bid, &ri.D1, &ri.D2)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+	if a.RRSID == 0 {
+		tbl.SetSection3(fmt.Sprintf("There is no rent roll snapshot for %s - %s", ri.D1.Format(rlib.RRDATEREPORTFMT), ri.D2.Format(rlib.RRDATEREPORTFMT)))
+		return tbl
+	}
+	rows, err := rlib.GetRentRollSnapshotRows(&a)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+	rrTableAddRows(&tbl, rows)
+	addTableNote(&tbl, fmt.Sprintf("Snapshot %s taken %s", rlib.IDtoShortString("RRS", a.RRSID), a.CreateTS.In(rlib.RRdb.Zone).Format(rlib.RRDATETIMEINPFMT)))
+	return tbl
+}
+
+// RRVarianceTable compares the rent roll snapshot of the business for the
+// period ri.D1 - ri.D2 with the snapshot of the period before it and lists
+// the move-ins, move-outs, rent changes and receivable changes by unit.
+func RRVarianceTable(ri *ReporterInfo) gotable.Table {
+	const funcname = "RRVarianceTable"
+	tbl := getRRTable()
+
+	ri.RptHeaderD1 = true
+	ri.RptHeaderD2 = true
+	err := TableReportHeaderBlock(&tbl, "Rentroll Variance", funcname, ri)
+	if err != nil {
+		rlib.LogAndPrintError(funcname, err)
+		return tbl
+	}
+
+	const (
+		RName    = 0
+		RType    = iota
+		Change   = iota
+		PrevRA   = iota
+		PrevPay  = iota
+		CurRA    = iota
+		CurPay   = iota
+		PrevGSR  = iota
+		CurGSR   = iota
+		DeltaGSR = iota
+		PrevRcv  = iota
+		CurRcv   = iota
+		DeltaRcv = iota
+	)
+	tbl.AddColumn("Rentable", 20, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)                  // rentable name
+	tbl.AddColumn("Rentable Type", 15, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)             // rentable type name
+	tbl.AddColumn("Change", 25, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)                    // move-in, move-out, ...
+	tbl.AddColumn("Previous Rental Agreement", 10, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT) // occupant at the end of the earlier period
+	tbl.AddColumn("Previous Payors", 30, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)           // its payors
+	tbl.AddColumn("Rental Agreement", 10, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)          // occupant at the end of the period
+	tbl.AddColumn("Payors", 30, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)                    // its payors
+	tbl.AddColumn("Previous GSR", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)              // rent cycle GSR in the earlier period
+	tbl.AddColumn("GSR", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)                       // rent cycle GSR
+	tbl.AddColumn("Change In GSR", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)             // difference
+	tbl.AddColumn("Previous Receivable", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)       // receivable at the end of the earlier period
+	tbl.AddColumn("Receivable", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)                // receivable at the end of the period
+	tbl.AddColumn("Change In Receivable", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)      // difference
+
+	cur, err := rlib.GetRentRollSnapshotByPeriod(ri.Bid, &ri.D1, &ri.D2)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+	if cur.RRSID == 0 {
+		tbl.SetSection3(fmt.Sprintf("There is no rent roll snapshot for %s - %s", ri.D1.Format(rlib.RRDATEREPORTFMT), ri.D2.Format(rlib.RRDATEREPORTFMT)))
+		return tbl
+	}
+	prev, err := rlib.GetRentRollSnapshotBefore(ri.Bid, &ri.D1)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+	if prev.RRSID == 0 {
+		tbl.SetSection3(fmt.Sprintf("There is no rent roll snapshot for a period that ends by %s", ri.D1.Format(rlib.RRDATEREPORTFMT)))
+		return tbl
+	}
+	prows, err := rlib.GetRentRollSnapshotRows(&prev)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+	crows, err := rlib.GetRentRollSnapshotRows(&cur)
+	if err != nil {
+		tbl.SetSection3(err.Error())
+		return tbl
+	}
+	m := rlib.GetRentRollVariance(rlib.RentRollSnapshotUnits(prows, &prev.DtStop), rlib.RentRollSnapshotUnits(crows, &cur.DtStop))
+
+	var moveins, moveouts int
+	var dGSR, dRcv float64
+	for i := 0; i < len(m); i++ {
+		u := m[i].Unit()
+		tbl.AddRow()
+		tbl.Puts(-1, RName, u.Rentable)
+		tbl.Puts(-1, RType, u.RentableType)
+		tbl.Puts(-1, Change, rlib.RentRollVarianceKindString(m[i].Kind))
+		tbl.Puts(-1, PrevRA, rrVarianceRA(m[i].Prev.RAID))
+		tbl.Puts(-1, PrevPay, m[i].Prev.Payors)
+		tbl.Puts(-1, CurRA, rrVarianceRA(m[i].Cur.RAID))
+		tbl.Puts(-1, CurPay, m[i].Cur.Payors)
+		tbl.Putf(-1, PrevGSR, m[i].Prev.GSR)
+		tbl.Putf(-1, CurGSR, m[i].Cur.GSR)
+		tbl.Putf(-1, DeltaGSR, m[i].Cur.GSR-m[i].Prev.GSR)
+		tbl.Putf(-1, PrevRcv, m[i].Prev.EndReceivable)
+		tbl.Putf(-1, CurRcv, m[i].Cur.EndReceivable)
+		tbl.Putf(-1, DeltaRcv, m[i].Cur.EndReceivable-m[i].Prev.EndReceivable)
+		if m[i].Kind&rlib.RRVARMOVEIN != 0 {
+			moveins++
+		}
+		if m[i].Kind&rlib.RRVARMOVEOUT != 0 {
+			moveouts++
+		}
+		dGSR += m[i].Cur.GSR - m[i].Prev.GSR
+		dRcv += m[i].Cur.EndReceivable - m[i].Prev.EndReceivable
+	}
+	if len(m) > 0 {
+		tbl.AddLineAfter(len(tbl.Row) - 1)
+		tbl.AddRow()
+		tbl.Puts(-1, RName, "Total")
+		tbl.Puts(-1, Change, fmt.Sprintf("%d move-ins, %d move-outs", moveins, moveouts))
+		tbl.Putf(-1, DeltaGSR, dGSR)
+		tbl.Putf(-1, DeltaRcv, dRcv)
+	}
+	addTableNote(&tbl, fmt.Sprintf("Compared with snapshot %s of %s - %s", rlib.IDtoShortString("RRS", prev.RRSID), prev.DtStart.Format(rlib.RRDATEREPORTFMT), prev.DtStop.Format(rlib.RRDATEREPORTFMT)))
+	return tbl
+}
+
+// rrVarianceRA returns the rental agreement id as shown in the variance
+// report, blank if the unit was vacant
+func rrVarianceRA(raid int64) string {
+	if raid == 0 {
+		return ""
+	}
+	return rlib.IDtoShortString("RA", raid)
+}
+
+// RRVarianceReport returns a string version of the rentroll variance report
+func RRVarianceReport(ri *ReporterInfo) string {
+	tbl := RRVarianceTable(ri)
+	return ReportToString(&tbl, ri)
+}
+
+// RRSnapshotReport returns a string version of a rent roll snapshot
+func RRSnapshotReport(ri *ReporterInfo) string {
+	tbl := RRSnapshotTable(ri)
+	return ReportToString(&tbl, ri)
+}
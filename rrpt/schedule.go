@@ -0,0 +1,245 @@
+package rrpt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gotable"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"rentroll/rlib"
+	"strings"
+	"time"
+)
+
+// ReportScheduleMaxErrLength is the longest error saved in a ReportSchedule
+const ReportScheduleMaxErrLength = 256
+
+// ReportManifestName is the name of the manifest file in each directory of
+// the report archive
+const ReportManifestName = "manifest.json"
+
+// ReportOutputExt is the file extension for each report output format
+var ReportOutputExt = map[int]string{
+	gotable.TABLEOUTTEXT: "txt",
+	gotable.TABLEOUTHTML: "html",
+	gotable.TABLEOUTCSV:  "csv",
+	gotable.TABLEOUTPDF:  "pdf",
+	TABLEOUTXLSX:         "xlsx",
+	TABLEOUTJSON:         "json",
+}
+
+// ReportManifestEntry describes a file written by a scheduled report. The
+// manifest of an archive directory is a json list of these.
+type ReportManifestEntry struct {
+	RSID       int64     // the schedule
+	Schedule   string    // name of the schedule
+	BUD        string    // business
+	Report     string    // report name
+	DtStart    string    // start of the report period
+	DtStop     string    // end of the report period, not included
+	File       string    // file name, in the same directory as the manifest
+	Size       int64     // bytes in the file
+	SHA256     string    // hex sha256 of the file
+	Created    time.Time // when the file was written
+	Recipients string    // who it was sent to
+	Emailed    bool      // true if the mail was sent
+}
+
+// RunDueReportSchedules runs all the active report schedules whose NextRun
+// has arrived, then saves each schedule with its LastRun, LastError and next
+// run time.  A schedule with no cycle runs once and is then marked inactive.
+//
+// INPUTS
+//    now = the current time
+//
+// RETURNS
+//    the number of schedules run
+//    any error reading the schedules.  Errors running a schedule are saved
+//    in its LastError.
+//-----------------------------------------------------------------------------
+func RunDueReportSchedules(now time.Time) (int, error) {
+	funcname := "RunDueReportSchedules"
+	m, err := rlib.GetDueReportSchedules(&now)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(m); i++ {
+		rs := &m[i]
+		rs.LastError = ""
+		if err = RunReportSchedule(rs, now); err != nil {
+			rlib.Ulog("%s: schedule %d (%s): %s\n", funcname, rs.RSID, rs.Name, err.Error())
+			rs.LastError = err.Error()
+			if len(rs.LastError) > ReportScheduleMaxErrLength {
+				rs.LastError = rs.LastError[:ReportScheduleMaxErrLength]
+			}
+		}
+		rs.LastRun = now
+		rs.NextRun = rlib.NextReportRun(rs, now)
+		if rs.NextRun.IsZero() {
+			rs.NextRun = rs.LastRun
+			rs.FLAGS |= rlib.REPORTSCHEDINACTIVE
+		}
+		if err = rlib.UpdateReportSchedule(rs); err != nil {
+			rlib.LogAndPrintError(funcname, err)
+		}
+	}
+	return len(m), nil
+}
+
+// RunReportSchedule generates the report of rs for the period given by its
+// date rule, writes it to a directory for the business and the run date in
+// the report archive, adds it to the manifest of that directory, and mails
+// it to the recipients of rs.
+//
+// INPUTS
+//    rs  = the schedule
+//    now = time of the run
+//
+// RETURNS
+//    any error encountered
+//-----------------------------------------------------------------------------
+func RunReportSchedule(rs *rlib.ReportSchedule, now time.Time) error {
+	funcname := "RunReportSchedule"
+	if len(rlib.RRConfig.ReportArchive) == 0 {
+		return fmt.Errorf("%s: no ReportArchive directory in config.json", funcname)
+	}
+	tsh, tmh := FindReportHandler(rs.Report)
+	if !tsh.Found && !tmh.Found {
+		return fmt.Errorf("%s: unknown report: %s", funcname, rs.Report)
+	}
+	ext, ok := ReportOutputExt[int(rs.OutputFormat)]
+	if !ok {
+		return fmt.Errorf("%s: unknown output format: %d", funcname, rs.OutputFormat)
+	}
+	qp, err := url.ParseQuery(rs.Params)
+	if err != nil {
+		return fmt.Errorf("%s: bad Params: %s", funcname, err.Error())
+	}
+
+	var xbiz rlib.XBusiness
+	rlib.GetXBusiness(rs.BID, &xbiz)
+	if xbiz.P.BID == 0 {
+		return fmt.Errorf("%s: business %d not found", funcname, rs.BID)
+	}
+	rlib.InitBizInternals(rs.BID, &xbiz)
+	ri := ReporterInfo{
+		OutputFormat:          int(rs.OutputFormat),
+		Bid:                   rs.BID,
+		Xbiz:                  &xbiz,
+		BlankLineAfterRptName: true,
+		QueryParams:           &qp,
+	}
+	ri.D1, ri.D2 = rlib.ReportScheduleRange(rs.DateRule, now.In(rlib.RRdb.Zone))
+	if len(qp.Get("bizlist")) > 0 {
+		if ri.BIDList, err = rlib.GetBusinessListFromSpec(qp.Get("bizlist")); err != nil {
+			return err
+		}
+	}
+
+	// the run's files go in <ReportArchive>/<Directory>/<BUD>/<run date>
+	dir := filepath.Join(rlib.RRConfig.ReportArchive, rs.Directory, xbiz.P.Designation, now.In(rlib.RRdb.Zone).Format(rlib.RRDATEINPFMT))
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var name, title string
+	if tsh.Found {
+		name, title = tsh.ReportNames[1], strings.Title(tsh.ReportNames[1])
+	} else {
+		name, title = tmh.ReportNames[1], tmh.ReportTitle
+	}
+	fname := fmt.Sprintf("RS%d-%s-%s-From%sTo%s.%s", rs.RSID, xbiz.P.Designation, strings.Replace(strings.Title(name), " ", "", -1), GetAttachmentDate(ri.D1), GetAttachmentDate(ri.D2), ext)
+	path := filepath.Join(dir, fname)
+
+	fp, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if tsh.Found {
+		tbl := tsh.TableHandler(&ri)
+		err = WriteTable(fp, &tbl, ri.OutputFormat)
+	} else {
+		m := tmh.TableHandler(&ri)
+		err = WriteTables(fp, m, ri.OutputFormat, title)
+	}
+	if err1 := fp.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		return err
+	}
+
+	e := ReportManifestEntry{
+		RSID:       rs.RSID,
+		Schedule:   rs.Name,
+		BUD:        xbiz.P.Designation,
+		Report:     rs.Report,
+		DtStart:    ri.D1.Format(rlib.RRDATEINPFMT),
+		DtStop:     ri.D2.Format(rlib.RRDATEINPFMT),
+		File:       fname,
+		Created:    now,
+		Recipients: rs.Recipients,
+	}
+	if e.Size, e.SHA256, err = reportFileHash(path); err != nil {
+		return err
+	}
+
+	// the file is in the archive, a mail problem is reported but the entry is
+	// still added to the manifest
+	to := rlib.ReportScheduleRecipients(rs.Recipients)
+	var mailErr error
+	if len(to) > 0 {
+		subject := fmt.Sprintf("%s %s  %s - %s", xbiz.P.Designation, title, ri.D1.Format(rlib.RRDATEREPORTFMT), ri.D2.AddDate(0, 0, -1).Format(rlib.RRDATEREPORTFMT))
+		body := fmt.Sprintf("The attached report was generated by the RentRoll report schedule %q on %s.\n", rs.Name, now.In(rlib.RRdb.Zone).Format(rlib.RRDATETIMEINPFMT))
+		mailErr = rlib.SendMail(to, subject, body, []string{path})
+		e.Emailed = mailErr == nil
+	}
+	if err = addToReportManifest(dir, &e); err != nil {
+		return err
+	}
+	return mailErr
+}
+
+// reportFileHash returns the size and hex sha256 of the file at path
+func reportFileHash(path string) (int64, string, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer fp.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, fp)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// addToReportManifest appends e to the manifest in dir, creating the
+// manifest if needed. The manifest is rewritten through a temporary file so
+// that a reader never sees a partial list.
+func addToReportManifest(dir string, e *ReportManifestEntry) error {
+	var m []ReportManifestEntry
+	fname := filepath.Join(dir, ReportManifestName)
+	b, err := ioutil.ReadFile(fname)
+	if err == nil {
+		if err = json.Unmarshal(b, &m); err != nil {
+			return fmt.Errorf("%s: %s", fname, err.Error())
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	m = append(m, *e)
+	if b, err = json.MarshalIndent(m, "", "    "); err != nil {
+		return err
+	}
+	tmp := fname + ".tmp"
+	if err = ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fname)
+}
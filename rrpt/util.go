@@ -130,6 +130,7 @@ type ReporterInfo struct {
 	Handler               func(*ReporterInfo) string
 	Xbiz                  *rlib.XBusiness // may not be set in all cases
 	QueryParams           *url.Values
+	BIDList               []int64 // businesses to include in a consolidated report, all if empty
 }
 
 // TableReportHeader returns a title block of text for a report. The format is:
@@ -312,6 +313,16 @@ func getRRTable() gotable.Table {
 	return tbl
 }
 
+// addTableNote adds line s to Section2 of tbl, below the report header.
+// Section3 is kept for errors.
+func addTableNote(tbl *gotable.Table, s string) {
+	n := strings.TrimRight(tbl.Section2, "\n")
+	if len(n) > 0 {
+		n += "\n"
+	}
+	tbl.SetSection2(n + s + "\n")
+}
+
 // MultiTablePDFPrint writes pdf output from each table to w io.Writer
 func MultiTablePDFPrint(m []gotable.Table, w io.Writer, pdfTitle string, pdfPageWidth float64, pdfPageHeight float64, pdfPageSizeUnit string) {
 
@@ -0,0 +1,28 @@
+// +build sqlite
+
+package rrpt
+
+import (
+	"gotable"
+	"rentroll/rrtest"
+	"testing"
+	"time"
+)
+
+// newTestReporter opens a new test database with one business in it and
+// returns a ReporterInfo for the business covering d1 up to d2
+func newTestReporter(t *testing.T, d1, d2 time.Time) (*rrtest.Biz, *ReporterInfo) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	return b, &ReporterInfo{Bid: b.BID, Xbiz: &b.XBiz, D1: d1, D2: d2}
+}
+
+// cellf returns the float value in row r, column c of tbl
+func cellf(tbl *gotable.Table, r, c int) float64 {
+	return tbl.Row[r].Col[c].Fval
+}
+
+// cells returns the string value in row r, column c of tbl
+func cells(tbl *gotable.Table, r, c int) string {
+	return tbl.Row[r].Col[c].Sval
+}
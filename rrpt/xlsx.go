@@ -0,0 +1,377 @@
+package rrpt
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"gotable"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TABLEOUTXLSX is the report output format for Excel workbooks. gotable
+// handles text, html, pdf and csv itself (1 - 4), xlsx is written here.
+const TABLEOUTXLSX = 5
+
+// Cell styles, these are indexes into cellXfs in xlsxStylesXML. Each data
+// style has a total-row version, xlsxTotal higher, that is bold with a line
+// above it.
+const (
+	xlsxStyleGeneral  = 0
+	xlsxStyleInt      = 1
+	xlsxStyleFloat    = 2
+	xlsxStyleDate     = 3
+	xlsxStyleDateTime = 4
+	xlsxTotal         = 5
+	xlsxStyleTitle    = 10
+	xlsxStyleHeader   = 11
+)
+
+// xlsxMaxSheetName is the longest sheet name Excel accepts
+const xlsxMaxSheetName = 31
+
+// xlsxEpoch is day 0 of Excel's date serial numbers
+var xlsxEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// xlsxCell is one cell of a worksheet. Numbers and dates are written as
+// numbers in v, everything else as an inline string in s.
+type xlsxCell struct {
+	s       string  // string value
+	v       float64 // numeric value, dates as Excel serial numbers
+	numeric bool    // true if v is the value
+	style   int     // index into cellXfs
+}
+
+// xlsxSheet is a worksheet built from a gotable.Table
+type xlsxSheet struct {
+	name   string       // sheet tab name
+	widths []int        // column widths in characters
+	rows   [][]xlsxCell // rows, nil for a blank row
+	freeze int          // rows above the data that stay in view when scrolling
+}
+
+// XLSXprintTable writes t to w as an Excel workbook with a single sheet.
+func XLSXprintTable(t *gotable.Table, w io.Writer) error {
+	return MultiTableXLSXPrint([]gotable.Table{*t}, w)
+}
+
+// MultiTableXLSXPrint writes the tables in m to w as one Excel workbook with
+// a sheet for each table.
+//
+// Each sheet starts with the table's header block, the Title, Section1 and
+// Section2 lines set by TableReportHeaderBlock, followed by the column
+// headings and the rows. Int and float cells are written as numbers, date
+// cells as Excel dates, and a row that follows a line (AddLineAfter) is a
+// subtotal or total row and is shown bold with a line above it. Section3,
+// the error or summary section, follows the rows.
+func MultiTableXLSXPrint(m []gotable.Table, w io.Writer) error {
+	var sheets []xlsxSheet
+	used := map[string]bool{}
+	for i := 0; i < len(m); i++ {
+		sh := xlsxSheetFromTable(&m[i])
+		sh.name = xlsxSheetName(m[i].Title, i+1, used)
+		sheets = append(sheets, sh)
+	}
+	if len(sheets) == 0 {
+		sheets = append(sheets, xlsxSheet{name: "Sheet1"})
+	}
+
+	z := zip.NewWriter(w)
+	parts := []struct {
+		name string
+		body string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes(len(sheets))},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook(sheets)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels(len(sheets))},
+		{"xl/styles.xml", xlsxStylesXML},
+	}
+	for _, p := range parts {
+		f, err := z.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < len(sheets); i++ {
+		f, err := z.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err = xlsxWriteSheet(f, &sheets[i]); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// xlsxSheetFromTable lays out the header block, column headings, rows and
+// Section3 of t as worksheet rows.
+func xlsxSheetFromTable(t *gotable.Table) xlsxSheet {
+	var sh xlsxSheet
+	ncols := len(t.ColDefs)
+
+	for _, ln := range xlsxLines(t.Title) {
+		sh.rows = append(sh.rows, []xlsxCell{{s: ln, style: xlsxStyleTitle}})
+	}
+	for _, sec := range []string{t.Section1, t.Section2} {
+		for _, ln := range xlsxLines(sec) {
+			sh.rows = append(sh.rows, []xlsxCell{{s: ln}})
+		}
+	}
+	if len(sh.rows) > 0 {
+		sh.rows = append(sh.rows, nil)
+	}
+
+	hdr := make([]xlsxCell, ncols)
+	for j := 0; j < ncols; j++ {
+		hdr[j] = xlsxCell{s: t.ColDefs[j].ColTitle, style: xlsxStyleHeader}
+		wid := t.ColDefs[j].Width
+		if n := len(t.ColDefs[j].ColTitle); n > wid {
+			wid = n
+		}
+		sh.widths = append(sh.widths, wid)
+	}
+	sh.rows = append(sh.rows, hdr)
+	sh.freeze = len(sh.rows)
+
+	// rows following a line are subtotal or total rows
+	total := map[int]bool{}
+	for _, k := range t.LineAfter {
+		total[k+1] = true
+	}
+	for _, k := range t.LineBefore {
+		total[k] = true
+	}
+
+	for i := 0; i < len(t.Row); i++ {
+		r := make([]xlsxCell, ncols)
+		for j := 0; j < ncols && j < len(t.Row[i].Col); j++ {
+			r[j] = xlsxCellFromTable(&t.Row[i].Col[j])
+			if total[i] {
+				r[j].style += xlsxTotal
+			}
+		}
+		sh.rows = append(sh.rows, r)
+	}
+
+	if s3 := xlsxLines(t.Section3); len(s3) > 0 {
+		sh.rows = append(sh.rows, nil)
+		for _, ln := range s3 {
+			sh.rows = append(sh.rows, []xlsxCell{{s: ln}})
+		}
+	}
+	return sh
+}
+
+// xlsxCellFromTable converts a gotable cell, keeping its type
+func xlsxCellFromTable(c *gotable.Cell) xlsxCell {
+	switch c.Type {
+	case gotable.CELLINT:
+		return xlsxCell{v: float64(c.Ival), numeric: true, style: xlsxStyleInt}
+	case gotable.CELLFLOAT:
+		return xlsxCell{v: c.Fval, numeric: true, style: xlsxStyleFloat}
+	case gotable.CELLDATE:
+		if c.Dval.IsZero() {
+			return xlsxCell{}
+		}
+		return xlsxCell{v: xlsxDateSerial(c.Dval), numeric: true, style: xlsxStyleDate}
+	case gotable.CELLDATETIME:
+		if c.Dval.IsZero() {
+			return xlsxCell{}
+		}
+		return xlsxCell{v: xlsxDateSerial(c.Dval), numeric: true, style: xlsxStyleDateTime}
+	}
+	return xlsxCell{s: c.Sval}
+}
+
+// xlsxDateSerial returns the Excel serial number for the wall clock time of
+// dt, days since xlsxEpoch with the time of day as the fraction.
+func xlsxDateSerial(dt time.Time) float64 {
+	y, m, d := dt.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	secs := dt.Hour()*3600 + dt.Minute()*60 + dt.Second()
+	return float64(day.Sub(xlsxEpoch)/(24*time.Hour)) + float64(secs)/86400
+}
+
+// xlsxLines splits a header section into lines, dropping blank lines at the
+// start and end
+func xlsxLines(s string) []string {
+	s = strings.Trim(s, "\n")
+	if len(strings.TrimSpace(s)) == 0 {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// xlsxTruncate returns the first n characters of s
+func xlsxTruncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// xlsxSheetName makes a valid, unique sheet name from a table title. n is
+// the sheet number, used when the title is empty.
+func xlsxSheetName(title string, n int, used map[string]bool) string {
+	s := strings.Map(func(r rune) rune {
+		switch r {
+		case '[', ']', ':', '*', '?', '/', '\\', '\n', '\r', '\t':
+			return ' '
+		}
+		return r
+	}, strings.TrimSpace(title))
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.Trim(s, "'")
+	if len(s) == 0 {
+		s = fmt.Sprintf("Sheet%d", n)
+	}
+	s = strings.TrimSpace(xlsxTruncate(s, xlsxMaxSheetName))
+	name := s
+	for k := 2; used[strings.ToLower(name)]; k++ {
+		sfx := fmt.Sprintf(" (%d)", k)
+		name = strings.TrimSpace(xlsxTruncate(s, xlsxMaxSheetName-len(sfx))) + sfx
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// xlsxColName returns the column letters for the 0-based column j
+func xlsxColName(j int) string {
+	s := ""
+	for j++; j > 0; j = (j - 1) / 26 {
+		s = string(rune('A'+(j-1)%26)) + s
+	}
+	return s
+}
+
+// xlsxWriteSheet writes the worksheet xml for sh to w
+func xlsxWriteSheet(w io.Writer, sh *xlsxSheet) error {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if sh.freeze > 0 {
+		fmt.Fprintf(&b, `<sheetViews><sheetView workbookViewId="0"><pane ySplit="%d" topLeftCell="A%d" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`, sh.freeze, sh.freeze+1)
+	}
+	if len(sh.widths) > 0 {
+		b.WriteString("<cols>")
+		for j, wid := range sh.widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, j+1, j+1, wid+2)
+		}
+		b.WriteString("</cols>")
+	}
+	b.WriteString("<sheetData>")
+	for i, r := range sh.rows {
+		if len(r) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, c := range r {
+			ref := xlsxColName(j) + strconv.Itoa(i+1)
+			switch {
+			case c.numeric:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, c.style, strconv.FormatFloat(c.v, 'f', -1, 64))
+			case len(c.s) > 0:
+				fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, c.style)
+				if err := xml.EscapeText(&b, []byte(c.s)); err != nil {
+					return err
+				}
+				b.WriteString("</t></is></c>")
+			case c.style != xlsxStyleGeneral:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"/>`, ref, c.style)
+			}
+		}
+		b.WriteString("</row>")
+	}
+	b.WriteString("</sheetData></worksheet>")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// xlsxContentTypes returns [Content_Types].xml for a workbook of n sheets
+func xlsxContentTypes(n int) string {
+	s := xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`
+	for i := 1; i <= n; i++ {
+		s += fmt.Sprintf(`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	return s + "</Types>"
+}
+
+// xlsxRootRels points the package at the workbook
+const xlsxRootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// xlsxWorkbook returns xl/workbook.xml listing the sheets
+func xlsxWorkbook(sheets []xlsxSheet) string {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i := 0; i < len(sheets); i++ {
+		b.WriteString(`<sheet name="`)
+		xml.EscapeText(&b, []byte(sheets[i].name))
+		fmt.Fprintf(&b, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	b.WriteString("</sheets></workbook>")
+	return b.String()
+}
+
+// xlsxWorkbookRels returns xl/_rels/workbook.xml.rels. Sheets are rId1 - rIdn,
+// the styles are rIdn+1.
+func xlsxWorkbookRels(n int) string {
+	s := xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`
+	for i := 1; i <= n; i++ {
+		s += fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	s += fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, n+1)
+	return s + "</Relationships>"
+}
+
+// xlsxStylesXML defines the number formats, fonts, borders and the cell
+// styles listed in the xlsxStyle constants, in the same order.
+const xlsxStylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="3">` +
+	`<numFmt numFmtId="164" formatCode="#,##0.00"/>` +
+	`<numFmt numFmtId="165" formatCode="yyyy\-mm\-dd"/>` +
+	`<numFmt numFmtId="166" formatCode="yyyy\-mm\-dd\ hh:mm"/>` +
+	`</numFmts>` +
+	`<fonts count="3">` +
+	`<font><sz val="11"/><name val="Calibri"/></font>` +
+	`<font><b/><sz val="11"/><name val="Calibri"/></font>` +
+	`<font><b/><sz val="14"/><name val="Calibri"/></font>` +
+	`</fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="3">` +
+	`<border><left/><right/><top/><bottom/><diagonal/></border>` +
+	`<border><left/><right/><top style="thin"/><bottom/><diagonal/></border>` +
+	`<border><left/><right/><top/><bottom style="thin"/><diagonal/></border>` +
+	`</borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="12">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="1" xfId="0" applyFont="1" applyBorder="1"/>` +
+	`<xf numFmtId="3" fontId="1" fillId="0" borderId="1" xfId="0" applyNumberFormat="1" applyFont="1" applyBorder="1"/>` +
+	`<xf numFmtId="164" fontId="1" fillId="0" borderId="1" xfId="0" applyNumberFormat="1" applyFont="1" applyBorder="1"/>` +
+	`<xf numFmtId="165" fontId="1" fillId="0" borderId="1" xfId="0" applyNumberFormat="1" applyFont="1" applyBorder="1"/>` +
+	`<xf numFmtId="166" fontId="1" fillId="0" borderId="1" xfId="0" applyNumberFormat="1" applyFont="1" applyBorder="1"/>` +
+	`<xf numFmtId="0" fontId="2" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="2" xfId="0" applyFont="1" applyBorder="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
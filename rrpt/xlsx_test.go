@@ -0,0 +1,146 @@
+package rrpt
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"gotable"
+	"io/ioutil"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestXLSXSheetName(t *testing.T) {
+	used := map[string]bool{}
+	m := []struct {
+		title, expect string
+	}{
+		{"Rent Roll", "Rent Roll"},
+		{"rent roll", "rent roll (2)"},
+		{"", "Sheet3"},
+		{"a/b: [c]*?", "a b c"},
+		{"Mieteinnahmen für Geschäftsräume und Wohnungen", "Mieteinnahmen für Geschäftsräum"},
+		{"Mieteinnahmen für Geschäftsräume im Süden", "Mieteinnahmen für Geschäfts (2)"},
+	}
+	for i, x := range m {
+		s := xlsxSheetName(x.title, i+1, used)
+		if s != x.expect {
+			t.Errorf("%q: expect %q, got %q", x.title, x.expect, s)
+		}
+		if !utf8.ValidString(s) || utf8.RuneCountInString(s) > xlsxMaxSheetName {
+			t.Errorf("%q: %q is not a valid sheet name", x.title, s)
+		}
+	}
+}
+
+// xlsxTestCell is a cell of a worksheet as it is read back
+type xlsxTestCell struct {
+	R string `xml:"r,attr"`
+	S int    `xml:"s,attr"`
+	V string `xml:"v"`
+	T string `xml:"is>t"`
+}
+
+// xlsxTestSheet is a worksheet as it is read back
+type xlsxTestSheet struct {
+	Rows []struct {
+		R     int            `xml:"r,attr"`
+		Cells []xlsxTestCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestMultiTableXLSXPrint(t *testing.T) {
+	tbl := getRRTable()
+	tbl.SetTitle("Übersicht der Mieteinnahmen im Geschäftsjahr 2017")
+	tbl.AddColumn("Unit", 10, gotable.CELLSTRING, gotable.COLJUSTIFYLEFT)
+	tbl.AddColumn("Days", 6, gotable.CELLINT, gotable.COLJUSTIFYRIGHT)
+	tbl.AddColumn("Rent", 10, gotable.CELLFLOAT, gotable.COLJUSTIFYRIGHT)
+	tbl.AddColumn("Date", 10, gotable.CELLDATE, gotable.COLJUSTIFYLEFT)
+	tbl.AddRow()
+	tbl.Puts(-1, 0, "101 & 102")
+	tbl.Puti(-1, 1, 31)
+	tbl.Putf(-1, 2, 1234.5)
+	tbl.Putd(-1, 3, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC))
+	tbl.AddLineAfter(0)
+	tbl.AddRow()
+	tbl.Puts(-1, 0, "Total")
+	tbl.Putf(-1, 2, 1234.5)
+	tbl.SetSection3("1 warning")
+
+	var buf bytes.Buffer
+	if err := MultiTableXLSXPrint([]gotable.Table{tbl, tbl}, &buf); err != nil {
+		t.Fatalf("MultiTableXLSXPrint: %s", err.Error())
+	}
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("the workbook is not a zip file: %s", err.Error())
+	}
+	parts := map[string][]byte{}
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("%s: %s", f.Name, err.Error())
+		}
+		parts[f.Name], _ = ioutil.ReadAll(r)
+		r.Close()
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("expect part %s", name)
+		}
+	}
+	for name, b := range parts {
+		if err = xml.Unmarshal(b, new(struct{})); err != nil {
+			t.Errorf("%s is not well formed: %s", name, err.Error())
+		}
+	}
+
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err = xml.Unmarshal(parts["xl/workbook.xml"], &wb); err != nil {
+		t.Fatalf("workbook.xml: %s", err.Error())
+	}
+	if len(wb.Sheets) != 2 || wb.Sheets[0].Name != "Übersicht der Mieteinnahmen im" || wb.Sheets[1].Name != "Übersicht der Mieteinnahmen (2)" {
+		t.Errorf("expect two sheets with names cut to 31 characters, got %+v", wb.Sheets)
+	}
+
+	var sh xlsxTestSheet
+	if err = xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sh); err != nil {
+		t.Fatalf("sheet1.xml: %s", err.Error())
+	}
+	cells := map[string]xlsxTestCell{}
+	for _, r := range sh.Rows {
+		for _, c := range r.Cells {
+			cells[c.R] = c
+		}
+	}
+	// title, blank row, headings on row 3, then the rows and section 3
+	expect := []struct {
+		ref, text, value string
+		style            int
+	}{
+		{"A1", "Übersicht der Mieteinnahmen im Geschäftsjahr 2017", "", xlsxStyleTitle},
+		{"A3", "Unit", "", xlsxStyleHeader},
+		{"A4", "101 & 102", "", xlsxStyleGeneral},
+		{"B4", "", "31", xlsxStyleInt},
+		{"C4", "", "1234.5", xlsxStyleFloat},
+		{"D4", "", "42795", xlsxStyleDate},
+		{"A5", "Total", "", xlsxStyleGeneral + xlsxTotal},
+		{"C5", "", "1234.5", xlsxStyleFloat + xlsxTotal},
+		{"A7", "1 warning", "", xlsxStyleGeneral},
+	}
+	for _, x := range expect {
+		c, ok := cells[x.ref]
+		if !ok {
+			t.Errorf("%s: expect a cell", x.ref)
+			continue
+		}
+		if c.T != x.text || c.V != x.value || c.S != x.style {
+			t.Errorf("%s: expect %q %q style %d, got %q %q style %d", x.ref, x.text, x.value, x.style, c.T, c.V, c.S)
+		}
+	}
+}
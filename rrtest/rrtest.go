@@ -0,0 +1,172 @@
+// +build sqlite
+
+// Package rrtest gives the package tests a RentRoll database to work with.
+// OpenDB makes a new SQLite database in a temporary directory and NewBusiness
+// loads it with a small business: a chart of accounts, account rules, three
+// rentables and one rental agreement. The SQLite driver needs cgo, so the
+// package and the tests that use it are only built with the sqlite tag:
+//
+//     go test -tags sqlite ./...
+package rrtest
+
+import (
+	"path/filepath"
+	"rentroll/rlib"
+	"runtime"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // the driver OpenSQLiteDB uses
+)
+
+// Biz is a business made by NewBusiness, with the ids of what was made for it
+type Biz struct {
+	BID   int64
+	BUD   string
+	XBiz  rlib.XBusiness
+	LID   map[string]int64 // GL number -> LID
+	ARID  map[string]int64 // account rule name -> ARID
+	RTID  int64            // the rentable type of every rentable
+	RID   []int64          // rentables 101, 102 and 103
+	RAID  int64            // the rental agreement for rentable 101
+	TCID  int64            // the payor of RAID
+	PMTID int64            // the payment type "Check"
+	DEPID int64            // the depository for GL account 10001
+}
+
+// Dt returns midnight UTC on the supplied date
+func Dt(y, m, d int) time.Time {
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+}
+
+// Business data. The business opens on BizStart, the rental agreement runs
+// from BizStart to RAStop and rentable 101 rents for MarketRate.
+var (
+	BizStart   = Dt(2017, 1, 1)
+	RAStop     = Dt(2018, 1, 1)
+	Forever    = Dt(9999, 12, 31)
+	MarketRate = float64(1000)
+)
+
+// Accounts is the chart of accounts of the business. The names of the rent
+// and vacancy accounts are the ones vacancy posting looks for.
+var Accounts = []rlib.GLAccount{
+	{GLNumber: "10001", Name: "Bank Account", AcctType: "Cash"},
+	{GLNumber: "10999", Name: "Undeposited Funds", AcctType: "Cash"},
+	{GLNumber: "11001", Name: "Accounts Receivable", AcctType: rlib.AccountsReceivable},
+	{GLNumber: "20001", Name: "Accounts Payable", AcctType: rlib.AccountsPayable},
+	{GLNumber: "21001", Name: "Unapplied Funds", AcctType: "Liabilities"},
+	{GLNumber: "23000", Name: "Security Deposits", AcctType: rlib.LiabilitySecDep},
+	{GLNumber: "41000", Name: "Gross Scheduled Rent-not taxable", AcctType: "Income"},
+	{GLNumber: "41101", Name: "Vacancy", AcctType: "Income Offset"},
+	{GLNumber: "42003", Name: "Late Fees", AcctType: "Income"},
+	{GLNumber: "50001", Name: "Repairs", AcctType: "Expense"},
+}
+
+// AccountRules are the account rules of the business: the debit and credit
+// GL numbers of each
+var AccountRules = []struct {
+	Name          string
+	ARType        int64
+	Debit, Credit string
+}{
+	{"Rent", rlib.ARASSESSMENT, "11001", "41000"},
+	{"Security Deposit", rlib.ARASSESSMENT, "11001", "23000"},
+	{"Late Fee", rlib.ARASSESSMENT, "11001", "42003"},
+	{"Receive Payment", rlib.ARRECEIPT, "10999", "21001"},
+	{"Deposit Funds", rlib.ARRECEIPT, "10001", "10999"},
+}
+
+// OpenDB creates the RentRoll tables in a new SQLite database and makes it
+// the RentRoll database. The database is closed when the test ends.
+func OpenDB(t testing.TB) {
+	_, file, _, _ := runtime.Caller(0)
+	schema := filepath.Join(filepath.Dir(file), "..", "db", "schema", "schema.sql")
+	db, err := rlib.OpenSQLiteDB(filepath.Join(t.TempDir(), "rentroll.db"), schema)
+	if err != nil {
+		t.Fatalf("cannot open the test database: %s", err.Error())
+	}
+	t.Cleanup(func() { db.Close() })
+	rlib.RRdb.Zone = time.UTC
+	rlib.InitDBHelpers(db, nil) // business units come from the local directory
+	rlib.RpnInit()
+}
+
+// NewBusiness adds a business named bud to the database opened by OpenDB.
+// Rentable 101 is rented to one payor under RAID from BizStart to RAStop,
+// rentables 102 and 103 are vacant.
+func NewBusiness(t testing.TB, bud string) *Biz {
+	b := Biz{BUD: bud, LID: map[string]int64{}, ARID: map[string]int64{}}
+	check := func(what string, err error) {
+		if err != nil {
+			t.Fatalf("NewBusiness %s: cannot insert %s: %s", bud, what, err.Error())
+		}
+	}
+
+	biz := rlib.Business{Designation: bud, Name: bud + " Apartments", DefaultRentCycle: rlib.RECURMONTHLY, DefaultProrationCycle: rlib.RECURDAILY, DefaultGSRPC: rlib.RECURDAILY}
+	_, err := rlib.InsertBusiness(&biz)
+	check("Business", err)
+	b.BID = biz.BID
+	rlib.RRdb.BUDlist = rlib.BuildBusinessDesignationMap()
+
+	for i := 0; i < len(Accounts); i++ {
+		l := Accounts[i]
+		l.BID, l.Status, l.AllowPost = b.BID, 2, 1
+		_, err = rlib.InsertLedger(&l)
+		check("GLAccount "+l.GLNumber, err)
+		b.LID[l.GLNumber] = l.LID
+		lm := rlib.LedgerMarker{LID: l.LID, BID: b.BID, Dt: BizStart, State: rlib.LMINITIAL}
+		check("LedgerMarker", rlib.InsertLedgerMarker(&lm))
+	}
+	for _, r := range AccountRules {
+		a := rlib.AR{BID: b.BID, Name: r.Name, ARType: r.ARType, DebitLID: b.LID[r.Debit], CreditLID: b.LID[r.Credit], DtStart: BizStart, DtStop: Forever}
+		_, err = rlib.InsertAR(&a)
+		check("AR "+r.Name, err)
+		b.ARID[r.Name] = a.ARID
+	}
+	pt := rlib.PaymentType{BID: b.BID, Name: "Check"}
+	check("PaymentType", rlib.InsertPaymentType(&pt))
+	b.PMTID = pt.PMTID
+	dep := rlib.Depository{BID: b.BID, LID: b.LID["10001"], Name: "First National", AccountNo: "2332352"}
+	b.DEPID, err = rlib.InsertDepository(&dep)
+	check("Depository", err)
+
+	rt := rlib.RentableType{BID: b.BID, Style: "FS", Name: "Flat Studio", RentCycle: rlib.RECURMONTHLY, Proration: rlib.RECURDAILY, GSRPC: rlib.RECURDAILY, ManageToBudget: 1}
+	b.RTID, err = rlib.InsertRentableType(&rt)
+	check("RentableType", err)
+	check("RentableMarketRate", rlib.InsertRentableMarketRates(&rlib.RentableMarketRate{RTID: b.RTID, BID: b.BID, MarketRate: MarketRate, DtStart: BizStart, DtStop: Forever}))
+
+	for _, name := range []string{"101", "102", "103"} {
+		r := rlib.Rentable{BID: b.BID, RentableName: name, AssignmentTime: 1, DtMRStart: BizStart}
+		_, err = rlib.InsertRentable(&r)
+		check("Rentable "+name, err)
+		check("RentableTypeRef", rlib.InsertRentableTypeRef(&rlib.RentableTypeRef{RID: r.RID, BID: b.BID, RTID: b.RTID, DtStart: BizStart, DtStop: Forever}))
+		ls := int64(rlib.LEASESTATUSvacantNotRented)
+		if name == "101" {
+			ls = rlib.LEASESTATUSleased
+		}
+		check("RentableStatus", rlib.InsertRentableStatus(&rlib.RentableStatus{RID: r.RID, BID: b.BID, DtStart: BizStart, DtStop: Forever, UseStatus: rlib.USESTATUSinService, LeaseStatus: ls}))
+		b.RID = append(b.RID, r.RID)
+	}
+
+	p := rlib.Transactant{BID: b.BID, FirstName: "Aaron", LastName: "Read", PrimaryEmail: "aaron.read@example.com"}
+	b.TCID, err = rlib.InsertTransactant(&p)
+	check("Transactant", err)
+	_, err = rlib.InsertPayor(&rlib.Payor{TCID: b.TCID, BID: b.BID})
+	check("Payor", err)
+	_, err = rlib.InsertUser(&rlib.User{TCID: b.TCID, BID: b.BID})
+	check("User", err)
+
+	ra := rlib.RentalAgreement{BID: b.BID, AgreementStart: BizStart, AgreementStop: RAStop, PossessionStart: BizStart, PossessionStop: RAStop, RentStart: BizStart, RentStop: RAStop, RentCycleEpoch: BizStart}
+	b.RAID, err = rlib.InsertRentalAgreement(&ra)
+	check("RentalAgreement", err)
+	check("LedgerMarker", rlib.InsertLedgerMarker(&rlib.LedgerMarker{BID: b.BID, RAID: b.RAID, Dt: BizStart, State: rlib.LMINITIAL}))
+	_, err = rlib.InsertRentalAgreementRentable(&rlib.RentalAgreementRentable{RAID: b.RAID, BID: b.BID, RID: b.RID[0], ContractRent: MarketRate, RARDtStart: BizStart, RARDtStop: RAStop})
+	check("RentalAgreementRentable", err)
+	_, err = rlib.InsertRentalAgreementPayor(&rlib.RentalAgreementPayor{RAID: b.RAID, BID: b.BID, TCID: b.TCID, DtStart: BizStart, DtStop: RAStop})
+	check("RentalAgreementPayor", err)
+	check("RentableUser", rlib.InsertRentableUser(&rlib.RentableUser{RID: b.RID[0], BID: b.BID, TCID: b.TCID, DtStart: BizStart, DtStop: RAStop}))
+
+	rlib.InitBizInternals(b.BID, &b.XBiz)
+	return &b
+}
@@ -5,11 +5,11 @@ TESTSUMMARY="Test rentroll RA Acct Balance and Expenses"
 
 CREATENEWDB=0
 
-echo "Create new database..."
-mysql --no-defaults rentroll < baltest.sql
-
 source ../share/base.sh
 
+echo "Create new database..."
+loadSQL baltest.sql
+
 ./acctbal > z
 
 genericlogcheck "z"  ""  "AcctBal-Checks"
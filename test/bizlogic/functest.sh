@@ -10,11 +10,11 @@ CREATENEWDB=0
 #---------------------------------------------------------------
 #  Use the testdb for these tests...
 #---------------------------------------------------------------
-echo "Create new database..."
-mysql --no-defaults rentroll < rex.sql
-
 source ../share/base.sh
 
+echo "Create new database..."
+loadSQL rex.sql
+
 ./bizlogic > z
 
 genericlogcheck "z"  ""  "Accts-Bizlogic-Checks"
@@ -7,11 +7,17 @@
 # 
 #  If the test file uses its own database saved as a .sql file, make sure
 #  it is listed in the dbs array
+#
+#  Schema changes made since the SchemaVersion table was added are
+#  migrations in rlib/migrations.go. They are applied with rentroll -migrate
+#  after the ALTER commands below, so the saved files are at the latest
+#  schema version.
 #==========================================================================
 
 MODFILE="dbqqqmods.sql"
 MYSQL="mysql --no-defaults"
 MYSQLDUMP="mysqldump --no-defaults"
+RENTROLL="../tmp/rentroll/rentroll"
 
 #=====================================================
 #  Put modifications to schema in the lines below
@@ -41,7 +47,7 @@ cat >${MODFILE} <<EOF
 #     CreateBy BIGINT NOT NULL DEFAULT 0,                     -- employee UID (from phonebook) that created this record
 #     PRIMARY KEY(SARID)
 # );
-ALTER TABLE Assessments ADD COLUMN AGRCPTID BIGINT NOT NULL DEFAULT 0 AFTER RPASMID;
+# ALTER TABLE Assessments ADD COLUMN AGRCPTID BIGINT NOT NULL DEFAULT 0 AFTER RPASMID;
 EOF
 
 #=====================================================
@@ -60,6 +66,8 @@ do
 	${MYSQL} rentroll < ${f}
 	echo -n "updating... "
 	${MYSQL} rentroll < ${MODFILE}
+	echo -n "migrating... "
+	${RENTROLL} -migrate >/dev/null || exit 1
 	echo -n "saving... "
 	${MYSQLDUMP} rentroll > ${f}
 	echo "done"
@@ -158,7 +158,10 @@ func createJournalAndLedgerEntries(xbiz *rlib.XBusiness, r *rlib.Receipt, d1, d2
 	// update the ledgers
 	//--------------------------------------------------------------
 	fmt.Printf("GENERATING LEDGER ENTRIES...\n")
-	rlib.GenerateLedgerEntriesFromJournal(xbiz, &j, d1, d2)
+	if _, err = rlib.GenerateLedgerEntriesFromJournal(xbiz, &j, d1, d2); err != nil {
+		rlib.Ulog("Error from rlib.GenerateLedgerEntriesFromJournal: %s\n", err.Error())
+		return err
+	}
 
 	//----------------------------------------------
 	// force the LedgerMarkers to be generated...
@@ -5,11 +5,11 @@ TESTSUMMARY="Test Payor Statements"
 
 CREATENEWDB=0
 
-echo "Create new database..."
-mysql --no-defaults rentroll < pstmt.sql
-
 source ../share/base.sh
 
+echo "Create new database..."
+loadSQL pstmt.sql
+
 dorrtest "a" "-j 2017-01-01 -k 2017-02-01 -b ${BUD} -r 23,1" "PayorStatement-Bill-JAN"
 dorrtest "b" "-j 2017-02-01 -k 2017-03-01 -b ${BUD} -r 23,1" "PayorStatement-Bill-FEB"
 dorrtest "c" "-j 2017-03-01 -k 2017-04-01 -b ${BUD} -r 23,1" "PayorStatement-Bill-MAR"
@@ -51,6 +51,17 @@ TREPORT="${RRBIN}/../../test/testreport.txt"
 
 RENTROLL="${RRBIN}/rentroll -A ${NOCONSOLE}"
 CSVLOAD="${RRBIN}/rrloadcsv  ${NOCONSOLE}"
+
+#--------------------------------------------------------------------------
+#  If RRSQLITE names a file, rentroll and rrloadcsv use it as an SQLite
+#  database instead of MySQL, so no database server is needed. The programs
+#  must be built with the sqlite tag. Tests that load .sql dumps or check
+#  results with the mysql client still need MySQL.
+#--------------------------------------------------------------------------
+if [ "x${RRSQLITE}" != "x" ]; then
+	RENTROLL="${RENTROLL} -sqlite ${RRSQLITE}"
+	CSVLOAD="${CSVLOAD} -sqlite ${RRSQLITE}"
+fi
 GOLD="./gold"
 
 PAUSE=0
@@ -1243,7 +1254,12 @@ function newDB() {
 #############################################################################
 function createDB() {
 	echo -n "Create new database... " >> ${LOGFILE} 2>&1
-	${RRBIN}/rrnewdb
+	if [ "x${RRSQLITE}" != "x" ]; then
+		# rentroll or rrloadcsv creates the tables when it opens the new file
+		rm -f ${RRSQLITE}
+	else
+		${RRBIN}/rrnewdb
+	fi
 	if [ $? -eq 0 ]; then
 		echo " successful" >> ${LOGFILE} 2>&1
 	else
@@ -1255,6 +1271,27 @@ function createDB() {
 	fi
 }
 
+#############################################################################
+# loadSQL
+#   Description:
+#       Load the mysqldump file $1 into the rentroll database, then apply
+#       the schema migrations it does not have. Many of the saved test
+#       databases are older than the current schema.
+#############################################################################
+function loadSQL() {
+	echo -n "Load database ${1}... " >> ${LOGFILE} 2>&1
+	mysql --no-defaults rentroll < ${1} && ${RRBIN}/rentroll -migrate >> ${LOGFILE} 2>&1
+	if [ $? -eq 0 ]; then
+		echo " successful" >> ${LOGFILE} 2>&1
+	else
+		echo " ERROR" >> ${LOGFILE} 2>&1
+		echo "Failed to load and migrate database ${1}" > ${ERRFILE}
+		cat ${ERRFILE}
+		failmsg
+		exit 1
+	fi
+}
+
 #--------------------------------------------------------------------------
 #  Handle command line options...
 #--------------------------------------------------------------------------
@@ -8,7 +8,7 @@ BUD="OKC"
 source ../share/base.sh
 
 if [ -f rex.sql ]; then
-	mysql --no-defaults rentroll < rex.sql
+	loadSQL rex.sql
 else
 	pushd ../jm1;./functest.sh ;popd
 fi
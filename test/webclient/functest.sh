@@ -23,7 +23,7 @@ CREATENEWDB=0
 #  Use the testdb for these tests...
 #---------------------------------------------------------------
 echo "Create new database..."
-mysql --no-defaults rentroll < restore.sql
+loadSQL restore.sql
 ###################
 
 echo "STARTING RENTROLL SERVER"
@@ -15,11 +15,11 @@ CREATENEWDB=0
 #---------------------------------------------------------------
 #  Use the testdb for these tests...
 #---------------------------------------------------------------
-echo "Create new database..."
-mysql --no-defaults rentroll < asmtest.sql
-
 source ../share/base.sh
 
+echo "Create new database..."
+loadSQL asmtest.sql
+
 echo "STARTING RENTROLL SERVER"
 startRentRollServer
 
@@ -15,11 +15,11 @@ CREATENEWDB=0
 #---------------------------------------------------------------
 #  Use the testdb for these tests...
 #---------------------------------------------------------------
-echo "Create new database..."
-mysql --no-defaults rentroll < restore.sql
-
 source ../share/base.sh
 
+echo "Create new database..."
+loadSQL restore.sql
+
 echo "STARTING RENTROLL SERVER"
 startRentRollServer
 
@@ -174,6 +174,6 @@ stopRentRollServer
 echo "RENTROLL SERVER STOPPED"
 
 echo "Restoring test database..."
-mysql --no-defaults rentroll < restore.sql
+loadSQL restore.sql
 
 logcheck
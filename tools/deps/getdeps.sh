@@ -7,6 +7,7 @@ go get -u github.com/golang/lint/golint
 go get honnef.co/go/tools/cmd/gosimple
 go get github.com/dustin/go-humanize
 go get github.com/go-sql-driver/mysql
+go get github.com/mattn/go-sqlite3
 go get github.com/kardianos/osext
 go get gopkg.in/gomail.v2
 go get github.com/yosssi/gohtml
@@ -0,0 +1,402 @@
+// openapi generates an OpenAPI 3.0 document describing the RentRoll web
+// services.  It reads the wsdoc comment blocks of the functions in the web
+// service package along with the struct types they name as @Input and
+// @Response.  A wsdoc block looks like this:
+//
+//	// wsdoc {
+//	//  @Title  Get Vendor
+//	//	@URL /v1/vendor/:BUI/:VENDID
+//	//  @Method  GET
+//	//	@Synopsis Get information on a Vendor
+//	//  @Description  Return all fields for Vendor :VENDID
+//	//	@Input WebGridSearchRequest
+//	//  @Response VendorGetResponse
+//	// wsdoc }
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// App is the global data for this program
+var App struct {
+	WsDir   string // directory of the web service package
+	LibDirs string // other packages whose types may be referenced, for example rlib=../../rlib
+	Out     string // output file, stdout if empty
+	Title   string
+	Version string
+	Types   map[string]*ast.StructType // all known struct types
+}
+
+// WsDoc is the information in one wsdoc block
+type WsDoc struct {
+	Func     string
+	Title    string
+	URL      string
+	Method   string
+	Synopsis string
+	Descr    []string
+	Input    string
+	Response string
+}
+
+var reParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+func readCommandLineArgs() {
+	flag.StringVar(&App.WsDir, "d", "../../ws", "directory containing the web service source")
+	flag.StringVar(&App.LibDirs, "l", "rlib=../../rlib", "comma separated list of pkg=dir for packages whose types are referenced")
+	flag.StringVar(&App.Out, "o", "", "output file, default is stdout")
+	flag.StringVar(&App.Title, "t", "RentRoll Web Services", "title of the api")
+	flag.StringVar(&App.Version, "v", "2.0", "version of the api")
+	flag.Parse()
+}
+
+func main() {
+	readCommandLineArgs()
+	App.Types = map[string]*ast.StructType{}
+
+	for _, s := range strings.Split(App.LibDirs, ",") {
+		sa := strings.Split(s, "=")
+		if len(sa) != 2 {
+			continue
+		}
+		if _, err := parseDir(sa[1], sa[0]+"."); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			os.Exit(1)
+		}
+	}
+	docs, err := parseDir(App.WsDir, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(1)
+	}
+
+	b, err := json.MarshalIndent(genOpenAPI(docs), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(1)
+	}
+	if len(App.Out) == 0 {
+		fmt.Printf("%s\n", b)
+		return
+	}
+	if err = ioutil.WriteFile(App.Out, b, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+// parseDir records the struct types of the go files in dir using the
+// supplied prefix and returns the wsdoc blocks found in them
+func parseDir(dir, prefix string) ([]WsDoc, error) {
+	var docs []WsDoc
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return docs, err
+	}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				switch x := decl.(type) {
+				case *ast.GenDecl:
+					for _, spec := range x.Specs {
+						if ts, ok := spec.(*ast.TypeSpec); ok {
+							if st, ok := ts.Type.(*ast.StructType); ok {
+								App.Types[prefix+ts.Name.Name] = st
+							}
+						}
+					}
+				case *ast.FuncDecl:
+					if x.Doc != nil {
+						docs = append(docs, parseWsDoc(x.Name.Name, x.Doc.Text())...)
+					}
+				}
+			}
+		}
+	}
+	return docs, nil
+}
+
+// parseWsDoc returns the wsdoc blocks in the comment text s
+func parseWsDoc(fn, s string) []WsDoc {
+	var docs []WsDoc
+	var d *WsDoc
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "wsdoc {"):
+			d = &WsDoc{Func: fn}
+			continue
+		case strings.HasPrefix(line, "wsdoc }"):
+			if d != nil && len(d.URL) > 0 {
+				docs = append(docs, *d)
+			}
+			d = nil
+			continue
+		}
+		if d == nil || !strings.HasPrefix(line, "@") {
+			continue
+		}
+		tag, val := line, ""
+		if i := strings.IndexAny(line, " \t"); i > 0 {
+			tag, val = line[:i], strings.TrimSpace(line[i:])
+		}
+		switch tag {
+		case "@Title":
+			d.Title = val
+		case "@URL":
+			d.URL = val
+		case "@Method":
+			d.Method = strings.ToLower(val)
+		case "@Synopsis":
+			d.Synopsis = val
+		case "@Descr", "@Description":
+			d.Descr = append(d.Descr, val)
+		case "@Input":
+			d.Input = val
+		case "@Response":
+			d.Response = val
+		}
+	}
+	return docs
+}
+
+// genOpenAPI returns the OpenAPI document for docs
+func genOpenAPI(docs []WsDoc) map[string]interface{} {
+	paths := map[string]map[string]interface{}{}
+	schemas := map[string]interface{}{}
+
+	for _, d := range docs {
+		path, params := openAPIPath(d.URL)
+		if _, ok := paths[path]; !ok {
+			paths[path] = map[string]interface{}{}
+		}
+		method := d.Method
+		if len(method) == 0 {
+			method = "post"
+		}
+
+		descr := strings.TrimSpace(d.Synopsis + "\n\n" + strings.Join(d.Descr, " "))
+		if op, ok := paths[path][method].(map[string]interface{}); ok {
+			// /v1 services often share a url and are distinguished by the cmd in
+			// the request.  Describe all of them in a single operation.
+			op["description"] = fmt.Sprintf("%s\n\n%s: %s", op["description"], d.Title, descr)
+			continue
+		}
+
+		op := map[string]interface{}{
+			"summary":     d.Title,
+			"description": descr,
+			"operationId": d.Func,
+			"tags":        []string{pathTag(path)},
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if method != "get" && method != "delete" && len(d.Input) > 0 {
+			op["requestBody"] = map[string]interface{}{
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemaRef(d.Input, schemas)},
+				},
+			}
+		}
+		resp := map[string]interface{}{"description": d.Response}
+		if _, ok := App.Types[d.Response]; ok {
+			resp["content"] = map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemaRef(d.Response, schemas)},
+			}
+		}
+		status := "200"
+		responses := map[string]interface{}{}
+		if strings.HasPrefix(path, "/v2/") {
+			switch method {
+			case "post":
+				status = "201"
+			case "delete":
+				status = "204"
+			}
+			if _, ok := App.Types["V2ErrorResponse"]; ok {
+				responses["default"] = map[string]interface{}{
+					"description": "V2ErrorResponse",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": schemaRef("V2ErrorResponse", schemas)},
+					},
+				}
+			}
+		}
+		responses[status] = resp
+		op["responses"] = responses
+		paths[path][method] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.0",
+		"info": map[string]interface{}{
+			"title":   App.Title,
+			"version": App.Version,
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+// pathTag returns the tag used to group the operations on path. For /v1
+// paths it is the service name, for /v2 paths it is the resource name.
+func pathTag(path string) string {
+	sa := strings.Split(strings.Trim(path, "/"), "/")
+	i := 1
+	if sa[0] == "v2" {
+		i = 2
+	}
+	if i >= len(sa) || strings.HasPrefix(sa[i], "{") {
+		return sa[0]
+	}
+	return sa[i]
+}
+
+// openAPIPath converts a wsdoc url such as /v1/vendor/:BUI/:VENDID?x=:Y to
+// an OpenAPI path and its parameters
+func openAPIPath(u string) (string, []interface{}) {
+	var params []interface{}
+	u = strings.Fields(u)[0]
+	q := ""
+	if i := strings.Index(u, "?"); i >= 0 {
+		u, q = u[:i], u[i+1:]
+	}
+	for _, m := range reParam.FindAllStringSubmatch(u, -1) {
+		params = append(params, map[string]interface{}{
+			"name": m[1], "in": "path", "required": true, "schema": map[string]string{"type": "string"},
+		})
+	}
+	u = reParam.ReplaceAllString(u, "{$1}")
+	for _, kv := range strings.Split(q, "&") {
+		name := strings.Split(kv, "=")[0]
+		if len(name) == 0 || strings.HasPrefix(name, ":") {
+			continue
+		}
+		params = append(params, map[string]interface{}{
+			"name": name, "in": "query", "schema": map[string]string{"type": "string"},
+		})
+	}
+	return u, params
+}
+
+// schemaName returns the name of the component schema for type name
+func schemaName(name string) string {
+	return strings.Replace(name, ".", "_", -1)
+}
+
+// schemaRef returns a reference to the schema for the named type, adding it
+// and any types it refers to into schemas.  Unknown types are described as
+// generic objects.
+func schemaRef(name string, schemas map[string]interface{}) interface{} {
+	st, ok := App.Types[name]
+	if !ok {
+		return map[string]interface{}{"type": "object", "description": name}
+	}
+	sn := schemaName(name)
+	if _, ok := schemas[sn]; !ok {
+		schemas[sn] = map[string]interface{}{} // placeholder stops recursion
+		schemas[sn] = structSchema(name, st, schemas)
+	}
+	return map[string]interface{}{"$ref": "#/components/schemas/" + sn}
+}
+
+// structSchema returns the schema for a struct type
+func structSchema(name string, st *ast.StructType, schemas map[string]interface{}) interface{} {
+	pkg := ""
+	if i := strings.Index(name, "."); i > 0 {
+		pkg = name[:i+1]
+	}
+	props := map[string]interface{}{}
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			continue // embedded
+		}
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			jn := n.Name
+			if f.Tag != nil {
+				tag := strings.Trim(f.Tag.Value, "`")
+				if i := strings.Index(tag, `json:"`); i >= 0 {
+					t := tag[i+6:]
+					t = strings.Split(t[:strings.Index(t, `"`)], ",")[0]
+					if t == "-" {
+						continue
+					}
+					if len(t) > 0 {
+						jn = t
+					}
+				}
+			}
+			props[jn] = typeSchema(pkg, f.Type, schemas)
+		}
+	}
+	return map[string]interface{}{"type": "object", "properties": props}
+}
+
+// typeSchema returns the schema for a field type. pkg is the package prefix
+// of the struct containing the field.
+func typeSchema(pkg string, t ast.Expr, schemas map[string]interface{}) interface{} {
+	switch x := t.(type) {
+	case *ast.StarExpr:
+		return typeSchema(pkg, x.X, schemas)
+	case *ast.ArrayType:
+		if id, ok := x.Elt.(*ast.Ident); ok && id.Name == "byte" {
+			return map[string]string{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeSchema(pkg, x.Elt, schemas)}
+	case *ast.MapType:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(pkg, x.Value, schemas)}
+	case *ast.InterfaceType:
+		return map[string]interface{}{}
+	case *ast.SelectorExpr:
+		name := fmt.Sprintf("%s.%s", x.X, x.Sel.Name)
+		return namedSchema(name, schemas)
+	case *ast.Ident:
+		switch x.Name {
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+			return map[string]string{"type": "integer"}
+		case "float32", "float64":
+			return map[string]string{"type": "number"}
+		case "string":
+			return map[string]string{"type": "string"}
+		case "bool":
+			return map[string]string{"type": "boolean"}
+		}
+		return namedSchema(pkg+x.Name, schemas)
+	}
+	return map[string]interface{}{}
+}
+
+// namedSchema returns the schema for a named, non-builtin type
+func namedSchema(name string, schemas map[string]interface{}) interface{} {
+	switch {
+	case name == "time.Time" || strings.HasSuffix(name, "JSONDateTime"):
+		return map[string]string{"type": "string", "format": "date-time"}
+	case strings.HasSuffix(name, "JSONDate"):
+		return map[string]string{"type": "string", "format": "date"}
+	case strings.Contains(name, ".XJSON") || strings.HasSuffix(name, "NullString"):
+		return map[string]string{"type": "string"}
+	case strings.HasSuffix(name, "NullInt64"):
+		return map[string]string{"type": "integer"}
+	}
+	if _, ok := App.Types[name]; ok {
+		return schemaRef(name, schemas)
+	}
+	return map[string]interface{}{"type": "object", "description": name}
+}
@@ -61,6 +61,7 @@ function buildReportElements(){
     tmp.push.apply(tmp, [
         { type: 'spacer',},
         { type: 'button', id: 'csvexport', icon: 'fa fa-table', tooltip: 'export to CSV' },
+        { type: 'button', id: 'xlsxexport', icon: 'fa fa-file-excel-o', tooltip: 'export to Excel' },
         { type: 'button', id: 'printreport', icon: 'fa fa-file-pdf-o', tooltip: 'export to PDF' },
         { type: 'break', id: 'break2' },
         { type: 'menu-radio', id: 'page_size', icon: 'fa fa-print',
@@ -126,6 +127,15 @@ function buildReportElements(){
                 // now call to export csv report function with start and stop date
                 exportReportCSV(app.last.report, d1, d2);
             }
+            else if (event.target == "xlsxexport") {
+                d1 = document.getElementsByName("dateD1")[0].value;
+                app.D1 = d1;
+                d2 = document.getElementsByName("dateD2")[0].value;
+                app.D2 = d2;
+
+                // call to export xlsx report function with start and stop date
+                exportReportXLSX(app.last.report, d1, d2);
+            }
             else if (event.target == "printreport") {
                 d1 = document.getElementsByName("dateD1")[0].value;
                 app.D1 = d1;
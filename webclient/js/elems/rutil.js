@@ -1055,6 +1055,41 @@ function exportReportCSV(rptname, dtStart, dtStop, returnURL){
     }
 }
 
+//-------------------------------------------------------------------------------
+// Download the Excel (xlsx) report for given report name, date range
+//
+// @params
+//   rptname            : report name to be downloaded
+//   dtStart            : Start Date
+//   dtStop             : Stop Date
+//   returnURL          : it true then returns the url otherwise
+//                        downloads the report from built url in separate window
+//-------------------------------------------------------------------------------
+function exportReportXLSX(rptname, dtStart, dtStop, returnURL){
+    if (rptname === '') {
+        return;
+    }
+    var x = getCurrentBusiness();
+    var url = '/wsvc/' + x.value + '?r=' + rptname;
+
+    // if both dates are available then only append dtstart and dtstop in query params
+    if (dtStart && dtStop) {
+        url += '&dtstart=' + dtStart; // StartDate
+        url += '&dtstop=' + dtStop; // stopDate
+    }
+
+    // now append the report output format
+    url += '&rof=' + app.rof.xlsx;
+    console.log('url = ' + url);
+
+    // open separate window if returnURL is not true
+    if (returnURL) {
+        return url;
+    } else {
+        window.open(url);
+    }
+}
+
 //-------------------------------------------------------------------------------
 // Pops up dialog to get custom width and height from user's input
 //-------------------------------------------------------------------------------
@@ -16,6 +16,11 @@ var workers = []struct {
 	{"CleanSecDepBalanceCache", CleanSecDepBalanceCache},
 	{"CleanAcctSliceCache", CleanAcctSliceCache},
 	{"CleanARSliceCache", CleanARSliceCache},
+	{"CleanKPICache", CleanKPICache},
+	{"DeliverWebhooks", DeliverWebhooks},
+	{"RunReportSchedules", RunReportSchedules},
+	{"TakeRentRollSnapshots", TakeRentRollSnapshots},
+	{"CheckIntegrity", CheckIntegrity},
 }
 
 // Init registers the TWS functions needed by RentRoll
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"rentroll/rlib"
+	"time"
+	"tws"
+)
+
+// CheckIntegrity is a worker that checks the records of every business from
+// the start of the previous month through today and logs the problems it
+// finds.  The problems that are safe to repair are repaired if
+// IntegrityRepair is set in the config file.  It runs every night.
+//-----------------------------------------------------------------------------
+func CheckIntegrity(item *tws.Item) {
+	funcname := "worker.CheckIntegrity"
+	tws.ItemWorking(item) // inform the tws system that we're working
+
+	now := time.Now().In(rlib.RRdb.Zone)
+	d2 := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	d1 := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+	m, err := rlib.GetAllBusinesses()
+	if err != nil {
+		rlib.LogAndPrintError(funcname, err)
+	}
+	for i := 0; i < len(m); i++ {
+		var xbiz rlib.XBusiness
+		rlib.InitBizInternals(m[i].BID, &xbiz)
+		f, err := rlib.CheckIntegrity(&xbiz, &d1, &d2, rlib.RRConfig.IntegrityRepair)
+		for j := 0; j < len(f); j++ {
+			rlib.Ulog("%s: %s: %s %d: %s (expected %.2f, actual %.2f, repaired %t)\n", funcname, m[i].Designation,
+				f[j].Table, f[j].ID, f[j].Comment, f[j].Expected, f[j].Actual, f[j].Repaired)
+		}
+		if err != nil {
+			rlib.Ulog("%s: %s: %s\n", funcname, m[i].Designation, err.Error())
+		}
+	}
+
+	// reschedule for 2am tomorrow...
+	resched := time.Date(now.Year(), now.Month(), now.Day()+1, 2, 0, 0, 0, rlib.RRdb.Zone)
+	tws.RescheduleItem(item, resched)
+}
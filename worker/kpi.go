@@ -0,0 +1,19 @@
+package worker
+
+import (
+	"rentroll/rlib"
+	"time"
+	"tws"
+)
+
+// CleanKPICache is a worker that cleans the KPI cache.
+//-----------------------------------------------------------------------------
+func CleanKPICache(item *tws.Item) {
+	tws.ItemWorking(item) // inform the tws system that we're working
+
+	rlib.CleanKPICache(false) // false means don't remove everything, remove only if expire time is < Now()
+
+	// reschedule after the caches default time to live...
+	resched := time.Now().Add(rlib.KPICacheCtx.Expiry)
+	tws.RescheduleItem(item, resched)
+}
@@ -0,0 +1,23 @@
+package worker
+
+import (
+	"rentroll/rlib"
+	"rentroll/rrpt"
+	"time"
+	"tws"
+)
+
+// RunReportSchedules is a worker that generates and delivers the scheduled
+// reports whose next run time has arrived.
+//-----------------------------------------------------------------------------
+func RunReportSchedules(item *tws.Item) {
+	tws.ItemWorking(item) // inform the tws system that we're working
+
+	if _, err := rrpt.RunDueReportSchedules(time.Now()); err != nil {
+		rlib.LogAndPrintError("worker.RunReportSchedules", err)
+	}
+
+	// check again in 5 minutes...
+	resched := time.Now().Add(5 * time.Minute)
+	tws.RescheduleItem(item, resched)
+}
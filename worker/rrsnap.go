@@ -0,0 +1,25 @@
+package worker
+
+import (
+	"rentroll/rlib"
+	"time"
+	"tws"
+)
+
+// TakeRentRollSnapshots is a worker that saves the rent roll of the previous
+// month for every business that does not have a snapshot of it yet. It runs
+// every day so that a snapshot missed at the start of the month is taken on a
+// later day.
+//-----------------------------------------------------------------------------
+func TakeRentRollSnapshots(item *tws.Item) {
+	tws.ItemWorking(item) // inform the tws system that we're working
+
+	now := time.Now().In(rlib.RRdb.Zone)
+	if _, err := rlib.TakeMonthEndRentRollSnapshots(now); err != nil {
+		rlib.LogAndPrintError("worker.TakeRentRollSnapshots", err)
+	}
+
+	// reschedule for 1am tomorrow...
+	resched := time.Date(now.Year(), now.Month(), now.Day()+1, 1, 0, 0, 0, rlib.RRdb.Zone)
+	tws.RescheduleItem(item, resched)
+}
@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"rentroll/rlib"
+	"time"
+	"tws"
+)
+
+// DeliverWebhooks is a worker that delivers pending outbox events to the
+// webhooks that subscribe to them.
+//-----------------------------------------------------------------------------
+func DeliverWebhooks(item *tws.Item) {
+	tws.ItemWorking(item) // inform the tws system that we're working
+
+	now := time.Now()
+	for {
+		n, err := rlib.DeliverWebhooks(now, rlib.WebhookBatchSize)
+		if err != nil {
+			rlib.LogAndPrintError("worker.DeliverWebhooks", err)
+			break
+		}
+		if n < rlib.WebhookBatchSize {
+			break
+		}
+	}
+
+	// check again in a minute...
+	resched := time.Now().Add(time.Minute)
+	tws.RescheduleItem(item, resched)
+}
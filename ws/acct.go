@@ -332,6 +332,7 @@ func SvcSearchHandlerGLAccounts(w http.ResponseWriter, r *http.Request, d *Servi
 		return
 	}
 
+	x := newGridExporter(w, d, "GLAccounts", GLAccount{}) // nil unless the search is being exported
 	count := 0
 	for rows.Next() {
 		var p GLAccount
@@ -340,7 +341,11 @@ func SvcSearchHandlerGLAccounts(w http.ResponseWriter, r *http.Request, d *Servi
 		rlib.MigrateStructVals(&q, &p)
 		p.Recid = count
 
-		g.Records = append(g.Records, p)
+		if x != nil {
+			x.Write(&p)
+		} else {
+			g.Records = append(g.Records, p)
+		}
 
 		count++ // update the count only after adding the record
 		if count >= d.wsSearchReq.Limit {
@@ -349,7 +354,12 @@ func SvcSearchHandlerGLAccounts(w http.ResponseWriter, r *http.Request, d *Servi
 	}
 	err = rows.Err()
 	if err != nil {
-		SvcGridErrorReturn(w, err, funcname)
+		x.ErrorReturn(w, err, funcname)
+		return
+	}
+
+	if x != nil {
+		x.Close(funcname)
 		return
 	}
 
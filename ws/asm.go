@@ -243,6 +243,7 @@ func SvcSearchHandlerAssessments(w http.ResponseWriter, r *http.Request, d *Serv
 	}
 	defer rows.Close()
 
+	x := newGridExporter(w, d, "Assessments", AssessmentGrid{}) // nil unless the search is being exported
 	i := int64(d.wsSearchReq.Offset)
 	count := 0
 	for rows.Next() {
@@ -251,11 +252,15 @@ func SvcSearchHandlerAssessments(w http.ResponseWriter, r *http.Request, d *Serv
 
 		q, err = assessmentGridRowScan(rows, q)
 		if err != nil {
-			SvcGridErrorReturn(w, err, funcname)
+			x.ErrorReturn(w, err, funcname)
 			return
 		}
 
-		g.Records = append(g.Records, q)
+		if x != nil {
+			x.Write(&q)
+		} else {
+			g.Records = append(g.Records, q)
+		}
 		count++ // update the count only after adding the record
 		if count >= d.wsSearchReq.Limit {
 			break // if we've added the max number requested, then exit
@@ -265,7 +270,12 @@ func SvcSearchHandlerAssessments(w http.ResponseWriter, r *http.Request, d *Serv
 
 	err = rows.Err()
 	if err != nil {
-		SvcGridErrorReturn(w, err, funcname)
+		x.ErrorReturn(w, err, funcname)
+		return
+	}
+
+	if x != nil {
+		x.Close(funcname)
 		return
 	}
 
@@ -0,0 +1,549 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"rentroll/bizlogic"
+	"rentroll/rlib"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BillGrid contains the data from Bill that is targeted to the UI Grid that displays
+// a list of Bill structs
+type BillGrid struct {
+	Recid       int64 `json:"recid"`
+	BILLID      int64
+	RPBILLID    int64
+	BID         int64
+	BUD         rlib.XJSONBud
+	VENDID      int64
+	VendorName  string
+	APLID       int64
+	Dt          rlib.JSONDate
+	DtDue       rlib.JSONDate
+	Amount      float64
+	Balance     float64
+	DocNo       string
+	FLAGS       uint64
+	Comment     string
+	LastModTime rlib.JSONDateTime
+	LastModBy   int64
+	CreateTS    rlib.JSONDateTime
+	CreateBy    int64
+}
+
+// BillItemForm is a line item of a bill
+type BillItemForm struct {
+	BIID        int64
+	LID         int64
+	RID         int64
+	Amount      float64
+	Description string
+}
+
+// BillSearchResponse is a response string to the search request for Bill records
+type BillSearchResponse struct {
+	Status  string     `json:"status"`
+	Total   int64      `json:"total"`
+	Records []BillGrid `json:"records"`
+}
+
+// BillSaveForm is a struct to handle direct inputs from the form
+type BillSaveForm struct {
+	Recid   int64 `json:"recid"`
+	BILLID  int64
+	BID     int64
+	BUD     rlib.XJSONBud
+	VENDID  int64
+	APLID   int64
+	Dt      rlib.JSONDate
+	DtDue   rlib.JSONDate
+	Amount  float64
+	DocNo   string
+	Comment string
+	FLAGS   uint64
+	Items   []BillItemForm
+}
+
+// SaveBillInput is the input data format for a Save command
+type SaveBillInput struct {
+	Recid    int64        `json:"recid"`
+	Status   string       `json:"status"`
+	FormName string       `json:"name"`
+	Record   BillSaveForm `json:"record"`
+}
+
+// BillDetail is a Bill along with its line items and payments
+type BillDetail struct {
+	BillGrid
+	Items    []BillItemForm
+	Payments []BillPaymentGrid
+}
+
+// BillGetResponse is the response to a GetBill request
+type BillGetResponse struct {
+	Status string     `json:"status"`
+	Record BillDetail `json:"record"`
+}
+
+// DeleteBillForm used to reverse a bill or bill payment
+type DeleteBillForm struct {
+	ID int64
+}
+
+// BillPaymentGrid is a payment made against a bill
+type BillPaymentGrid struct {
+	Recid   int64 `json:"recid"`
+	BPID    int64
+	RPBPID  int64
+	BID     int64
+	BILLID  int64
+	VENDID  int64
+	DEPID   int64
+	Dt      rlib.JSONDate
+	Amount  float64
+	DocNo   string
+	FLAGS   uint64
+	Comment string
+}
+
+// BillPaymentSaveForm is a struct to handle direct inputs from the payment form
+type BillPaymentSaveForm struct {
+	Recid   int64 `json:"recid"`
+	BUD     rlib.XJSONBud
+	BILLID  int64
+	DEPID   int64
+	Dt      rlib.JSONDate
+	Amount  float64
+	DocNo   string
+	Comment string
+}
+
+// SaveBillPaymentInput is the input data format for a bill payment Save command
+type SaveBillPaymentInput struct {
+	Recid    int64               `json:"recid"`
+	Status   string              `json:"status"`
+	FormName string              `json:"name"`
+	Record   BillPaymentSaveForm `json:"record"`
+}
+
+// BillPaymentSearchResponse is the list of payments made against a bill
+type BillPaymentSearchResponse struct {
+	Status  string            `json:"status"`
+	Total   int64             `json:"total"`
+	Records []BillPaymentGrid `json:"records"`
+}
+
+var billSearchFieldMap = rlib.SelectQueryFieldMap{
+	"BILLID":     {"Bill.BILLID"},
+	"VendorName": {"Vendor.Name"},
+	"Dt":         {"Bill.Dt"},
+	"DtDue":      {"Bill.DtDue"},
+	"Amount":     {"Bill.Amount"},
+	"DocNo":      {"Bill.DocNo"},
+	"FLAGS":      {"Bill.FLAGS"},
+	"Comment":    {"Bill.Comment"},
+}
+
+// SvcHandlerBill dispatches the web request to the appropriate handler:
+//
+// The server command can be:
+//      get
+//      save
+//      delete
+//-----------------------------------------------------------------------------------
+func SvcHandlerBill(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	funcname := "SvcHandlerBill"
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("Request: %s:  BID = %d,  BILLID = %d\n", d.wsSearchReq.Cmd, d.BID, d.ID)
+
+	switch d.wsSearchReq.Cmd {
+	case "get":
+		if d.ID <= 0 && d.wsSearchReq.Limit > 0 {
+			SvcSearchHandlerBills(w, r, d) // it is a query for the grid.
+		} else {
+			if d.ID < 0 {
+				SvcGridErrorReturn(w, fmt.Errorf("BillID is required but was not specified"), funcname)
+				return
+			}
+			getBill(w, r, d)
+		}
+	case "save":
+		saveBill(w, r, d)
+	case "delete":
+		deleteBill(w, r, d)
+	default:
+		err := fmt.Errorf("Unhandled command: %s", d.wsSearchReq.Cmd)
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+}
+
+// SvcSearchHandlerBills generates a list of the bills in business d.BID
+// wsdoc {
+//  @Title  Search Bills
+//	@URL /v1/bill/:BUI
+//  @Method  POST
+//	@Synopsis Search Bills
+//  @Descr  Search all vendor Bills dated within the search date range.
+//	@Input WebGridSearchRequest
+//  @Response BillSearchResponse
+// wsdoc }
+func SvcSearchHandlerBills(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "SvcSearchHandlerBills"
+		g        BillSearchResponse
+		err      error
+		order    = "Bill.DtDue ASC" // default ORDER
+		whr      = fmt.Sprintf("Bill.BID=%d AND %q <= Bill.Dt AND Bill.Dt < %q", d.BID,
+			d.wsSearchReq.SearchDtStart.Format(rlib.RRDATEFMTSQL),
+			d.wsSearchReq.SearchDtStop.Format(rlib.RRDATEFMTSQL))
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	_, orderClause := GetSearchAndSortSQL(d, billSearchFieldMap)
+	if len(orderClause) > 0 {
+		order = orderClause
+	}
+
+	theQuery := `
+	SELECT
+		{{.SelectClause}}
+	FROM Bill
+	LEFT JOIN Vendor ON Bill.VENDID = Vendor.VENDID
+	WHERE {{.WhereClause}}
+	ORDER BY {{.OrderClause}}`
+
+	qc := rlib.QueryClause{
+		"SelectClause": "Bill." + strings.Replace(rlib.RRdb.DBFields["Bill"], ",", ",Bill.", -1) + ",Vendor.Name",
+		"WhereClause":  whr,
+		"OrderClause":  order,
+	}
+
+	countQuery := rlib.RenderSQLQuery(theQuery, qc)
+	g.Total, err = rlib.GetQueryCount(countQuery)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+
+	limitAndOffsetClause := `
+	LIMIT {{.LimitClause}}
+	OFFSET {{.OffsetClause}};`
+	qc["LimitClause"] = strconv.Itoa(d.wsSearchReq.Limit)
+	qc["OffsetClause"] = strconv.Itoa(d.wsSearchReq.Offset)
+	qry := rlib.RenderSQLQuery(theQuery+limitAndOffsetClause, qc)
+
+	rows, err := rlib.RRdb.Dbrr.Query(qry)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	defer rows.Close()
+
+	i := int64(d.wsSearchReq.Offset)
+	count := 0
+	for rows.Next() {
+		var a rlib.Bill
+		var q BillGrid
+		var vname rlib.NullString
+		err = rows.Scan(&a.BILLID, &a.RPBILLID, &a.BID, &a.VENDID, &a.APLID, &a.Dt, &a.DtDue, &a.Amount, &a.DocNo, &a.FLAGS, &a.Comment, &a.LastModTime, &a.LastModBy, &a.CreateTS, &a.CreateBy, &vname)
+		if err != nil {
+			SvcGridErrorReturn(w, err, funcname)
+			return
+		}
+		rlib.MigrateStructVals(&a, &q)
+		if vname.Valid {
+			q.VendorName = vname.String
+		}
+		q.Balance, err = bizlogic.BillBalance(&a, &rlib.ENDOFTIME)
+		if err != nil {
+			SvcGridErrorReturn(w, err, funcname)
+			return
+		}
+		q.Recid = i
+		q.BUD = getBUDFromBIDList(q.BID)
+		g.Records = append(g.Records, q)
+		count++ // update the count only after adding the record
+		if count >= d.wsSearchReq.Limit {
+			break // if we've added the max number requested, then exit
+		}
+		i++
+	}
+	if err = rows.Err(); err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+
+	g.Status = "success"
+	w.Header().Set("Content-Type", "application/json")
+	SvcWriteResponse(&g, w)
+}
+
+// saveBill creates a bill, or updates an existing one. If the financial
+// content of an existing bill changes, the old bill is reversed.
+// wsdoc {
+//  @Title  Save Bill
+//	@URL /v1/bill/:BUI/:BILLID
+//  @Method  POST
+//	@Synopsis Create or update a vendor Bill
+//  @Description  If :BILLID is 0 a new Bill is created and journaled. If it exists and
+//  @Description  its date, amount, AP account, or items change, it is reversed and
+//  @Description  a new Bill is created. All fields must be supplied.
+//	@Input SaveBillInput
+//  @Response SvcStatusResponse
+// wsdoc }
+func saveBill(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "saveBill"
+		foo      SaveBillInput
+	)
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("record data = %s\n", d.data)
+
+	if err := json.Unmarshal([]byte(d.data), &foo); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+
+	var a rlib.Bill
+	rlib.MigrateStructVals(&foo.Record, &a)
+	var ok bool
+	a.BID, ok = rlib.RRdb.BUDlist[string(foo.Record.BUD)]
+	if !ok {
+		e := fmt.Errorf("%s: Could not map BID value: %s", funcname, foo.Record.BUD)
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	for i := 0; i < len(foo.Record.Items); i++ {
+		var bi rlib.BillItem
+		rlib.MigrateStructVals(&foo.Record.Items[i], &bi)
+		bi.BID = a.BID
+		bi.BILLID = a.BILLID
+		a.BI = append(a.BI, bi)
+	}
+	if errlist := bizlogic.SaveBill(&a); len(errlist) > 0 {
+		SvcErrListReturn(w, errlist, funcname)
+		return
+	}
+	SvcWriteSuccessResponseWithID(w, a.BILLID)
+}
+
+// deleteBill reverses a bill and any payments made against it
+// wsdoc {
+//  @Title  Delete Bill
+//	@URL /v1/bill/:BUI
+//  @Method  POST
+//	@Synopsis Reverses a Bill
+//  @Desc  This service reverses a Bill along with all of its payments.
+//	@Input DeleteBillForm
+//  @Response SvcStatusResponse
+// wsdoc }
+func deleteBill(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "deleteBill"
+		del      DeleteBillForm
+	)
+	rlib.Console("Entered %s\n", funcname)
+	if err := json.Unmarshal([]byte(d.data), &del); err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	a, err := rlib.GetBill(del.ID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	now := time.Now()
+	if errlist := bizlogic.ReverseBill(&a, &now); len(errlist) > 0 {
+		SvcErrListReturn(w, errlist, funcname)
+		return
+	}
+	SvcWriteSuccessResponse(w)
+}
+
+// getBill returns the requested Bill with its items and payments
+// wsdoc {
+//  @Title  Get Bill
+//	@URL /v1/bill/:BUI/:BILLID
+//  @Method  GET
+//	@Synopsis Get information on a Bill
+//  @Description  Return all fields for Bill :BILLID, its line items and its payments
+//	@Input WebGridSearchRequest
+//  @Response BillGetResponse
+// wsdoc }
+func getBill(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "getBill"
+		g        BillGetResponse
+	)
+	rlib.Console("entered %s.  BILLID = %d\n", funcname, d.ID)
+	a, err := rlib.GetBill(d.ID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	if a.BILLID > 0 {
+		rlib.MigrateStructVals(&a, &g.Record.BillGrid)
+		g.Record.BUD = getBUDFromBIDList(a.BID)
+		v, _ := rlib.GetVendor(a.VENDID)
+		g.Record.VendorName = v.Name
+		if g.Record.Balance, err = bizlogic.BillBalance(&a, &rlib.ENDOFTIME); err != nil {
+			SvcGridErrorReturn(w, err, funcname)
+			return
+		}
+		for i := 0; i < len(a.BI); i++ {
+			var bi BillItemForm
+			rlib.MigrateStructVals(&a.BI[i], &bi)
+			g.Record.Items = append(g.Record.Items, bi)
+		}
+		m, err := rlib.GetBillPayments(a.BILLID)
+		if err != nil {
+			SvcGridErrorReturn(w, err, funcname)
+			return
+		}
+		for i := 0; i < len(m); i++ {
+			var p BillPaymentGrid
+			rlib.MigrateStructVals(&m[i], &p)
+			p.Recid = int64(i)
+			g.Record.Payments = append(g.Record.Payments, p)
+		}
+	}
+	g.Status = "success"
+	SvcWriteResponse(&g, w)
+}
+
+// SvcHandlerBillPayment dispatches the web request to the appropriate handler:
+//
+// The server command can be:
+//      get      - list the payments for bill d.ID
+//      save     - pay a bill
+//      delete   - reverse a payment
+//-----------------------------------------------------------------------------------
+func SvcHandlerBillPayment(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	funcname := "SvcHandlerBillPayment"
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("Request: %s:  BID = %d,  ID = %d\n", d.wsSearchReq.Cmd, d.BID, d.ID)
+
+	switch d.wsSearchReq.Cmd {
+	case "get":
+		getBillPayments(w, r, d)
+	case "save":
+		saveBillPayment(w, r, d)
+	case "delete":
+		deleteBillPayment(w, r, d)
+	default:
+		err := fmt.Errorf("Unhandled command: %s", d.wsSearchReq.Cmd)
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+}
+
+// getBillPayments returns the payments made against a bill
+// wsdoc {
+//  @Title  Get Bill Payments
+//	@URL /v1/billpayment/:BUI/:BILLID
+//  @Method  GET
+//	@Synopsis List the payments made against a Bill
+//  @Description  Return all payments, including reversals, for Bill :BILLID
+//	@Input WebGridSearchRequest
+//  @Response BillPaymentSearchResponse
+// wsdoc }
+func getBillPayments(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "getBillPayments"
+		g        BillPaymentSearchResponse
+	)
+	m, err := rlib.GetBillPayments(d.ID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	for i := 0; i < len(m); i++ {
+		var p BillPaymentGrid
+		rlib.MigrateStructVals(&m[i], &p)
+		p.Recid = int64(i)
+		g.Records = append(g.Records, p)
+	}
+	g.Total = int64(len(g.Records))
+	g.Status = "success"
+	SvcWriteResponse(&g, w)
+}
+
+// saveBillPayment pays a bill
+// wsdoc {
+//  @Title  Pay Bill
+//	@URL /v1/billpayment/:BUI
+//  @Method  POST
+//	@Synopsis Record a payment against a Bill
+//  @Description  Pays Bill BILLID from depository DEPID. The payment cannot exceed
+//  @Description  the unpaid balance of the bill.
+//	@Input SaveBillPaymentInput
+//  @Response SvcStatusResponse
+// wsdoc }
+func saveBillPayment(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "saveBillPayment"
+		foo      SaveBillPaymentInput
+	)
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("record data = %s\n", d.data)
+
+	if err := json.Unmarshal([]byte(d.data), &foo); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+
+	var a rlib.BillPayment
+	rlib.MigrateStructVals(&foo.Record, &a)
+	var ok bool
+	a.BID, ok = rlib.RRdb.BUDlist[string(foo.Record.BUD)]
+	if !ok {
+		e := fmt.Errorf("%s: Could not map BID value: %s", funcname, foo.Record.BUD)
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	if errlist := bizlogic.PayBill(&a); len(errlist) > 0 {
+		SvcErrListReturn(w, errlist, funcname)
+		return
+	}
+	SvcWriteSuccessResponseWithID(w, a.BPID)
+}
+
+// deleteBillPayment reverses a bill payment
+// wsdoc {
+//  @Title  Delete Bill Payment
+//	@URL /v1/billpayment/:BUI
+//  @Method  POST
+//	@Synopsis Reverses a Bill Payment
+//  @Desc  This service reverses a payment made against a Bill.
+//	@Input DeleteBillForm
+//  @Response SvcStatusResponse
+// wsdoc }
+func deleteBillPayment(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "deleteBillPayment"
+		del      DeleteBillForm
+	)
+	rlib.Console("Entered %s\n", funcname)
+	if err := json.Unmarshal([]byte(d.data), &del); err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	a, err := rlib.GetBillPayment(del.ID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	now := time.Now()
+	if errlist := bizlogic.ReverseBillPayment(&a, &now); len(errlist) > 0 {
+		SvcErrListReturn(w, errlist, funcname)
+		return
+	}
+	SvcWriteSuccessResponse(w)
+}
@@ -0,0 +1,249 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"rentroll/bizlogic"
+	"rentroll/rlib"
+)
+
+// BusinessGroupGrid contains the data from BusinessGroup that is targeted to
+// the UI Grid that displays a list of BusinessGroup structs
+type BusinessGroupGrid struct {
+	Recid       int64 `json:"recid"`
+	BGID        int64
+	Name        string
+	GroupType   string
+	Description string
+	Members     []rlib.XJSONBud // BUDs of the businesses in the group
+	LastModTime rlib.JSONDateTime
+	LastModBy   int64
+	CreateTS    rlib.JSONDateTime
+	CreateBy    int64
+}
+
+// BusinessGroupSearchResponse is the response to a request for the list of
+// BusinessGroups
+type BusinessGroupSearchResponse struct {
+	Status  string              `json:"status"`
+	Total   int64               `json:"total"`
+	Records []BusinessGroupGrid `json:"records"`
+}
+
+// BusinessGroupGetResponse is the response to a GetBusinessGroup request
+type BusinessGroupGetResponse struct {
+	Status string            `json:"status"`
+	Record BusinessGroupGrid `json:"record"`
+}
+
+// BusinessGroupSaveForm is a struct to handle direct inputs from the form
+type BusinessGroupSaveForm struct {
+	Recid       int64 `json:"recid"`
+	BGID        int64
+	Name        string
+	GroupType   string
+	Description string
+	Members     []rlib.XJSONBud
+}
+
+// SaveBusinessGroupInput is the input data format for a Save command
+type SaveBusinessGroupInput struct {
+	Recid    int64                 `json:"recid"`
+	Status   string                `json:"status"`
+	FormName string                `json:"name"`
+	Record   BusinessGroupSaveForm `json:"record"`
+}
+
+// DeleteBusinessGroupForm holds the BGID of the group to delete
+type DeleteBusinessGroupForm struct {
+	BGID int64
+}
+
+// SvcHandlerBusinessGroup dispatches the web request to the appropriate
+// handler. Business groups span businesses, so the BUI in the URL is ignored.
+//
+// The server command can be:
+//      get
+//      save
+//      delete
+//-----------------------------------------------------------------------------------
+func SvcHandlerBusinessGroup(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	funcname := "SvcHandlerBusinessGroup"
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("Request: %s:  BGID = %d\n", d.wsSearchReq.Cmd, d.ID)
+
+	switch d.wsSearchReq.Cmd {
+	case "get":
+		if d.ID <= 0 {
+			SvcSearchHandlerBusinessGroups(w, r, d)
+		} else {
+			getBusinessGroup(w, r, d)
+		}
+	case "save":
+		saveBusinessGroup(w, r, d)
+	case "delete":
+		deleteBusinessGroup(w, r, d)
+	default:
+		err := fmt.Errorf("Unhandled command: %s", d.wsSearchReq.Cmd)
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+}
+
+// businessGroupToGrid fills out the grid record for a including the BUDs of
+// its member businesses
+func businessGroupToGrid(a *rlib.BusinessGroup, g *BusinessGroupGrid) error {
+	rlib.MigrateStructVals(a, g)
+	bids, err := rlib.GetBusinessGroupMembers(a.BGID)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(bids); i++ {
+		g.Members = append(g.Members, getBUDFromBIDList(bids[i]))
+	}
+	return nil
+}
+
+// SvcSearchHandlerBusinessGroups returns all the BusinessGroups
+// wsdoc {
+//  @Title  Search Business Groups
+//	@URL /v1/bizgroup/:BUI
+//  @Method  POST
+//	@Synopsis Return all Business Groups
+//  @Descr  Returns every Business Group along with the BUDs of its members.
+//	@Input WebGridSearchRequest
+//  @Response BusinessGroupSearchResponse
+// wsdoc }
+func SvcSearchHandlerBusinessGroups(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "SvcSearchHandlerBusinessGroups"
+		g        BusinessGroupSearchResponse
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	m, err := rlib.GetAllBusinessGroups()
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	for i := 0; i < len(m); i++ {
+		var q BusinessGroupGrid
+		if err = businessGroupToGrid(&m[i], &q); err != nil {
+			SvcGridErrorReturn(w, err, funcname)
+			return
+		}
+		q.Recid = int64(i)
+		g.Records = append(g.Records, q)
+	}
+	g.Total = int64(len(g.Records))
+	g.Status = "success"
+	w.Header().Set("Content-Type", "application/json")
+	SvcWriteResponse(&g, w)
+}
+
+// getBusinessGroup returns the requested BusinessGroup
+// wsdoc {
+//  @Title  Get Business Group
+//	@URL /v1/bizgroup/:BUI/:BGID
+//  @Method  GET
+//	@Synopsis Get information on a Business Group
+//  @Description  Return all fields for Business Group :BGID and the BUDs of its members
+//	@Input WebGridSearchRequest
+//  @Response BusinessGroupGetResponse
+// wsdoc }
+func getBusinessGroup(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "getBusinessGroup"
+		g        BusinessGroupGetResponse
+	)
+	rlib.Console("entered %s.  BGID = %d\n", funcname, d.ID)
+	a, err := rlib.GetBusinessGroup(d.ID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	if a.BGID > 0 {
+		if err = businessGroupToGrid(&a, &g.Record); err != nil {
+			SvcGridErrorReturn(w, err, funcname)
+			return
+		}
+	}
+	g.Status = "success"
+	SvcWriteResponse(&g, w)
+}
+
+// saveBusinessGroup creates or updates a BusinessGroup and its member list
+// wsdoc {
+//  @Title  Save Business Group
+//	@URL /v1/bizgroup/:BUI/:BGID
+//  @Method  POST
+//	@Synopsis Create or update a Business Group
+//  @Description  If :BGID is 0 a new Business Group is created, otherwise Business Group :BGID
+//  @Description  is updated. Members is the complete list of BUDs in the group.
+//	@Input SaveBusinessGroupInput
+//  @Response SvcStatusResponse
+// wsdoc }
+func saveBusinessGroup(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "saveBusinessGroup"
+		foo      SaveBusinessGroupInput
+		bids     []int64
+	)
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("record data = %s\n", d.data)
+
+	if err := json.Unmarshal([]byte(d.data), &foo); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+
+	var a rlib.BusinessGroup
+	rlib.MigrateStructVals(&foo.Record, &a)
+	for i := 0; i < len(foo.Record.Members); i++ {
+		bid, ok := rlib.RRdb.BUDlist[string(foo.Record.Members[i])]
+		if !ok {
+			e := fmt.Errorf("%s: Could not map BID value: %s", funcname, foo.Record.Members[i])
+			SvcGridErrorReturn(w, e, funcname)
+			return
+		}
+		bids = append(bids, bid)
+	}
+	if errlist := bizlogic.SaveBusinessGroup(&a, bids); len(errlist) > 0 {
+		SvcErrListReturn(w, errlist, funcname)
+		return
+	}
+	SvcWriteSuccessResponseWithID(w, a.BGID)
+}
+
+// deleteBusinessGroup removes a BusinessGroup. The member businesses are not
+// affected.
+// wsdoc {
+//  @Title  Delete Business Group
+//	@URL /v1/bizgroup/:BUI/:BGID
+//  @Method  POST
+//	@Synopsis Delete a Business Group
+//  @Description  Deletes the Business Group and its member list.
+//	@Input DeleteBusinessGroupForm
+//  @Response SvcStatusResponse
+// wsdoc }
+func deleteBusinessGroup(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "deleteBusinessGroup"
+		del      DeleteBusinessGroupForm
+	)
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("record data = %s\n", d.data)
+
+	if err := json.Unmarshal([]byte(d.data), &del); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	if err := rlib.DeleteBusinessGroup(del.BGID); err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	SvcWriteSuccessResponse(w)
+}
@@ -0,0 +1,78 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"rentroll/rlib"
+)
+
+// CloneBusinessForm describes the new business made by a clone request
+type CloneBusinessForm struct {
+	BUD       string // designation of the new business
+	Name      string // its name, default is the name of the template business
+	ShiftDays int    // number of days to move the market rate dates by
+}
+
+// CloneBusinessCount is the number of records copied into one table
+type CloneBusinessCount struct {
+	Recid int64 `json:"recid"`
+	Table string
+	Count int
+}
+
+// CloneBusinessResponse is the response to a clone request
+type CloneBusinessResponse struct {
+	Status  string               `json:"status"`
+	BID     int64                // BID of the new business
+	BUD     string               // its designation
+	Total   int64                `json:"total"`
+	Records []CloneBusinessCount `json:"records"`
+}
+
+// SvcHandlerCloneBusiness makes a new business with the configuration of
+// business :BUI.
+// wsdoc {
+//  @Title  Clone Business
+//	@URL /v1/clonebiz/:BUI
+//  @Method  POST
+//	@Synopsis Make a new business from a template business
+//  @Description  Copies the chart of accounts, account rules, payment types, deposit methods,
+//  @Description  depositories, rentable types and market rates, string lists and note types
+//  @Description  of business :BUI into a new business. No rentables, people, rental agreements
+//  @Description  or transactions are copied. The market rate dates can be moved by ShiftDays.
+//	@Input CloneBusinessForm
+//  @Response CloneBusinessResponse
+// wsdoc }
+func SvcHandlerCloneBusiness(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "SvcHandlerCloneBusiness"
+		foo      CloneBusinessForm
+		g        CloneBusinessResponse
+	)
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("record data = %s\n", d.data)
+
+	if err := json.Unmarshal([]byte(d.data), &foo); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	o := rlib.CloneOptions{BUD: foo.BUD, Name: foo.Name, ShiftDays: foo.ShiftDays}
+	res, err := rlib.CloneBusiness(d.BID, &o)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	for i := 0; i < len(rlib.AllTables); i++ {
+		if n := res.Rows[rlib.AllTables[i]]; n > 0 {
+			g.Records = append(g.Records, CloneBusinessCount{Recid: int64(len(g.Records)), Table: rlib.AllTables[i], Count: n})
+		}
+	}
+	g.BID = res.BID
+	g.BUD = res.BUD
+	g.Total = int64(len(g.Records))
+	g.Status = "success"
+	w.Header().Set("Content-Type", "application/json")
+	SvcWriteResponse(&g, w)
+}
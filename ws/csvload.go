@@ -0,0 +1,198 @@
+package ws
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"rentroll/rcsv"
+	"rentroll/rlib"
+	"strings"
+	"sync"
+	"time"
+)
+
+// csvLoadMu serializes csv loads. The rcsv loaders share the business, date
+// range and validate flag in rcsv.Rcsv.
+var csvLoadMu sync.Mutex
+
+// CSVLoadErrorGrid is one error found while loading a csv file
+type CSVLoadErrorGrid struct {
+	Recid   int64 `json:"recid"`
+	Line    int   // line of the csv file, 0 if the error is not about a line
+	Message string
+}
+
+// CSVLoadResponse is the response to a csv load. The load itself succeeded
+// if Total is 0, otherwise Records lists the lines that were not loaded.
+type CSVLoadResponse struct {
+	Status       string             `json:"status"`
+	Loader       string             // name of the loader that was run
+	ValidateOnly bool               // true if nothing was written
+	Total        int64              `json:"total"` // number of errors
+	Records      []CSVLoadErrorGrid `json:"records"`
+}
+
+// csvLoadBUDCheck makes sure that every data row of a csv file whose first
+// column is BUD belongs to the business the request is for. The loaders
+// look up the business of each row by its BUD, so without this check a file
+// could load data into any business.
+func csvLoadBUDCheck(recs [][]string, bud string) error {
+	if len(recs) == 0 || len(recs[0]) == 0 || strings.ToLower(rlib.Stripchars(strings.TrimSpace(recs[0][0]), " ")) != "bud" {
+		return nil
+	}
+	for i := 1; i < len(recs); i++ {
+		if len(recs[i]) == 0 || len(recs[i][0]) == 0 || recs[i][0][0] == '#' {
+			continue
+		}
+		if s := strings.TrimSpace(recs[i][0]); !strings.EqualFold(s, bud) {
+			return fmt.Errorf("line %d is for business %s, this request is for %s", i+1, s, bud)
+		}
+	}
+	return nil
+}
+
+// SvcHandlerCSVLoad loads a csv file with one of the rcsv loaders.
+// wsdoc {
+//  @Title  Load CSV File
+//	@URL /v1/csvload/:BUI
+//  @Method  POST
+//	@Synopsis Load a csv file into a business
+//  @Description  The request is multipart/form-data with the csv file in part "file" and
+//  @Description  the name of the loader in "loader", for example RentableTypes, People,
+//  @Description  Rentables or RentalAgreement. See rcsv.CSVLoaders for the full list.
+//  @Description  If "validate" is 1 every line is checked but nothing is saved. "DtStart"
+//  @Description  and "DtStop" set the date range used by the Assessments and Receipts
+//  @Description  loaders, the default is the current month. Every row of a file whose
+//  @Description  first column is BUD must be for business :BUI. The Business loader
+//  @Description  can be run with :BUI = 0 to create new businesses.
+//	@Input multipart/form-data
+//  @Response CSVLoadResponse
+// wsdoc }
+func SvcHandlerCSVLoad(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "SvcHandlerCSVLoad"
+		g        CSVLoadResponse
+		mfValue  = func(k string) string {
+			if v, ok := d.MFValues[k]; ok && len(v) > 0 {
+				return strings.TrimSpace(v[0])
+			}
+			return ""
+		}
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	l, ok := rcsv.GetCSVLoader(mfValue("loader"))
+	if !ok {
+		e := fmt.Errorf("%s: unknown loader: %q", funcname, mfValue("loader"))
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	if d.BID == 0 && l.Index != rcsv.CSVBusiness {
+		e := fmt.Errorf("%s: the %s loader needs a business", funcname, l.Name)
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	g.Loader = l.Name
+	g.ValidateOnly = mfValue("validate") == "1" || strings.EqualFold(mfValue("validate"), "true")
+
+	//----------------------------------------------------------------
+	// the date range, used by the assessment and receipt loaders
+	//----------------------------------------------------------------
+	now := time.Now()
+	d1 := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	d2 := d1.AddDate(0, 1, 0)
+	var err error
+	if s := mfValue("DtStart"); len(s) > 0 {
+		if d1, err = rlib.StringToDate(s); err != nil {
+			SvcGridErrorReturn(w, fmt.Errorf("%s: invalid DtStart: %s", funcname, err.Error()), funcname)
+			return
+		}
+	}
+	if s := mfValue("DtStop"); len(s) > 0 {
+		if d2, err = rlib.StringToDate(s); err != nil {
+			SvcGridErrorReturn(w, fmt.Errorf("%s: invalid DtStop: %s", funcname, err.Error()), funcname)
+			return
+		}
+	}
+
+	//----------------------------------------------------------------
+	// read the file. The loaders read it again by name, it is parsed
+	// here first because rlib.LoadCSV exits on a malformed file.
+	//----------------------------------------------------------------
+	fheaders, ok := d.Files["file"]
+	if !ok || len(fheaders) == 0 {
+		SvcGridErrorReturn(w, fmt.Errorf("%s: file is missing", funcname), funcname)
+		return
+	}
+	inf, err := fheaders[0].Open()
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	defer inf.Close()
+	tmp, err := ioutil.TempFile("", "csvload")
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, inf)
+	tmp.Close()
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	f, err := os.Open(tmp.Name())
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	cr := csv.NewReader(f)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	recs, err := cr.ReadAll()
+	f.Close()
+	if err != nil {
+		SvcGridErrorReturn(w, fmt.Errorf("%s: unable to read the csv file: %s", funcname, err.Error()), funcname)
+		return
+	}
+	if d.BID > 0 {
+		if err = csvLoadBUDCheck(recs, string(getBUDFromBIDList(d.BID))); err != nil {
+			SvcGridErrorReturn(w, fmt.Errorf("%s: %s", funcname, err.Error()), funcname)
+			return
+		}
+	}
+
+	//----------------------------------------------------------------
+	// load it
+	//----------------------------------------------------------------
+	load := func() []error {
+		csvLoadMu.Lock()
+		defer csvLoadMu.Unlock()
+		var xbiz rlib.XBusiness
+		if d.BID > 0 {
+			rlib.GetXBusiness(d.BID, &xbiz)
+			rlib.InitBizInternals(d.BID, &xbiz)
+		}
+		rcsv.InitRCSV(&d1, &d2, &xbiz)
+		rcsv.Rcsv.ValidateOnly = g.ValidateOnly
+		defer func() { rcsv.Rcsv.ValidateOnly = false }()
+		m := l.Loader(tmp.Name())
+		if l.Index == rcsv.CSVBusiness && !g.ValidateOnly {
+			rlib.RRdb.BUDlist = rlib.BuildBusinessDesignationMap()
+		}
+		return m
+	}
+
+	el := rcsv.CSVErrors(load())
+	for i := 0; i < len(el); i++ {
+		g.Records = append(g.Records, CSVLoadErrorGrid{Recid: int64(i), Line: el[i].Line, Message: el[i].Message})
+	}
+	g.Total = int64(len(g.Records))
+	g.Status = "success"
+	w.Header().Set("Content-Type", "application/json")
+	SvcWriteResponse(&g, w)
+}
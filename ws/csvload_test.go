@@ -0,0 +1,112 @@
+// +build sqlite
+
+package ws
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"mime/multipart"
+	"net/http/httptest"
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"strings"
+	"testing"
+)
+
+// csvLoad posts the csv file f to the csvload service for business bid and
+// returns the raw response
+func csvLoad(t *testing.T, bid int64, loader, validate, f string) string {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("loader", loader)
+	mw.WriteField("validate", validate)
+	fw, err := mw.CreateFormFile("file", "rt.csv")
+	if err != nil {
+		t.Fatalf("CreateFormFile: %s", err.Error())
+	}
+	fw.Write([]byte(f))
+	mw.Close()
+
+	r := httptest.NewRequest("POST", "/v1/csvload/", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	if err = r.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatalf("ParseMultipartForm: %s", err.Error())
+	}
+	d := ServiceData{BID: bid, Files: r.MultipartForm.File, MFValues: r.MultipartForm.Value}
+	w := httptest.NewRecorder()
+	SvcHandlerCSVLoad(w, r, &d)
+	return w.Body.String()
+}
+
+// csvLoadResult decodes a successful csvload response
+func csvLoadResult(t *testing.T, s string) CSVLoadResponse {
+	var g CSVLoadResponse
+	if err := json.Unmarshal([]byte(s), &g); err != nil || g.Status != "success" {
+		t.Fatalf("expect a success response, got %s", s)
+	}
+	return g
+}
+
+const csvLoadRentableTypes = `BUD,Style,Name,RentCycle,Proration,GSRPC,ManageToBudget,MarketRate,DtStart,DtStop
+REX,GM,Garden Studio,6,4,4,1,900,1/1/2017,12/31/9999
+REX,PH,Penthouse,6,4,4,1,2500,1/1/2017,12/31/9999
+`
+
+// With validate set every line is checked and the errors are reported, but
+// nothing is written
+func TestCSVLoadValidateOnly(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	n := rlib.GetCountBusinessRentableTypes(b.BID)
+
+	bad := csvLoadRentableTypes + "REX,XX,Bad Cycle,60,4,4,1,100,1/1/2017,12/31/9999\n"
+	g := csvLoadResult(t, csvLoad(t, b.BID, "RentableTypes", "1", bad))
+	if !g.ValidateOnly || g.Loader != "RentableTypes" {
+		t.Errorf("expect a validate only RentableTypes load, got %#v", g)
+	}
+	if g.Total != 1 || len(g.Records) != 1 || g.Records[0].Line != 4 {
+		t.Errorf("expect one error on line 4, got %#v", g.Records)
+	}
+	if m := rlib.GetCountBusinessRentableTypes(b.BID); m != n {
+		t.Errorf("validate only: expect %d rentable types, got %d", n, m)
+	}
+
+	g = csvLoadResult(t, csvLoad(t, b.BID, "RentableTypes", "0", csvLoadRentableTypes))
+	if g.ValidateOnly || g.Total != 0 {
+		t.Errorf("expect the load to succeed, got %#v", g)
+	}
+	if m := rlib.GetCountBusinessRentableTypes(b.BID); m != n+2 {
+		t.Errorf("expect %d rentable types after the load, got %d", n+2, m)
+	}
+}
+
+// A file with rows for another business is refused before anything is
+// loaded
+func TestCSVLoadBUDMismatch(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	x := rrtest.NewBusiness(t, "XYZ")
+	n, nx := rlib.GetCountBusinessRentableTypes(b.BID), rlib.GetCountBusinessRentableTypes(x.BID)
+
+	f := csvLoadRentableTypes + "XYZ,ZZ,Other Business,6,4,4,1,100,1/1/2017,12/31/9999\n"
+	s := csvLoad(t, b.BID, "RentableTypes", "0", f)
+	if !strings.Contains(s, `"status":"error"`) || !strings.Contains(s, "line 4 is for business XYZ") {
+		t.Errorf("expect an error about line 4, got %s", s)
+	}
+	if m, mx := rlib.GetCountBusinessRentableTypes(b.BID), rlib.GetCountBusinessRentableTypes(x.BID); m != n || mx != nx {
+		t.Errorf("expect nothing loaded, got %d and %d rentable types for REX and XYZ, was %d and %d", m, mx, n, nx)
+	}
+
+	// designations compare without case, comment lines are skipped
+	s = "BUD,Style,Name,RentCycle,Proration,GSRPC,ManageToBudget,MarketRate,DtStart,DtStop\n#XYZ,a comment\nrex,GM,Garden Studio,6,4,4,1,900,1/1/2017,12/31/9999\n"
+	cr := csv.NewReader(strings.NewReader(s))
+	cr.FieldsPerRecord = -1
+	recs, err := cr.ReadAll()
+	if err != nil {
+		t.Fatalf("cannot read csv: %s", err.Error())
+	}
+	if err = csvLoadBUDCheck(recs, "REX"); err != nil {
+		t.Errorf("expect rex to match REX, got %s", err.Error())
+	}
+}
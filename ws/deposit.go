@@ -240,6 +240,7 @@ func SvcSearchHandlerDeposits(w http.ResponseWriter, r *http.Request, d *Service
 	}
 	defer rows.Close()
 
+	x := newGridExporter(w, d, "Deposits", DepositGrid{}) // nil unless the search is being exported
 	i := int64(d.wsSearchReq.Offset)
 	count := 0
 	for rows.Next() {
@@ -250,11 +251,15 @@ func SvcSearchHandlerDeposits(w http.ResponseWriter, r *http.Request, d *Service
 
 		err = depositGridRowScan(rows, &q)
 		if err != nil {
-			SvcGridErrorReturn(w, err, funcname)
+			x.ErrorReturn(w, err, funcname)
 			return
 		}
 
-		g.Records = append(g.Records, q)
+		if x != nil {
+			x.Write(&q)
+		} else {
+			g.Records = append(g.Records, q)
+		}
 		count++ // update the count only after adding the record
 		if count >= d.wsSearchReq.Limit {
 			break // if we've added the max number requested, then exit
@@ -264,7 +269,12 @@ func SvcSearchHandlerDeposits(w http.ResponseWriter, r *http.Request, d *Service
 
 	err = rows.Err()
 	if err != nil {
-		SvcGridErrorReturn(w, err, funcname)
+		x.ErrorReturn(w, err, funcname)
+		return
+	}
+
+	if x != nil {
+		x.Close(funcname)
 		return
 	}
 
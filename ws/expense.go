@@ -265,19 +265,24 @@ func SvcSearchHandlerExpenses(w http.ResponseWriter, r *http.Request, d *Service
 	}
 	defer rows.Close()
 
+	x := newGridExporter(w, d, "Expenses", ExpenseGrid{}) // nil unless the search is being exported
 	i := int64(d.wsSearchReq.Offset)
 	count := 0
 	for rows.Next() {
 
 		q, err := expenseRowScan(rows)
 		if err != nil {
-			SvcGridErrorReturn(w, err, funcname)
+			x.ErrorReturn(w, err, funcname)
 			return
 		}
 		q.Recid = i
 		q.BUD = getBUDFromBIDList(q.BID)
 
-		g.Records = append(g.Records, q)
+		if x != nil {
+			x.Write(&q)
+		} else {
+			g.Records = append(g.Records, q)
+		}
 		count++ // update the count only after adding the record
 		if count >= d.wsSearchReq.Limit {
 			break // if we've added the max number requested, then exit
@@ -287,7 +292,12 @@ func SvcSearchHandlerExpenses(w http.ResponseWriter, r *http.Request, d *Service
 
 	err = rows.Err()
 	if err != nil {
-		SvcGridErrorReturn(w, err, funcname)
+		x.ErrorReturn(w, err, funcname)
+		return
+	}
+
+	if x != nil {
+		x.Close(funcname)
 		return
 	}
 
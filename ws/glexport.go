@@ -0,0 +1,208 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"rentroll/rlib"
+	"strings"
+)
+
+// GLExportGrid contains the data from GLExport that is targeted to the UI
+// Grid that lists the exports for a business
+type GLExportGrid struct {
+	Recid        int64 `json:"recid"`
+	GLEXID       int64
+	BID          int64
+	BUD          rlib.XJSONBud
+	Format       string
+	DtStart      rlib.JSONDate
+	DtStop       rlib.JSONDate
+	JournalCount int64
+	CreateTS     rlib.JSONDateTime
+	CreateBy     int64
+}
+
+// GLExportSearchResponse is the response to a request for the list of
+// GLExports
+type GLExportSearchResponse struct {
+	Status  string         `json:"status"`
+	Total   int64          `json:"total"`
+	Records []GLExportGrid `json:"records"`
+}
+
+// DeleteGLExportForm holds the GLEXID of the export to delete
+type DeleteGLExportForm struct {
+	GLEXID int64
+}
+
+// ExportGLJournalForm is the request to export the Journal entries of a
+// business
+type ExportGLJournalForm struct {
+	DtStart  string // start of the range
+	DtStop   string // end of the range, not inclusive
+	Format   string // iif or csv, the default is iif
+	Cols     string // comma separated list of csv columns
+	ReExport bool   // include Journal entries that were already exported
+	Preview  bool   // do not record the export
+}
+
+// SvcExportGLJournal exports the Journal entries for a business over a date
+// range in QuickBooks IIF format or as a flat csv file.  The export is
+// recorded so that a later export will not include the same entries.
+// wsdoc {
+//  @Title  Export Journal
+//	@URL /v1/exportjournal/:BUI
+//  @Method  POST
+//	@Synopsis Export the Journal entries in the range DtStart to DtStop
+//  @Description  Format is iif or csv, the default is iif. Cols is a comma separated
+//  @Description  list of csv columns. Any of Date, JID, LID, GLNumber, Account, Debit,
+//  @Description  Credit, Amount, Memo, RAID, RID, TCID may be used. Journal entries
+//  @Description  that were already exported are skipped unless ReExport is true. If
+//  @Description  Preview is true the export is not recorded.
+//	@Input ExportGLJournalForm
+//  @Response text/csv or application/iif
+// wsdoc }
+func SvcExportGLJournal(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "SvcExportGLJournal"
+		buf      bytes.Buffer
+		f        ExportGLJournalForm
+		opt      = rlib.GLExportOptions{Format: rlib.GLEXPORTIIF, UID: d.UID}
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	if r.Method != "POST" {
+		SvcGridErrorReturn(w, fmt.Errorf("%s: the export must be requested with POST", funcname), funcname)
+		return
+	}
+	if err := json.Unmarshal([]byte(d.data), &f); err != nil {
+		SvcGridErrorReturn(w, fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error()), funcname)
+		return
+	}
+	d1, err := rlib.StringToDate(f.DtStart)
+	if err != nil {
+		SvcGridErrorReturn(w, fmt.Errorf("%s: invalid DtStart: %s", funcname, err.Error()), funcname)
+		return
+	}
+	d2, err := rlib.StringToDate(f.DtStop)
+	if err != nil {
+		SvcGridErrorReturn(w, fmt.Errorf("%s: invalid DtStop: %s", funcname, err.Error()), funcname)
+		return
+	}
+	if s := strings.ToLower(f.Format); len(s) > 0 {
+		opt.Format = s
+	}
+	if len(f.Cols) > 0 {
+		sa := strings.Split(f.Cols, ",")
+		for i := 0; i < len(sa); i++ {
+			opt.Columns = append(opt.Columns, strings.TrimSpace(sa[i]))
+		}
+	}
+	opt.ReExport = f.ReExport
+	opt.Preview = f.Preview
+
+	if _, err = rlib.ExportGL(d.BID, &d1, &d2, &opt, &buf); err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+
+	ct := "application/iif"
+	if opt.Format == rlib.GLEXPORTCSV {
+		ct = "text/csv"
+	}
+	expFileName := fmt.Sprintf("%s_Journal_%s_%s.%s", getBUDFromBIDList(d.BID), d1.Format(rlib.RRDATEINPFMT), d2.Format(rlib.RRDATEINPFMT), opt.Format)
+	w.Header().Set("Content-Type", ct)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=%s", expFileName))
+	w.Write(buf.Bytes())
+}
+
+// SvcHandlerGLExports dispatches the web request to the appropriate handler:
+//
+// The server command can be:
+//      get
+//      delete
+//-----------------------------------------------------------------------------------
+func SvcHandlerGLExports(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	funcname := "SvcHandlerGLExports"
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("Request: %s:  BID = %d\n", d.wsSearchReq.Cmd, d.BID)
+
+	switch d.wsSearchReq.Cmd {
+	case "get":
+		SvcSearchHandlerGLExports(w, r, d)
+	case "delete":
+		deleteGLExport(w, r, d)
+	default:
+		err := fmt.Errorf("Unhandled command: %s", d.wsSearchReq.Cmd)
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+}
+
+// SvcSearchHandlerGLExports returns the Journal exports for business d.BID
+// wsdoc {
+//  @Title  Search Journal Exports
+//	@URL /v1/glexports/:BUI
+//  @Method  POST
+//	@Synopsis Return the Journal exports for a business
+//  @Descr  Returns every Journal export for the business, most recent first.
+//	@Input WebGridSearchRequest
+//  @Response GLExportSearchResponse
+// wsdoc }
+func SvcSearchHandlerGLExports(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "SvcSearchHandlerGLExports"
+		g        GLExportSearchResponse
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	m, err := rlib.GetGLExports(d.BID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	for i := 0; i < len(m); i++ {
+		var q GLExportGrid
+		rlib.MigrateStructVals(&m[i], &q)
+		q.Recid = int64(i)
+		q.BUD = getBUDFromBIDList(q.BID)
+		g.Records = append(g.Records, q)
+	}
+	g.Total = int64(len(g.Records))
+	g.Status = "success"
+	w.Header().Set("Content-Type", "application/json")
+	SvcWriteResponse(&g, w)
+}
+
+// deleteGLExport removes the record of an export.  The Journal entries it
+// covered will be included in the next export.
+// wsdoc {
+//  @Title  Delete Journal Export
+//	@URL /v1/glexports/:BUI
+//  @Method  POST
+//	@Synopsis Delete the record of a Journal export
+//  @Description  The Journal entries covered by the export will be exported again.
+//	@Input DeleteGLExportForm
+//  @Response SvcStatusResponse
+// wsdoc }
+func deleteGLExport(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "deleteGLExport"
+		del      DeleteGLExportForm
+	)
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("record data = %s\n", d.data)
+
+	if err := json.Unmarshal([]byte(d.data), &del); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	if err := rlib.DeleteGLExport(del.GLEXID); err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	SvcWriteSuccessResponse(w)
+}
@@ -0,0 +1,245 @@
+package ws
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"rentroll/rlib"
+	"strings"
+)
+
+// A grid search request can ask for its complete result set to be exported
+// instead of returned one page at a time.  The request is the same one the
+// grid sends, with the search and sort of what is on screen, plus
+//
+//     "export": "csv"      -- a csv file with a header row of field names
+//     "export": "ndjson"   -- one json record per line
+//
+// The records are written as they are read from the database, so exports of
+// any size use little memory.  The csv columns are the fields of the grid
+// records, in order, except recid.  Values are formatted as they are in the
+// grid's json.
+
+// GRIDEXPORTCSV and GRIDEXPORTNDJSON are the formats a grid search can be
+// exported in
+const (
+	GRIDEXPORTCSV    = "csv"
+	GRIDEXPORTNDJSON = "ndjson"
+)
+
+// gridExportLimit is the Limit of a search that is being exported.  It is
+// large enough that the result set is never paged.
+const gridExportLimit = math.MaxInt32
+
+// gridExportFlushRows is the number of records written between flushes of
+// the response
+const gridExportFlushRows = 500
+
+// gridExportSvcs are the services whose grid searches can be exported
+var gridExportSvcs = map[string]bool{
+	"accounts":     true,
+	"asms":         true,
+	"deposit":      true,
+	"expense":      true,
+	"ledgers":      true,
+	"receipts":     true,
+	"rentables":    true,
+	"rentalagrs":   true,
+	"transactants": true,
+}
+
+// gridExportInit checks the export format of a grid search request and, if
+// the search is being exported, removes its paging.  An export requested of a
+// service that cannot export is an error.  An error is returned to the client
+// as well as to the caller.
+func gridExportInit(w http.ResponseWriter, d *ServiceData) error {
+	funcname := "gridExportInit"
+	d.wsSearchReq.Export = strings.ToLower(strings.TrimSpace(d.wsSearchReq.Export))
+	switch d.wsSearchReq.Export {
+	case "":
+		return nil
+	case GRIDEXPORTCSV, GRIDEXPORTNDJSON:
+		if !gridExportSvcs[d.Service] {
+			e := fmt.Errorf("%s: service %s does not support export", funcname, d.Service)
+			SvcGridErrorReturn(w, e, funcname)
+			return e
+		}
+		d.wsSearchReq.Offset = 0
+		d.wsSearchReq.Limit = gridExportLimit
+		return nil
+	}
+	e := fmt.Errorf("%s: unknown export format: %s", funcname, d.wsSearchReq.Export)
+	SvcGridErrorReturn(w, e, funcname)
+	return e
+}
+
+// gridQueryLimit returns the LIMIT of a grid search whose page size is fixed
+// at limit, or no limit if the search is being exported
+func gridQueryLimit(d *ServiceData, limit int) int {
+	if len(d.wsSearchReq.Export) > 0 {
+		return d.wsSearchReq.Limit
+	}
+	return limit
+}
+
+// gridExporter writes the records of a grid search that is being exported
+type gridExporter struct {
+	w       http.ResponseWriter
+	format  string        // GRIDEXPORTCSV or GRIDEXPORTNDJSON
+	name    string        // file name
+	typ     reflect.Type  // type of the grid records
+	cols    []int         // fields of the record written to csv
+	cw      *csv.Writer   // csv output
+	enc     *json.Encoder // ndjson output
+	n       int           // number of records written
+	started bool          // true once the response header is written
+	err     error         // first write error
+}
+
+// newGridExporter returns an exporter for the search in d, or nil if the
+// search is not being exported.
+//
+// INPUTS
+//  w    - the response
+//  d    - the service data of the search
+//  name - the name of the exported records, as in REX_Receipts.csv
+//  rec  - a grid record, its fields are the csv columns
+//
+// RETURNS
+//  the exporter or nil
+//-----------------------------------------------------------------------------
+func newGridExporter(w http.ResponseWriter, d *ServiceData, name string, rec interface{}) *gridExporter {
+	if len(d.wsSearchReq.Export) == 0 {
+		return nil
+	}
+	x := gridExporter{
+		w:      w,
+		format: d.wsSearchReq.Export,
+		name:   fmt.Sprintf("%s_%s.%s", getBUDFromBIDList(d.BID), name, d.wsSearchReq.Export),
+		typ:    reflect.TypeOf(rec),
+	}
+	for i := 0; i < x.typ.NumField(); i++ {
+		f := x.typ.Field(i)
+		if n := gridExportFieldName(f); len(f.PkgPath) > 0 || n == "-" || n == "recid" {
+			continue // unexported, not in the json, or the grid's row number
+		}
+		x.cols = append(x.cols, i)
+	}
+	return &x
+}
+
+// gridExportFieldName returns the name of field f in the json of a record
+func gridExportFieldName(f reflect.StructField) string {
+	if tag := strings.Split(f.Tag.Get("json"), ",")[0]; len(tag) > 0 {
+		return tag
+	}
+	return f.Name
+}
+
+// gridExportValue returns the value of a field as it is written in csv
+func gridExportValue(v reflect.Value) string {
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return ""
+	}
+	switch {
+	case string(b) == "null":
+		return ""
+	case len(b) > 0 && b[0] == '"':
+		var s string
+		if json.Unmarshal(b, &s) == nil {
+			return s
+		}
+	}
+	return string(b)
+}
+
+// start writes the response header and, for csv, the header row
+func (x *gridExporter) start() {
+	if x.started {
+		return
+	}
+	x.started = true
+	switch x.format {
+	case GRIDEXPORTCSV:
+		x.w.Header().Set("Content-Type", "text/csv")
+		x.cw = csv.NewWriter(x.w)
+	case GRIDEXPORTNDJSON:
+		x.w.Header().Set("Content-Type", "application/x-ndjson")
+		x.enc = json.NewEncoder(x.w)
+	}
+	x.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=%s", x.name))
+	x.w.WriteHeader(http.StatusOK)
+	if x.cw != nil {
+		var hdr []string
+		for _, col := range x.cols {
+			hdr = append(hdr, gridExportFieldName(x.typ.Field(col)))
+		}
+		x.err = x.cw.Write(hdr)
+	}
+}
+
+// Write writes rec, a grid record of the type given to newGridExporter.  The
+// first write error is kept and reported by Close.
+func (x *gridExporter) Write(rec interface{}) {
+	x.start()
+	if x.err != nil {
+		return
+	}
+	switch x.format {
+	case GRIDEXPORTCSV:
+		v := reflect.Indirect(reflect.ValueOf(rec))
+		var row []string
+		for _, col := range x.cols {
+			row = append(row, gridExportValue(v.Field(col)))
+		}
+		x.err = x.cw.Write(row)
+	case GRIDEXPORTNDJSON:
+		x.err = x.enc.Encode(rec)
+	}
+	x.n++
+	if x.n%gridExportFlushRows == 0 {
+		x.flush()
+	}
+}
+
+// ErrorReturn reports err, an error reading the records of a search.  If
+// the export has started the client already has a successful response, so
+// err is logged instead; the export stops and the client gets a truncated
+// file.  x can be nil, for a search that is not being exported.
+func (x *gridExporter) ErrorReturn(w http.ResponseWriter, err error, funcname string) {
+	if x == nil || !x.started {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	rlib.Ulog("%s: export of %s failed after %d records: %s\n", funcname, x.name, x.n, err.Error())
+}
+
+// flush sends what has been written so far to the client
+func (x *gridExporter) flush() {
+	if x.cw != nil {
+		x.cw.Flush()
+		if x.err == nil {
+			x.err = x.cw.Error()
+		}
+	}
+	if f, ok := x.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close finishes the export.  An export with no records is just the csv
+// header row, or an empty body for ndjson.  Write errors cannot be returned
+// to the client once the export has started, so they are logged.
+func (x *gridExporter) Close(funcname string) {
+	x.start()
+	x.flush()
+	if x.err != nil {
+		rlib.Ulog("%s: export of %s failed after %d records: %s\n", funcname, x.name, x.n, x.err.Error())
+		return
+	}
+	rlib.Console("%s: exported %d records as %s\n", funcname, x.n, x.format)
+}
@@ -0,0 +1,139 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"rentroll/rlib"
+	"strings"
+	"testing"
+)
+
+type gridExportRec struct {
+	Recid  int64   `json:"recid"`
+	Name   string  `json:"name"`
+	Amount float64 // no json tag
+	Note   string  `json:"-"`
+	hidden int
+}
+
+// exportData returns the service data of a grid search of svc exported as
+// format, after gridExportInit has checked it
+func exportData(t *testing.T, svc, format string) (*ServiceData, *httptest.ResponseRecorder) {
+	rlib.RRdb.BUDlist = rlib.Str2Int64Map{"REX": 1}
+	d := ServiceData{Service: svc, BID: 1}
+	d.wsSearchReq.Export = format
+	d.wsSearchReq.Offset = 100
+	d.wsSearchReq.Limit = 100
+	w := httptest.NewRecorder()
+	if err := gridExportInit(w, &d); err != nil {
+		t.Fatalf("gridExportInit: %s", err.Error())
+	}
+	return &d, w
+}
+
+func TestGridExportInit(t *testing.T) {
+	d, w := exportData(t, "receipts", " CSV ")
+	if d.wsSearchReq.Export != GRIDEXPORTCSV || d.wsSearchReq.Offset != 0 || d.wsSearchReq.Limit != gridExportLimit {
+		t.Errorf("expect an unpaged csv export, got %+v", d.wsSearchReq)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expect nothing written, got %s", w.Body.String())
+	}
+	if d, _ = exportData(t, "ping", ""); d.wsSearchReq.Limit != 100 || newGridExporter(w, d, "Receipts", gridExportRec{}) != nil {
+		t.Errorf("expect a search that is not exported to be unchanged, got %+v", d.wsSearchReq)
+	}
+
+	for _, x := range []struct{ svc, format string }{{"receipts", "xml"}, {"ping", "csv"}, {"rr", "ndjson"}} {
+		d := ServiceData{Service: x.svc}
+		d.wsSearchReq.Export = x.format
+		w := httptest.NewRecorder()
+		if err := gridExportInit(w, &d); err == nil {
+			t.Errorf("%s %s: expect an error", x.svc, x.format)
+		}
+		var e SvcGridError
+		if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || e.Status != "error" {
+			t.Errorf("%s %s: expect an error response, got %s", x.svc, x.format, w.Body.String())
+		}
+	}
+}
+
+func TestGridExportCSV(t *testing.T) {
+	d, w := exportData(t, "receipts", "csv")
+	x := newGridExporter(w, d, "Receipts", gridExportRec{})
+	x.Write(&gridExportRec{Recid: 1, Name: "Smith, J", Amount: 1000.5, Note: "n", hidden: 1})
+	x.Write(&gridExportRec{Recid: 2, Name: "Jones", Amount: 25})
+	x.Close("TestGridExportCSV")
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("expect text/csv, got %s", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != "attachment;filename=REX_Receipts.csv" {
+		t.Errorf("expect the file name in the header, got %s", cd)
+	}
+	expect := "name,Amount\n\"Smith, J\",1000.5\nJones,25\n"
+	if w.Body.String() != expect {
+		t.Errorf("expect %q, got %q", expect, w.Body.String())
+	}
+}
+
+func TestGridExportNDJSON(t *testing.T) {
+	d, w := exportData(t, "asms", "ndjson")
+	x := newGridExporter(w, d, "Assessments", gridExportRec{})
+	x.Write(&gridExportRec{Recid: 1, Name: "Smith", Amount: 10})
+	x.Write(&gridExportRec{Recid: 2, Name: "Jones", Amount: 20})
+	x.Close("TestGridExportNDJSON")
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("expect application/x-ndjson, got %s", ct)
+	}
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expect 2 records, got %q", w.Body.String())
+	}
+	var r gridExportRec
+	if err := json.Unmarshal([]byte(lines[1]), &r); err != nil || r.Recid != 2 || r.Name != "Jones" || r.Amount != 20 {
+		t.Errorf("expect the second record, got %s", lines[1])
+	}
+}
+
+// An export with no records is the header row alone
+func TestGridExportEmpty(t *testing.T) {
+	d, w := exportData(t, "receipts", "csv")
+	newGridExporter(w, d, "Receipts", gridExportRec{}).Close("TestGridExportEmpty")
+	if w.Code != 200 || w.Body.String() != "name,Amount\n" {
+		t.Errorf("expect the header row, got %d %q", w.Code, w.Body.String())
+	}
+
+	d, w = exportData(t, "receipts", "ndjson")
+	newGridExporter(w, d, "Receipts", gridExportRec{}).Close("TestGridExportEmpty")
+	if w.Code != 200 || w.Body.Len() != 0 {
+		t.Errorf("expect an empty body, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// An error before the export starts is returned to the client; after it
+// starts it cannot be, and the file is just cut short
+func TestGridExportErrorReturn(t *testing.T) {
+	err := errors.New("read failed")
+	for _, started := range []bool{false, true} {
+		d, w := exportData(t, "receipts", "csv")
+		x := newGridExporter(w, d, "Receipts", gridExportRec{})
+		if started {
+			x.Write(&gridExportRec{Name: "Smith"})
+		}
+		x.ErrorReturn(w, err, "TestGridExportErrorReturn")
+		x.flush()
+		isErr := strings.Contains(w.Body.String(), `"status":"error"`)
+		if isErr == started {
+			t.Errorf("started %t: expect an error response %t, got %q", started, !started, w.Body.String())
+		}
+	}
+
+	var x *gridExporter // the search is not being exported
+	w := httptest.NewRecorder()
+	x.ErrorReturn(w, err, "TestGridExportErrorReturn")
+	if !strings.Contains(w.Body.String(), "read failed") {
+		t.Errorf("expect an error response, got %q", w.Body.String())
+	}
+}
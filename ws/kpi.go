@@ -0,0 +1,96 @@
+package ws
+
+import (
+	"fmt"
+	"net/http"
+	"rentroll/rlib"
+	"time"
+)
+
+// KPIRecord is the set of headline metrics of a business on a date.  See
+// rlib.BusinessKPI for the meaning of each value.
+type KPIRecord struct {
+	BID                 int64
+	BUD                 rlib.XJSONBud
+	Dt                  rlib.JSONDate
+	Computed            rlib.JSONDateTime
+	Units               int64
+	ExcludedUnits       int64
+	AvailableUnits      int64
+	OccupiedUnits       int64
+	PreleasedUnits      int64
+	OnNotice            int64
+	Occupancy           float64
+	Leased              float64
+	RentableTypes       []rlib.KPIRentableType
+	GSR                 float64
+	Delinquency         float64
+	DelinquencyPct      float64
+	UnallocatedReceipts int64
+	UnclearedDeposits   int64
+	UnclearedAmount     float64
+	MoveIns             int64
+	MoveOuts            int64
+}
+
+// KPIResponse is the response to a KPI request
+type KPIResponse struct {
+	Status string    `json:"status"`
+	Record KPIRecord `json:"record"`
+}
+
+// SvcHandlerKPI returns the headline metrics of a business
+// wsdoc {
+//  @Title  Get Business KPIs
+//	@URL /v1/kpi/:BUI
+//  @Method  GET
+//	@Synopsis Get the headline metrics of a business
+//  @Descr  Returns occupancy and leased percentages, units on notice, average contract
+//  @Descr  rent vs. market rate by rentable type, delinquency and its percentage of
+//  @Descr  GSR, unallocated receipts, deposits not yet cleared, and move-ins and
+//  @Descr  move-outs month to date.  The date is given by the dt parameter,
+//  @Descr  ?dt=2018-02-15, or by searchDtStart in the request. It is today if neither
+//  @Descr  is given.  The metrics are cached per business per day for a few minutes.
+//	@Input WebGridSearchRequest
+//  @Response KPIResponse
+// wsdoc }
+func SvcHandlerKPI(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "SvcHandlerKPI"
+		g        KPIResponse
+		err      error
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	now := time.Now().In(rlib.RRdb.Zone)
+	dt := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC) // default to current date
+	if !d.wsSearchReq.SearchDtStart.IsZero() {
+		dt = d.wsSearchReq.SearchDtStart
+	}
+	if f := r.URL.Query()["dt"]; len(f) > 0 {
+		dt, err = rlib.StringToDate(f[0])
+		if err != nil {
+			err = fmt.Errorf("invalid date:  %s", f[0])
+			SvcGridErrorReturn(w, err, funcname)
+			return
+		}
+	}
+
+	var xbiz rlib.XBusiness
+	rlib.GetXBusiness(d.BID, &xbiz)
+	if xbiz.P.BID == 0 {
+		err = fmt.Errorf("business %d not found", d.BID)
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	k, err := rlib.GetBusinessKPI(&xbiz, &dt)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	rlib.MigrateStructVals(&k, &g.Record)
+	g.Record.BUD = getBUDFromBIDList(k.BID)
+	g.Status = "success"
+	w.Header().Set("Content-Type", "application/json")
+	SvcWriteResponse(&g, w)
+}
@@ -99,6 +99,7 @@ func getLedgerGrid(w http.ResponseWriter, r *http.Request, d *ServiceData) {
 	}
 	defer rows.Close()
 
+	x := newGridExporter(w, d, "Ledgers", LedgerGrid{}) // nil unless the search is being exported
 	dt := time.Time(d.wsSearchReq.SearchDtStart)
 	i := int64(d.wsSearchReq.Offset)
 	for rows.Next() {
@@ -134,14 +135,23 @@ func getLedgerGrid(w http.ResponseWriter, r *http.Request, d *ServiceData) {
 			LMState:   state,
 		}
 
-		g.Records = append(g.Records, lg)
+		if x != nil {
+			x.Write(&lg)
+		} else {
+			g.Records = append(g.Records, lg)
+		}
 		i++
 	}
 
 	// error check
 	err = rows.Err()
 	if err != nil {
-		SvcGridErrorReturn(w, err, funcname)
+		x.ErrorReturn(w, err, funcname)
+		return
+	}
+
+	if x != nil {
+		x.Close(funcname)
 		return
 	}
 
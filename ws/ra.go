@@ -353,7 +353,7 @@ func SvcSearchHandlerRentalAgr(w http.ResponseWriter, r *http.Request, d *Servic
 	rentalAgrQueryWithLimit := rentalAgrQuery + limitAndOffsetClause
 
 	// Add limit and offset value
-	qc["LimitClause"] = strconv.Itoa(limitClause)
+	qc["LimitClause"] = strconv.Itoa(gridQueryLimit(d, limitClause))
 	qc["OffsetClause"] = strconv.Itoa(d.wsSearchReq.Offset)
 
 	// get formatted query with substitution of select, where, order clause
@@ -368,6 +368,7 @@ func SvcSearchHandlerRentalAgr(w http.ResponseWriter, r *http.Request, d *Servic
 	}
 	defer rows.Close()
 
+	x := newGridExporter(w, d, "RentalAgreements", RentalAgr{}) // nil unless the search is being exported
 	i := int64(d.wsSearchReq.Offset)
 	count := 0
 	for rows.Next() {
@@ -379,11 +380,15 @@ func SvcSearchHandlerRentalAgr(w http.ResponseWriter, r *http.Request, d *Servic
 		// get records info in struct q
 		q, err = rentalAgrRowScan(rows, q)
 		if err != nil {
-			SvcGridErrorReturn(w, err, funcname)
+			x.ErrorReturn(w, err, funcname)
 			return
 		}
 
-		g.Records = append(g.Records, q)
+		if x != nil {
+			x.Write(&q)
+		} else {
+			g.Records = append(g.Records, q)
+		}
 		count++ // update the count only after adding the record
 		if count >= d.wsSearchReq.Limit {
 			break // if we've added the max number requested, then exit
@@ -393,7 +398,12 @@ func SvcSearchHandlerRentalAgr(w http.ResponseWriter, r *http.Request, d *Servic
 	// error check
 	err = rows.Err()
 	if err != nil {
-		SvcGridErrorReturn(w, err, funcname)
+		x.ErrorReturn(w, err, funcname)
+		return
+	}
+
+	if x != nil {
+		x.Close(funcname)
 		return
 	}
 
@@ -235,6 +235,7 @@ func SvcSearchHandlerReceipts(w http.ResponseWriter, r *http.Request, d *Service
 	}
 	defer rows.Close()
 
+	x := newGridExporter(w, d, "Receipts", PrReceiptGrid{}) // nil unless the search is being exported
 	i := int64(d.wsSearchReq.Offset)
 	count := 0
 	for rows.Next() {
@@ -243,11 +244,15 @@ func SvcSearchHandlerReceipts(w http.ResponseWriter, r *http.Request, d *Service
 
 		q, err = receiptsGridRowScan(rows, q)
 		if err != nil {
-			SvcGridErrorReturn(w, err, funcname)
+			x.ErrorReturn(w, err, funcname)
 			return
 		}
 
-		g.Records = append(g.Records, q)
+		if x != nil {
+			x.Write(&q)
+		} else {
+			g.Records = append(g.Records, q)
+		}
 		count++ // update the count only after adding the record
 		if count >= d.wsSearchReq.Limit {
 			break // if we've added the max number requested, then exit
@@ -257,7 +262,12 @@ func SvcSearchHandlerReceipts(w http.ResponseWriter, r *http.Request, d *Service
 
 	err = rows.Err()
 	if err != nil {
-		SvcGridErrorReturn(w, err, funcname)
+		x.ErrorReturn(w, err, funcname)
+		return
+	}
+
+	if x != nil {
+		x.Close(funcname)
 		return
 	}
 
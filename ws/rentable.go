@@ -269,7 +269,7 @@ func SvcSearchHandlerRentables(w http.ResponseWriter, r *http.Request, d *Servic
 	rentablesQueryWithLimit := rentablesQuery + limitAndOffsetClause
 
 	// Add limit and offset value
-	qc["LimitClause"] = strconv.Itoa(limitClause)
+	qc["LimitClause"] = strconv.Itoa(gridQueryLimit(d, limitClause))
 	qc["OffsetClause"] = strconv.Itoa(d.wsSearchReq.Offset)
 
 	// get formatted query with substitution of select, where, order clause
@@ -284,6 +284,7 @@ func SvcSearchHandlerRentables(w http.ResponseWriter, r *http.Request, d *Servic
 	}
 	defer rows.Close()
 
+	x := newGridExporter(w, d, "Rentables", PrRentableOther{}) // nil unless the search is being exported
 	// get records by iteration
 	i := int64(d.wsSearchReq.Offset)
 	count := 0
@@ -295,11 +296,15 @@ func SvcSearchHandlerRentables(w http.ResponseWriter, r *http.Request, d *Servic
 		// get records in q struct
 		q, err = rentablesRowScan(rows, q)
 		if err != nil {
-			SvcGridErrorReturn(w, err, funcname)
+			x.ErrorReturn(w, err, funcname)
 			return
 		}
 
-		g.Records = append(g.Records, q)
+		if x != nil {
+			x.Write(&q)
+		} else {
+			g.Records = append(g.Records, q)
+		}
 		count++ // update the count only after adding the record
 		if count >= d.wsSearchReq.Limit {
 			break // if we've added the max number requested, then exit
@@ -309,7 +314,12 @@ func SvcSearchHandlerRentables(w http.ResponseWriter, r *http.Request, d *Servic
 	// error check
 	err = rows.Err()
 	if err != nil {
-		SvcGridErrorReturn(w, err, funcname)
+		x.ErrorReturn(w, err, funcname)
+		return
+	}
+
+	if x != nil {
+		x.Close(funcname)
 		return
 	}
 
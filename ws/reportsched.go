@@ -0,0 +1,267 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"rentroll/bizlogic"
+	"rentroll/rlib"
+)
+
+// ReportScheduleGrid contains the data from ReportSchedule that is targeted
+// to the UI Grid that displays a list of ReportSchedule structs
+type ReportScheduleGrid struct {
+	Recid        int64 `json:"recid"`
+	RSID         int64
+	BID          int64
+	BUD          rlib.XJSONBud
+	Name         string
+	Report       string
+	Params       string
+	DateRule     int64
+	OutputFormat int64
+	Cycle        int64
+	NextRun      rlib.JSONDateTime
+	LastRun      rlib.JSONDateTime
+	LastError    string
+	Recipients   string
+	Directory    string
+	FLAGS        uint64
+	LastModTime  rlib.JSONDateTime
+	LastModBy    int64
+	CreateTS     rlib.JSONDateTime
+	CreateBy     int64
+}
+
+// ReportScheduleSearchResponse is the response to a request for the list of
+// ReportSchedules
+type ReportScheduleSearchResponse struct {
+	Status  string               `json:"status"`
+	Total   int64                `json:"total"`
+	Records []ReportScheduleGrid `json:"records"`
+}
+
+// ReportScheduleGetResponse is the response to a GetReportSchedule request
+type ReportScheduleGetResponse struct {
+	Status string             `json:"status"`
+	Record ReportScheduleGrid `json:"record"`
+}
+
+// ReportScheduleSaveForm is a struct to handle direct inputs from the form.
+// LastRun and LastError are set by the worker that runs the schedule.
+type ReportScheduleSaveForm struct {
+	Recid        int64 `json:"recid"`
+	RSID         int64
+	Name         string
+	Report       string
+	Params       string
+	DateRule     int64
+	OutputFormat int64
+	Cycle        int64
+	NextRun      rlib.JSONDateTime
+	Recipients   string
+	Directory    string
+	FLAGS        uint64
+}
+
+// SaveReportScheduleInput is the input data format for a Save command
+type SaveReportScheduleInput struct {
+	Recid    int64                  `json:"recid"`
+	Status   string                 `json:"status"`
+	FormName string                 `json:"name"`
+	Record   ReportScheduleSaveForm `json:"record"`
+}
+
+// DeleteReportScheduleForm holds the RSID of the report schedule to delete
+type DeleteReportScheduleForm struct {
+	RSID int64
+}
+
+// SvcHandlerReportSchedule dispatches the web request to the appropriate handler:
+//
+// The server command can be:
+//      get
+//      save
+//      delete
+//-----------------------------------------------------------------------------------
+func SvcHandlerReportSchedule(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	funcname := "SvcHandlerReportSchedule"
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("Request: %s:  BID = %d,  RSID = %d\n", d.wsSearchReq.Cmd, d.BID, d.ID)
+
+	switch d.wsSearchReq.Cmd {
+	case "get":
+		if d.ID <= 0 {
+			SvcSearchHandlerReportSchedules(w, r, d)
+		} else {
+			getReportSchedule(w, r, d)
+		}
+	case "save":
+		saveReportSchedule(w, r, d)
+	case "delete":
+		deleteReportSchedule(w, r, d)
+	default:
+		err := fmt.Errorf("Unhandled command: %s", d.wsSearchReq.Cmd)
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+}
+
+// SvcSearchHandlerReportSchedules returns the ReportSchedules for business d.BID
+// wsdoc {
+//  @Title  Search Report Schedules
+//	@URL /v1/reportsched/:BUI
+//  @Method  POST
+//	@Synopsis Return the Report Schedules for a business
+//  @Descr  Returns every Report Schedule for the business, with the time and error of its last run.
+//	@Input WebGridSearchRequest
+//  @Response ReportScheduleSearchResponse
+// wsdoc }
+func SvcSearchHandlerReportSchedules(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "SvcSearchHandlerReportSchedules"
+		g        ReportScheduleSearchResponse
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	m, err := rlib.GetReportSchedules(d.BID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	for i := 0; i < len(m); i++ {
+		var q ReportScheduleGrid
+		rlib.MigrateStructVals(&m[i], &q)
+		q.Recid = int64(i)
+		q.BUD = getBUDFromBIDList(q.BID)
+		g.Records = append(g.Records, q)
+	}
+	g.Total = int64(len(g.Records))
+	g.Status = "success"
+	w.Header().Set("Content-Type", "application/json")
+	SvcWriteResponse(&g, w)
+}
+
+// getReportSchedule returns the requested ReportSchedule
+// wsdoc {
+//  @Title  Get Report Schedule
+//	@URL /v1/reportsched/:BUI/:RSID
+//  @Method  GET
+//	@Synopsis Get information on a Report Schedule
+//  @Description  Return all fields for Report Schedule :RSID
+//	@Input WebGridSearchRequest
+//  @Response ReportScheduleGetResponse
+// wsdoc }
+func getReportSchedule(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "getReportSchedule"
+		g        ReportScheduleGetResponse
+	)
+	rlib.Console("entered %s.  RSID = %d\n", funcname, d.ID)
+	a, err := rlib.GetReportSchedule(d.ID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	if a.RSID > 0 && a.BID == d.BID {
+		rlib.MigrateStructVals(&a, &g.Record)
+		g.Record.BUD = getBUDFromBIDList(a.BID)
+	}
+	g.Status = "success"
+	SvcWriteResponse(&g, w)
+}
+
+// saveReportSchedule creates or updates a ReportSchedule
+// wsdoc {
+//  @Title  Save Report Schedule
+//	@URL /v1/reportsched/:BUI/:RSID
+//  @Method  POST
+//	@Synopsis Create or update a Report Schedule
+//  @Description  If :RSID is 0 a new Report Schedule is created, otherwise Report Schedule :RSID
+//  @Description  is updated.  Report is a report name as used by /wsvc, for example RPTrr or
+//  @Description  RPTdelinq. DateRule is 0 = previous month, 1 = month to date, 2 = current month,
+//  @Description  3 = previous week.  OutputFormat is the rof value used by /wsvc.  Cycle is
+//  @Description  4 = daily, 5 = weekly, 6 = monthly, 7 = quarterly, 8 = yearly, 0 = run once.
+//  @Description  Runs are a whole number of cycles after NextRun.  Recipients is a comma separated
+//  @Description  list of email addresses.  Directory is a subdirectory of the report archive.
+//	@Input SaveReportScheduleInput
+//  @Response SvcStatusResponse
+// wsdoc }
+func saveReportSchedule(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "saveReportSchedule"
+		foo      SaveReportScheduleInput
+		a        rlib.ReportSchedule
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	if err := json.Unmarshal([]byte(d.data), &foo); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+
+	if foo.Record.RSID > 0 {
+		var err error
+		if a, err = rlib.GetReportSchedule(foo.Record.RSID); err != nil {
+			SvcGridErrorReturn(w, err, funcname)
+			return
+		}
+		if a.BID != d.BID {
+			e := fmt.Errorf("%s: Report Schedule %d does not belong to business %d", funcname, foo.Record.RSID, d.BID)
+			SvcGridErrorReturn(w, e, funcname)
+			return
+		}
+	}
+	rlib.MigrateStructVals(&foo.Record, &a)
+	a.BID = d.BID
+	a.LastModBy = d.UID
+	if a.RSID == 0 {
+		a.CreateBy = d.UID
+	}
+	if errlist := bizlogic.SaveReportSchedule(&a); len(errlist) > 0 {
+		SvcErrListReturn(w, errlist, funcname)
+		return
+	}
+	SvcWriteSuccessResponseWithID(w, a.RSID)
+}
+
+// deleteReportSchedule removes a ReportSchedule
+// wsdoc {
+//  @Title  Delete Report Schedule
+//	@URL /v1/reportsched/:BUI/:RSID
+//  @Method  POST
+//	@Synopsis Delete a Report Schedule
+//  @Description  Deletes the Report Schedule. Reports it already wrote to the archive are kept.
+//	@Input DeleteReportScheduleForm
+//  @Response SvcStatusResponse
+// wsdoc }
+func deleteReportSchedule(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "deleteReportSchedule"
+		del      DeleteReportScheduleForm
+	)
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("record data = %s\n", d.data)
+
+	if err := json.Unmarshal([]byte(d.data), &del); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	a, err := rlib.GetReportSchedule(del.RSID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	if a.BID != d.BID {
+		e := fmt.Errorf("%s: Report Schedule %d does not belong to business %d", funcname, del.RSID, d.BID)
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	if err = rlib.DeleteReportSchedule(del.RSID); err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	SvcWriteSuccessResponse(w)
+}
@@ -0,0 +1,250 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"rentroll/rlib"
+	"time"
+)
+
+// RRSnapshotGrid describes a rent roll snapshot in the list of snapshots of
+// a business. The rows of the snapshot are not included.
+type RRSnapshotGrid struct {
+	Recid         int64 `json:"recid"`
+	RRSID         int64
+	BID           int64
+	BUD           rlib.XJSONBud
+	DtStart       rlib.JSONDate
+	DtStop        rlib.JSONDate
+	PeriodGSR     float64
+	EndReceivable float64
+	EndSecDep     float64
+	CreateTS      rlib.JSONDateTime
+	CreateBy      int64
+}
+
+// RRSnapshotSearchResponse is the response to a request for the list of
+// rent roll snapshots
+type RRSnapshotSearchResponse struct {
+	Status  string           `json:"status"`
+	Total   int64            `json:"total"`
+	Records []RRSnapshotGrid `json:"records"`
+}
+
+// RRSnapshotGetResponse is the response to a request for one rent roll
+// snapshot. Records are the rentroll rows as they were when the snapshot was
+// taken, in the same form as the rentroll view.
+type RRSnapshotGetResponse struct {
+	Status   string                    `json:"status"`
+	Total    int64                     `json:"total"`
+	Snapshot RRSnapshotGrid            `json:"snapshot"`
+	Records  []rlib.RentRollStaticInfo `json:"records"`
+}
+
+// RRSnapshotSaveForm holds the period of a new rent roll snapshot
+type RRSnapshotSaveForm struct {
+	DtStart rlib.JSONDate
+	DtStop  rlib.JSONDate
+}
+
+// SaveRRSnapshotInput is the input data format for a Save command
+type SaveRRSnapshotInput struct {
+	Status   string             `json:"status"`
+	FormName string             `json:"name"`
+	Record   RRSnapshotSaveForm `json:"record"`
+}
+
+// DeleteRRSnapshotForm holds the RRSID of the snapshot to delete
+type DeleteRRSnapshotForm struct {
+	RRSID int64
+}
+
+// SvcHandlerRRSnapshot dispatches the web request to the appropriate handler:
+//
+// The server command can be:
+//      get
+//      save
+//      delete
+//-----------------------------------------------------------------------------------
+func SvcHandlerRRSnapshot(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	funcname := "SvcHandlerRRSnapshot"
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("Request: %s:  BID = %d,  RRSID = %d\n", d.wsSearchReq.Cmd, d.BID, d.ID)
+
+	switch d.wsSearchReq.Cmd {
+	case "get":
+		if d.ID <= 0 {
+			SvcSearchHandlerRRSnapshots(w, r, d)
+		} else {
+			getRRSnapshot(w, r, d)
+		}
+	case "save":
+		saveRRSnapshot(w, r, d)
+	case "delete":
+		deleteRRSnapshot(w, r, d)
+	default:
+		err := fmt.Errorf("Unhandled command: %s", d.wsSearchReq.Cmd)
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+}
+
+// rrSnapshotGrid returns the grid record for snapshot a
+func rrSnapshotGrid(a *rlib.RentRollSnapshot) RRSnapshotGrid {
+	var q RRSnapshotGrid
+	rlib.MigrateStructVals(a, &q)
+	q.BUD = getBUDFromBIDList(a.BID)
+	return q
+}
+
+// SvcSearchHandlerRRSnapshots returns the rent roll snapshots for business d.BID
+// wsdoc {
+//  @Title  Search Rent Roll Snapshots
+//	@URL /v1/rrsnap/:BUI
+//  @Method  POST
+//	@Synopsis Return the rent roll snapshots of a business
+//  @Descr  Returns every rent roll snapshot of the business, the most recent period first.
+//  @Descr  The rows of the snapshots are not included.
+//	@Input WebGridSearchRequest
+//  @Response RRSnapshotSearchResponse
+// wsdoc }
+func SvcSearchHandlerRRSnapshots(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "SvcSearchHandlerRRSnapshots"
+		g        RRSnapshotSearchResponse
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	m, err := rlib.GetRentRollSnapshots(d.BID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	for i := 0; i < len(m); i++ {
+		q := rrSnapshotGrid(&m[i])
+		q.Recid = int64(i)
+		g.Records = append(g.Records, q)
+	}
+	g.Total = int64(len(g.Records))
+	g.Status = "success"
+	w.Header().Set("Content-Type", "application/json")
+	SvcWriteResponse(&g, w)
+}
+
+// getRRSnapshot returns the requested rent roll snapshot
+// wsdoc {
+//  @Title  Get Rent Roll Snapshot
+//	@URL /v1/rrsnap/:BUI/:RRSID
+//  @Method  GET
+//	@Synopsis Get a rent roll snapshot
+//  @Description  Return snapshot :RRSID with its rentroll rows exactly as they were when the
+//  @Description  snapshot was taken.
+//	@Input WebGridSearchRequest
+//  @Response RRSnapshotGetResponse
+// wsdoc }
+func getRRSnapshot(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "getRRSnapshot"
+		g        RRSnapshotGetResponse
+	)
+	rlib.Console("entered %s.  RRSID = %d\n", funcname, d.ID)
+	a, err := rlib.GetRentRollSnapshot(d.ID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	if a.RRSID > 0 && a.BID == d.BID {
+		g.Snapshot = rrSnapshotGrid(&a)
+		if g.Records, err = rlib.GetRentRollSnapshotRows(&a); err != nil {
+			SvcGridErrorReturn(w, err, funcname)
+			return
+		}
+		for i := 0; i < len(g.Records); i++ {
+			g.Records[i].Recid = int64(i)
+		}
+	}
+	g.Total = int64(len(g.Records))
+	g.Status = "success"
+	SvcWriteResponse(&g, w)
+}
+
+// saveRRSnapshot takes a rent roll snapshot
+// wsdoc {
+//  @Title  Save Rent Roll Snapshot
+//	@URL /v1/rrsnap/:BUI
+//  @Method  POST
+//	@Synopsis Take a rent roll snapshot
+//  @Description  Saves the rentroll of the business for DtStart - DtStop as a snapshot.
+//  @Description  A business has only one snapshot for a period, delete the existing one to
+//  @Description  take it again.  A snapshot of the previous month is taken automatically at
+//  @Description  the start of every month.
+//	@Input SaveRRSnapshotInput
+//  @Response SvcStatusResponse
+// wsdoc }
+func saveRRSnapshot(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "saveRRSnapshot"
+		foo      SaveRRSnapshotInput
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	if err := json.Unmarshal([]byte(d.data), &foo); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	d1 := time.Time(foo.Record.DtStart)
+	d2 := time.Time(foo.Record.DtStop)
+	if !d2.After(d1) {
+		e := fmt.Errorf("%s: DtStop must be after DtStart", funcname)
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	a, err := rlib.CreateRentRollSnapshot(d.BID, &d1, &d2, d.UID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	SvcWriteSuccessResponseWithID(w, a.RRSID)
+}
+
+// deleteRRSnapshot removes a rent roll snapshot
+// wsdoc {
+//  @Title  Delete Rent Roll Snapshot
+//	@URL /v1/rrsnap/:BUI/:RRSID
+//  @Method  POST
+//	@Synopsis Delete a rent roll snapshot
+//  @Description  Deletes the snapshot so that the snapshot of its period can be taken again.
+//	@Input DeleteRRSnapshotForm
+//  @Response SvcStatusResponse
+// wsdoc }
+func deleteRRSnapshot(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "deleteRRSnapshot"
+		del      DeleteRRSnapshotForm
+	)
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("record data = %s\n", d.data)
+
+	if err := json.Unmarshal([]byte(d.data), &del); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	a, err := rlib.GetRentRollSnapshot(del.RRSID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	if a.BID != d.BID {
+		e := fmt.Errorf("%s: rent roll snapshot %d does not belong to business %d", funcname, del.RRSID, d.BID)
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	if err = rlib.DeleteRentRollSnapshot(del.RRSID); err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	SvcWriteSuccessResponse(w)
+}
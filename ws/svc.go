@@ -65,6 +65,7 @@ type WebGridSearchRequestJSON struct {
 	SearchDtStart rlib.JSONDate `json:"searchDtStart"` // for time-sensitive searches
 	SearchDtStop  rlib.JSONDate `json:"searchDtStop"`  // for time-sensitive searches
 	Bool1         bool          `json:"Bool1"`         // a general purpose bool flag for postData from client
+	Export        string        `json:"export"`        // csv or ndjson to export the complete result set, see gridexport.go
 }
 
 // WebGridSearchRequest is a struct suitable for describing a webservice operation.
@@ -79,6 +80,7 @@ type WebGridSearchRequest struct {
 	SearchDtStart time.Time   `json:"searchDtStart"` // for time-sensitive searches
 	SearchDtStop  time.Time   `json:"searchDtStop"`  // for time-sensitive searches
 	Bool1         bool        `json:"Bool1"`         // a general purpose bool flag for postData from client
+	Export        string      `json:"export"`        // csv or ndjson to export the complete result set, see gridexport.go
 }
 
 // WebFormRequest is a struct suitable for describing a webservice operation.
@@ -141,6 +143,7 @@ type ServiceData struct {
 // Svcs is the table of all service handlers
 var Svcs = []ServiceHandler{
 	{"exportaccounts", SvcExportGLAccounts, true},
+	{"exportjournal", SvcExportGLJournal, true},
 	{"importaccounts", SvcImportGLAccounts, true},
 	{"account", SvcFormHandlerGLAccounts, true},
 	{"accountlist", SvcAccountsList, true},
@@ -150,6 +153,11 @@ var Svcs = []ServiceHandler{
 	{"ars", SvcSearchHandlerARs, true},
 	{"asm", SvcFormHandlerAssessment, true},
 	{"asms", SvcSearchHandlerAssessments, true},
+	{"bill", SvcHandlerBill, true},
+	{"billpayment", SvcHandlerBillPayment, true},
+	{"bizgroup", SvcHandlerBusinessGroup, false},
+	{"clonebiz", SvcHandlerCloneBusiness, true},
+	{"csvload", SvcHandlerCSVLoad, false},
 	{"dep", SvcHandlerDepository, true},
 	{"depmeth", SvcHandlerDepositMethod, true},
 	{"deposit", SvcHandlerDeposit, true},
@@ -157,6 +165,8 @@ var Svcs = []ServiceHandler{
 	{"discon", SvcDisableConsole, false},
 	{"encon", SvcEnableConsole, false},
 	{"expense", SvcHandlerExpense, false},
+	{"glexports", SvcHandlerGLExports, true},
+	{"kpi", SvcHandlerKPI, true},
 	{"ledgers", getLedgerGrid, true},
 	{"parentaccounts", SvcParentAccountsList, true},
 	{"payorfund", SvcHandlerTotalUnallocFund, true},
@@ -177,7 +187,9 @@ var Svcs = []ServiceHandler{
 	{"rentalagr", SvcFormHandlerRentalAgreement, true},
 	{"rentalagrs", SvcSearchHandlerRentalAgr, true},
 	{"rentalagrtd", SvcRentalAgreementTypeDown, true},
+	{"reportsched", SvcHandlerReportSchedule, true},
 	{"rr", SvcRR, true},
+	{"rrsnap", SvcHandlerRRSnapshot, true},
 	{"rt", SvcHandlerRentableType, true},
 	{"rmr", SvcHandlerRentableMarketRates, true},
 	{"rtlist", SvcRentableTypesTD, true},
@@ -191,7 +203,10 @@ var Svcs = []ServiceHandler{
 	{"uilists", SvcUILists, false},
 	{"uival", SvcUIVal, false},
 	{"unpaidasms", SvcHandlerGetUnpaidAsms, true},
+	{"vendor", SvcHandlerVendor, true},
 	{"version", SvcHandlerVersion, false},
+	{"webhook", SvcHandlerWebhook, true},
+	{"webhookdelivery", SvcHandlerWebhookDelivery, true},
 }
 
 // V1ServiceHandler is the main dispatch point for WEB SERVICE requests
@@ -487,7 +502,7 @@ func getPOSTdata(w http.ResponseWriter, r *http.Request, d *ServiceData) error {
 		return e
 	}
 	rlib.MigrateStructVals(&wjs, &d.wsSearchReq)
-	return err
+	return gridExportInit(w, d)
 }
 
 func getGETdata(w http.ResponseWriter, r *http.Request, d *ServiceData) error {
@@ -542,6 +557,7 @@ func showWebRequest(d *ServiceData) {
 		rlib.Console("\t\tCmd           = %s\n", d.wsSearchReq.Cmd)
 		rlib.Console("\t\tLimit         = %d\n", d.wsSearchReq.Limit)
 		rlib.Console("\t\tOffset        = %d\n", d.wsSearchReq.Offset)
+		rlib.Console("\t\tExport        = %s\n", d.wsSearchReq.Export)
 		rlib.Console("\t\tsearchLogic   = %s\n", d.wsSearchReq.SearchLogic)
 		rlib.Console("\t\tsearchDtStart = %s\n", time.Time(d.wsSearchReq.SearchDtStart).Format(rlib.RRDATEFMT4))
 		rlib.Console("\t\tsearchDtStop  = %s\n", time.Time(d.wsSearchReq.SearchDtStop).Format(rlib.RRDATEFMT4))
@@ -0,0 +1,626 @@
+package ws
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"rentroll/bizlogic"
+	"rentroll/rlib"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The /v2 API is a resource oriented alternative to the /v1 services, which
+// are shaped around the requests made by w2ui grids and forms.  Resources
+// are addressed as
+//
+//      /v2/{bud}/{resource}            GET = list, POST = create
+//      /v2/{bud}/{resource}/{id}       GET = read, PUT = update, DELETE = delete
+//
+// Lists accept the query parameters limit, offset, sort, and any column of
+// the resource as an equality filter, for example:
+//
+//      /v2/REX/rentables?RentableName=309+Rexford&sort=-RID&limit=20
+//
+// Errors are returned with an appropriate HTTP status and a V2ErrorResponse
+// body.
+//-----------------------------------------------------------------------------
+
+// V2DefaultLimit is the number of resources returned by a list request that
+// does not specify a limit.  V2MaxLimit is the most that will be returned.
+const (
+	V2DefaultLimit = 100
+	V2MaxLimit     = 1000
+)
+
+// V2OpenAPIFile is the OpenAPI document served at /v2/openapi.json.  It is
+// generated from the wsdoc comment blocks by tools/openapi.
+var V2OpenAPIFile = "openapi.json"
+
+// V2Request describes a parsed /v2 request
+type V2Request struct {
+	BID      int64      // business
+	BUD      string     // business unit designation as it appeared in the url
+	Resource string     // name of the resource
+	ID       int64      // id of the resource, 0 if not supplied
+	Limit    int        // max number of items to return in a list
+	Offset   int        // number of items to skip in a list
+	Sort     []string   // columns to sort by, a leading - means descending
+	Filter   [][]string // column, value pairs that a list must match
+	Body     []byte     // the request body for POST and PUT
+}
+
+// V2ErrorDetail describes a single business rule violation
+type V2ErrorDetail struct {
+	Errno   int    `json:"errno"`
+	Message string `json:"message"`
+}
+
+// V2Error is the body of the error returned for a failed /v2 request
+type V2Error struct {
+	Status  int             `json:"status"`            // HTTP status code
+	Errno   int             `json:"errno,omitempty"`   // BizError number if there is exactly one
+	Message string          `json:"message"`           // description of the problem
+	Details []V2ErrorDetail `json:"details,omitempty"` // all the BizErrors
+}
+
+// V2ErrorResponse wraps a V2Error
+type V2ErrorResponse struct {
+	Error V2Error `json:"error"`
+}
+
+// V2ListResponse is the response to a list request
+type V2ListResponse struct {
+	Data   []interface{} `json:"data"`
+	Total  int64         `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// V2ItemResponse is the response to a read request
+type V2ItemResponse struct {
+	Data interface{} `json:"data"`
+}
+
+// V2CreateResponse is the response to a successful create request
+type V2CreateResponse struct {
+	ID int64 `json:"id"`
+}
+
+// V2Resource describes a resource available through the /v2 API.  List and
+// read requests are handled generically from Table, IDCol and Scan.  Create,
+// Update and Delete are optional; if nil the method is not allowed.
+type V2Resource struct {
+	Name   string                                          // name used in the url
+	Table  string                                          // database table holding the resource
+	IDCol  string                                          // the column holding the resource's id
+	Scan   func(rows *sql.Rows) (interface{}, error)       // reads one resource from the result of a SELECT of RRdb.DBFields[Table]
+	Create func(d *V2Request) (int64, []bizlogic.BizError) // creates a resource from d.Body
+	Update func(d *V2Request) []bizlogic.BizError          // updates resource d.ID from d.Body
+	Delete func(d *V2Request) []bizlogic.BizError          // deletes resource d.ID
+}
+
+// V2Resources is the table of all the /v2 resources
+var V2Resources = []V2Resource{
+	{"accounts", "GLAccount", "LID", v2ScanGLAccount, nil, nil, nil},
+	{"assessments", "Assessments", "ASMID", v2ScanAssessment, nil, nil, nil},
+	{"bills", "Bill", "BILLID", v2ScanBill, v2CreateBill, v2UpdateBill, v2DeleteBill},
+	{"receipts", "Receipt", "RCPTID", v2ScanReceipt, nil, nil, nil},
+	{"rentables", "Rentable", "RID", v2ScanRentable, nil, nil, nil},
+	{"rentalagreements", "RentalAgreement", "RAID", v2ScanRentalAgreement, nil, nil, nil},
+	{"transactants", "Transactant", "TCID", v2ScanTransactant, nil, nil, nil},
+	{"vendors", "Vendor", "VENDID", v2ScanVendor, v2CreateVendor, v2UpdateVendor, nil},
+}
+
+// V2ServiceHandler is the main dispatch point for /v2 requests
+//-----------------------------------------------------------------------------
+func V2ServiceHandler(w http.ResponseWriter, r *http.Request) {
+	funcname := "V2ServiceHandler"
+	rlib.Console("Entered %s: %s %s\n", funcname, r.Method, r.URL.Path)
+
+	pe := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pe) == 2 && pe[1] == "openapi.json" {
+		v2OpenAPIHandler(w, r)
+		return
+	}
+	if len(pe) < 3 || len(pe) > 4 {
+		V2ErrorReturn(w, http.StatusNotFound, fmt.Errorf("resource not found: %s", r.URL.Path))
+		return
+	}
+
+	var d V2Request
+	var err error
+	d.BUD = pe[1]
+	d.Resource = pe[2]
+	d.BID, err = getBIDfromBUI(d.BUD)
+	if err != nil || d.BID <= 0 {
+		V2ErrorReturn(w, http.StatusNotFound, fmt.Errorf("business not found: %s", d.BUD))
+		return
+	}
+	if len(pe) == 4 {
+		d.ID, err = strconv.ParseInt(pe[3], 10, 64)
+		if err != nil || d.ID <= 0 {
+			V2ErrorReturn(w, http.StatusBadRequest, fmt.Errorf("invalid id: %s", pe[3]))
+			return
+		}
+	}
+
+	var rs *V2Resource
+	for i := 0; i < len(V2Resources); i++ {
+		if V2Resources[i].Name == d.Resource {
+			rs = &V2Resources[i]
+			break
+		}
+	}
+	if rs == nil {
+		V2ErrorReturn(w, http.StatusNotFound, fmt.Errorf("unknown resource: %s", d.Resource))
+		return
+	}
+
+	if r.Method == "POST" || r.Method == "PUT" {
+		if d.Body, err = ioutil.ReadAll(r.Body); err != nil {
+			V2ErrorReturn(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	switch {
+	case r.Method == "GET" && d.ID == 0:
+		if err = v2ParseListParams(r, rs, &d); err != nil {
+			V2ErrorReturn(w, http.StatusBadRequest, err)
+			return
+		}
+		v2List(w, rs, &d)
+	case r.Method == "GET":
+		v2Get(w, rs, &d)
+	case r.Method == "POST" && d.ID == 0 && rs.Create != nil:
+		id, errlist := rs.Create(&d)
+		if len(errlist) > 0 {
+			V2ErrListReturn(w, errlist)
+			return
+		}
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/%s/%d", d.BUD, d.Resource, id))
+		v2WriteJSON(w, http.StatusCreated, &V2CreateResponse{ID: id})
+	case r.Method == "PUT" && d.ID > 0 && rs.Update != nil:
+		if !v2Exists(w, rs, &d) {
+			return
+		}
+		if errlist := rs.Update(&d); len(errlist) > 0 {
+			V2ErrListReturn(w, errlist)
+			return
+		}
+		v2Get(w, rs, &d)
+	case r.Method == "DELETE" && d.ID > 0 && rs.Delete != nil:
+		if !v2Exists(w, rs, &d) {
+			return
+		}
+		if errlist := rs.Delete(&d); len(errlist) > 0 {
+			V2ErrListReturn(w, errlist)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", v2Allowed(rs, d.ID))
+		V2ErrorReturn(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed on %s", r.Method, r.URL.Path))
+	}
+}
+
+// v2Allowed returns the list of methods allowed on rs for the Allow header
+func v2Allowed(rs *V2Resource, id int64) string {
+	if id == 0 {
+		if rs.Create != nil {
+			return "GET, POST"
+		}
+		return "GET"
+	}
+	m := []string{"GET"}
+	if rs.Update != nil {
+		m = append(m, "PUT")
+	}
+	if rs.Delete != nil {
+		m = append(m, "DELETE")
+	}
+	return strings.Join(m, ", ")
+}
+
+// v2Columns returns the database columns of rs
+func v2Columns(rs *V2Resource) []string {
+	return strings.Split(rlib.RRdb.DBFields[rs.Table], ",")
+}
+
+// v2IsColumn returns true if col is a column of rs
+func v2IsColumn(rs *V2Resource, col string) bool {
+	cols := v2Columns(rs)
+	for i := 0; i < len(cols); i++ {
+		if cols[i] == col {
+			return true
+		}
+	}
+	return false
+}
+
+// v2ParseListParams reads the pagination, sort and filter parameters of a
+// list request.  Only columns of the resource may be used to sort or filter.
+func v2ParseListParams(r *http.Request, rs *V2Resource, d *V2Request) error {
+	var err error
+	qp := r.URL.Query()
+	d.Limit = V2DefaultLimit
+	if s := qp.Get("limit"); len(s) > 0 {
+		if d.Limit, err = strconv.Atoi(s); err != nil || d.Limit <= 0 {
+			return fmt.Errorf("invalid limit: %s", s)
+		}
+		if d.Limit > V2MaxLimit {
+			d.Limit = V2MaxLimit
+		}
+	}
+	if s := qp.Get("offset"); len(s) > 0 {
+		if d.Offset, err = strconv.Atoi(s); err != nil || d.Offset < 0 {
+			return fmt.Errorf("invalid offset: %s", s)
+		}
+	}
+	if s := qp.Get("sort"); len(s) > 0 {
+		sa := strings.Split(s, ",")
+		for i := 0; i < len(sa); i++ {
+			if !v2IsColumn(rs, strings.TrimPrefix(sa[i], "-")) {
+				return fmt.Errorf("cannot sort by %s", sa[i])
+			}
+			d.Sort = append(d.Sort, sa[i])
+		}
+	}
+	for k, v := range qp {
+		if k == "limit" || k == "offset" || k == "sort" {
+			continue
+		}
+		if !v2IsColumn(rs, k) || k == "BID" {
+			return fmt.Errorf("cannot filter by %s", k)
+		}
+		d.Filter = append(d.Filter, []string{k, v[0]})
+	}
+	return nil
+}
+
+// v2List writes a page of the resources of type rs in business d.BID
+// wsdoc {
+//  @Title  List Resources
+//	@URL /v2/:BUD/:RESOURCE?limit=:N&offset=:N&sort=:COLS&:COL=:VALUE
+//  @Method  GET
+//	@Synopsis List the resources of a business
+//  @Description  RESOURCE is one of accounts, assessments, bills, receipts, rentables,
+//  @Description  rentalagreements, transactants, vendors. The default limit is 100.
+//  @Description  sort is a comma separated list of columns, a leading - sorts in
+//  @Description  descending order. Any other column may be used as an equality filter.
+//  @Response V2ListResponse
+// wsdoc }
+func v2List(w http.ResponseWriter, rs *V2Resource, d *V2Request) {
+	var (
+		g    = V2ListResponse{Data: []interface{}{}, Limit: d.Limit, Offset: d.Offset}
+		args = []interface{}{d.BID}
+		whr  = "BID=?"
+	)
+	for i := 0; i < len(d.Filter); i++ {
+		whr += " AND " + d.Filter[i][0] + "=?"
+		args = append(args, d.Filter[i][1])
+	}
+	order := rs.IDCol + " ASC"
+	if len(d.Sort) > 0 {
+		var sa []string
+		for i := 0; i < len(d.Sort); i++ {
+			if strings.HasPrefix(d.Sort[i], "-") {
+				sa = append(sa, d.Sort[i][1:]+" DESC")
+			} else {
+				sa = append(sa, d.Sort[i]+" ASC")
+			}
+		}
+		order = strings.Join(sa, ",")
+	}
+
+	err := rlib.RRdb.Dbrr.QueryRow("SELECT COUNT(*) FROM "+rs.Table+" WHERE "+whr, args...).Scan(&g.Total)
+	if err != nil {
+		V2ErrorReturn(w, http.StatusInternalServerError, err)
+		return
+	}
+	q := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT %d OFFSET %d", rlib.RRdb.DBFields[rs.Table], rs.Table, whr, order, d.Limit, d.Offset)
+	rows, err := rlib.RRdb.Dbrr.Query(q, args...)
+	if err != nil {
+		V2ErrorReturn(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		a, err := rs.Scan(rows)
+		if err != nil {
+			V2ErrorReturn(w, http.StatusInternalServerError, err)
+			return
+		}
+		g.Data = append(g.Data, a)
+	}
+	if err = rows.Err(); err != nil {
+		V2ErrorReturn(w, http.StatusInternalServerError, err)
+		return
+	}
+	v2WriteJSON(w, http.StatusOK, &g)
+}
+
+// v2Read returns resource d.ID of type rs if it belongs to business d.BID.
+// If it is not found the returned value is nil.
+func v2Read(rs *V2Resource, d *V2Request) (interface{}, error) {
+	q := fmt.Sprintf("SELECT %s FROM %s WHERE BID=? AND %s=?", rlib.RRdb.DBFields[rs.Table], rs.Table, rs.IDCol)
+	rows, err := rlib.RRdb.Dbrr.Query(q, d.BID, d.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	return rs.Scan(rows)
+}
+
+// v2Get writes resource d.ID of type rs
+// wsdoc {
+//  @Title  Get Resource
+//	@URL /v2/:BUD/:RESOURCE/:ID
+//  @Method  GET
+//	@Synopsis Get a single resource
+//  @Description  Returns 404 if the resource does not exist in business BUD.
+//  @Response V2ItemResponse
+// wsdoc }
+func v2Get(w http.ResponseWriter, rs *V2Resource, d *V2Request) {
+	a, err := v2Read(rs, d)
+	if err != nil {
+		V2ErrorReturn(w, http.StatusInternalServerError, err)
+		return
+	}
+	if a == nil {
+		V2ErrorReturn(w, http.StatusNotFound, fmt.Errorf("%s %d not found", d.Resource, d.ID))
+		return
+	}
+	v2WriteJSON(w, http.StatusOK, &V2ItemResponse{Data: a})
+}
+
+// v2Exists returns true if resource d.ID of type rs exists in business d.BID.
+// Otherwise it writes the error response and returns false.
+func v2Exists(w http.ResponseWriter, rs *V2Resource, d *V2Request) bool {
+	a, err := v2Read(rs, d)
+	if err != nil {
+		V2ErrorReturn(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if a == nil {
+		V2ErrorReturn(w, http.StatusNotFound, fmt.Errorf("%s %d not found", d.Resource, d.ID))
+		return false
+	}
+	return true
+}
+
+// v2WriteJSON writes g to w with the supplied HTTP status
+func v2WriteJSON(w http.ResponseWriter, status int, g interface{}) {
+	b, err := json.Marshal(g)
+	if err != nil {
+		rlib.Ulog("v2WriteJSON: %s\n", err.Error())
+		status = http.StatusInternalServerError
+		b, _ = json.Marshal(&V2ErrorResponse{Error: V2Error{Status: status, Message: err.Error()}})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	SvcWrite(w, b)
+}
+
+// V2ErrorReturn writes an error response with the supplied HTTP status
+func V2ErrorReturn(w http.ResponseWriter, status int, err error) {
+	rlib.Console("V2ErrorReturn: %d %s\n", status, err.Error())
+	v2WriteJSON(w, status, &V2ErrorResponse{Error: V2Error{Status: status, Message: err.Error()}})
+}
+
+// V2ErrListReturn writes the business logic errors in errlist. If any of
+// them is a system error (Errno <= 0) the status is 500, otherwise it is 422.
+func V2ErrListReturn(w http.ResponseWriter, errlist []bizlogic.BizError) {
+	status := http.StatusUnprocessableEntity
+	e := V2Error{Message: strings.TrimSpace(bizlogic.BizErrorListToError(errlist).Error())}
+	for i := 0; i < len(errlist); i++ {
+		if errlist[i].Errno <= 0 {
+			status = http.StatusInternalServerError
+		}
+		e.Details = append(e.Details, V2ErrorDetail{Errno: errlist[i].Errno, Message: errlist[i].Message})
+	}
+	if len(errlist) == 1 {
+		e.Errno = errlist[0].Errno
+	}
+	e.Status = status
+	v2WriteJSON(w, status, &V2ErrorResponse{Error: e})
+}
+
+// v2OpenAPIHandler serves the OpenAPI document describing the web services
+// wsdoc {
+//  @Title  OpenAPI Document
+//	@URL /v2/openapi.json
+//  @Method  GET
+//	@Synopsis Return the OpenAPI description of the web services
+//  @Description  The document is generated from the wsdoc comments in the source.
+//  @Response OpenAPI 3.0 JSON
+// wsdoc }
+func v2OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadFile(V2OpenAPIFile)
+	if err != nil {
+		V2ErrorReturn(w, http.StatusNotFound, fmt.Errorf("OpenAPI document is not available"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+//=============================================================================
+//  R E S O U R C E S
+//=============================================================================
+
+func v2ScanGLAccount(rows *sql.Rows) (interface{}, error) {
+	var a rlib.GLAccount
+	rlib.ReadGLAccounts(rows, &a)
+	return a, nil
+}
+
+func v2ScanAssessment(rows *sql.Rows) (interface{}, error) {
+	var a rlib.Assessment
+	rlib.ReadAssessments(rows, &a)
+	return a, nil
+}
+
+func v2ScanReceipt(rows *sql.Rows) (interface{}, error) {
+	var a rlib.Receipt
+	rlib.ReadReceipts(rows, &a)
+	return a, nil
+}
+
+func v2ScanRentable(rows *sql.Rows) (interface{}, error) {
+	var a rlib.Rentable
+	err := rlib.ReadRentables(rows, &a)
+	return a, err
+}
+
+func v2ScanRentalAgreement(rows *sql.Rows) (interface{}, error) {
+	var a rlib.RentalAgreement
+	err := rlib.ReadRentalAgreements(rows, &a)
+	return a, err
+}
+
+func v2ScanTransactant(rows *sql.Rows) (interface{}, error) {
+	var a rlib.Transactant
+	rlib.ReadTransactants(rows, &a)
+	return a, nil
+}
+
+func v2ScanVendor(rows *sql.Rows) (interface{}, error) {
+	var a rlib.Vendor
+	err := rlib.ReadVendors(rows, &a)
+	return a, err
+}
+
+func v2ScanBill(rows *sql.Rows) (interface{}, error) {
+	var a rlib.Bill
+	if err := rlib.ReadBills(rows, &a); err != nil {
+		return a, err
+	}
+	var err error
+	a.BI, err = rlib.GetBillItems(a.BILLID)
+	return a, err
+}
+
+// v2Unmarshal decodes the request body into a
+func v2Unmarshal(d *V2Request, a interface{}) []bizlogic.BizError {
+	if err := json.Unmarshal(d.Body, a); err != nil {
+		return []bizlogic.BizError{{Errno: bizlogic.InvalidField, Message: "invalid request body: " + err.Error()}}
+	}
+	return nil
+}
+
+// v2CreateVendor creates a vendor
+// wsdoc {
+//  @Title  Create Vendor
+//	@URL /v2/:BUD/vendors
+//  @Method  POST
+//	@Synopsis Create a Vendor
+//  @Description  Returns 201 and the new VENDID. The Location header is the url of the new vendor.
+//	@Input rlib.Vendor
+//  @Response V2CreateResponse
+// wsdoc }
+func v2CreateVendor(d *V2Request) (int64, []bizlogic.BizError) {
+	var a rlib.Vendor
+	if e := v2Unmarshal(d, &a); len(e) > 0 {
+		return 0, e
+	}
+	a.VENDID = 0
+	a.BID = d.BID
+	e := bizlogic.SaveVendor(&a)
+	return a.VENDID, e
+}
+
+// v2UpdateVendor updates a vendor
+// wsdoc {
+//  @Title  Update Vendor
+//	@URL /v2/:BUD/vendors/:VENDID
+//  @Method  PUT
+//	@Synopsis Update a Vendor
+//  @Description  All fields must be supplied. Returns the updated vendor.
+//	@Input rlib.Vendor
+//  @Response V2ItemResponse
+// wsdoc }
+func v2UpdateVendor(d *V2Request) []bizlogic.BizError {
+	var a rlib.Vendor
+	if e := v2Unmarshal(d, &a); len(e) > 0 {
+		return e
+	}
+	a.VENDID = d.ID
+	a.BID = d.BID
+	return bizlogic.SaveVendor(&a)
+}
+
+// v2CreateBill creates a bill and its journal entries
+// wsdoc {
+//  @Title  Create Bill
+//	@URL /v2/:BUD/bills
+//  @Method  POST
+//	@Synopsis Create a vendor Bill
+//  @Description  The bill items are supplied in BI. Returns 201 and the new BILLID.
+//	@Input rlib.Bill
+//  @Response V2CreateResponse
+// wsdoc }
+func v2CreateBill(d *V2Request) (int64, []bizlogic.BizError) {
+	var a rlib.Bill
+	if e := v2Unmarshal(d, &a); len(e) > 0 {
+		return 0, e
+	}
+	a.BILLID = 0
+	a.BID = d.BID
+	a.FLAGS = rlib.BILLUNPAID
+	e := bizlogic.SaveBill(&a)
+	return a.BILLID, e
+}
+
+// v2UpdateBill updates a bill. If its amounts, accounts or date change the
+// bill is reversed and replaced, so the returned bill may have a new BILLID.
+// wsdoc {
+//  @Title  Update Bill
+//	@URL /v2/:BUD/bills/:BILLID
+//  @Method  PUT
+//	@Synopsis Update a vendor Bill
+//  @Description  If the financial content changes the bill is reversed and a new bill is created.
+//	@Input rlib.Bill
+//  @Response V2ItemResponse
+// wsdoc }
+func v2UpdateBill(d *V2Request) []bizlogic.BizError {
+	var a rlib.Bill
+	if e := v2Unmarshal(d, &a); len(e) > 0 {
+		return e
+	}
+	old, err := rlib.GetBill(d.ID)
+	if err != nil {
+		return bizlogic.AddErrToBizErrlist(err, nil)
+	}
+	a.BILLID = d.ID
+	a.BID = d.BID
+	a.FLAGS = old.FLAGS
+	e := bizlogic.SaveBill(&a)
+	d.ID = a.BILLID
+	return e
+}
+
+// v2DeleteBill reverses a bill and its payments
+// wsdoc {
+//  @Title  Delete Bill
+//	@URL /v2/:BUD/bills/:BILLID
+//  @Method  DELETE
+//	@Synopsis Reverse a vendor Bill
+//  @Description  The bill and all payments made against it are reversed as of today. Returns 204.
+//  @Response none
+// wsdoc }
+func v2DeleteBill(d *V2Request) []bizlogic.BizError {
+	a, err := rlib.GetBill(d.ID)
+	if err != nil {
+		return bizlogic.AddErrToBizErrlist(err, nil)
+	}
+	now := time.Now()
+	return bizlogic.ReverseBill(&a, &now)
+}
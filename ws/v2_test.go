@@ -0,0 +1,40 @@
+// +build sqlite
+
+package ws
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"rentroll/rlib"
+	"rentroll/rrtest"
+	"strings"
+	"testing"
+)
+
+// A vendor created through /v2 is saved for the business in the path. A
+// header claiming to identify the person making the request is not trusted,
+// the vendor's CreateBy and LastModBy are not taken from it.
+func TestV2CreateVendor(t *testing.T) {
+	rrtest.OpenDB(t)
+	b := rrtest.NewBusiness(t, "REX")
+	p := rlib.DirectoryPerson{FirstName: "Pat", LastName: "Jones"}
+	if _, err := rlib.InsertDirectoryPerson(&p); err != nil {
+		t.Fatalf("InsertDirectoryPerson: %s", err.Error())
+	}
+
+	r := httptest.NewRequest("POST", "/v2/REX/vendors", strings.NewReader(`{"Name":"Acme Plumbing"}`))
+	r.Header.Set("X-RentRoll-UID", fmt.Sprintf("%d", p.UID))
+	w := httptest.NewRecorder()
+	V2ServiceHandler(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expect status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	v, err := rlib.GetVendorByName(b.BID, "Acme Plumbing")
+	if err != nil {
+		t.Fatalf("GetVendorByName: %s", err.Error())
+	}
+	if v.CreateBy != 0 || v.LastModBy != 0 {
+		t.Errorf("expect CreateBy and LastModBy 0, got %d and %d", v.CreateBy, v.LastModBy)
+	}
+}
@@ -0,0 +1,316 @@
+package ws
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"rentroll/bizlogic"
+	"rentroll/rlib"
+	"strconv"
+	"strings"
+)
+
+// VendorGrid contains the data from Vendor that is targeted to the UI Grid that displays
+// a list of Vendor structs
+type VendorGrid struct {
+	Recid       int64 `json:"recid"`
+	VENDID      int64
+	BID         int64
+	BUD         rlib.XJSONBud
+	Name        string
+	Address     string
+	Address2    string
+	City        string
+	State       string
+	PostalCode  string
+	Country     string
+	Phone       string
+	Email       string
+	TaxID       string
+	DefaultLID  int64
+	FLAGS       uint64
+	LastModTime rlib.JSONDateTime
+	LastModBy   int64
+	CreateTS    rlib.JSONDateTime
+	CreateBy    int64
+}
+
+// VendorSearchResponse is a response string to the search request for Vendor records
+type VendorSearchResponse struct {
+	Status  string       `json:"status"`
+	Total   int64        `json:"total"`
+	Records []VendorGrid `json:"records"`
+}
+
+// VendorSaveForm is a struct to handle direct inputs from the form
+type VendorSaveForm struct {
+	Recid      int64 `json:"recid"`
+	VENDID     int64
+	BID        int64
+	BUD        rlib.XJSONBud
+	Name       string
+	Address    string
+	Address2   string
+	City       string
+	State      string
+	PostalCode string
+	Country    string
+	Phone      string
+	Email      string
+	TaxID      string
+	DefaultLID int64
+	FLAGS      uint64
+}
+
+// SaveVendorInput is the input data format for a Save command
+type SaveVendorInput struct {
+	Recid    int64          `json:"recid"`
+	Status   string         `json:"status"`
+	FormName string         `json:"name"`
+	Record   VendorSaveForm `json:"record"`
+}
+
+// VendorGetResponse is the response to a GetVendor request
+type VendorGetResponse struct {
+	Status string     `json:"status"`
+	Record VendorGrid `json:"record"`
+}
+
+var vendorSearchFieldMap = rlib.SelectQueryFieldMap{
+	"VENDID":      {"Vendor.VENDID"},
+	"BID":         {"Vendor.BID"},
+	"Name":        {"Vendor.Name"},
+	"Address":     {"Vendor.Address"},
+	"Address2":    {"Vendor.Address2"},
+	"City":        {"Vendor.City"},
+	"State":       {"Vendor.State"},
+	"PostalCode":  {"Vendor.PostalCode"},
+	"Country":     {"Vendor.Country"},
+	"Phone":       {"Vendor.Phone"},
+	"Email":       {"Vendor.Email"},
+	"TaxID":       {"Vendor.TaxID"},
+	"DefaultLID":  {"Vendor.DefaultLID"},
+	"FLAGS":       {"Vendor.FLAGS"},
+	"LastModTime": {"Vendor.LastModTime"},
+	"LastModBy":   {"Vendor.LastModBy"},
+	"CreateTS":    {"Vendor.CreateTS"},
+	"CreateBy":    {"Vendor.CreateBy"},
+}
+
+// which fields needs to be fetch to satisfy the struct
+var vendorSearchSelectQueryFields = rlib.SelectQueryFields{
+	"Vendor.VENDID",
+	"Vendor.BID",
+	"Vendor.Name",
+	"Vendor.Address",
+	"Vendor.Address2",
+	"Vendor.City",
+	"Vendor.State",
+	"Vendor.PostalCode",
+	"Vendor.Country",
+	"Vendor.Phone",
+	"Vendor.Email",
+	"Vendor.TaxID",
+	"Vendor.DefaultLID",
+	"Vendor.FLAGS",
+	"Vendor.LastModTime",
+	"Vendor.LastModBy",
+	"Vendor.CreateTS",
+	"Vendor.CreateBy",
+}
+
+// vendorRowScan scans a result from sql row and dump it in a VendorGrid struct
+func vendorRowScan(rows *sql.Rows) (VendorGrid, error) {
+	var a rlib.Vendor
+	err := rlib.ReadVendors(rows, &a)
+	var b VendorGrid
+	rlib.MigrateStructVals(&a, &b)
+	return b, err
+}
+
+// SvcHandlerVendor dispatches the web request to the appropriate handler:
+//
+// The server command can be:
+//      get
+//      save
+//-----------------------------------------------------------------------------------
+func SvcHandlerVendor(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	funcname := "SvcHandlerVendor"
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("Request: %s:  BID = %d,  VENDID = %d\n", d.wsSearchReq.Cmd, d.BID, d.ID)
+
+	switch d.wsSearchReq.Cmd {
+	case "get":
+		if d.ID <= 0 && d.wsSearchReq.Limit > 0 {
+			SvcSearchHandlerVendors(w, r, d) // it is a query for the grid.
+		} else {
+			if d.ID < 0 {
+				SvcGridErrorReturn(w, fmt.Errorf("VendorID is required but was not specified"), funcname)
+				return
+			}
+			getVendor(w, r, d)
+		}
+	case "save":
+		saveVendor(w, r, d)
+	default:
+		err := fmt.Errorf("Unhandled command: %s", d.wsSearchReq.Cmd)
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+}
+
+// SvcSearchHandlerVendors generates a report of all Vendors defined business d.BID
+// wsdoc {
+//  @Title  Search Vendors
+//	@URL /v1/vendor/:BUI
+//  @Method  POST
+//	@Synopsis Search Vendors
+//  @Descr  Search all Vendors and return those that match the Search Logic.
+//	@Input WebGridSearchRequest
+//  @Response VendorSearchResponse
+// wsdoc }
+func SvcSearchHandlerVendors(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "SvcSearchHandlerVendors"
+		g        VendorSearchResponse
+		err      error
+		order    = "Vendor.Name ASC" // default ORDER
+		whr      = fmt.Sprintf("Vendor.BID=%d", d.BID)
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	_, orderClause := GetSearchAndSortSQL(d, vendorSearchFieldMap)
+	if len(orderClause) > 0 {
+		order = orderClause
+	}
+
+	theQuery := `
+	SELECT
+		{{.SelectClause}}
+	FROM Vendor
+	WHERE {{.WhereClause}}
+	ORDER BY {{.OrderClause}}`
+
+	qc := rlib.QueryClause{
+		"SelectClause": strings.Join(vendorSearchSelectQueryFields, ","),
+		"WhereClause":  whr,
+		"OrderClause":  order,
+	}
+
+	countQuery := rlib.RenderSQLQuery(theQuery, qc)
+	g.Total, err = rlib.GetQueryCount(countQuery)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+
+	limitAndOffsetClause := `
+	LIMIT {{.LimitClause}}
+	OFFSET {{.OffsetClause}};`
+	qc["LimitClause"] = strconv.Itoa(d.wsSearchReq.Limit)
+	qc["OffsetClause"] = strconv.Itoa(d.wsSearchReq.Offset)
+	qry := rlib.RenderSQLQuery(theQuery+limitAndOffsetClause, qc)
+
+	rows, err := rlib.RRdb.Dbrr.Query(qry)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	defer rows.Close()
+
+	i := int64(d.wsSearchReq.Offset)
+	count := 0
+	for rows.Next() {
+		q, err := vendorRowScan(rows)
+		if err != nil {
+			SvcGridErrorReturn(w, err, funcname)
+			return
+		}
+		q.Recid = i
+		q.BUD = getBUDFromBIDList(q.BID)
+		g.Records = append(g.Records, q)
+		count++ // update the count only after adding the record
+		if count >= d.wsSearchReq.Limit {
+			break // if we've added the max number requested, then exit
+		}
+		i++
+	}
+	if err = rows.Err(); err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+
+	g.Status = "success"
+	w.Header().Set("Content-Type", "application/json")
+	SvcWriteResponse(&g, w)
+}
+
+// saveVendor creates or updates a Vendor
+// wsdoc {
+//  @Title  Save Vendor
+//	@URL /v1/vendor/:BUI/:VENDID
+//  @Method  POST
+//	@Synopsis Create or update a Vendor
+//  @Description  If :VENDID is 0 a new Vendor is created, otherwise Vendor :VENDID is updated. All fields must be supplied.
+//	@Input SaveVendorInput
+//  @Response SvcStatusResponse
+// wsdoc }
+func saveVendor(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "saveVendor"
+		foo      SaveVendorInput
+	)
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("record data = %s\n", d.data)
+
+	if err := json.Unmarshal([]byte(d.data), &foo); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+
+	var a rlib.Vendor
+	rlib.MigrateStructVals(&foo.Record, &a)
+	var ok bool
+	a.BID, ok = rlib.RRdb.BUDlist[string(foo.Record.BUD)]
+	if !ok {
+		e := fmt.Errorf("%s: Could not map BID value: %s", funcname, foo.Record.BUD)
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	if errlist := bizlogic.SaveVendor(&a); len(errlist) > 0 {
+		SvcErrListReturn(w, errlist, funcname)
+		return
+	}
+	SvcWriteSuccessResponseWithID(w, a.VENDID)
+}
+
+// getVendor returns the requested Vendor
+// wsdoc {
+//  @Title  Get Vendor
+//	@URL /v1/vendor/:BUI/:VENDID
+//  @Method  GET
+//	@Synopsis Get information on a Vendor
+//  @Description  Return all fields for Vendor :VENDID
+//	@Input WebGridSearchRequest
+//  @Response VendorGetResponse
+// wsdoc }
+func getVendor(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "getVendor"
+		g        VendorGetResponse
+	)
+	rlib.Console("entered %s.  VENDID = %d\n", funcname, d.ID)
+	a, err := rlib.GetVendor(d.ID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	if a.VENDID > 0 {
+		rlib.MigrateStructVals(&a, &g.Record)
+		g.Record.BUD = getBUDFromBIDList(a.BID)
+	}
+	g.Status = "success"
+	SvcWriteResponse(&g, w)
+}
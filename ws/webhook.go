@@ -0,0 +1,322 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"rentroll/bizlogic"
+	"rentroll/rlib"
+)
+
+// WebhookGrid contains the data from Webhook that is targeted to the UI Grid
+// that displays a list of Webhook structs
+type WebhookGrid struct {
+	Recid       int64 `json:"recid"`
+	WHID        int64
+	BID         int64
+	BUD         rlib.XJSONBud
+	URL         string
+	EventTypes  string
+	FLAGS       uint64
+	LastModTime rlib.JSONDateTime
+	LastModBy   int64
+	CreateTS    rlib.JSONDateTime
+	CreateBy    int64
+}
+
+// WebhookSearchResponse is the response to a request for the list of Webhooks
+type WebhookSearchResponse struct {
+	Status  string        `json:"status"`
+	Total   int64         `json:"total"`
+	Records []WebhookGrid `json:"records"`
+}
+
+// WebhookGetResponse is the response to a GetWebhook request
+type WebhookGetResponse struct {
+	Status string      `json:"status"`
+	Record WebhookGrid `json:"record"`
+}
+
+// WebhookSaveForm is a struct to handle direct inputs from the form. The
+// Secret is write only, it is never returned. If it is empty on an update the
+// existing secret is kept.
+type WebhookSaveForm struct {
+	Recid      int64 `json:"recid"`
+	WHID       int64
+	URL        string
+	Secret     string
+	EventTypes string
+	FLAGS      uint64
+}
+
+// SaveWebhookInput is the input data format for a Save command
+type SaveWebhookInput struct {
+	Recid    int64           `json:"recid"`
+	Status   string          `json:"status"`
+	FormName string          `json:"name"`
+	Record   WebhookSaveForm `json:"record"`
+}
+
+// DeleteWebhookForm holds the WHID of the webhook to delete
+type DeleteWebhookForm struct {
+	WHID int64
+}
+
+// WebhookDeliveryGrid contains the data from WebhookDelivery that is targeted
+// to the UI Grid that displays the delivery log of a Webhook
+type WebhookDeliveryGrid struct {
+	Recid       int64 `json:"recid"`
+	WHDID       int64
+	WHID        int64
+	EVID        int64
+	EventType   string
+	ObjID       int64
+	Status      int64
+	Attempts    int64
+	NextAttempt rlib.JSONDateTime
+	LastAttempt rlib.JSONDateTime
+	HTTPStatus  int64
+	LastError   string
+}
+
+// WebhookDeliverySearchResponse is the response to a request for the
+// delivery log of a Webhook
+type WebhookDeliverySearchResponse struct {
+	Status  string                `json:"status"`
+	Total   int64                 `json:"total"`
+	Records []WebhookDeliveryGrid `json:"records"`
+}
+
+// SvcHandlerWebhook dispatches the web request to the appropriate handler:
+//
+// The server command can be:
+//      get
+//      save
+//      delete
+//-----------------------------------------------------------------------------------
+func SvcHandlerWebhook(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	funcname := "SvcHandlerWebhook"
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("Request: %s:  BID = %d,  WHID = %d\n", d.wsSearchReq.Cmd, d.BID, d.ID)
+
+	switch d.wsSearchReq.Cmd {
+	case "get":
+		if d.ID <= 0 {
+			SvcSearchHandlerWebhooks(w, r, d)
+		} else {
+			getWebhook(w, r, d)
+		}
+	case "save":
+		saveWebhook(w, r, d)
+	case "delete":
+		deleteWebhook(w, r, d)
+	default:
+		err := fmt.Errorf("Unhandled command: %s", d.wsSearchReq.Cmd)
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+}
+
+// SvcSearchHandlerWebhooks returns the Webhooks for business d.BID
+// wsdoc {
+//  @Title  Search Webhooks
+//	@URL /v1/webhook/:BUI
+//  @Method  POST
+//	@Synopsis Return the Webhooks for a business
+//  @Descr  Returns every Webhook for the business. Secrets are not returned.
+//	@Input WebGridSearchRequest
+//  @Response WebhookSearchResponse
+// wsdoc }
+func SvcSearchHandlerWebhooks(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "SvcSearchHandlerWebhooks"
+		g        WebhookSearchResponse
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	m, err := rlib.GetWebhooks(d.BID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	for i := 0; i < len(m); i++ {
+		var q WebhookGrid
+		rlib.MigrateStructVals(&m[i], &q)
+		q.Recid = int64(i)
+		q.BUD = getBUDFromBIDList(q.BID)
+		g.Records = append(g.Records, q)
+	}
+	g.Total = int64(len(g.Records))
+	g.Status = "success"
+	w.Header().Set("Content-Type", "application/json")
+	SvcWriteResponse(&g, w)
+}
+
+// getWebhook returns the requested Webhook
+// wsdoc {
+//  @Title  Get Webhook
+//	@URL /v1/webhook/:BUI/:WHID
+//  @Method  GET
+//	@Synopsis Get information on a Webhook
+//  @Description  Return all fields except the Secret for Webhook :WHID
+//	@Input WebGridSearchRequest
+//  @Response WebhookGetResponse
+// wsdoc }
+func getWebhook(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "getWebhook"
+		g        WebhookGetResponse
+	)
+	rlib.Console("entered %s.  WHID = %d\n", funcname, d.ID)
+	a, err := rlib.GetWebhook(d.ID)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	if a.WHID > 0 && a.BID == d.BID {
+		rlib.MigrateStructVals(&a, &g.Record)
+		g.Record.BUD = getBUDFromBIDList(a.BID)
+	}
+	g.Status = "success"
+	SvcWriteResponse(&g, w)
+}
+
+// saveWebhook creates or updates a Webhook
+// wsdoc {
+//  @Title  Save Webhook
+//	@URL /v1/webhook/:BUI/:WHID
+//  @Method  POST
+//	@Synopsis Create or update a Webhook
+//  @Description  If :WHID is 0 a new Webhook is created, otherwise Webhook :WHID is updated.
+//  @Description  EventTypes is a comma separated list such as receipt.created,assessment.*
+//  @Description  An empty list or * subscribes to every event.  If Secret is empty on an
+//  @Description  update the existing secret is kept.
+//	@Input SaveWebhookInput
+//  @Response SvcStatusResponse
+// wsdoc }
+func saveWebhook(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "saveWebhook"
+		foo      SaveWebhookInput
+		a        rlib.Webhook
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	if err := json.Unmarshal([]byte(d.data), &foo); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+
+	if foo.Record.WHID > 0 {
+		var err error
+		if a, err = rlib.GetWebhook(foo.Record.WHID); err != nil {
+			SvcGridErrorReturn(w, err, funcname)
+			return
+		}
+		if a.BID != d.BID {
+			e := fmt.Errorf("%s: Webhook %d does not belong to business %d", funcname, foo.Record.WHID, d.BID)
+			SvcGridErrorReturn(w, e, funcname)
+			return
+		}
+	}
+	secret := a.Secret
+	rlib.MigrateStructVals(&foo.Record, &a)
+	if len(a.Secret) == 0 {
+		a.Secret = secret
+	}
+	a.BID = d.BID
+	a.LastModBy = d.UID
+	if a.WHID == 0 {
+		a.CreateBy = d.UID
+	}
+	if errlist := bizlogic.SaveWebhook(&a); len(errlist) > 0 {
+		SvcErrListReturn(w, errlist, funcname)
+		return
+	}
+	SvcWriteSuccessResponseWithID(w, a.WHID)
+}
+
+// deleteWebhook removes a Webhook and its delivery log
+// wsdoc {
+//  @Title  Delete Webhook
+//	@URL /v1/webhook/:BUI/:WHID
+//  @Method  POST
+//	@Synopsis Delete a Webhook
+//  @Description  Deletes the Webhook and its delivery log. Pending deliveries are discarded.
+//	@Input DeleteWebhookForm
+//  @Response SvcStatusResponse
+// wsdoc }
+func deleteWebhook(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "deleteWebhook"
+		del      DeleteWebhookForm
+	)
+	rlib.Console("Entered %s\n", funcname)
+	rlib.Console("record data = %s\n", d.data)
+
+	if err := json.Unmarshal([]byte(d.data), &del); err != nil {
+		e := fmt.Errorf("%s: Error with json.Unmarshal:  %s", funcname, err.Error())
+		SvcGridErrorReturn(w, e, funcname)
+		return
+	}
+	if err := rlib.DeleteWebhook(del.WHID); err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	SvcWriteSuccessResponse(w)
+}
+
+// SvcHandlerWebhookDelivery returns the most recent deliveries for Webhook
+// d.ID, newest first.
+// wsdoc {
+//  @Title  Webhook Delivery Log
+//	@URL /v1/webhookdelivery/:BUI/:WHID
+//  @Method  POST
+//	@Synopsis Return the delivery log for a Webhook
+//  @Description  Returns up to Limit of the most recent deliveries to Webhook :WHID.
+//  @Description  Status is 0 = pending, 1 = delivered, 2 = failed.
+//	@Input WebGridSearchRequest
+//  @Response WebhookDeliverySearchResponse
+// wsdoc }
+func SvcHandlerWebhookDelivery(w http.ResponseWriter, r *http.Request, d *ServiceData) {
+	var (
+		funcname = "SvcHandlerWebhookDelivery"
+		g        WebhookDeliverySearchResponse
+		n        = 100
+	)
+	rlib.Console("Entered %s\n", funcname)
+
+	if d.wsSearchReq.Limit > 0 {
+		n = d.wsSearchReq.Limit
+	}
+	m, err := rlib.GetWebhookDeliveries(d.ID, n)
+	if err != nil {
+		SvcGridErrorReturn(w, err, funcname)
+		return
+	}
+	events := map[int64]rlib.OutboxEvent{}
+	for i := 0; i < len(m); i++ {
+		if m[i].BID != d.BID {
+			continue
+		}
+		var q WebhookDeliveryGrid
+		rlib.MigrateStructVals(&m[i], &q)
+		ev, ok := events[m[i].EVID]
+		if !ok {
+			if ev, err = rlib.GetOutboxEvent(m[i].EVID); err != nil {
+				SvcGridErrorReturn(w, err, funcname)
+				return
+			}
+			events[m[i].EVID] = ev
+		}
+		q.EventType = ev.EventType
+		q.ObjID = ev.ObjID
+		q.Recid = int64(i)
+		g.Records = append(g.Records, q)
+	}
+	g.Total = int64(len(g.Records))
+	g.Status = "success"
+	w.Header().Set("Content-Type", "application/json")
+	SvcWriteResponse(&g, w)
+}
@@ -74,6 +74,11 @@ type RPerson struct {
 	LastModBy                 int64
 	CreateTS                  rlib.JSONDateTime
 	CreateBy                  int64
+	ApproverName              string // names of the employees above, from the directory
+	CSAgentName               string
+	AccountRepName            string
+	LastModByName             string
+	CreateByName              string
 }
 
 // RPersonForm is the expected return data format for updating a person.
@@ -336,7 +341,7 @@ func SvcSearchHandlerTransactants(w http.ResponseWriter, r *http.Request, d *Ser
 	transactantsQueryWithLimit := transactantsQuery + limitAndOffsetClause
 
 	// Add limit and offset value
-	qc["LimitClause"] = strconv.Itoa(limitClause)
+	qc["LimitClause"] = strconv.Itoa(gridQueryLimit(d, limitClause))
 	qc["OffsetClause"] = strconv.Itoa(d.wsSearchReq.Offset)
 
 	// get formatted query with substitution of select, where, order clause
@@ -351,6 +356,7 @@ func SvcSearchHandlerTransactants(w http.ResponseWriter, r *http.Request, d *Ser
 	}
 	defer rows.Close()
 
+	x := newGridExporter(w, d, "Transactants", rlib.Transactant{}) // nil unless the search is being exported
 	i := int64(d.wsSearchReq.Offset)
 	count := 0
 	for rows.Next() {
@@ -360,11 +366,15 @@ func SvcSearchHandlerTransactants(w http.ResponseWriter, r *http.Request, d *Ser
 		// get record of transactant
 		t, err = transactantRowScan(rows, t)
 		if err != nil {
-			SvcGridErrorReturn(w, err, funcname)
+			x.ErrorReturn(w, err, funcname)
 			return
 		}
 
-		g.Records = append(g.Records, t)
+		if x != nil {
+			x.Write(&t)
+		} else {
+			g.Records = append(g.Records, t)
+		}
 		count++ // update the count only after adding the record
 		if count >= d.wsSearchReq.Limit {
 			break // if we've added the max number requested, then exit
@@ -374,7 +384,12 @@ func SvcSearchHandlerTransactants(w http.ResponseWriter, r *http.Request, d *Ser
 	// error check
 	err = rows.Err()
 	if err != nil {
-		SvcGridErrorReturn(w, err, funcname)
+		x.ErrorReturn(w, err, funcname)
+		return
+	}
+
+	if x != nil {
+		x.Close(funcname)
 		return
 	}
 
@@ -610,6 +625,11 @@ func getXPerson(w http.ResponseWriter, r *http.Request, d *ServiceData) {
 	}
 	g.Record.BID = d.BID
 	g.Record.BUD = getBUDFromBIDList(d.BID)
+	g.Record.ApproverName = rlib.GetDirectoryName(g.Record.Approver)
+	g.Record.CSAgentName = rlib.GetDirectoryName(g.Record.CSAgent)
+	g.Record.AccountRepName = rlib.GetDirectoryName(g.Record.AccountRep)
+	g.Record.LastModByName = rlib.GetDirectoryName(g.Record.LastModBy)
+	g.Record.CreateByName = rlib.GetDirectoryName(g.Record.CreateBy)
 	g.Status = "success"
 	SvcWriteResponse(&g, w)
 }
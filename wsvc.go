@@ -49,59 +49,19 @@ func v1ReportHandler(reportname string, xbiz *rlib.XBusiness, ui *RRuiSupport, w
 	// init business internals first
 	rlib.InitBizInternals(ri.Bid, xbiz)
 
-	// handler for reports which has single table
-	var wsr = []rrpt.SingleTableReportHandler{
-		{ReportNames: []string{"RPTasmrpt", "assessments"}, TableHandler: rrpt.RRAssessmentsTable},
-		{ReportNames: []string{"RPTb", "business"}, TableHandler: rrpt.RRreportBusinessTable},
-		{ReportNames: []string{"RPTcoa", "chart of accounts"}, TableHandler: rrpt.RRreportChartOfAccountsTable},
-		{ReportNames: []string{"RPTc", "custom attributes"}, TableHandler: rrpt.RRreportCustomAttributesTable},
-		{ReportNames: []string{"RPTcr", "custom attribute refs"}, TableHandler: rrpt.RRreportCustomAttributeRefsTable},
-		{ReportNames: []string{"RPTdelinq", "delinquency"}, TableHandler: rrpt.DelinquencyReportTable},
-		{ReportNames: []string{"RPTdpm", "deposit methods"}, TableHandler: rrpt.RRreportDepositMethodsTable},
-		{ReportNames: []string{"RPTdep", "depositories"}, TableHandler: rrpt.RRreportDepositoryTable},
-		{ReportNames: []string{"RPTgsr", "gsr"}, TableHandler: rrpt.GSRReportTable},
-		{ReportNames: []string{"RPTj", "journals"}, TableHandler: rrpt.JournalReportTable},
-		{ReportNames: []string{"RPTpeople", "people"}, TableHandler: rrpt.RRreportPeopleTable},
-		{ReportNames: []string{"RPTpmt", "payment types"}, TableHandler: rrpt.RRreportPaymentTypesTable},
-		{ReportNames: []string{"RPTr", "rentables"}, TableHandler: rrpt.RRreportRentablesTable},
-		{ReportNames: []string{"RPTra", "rental agreements"}, TableHandler: rrpt.RRreportRentalAgreementsTable},
-		{ReportNames: []string{"RPTrat", "rental agreement templates"}, TableHandler: rrpt.RRreportRentalAgreementTemplatesTable},
-		{ReportNames: []string{"RPTrcpt", "receipts"}, TableHandler: rrpt.RRReceiptsTable},
-		{ReportNames: []string{"RPTrr", "rentroll"}, TableHandler: rrpt.RRReportTable},
-		{ReportNames: []string{"RPTrt", "rentable types"}, TableHandler: rrpt.RRreportRentableTypesTable},
-		{ReportNames: []string{"RPTrcbt", "rentable type counts"}, TableHandler: rrpt.RentableCountByRentableTypeReportTable},
-		{ReportNames: []string{"RPTsl", "string lists"}, TableHandler: rrpt.RRreportStringListsTable},
-		{ReportNames: []string{"RPTt", "people"}, TableHandler: rrpt.RRreportPeopleTable},
-		{ReportNames: []string{"RPTtb", "trial balance"}, TableHandler: rrpt.LedgerBalanceReportTable},
-		{ReportNames: []string{"RPTpayorstmt", "payor statements"}, TableHandler: rrpt.RRPayorStatement},
-		{ReportNames: []string{"RPTrastmt", "rental agreement statements"}, TableHandler: rrpt.RRRentalAgreementStatements},
-	}
-
-	// handler for reports which has more than one table
-	var wmr = []rrpt.MultiTableReportHandler{
-		{ReportTitle: "Ledger", ReportNames: []string{"RPTl", "ledger"}, TableHandler: rrpt.LedgerReportTable},
-		{ReportTitle: "Ledger Activity", ReportNames: []string{"RPTla", "ledger activity"}, TableHandler: rrpt.LedgerActivityReportTable},
-		{ReportTitle: "Report Statements", ReportNames: []string{"RPTstatements", "report statements"}, TableHandler: rrpt.RptStatementReportTable},
-	}
-
-	// find reportname from list of report handler
-	// first find it from single table handler
-	var tsh rrpt.SingleTableReportHandler
-	for j := 0; j < len(wsr); j++ {
-		for _, rn := range wsr[j].ReportNames {
-			if strings.ToLower(rn) == strings.ToLower(reportname) {
-				tsh = wsr[j]
-				tsh.Found = true
-				// if found then stop looking over report names list
-				break
-			}
-		}
-		// if found then stop looping over handlers
-		if tsh.Found {
-			break
+	// consolidated reports work on a list of businesses and/or business groups
+	if qp != nil && len(qp.Get("bizlist")) > 0 {
+		var err error
+		ri.BIDList, err = rlib.GetBusinessListFromSpec(qp.Get("bizlist"))
+		if err != nil {
+			fmt.Fprintf(w, "Error in bizlist: %s", err.Error())
+			return
 		}
 	}
 
+	// find reportname from list of report handlers
+	tsh, tmh := rrpt.FindReportHandler(reportname)
+
 	// if found then handle service for request
 	if tsh.Found {
 		tbl := tsh.TableHandler(&ri)
@@ -146,6 +106,16 @@ func v1ReportHandler(reportname string, xbiz *rlib.XBusiness, ui *RRuiSupport, w
 				fmt.Fprintf(w, "%s\n", s)
 			}
 			return
+		case rrpt.TABLEOUTXLSX:
+			w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			w.Header().Set("Content-Disposition", "attachment; filename="+attachmentName+".xlsx")
+			err := rrpt.XLSXprintTable(&tbl, w)
+			if err != nil {
+				s := fmt.Sprintf("Error in XLSXprintTable: %s\n", err.Error())
+				fmt.Print(s)
+				fmt.Fprintf(w, "%s\n", s)
+			}
+			return
 		case gotable.TABLEOUTPDF:
 			w.Header().Set("Content-Type", "application/pdf")
 			w.Header().Set("Content-Disposition", "attachment; filename="+attachmentName+".pdf")
@@ -173,23 +143,6 @@ func v1ReportHandler(reportname string, xbiz *rlib.XBusiness, ui *RRuiSupport, w
 		}
 	}
 
-	// if not found from single, then find it from multi table handler
-	var tmh rrpt.MultiTableReportHandler
-	for j := 0; j < len(wmr); j++ {
-		for _, rn := range wmr[j].ReportNames {
-			if strings.ToLower(rn) == strings.ToLower(reportname) {
-				tmh = wmr[j]
-				tmh.Found = true
-				// if found then stop looking over report name list
-				break
-			}
-		}
-		// if found then stop looking over other handler
-		if tmh.Found {
-			break
-		}
-	}
-
 	// if found then handle service for request
 	if tmh.Found {
 		m := tmh.TableHandler(&ri)
@@ -219,6 +172,16 @@ func v1ReportHandler(reportname string, xbiz *rlib.XBusiness, ui *RRuiSupport, w
 			w.Header().Set("Content-Disposition", "attachment; filename="+attachmentName+".csv")
 			gotable.MultiTableCSVPrint(m, w)
 			return
+		case rrpt.TABLEOUTXLSX:
+			w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			w.Header().Set("Content-Disposition", "attachment; filename="+attachmentName+".xlsx")
+			err := rrpt.MultiTableXLSXPrint(m, w)
+			if err != nil {
+				s := fmt.Sprintf("Error in MultiTableXLSXPrint: %s\n", err.Error())
+				fmt.Print(s)
+				fmt.Fprintf(w, "%s\n", s)
+			}
+			return
 		case gotable.TABLEOUTPDF:
 			w.Header().Set("Content-Type", "application/pdf")
 			w.Header().Set("Content-Disposition", "attachment; filename="+attachmentName+".pdf")